@@ -0,0 +1,1628 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/agent"
+	"github.com/markcipolla/lfg/internal/bootstrap"
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/daemon"
+	"github.com/markcipolla/lfg/internal/editor"
+	"github.com/markcipolla/lfg/internal/export"
+	"github.com/markcipolla/lfg/internal/gc"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/history"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/layoutedit"
+	"github.com/markcipolla/lfg/internal/listcache"
+	"github.com/markcipolla/lfg/internal/metrics"
+	"github.com/markcipolla/lfg/internal/notify"
+	"github.com/markcipolla/lfg/internal/snapshot"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// commands maps subcommand names to their handlers. Each handler receives the
+// arguments following the subcommand name.
+var commands = map[string]func(args []string) error{
+	"run":              runCommand,
+	"daemon":           daemonCommand,
+	"exec":             execCommand,
+	"status":           statusCommand,
+	"bootstrap":        bootstrapCommand,
+	"review":           reviewCommand,
+	"url":              urlCommand,
+	"export":           exportCommand,
+	"import":           importCommand,
+	"prune":            pruneCommand,
+	"each":             eachCommand,
+	"pick":             pickCommand,
+	"history":          historyCommand,
+	"metrics":          metricsCommand,
+	"watch":            watchCommand,
+	"gc":               gcCommand,
+	"wip":              wipCommand,
+	"snapshot":         snapshotCommand,
+	"restore-snapshot": restoreSnapshotCommand,
+	"share":            shareCommand,
+	"layout":           layoutCommand,
+	"restart-pane":     restartPaneCommand,
+	"handoff":          handoffCommand,
+	"pr":               prCommand,
+	"summary":          summaryCommand,
+	"commit-msg":       commitMsgCommand,
+	"commit-prefix":    commitPrefixCommand,
+}
+
+// runCommand executes an agent task non-interactively inside a worktree
+// Usage: lfg run <worktree> "<prompt>"
+func runCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: lfg run <worktree> \"<prompt>\"")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return agent.RunHeadless(args[0], strings.Join(args[1:], " "), cfg)
+}
+
+// daemonCommand runs the daemon that keeps worktrees, sessions and backend items warm for
+// the TUI, or controls a running one
+// Usage: lfg daemon [stop|status|refresh]
+func daemonCommand(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	configPath := cfg.GetConfigPath()
+
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Fprintf(os.Stderr, "lfg daemon listening on %s\n", daemon.SocketPath(configPath))
+		ci, due, idle := &notify.CIWatch{}, &notify.DueWatch{}, &notify.IdleWatch{}
+		return daemon.Serve(ctx, configPath, daemon.Sources{
+			Worktrees: git.ListWorktreesContext,
+			Sessions:  tmux.ListSessionInfoContext,
+			Items: func(cfg *config.Config) ([]github.ProjectItem, []string, error) {
+				return core.New(cfg).ListItems()
+			},
+			Watch: func(cfg *config.Config, worktrees []git.Worktree, items []github.ProjectItem) {
+				if err := ci.Check(cfg, worktrees); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to check CI: %v\n", err)
+				}
+				if err := due.Check(cfg, items); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to notify of due dates: %v\n", err)
+				}
+				sessions, err := core.New(cfg).ReapSessions(time.Now())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to reap idle sessions: %v\n", err)
+				}
+				for _, session := range sessions {
+					if session.Killed {
+						fmt.Fprintf(os.Stderr, "Killed the session of %s, unused since %s\n", session.Worktree, session.LastActive.Format(time.DateTime))
+					}
+				}
+				if err := idle.Check(sessions); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to notify of idle sessions: %v\n", err)
+				}
+				prewarmed, err := core.New(cfg).PrewarmSessions(items)
+				for _, name := range prewarmed {
+					fmt.Fprintf(os.Stderr, "Prewarmed the session of %s\n", name)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to prewarm sessions: %v\n", err)
+				}
+				pruned, err := core.New(cfg).PruneReviews()
+				for _, name := range pruned {
+					fmt.Fprintf(os.Stderr, "Removed %s, its pull request is closed\n", name)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to clean up reviews: %v\n", err)
+				}
+				summarized, err := agent.PostDailySummaries(cfg, time.Now())
+				for _, name := range summarized {
+					fmt.Fprintf(os.Stderr, "Posted the daily summary of %s\n", name)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to post daily summaries: %v\n", err)
+				}
+			},
+		})
+	case "stop":
+		return daemon.Stop(configPath)
+	case "refresh":
+		return daemon.Refresh(configPath)
+	case "status":
+		st, err := daemon.Query(configPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Running as pid %d since %s\n", st.PID, st.StartedAt.Format(time.Kitchen))
+		fmt.Printf("%s, %s\n", plural(len(st.Worktrees), "worktree"), plural(len(st.Sessions), "tmux session"))
+		switch {
+		case st.ItemsError != "":
+			fmt.Printf("Failed to fetch items: %s\n", st.ItemsError)
+		case st.RefreshedAt.IsZero():
+			fmt.Println("Fetching items...")
+		case st.Backend.Remote():
+			fmt.Printf("%s, fetched %s\n", plural(len(st.Items), "item"), humanize.Ago(st.RefreshedAt))
+		}
+		return nil
+	}
+	return fmt.Errorf("usage: lfg daemon [stop|status|refresh]")
+}
+
+// execCommand runs a command given after -- in a worktree, or otherwise a layout pane's
+// command in the user's shell, notifying when it exits with an error. Panes' commands are
+// run through it when crash notifications are on.
+// Usage: lfg exec --config <path> <worktree> <pane> <command>
+func execCommand(args []string) error {
+	if slices.Contains(args, "--") {
+		return execInWorktree(args)
+	}
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path to config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 3 {
+		return fmt.Errorf("usage: lfg exec --config <path> <worktree> <pane> <command>")
+	}
+	worktree, pane, command := flags.Arg(0), flags.Arg(1), strings.Join(flags.Args()[2:], " ")
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// Ctrl-C and the like are for the command; lfg waits to hear how it exited
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return err
+	}
+	if !stopped(exit) {
+		var cfg *config.Config
+		if *configPath != "" {
+			cfg, err = config.LoadFromPath(*configPath)
+		} else {
+			cfg, err = config.Load()
+		}
+		if err == nil {
+			core.New(cfg).Record(core.Event{Kind: core.PaneCrashed, Worktree: worktree, Detail: fmt.Sprintf("%s (%s) %v", pane, command, exit)})
+			err = notify.Send(cfg, config.NotifyCrash, pane+" crashed",
+				fmt.Sprintf("%s in %s: %v", command, worktree, exit))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	// Pass the command's status on, as the shell would have
+	os.Exit(exitCode(exit))
+	return nil
+}
+
+// execInWorktree runs a command in a worktree's directory with its ports in the environment,
+// passing its output and exit status on, or with --pane types it into one of the panes of
+// the worktree's session
+// Usage: lfg exec [--pane <name>] <worktree> -- <command...>
+func execInWorktree(args []string) error {
+	usage := fmt.Errorf("usage: lfg exec [--pane <name>] <worktree> -- <command...>")
+	split := slices.Index(args, "--")
+	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	pane := flags.String("pane", "", "Type the command into this pane of the worktree's session, agent for the agent's pane")
+	if err := flags.Parse(args[:split]); err != nil {
+		return err
+	}
+	command := args[split+1:]
+	if flags.NArg() != 1 || len(command) == 0 {
+		return usage
+	}
+	worktree := flags.Arg(0)
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *pane != "" {
+		return tmux.SendToPane(cfg, worktree, *pane, command)
+	}
+	if err := cfg.RequireLocal("lfg exec"); err != nil {
+		return err
+	}
+
+	// The command's words are run as they were given, so pipes and the like need sh -c
+	cmd, err := core.New(cfg).WorktreeCommand(worktree, command)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	err = cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		os.Exit(exitCode(exit))
+	}
+	return err
+}
+
+// exitCode is the status a shell would give for how a command exited
+func exitCode(exit *exec.ExitError) int {
+	if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exit.ExitCode()
+}
+
+// stopped reports whether a command exited because it was interrupted or told to stop,
+// rather than crashing
+func stopped(exit *exec.ExitError) bool {
+	if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		switch status.Signal() {
+		case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP:
+			return true
+		}
+		return false
+	}
+	// Shells exit with 128 plus the signal's number when their command is signalled
+	switch exit.ExitCode() {
+	case 128 + int(syscall.SIGINT), 128 + int(syscall.SIGTERM), 128 + int(syscall.SIGHUP):
+		return true
+	}
+	return false
+}
+
+// bootstrapCommand installs a worktree's dependencies. New worktrees are bootstrapped with
+// it in the background when bootstrap is configured.
+// Usage: lfg bootstrap [--config <path>] <worktree>
+func bootstrapCommand(args []string) error {
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	configPath := flags.String("config", "", "Path to config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lfg bootstrap [--config <path>] <worktree>")
+	}
+
+	var cfg *config.Config
+	var err error
+	if *configPath != "" {
+		cfg, err = config.LoadFromPath(*configPath)
+	} else {
+		cfg, err = config.Load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Bootstrap == "" {
+		cfg.Bootstrap = "auto"
+	}
+	return bootstrap.Run(cfg, flags.Arg(0))
+}
+
+// reviewCommand checks a pull request out in a worktree to review it in and opens its
+// session, or removes the review worktrees of closed pull requests. The daemon removes
+// those itself while it runs.
+// Usage: lfg review <pr-number>|prune
+func reviewCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: lfg review <pr-number>|prune")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	service := core.New(cfg)
+
+	if args[0] == "prune" {
+		pruned, err := service.PruneReviews()
+		for _, name := range pruned {
+			fmt.Printf("Removed %s\n", name)
+		}
+		return err
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("usage: lfg review <pr-number>|prune")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	name, warnings, err := service.Review(ctx, number)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	path, err := git.GetWorktreePath(name)
+	if err != nil {
+		return err
+	}
+	return tmux.CreateOrAttachSession(name, path, cfg)
+}
+
+// urlCommand jumps to the worktree for a GitHub issue or pull request, creating it first
+// when there isn't one
+// Usage: lfg url <github-issue-or-pr-url>
+func urlCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: lfg url <github-issue-or-pr-url>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	service := core.New(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	name, warnings, err := service.OpenLink(ctx, args[0])
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	recordWorktreeUse(service.Config(), name)
+	return git.JumpToWorktree(name, service.Config())
+}
+
+// exportCommand writes the repository's todos, the branches of their worktrees and the
+// TUI's settings, for lfg import to read back in this clone or another
+// Usage: lfg export [--format json|yaml] [--output <file>]
+func exportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "yaml", "Format to write: json or yaml")
+	output := flags.String("output", "", "File to write to instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	branches := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		branches[git.GetWorktreeName(wt.Path)] = strings.TrimPrefix(wt.Branch, "refs/heads/")
+	}
+
+	data, err := export.Marshal(export.Build(cfg, st, branches), *format)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// importCommand merges todos written by lfg export into the config, optionally creating the
+// worktrees they're linked to that this clone doesn't have
+// Usage: lfg import [--worktrees] <file>|-
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	createWorktrees := flags.Bool("worktrees", false, "Create the worktrees of imported todos that don't exist here")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lfg import [--worktrees] <file>|-")
+	}
+	var data []byte
+	var err error
+	if file := flags.Arg(0); file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	doc, err := export.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	var result export.Result
+	cfg, err = config.UpdateFile(cfg.GetConfigPath(), func(cfg *config.Config) error {
+		result = export.Merge(cfg, doc)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		export.MergeState(st, doc)
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %s, %d new\n", plural(result.Added+result.Updated, "todo"), result.Added)
+
+	var missing []export.Todo
+	for _, todo := range doc.Todos {
+		if _, err := git.GetWorktreePath(todo.Worktree); todo.Worktree != "" && err != nil {
+			missing = append(missing, todo)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !*createWorktrees {
+		fmt.Printf("Not checked out here: %s, run with --worktrees to create them\n", plural(len(missing), "worktree"))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	service := core.New(cfg)
+	var errs []error
+	for _, todo := range missing {
+		warnings, err := importWorktree(ctx, service, todo)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", todo.Worktree, warning)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", todo.Worktree, err))
+			continue
+		}
+		fmt.Printf("Created %s\n", todo.Worktree)
+	}
+	return errors.Join(errs...)
+}
+
+// importWorktree creates an imported todo's worktree on its branch, from the local branch
+// or origin's, fetching a pull request being reviewed from its head
+func importWorktree(ctx context.Context, service *core.Service, todo export.Todo) ([]string, error) {
+	if todo.Branch == "" {
+		return nil, fmt.Errorf("the export doesn't say which branch it was on")
+	}
+	if todo.Review != nil {
+		if err := git.FetchPullRequest(ctx, todo.Review.Number, todo.Branch); err != nil {
+			return nil, err
+		}
+	}
+	branches, err := git.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+	for _, branch := range branches {
+		if branch.LocalName() != todo.Branch {
+			continue
+		}
+		request := core.CreateRequest{Name: todo.Worktree, Branch: todo.Branch, Checkout: true}
+		if branch.Remote != "" {
+			request = core.CreateRequest{Name: todo.Worktree, Branch: todo.Branch, Base: branch.Name}
+		}
+		return service.CreateWorktree(ctx, request)
+	}
+	return nil, fmt.Errorf("branch %s isn't in this clone", todo.Branch)
+}
+
+// pruneCommand offers each merged, Done or idle worktree, and each session and todo left
+// behind by a deleted one, for removal in turn, then sums up what it did
+// Usage: lfg prune [--idle <days>] [--yes]
+func pruneCommand(args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	idleDays := flags.Int("idle", 30, "Offer worktrees not worked on for this many days, 0 for none")
+	yes := flags.Bool("yes", false, "Remove every candidate without asking")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	service := core.New(cfg)
+
+	// Without the backend, merges and idle worktrees are still worth offering
+	items, _, err := service.ListItems()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load items: %v\n", err)
+	}
+	candidates, err := service.PruneCandidates(items, time.Duration(*idleDays)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Println("Nothing to prune")
+		return nil
+	}
+
+	removed := make(map[core.PruneKind]int)
+	skipped := 0
+	answers := bufio.NewScanner(os.Stdin)
+	for i, candidate := range candidates {
+		fmt.Printf("%s %s: %s\n", candidate.Kind, candidate.Name, strings.Join(candidate.Reasons, ", "))
+		if candidate.Dirty > 0 {
+			fmt.Printf("  %s will be lost\n", plural(candidate.Dirty, "uncommitted file"))
+		}
+		if !*yes {
+			fmt.Print("Remove? [y/N/q] ")
+			answer := ""
+			if answers.Scan() {
+				answer = strings.ToLower(strings.TrimSpace(answers.Text()))
+			} else {
+				answer = "q"
+				fmt.Println()
+			}
+			if answer == "q" {
+				skipped += len(candidates) - i
+				break
+			}
+			if answer != "y" && answer != "yes" {
+				skipped++
+				continue
+			}
+		}
+
+		warnings, err := service.Prune(candidate)
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to remove %s: %v\n", candidate.Name, err)
+			skipped++
+			continue
+		}
+		removed[candidate.Kind]++
+	}
+
+	var done []string
+	for _, kind := range []core.PruneKind{core.PruneWorktree, core.PruneSession, core.PruneTodo} {
+		if removed[kind] > 0 {
+			done = append(done, plural(removed[kind], string(kind)))
+		}
+	}
+	if len(done) == 0 {
+		done = []string{"nothing"}
+	}
+	fmt.Printf("\nRemoved %s, kept %d\n", strings.Join(done, ", "), skipped)
+	return nil
+}
+
+// gcCommand removes build artifacts and installed dependencies from a worktree, or with
+// --all from every worktree without a tmux session, and says how much space it freed
+// Usage: lfg gc [--force] <worktree>|--all
+func gcCommand(args []string) error {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	all := flags.Bool("all", false, "Clean every worktree without a tmux session running")
+	force := flags.Bool("force", false, "Clean the worktree even though its session is running")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *all == (flags.NArg() == 1) || flags.NArg() > 1 {
+		return fmt.Errorf("usage: lfg gc [--force] <worktree>|--all")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.RequireLocal("lfg gc"); err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+
+	var targets []git.Worktree
+	for i, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		running := tmux.SessionExists(tmux.SanitizeSessionName(name))
+		switch {
+		case !*all && name != flags.Arg(0):
+			continue
+		case !*all && running && !*force:
+			return fmt.Errorf("%s has a tmux session running, which may be using what gc would remove; kill it or pass --force", name)
+		case *all && (i == 0 || running):
+			// The main worktree and ones being worked in are left alone
+			continue
+		}
+		targets = append(targets, wt)
+	}
+	if !*all && len(targets) == 0 {
+		return fmt.Errorf("%w: %s", git.ErrWorktreeNotFound, flags.Arg(0))
+	}
+	if len(targets) == 0 {
+		fmt.Println("No idle worktrees to clean")
+		return nil
+	}
+
+	var total int64
+	var failed []string
+	for _, wt := range targets {
+		name := git.GetWorktreeName(wt.Path)
+		commands := gc.Commands(cfg, wt.Path)
+		if len(commands) == 0 {
+			fmt.Printf("=== %s: nothing to clean\n", name)
+			continue
+		}
+		fmt.Printf("=== %s\n", name)
+		reclaimed, err := gc.Run(commands, wt.Path, os.Stdout)
+		total += reclaimed
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = append(failed, name)
+		}
+		fmt.Printf("Freed %s\n", humanize.Bytes(reclaimed))
+	}
+
+	fmt.Printf("\nFreed %s in total across %s\n", humanize.Bytes(total), plural(len(targets), "worktree"))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed in %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// wipCommand saves the uncommitted work of a worktree, or with --all of every worktree but
+// the main one, as a wip: commit or the stash the config's wip asks for, e.g. from a hook
+// run before the machine sleeps
+// Usage: lfg wip <worktree>|--all
+func wipCommand(args []string) error {
+	flags := flag.NewFlagSet("wip", flag.ContinueOnError)
+	all := flags.Bool("all", false, "Save the work of every worktree but the main one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *all == (flags.NArg() == 1) || flags.NArg() > 1 {
+		return fmt.Errorf("usage: lfg wip <worktree>|--all")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	service := core.New(cfg)
+
+	names := flags.Args()
+	if *all {
+		worktrees, err := git.ListWorktrees()
+		if err != nil {
+			return err
+		}
+		names = nil
+		for _, wt := range worktrees[min(1, len(worktrees)):] {
+			names = append(names, git.GetWorktreeName(wt.Path))
+		}
+	}
+	var failed []string
+	saved := 0
+	for _, name := range names {
+		ok, err := service.SaveWIP(name)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+			failed = append(failed, name)
+		case ok:
+			fmt.Printf("Saved the uncommitted work of %s\n", name)
+			saved++
+		}
+	}
+	if saved == 0 && len(failed) == 0 {
+		fmt.Println("Nothing uncommitted to save")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to save %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// snapshotCommand records a worktree's commit, uncommitted changes and tmux session for
+// lfg restore-snapshot to put back, or lists the snapshots taken of it
+// Usage: lfg snapshot [-m <message>] [--list] <worktree>
+func snapshotCommand(args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	message := flags.String("m", "", "What the snapshot is for, e.g. before rebasing")
+	list := flags.Bool("list", false, "List the worktree's snapshots instead")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lfg snapshot [-m <message>] [--list] <worktree>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	name := flags.Arg(0)
+
+	if *list {
+		snaps, err := snapshot.List(cfg, name)
+		if err != nil {
+			return err
+		}
+		if len(snaps) == 0 {
+			fmt.Printf("%s has no snapshots\n", name)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, snap := range snaps {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", snap.ID, humanize.Ago(snap.CreatedAt), snap.Head[:min(7, len(snap.Head))], snap.Message)
+		}
+		return w.Flush()
+	}
+
+	snap, err := snapshot.Take(cfg, name, *message)
+	if err != nil {
+		return err
+	}
+	session := "no tmux session"
+	if snap.Window != nil {
+		session = plural(len(snap.Window.Panes), "pane")
+	}
+	fmt.Printf("Took snapshot %s of %s at %s: %s, %s\n", snap.ID, name, snap.Head[:min(7, len(snap.Head))], plural(len(snap.Untracked), "untracked file"), session)
+	return nil
+}
+
+// restoreSnapshotCommand puts a worktree back as a snapshot has it, the latest unless one
+// is named
+// Usage: lfg restore-snapshot [--force] <worktree> [<id>]
+func restoreSnapshotCommand(args []string) error {
+	flags := flag.NewFlagSet("restore-snapshot", flag.ContinueOnError)
+	force := flags.Bool("force", false, "Throw away the worktree's own uncommitted changes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return fmt.Errorf("usage: lfg restore-snapshot [--force] <worktree> [<id>]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	restored, err := snapshot.Restore(cfg, flags.Arg(0), flags.Arg(1), *force)
+	if restored != nil {
+		fmt.Printf("Restored %s to snapshot %s\n", flags.Arg(0), restored.Snapshot.ID)
+		if restored.MovedFrom != "" {
+			fmt.Printf("Moved %s back from %s, which git reflog still has\n", restored.Snapshot.Branch, restored.MovedFrom[:min(7, len(restored.MovedFrom))])
+		}
+		if restored.Session {
+			fmt.Println("Recreated its tmux session; open it with lfg " + flags.Arg(0))
+		}
+	}
+	return err
+}
+
+// shareCommand prints the command a teammate on the same machine runs to watch a worktree's
+// session read-only, or with --attach attaches this terminal to it read-only
+// Usage: lfg share [--user <login>] [--attach] <worktree>
+func shareCommand(args []string) error {
+	flags := flag.NewFlagSet("share", flag.ContinueOnError)
+	user := flags.String("user", "", "Let this user into the tmux server read-only, for a teammate logged in as someone else; tmux must be run with -S on a socket they can open")
+	attach := flags.Bool("attach", false, "Attach this terminal to the session read-only too")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lfg share [--user <login>] [--attach] <worktree>")
+	}
+	name := flags.Arg(0)
+
+	command, err := tmux.Share(name, *user)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("To watch %s read-only, run: %s\n", name, command)
+	if *attach {
+		return tmux.AttachReadOnly(name)
+	}
+	return nil
+}
+
+// layoutCommand opens the layout editor on the config's layout, or one of its named
+// layouts, or checks one and prints how it would be built
+// Usage: lfg layout edit [<name>] | lfg layout check [--size <width>x<height>] [<name>]
+func layoutCommand(args []string) error {
+	usage := fmt.Errorf("usage: lfg layout edit [<name>] | lfg layout check [--size <width>x<height>] [<name>]")
+	if len(args) < 1 {
+		return usage
+	}
+	switch args[0] {
+	case "edit":
+		if len(args) > 2 {
+			return usage
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return layoutedit.Run(cfg, name)
+	case "check":
+		return layoutCheckCommand(args[1:])
+	}
+	return usage
+}
+
+// layoutCheckCommand validates a layout and prints the splits it's built with and the panes
+// they leave, in a window the size of the terminal unless one is given
+func layoutCheckCommand(args []string) error {
+	flags := flag.NewFlagSet("layout check", flag.ContinueOnError)
+	sizeFlag := flags.String("size", "", "Window size to plan for, e.g. 200x50 (default the terminal's)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: lfg layout check [--size <width>x<height>] [<name>]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	width, height := tmux.LayoutSize()
+	if *sizeFlag != "" {
+		w, h, ok := strings.Cut(*sizeFlag, "x")
+		width, err = strconv.Atoi(w)
+		if err == nil {
+			height, err = strconv.Atoi(h)
+		}
+		if !ok || err != nil || width < 1 || height < 1 {
+			return fmt.Errorf("invalid --size %q, want e.g. 200x50", *sizeFlag)
+		}
+	}
+
+	layout, title := cfg.GetLayout(), "layout"
+	if name := flags.Arg(0); name != "" {
+		var found bool
+		if layout, found = cfg.Layouts[name]; !found {
+			return fmt.Errorf("no layout named %q in layouts", name)
+		}
+		title = "layouts." + name
+	}
+
+	plan := tmux.PlanLayout(layout, width, height)
+	fmt.Printf("%s in a %dx%d window\n\nSplits, in order:\n", title, width, height)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, split := range plan.Splits {
+		fmt.Fprintf(w, "  %d.\ttmux %s\t%s\n", i+1, strings.Join(split.Args("<session>:0"), " "), split.For)
+	}
+	w.Flush()
+
+	fmt.Println("\nPanes:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PANE\tNAME\tSIZE\tAT\tCOMMAND")
+	for _, pane := range plan.Panes {
+		command := pane.Command
+		if command == "" && pane.Index > 0 {
+			command = "(shell)"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%dx%d\t%d,%d\t%s\n", pane.Index, pane.Name, pane.Width, pane.Height, pane.Left, pane.Top, command)
+	}
+	w.Flush()
+
+	// Commands can only be looked for when they're run here
+	problems := tmux.CheckLayout(layout, width, height, cfg.Container == nil && cfg.RemoteHost() == nil)
+	if len(problems) == 0 {
+		fmt.Println("\nNo problems found")
+		return nil
+	}
+	fmt.Println("\nProblems:")
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	return fmt.Errorf("%s has %s", title, plural(len(problems), "problem"))
+}
+
+// restartPaneCommand kills what's running in one of the panes of a worktree's session and
+// runs its command from the layout again. --target takes the pane as tmux names it, as the
+// key lfg binds in its sessions does.
+// Usage: lfg restart-pane <worktree> <pane> | lfg restart-pane --target <tmux pane>
+func restartPaneCommand(args []string) error {
+	flags := flag.NewFlagSet("restart-pane", flag.ContinueOnError)
+	target := flags.String("target", "", "The pane as tmux names it, e.g. %3")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *target != "" && flags.NArg() == 0:
+		return tmux.RestartPane(*target)
+	case *target == "" && flags.NArg() == 2:
+		return tmux.RestartWorktreePane(flags.Arg(0), flags.Arg(1))
+	}
+	return fmt.Errorf("usage: lfg restart-pane <worktree> <pane> | lfg restart-pane --target <tmux pane>")
+}
+
+// handoffCommand moves a worktree's todo, with its transcripts, scratchpad and item link, to
+// another worktree, creating it from the first's branch if it doesn't exist
+// Usage: lfg handoff <from> <to>
+func handoffCommand(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: lfg handoff <from> <to>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	from, to := args[0], args[1]
+
+	handed, err := core.New(cfg).HandOff(context.Background(), from, to)
+	if handed.Session != "" {
+		fmt.Printf("Closed the session of %s\n", from)
+	}
+	if handed.Created {
+		fmt.Printf("Created %s from %s's branch\n", to, from)
+	}
+	for _, warning := range handed.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Handed %s's todo to %s; open it with lfg %s\n", from, to, to)
+	return nil
+}
+
+// prCommand opens a pull request for a worktree's branch, the current worktree's unless one
+// is named, described from the pull_request template and edited in $EDITOR first
+// Usage: lfg pr [--base <branch>] [--draft] [--no-edit] [--print] [<worktree>]
+func prCommand(args []string) error {
+	flags := flag.NewFlagSet("pr", flag.ContinueOnError)
+	base := flags.String("base", "", "The branch to merge into, the main worktree's unless set")
+	draft := flags.Bool("draft", false, "Open it as a draft")
+	noEdit := flags.Bool("no-edit", false, "Open it with the description as the template fills it in, without editing it")
+	printOnly := flags.Bool("print", false, "Print the title and description instead of opening it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: lfg pr [--base <branch>] [--draft] [--no-edit] [--print] [<worktree>]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	name := flags.Arg(0)
+	if name == "" {
+		if name, err = git.GetCurrentWorktree(); err != nil {
+			return err
+		}
+		if name == "" {
+			return fmt.Errorf("usage: lfg pr [--base <branch>] [--draft] [--no-edit] [--print] [<worktree>]")
+		}
+	}
+
+	summary, err := agent.Summary(cfg, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read the agent's summary: %v\n", err)
+	}
+	service := core.New(cfg)
+	pr, err := service.DraftPullRequest(name, *base, summary)
+	if err != nil {
+		return err
+	}
+	pr.Draft = pr.Draft || *draft
+	if *printOnly {
+		fmt.Printf("%s\n\n%s", pr.Title, pr.Body)
+		return nil
+	}
+	if !*noEdit {
+		if pr.Title, pr.Body, err = editPullRequest(pr.Title, pr.Body); err != nil {
+			return err
+		}
+		if pr.Title == "" {
+			fmt.Println("The title is empty, so no pull request was opened")
+			return nil
+		}
+	}
+
+	url, err := service.OpenPullRequest(*pr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Opened %s\n", url)
+	return nil
+}
+
+// summaryCommand prints the summary of a day of agent work in a worktree, the current
+// worktree's unless one is named, as mirror daily posts it on the worktree's issue
+// Usage: lfg summary [--date <yyyy-mm-dd>] [<worktree>]
+func summaryCommand(args []string) error {
+	flags := flag.NewFlagSet("summary", flag.ContinueOnError)
+	date := flags.String("date", "", "The day to sum up, today unless set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("usage: lfg summary [--date <yyyy-mm-dd>] [<worktree>]")
+	}
+	day := time.Now()
+	if *date != "" {
+		var err error
+		if day, err = time.ParseInLocation(time.DateOnly, *date, time.Local); err != nil {
+			return fmt.Errorf("invalid date %q, expected yyyy-mm-dd", *date)
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	name := flags.Arg(0)
+	if name == "" {
+		if name, err = git.GetCurrentWorktree(); err != nil {
+			return err
+		}
+		if name == "" {
+			return fmt.Errorf("usage: lfg summary [--date <yyyy-mm-dd>] [<worktree>]")
+		}
+	}
+
+	summary, err := agent.SummarizeDay(cfg, name, day)
+	if err != nil {
+		return err
+	}
+	if summary.Empty() {
+		fmt.Printf("The agent didn't work in %s on %s\n", name, day.Format(time.DateOnly))
+		return nil
+	}
+	fmt.Println(summary.Markdown())
+	return nil
+}
+
+// commitMsgCommand is run by the commit-msg hook commit_prefix installs, starting the
+// message of a commit in a worktree with its todo's issue. It never fails the commit.
+// Usage: lfg commit-msg <message-file>
+func commitMsgCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: lfg commit-msg <message-file>")
+	}
+	if err := prefixCommitMessage(args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "lfg: commit left unprefixed: %v\n", err)
+	}
+	return nil
+}
+
+// commitPrefixCommand turns commit_prefix on or off, installing or removing the commit-msg
+// hook that prefixes commits. Given neither, it brings the hook in step with the config, as
+// in a new clone of a repository whose committed config has it on.
+// Usage: lfg commit-prefix [on|off]
+func commitPrefixCommand(args []string) error {
+	if len(args) > 1 || len(args) == 1 && args[0] != "on" && args[0] != "off" {
+		return fmt.Errorf("usage: lfg commit-prefix [on|off]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(args) == 1 {
+		on := args[0] == "on"
+		if err := cfg.Update(func(c *config.Config) error {
+			c.CommitPrefix = on
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if err := core.New(cfg).SyncCommitHook(); err != nil {
+		return err
+	}
+	if !cfg.CommitPrefix {
+		fmt.Println("Commits are left unprefixed")
+		return nil
+	}
+	fmt.Println("Commits in worktrees are prefixed with their todo's issue")
+	return nil
+}
+
+// prefixCommitMessage prefixes the commit message in path for the worktree it's committed in
+func prefixCommitMessage(path string) error {
+	cfg, err := config.LoadExisting()
+	if err != nil {
+		return err
+	}
+	if !cfg.CommitPrefix {
+		return nil
+	}
+	worktree, err := git.GetCurrentWorktree()
+	if err != nil || worktree == "" {
+		return err
+	}
+	warning, err := core.New(cfg).PrefixCommitFile(worktree, path)
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "lfg: %s\n", warning)
+	}
+	return err
+}
+
+// editPullRequest opens a pull request's title and description in the user's editor, the
+// title on the first line as in a commit message, and returns them as they were saved
+func editPullRequest(title, body string) (string, string, error) {
+	file, err := os.CreateTemp("", "lfg-pr-*.md")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(title + "\n\n" + body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write the description to edit: %w", err)
+	}
+
+	cmd := editor.Command(file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("failed to edit the description: %w", err)
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the edited description: %w", err)
+	}
+	title, body, _ = strings.Cut(strings.TrimSpace(string(edited)), "\n")
+	return strings.TrimSpace(title), strings.TrimSpace(body) + "\n", nil
+}
+
+// eachCommand runs a command in every worktree at once, printing each one's output as a
+// section of its own once it finishes
+// Usage: lfg each [--status <status>] [--dirty] [--jobs <n>] -- <command...>
+func eachCommand(args []string) error {
+	usage := fmt.Errorf("usage: lfg each [--status <status>] [--dirty] [--jobs <n>] -- <command...>")
+	split := slices.Index(args, "--")
+	if split < 0 {
+		return usage
+	}
+	flags := flag.NewFlagSet("each", flag.ContinueOnError)
+	status := flags.String("status", "", "Only worktrees whose item or todo has this status")
+	dirty := flags.Bool("dirty", false, "Only worktrees with uncommitted changes")
+	jobs := flags.Int("jobs", 4, "How many worktrees to run the command in at once")
+	if err := flags.Parse(args[:split]); err != nil {
+		return err
+	}
+	command := args[split+1:]
+	if flags.NArg() != 0 || len(command) == 0 || *jobs < 1 {
+		return usage
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.RequireLocal("lfg each"); err != nil {
+		return err
+	}
+	service := core.New(cfg)
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	var items []github.ProjectItem
+	if *status != "" {
+		if items, _, err = service.ListItems(); err != nil {
+			return err
+		}
+	}
+
+	var targets []git.Worktree
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		if *status != "" && !sameStatus(service.WorktreeStatus(items, name), *status) {
+			continue
+		}
+		if *dirty {
+			if count, err := git.DirtyFileCount(wt.Path); err != nil || count == 0 {
+				continue
+			}
+		}
+		targets = append(targets, wt)
+	}
+	if len(targets) == 0 {
+		fmt.Println("No worktrees to run in")
+		return nil
+	}
+	var mu sync.Mutex
+	var failed []string
+	slots := make(chan struct{}, *jobs)
+	var wg sync.WaitGroup
+	for _, wt := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			name := git.GetWorktreeName(wt.Path)
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Dir = wt.Path
+			env, err := service.CommandEnv(name)
+			cmd.Env = env
+			started := time.Now()
+			var output []byte
+			if err == nil {
+				output, err = cmd.CombinedOutput()
+			}
+			took := time.Since(started).Round(100 * time.Millisecond)
+
+			// Sections are printed whole, so worktrees' output doesn't interleave
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, name)
+				fmt.Printf("=== %s ✗ %v (%s)\n", name, err, took)
+			} else {
+				fmt.Printf("=== %s ✓ (%s)\n", name, took)
+			}
+			os.Stdout.Write(output)
+			if len(output) > 0 && output[len(output)-1] != '\n' {
+				fmt.Println()
+			}
+		}()
+	}
+	wg.Wait()
+
+	fmt.Printf("\nRan in %s, %d failed\n", plural(len(targets), "worktree"), len(failed))
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return fmt.Errorf("failed in %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// sameStatus compares statuses as people type them, so "in-progress" is "In Progress" and
+// "done" a todo's "done"
+func sameStatus(status, want string) bool {
+	normalize := func(s string) string {
+		return strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(s))
+	}
+	return normalize(status) == normalize(want)
+}
+
+// pickCommand prints a line for each worktree for a fuzzy finder like fzf to choose from,
+// or given the chosen line, jumps to its worktree. Item statuses come from the daemon or
+// the TUI's last list, so listing never waits on the backend.
+// Usage: lfg pick [<line>], e.g. lfg pick "$(lfg pick | fzf)"
+func pickCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: lfg pick [<line>]")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(args) == 1 {
+		// The name is the line's first field, so a plain worktree name works too
+		name, _, _ := strings.Cut(args[0], "\t")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("nothing was picked")
+		}
+		recordWorktreeUse(cfg, name)
+		return git.JumpToWorktree(name, cfg)
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	service := core.New(cfg)
+	items := cachedItems(cfg)
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		description := strings.TrimPrefix(wt.Branch, "refs/heads/")
+		if todo := cfg.GetTodoForWorktree(name); todo != nil {
+			description = todo.Description
+		}
+		// Tabs and newlines would break the line into the wrong fields
+		description = strings.Join(strings.Fields(description), " ")
+		fmt.Printf("%s\t%s\t%s\n", name, description, service.WorktreeStatus(items, name))
+	}
+	return nil
+}
+
+// cachedItems is the backend's items as the running daemon or the TUI last had them, nil
+// if neither has any
+func cachedItems(cfg *config.Config) []github.ProjectItem {
+	if warm, err := daemon.Query(cfg.GetConfigPath()); err == nil && warm.HasItems() {
+		return warm.Items
+	}
+	snapshot, err := listcache.Load(cfg.GetConfigPath())
+	if err != nil || !snapshot.Matches(cfg.StorageBackend) {
+		return nil
+	}
+	var items []github.ProjectItem
+	for _, item := range snapshot.Items {
+		if item.GitHubItem != nil {
+			items = append(items, *item.GitHubItem)
+		}
+	}
+	return items
+}
+
+// statusCommand lists the worktrees with their branches, tmux sessions and ports
+// Usage: lfg status
+func statusCommand(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return err
+	}
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return err
+	}
+	// Worktrees are still worth listing without tmux
+	sessions := make(map[string]tmux.SessionInfo)
+	if infos, err := tmux.ListSessionInfo(); err == nil {
+		for _, info := range infos {
+			sessions[info.Name] = info
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		branch := strings.TrimPrefix(wt.Branch, "refs/heads/")
+		if branch == "" {
+			branch = "(detached)"
+		}
+		session := "no session"
+		if info, ok := sessions[tmux.SanitizeSessionName(name)]; ok {
+			session = "detached"
+			if info.Attached > 0 {
+				session = "attached"
+			}
+		}
+		ports := "-"
+		if block, ok := st.PortBlocks[name]; ok && cfg.Ports != nil {
+			var env []string
+			for _, port := range cfg.Ports.InBlock(block) {
+				env = append(env, port.Env())
+			}
+			ports = strings.Join(env, " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, branch, session, ports)
+	}
+	return w.Flush()
+}
+
+// historyCommand shows what lfg has done in the repository, oldest first
+// Usage: lfg history [-n <count>] [--worktree <name>] [--json]
+func historyCommand(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	count := flags.Int("n", 50, "Show this many of the latest entries, 0 for all of them")
+	worktree := flags.String("worktree", "", "Only show what happened to this worktree")
+	asJSON := flags.Bool("json", false, "Write the entries as JSON, one per line")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	entries, err := history.Load(cfg.GetConfigPath())
+	if err != nil {
+		return err
+	}
+
+	if *worktree != "" {
+		entries = slices.DeleteFunc(entries, func(entry history.Entry) bool { return entry.Worktree != *worktree })
+	}
+	if *count > 0 && len(entries) > *count {
+		entries = entries[len(entries)-*count:]
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("Nothing recorded yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"), entry.Actor, entry.Via, core.DescribeEntry(entry))
+	}
+	return w.Flush()
+}
+
+// watchCommand prints what happens in the repository as it happens, a line at a time, for
+// a spare pane or another tool to read: worktrees created and deleted, status changes,
+// agents finishing, panes crashing and, with a GitHub backend, pull requests merged
+// Usage: lfg watch [--kind <kind,...>] [--interval <duration>] [--json]
+func watchCommand(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	kinds := flags.String("kind", "", "Only print these kinds of events, comma separated, e.g. status_changed,pane_crashed")
+	interval := flags.Duration("interval", time.Minute, "How often to look for merged pull requests on GitHub")
+	asJSON := flags.Bool("json", false, "Write the events as JSON, one per line")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	var only []string
+	if *kinds != "" {
+		only = strings.Split(*kinds, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	entries := make(chan history.Entry)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- history.Follow(ctx, cfg.GetConfigPath(), time.Second, func(entry history.Entry) {
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	service := core.New(cfg)
+	merges := time.NewTicker(*interval)
+	defer merges.Stop()
+	mergedSince := time.Now()
+	encoder := json.NewEncoder(os.Stdout)
+	write := func(entry history.Entry) error {
+		if only != nil && !slices.Contains(only, entry.Kind) {
+			return nil
+		}
+		if *asJSON {
+			return encoder.Encode(entry)
+		}
+		_, err := fmt.Printf("%s  %s  %s  %s\n", entry.Time.Local().Format(time.DateTime), entry.Actor, entry.Via, core.DescribeEntry(entry))
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-followed:
+			return err
+		case entry := <-entries:
+			if err := write(entry); err != nil {
+				return err
+			}
+		case <-merges.C:
+			merged, err := service.MergedPullRequests(mergedSince)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to look for merged pull requests: %v\n", err)
+				continue
+			}
+			for _, entry := range merged {
+				mergedSince = entry.Time
+				if err := write(entry); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// metricsCommand prints how long todos and items took from being started to done, how
+// many were in progress and how many were done each week, from the history and the todos
+// Usage: lfg metrics [--weeks N] [--format text|json|csv] [--output <file>]
+func metricsCommand(args []string) error {
+	flags := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	weeks := flags.Int("weeks", 8, "Report on this many weeks, up to and including this one")
+	format := flags.String("format", "text", "Format to write: text, json or csv")
+	output := flags.String("output", "", "File to write to instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *weeks < 1 {
+		return fmt.Errorf("--weeks must be at least 1")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	entries, err := history.Load(cfg.GetConfigPath())
+	if err != nil {
+		return err
+	}
+	report := metrics.Build(metrics.Tasks(cfg.Todos, entries), time.Now(), *weeks)
+
+	var out bytes.Buffer
+	switch *format {
+	case "text":
+		err = writeMetrics(&out, report)
+	case "json":
+		err = metrics.WriteJSON(&out, report)
+	case "csv":
+		err = metrics.WriteCSV(&out, report)
+	default:
+		return fmt.Errorf("unknown format %q, want text, json or csv", *format)
+	}
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(out.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*output, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// writeMetrics writes a report as a summary and a table of its weeks
+func writeMetrics(out io.Writer, report metrics.Report) error {
+	cycle := report.CycleTime
+	fmt.Fprintf(out, "Since %s: %s done, %d in progress now\n", report.Since.Format(time.DateOnly), plural(cycle.Count, "task"), report.InProgress)
+	if cycle.Count > 0 {
+		fmt.Fprintf(out, "Cycle time: median %s, mean %s, 85%% within %s\n", humanize.Duration(cycle.Median), humanize.Duration(cycle.Mean), humanize.Duration(cycle.Percentile85))
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WEEK\tDONE\tIN PROGRESS\tMEDIAN CYCLE TIME")
+	for _, week := range report.Weeks {
+		median := "-"
+		if week.CycleTime.Count > 0 {
+			median = humanize.Duration(week.CycleTime.Median)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", week.Start.Format(time.DateOnly), week.Completed, week.InProgress, median)
+	}
+	return w.Flush()
+}
+
+// plural counts n of something, e.g. "1 worktree" or "2 worktrees"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
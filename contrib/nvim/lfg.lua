@@ -0,0 +1,135 @@
+-- A worktree picker for Neovim over `lfg --rpc`.
+--
+-- Put this file on your runtimepath as lua/lfg.lua and call require("lfg").setup(). It adds:
+--
+--   :Lfg        pick a worktree and jump to its tmux session
+--   :LfgCreate  create a worktree, prompting for its name and description
+--   :LfgDelete  pick a worktree to delete
+--
+-- Inside tmux, jumping switches your client to the worktree's session. Outside of it, the
+-- session opens in a terminal in a new tab.
+
+local M = {}
+
+local job, next_id, pending, partial = nil, 0, {}, ""
+
+-- Starts lfg --rpc in Neovim's working directory, once
+local function start()
+  if job then
+    return job
+  end
+  job = vim.fn.jobstart({ M.options.cmd, "--rpc" }, {
+    cwd = vim.fn.getcwd(),
+    on_stdout = function(_, data)
+      -- Lines can arrive split across callbacks; the last element is what's left of one
+      data[1] = partial .. data[1]
+      partial = table.remove(data)
+      for _, line in ipairs(data) do
+        if line ~= "" then
+          local response = vim.json.decode(line)
+          local callback = pending[response.id]
+          pending[response.id] = nil
+          if callback then
+            callback(response.error, response.result)
+          end
+        end
+      end
+    end,
+    on_exit = function()
+      job, pending, partial = nil, {}, ""
+    end,
+  })
+  if job <= 0 then
+    job = nil
+    error("lfg: couldn't run " .. M.options.cmd)
+  end
+  return job
+end
+
+-- Sends a request, calling callback(err, result) with its response on the main loop
+local function request(method, params, callback)
+  next_id = next_id + 1
+  pending[next_id] = vim.schedule_wrap(function(err, result)
+    if err and err ~= vim.NIL then
+      vim.notify("lfg: " .. err, vim.log.levels.ERROR)
+      return
+    end
+    callback(result)
+  end)
+  local line = vim.json.encode({ id = next_id, method = method, params = params or vim.empty_dict() })
+  vim.fn.chansend(start(), line .. "\n")
+end
+
+local function warn(result)
+  for _, warning in ipairs(result.warnings) do
+    vim.notify("lfg: " .. warning, vim.log.levels.WARN)
+  end
+end
+
+-- Lists the worktrees with vim.ui.select, calling choose with the one picked
+local function pick(prompt, choose)
+  request("list", nil, function(worktrees)
+    vim.ui.select(worktrees, {
+      prompt = prompt,
+      format_item = function(wt)
+        local marker = wt.session and "● " or "  "
+        local description = wt.description and wt.description ~= vim.NIL and ("  " .. wt.description) or ""
+        return marker .. wt.name .. description
+      end,
+    }, function(wt)
+      if wt then
+        choose(wt)
+      end
+    end)
+  end)
+end
+
+function M.jump(name)
+  request("jump", { name = name }, function(result)
+    if not result.switched then
+      vim.cmd.tabnew()
+      vim.fn.termopen(result.attach)
+      vim.cmd.startinsert()
+    end
+  end)
+end
+
+function M.pick()
+  pick("Jump to worktree", function(wt)
+    M.jump(wt.name)
+  end)
+end
+
+function M.create()
+  vim.ui.input({ prompt = "Worktree name: " }, function(name)
+    if not name or name == "" then
+      return
+    end
+    vim.ui.input({ prompt = "Description: " }, function(description)
+      request("create", { name = name, description = description }, function(result)
+        warn(result)
+        M.jump(name)
+      end)
+    end)
+  end)
+end
+
+function M.delete()
+  pick("Delete worktree", function(wt)
+    if vim.fn.confirm("Delete " .. wt.name .. " and its branch?", "&Yes\n&No", 2) == 1 then
+      request("delete", { name = wt.name }, function(result)
+        warn(result)
+        vim.notify("lfg: deleted " .. wt.name)
+      end)
+    end
+  end)
+end
+
+function M.setup(options)
+  M.options = vim.tbl_extend("force", { cmd = "lfg" }, options or {})
+  vim.api.nvim_create_user_command("Lfg", M.pick, { desc = "Jump to a worktree" })
+  vim.api.nvim_create_user_command("LfgCreate", M.create, { desc = "Create a worktree" })
+  vim.api.nvim_create_user_command("LfgDelete", M.delete, { desc = "Delete a worktree" })
+end
+
+return M
@@ -1,6 +1,25 @@
 #!/bin/bash
 set -e
 
+# ./install.sh --gh installs lfg as gh's extension, to be run as gh lfg
+if [ "$1" = "--gh" ]; then
+    dir="${XDG_DATA_HOME:-$HOME/.local/share}/lfg/gh-lfg"
+    mkdir -p "$dir"
+
+    echo "Building gh-lfg..."
+    go build -o "$dir/gh-lfg" .
+
+    # gh links a local extension's directory, so rebuilding updates it
+    if ! gh extension list | grep -q "^gh lfg"; then
+        echo "Installing as a gh extension..."
+        (cd "$dir" && gh extension install .)
+    fi
+
+    echo "✓ gh lfg installed successfully!"
+    echo "Run 'gh lfg' to get started."
+    exit 0
+fi
+
 echo "Building lfg..."
 go build -o lfg .
 
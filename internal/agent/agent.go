@@ -5,16 +5,44 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
 	"github.com/markcipolla/lfg/internal/git"
 	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/notify"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/internal/webhook"
 )
 
+// commands runs Claude, and is swapped out by tests. Claude works for as long as it takes,
+// so it isn't given a timeout.
+var commands runner.Runner = runner.Exec{}
+
+// SetRunner makes the package run Claude through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// launchClaude starts Claude with args as the job, through commands
+func launchClaude(job runner.Job, args ...string) (*runner.Launched, error) {
+	launcher, ok := commands.(runner.Launcher)
+	if !ok {
+		return nil, fmt.Errorf("claude can't be launched through %T", commands)
+	}
+	return launcher.Launch(job, "claude", args...)
+}
+
 // Message represents a single message in the conversation
 type Message struct {
 	Role    string `json:"role"`    // "user" or "assistant"
@@ -30,7 +58,9 @@ type StreamMessage struct {
 // JSONLEntry represents a single line from Claude's JSONL log file
 type JSONLEntry struct {
 	Type      string         `json:"type"`      // "user", "assistant", "summary", etc.
+	UUID      string         `json:"uuid"`      // Unique message ID
 	SessionID string         `json:"sessionId"` // Session ID
+	Timestamp string         `json:"timestamp"` // When the entry was written, in RFC 3339
 	Message   MessageContent `json:"message"`   // The actual message
 }
 
@@ -42,24 +72,83 @@ type MessageContent struct {
 
 // ContentBlock represents a content block (text, tool use, etc.)
 type ContentBlock struct {
-	Type string `json:"type"` // "text", "tool_use", etc.
-	Text string `json:"text"` // Text content
+	Type  string          `json:"type"`  // "text", "tool_use", etc.
+	Text  string          `json:"text"`  // Text content
+	Name  string          `json:"name"`  // The tool used, for tool_use
+	Input json.RawMessage `json:"input"` // The tool's input, for tool_use
 }
 
 // conversationMonitor monitors the Claude JSONL log and posts to GitHub
 type conversationMonitor struct {
-	cfg               *config.Config
-	issueNumber       int
-	worktreePath      string // Full path to the worktree directory
-	lastPosition      int64
-	lastCommentID     int    // Track last processed GitHub comment
-	stopChan          chan bool
-	tmuxPane          string // Tmux pane target for sending input
+	cfg           *config.Config
+	issueNumber   int
+	worktreeName  string
+	worktreePath  string // Full path to the worktree directory
+	lastPosition  int64
+	lastUUID      string          // UUID of the last mirrored message
+	mirrored      map[string]bool // Message UUIDs already posted, including previous sessions
+	unsavedUUIDs  []string        // Newly mirrored UUIDs not yet persisted to state
+	pending       []pendingPost   // Messages read from the log but not yet posted
+	status        state.MonitorStatus
+	lastFlush     time.Time // FlushRequestedAt of the last flush we honoured
+	lastCommentID int       // Track last processed GitHub comment
+	daily         bool      // Conversation is summed up once a day, so nothing is mirrored as it happens
+	stopChan      chan bool
+	done          chan struct{} // Closed when the log monitoring goroutine returns
+	tmuxPane      string        // Tmux pane target for sending input
+	logPath       string        // Claude session log being mirrored, once found
+	startedAt     time.Time
+	postedCount   int // Messages mirrored during this session
+
+	targetMu        sync.Mutex
+	target          int       // Issue or PR number conversation is mirrored to
+	targetCheckedAt time.Time // When the target was last resolved
 }
 
+// pendingPost is a message waiting to be mirrored
+type pendingPost struct {
+	uuid  string
+	body  string
+	start int64 // Log offset of the start of this message
+}
+
+// Markers identifying comments lfg posts, so they aren't fed back to Claude as new input
+const (
+	claudeMarker  = "🤖 **Claude:**"
+	userMarker    = "**User:**"
+	sessionMarker = "🤖 **lfg:**"
+	summaryMarker = "🤖 **lfg summary:**"
+)
+
+const (
+	// targetRecheckInterval is how often the monitor looks for a newly opened PR
+	targetRecheckInterval = 2 * time.Minute
+
+	// shutdownGrace is how long Claude gets to exit after we forward a termination signal
+	shutdownGrace = 5 * time.Second
+
+	// shutdownTimeout bounds how long the monitor may spend flushing posts on exit
+	shutdownTimeout = 15 * time.Second
+)
+
 // Run starts the agent wrapper for a given worktree
 // It launches Claude Code normally and shows context from previous conversation
 func Run(worktreeName string, cfg *config.Config) error {
+	err := run(worktreeName, cfg)
+	core.New(cfg).Record(core.Event{Kind: core.AgentFinished, Worktree: worktreeName})
+	if notifyErr := notify.Send(cfg, config.NotifyAgent, "Agent finished", "The agent session in "+worktreeName+" has ended"); notifyErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", notifyErr)
+	}
+	webhook.Send(cfg, webhook.Event{
+		Kind:     config.WebhookAgentFinished,
+		Worktree: worktreeName,
+		Text:     fmt.Sprintf("%s: the agent session in %s has ended", cfg.Name, worktreeName),
+	})
+	return err
+}
+
+// run is Run until the agent exits
+func run(worktreeName string, cfg *config.Config) error {
 	// Find the todo for this worktree
 	todo := cfg.GetTodoForWorktree(worktreeName)
 	if todo == nil {
@@ -68,32 +157,41 @@ func Run(worktreeName string, cfg *config.Config) error {
 	}
 
 	// Check if we have GitHub integration
-	if cfg.StorageBackend == nil || cfg.StorageBackend.Type != "github" {
+	if !cfg.StorageBackend.IsGitHub() {
 		// No GitHub integration - just run Claude Code normally
 		return runClaudeCode("", nil)
 	}
 
 	// Get the issue number from the GitHub URL
-	issueNumber, err := extractIssueNumber(todo.GitHubURL)
+	issueNumber, err := github.IssueNumberFromURL(todo.GitHubURL)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to extract issue number: %v\n", err)
 		return runClaudeCode("", nil)
 	}
 
-	// Load previous conversation from GitHub issue comments
-	ctx, err := loadContextFromIssue(cfg, issueNumber)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load context: %v\n", err)
-		ctx = ""
-	}
-
 	// Get the worktree path
 	worktreePath, err := git.GetWorktreePath(worktreeName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to get worktree path: %v\n", err)
+		ctx, _ := loadContextFromIssue(cfg, issueNumber)
 		return runClaudeCode(ctx, nil)
 	}
 
+	// Conversation goes to the worktree's PR once one is open
+	target := mirrorTarget(cfg, issueNumber, worktreePath)
+
+	// Load previous conversation from the issue, plus the PR if we're mirroring there
+	ctx, err := loadContextFromIssue(cfg, issueNumber)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load context: %v\n", err)
+		ctx = ""
+	}
+	if target != issueNumber {
+		if prCtx, err := loadContextFromIssue(cfg, target); err == nil && prCtx != "" {
+			ctx += "\n" + prCtx
+		}
+	}
+
 	// Get current tmux pane for sending input
 	tmuxPane := os.Getenv("TMUX_PANE")
 
@@ -101,7 +199,7 @@ func Run(worktreeName string, cfg *config.Config) error {
 	comments, err := github.GetIssueComments(
 		cfg.StorageBackend.Owner,
 		cfg.StorageBackend.Repo,
-		issueNumber,
+		target,
 	)
 	var lastCommentID int
 	if err == nil && len(comments) > 0 {
@@ -110,12 +208,25 @@ func Run(worktreeName string, cfg *config.Config) error {
 
 	// Create conversation monitor
 	monitor := &conversationMonitor{
-		cfg:           cfg,
-		issueNumber:   issueNumber,
-		worktreePath:  worktreePath,
-		lastCommentID: lastCommentID,
-		tmuxPane:      tmuxPane,
-		stopChan:      make(chan bool),
+		cfg:             cfg,
+		issueNumber:     issueNumber,
+		worktreeName:    worktreeName,
+		worktreePath:    worktreePath,
+		lastCommentID:   lastCommentID,
+		tmuxPane:        tmuxPane,
+		stopChan:        make(chan bool),
+		done:            make(chan struct{}),
+		startedAt:       time.Now(),
+		target:          target,
+		targetCheckedAt: time.Now(),
+		mirrored:        make(map[string]bool),
+		daily:           cfg.StorageBackend.MirrorsDaily(),
+		status: state.MonitorStatus{
+			PID:    os.Getpid(),
+			Active: true,
+			Target: target,
+			Daily:  cfg.StorageBackend.MirrorsDaily(),
+		},
 	}
 
 	// Run Claude Code with context and monitor
@@ -131,11 +242,18 @@ func runClaudeCode(context string, monitor *conversationMonitor) error {
 		args = append(args, "--append-system-prompt", context)
 	}
 
+	// Catch signals before starting Claude so none slip through. SIGINT goes to the whole
+	// foreground process group, so Claude already receives it (to interrupt a response);
+	// we only need to make sure it doesn't kill the wrapper.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
 	// Start Claude Code
-	cmd := exec.Command("claude", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	claude, err := launchClaude(runner.Job{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}, args...)
+	if err != nil {
+		return err
+	}
 
 	// If we have a monitor, start it in the background
 	if monitor != nil {
@@ -147,18 +265,60 @@ func runClaudeCode(context string, monitor *conversationMonitor) error {
 		defer monitor.stop()
 	}
 
-	return cmd.Run()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- claude.Wait()
+	}()
+
+	for {
+		select {
+		case err := <-exited:
+			return err
+
+		case sig := <-signals:
+			if sig == syscall.SIGINT {
+				continue
+			}
+
+			// SIGTERM or SIGHUP (e.g. the tmux session was killed): pass it on and give
+			// Claude a moment to exit before the monitor flushes
+			claude.Signal(sig)
+			select {
+			case err := <-exited:
+				return err
+			case <-time.After(shutdownGrace):
+				claude.Kill()
+				return <-exited
+			}
+		}
+	}
 }
 
 // start begins monitoring the Claude JSONL log file
 func (m *conversationMonitor) start() {
+	defer close(m.done)
+
+	// Report that we're running before waiting for the session to appear
+	m.sync("")
+
+	// Catch up on the summaries of days nobody was running the daemon
+	if m.daily {
+		if _, err := PostDailySummaries(m.cfg, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to post daily summaries: %v\n", err)
+		}
+	}
+
 	// Wait for Claude to create a session (up to 30 seconds)
 	var logPath string
 	var err error
 
 	// Check more frequently - every 100ms
 	for i := 0; i < 300; i++ {
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-m.stopChan:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
 
 		logPath, err = m.findLatestSession()
 		if err == nil {
@@ -173,20 +333,85 @@ func (m *conversationMonitor) start() {
 
 	fmt.Fprintf(os.Stderr, "Monitoring Claude session log: %s\n", logPath)
 
-	// Don't seek to end - monitor from beginning to catch all messages
-	m.lastPosition = 0
+	// Resume from the persisted high-water mark so relaunching the agent pane
+	// doesn't repost messages that were already mirrored
+	if st, err := state.Load(m.cfg.GetConfigPath()); err == nil {
+		m.lastPosition = st.SessionOffset(logPath)
+		for _, id := range st.MirroredUUIDs[m.worktreeName] {
+			m.mirrored[id] = true
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load state: %v\n", err)
+	}
 
 	// Monitor the log file
+	m.logPath = logPath
 	m.monitorLogFile(logPath)
 }
 
-// stop signals the monitor to stop
+// stop signals the monitor to stop, waits for any in-flight post to finish, then
+// mirrors the rest of the log and records the session's end
 func (m *conversationMonitor) stop() {
 	close(m.stopChan)
+
+	finished := make(chan struct{})
+	go func() {
+		<-m.done
+		m.finish()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(shutdownTimeout):
+		fmt.Fprintf(os.Stderr, "Warning: timed out flushing conversation to GitHub\n")
+	}
+}
+
+// finish does the final flush once the monitoring goroutine has returned
+func (m *conversationMonitor) finish() {
+	if m.logPath != "" {
+		m.readLog(m.logPath)
+
+		// Respect a pause: anything unposted stays behind the saved offset for next time
+		st, err := state.Load(m.cfg.GetConfigPath())
+		if err == nil && !st.Monitors[m.worktreeName].Paused && !m.daily {
+			m.postPending()
+			if len(m.pending) == 0 {
+				m.postSessionEnded()
+			}
+		}
+	}
+
+	m.status.Active = false
+	m.sync(m.logPath)
+}
+
+// postSessionEnded posts a marker comment so the thread shows where a session stopped
+func (m *conversationMonitor) postSessionEnded() {
+	body := fmt.Sprintf("%s Session ended after %s · %d message(s) mirrored",
+		sessionMarker,
+		time.Since(m.startedAt).Round(time.Minute),
+		m.postedCount)
+
+	if err := github.CreateIssueComment(
+		m.cfg.StorageBackend.Owner,
+		m.cfg.StorageBackend.Repo,
+		m.commentTarget(),
+		body,
+	); err != nil {
+		m.status.Errors++
+		m.status.LastError = err.Error()
+	}
 }
 
 // findLatestSession finds the most recent Claude session JSONL file
 func (m *conversationMonitor) findLatestSession() (string, error) {
+	return latestSession(m.worktreePath)
+}
+
+// projectDir is where Claude keeps the session logs of the worktree at path
+func projectDir(path string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -195,13 +420,38 @@ func (m *conversationMonitor) findLatestSession() (string, error) {
 	// Convert worktree path to Claude's project name format
 	// Claude replaces slashes and dots with hyphens:
 	// /Users/foo/bar.baz -> -Users-foo-bar-baz
-	projectName := strings.ReplaceAll(m.worktreePath, "/", "-")
+	projectName := strings.ReplaceAll(path, "/", "-")
 	projectName = strings.ReplaceAll(projectName, ".", "-")
 
-	projectDir := filepath.Join(homeDir, ".claude", "projects", projectName)
+	return filepath.Join(homeDir, ".claude", "projects", projectName), nil
+}
 
-	// List all JSONL files in the project directory
+// sessionLogs lists the Claude session JSONL files for the worktree at path, none when the
+// agent hasn't been run there
+func sessionLogs(path string) ([]string, error) {
+	projectDir, err := projectDir(path)
+	if err != nil {
+		return nil, err
+	}
 	entries, err := os.ReadDir(projectDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var logs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+			logs = append(logs, filepath.Join(projectDir, entry.Name()))
+		}
+	}
+	return logs, nil
+}
+
+// latestSession finds the most recent Claude session JSONL file for the worktree at path
+func latestSession(path string) (string, error) {
+	logs, err := sessionLogs(path)
 	if err != nil {
 		return "", err
 	}
@@ -210,12 +460,7 @@ func (m *conversationMonitor) findLatestSession() (string, error) {
 	var latestFile string
 	var latestTime time.Time
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
-			continue
-		}
-
-		fullPath := filepath.Join(projectDir, entry.Name())
+	for _, fullPath := range logs {
 		info, err := os.Stat(fullPath)
 		if err != nil {
 			continue
@@ -228,6 +473,7 @@ func (m *conversationMonitor) findLatestSession() (string, error) {
 	}
 
 	if latestFile == "" {
+		projectDir, _ := projectDir(path)
 		return "", fmt.Errorf("no JSONL files found in %s", projectDir)
 	}
 
@@ -241,91 +487,232 @@ func (m *conversationMonitor) monitorLogFile(logPath string) {
 		case <-m.stopChan:
 			return
 		default:
-			// Open file each time to pick up new data
-			file, err := os.Open(logPath)
-			if err != nil {
+			if err := m.readLog(logPath); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to open log file: %v\n", err)
 				time.Sleep(1 * time.Second)
 				continue
 			}
 
-			// Seek to last position
-			file.Seek(m.lastPosition, 0)
-			reader := bufio.NewReader(file)
+			m.sync(logPath)
 
-			// Read all available lines
-			for {
-				line, err := reader.ReadString('\n')
-				if err != nil {
-					// No more data available
-					break
-				}
+			// Wait before checking for more data
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+}
 
-				m.lastPosition += int64(len(line))
-				m.processLogEntry(line)
-			}
+// readLog processes every complete line added to the log since the last read
+func (m *conversationMonitor) readLog(logPath string) error {
+	// Open file each time to pick up new data
+	file, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-			file.Close()
+	// Seek to last position
+	file.Seek(m.lastPosition, 0)
+	reader := bufio.NewReader(file)
 
-			// Wait before checking for more data
-			time.Sleep(500 * time.Millisecond)
+	// Read all available lines
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// No more data available
+			return nil
 		}
+
+		start := m.lastPosition
+		m.lastPosition += int64(len(line))
+		m.processLogEntry(line, start)
 	}
 }
 
-// processLogEntry parses and processes a single JSONL log entry
-func (m *conversationMonitor) processLogEntry(line string) {
+// processLogEntry parses a single JSONL log entry starting at offset start and
+// queues its text for posting
+func (m *conversationMonitor) processLogEntry(line string, start int64) {
 	var entry JSONLEntry
 	if err := json.Unmarshal([]byte(line), &entry); err != nil {
 		return // Skip invalid JSON
 	}
 
-	// Only process user and assistant messages
-	if entry.Type != "user" && entry.Type != "assistant" {
+	// Only process user and assistant messages, and none when they're summed up daily
+	if entry.Type != "user" && entry.Type != "assistant" || m.daily {
 		return
 	}
 
-	// Extract text content - handle both string (user) and array (assistant) formats
-	var text string
-
-	// Try parsing as a string first (user messages)
-	if err := json.Unmarshal(entry.Message.Content, &text); err == nil && text != "" {
-		// Successfully parsed as string
-	} else {
-		// Try parsing as array of content blocks (assistant messages)
-		var blocks []ContentBlock
-		if err := json.Unmarshal(entry.Message.Content, &blocks); err == nil {
-			var textParts []string
-			for _, block := range blocks {
-				if block.Type == "text" && block.Text != "" {
-					textParts = append(textParts, block.Text)
-				}
-			}
-			text = strings.Join(textParts, "\n")
-		}
+	// Skip messages mirrored by a previous run (e.g. a resumed session)
+	if entry.UUID != "" && m.mirrored[entry.UUID] {
+		return
 	}
 
+	text := entryText(entry)
 	if text == "" {
 		return // No text content to post
 	}
 
-	// Post to GitHub
+	// Queue for posting, the next sync decides whether to post now
 	var body string
 	if entry.Type == "user" {
-		body = fmt.Sprintf("**User:** %s", text)
+		body = fmt.Sprintf("%s %s", userMarker, text)
 	} else {
-		body = fmt.Sprintf("🤖 **Claude:** %s", text)
+		body = fmt.Sprintf("%s %s", claudeMarker, text)
+	}
+	m.pending = append(m.pending, pendingPost{uuid: entry.UUID, body: body, start: start})
+}
+
+// entryText extracts a log entry's text, from a string (user) or an array of content
+// blocks (assistant)
+func entryText(entry JSONLEntry) string {
+	// Try parsing as a string first (user messages)
+	var text string
+	if err := json.Unmarshal(entry.Message.Content, &text); err == nil && text != "" {
+		return text
 	}
 
-	err := github.CreateIssueComment(
-		m.cfg.StorageBackend.Owner,
-		m.cfg.StorageBackend.Repo,
-		m.issueNumber,
-		body,
-	)
+	// Try parsing as array of content blocks (assistant messages)
+	var blocks []ContentBlock
+	if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+		return ""
+	}
+	var textParts []string
+	for _, block := range blocks {
+		if block.Type == "text" && block.Text != "" {
+			textParts = append(textParts, block.Text)
+		}
+	}
+	return strings.Join(textParts, "\n")
+}
+
+// postPending posts queued messages in order, stopping at the first failure so
+// ordering is preserved on retry
+func (m *conversationMonitor) postPending() {
+	for len(m.pending) > 0 {
+		post := m.pending[0]
+		target := m.commentTarget()
+		err := github.CreateIssueComment(
+			m.cfg.StorageBackend.Owner,
+			m.cfg.StorageBackend.Repo,
+			target,
+			post.body,
+		)
+		if err != nil {
+			m.status.Errors++
+			m.status.LastError = err.Error()
+			return
+		}
+
+		m.pending = m.pending[1:]
+		m.postedCount++
+		m.status.LastPostAt = time.Now()
+		m.status.Target = target
+		if post.uuid != "" {
+			m.mirrored[post.uuid] = true
+			m.unsavedUUIDs = append(m.unsavedUUIDs, post.uuid)
+			m.lastUUID = post.uuid
+		}
+	}
+}
+
+// mirroredPosition is the log offset up to which every message has been posted
+func (m *conversationMonitor) mirroredPosition() int64 {
+	if len(m.pending) > 0 {
+		// Resume at the start of the first unposted message
+		return m.pending[0].start
+	}
+	return m.lastPosition
+}
+
+// sync exchanges state with the TUI: it picks up pause and flush requests, posts
+// pending messages accordingly, and persists the high-water mark and status.
+// State is reloaded first so writes from other lfg processes aren't lost.
+func (m *conversationMonitor) sync(logPath string) {
+	st, err := state.Load(m.cfg.GetConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load state: %v\n", err)
+		return
+	}
+
+	control := st.Monitors[m.worktreeName]
+	flush := control.FlushRequestedAt.After(m.lastFlush)
+	if !control.Paused || flush {
+		m.postPending()
+	}
+	if flush {
+		m.lastFlush = control.FlushRequestedAt
+	}
+
+	status := m.status
+	status.Paused = control.Paused
+	status.FlushRequestedAt = control.FlushRequestedAt
+	status.Pending = len(m.pending)
+
+	position := m.mirroredPosition()
+	moved := logPath != "" && st.SessionOffset(logPath) != position
+	if status.Equal(control) && len(m.unsavedUUIDs) == 0 && !moved {
+		return
+	}
+
+	// Posting took a while, so the write goes to the state as it is now, keeping any pause
+	// or flush the TUI has asked for since
+	_, err = state.Update(m.cfg.GetConfigPath(), func(latest *state.State) error {
+		if moved {
+			latest.SetSessionMark(logPath, state.SessionMark{
+				Worktree: m.worktreeName,
+				Offset:   position,
+				LastUUID: m.lastUUID,
+			})
+		}
+		current := latest.Monitors[m.worktreeName]
+		status.Paused = current.Paused
+		status.FlushRequestedAt = current.FlushRequestedAt
+		latest.SetMonitor(m.worktreeName, status)
+		for _, id := range m.unsavedUUIDs {
+			latest.MarkMirrored(m.worktreeName, id)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save state: %v\n", err)
+		return
+	}
+	m.unsavedUUIDs = nil
+}
+
+// commentTarget returns the issue or PR number to mirror to, periodically checking
+// whether a PR has been opened (or closed) for the worktree's branch
+func (m *conversationMonitor) commentTarget() int {
+	m.targetMu.Lock()
+	defer m.targetMu.Unlock()
+
+	if time.Since(m.targetCheckedAt) > targetRecheckInterval {
+		m.target = mirrorTarget(m.cfg, m.issueNumber, m.worktreePath)
+		m.targetCheckedAt = time.Now()
+	}
+	return m.target
+}
+
+// mirrorTarget returns the open PR for the worktree's branch when mirroring to PRs is
+// enabled, falling back to the linked issue
+func mirrorTarget(cfg *config.Config, issueNumber int, worktreePath string) int {
+	if !cfg.StorageBackend.MirrorToPR() {
+		return issueNumber
+	}
+
+	branch, err := git.GetBranch(worktreePath)
+	if err != nil {
+		return issueNumber
+	}
+
+	pr, err := github.FindPullRequestForBranch(cfg.StorageBackend.Owner, cfg.StorageBackend.Repo, branch)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to post comment to GitHub: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to look up pull request: %v\n", err)
+		return issueNumber
 	}
+	if pr == nil {
+		return issueNumber
+	}
+	return pr.Number
 }
 
 // loadContextFromIssue loads previous conversation from GitHub issue comments
@@ -350,8 +737,15 @@ func loadContextFromIssue(cfg *config.Config, issueNumber int) (string, error) {
 	for _, comment := range comments {
 		// Determine if this is a user or Claude message
 		// We'll use a marker in the comment body to identify Claude's messages
-		if strings.HasPrefix(comment.Body, "🤖 **Claude:**") {
-			ctx.WriteString(fmt.Sprintf("Assistant: %s\n\n", strings.TrimPrefix(comment.Body, "🤖 **Claude:**")))
+		if strings.HasPrefix(comment.Body, sessionMarker) {
+			continue
+		}
+		if strings.HasPrefix(comment.Body, summaryMarker) {
+			ctx.WriteString(fmt.Sprintf("Summary of earlier work: %s\n\n", strings.TrimPrefix(comment.Body, summaryMarker)))
+			continue
+		}
+		if strings.HasPrefix(comment.Body, claudeMarker) {
+			ctx.WriteString(fmt.Sprintf("Assistant: %s\n\n", strings.TrimPrefix(comment.Body, claudeMarker)))
 		} else {
 			ctx.WriteString(fmt.Sprintf("User: %s\n\n", comment.Body))
 		}
@@ -382,7 +776,7 @@ func (m *conversationMonitor) pollGitHubComments() {
 			comments, err := github.GetIssueComments(
 				m.cfg.StorageBackend.Owner,
 				m.cfg.StorageBackend.Repo,
-				m.issueNumber,
+				m.commentTarget(),
 			)
 			if err != nil {
 				continue
@@ -395,14 +789,14 @@ func (m *conversationMonitor) pollGitHubComments() {
 					continue
 				}
 
-				// Skip comments from Claude (our bot)
-				if strings.HasPrefix(comment.Body, "🤖 **Claude:**") {
+				// Skip comments from Claude (our bot), lfg's session markers and its summaries
+				if strings.HasPrefix(comment.Body, claudeMarker) || strings.HasPrefix(comment.Body, sessionMarker) || strings.HasPrefix(comment.Body, summaryMarker) {
 					m.lastCommentID = comment.ID
 					continue
 				}
 
 				// Skip comments from user (they're typing directly)
-				if strings.HasPrefix(comment.Body, "**User:**") {
+				if strings.HasPrefix(comment.Body, userMarker) {
 					m.lastCommentID = comment.ID
 					continue
 				}
@@ -421,42 +815,7 @@ func (m *conversationMonitor) sendToTmux(text string) {
 		return
 	}
 
-	// Use tmux send-keys to inject the text, then press Enter
-	// We need to send Enter as a literal key, not the string "Enter"
-	cmd := exec.Command("tmux", "send-keys", "-t", m.tmuxPane, "-l", text)
-	if err := cmd.Run(); err != nil {
+	if err := tmux.TypeLine(m.tmuxPane, text); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to send text to tmux: %v\n", err)
-		return
 	}
-
-	// Send the Enter key separately
-	cmd = exec.Command("tmux", "send-keys", "-t", m.tmuxPane, "Enter")
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to send Enter to tmux: %v\n", err)
-	}
-}
-
-// extractIssueNumber extracts the issue number from a GitHub URL
-// e.g., "https://github.com/owner/repo/issues/123" -> 123
-func extractIssueNumber(url string) (int, error) {
-	if url == "" {
-		return 0, fmt.Errorf("empty GitHub URL")
-	}
-
-	// Remove trailing slash if present
-	url = strings.TrimSuffix(url, "/")
-
-	// Split by "/"
-	parts := strings.Split(url, "/")
-	if len(parts) < 2 {
-		return 0, fmt.Errorf("invalid GitHub URL format")
-	}
-
-	// Last part should be the issue number
-	var issueNum int
-	if _, err := fmt.Sscanf(parts[len(parts)-1], "%d", &issueNum); err != nil {
-		return 0, fmt.Errorf("failed to parse issue number: %w", err)
-	}
-
-	return issueNum, nil
 }
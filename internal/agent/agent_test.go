@@ -2,73 +2,19 @@ package agent
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-)
-
-func TestExtractIssueNumber(t *testing.T) {
-	tests := []struct {
-		name        string
-		url         string
-		expected    int
-		expectError bool
-	}{
-		{
-			name:        "valid GitHub issue URL",
-			url:         "https://github.com/owner/repo/issues/123",
-			expected:    123,
-			expectError: false,
-		},
-		{
-			name:        "valid GitHub issue URL with trailing slash",
-			url:         "https://github.com/owner/repo/issues/456/",
-			expected:    456,
-			expectError: false,
-		},
-		{
-			name:        "empty URL",
-			url:         "",
-			expected:    0,
-			expectError: true,
-		},
-		{
-			name:        "invalid URL format",
-			url:         "not-a-url",
-			expected:    0,
-			expectError: true,
-		},
-		{
-			name:        "URL without issue number",
-			url:         "https://github.com/owner/repo/issues/",
-			expected:    0,
-			expectError: true,
-		},
-		{
-			name:        "URL with non-numeric issue",
-			url:         "https://github.com/owner/repo/issues/abc",
-			expected:    0,
-			expectError: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result, err := extractIssueNumber(tt.url)
+	"time"
+	"unicode/utf8"
 
-			if tt.expectError {
-				if err == nil {
-					t.Errorf("extractIssueNumber(%q) expected error, got nil", tt.url)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("extractIssueNumber(%q) unexpected error: %v", tt.url, err)
-				}
-				if result != tt.expected {
-					t.Errorf("extractIssueNumber(%q) = %d, want %d", tt.url, result, tt.expected)
-				}
-			}
-		})
-	}
-}
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/testrepo"
+)
 
 func TestProcessLogEntry(t *testing.T) {
 	// Skip this test as it requires mocking the GitHub API
@@ -128,12 +74,228 @@ func TestJSONLEntryParsing(t *testing.T) {
 				if entry.Type != tt.expectedType {
 					t.Errorf("got type %q, want %q", entry.Type, tt.expectedType)
 				}
-				if tt.expectedText != "" && len(entry.Message.Content) > 0 {
-					if entry.Message.Content[0].Text != tt.expectedText {
-						t.Errorf("got text %q, want %q", entry.Message.Content[0].Text, tt.expectedText)
+				if tt.expectedText != "" {
+					var blocks []ContentBlock
+					if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+						t.Fatalf("failed to parse content blocks: %v", err)
+					}
+					if len(blocks) == 0 || blocks[0].Text != tt.expectedText {
+						t.Errorf("got blocks %+v, want first text %q", blocks, tt.expectedText)
 					}
 				}
 			}
 		})
 	}
 }
+
+func TestSummary(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	cfg := repo.Config("name: proj\n")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if summary, err := Summary(cfg, "proj-login"); err != nil || summary != "" {
+		t.Errorf("Summary() before the agent ran = %q, %v, want none", summary, err)
+	}
+
+	// An interactive session, whose last reply sums it up
+	logDir := filepath.Join(home, ".claude", "projects", strings.NewReplacer("/", "-", ".", "-").Replace(path))
+	os.MkdirAll(logDir, 0755)
+	log := `{"type":"user","message":{"role":"user","content":"Fix the login"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Looking at the form"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Fixed the redirect loop"}]}}
+{"type":"summary","summary":"Login fix"}
+`
+	logPath := filepath.Join(logDir, "session.jsonl")
+	if err := os.WriteFile(logPath, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err := Summary(cfg, "proj-login"); err != nil || summary != "Fixed the redirect loop" {
+		t.Errorf("Summary() = %q, %v, want the session's last reply", summary, err)
+	}
+
+	// A headless task that finished since
+	transcriptPath := filepath.Join(t.TempDir(), "run.md")
+	os.WriteFile(transcriptPath, []byte(transcriptHeader("proj-login", "Add tests")+"Added tests for the redirect\n"), 0644)
+	_, err := state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		run := st.EnqueueRun("proj-login", "Add tests")
+		run.Status, run.Transcript, run.FinishedAt = state.RunStatusSucceeded, transcriptPath, time.Now().Add(time.Minute)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary, err := Summary(cfg, "proj-login"); err != nil || summary != "Added tests for the redirect" {
+		t.Errorf("Summary() = %q, %v, want the task's output", summary, err)
+	}
+}
+
+func TestDailySummary(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	cfg := repo.Config(`name: proj
+storage_backend:
+    type: github
+    owner: owner
+    repo: repo
+    mirror: daily
+todos:
+    - description: Fix the login
+      status: pending
+      worktree: proj-login
+      github_url: https://github.com/owner/repo/issues/7
+`)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	gh := &runner.Fake{}
+	gh.On("gh api /repos/owner/repo/issues/7/comments", "{}", nil)
+	previous := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previous) })
+
+	// A session on the 13th, and one that carried on past midnight into the 14th
+	day := time.Date(2026, 10, 13, 0, 0, 0, 0, time.Local)
+	at := func(hours float64) string {
+		return day.Add(time.Duration(hours * float64(time.Hour))).UTC().Format(time.RFC3339)
+	}
+	logDir := filepath.Join(home, ".claude", "projects", strings.NewReplacer("/", "-", ".", "-").Replace(path))
+	os.MkdirAll(logDir, 0755)
+	os.WriteFile(filepath.Join(logDir, "morning.jsonl"), []byte(`{"type":"user","timestamp":"`+at(9)+`","message":{"role":"user","content":"Fix the login"}}
+{"type":"assistant","timestamp":"`+at(9.1)+`","message":{"role":"assistant","content":[{"type":"text","text":"Looking at the form"},{"type":"tool_use","name":"Edit","input":{"file_path":"`+filepath.Join(path, "login.go")+`"}}]}}
+{"type":"assistant","timestamp":"`+at(9.5)+`","message":{"role":"assistant","content":[{"type":"text","text":"Fixed the redirect loop by keeping the session cookie.\n\n- Should logout clear it too?"}]}}
+`), 0644)
+	os.WriteFile(filepath.Join(logDir, "late.jsonl"), []byte(`{"type":"user","timestamp":"`+at(23.5)+`","message":{"role":"user","content":"Now the tests"}}
+{"type":"assistant","timestamp":"`+at(23.6)+`","message":{"role":"assistant","content":[{"type":"tool_use","name":"Write","input":{"file_path":"`+filepath.Join(path, "login_test.go")+`"}}]}}
+{"type":"assistant","timestamp":"`+at(24.5)+`","message":{"role":"assistant","content":[{"type":"text","text":"Tests pass"}]}}
+`), 0644)
+
+	summary, err := SummarizeDay(cfg, "proj-login", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Sessions) != 2 || summary.Sessions[0].Messages != 3 || summary.Sessions[1].Messages != 1 {
+		t.Errorf("Sessions = %+v, want the morning's 3 messages and the evening's 1", summary.Sessions)
+	}
+	if got := strings.Join(summary.Files, " "); got != "login.go login_test.go" {
+		t.Errorf("Files = %s, want login.go login_test.go", got)
+	}
+	if len(summary.Decisions) != 1 || summary.Decisions[0] != "Fixed the redirect loop by keeping the session cookie." {
+		t.Errorf("Decisions = %q, want the morning's closing reply", summary.Decisions)
+	}
+	if len(summary.Questions) != 1 || summary.Questions[0] != "Should logout clear it too?" {
+		t.Errorf("Questions = %q, want the question the morning left open", summary.Questions)
+	}
+	for _, want := range []string{summaryMarker + " Tuesday 13 October 2026 in proj-login", "- 09:00–09:30 · 3 message(s)", "- `login.go`", "**Open questions**"} {
+		if !strings.Contains(summary.Markdown(), want) {
+			t.Errorf("Markdown() = %q, want it to contain %q", summary.Markdown(), want)
+		}
+	}
+	// Long summaries are cut between characters, not within one
+	for _, pad := range []string{"", "x", "xx"} {
+		long := &DaySummary{Worktree: "proj-login", Day: day, Questions: []string{pad + strings.Repeat("€", maxCommentLength)}}
+		if markdown := long.Markdown(); !utf8.ValidString(markdown) || !strings.HasSuffix(markdown, "€\n\n_(summary truncated)_") {
+			t.Errorf("Markdown() of a long summary ends %q, want it cut on a character", markdown[max(len(markdown)-40, 0):])
+		}
+	}
+
+	// Before summary_at nothing's due for the 14th, and the 13th is posted once
+	now := day.AddDate(0, 0, 1).Add(12 * time.Hour)
+	for range 2 {
+		posted, err := PostDailySummaries(cfg, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(gh.Calls()) != 1 || !strings.Contains(gh.Calls()[0].Stdin, "13 October") {
+			t.Fatalf("PostDailySummaries() posted %v, want the 13th's summary once", gh.Calls())
+		}
+		if len(posted) > 0 && posted[0] != "proj-login" {
+			t.Errorf("PostDailySummaries() = %v, want proj-login", posted)
+		}
+	}
+
+	// Once it's passed, the 14th's is
+	if _, err := PostDailySummaries(cfg, now.Add(7*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gh.Calls()) != 2 || !strings.Contains(gh.Calls()[1].Stdin, "Tests pass") {
+		t.Errorf("PostDailySummaries() posted %v, want the 14th's summary", gh.Calls())
+	}
+}
+
+func TestExecuteHeadless(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	cfg := repo.Config("name: proj\n")
+	claude := &runner.Fake{}
+	claude.On("claude -p", "Fixed the redirect loop\n", nil)
+	previous := SetRunner(claude)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	transcriptPath, output, err := executeHeadless("proj-login", path, "Fix the login", cfg)
+	if err != nil {
+		t.Fatalf("executeHeadless() error = %v", err)
+	}
+	calls := claude.Calls()
+	if len(calls) != 1 || calls[0].String() != "claude -p Fix the login --dangerously-skip-permissions" || calls[0].Dir != path {
+		t.Fatalf("executeHeadless() ran %+v, want claude given the prompt in the worktree", calls)
+	}
+	if output != "Fixed the redirect loop\n" {
+		t.Errorf("executeHeadless() output = %q, want the agent's", output)
+	}
+	if written, _ := os.ReadFile(transcriptPath); string(written) != transcriptHeader("proj-login", "Fix the login")+output {
+		t.Errorf("transcript = %q, want the header and the agent's output", written)
+	}
+}
+
+func TestMirrorKeepsOffsetUntilPosted(t *testing.T) {
+	repo := testrepo.New(t)
+	cfg := repo.Config(`name: proj
+storage_backend:
+    type: github
+    owner: owner
+    repo: repo
+`)
+	logPath := filepath.Join(t.TempDir(), "session.jsonl")
+	log := `{"type":"user","uuid":"u1","message":{"role":"user","content":"Fix the login"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}
+`
+	os.WriteFile(logPath, []byte(log), 0644)
+	m := &conversationMonitor{
+		cfg:             cfg,
+		worktreeName:    "proj-login",
+		mirrored:        make(map[string]bool),
+		target:          7,
+		targetCheckedAt: time.Now(),
+	}
+	offset := func() int64 {
+		st, err := state.Load(cfg.GetConfigPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.SessionOffset(logPath)
+	}
+
+	down := &runner.Fake{}
+	down.On("gh api", "", errors.New("HTTP 502"))
+	previous := github.SetRunner(down)
+	t.Cleanup(func() { github.SetRunner(previous) })
+	if err := m.readLog(logPath); err != nil {
+		t.Fatal(err)
+	}
+	m.sync(logPath)
+	if got := offset(); got != 0 || len(m.pending) != 2 {
+		t.Fatalf("after a failed post, offset = %d with %d pending, want 0 with both pending", got, len(m.pending))
+	}
+
+	up := &runner.Fake{}
+	up.On("gh api", "{}", nil)
+	github.SetRunner(up)
+	m.sync(logPath)
+	if got := offset(); got != int64(len(log)) || len(m.pending) != 0 {
+		t.Errorf("once posted, offset = %d with %d pending, want %d with none", got, len(m.pending), len(log))
+	}
+	if len(up.Calls()) != 2 {
+		t.Errorf("gh ran %v, want both messages posted", up.Calls())
+	}
+}
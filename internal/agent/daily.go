@@ -0,0 +1,319 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+const (
+	// maxSummaryDays bounds how far back missed summaries are caught up on, e.g. after a
+	// week without the daemon running
+	maxSummaryDays = 7
+
+	// maxDecisionLength bounds how much of each of the agent's closing replies a summary quotes
+	maxDecisionLength = 400
+)
+
+// fileTools are Claude's tools that change files, with the input naming the file changed
+var fileTools = map[string]string{
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"Write":        "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// DaySummary is a day of agent work in a worktree, gathered from its Claude session logs
+// and its headless runs' transcripts
+type DaySummary struct {
+	Worktree  string
+	Day       time.Time
+	Sessions  []SummarizedSession
+	Runs      []state.Run
+	Files     []string // Files the agent changed, relative to the worktree
+	Decisions []string // How each session and run ended, from the start of the agent's last reply
+	Questions []string // Questions the agent's last replies left open
+}
+
+// SummarizedSession is the part of an interactive session that fell on the summary's day
+type SummarizedSession struct {
+	Start    time.Time
+	End      time.Time
+	Messages int
+}
+
+// closingReply is the agent's last reply in a session or run, and when it ended
+type closingReply struct {
+	at   time.Time
+	text string
+}
+
+// Empty reports whether the agent did nothing in the worktree that day
+func (d *DaySummary) Empty() bool {
+	return len(d.Sessions) == 0 && len(d.Runs) == 0
+}
+
+// SummarizeDay gathers what the agent did in a worktree on the day, in the day's time zone
+func SummarizeDay(cfg *config.Config, worktreeName string, day time.Time) (*DaySummary, error) {
+	path, err := git.GetWorktreePath(worktreeName)
+	if err != nil {
+		return nil, err
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	summary := &DaySummary{Worktree: worktreeName, Day: day}
+	var replies []closingReply
+
+	logs, err := sessionLogs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, logPath := range logs {
+		session, reply, files, err := summarizeSession(logPath, path, day)
+		if err != nil {
+			return nil, err
+		}
+		if session.Messages == 0 {
+			continue
+		}
+		summary.Sessions = append(summary.Sessions, session)
+		summary.Files = append(summary.Files, files...)
+		if reply != "" {
+			replies = append(replies, closingReply{at: session.End, text: reply})
+		}
+	}
+
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range st.Runs {
+		if run.Worktree != worktreeName || !onDay(run.FinishedAt, day) {
+			continue
+		}
+		summary.Runs = append(summary.Runs, run)
+		// A transcript cleaned up since still leaves its run in the summary
+		if output, err := os.ReadFile(run.Transcript); err == nil && run.Status == state.RunStatusSucceeded {
+			text := strings.TrimSpace(strings.TrimPrefix(string(output), transcriptHeader(worktreeName, run.Prompt)))
+			replies = append(replies, closingReply{at: run.FinishedAt, text: text})
+		}
+	}
+
+	slices.SortFunc(summary.Sessions, func(a, b SummarizedSession) int { return a.Start.Compare(b.Start) })
+	slices.SortFunc(replies, func(a, b closingReply) int { return a.at.Compare(b.at) })
+	for _, reply := range replies {
+		summary.Decisions = append(summary.Decisions, decision(reply.text))
+		summary.Questions = append(summary.Questions, questions(reply.text)...)
+	}
+	slices.Sort(summary.Files)
+	summary.Files = slices.Compact(summary.Files)
+	return summary, nil
+}
+
+// summarizeSession reads the part of a session log that fell on the day: its messages, the
+// agent's last reply and the files it changed
+func summarizeSession(logPath, worktreePath string, day time.Time) (SummarizedSession, string, []string, error) {
+	var session SummarizedSession
+	var reply string
+	var files []string
+	file, err := os.Open(logPath)
+	if err != nil {
+		return session, "", nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadString('\n')
+		var entry JSONLEntry
+		if json.Unmarshal([]byte(line), &entry) == nil && (entry.Type == "user" || entry.Type == "assistant") {
+			at, err := time.Parse(time.RFC3339, entry.Timestamp)
+			if err == nil && onDay(at, day) {
+				at = at.In(day.Location())
+				if text := entryText(entry); text != "" {
+					if session.Messages == 0 {
+						session.Start = at
+					}
+					session.End = at
+					session.Messages++
+					if entry.Type == "assistant" {
+						reply = text
+					}
+				}
+				files = append(files, changedFiles(entry, worktreePath)...)
+			}
+		}
+		if readErr != nil {
+			return session, strings.TrimSpace(reply), files, nil
+		}
+	}
+}
+
+// changedFiles lists the files an assistant entry's tool uses changed, relative to the
+// worktree when they're in it
+func changedFiles(entry JSONLEntry, worktreePath string) []string {
+	var blocks []ContentBlock
+	if entry.Type != "assistant" || json.Unmarshal(entry.Message.Content, &blocks) != nil {
+		return nil
+	}
+	var files []string
+	for _, block := range blocks {
+		field, ok := fileTools[block.Name]
+		if block.Type != "tool_use" || !ok {
+			continue
+		}
+		var input map[string]any
+		if json.Unmarshal(block.Input, &input) != nil {
+			continue
+		}
+		path, _ := input[field].(string)
+		if path == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(worktreePath, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+// onDay reports whether t falls on the day starting at midnight day
+func onDay(t, day time.Time) bool {
+	return !t.Before(day) && t.Before(day.AddDate(0, 0, 1))
+}
+
+// decision is the first paragraph of a closing reply, where the agent usually says what it did
+func decision(reply string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(reply), "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if runes := []rune(paragraph); len(runes) > maxDecisionLength {
+		paragraph = string(runes[:maxDecisionLength]) + "…"
+	}
+	return paragraph
+}
+
+// questions picks the lines of a closing reply that ask something
+func questions(reply string) []string {
+	var asked []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if strings.HasSuffix(line, "?") {
+			asked = append(asked, line)
+		}
+	}
+	return asked
+}
+
+// Markdown is the summary as it's posted on the worktree's issue
+func (d *DaySummary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s in %s\n\n**Sessions**\n\n", summaryMarker, d.Day.Format("Monday 2 January 2006"), d.Worktree)
+	for _, session := range d.Sessions {
+		fmt.Fprintf(&b, "- %s–%s · %d message(s)\n", session.Start.Format("15:04"), session.End.Format("15:04"), session.Messages)
+	}
+	for _, run := range d.Runs {
+		fmt.Fprintf(&b, "- %s · task %q %s\n", run.FinishedAt.In(d.Day.Location()).Format("15:04"), run.Prompt, run.Status)
+	}
+	writeSection(&b, "Key decisions", d.Decisions, "%s")
+	writeSection(&b, "Files touched", d.Files, "`%s`")
+	writeSection(&b, "Open questions", d.Questions, "%s")
+
+	body := strings.TrimSpace(b.String())
+	if runes := []rune(body); len(runes) > maxCommentLength {
+		body = string(runes[:maxCommentLength]) + "\n\n_(summary truncated)_"
+	}
+	return body
+}
+
+// writeSection adds a list to a summary under its heading, when it has anything in it
+func writeSection(b *strings.Builder, heading string, lines []string, format string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n**%s**\n\n", heading)
+	for _, line := range lines {
+		fmt.Fprintf(b, "- "+format+"\n", line)
+	}
+}
+
+// PostDailySummaries posts the summaries due at now on the issues of the worktrees' todos
+// when mirror is daily: one for each day since a worktree was last summed up, up to a week
+// back, that the agent worked in it. It returns the worktrees it posted for.
+func PostDailySummaries(cfg *config.Config, now time.Time) ([]string, error) {
+	backend := cfg.StorageBackend
+	if !backend.IsGitHub() || !backend.MirrorsDaily() {
+		return nil, nil
+	}
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	due := backend.SummaryDay(now)
+	var posted []string
+	var errs []error
+	for _, todo := range cfg.Todos {
+		issueNumber, err := github.IssueNumberFromURL(todo.GitHubURL)
+		if todo.Worktree == "" || err != nil {
+			continue
+		}
+		// The agent only worked here in worktrees checked out here
+		if _, err := git.GetWorktreePath(todo.Worktree); err != nil {
+			continue
+		}
+
+		// A worktree that's never been summed up starts with the latest day, rather than
+		// with everything done before summaries were turned on
+		from := due
+		if last, err := time.ParseInLocation(time.DateOnly, st.Summarized[todo.Worktree], now.Location()); err == nil {
+			from = last.AddDate(0, 0, 1)
+			if earliest := due.AddDate(0, 0, 1-maxSummaryDays); from.Before(earliest) {
+				from = earliest
+			}
+		}
+
+		didPost := false
+		for day := from; !day.After(due); day = day.AddDate(0, 0, 1) {
+			ok, err := postSummary(cfg, todo.Worktree, issueNumber, day)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", todo.Worktree, err))
+				break
+			}
+			didPost = didPost || ok
+		}
+		if didPost {
+			posted = append(posted, todo.Worktree)
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+// postSummary posts a worktree's summary for the day on its issue, unless the agent did
+// nothing there that day, and records the day as summed up. It reports whether it posted.
+func postSummary(cfg *config.Config, worktree string, issueNumber int, day time.Time) (bool, error) {
+	summary, err := SummarizeDay(cfg, worktree, day)
+	if err != nil {
+		return false, err
+	}
+	if !summary.Empty() {
+		if err := github.CreateIssueComment(cfg.StorageBackend.Owner, cfg.StorageBackend.Repo, issueNumber, summary.Markdown()); err != nil {
+			return false, err
+		}
+	}
+	_, err = state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		st.SetSummarized(worktree, day.Format(time.DateOnly))
+		return nil
+	})
+	return !summary.Empty(), err
+}
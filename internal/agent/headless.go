@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/notify"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/transcript"
+	"github.com/markcipolla/lfg/internal/webhook"
+)
+
+// maxCommentLength keeps posted output under GitHub's 65536 character comment limit
+const maxCommentLength = 60000
+
+// errQueueEmpty stops a state update when the worktree has no tasks left to run
+var errQueueEmpty = errors.New("no queued tasks")
+
+// RunHeadless queues a non-interactive agent task for a worktree and works through
+// the worktree's queue. If another process is already running tasks for the worktree,
+// the task is left queued for that process to pick up.
+func RunHeadless(worktreeName, prompt string, cfg *config.Config) error {
+	if err := cfg.RequireLocal("lfg run"); err != nil {
+		return err
+	}
+	worktreePath, err := git.GetWorktreePath(worktreeName)
+	if err != nil {
+		return err
+	}
+
+	var run state.Run
+	var queuedBehind int
+	_, err = state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		st.EnqueueRun(worktreeName, prompt)
+		if next := st.StartNextRun(worktreeName, os.Getpid()); next != nil {
+			run = *next
+		} else {
+			queuedBehind = st.CountQueuedRuns(worktreeName)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if queuedBehind > 0 {
+		fmt.Printf("Queued behind a running task for %s (%d waiting)\n", worktreeName, queuedBehind)
+		return nil
+	}
+
+	// Work through the queue until it's empty, picking up tasks queued by other invocations
+	for {
+		fmt.Printf("▶ Running task in %s: %s\n", worktreeName, run.Prompt)
+		transcriptPath, output, runErr := executeHeadless(worktreeName, worktreePath, run.Prompt, cfg)
+
+		finishedAt := time.Now()
+		_, err = state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+			if saved := st.GetRun(run.ID); saved != nil {
+				saved.FinishedAt = finishedAt
+				saved.PID = 0
+				saved.Transcript = transcriptPath
+				if runErr != nil {
+					saved.Status = state.RunStatusFailed
+					saved.Error = runErr.Error()
+				} else {
+					saved.Status = state.RunStatusSucceeded
+				}
+			}
+			st.PruneRuns()
+			return nil
+		})
+		title := "Task finished"
+		if runErr != nil {
+			title = "Task failed"
+			fmt.Fprintf(os.Stderr, "✗ Task failed: %v\n", runErr)
+		} else {
+			fmt.Printf("✓ Task finished in %s\n", finishedAt.Sub(run.StartedAt).Round(time.Second))
+		}
+		outcome := "finished"
+		if runErr != nil {
+			outcome = "failed"
+		}
+		core.New(cfg).Record(core.Event{Kind: core.AgentFinished, Worktree: worktreeName, Detail: fmt.Sprintf("%s the task %q", outcome, run.Prompt)})
+		if err := notify.Send(cfg, config.NotifyAgent, title, fmt.Sprintf("%s: %s", worktreeName, run.Prompt)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		webhook.Send(cfg, webhook.Event{
+			Kind:     config.WebhookAgentFinished,
+			Worktree: worktreeName,
+			Title:    run.Prompt,
+			Text:     fmt.Sprintf("%s: %s in %s: %s", cfg.Name, strings.ToLower(title), worktreeName, run.Prompt),
+		})
+		if err != nil {
+			return err
+		}
+
+		postRunToIssue(worktreeName, run.Prompt, output, runErr, cfg)
+
+		// Other invocations may have queued more tasks meanwhile
+		_, err = state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+			next := st.StartNextRun(worktreeName, os.Getpid())
+			if next == nil {
+				return errQueueEmpty
+			}
+			run = *next
+			return nil
+		})
+		if errors.Is(err, errQueueEmpty) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// transcriptHeader starts a headless run's transcript, before the agent's output
+func transcriptHeader(worktreeName, prompt string) string {
+	return fmt.Sprintf("# Headless run: %s\n\n**Prompt:** %s\n\n", worktreeName, prompt)
+}
+
+// executeHeadless runs the agent non-interactively in the worktree, streaming its
+// output to stdout and a new transcript file
+func executeHeadless(worktreeName, worktreePath, prompt string, cfg *config.Config) (string, string, error) {
+	file, err := transcript.Create(cfg.GetConfigPath(), worktreeName)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	fmt.Fprint(file, transcriptHeader(worktreeName, prompt))
+
+	var output strings.Builder
+	// The agent gets the worktree's ports, shared caches and environment, as its session's
+	// panes do
+	ports, err := state.AssignPorts(cfg.GetConfigPath(), cfg.Ports, worktreeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	caches, err := cfg.CacheEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	environment, err := cfg.WorktreeEnv(worktreeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	job := runner.Job{
+		Dir:    worktreePath,
+		Env:    append(os.Environ(), caches...),
+		Stdout: io.MultiWriter(os.Stdout, file, &output),
+		Stderr: io.MultiWriter(os.Stderr, file),
+	}
+	for _, port := range ports {
+		job.Env = append(job.Env, port.Env())
+	}
+	job.Env = append(job.Env, environment...)
+
+	claude, err := launchClaude(job, "-p", prompt, "--dangerously-skip-permissions")
+	if err == nil {
+		err = claude.Wait()
+	}
+	return file.Name(), output.String(), err
+}
+
+// postRunToIssue mirrors a finished headless run to the worktree's linked issue, or its PR
+func postRunToIssue(worktreeName, prompt, output string, runErr error, cfg *config.Config) {
+	// Runs are summed up with the rest of the day's work when mirroring is daily
+	if !cfg.StorageBackend.IsGitHub() || cfg.StorageBackend.MirrorsDaily() {
+		return
+	}
+
+	todo := cfg.GetTodoForWorktree(worktreeName)
+	if todo == nil {
+		return
+	}
+
+	issueNumber, err := github.IssueNumberFromURL(todo.GitHubURL)
+	if err != nil {
+		return
+	}
+	target := issueNumber
+	if worktreePath, err := git.GetWorktreePath(worktreeName); err == nil {
+		target = mirrorTarget(cfg, issueNumber, worktreePath)
+	}
+
+	output = strings.TrimSpace(output)
+	if runes := []rune(output); len(runes) > maxCommentLength {
+		output = string(runes[:maxCommentLength]) + "\n\n_(output truncated)_"
+	}
+	if runErr != nil {
+		output = fmt.Sprintf("%s\n\n_Headless run failed: %v_", output, runErr)
+	}
+
+	// Use the same markers as the interactive monitor so context loading understands them
+	for _, body := range []string{
+		fmt.Sprintf("%s %s", userMarker, prompt),
+		fmt.Sprintf("%s %s", claudeMarker, output),
+	} {
+		if err := github.CreateIssueComment(
+			cfg.StorageBackend.Owner,
+			cfg.StorageBackend.Repo,
+			target,
+			body,
+		); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to post comment to GitHub: %v\n", err)
+			return
+		}
+	}
+}
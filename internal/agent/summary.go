@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Summary is the agent's last reply in a worktree, where it usually sums up what it did:
+// from its latest interactive session or its latest headless task, whichever ended last.
+// It's "" when the agent hasn't replied there.
+func Summary(cfg *config.Config, worktreeName string) (string, error) {
+	path, err := git.GetWorktreePath(worktreeName)
+	if err != nil {
+		return "", err
+	}
+
+	var summary string
+	var at time.Time
+	// Without a session log the agent hasn't been run interactively here
+	if logPath, err := latestSession(path); err == nil {
+		info, err := os.Stat(logPath)
+		if err != nil {
+			return "", err
+		}
+		if summary, err = lastReply(logPath); err != nil {
+			return "", err
+		}
+		at = info.ModTime()
+	}
+
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return "", err
+	}
+	run := st.LatestRun(worktreeName)
+	if run == nil || run.Status != state.RunStatusSucceeded || run.Transcript == "" || run.FinishedAt.Before(at) {
+		return summary, nil
+	}
+	output, err := os.ReadFile(run.Transcript)
+	if err != nil {
+		return "", fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(output), transcriptHeader(worktreeName, run.Prompt))), nil
+}
+
+// lastReply is the text of the last assistant message in a Claude session log
+func lastReply(logPath string) (string, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var reply string
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		var entry JSONLEntry
+		if json.Unmarshal([]byte(line), &entry) == nil && entry.Type == "assistant" {
+			if text := entryText(entry); text != "" {
+				reply = text
+			}
+		}
+		if err != nil {
+			return strings.TrimSpace(reply), nil
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package bootstrap
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// installer is the install step for a kind of project, found by a file in its root
+type installer struct {
+	marker  string
+	command string
+	// Lock files that pick a different command, checked in order
+	locks []lock
+}
+
+type lock struct {
+	file    string
+	command string
+}
+
+// installers are the project kinds Detect knows, in the order their steps run
+var installers = []installer{
+	{marker: "package.json", command: "npm install", locks: []lock{
+		{file: "pnpm-lock.yaml", command: "pnpm install --frozen-lockfile"},
+		{file: "yarn.lock", command: "yarn install --frozen-lockfile"},
+		{file: "bun.lockb", command: "bun install --frozen-lockfile"},
+		{file: "package-lock.json", command: "npm ci"},
+	}},
+	{marker: "go.mod", command: "go mod download"},
+	{marker: "Cargo.toml", command: "cargo fetch"},
+	{marker: "Gemfile", command: "bundle install"},
+}
+
+// Detect returns the command that installs the dependencies of the project in dir, "" if
+// it isn't a kind of project lfg knows. Projects of more than one kind install each.
+func Detect(dir string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	var steps []string
+	for _, kind := range installers {
+		if !exists(kind.marker) {
+			continue
+		}
+		command := kind.command
+		for _, lock := range kind.locks {
+			if exists(lock.file) {
+				command = lock.command
+				break
+			}
+		}
+		steps = append(steps, command)
+	}
+	return strings.Join(steps, " && ")
+}
+
+// Command returns the configured bootstrap command for the worktree in dir, detecting it
+// when the config says "auto"
+func Command(cfg *config.Config, dir string) string {
+	if cfg.Bootstrap == "auto" {
+		return Detect(dir)
+	}
+	return cfg.Bootstrap
+}
+
+// LogPath is where a worktree's bootstrap output is written
+func LogPath(cfg *config.Config, worktree string) string {
+	return filepath.Join(state.Dir(cfg.GetConfigPath()), "logs", "bootstrap-"+worktree+".log")
+}
+
+// Start runs a new worktree's bootstrap with `lfg bootstrap` in a process of its own, so it
+// carries on after the TUI has exited or attached to the worktree's session. Worktrees with
+// nothing to install are left alone. env is added to its environment, for the worktree's
+// environment from the config.
+func Start(cfg *config.Config, worktree string, env []string) error {
+	if err := cfg.RequireLocal("Bootstrapping"); err != nil {
+		return err
+	}
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return err
+	}
+	command := Command(cfg, path)
+	if command == "" {
+		return nil
+	}
+	// Queued until the process starts running it, so the TUI shows it straight away
+	queued := state.Bootstrap{Command: command, Status: state.RunStatusQueued, StartedAt: time.Now()}
+	if err := record(cfg, worktree, queued); err != nil {
+		return err
+	}
+
+	lfgPath := "lfg"
+	if absPath, err := exec.LookPath("lfg"); err == nil {
+		lfgPath = absPath
+	} else if self, err := os.Executable(); err == nil {
+		lfgPath = self // Installed as gh lfg, lfg needn't be on PATH
+	}
+	cmd := exec.Command(lfgPath, "bootstrap", "--config", cfg.GetConfigPath(), worktree)
+	// The worktree may not have its todo yet to say which environment it has
+	cmd.Env = append(os.Environ(), env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start bootstrap: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// Run installs a worktree's dependencies, writing the output to stdout and its log and
+// recording its progress in the state for the TUI
+func Run(cfg *config.Config, worktree string) error {
+	if err := cfg.RequireLocal("lfg bootstrap"); err != nil {
+		return err
+	}
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return err
+	}
+	command := Command(cfg, path)
+	if command == "" {
+		fmt.Println("Nothing to bootstrap: no package.json, go.mod, Cargo.toml or Gemfile")
+		// The project may have changed since Start found something to install
+		_, err := state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+			delete(st.Bootstraps, worktree)
+			return nil
+		})
+		return err
+	}
+
+	logPath := LogPath(cfg, worktree)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	log, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create bootstrap log: %w", err)
+	}
+	defer log.Close()
+
+	progress := state.Bootstrap{Command: command, Status: state.RunStatusRunning, StartedAt: time.Now(), Log: logPath}
+	if err := record(cfg, worktree, progress); err != nil {
+		return err
+	}
+
+	environment, err := cfg.WorktreeEnv(worktree)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Fprintf(log, "$ %s\n", command)
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = path
+	cmd.Env = append(os.Environ(), environment...)
+	cmd.Stdout = io.MultiWriter(os.Stdout, log)
+	cmd.Stderr = io.MultiWriter(os.Stderr, log)
+	runErr := cmd.Run()
+
+	progress.FinishedAt = time.Now()
+	progress.Status = state.RunStatusSucceeded
+	if runErr != nil {
+		progress.Status = state.RunStatusFailed
+		progress.Error = runErr.Error()
+	}
+	if err := record(cfg, worktree, progress); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s failed: %w", command, runErr)
+	}
+	return nil
+}
+
+// record saves a bootstrap's progress
+func record(cfg *config.Config, worktree string, progress state.Bootstrap) error {
+	_, err := state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		st.SetBootstrap(worktree, progress)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,41 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "nothing to install", files: []string{"README.md"}, expected: ""},
+		{name: "npm without a lock file", files: []string{"package.json"}, expected: "npm install"},
+		{name: "npm", files: []string{"package.json", "package-lock.json"}, expected: "npm ci"},
+		{name: "pnpm", files: []string{"package.json", "pnpm-lock.yaml"}, expected: "pnpm install --frozen-lockfile"},
+		{name: "yarn", files: []string{"package.json", "yarn.lock"}, expected: "yarn install --frozen-lockfile"},
+		{name: "bun", files: []string{"package.json", "bun.lockb"}, expected: "bun install --frozen-lockfile"},
+		{name: "lock file without package.json", files: []string{"yarn.lock"}, expected: ""},
+		{name: "go", files: []string{"go.mod"}, expected: "go mod download"},
+		{name: "rust", files: []string{"Cargo.toml"}, expected: "cargo fetch"},
+		{name: "ruby", files: []string{"Gemfile"}, expected: "bundle install"},
+		{name: "several kinds", files: []string{"Gemfile", "package.json", "yarn.lock"}, expected: "yarn install --frozen-lockfile && bundle install"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", file, err)
+				}
+			}
+			if got := Detect(dir); got != tt.expected {
+				t.Errorf("Detect() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
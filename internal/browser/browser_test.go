@@ -0,0 +1,30 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenCommand(t *testing.T) {
+	url := "https://github.com/owner/repo/issues/1"
+
+	tests := []struct {
+		goos         string
+		expectedName string
+		expectedArgs []string
+	}{
+		{goos: "darwin", expectedName: "open", expectedArgs: []string{url}},
+		{goos: "windows", expectedName: "rundll32", expectedArgs: []string{"url.dll,FileProtocolHandler", url}},
+		{goos: "linux", expectedName: "xdg-open", expectedArgs: []string{url}},
+		{goos: "freebsd", expectedName: "xdg-open", expectedArgs: []string{url}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			name, args := openCommand(tt.goos, url)
+			if name != tt.expectedName || !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("openCommand(%q) = %s %v, want %s %v", tt.goos, name, args, tt.expectedName, tt.expectedArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package checklist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Item is a task list entry in markdown, e.g. "- [x] Write tests"
+type Item struct {
+	Text    string
+	Checked bool
+	line    int // Index of the item's line in the markdown
+}
+
+// itemPattern matches a task list line, capturing everything up to the box, the box's mark,
+// and the text after it
+var itemPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\](\s+.*)$`)
+
+// Parse returns the task list items in markdown, in order. Items in code blocks don't count.
+func Parse(markdown string) []Item {
+	var items []Item
+	inFence := false
+	for idx, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if match := itemPattern.FindStringSubmatch(line); match != nil {
+			items = append(items, Item{
+				Text:    strings.TrimSpace(match[3]),
+				Checked: match[2] != " ",
+				line:    idx,
+			})
+		}
+	}
+	return items
+}
+
+// Progress counts the checked items and all of them
+func Progress(items []Item) (checked, total int) {
+	for _, item := range items {
+		if item.Checked {
+			checked++
+		}
+	}
+	return checked, len(items)
+}
+
+// Set checks or unchecks the index'th item, leaving the rest of the markdown untouched
+func Set(markdown string, index int, checked bool) (string, error) {
+	return rewriteItem(markdown, index, func(match []string) string {
+		mark := " "
+		if checked {
+			mark = "x"
+		}
+		return match[1] + mark + "]" + match[3]
+	})
+}
+
+// Highlight puts a marker in front of the index'th item's text, so it stands out once rendered
+func Highlight(markdown string, index int, marker string) string {
+	highlighted, err := rewriteItem(markdown, index, func(match []string) string {
+		return match[1] + match[2] + "] " + marker + strings.TrimLeft(match[3], " \t")
+	})
+	if err != nil {
+		return markdown
+	}
+	return highlighted
+}
+
+func rewriteItem(markdown string, index int, rewrite func(match []string) string) (string, error) {
+	items := Parse(markdown)
+	if index < 0 || index >= len(items) {
+		return "", fmt.Errorf("checklist has no item %d", index+1)
+	}
+
+	lines := strings.Split(markdown, "\n")
+	line := lines[items[index].line]
+	cr := strings.HasSuffix(line, "\r")
+	match := itemPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+	line = rewrite(match)
+	if cr {
+		line += "\r"
+	}
+	lines[items[index].line] = line
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,74 @@
+package checklist
+
+import (
+	"reflect"
+	"testing"
+)
+
+const body = "Acceptance criteria:\r\n\r\n- [ ] Login works\r\n- [x] Logout works\r\n  * [X] Nested\r\n1. [ ] Numbered\r\n\r\n```\r\n- [ ] Not a task\r\n```\r\n- [] Not a box either\r\n"
+
+func TestParse(t *testing.T) {
+	expected := []Item{
+		{Text: "Login works", Checked: false, line: 2},
+		{Text: "Logout works", Checked: true, line: 3},
+		{Text: "Nested", Checked: true, line: 4},
+		{Text: "Numbered", Checked: false, line: 5},
+	}
+	if items := Parse(body); !reflect.DeepEqual(items, expected) {
+		t.Errorf("Parse() = %+v, want %+v", items, expected)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    int
+		checked  bool
+		expected string
+	}{
+		{
+			name:     "check",
+			index:    0,
+			checked:  true,
+			expected: "Acceptance criteria:\r\n\r\n- [x] Login works\r\n- [x] Logout works\r\n  * [X] Nested\r\n1. [ ] Numbered\r\n\r\n```\r\n- [ ] Not a task\r\n```\r\n- [] Not a box either\r\n",
+		},
+		{
+			name:     "uncheck nested",
+			index:    2,
+			checked:  false,
+			expected: "Acceptance criteria:\r\n\r\n- [ ] Login works\r\n- [x] Logout works\r\n  * [ ] Nested\r\n1. [ ] Numbered\r\n\r\n```\r\n- [ ] Not a task\r\n```\r\n- [] Not a box either\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Set(body, tt.index, tt.checked)
+			if err != nil {
+				t.Fatalf("Set() unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Set() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Set(body, 4, true); err == nil {
+		t.Error("Set() past the last item expected error, got nil")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	result := Highlight("- [ ] One\n- [x] Two\n", 1, "▸ ")
+	if expected := "- [ ] One\n- [x] ▸ Two\n"; result != expected {
+		t.Errorf("Highlight() = %q, want %q", result, expected)
+	}
+	if result := Highlight("no tasks", 0, "▸ "); result != "no tasks" {
+		t.Errorf("Highlight() without items = %q, want the markdown unchanged", result)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	if checked, total := Progress(Parse(body)); checked != 2 || total != 4 {
+		t.Errorf("Progress() = %d/%d, want 2/4", checked, total)
+	}
+}
@@ -0,0 +1,88 @@
+package clipboard
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// commands runs the clipboard tools, and is swapped out by tests
+var commands runner.Runner = runner.Exec{Timeout: 5 * time.Second}
+
+// SetRunner makes the package run clipboard tools through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// Copy puts text on the system clipboard. Over SSH the local machine's clipboard is
+// reached through the terminal with an OSC 52 escape sequence, via tmux when inside it.
+func Copy(text string, terminal io.Writer) error {
+	remote := os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+	if !remote {
+		for _, tool := range clipboardTools(runtime.GOOS, os.Getenv("WAYLAND_DISPLAY") != "") {
+			if _, err := exec.LookPath(tool[0]); err != nil {
+				continue
+			}
+			if err := copyWith(text, tool[0], tool[1:]...); err == nil {
+				return nil
+			}
+		}
+	}
+
+	// tmux forwards its buffer to the outer terminal's clipboard with -w
+	if os.Getenv("TMUX") != "" {
+		if err := copyWith(text, "tmux", "load-buffer", "-w", "-"); err == nil {
+			return nil
+		}
+	}
+
+	if _, err := io.WriteString(terminal, osc52(text)); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// copyWith feeds text to a clipboard command. xclip stays behind to serve the clipboard,
+// holding its output open, which still counts as copied.
+func copyWith(text, name string, args ...string) error {
+	_, err := commands.Run(context.Background(), strings.NewReader(text), name, args...)
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return nil
+	}
+	return err
+}
+
+// clipboardTools lists the platform's clipboard commands to try, in order
+func clipboardTools(goos string, wayland bool) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+
+	tools := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if wayland {
+		tools = append([][]string{{"wl-copy"}}, tools...)
+	}
+	return tools
+}
+
+// osc52 is the escape sequence asking the terminal to set its clipboard to text
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
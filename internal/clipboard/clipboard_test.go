@@ -0,0 +1,64 @@
+package clipboard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+func TestClipboardTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		wayland  bool
+		expected []string
+	}{
+		{name: "macOS", goos: "darwin", expected: []string{"pbcopy"}},
+		{name: "Windows", goos: "windows", expected: []string{"clip"}},
+		{name: "X11", goos: "linux", expected: []string{"xclip", "xsel"}},
+		{name: "Wayland", goos: "linux", wayland: true, expected: []string{"wl-copy", "xclip", "xsel"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tool := range clipboardTools(tt.goos, tt.wayland) {
+				got = append(got, tool[0])
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("clipboardTools(%q, %v) = %v, want %v", tt.goos, tt.wayland, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	got := osc52("feature-branch")
+	expected := "\x1b]52;c;ZmVhdHVyZS1icmFuY2g=\a"
+	if got != expected {
+		t.Errorf("osc52() = %q, want %q", got, expected)
+	}
+}
+
+func TestCopyInTmux(t *testing.T) {
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	fake := &runner.Fake{}
+	fake.On("tmux load-buffer", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	var terminal strings.Builder
+	if err := Copy("feature/login", &terminal); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].String() != "tmux load-buffer -w -" || calls[0].Stdin != "feature/login" {
+		t.Errorf("Copy() ran %v, want the text loaded into tmux's buffer", calls)
+	}
+	if terminal.Len() != 0 {
+		t.Errorf("Copy() wrote %q to the terminal, want nothing once tmux has it", terminal.String())
+	}
+}
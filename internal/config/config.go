@@ -2,12 +2,18 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/markcipolla/lfg/internal/sharedfile"
 )
 
 type TodoStatus string
@@ -23,6 +29,31 @@ type Todo struct {
 	Worktree    string     `yaml:"worktree,omitempty"`
 	GitHubBody  string     `yaml:"github_body,omitempty"`
 	GitHubURL   string     `yaml:"github_url,omitempty"`
+	Notes       string     `yaml:"notes,omitempty"`       // Free-form notes, edited from the TUI
+	Layout      string     `yaml:"layout,omitempty"`      // Name of the entry in Layouts to open the worktree with
+	Environment string     `yaml:"environment,omitempty"` // Name of the entry in Environments the worktree's panes and commands get
+	Priority    string     `yaml:"priority,omitempty"`    // e.g. "High": the board's Priority options, or High, Medium and Low
+	Due         string     `yaml:"due,omitempty"`         // Due date as YYYY-MM-DD
+	Tags        []string   `yaml:"tags,omitempty"`        // Free-form tags, kept as the issue's labels when it has one
+	BlockedBy   []string   `yaml:"blocked_by,omitempty"`  // Worktrees and issues (#12) to finish before this one is started
+	CreatedAt   time.Time  `yaml:"created_at,omitempty"`
+	StartedAt   time.Time  `yaml:"started_at,omitempty"` // When work on it began in a worktree
+	CompletedAt time.Time  `yaml:"completed_at,omitempty"`
+}
+
+// SetStatus changes a todo's status, recording when it was completed, and started if
+// that wasn't recorded when its worktree was made
+func (t *Todo) SetStatus(status TodoStatus) {
+	now := time.Now()
+	if status == TodoStatusDone && t.Status != TodoStatusDone {
+		t.CompletedAt = now
+	} else if status != TodoStatusDone {
+		t.CompletedAt = time.Time{}
+	}
+	if t.StartedAt.IsZero() && t.Worktree != "" {
+		t.StartedAt = now
+	}
+	t.Status = status
 }
 
 type TmuxWindow struct {
@@ -31,51 +62,504 @@ type TmuxWindow struct {
 }
 
 type Pane struct {
-	Name    string  `yaml:"name"`
-	Width   string  `yaml:"width,omitempty"`   // e.g. "50%", "33%"
-	Command *string `yaml:"command,omitempty"`
+	Name     string  `yaml:"name"`
+	Width    string  `yaml:"width,omitempty"`     // e.g. "50%", "33%", or a number of columns like "40"
+	MinWidth int     `yaml:"min_width,omitempty"` // Fewest columns a percentage or even share may give it
+	MaxWidth int     `yaml:"max_width,omitempty"` // Most columns a percentage or even share may give it
+	Command  *string `yaml:"command,omitempty"`
 }
 
 type LayoutRow struct {
-	Height  string  `yaml:"height"`            // Height as percentage of work area (excluding description and agent panes)
-	Name    string  `yaml:"name,omitempty"`    // For single-pane rows
-	Command *string `yaml:"command,omitempty"` // For single-pane rows
-	Panes   []Pane  `yaml:"panes,omitempty"`   // For multi-pane rows (split horizontally)
+	Height    string  `yaml:"height"`               // Height as percentage of work area (excluding description and agent panes), or a number of lines like "3"
+	MinHeight int     `yaml:"min_height,omitempty"` // Fewest lines a percentage or even share may give it
+	MaxHeight int     `yaml:"max_height,omitempty"` // Most lines a percentage or even share may give it
+	Name      string  `yaml:"name,omitempty"`       // For single-pane rows
+	Command   *string `yaml:"command,omitempty"`    // For single-pane rows
+	Panes     []Pane  `yaml:"panes,omitempty"`      // For multi-pane rows (split horizontally)
 }
 
 type StorageBackend struct {
-	Type          string `yaml:"type"` // "local" or "github"
-	Owner         string `yaml:"owner,omitempty"`
-	Repo          string `yaml:"repo,omitempty"`
-	ProjectNumber int    `yaml:"project_number,omitempty"`
+	Type            string            `yaml:"type"` // "local", "github" (a Projects board), "github-issues" (issues and a label), "org" or "plugin"
+	Owner           string            `yaml:"owner,omitempty"`
+	Repo            string            `yaml:"repo,omitempty"`
+	ProjectNumber   int               `yaml:"project_number,omitempty"`
+	MirrorTo        string            `yaml:"mirror_to,omitempty"`         // "pr" (default, falls back to the issue) or "issue"
+	Mirror          string            `yaml:"mirror,omitempty"`            // "live" (default) mirrors conversation as it happens, "daily" posts a summary a day instead
+	SummaryAt       string            `yaml:"summary_at,omitempty"`        // With mirror daily, when the day's summary is posted, "18:00" unless set
+	InProgressLabel string            `yaml:"in_progress_label,omitempty"` // Label marking issues in progress with github-issues
+	Plugin          string            `yaml:"plugin,omitempty"`            // With type plugin, lfg runs lfg-backend-<plugin>
+	Options         map[string]string `yaml:"options,omitempty"`           // Passed to the plugin with every request
+	File            string            `yaml:"file,omitempty"`              // With type org, the org file, relative to the repository root
+	AutoStatus      *AutoStatus       `yaml:"auto_status,omitempty"`       // Which moves lfg makes on its own as items' worktrees are created and found
+}
+
+// AutoStatus sets the move lfg makes on its own when an item has a worktree: to In Progress
+// from any status but Done, unless it says otherwise
+type AutoStatus struct {
+	Enabled *bool    `yaml:"enabled,omitempty"` // Move items at all, true unless set to false
+	To      string   `yaml:"to,omitempty"`      // The status items are moved to, "In Progress" unless set
+	From    []string `yaml:"from,omitempty"`    // The only statuses items are moved from, e.g. [Todo]
+}
+
+// defaultAutoStatus is where items are moved when auto_status doesn't say
+const defaultAutoStatus = "In Progress"
+
+// Move returns the status an item in status is moved to once it has a worktree, and whether
+// it's moved at all
+func (a *AutoStatus) Move(status string) (string, bool) {
+	to := defaultAutoStatus
+	if a != nil && a.To != "" {
+		to = a.To
+	}
+	switch {
+	case a != nil && a.Enabled != nil && !*a.Enabled, status == to:
+		return to, false
+	case a != nil && len(a.From) > 0:
+		return to, slices.Contains(a.From, status)
+	}
+	return to, status != "Done"
+}
+
+// MirrorToPR reports whether agent conversation should go to the worktree's open PR when one exists
+func (b *StorageBackend) MirrorToPR() bool {
+	return b.MirrorTo != "issue"
+}
+
+// Ways of mirroring agent conversation
+const (
+	MirrorLive  = "live"
+	MirrorDaily = "daily"
+)
+
+const defaultSummaryAt = "18:00"
+
+// MirrorsDaily reports whether agent conversation is summed up on issues once a day rather
+// than mirrored as it happens
+func (b *StorageBackend) MirrorsDaily() bool {
+	return b.Mirror == MirrorDaily
+}
+
+// SummaryDay is the latest day whose summary is due at now: today once summary_at has
+// passed, yesterday before then. A summary_at that isn't a time like 18:00 is taken as 18:00.
+func (b *StorageBackend) SummaryDay(now time.Time) time.Time {
+	at, err := time.Parse("15:04", b.SummaryAt)
+	if err != nil {
+		at, _ = time.Parse("15:04", defaultSummaryAt)
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// IsGitHub reports whether todos are tracked on GitHub, with or without a project board
+func (b *StorageBackend) IsGitHub() bool {
+	return b != nil && (b.Type == "github" || b.Type == "github-issues")
+}
+
+// IsPlugin reports whether todos are tracked by an external lfg-backend-<plugin> command
+func (b *StorageBackend) IsPlugin() bool {
+	return b != nil && b.Type == "plugin" && b.Plugin != ""
+}
+
+// IsOrg reports whether todos are headlines in an org file
+func (b *StorageBackend) IsOrg() bool {
+	return b != nil && b.Type == "org"
+}
+
+// Remote reports whether todos are tracked outside the config, so the TUI fetches items
+// and moves them between the backend's statuses
+func (b *StorageBackend) Remote() bool {
+	return b.IsGitHub() || b.IsPlugin() || b.IsOrg()
+}
+
+// UsesProject reports whether items live on a GitHub Projects board, rather than being
+// plain issues whose status comes from their state and labels
+func (b *StorageBackend) UsesProject() bool {
+	return b != nil && b.Type == "github"
+}
+
+// defaultInProgressLabel marks issues in progress when in_progress_label isn't set
+const defaultInProgressLabel = "in-progress"
+
+// InProgress returns the label that marks an issue in progress with the github-issues backend
+func (b *StorageBackend) InProgress() string {
+	if b.InProgressLabel != "" {
+		return b.InProgressLabel
+	}
+	return defaultInProgressLabel
 }
 
 type Config struct {
-	Name            string          `yaml:"name"`
-	WorktreeNaming  string          `yaml:"worktree_naming"`
-	StorageBackend  *StorageBackend `yaml:"storage_backend,omitempty"`
-	Todos           []Todo          `yaml:"todos"`
-	Windows         []TmuxWindow    `yaml:"windows,omitempty"` // Deprecated, use Layout
-	Layout          []LayoutRow     `yaml:"layout,omitempty"`
-	configPath      string
+	Name           string                 `yaml:"name"`
+	WorktreeNaming string                 `yaml:"worktree_naming"`
+	StorageBackend *StorageBackend        `yaml:"storage_backend,omitempty"`
+	Todos          []Todo                 `yaml:"todos"`
+	Windows        []TmuxWindow           `yaml:"windows,omitempty"` // Deprecated, use Layout
+	Layout         []LayoutRow            `yaml:"layout,omitempty"`
+	Layouts        map[string][]LayoutRow `yaml:"layouts,omitempty"`       // Named alternatives to Layout, chosen when creating a worktree
+	Keybindings    Keybindings            `yaml:"keybindings,omitempty"`   // Overrides for the TUI's default keys
+	ArchiveAfter   *int                   `yaml:"archive_after,omitempty"` // Days before finished items are hidden from the TUI
+	Theme          *Theme                 `yaml:"theme,omitempty"`
+	Language       string                 `yaml:"language,omitempty"`         // The TUI's language, e.g. "es"; from LC_ALL, LC_MESSAGES or LANG unless set
+	Messages       map[string]string      `yaml:"messages,omitempty"`         // Replacements for single messages or glyphs, by their English
+	Notify         []string               `yaml:"notify,omitempty"`           // Events to show desktop notifications for, see Notifies
+	Ports          *Ports                 `yaml:"ports,omitempty"`            // Ports given to each worktree's sessions
+	Envrc          *Envrc                 `yaml:"envrc,omitempty"`            // Generates a .envrc in each new worktree
+	Bootstrap      string                 `yaml:"bootstrap,omitempty"`        // Installs a new worktree's dependencies: "auto" picks the command from the project's files, anything else is run as it is
+	Container      *Container             `yaml:"container,omitempty"`        // Runs each worktree's layout commands in containers of its own
+	Webhooks       []Webhook              `yaml:"webhooks,omitempty"`         // Posted to when worktrees are created, items move or agents finish
+	Caches         *Caches                `yaml:"caches,omitempty"`           // Build and dependency caches shared by every worktree
+	GC             []string               `yaml:"gc,omitempty"`               // Commands lfg gc runs to remove a worktree's build artifacts; picked from the project's files unless set
+	IdleSessions   *IdleSessions          `yaml:"idle_sessions,omitempty"`    // Reports or kills sessions nobody has used for a while
+	Prewarm        *Prewarm               `yaml:"prewarm_sessions,omitempty"` // Keeps sessions ready for the worktrees likely to be jumped to next
+	WIP            string                 `yaml:"wip,omitempty"`              // Saves a worktree's uncommitted work when lfg kills its session: "commit" or "stash"
+	PullRequest    *PullRequest           `yaml:"pull_request,omitempty"`     // How lfg pr describes the pull requests it opens
+	CommitPrefix   bool                   `yaml:"commit_prefix,omitempty"`    // Prefix commits in worktrees with their todo's issue, e.g. "#123: ", from a commit-msg hook lfg installs
+	Remote         *Remote                `yaml:"remote,omitempty"`           // A development host whose clone of the repository lfg manages over SSH
+	Environments   map[string]Environment `yaml:"environments,omitempty"`     // Named sets of variables, e.g. local and staging, one picked per worktree
+	BranchTypes    []BranchType           `yaml:"branch_types,omitempty"`     // Prefixes new branches can be given, like feat/ and fix/
+	Files          string                 `yaml:"files,omitempty"`            // Where lfg's files are kept and how git ignores them, see IgnoreFiles
+	configPath     string
+}
+
+// Environment is a set of variables, like the URLs of the services to test against, given
+// to every pane and command of the worktrees it's picked for
+type Environment map[string]string
+
+// BranchType is a prefix for the branches of a kind of work, e.g. fix/ for bug fixes.
+// Worktrees' directories are named without it.
+type BranchType struct {
+	Prefix string   `yaml:"prefix"`
+	Labels []string `yaml:"labels,omitempty"` // Issue labels that pick this type for an item's branch, e.g. bug
+}
+
+// Envrc generates a direnv .envrc in each worktree lfg creates, from a Go template given
+// the worktree's name, branch, paths and ports
+type Envrc struct {
+	Template string `yaml:"template"`        // The template file, relative to the repository root
+	Allow    *bool  `yaml:"allow,omitempty"` // Run direnv allow on the file, true unless set to false
+}
+
+// Allows reports whether a generated .envrc should be allowed with direnv
+func (e *Envrc) Allows() bool {
+	return e.Allow == nil || *e.Allow
+}
+
+// Container runs a worktree's layout commands in its own docker compose project, or in
+// its dev container, so databases and networks aren't shared between worktrees
+type Container struct {
+	Type    string   `yaml:"type,omitempty"`    // "compose", the default, or "devcontainer"
+	Service string   `yaml:"service,omitempty"` // The compose service commands are run in
+	Files   []string `yaml:"files,omitempty"`   // Compose files, relative to the worktree; compose.yaml or docker-compose.yml unless set
+	Session bool     `yaml:"session,omitempty"` // Open a shell in the container in panes without a command too
+}
+
+// Container types
+const (
+	ContainerCompose      = "compose"
+	ContainerDevcontainer = "devcontainer"
+)
+
+// Devcontainer reports whether commands run in a dev container rather than a compose service
+func (c *Container) Devcontainer() bool {
+	return c.Type == ContainerDevcontainer
+}
+
+// Caches points every worktree's panes at one set of build and dependency caches, so
+// each new worktree doesn't download and build everything again
+type Caches struct {
+	Share []string          `yaml:"share,omitempty"` // Caches from SharedCaches to share, e.g. go, pnpm and cargo
+	Dir   string            `yaml:"dir,omitempty"`   // Where shared caches are kept, relative to the repository root; caches in .lfg unless set
+	Env   map[string]string `yaml:"env,omitempty"`   // Other variables to set, e.g. CCACHE_DIR: ~/.ccache
+}
+
+// SharedCaches are the caches Caches.Share can name, with the variables that point each
+// tool at them and the cache directories they're given
+var SharedCaches = map[string]map[string]string{
+	"go":    {"GOMODCACHE": "go/mod", "GOCACHE": "go/build"},
+	"pnpm":  {"npm_config_store_dir": "pnpm-store"},
+	"npm":   {"npm_config_cache": "npm"},
+	"yarn":  {"YARN_CACHE_FOLDER": "yarn"},
+	"cargo": {"CARGO_TARGET_DIR": "cargo-target"},
+}
+
+// CacheEnv returns the environment pointing a worktree at the shared caches, sorted by
+// variable. It's nil without caches, and naming a cache lfg doesn't know is an error.
+func (c *Config) CacheEnv() ([]string, error) {
+	if c.Caches == nil {
+		return nil, nil
+	}
+	dir := expandHome(c.Caches.Dir)
+	if dir == "" {
+		// On a remote host, the caches are there too
+		dir = filepath.Join(StateDir(c.HostConfigPath()), "caches")
+	} else if !filepath.IsAbs(dir) {
+		dir = filepath.Join(rootOf(c.HostConfigPath()), dir)
+	}
+
+	vars := map[string]string{}
+	for _, name := range c.Caches.Share {
+		cache, ok := SharedCaches[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cache %q, want one of %s", name, strings.Join(slices.Sorted(maps.Keys(SharedCaches)), ", "))
+		}
+		for variable, sub := range cache {
+			vars[variable] = filepath.Join(dir, sub)
+		}
+	}
+	for variable, value := range c.Caches.Env {
+		vars[variable] = expandHome(value)
+	}
+
+	env := make([]string, 0, len(vars))
+	for _, variable := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, variable+"="+vars[variable])
+	}
+	return env, nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// IdleSessions keeps forgotten tmux sessions from piling up: the daemon reports the
+// worktrees' sessions that nobody is attached to and that haven't been used for Days, or
+// kills them, keeping their worktrees
+type IdleSessions struct {
+	Days   int    `yaml:"days,omitempty"`   // How long a session goes unused before it's idle, 7 days unless set
+	Action string `yaml:"action,omitempty"` // "notify", the default, or "kill"
+}
+
+// Prewarm keeps sessions ready for the worktrees likely to be jumped to next: the daemon
+// builds detached sessions for the Count most recently used or most urgent, with their
+// panes' commands typed but not run until they're jumped to
+type Prewarm struct {
+	Count int    `yaml:"count,omitempty"` // How many worktrees to keep a session ready for, 3 unless set
+	By    string `yaml:"by,omitempty"`    // "recent", the default, or "priority"
+}
+
+// PullRequest describes the pull requests lfg pr opens, from a Go template given the todo,
+// its issue, the branch's changes and the agent's summary
+type PullRequest struct {
+	Template string `yaml:"template,omitempty"` // The template file, relative to the repository root; lfg's own unless set
+	Draft    bool   `yaml:"draft,omitempty"`    // Open them as drafts
+}
+
+// Remote is a development host lfg runs git and tmux on over SSH, attaching the local
+// terminal to the sessions there
+type Remote struct {
+	Host    string   `yaml:"host"`                  // What ssh connects to, e.g. me@devbox or a Host from ~/.ssh/config
+	Path    string   `yaml:"path"`                  // The repository's main worktree on the host, e.g. ~/src/app
+	Options []string `yaml:"ssh_options,omitempty"` // More arguments for ssh, e.g. ["-p", "2222"]
+}
+
+// OnHostEnv is set in the sessions lfg creates on a remote host, so lfg run in them works
+// on the host's clone rather than connecting to the host again
+const OnHostEnv = "LFG_ON_HOST"
+
+// RemoteHost returns the host the repository is managed on, nil when it's managed here,
+// including when lfg is already running on the host
+func (c *Config) RemoteHost() *Remote {
+	if c.Remote == nil || c.Remote.Host == "" || os.Getenv(OnHostEnv) != "" {
+		return nil
+	}
+	return c.Remote
+}
+
+// RequireLocal fails for what, something lfg runs in a worktree's directory on this machine,
+// when the worktrees are managed on a remote host, saying to run it on the host instead
+func (c *Config) RequireLocal(what string) error {
+	remote := c.RemoteHost()
+	if remote == nil {
+		return nil
+	}
+	return fmt.Errorf("%s runs in the worktrees' directories, which are on %s; run it from a worktree's session there", what, remote.Host)
+}
+
+// HostConfigPath returns the path of the config for lfg run in worktrees' panes: the host's
+// copy in the repository there when it's remote, this one otherwise
+func (c *Config) HostConfigPath() string {
+	if remote := c.RemoteHost(); remote != nil && c.Files == FilesGitDir {
+		return path.Join(remote.Path, ".git", gitDirName, configFileName)
+	} else if remote != nil {
+		return path.Join(remote.Path, configFileName)
+	}
+	return c.configPath
+}
+
+// Ways of saving uncommitted work
+const (
+	WIPCommit = "commit"
+	WIPStash  = "stash"
+)
+
+// Idle session actions
+const (
+	IdleNotify = "notify"
+	IdleKill   = "kill"
+)
+
+const defaultIdleDays = 7
+
+// Ways of picking the worktrees to prewarm sessions for
+const (
+	PrewarmRecent   = "recent"
+	PrewarmPriority = "priority"
+)
+
+const defaultPrewarmCount = 3
+
+// Sessions is how many worktrees to keep a session ready for
+func (p *Prewarm) Sessions() int {
+	if p.Count <= 0 {
+		return defaultPrewarmCount
+	}
+	return p.Count
+}
+
+// After is how long a session goes unused before it's idle
+func (i *IdleSessions) After() time.Duration {
+	days := i.Days
+	if days <= 0 {
+		days = defaultIdleDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Ports gives each worktree a block of ports of its own, so dev servers in different
+// worktrees don't fight over one
+type Ports struct {
+	Names []string `yaml:"names"`           // Environment variables that get a port each, e.g. PORT and DB_PORT
+	Base  int      `yaml:"base,omitempty"`  // The first block's first port, 4000 unless set
+	Block int      `yaml:"block,omitempty"` // How many ports each block has, 10 unless set
+}
+
+const (
+	defaultPortBase  = 4000
+	defaultPortBlock = 10
+)
+
+// Port is one of the ports in a worktree's block
+type Port struct {
+	Name   string
+	Number int
+}
+
+// Env is the port as an environment variable, e.g. PORT=4000
+func (p Port) Env() string {
+	return fmt.Sprintf("%s=%d", p.Name, p.Number)
+}
+
+// InBlock returns the ports in the block-th block, one for each of Names in order
+func (p *Ports) InBlock(block int) []Port {
+	if p == nil {
+		return nil
+	}
+	base, size := p.Base, p.Block
+	if base == 0 {
+		base = defaultPortBase
+	}
+	if size == 0 {
+		size = defaultPortBlock
+	}
+	size = max(size, len(p.Names))
+
+	ports := make([]Port, 0, len(p.Names))
+	for i, name := range p.Names {
+		ports = append(ports, Port{Name: name, Number: base + block*size + i})
+	}
+	return ports
+}
+
+// Events the notify list can name
+const (
+	NotifyAgent = "agent" // An agent session or headless task finished
+	NotifyCrash = "crash" // A layout pane's command exited with an error
+	NotifyCI    = "ci"    // A pull request's checks passed or failed
+	NotifyDue   = "due"   // A todo or item is due today or overdue
+)
+
+// Notifies reports whether the config asks for notifications of event
+func (c *Config) Notifies(event string) bool {
+	return c != nil && slices.Contains(c.Notify, event)
+}
+
+// Webhook is a URL, such as a Slack or Discord incoming webhook, that lfg posts to when
+// things happen
+type Webhook struct {
+	URL      string   `yaml:"url"`
+	Events   []string `yaml:"events,omitempty"`   // The events to post, every one unless set
+	Statuses []string `yaml:"statuses,omitempty"` // The statuses status_changed is posted for, In Progress and Done unless set
+	Template string   `yaml:"template,omitempty"` // Go template for the JSON body, a chat message unless set
+}
+
+// Events a webhook can be posted for
+const (
+	WebhookWorktreeCreated = "worktree_created" // lfg created a worktree
+	WebhookStatusChanged   = "status_changed"   // An item moved to one of the webhook's statuses
+	WebhookAgentFinished   = "agent_finished"   // An agent session or headless task finished
+)
+
+// Sends reports whether the webhook is posted to for event, moving to status for
+// status_changed
+func (w Webhook) Sends(event, status string) bool {
+	if len(w.Events) > 0 && !slices.Contains(w.Events, event) {
+		return false
+	}
+	if event != WebhookStatusChanged {
+		return true
+	}
+	if len(w.Statuses) == 0 {
+		return status == "In Progress" || status == "Done"
+	}
+	return slices.Contains(w.Statuses, status)
 }
 
 const configFileName = "lfg-config.yaml"
 
-// Load loads the config from the repository root, or creates a default one
+// Load loads the config from the repository root, or .git/lfg, or creates a default one
 func Load() (*Config, error) {
-	repoRoot, err := getRepoRoot()
+	repoRoot, err := RepoRoot()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get repo root: %w", err)
+		return nil, err
 	}
 
-	configPath := filepath.Join(repoRoot, configFileName)
-
 	// If config doesn't exist, run init wizard
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return runInitWizard(configPath, repoRoot)
+	var cfg *Config
+	if configPath, ok := findConfig(repoRoot); !ok {
+		if cfg, err = runInitWizard(configPath, repoRoot); err != nil {
+			return nil, err
+		}
+	} else {
+		if cfg, err = LoadFromPath(configPath); err != nil {
+			return nil, err
+		}
+	}
+	// So it's offered when lfg is next run outside a repository
+	if err := rememberRepo(repoRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
+	return cfg, nil
+}
 
+// LoadExisting loads the config of the repository the working directory is in, failing
+// rather than running the init wizard if it hasn't been set up
+func LoadExisting() (*Config, error) {
+	repoRoot, err := RepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	configPath, _ := findConfig(repoRoot)
 	return LoadFromPath(configPath)
 }
 
@@ -96,40 +580,96 @@ func LoadFromPath(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// defaultOrgFile is the org file todos are kept in when storage_backend.file isn't set
+const defaultOrgFile = "todo.org"
+
+// OrgFile returns the path of the org file todos are kept in with the org backend
+func (c *Config) OrgFile() string {
+	file := defaultOrgFile
+	if c.StorageBackend != nil && c.StorageBackend.File != "" {
+		file = c.StorageBackend.File
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(c.Root(), file)
+}
+
 // GetConfigPath returns the path to the config file
 func (c *Config) GetConfigPath() string {
 	return c.configPath
 }
 
-// Save saves the config to disk
+// Save saves the config to disk, replacing whatever is there. Use Update to change a config
+// other processes may be changing too.
 func (c *Config) Save() error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(c.configPath, data, 0644); err != nil {
+	if err := sharedfile.Write(c.configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
 }
 
-// AddTodo adds a new todo to the config
+// UpdateFile makes change to the config at configPath as it is on disk and saves it, holding
+// a lock so that the TUI, viewer panes and monitors changing the config at once each keep
+// their changes. It returns what was saved.
+func UpdateFile(configPath string, change func(cfg *Config) error) (*Config, error) {
+	// The lock is kept with lfg's local state, out of the repository
+	unlock, err := sharedfile.Lock(filepath.Join(StateDir(configPath), "config.lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	latest, err := LoadFromPath(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(latest); err != nil {
+		return nil, err
+	}
+	if err := latest.Save(); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// Update is UpdateFile for the config c was loaded from, refreshing c to what was saved
+func (c *Config) Update(change func(cfg *Config) error) error {
+	latest, err := UpdateFile(c.configPath, change)
+	if err != nil {
+		return err
+	}
+	*c = *latest
+	return nil
+}
+
+// AddTodo adds a new todo to the config. Its work starts now when it has a worktree.
 func (c *Config) AddTodo(description, worktree string) {
-	// Add to the beginning of the list
-	c.Todos = append([]Todo{{
+	now := time.Now()
+	todo := Todo{
 		Description: description,
 		Status:      TodoStatusPending,
 		Worktree:    worktree,
-	}}, c.Todos...)
+		CreatedAt:   now,
+	}
+	if worktree != "" {
+		todo.StartedAt = now
+	}
+	// Add to the beginning of the list
+	c.Todos = append([]Todo{todo}, c.Todos...)
 }
 
 // MarkTodoDone marks a todo as done by worktree name
 func (c *Config) MarkTodoDone(worktree string) {
 	for i := range c.Todos {
 		if c.Todos[i].Worktree == worktree {
-			c.Todos[i].Status = TodoStatusDone
+			c.Todos[i].SetStatus(TodoStatusDone)
 			break
 		}
 	}
@@ -187,14 +727,105 @@ func (c *Config) GetLayout() []LayoutRow {
 	return nil
 }
 
-func getRepoRoot() (string, error) {
+// GetLayoutForWorktree returns the named layout chosen for the worktree, or the default layout
+func (c *Config) GetLayoutForWorktree(worktree string) []LayoutRow {
+	if todo := c.GetTodoForWorktree(worktree); todo != nil && todo.Layout != "" {
+		if layout, ok := c.Layouts[todo.Layout]; ok && len(layout) > 0 {
+			return layout
+		}
+	}
+	return c.GetLayout()
+}
+
+// LayoutNames returns the names of the named layouts, sorted
+func (c *Config) LayoutNames() []string {
+	names := make([]string, 0, len(c.Layouts))
+	for name := range c.Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvironmentNames returns the names of the environments, sorted
+func (c *Config) EnvironmentNames() []string {
+	return slices.Sorted(maps.Keys(c.Environments))
+}
+
+// EnvironmentVars returns the named environment's variables as NAME=value, sorted by
+// variable. "" is no environment, and a name that isn't in environments is an error.
+func (c *Config) EnvironmentVars(name string) ([]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	vars, ok := c.Environments[name]
+	if !ok {
+		return nil, fmt.Errorf("no environment named %q in environments", name)
+	}
+	env := make([]string, 0, len(vars))
+	for _, variable := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, variable+"="+vars[variable])
+	}
+	return env, nil
+}
+
+// BranchPrefixes returns the branch types' prefixes, in the order they're configured
+func (c *Config) BranchPrefixes() []string {
+	prefixes := make([]string, 0, len(c.BranchTypes))
+	for _, branchType := range c.BranchTypes {
+		prefixes = append(prefixes, branchType.Prefix)
+	}
+	return prefixes
+}
+
+// BranchPrefixFor returns the prefix of the first branch type with one of an item's
+// labels, compared ignoring case, or "" when none has
+func (c *Config) BranchPrefixFor(labels []string) string {
+	for _, branchType := range c.BranchTypes {
+		for _, label := range branchType.Labels {
+			if slices.ContainsFunc(labels, func(l string) bool { return strings.EqualFold(l, label) }) {
+				return branchType.Prefix
+			}
+		}
+	}
+	return ""
+}
+
+// WorktreeEnvironment returns the name of the environment picked for a worktree, "" for none
+func (c *Config) WorktreeEnvironment(worktree string) string {
+	if todo := c.GetTodoForWorktree(worktree); todo != nil {
+		return todo.Environment
+	}
+	return ""
+}
+
+// WorktreeEnv returns the variables of the environment picked for a worktree, as
+// EnvironmentVars does
+func (c *Config) WorktreeEnv(worktree string) ([]string, error) {
+	return c.EnvironmentVars(c.WorktreeEnvironment(worktree))
+}
+
+// defaultArchiveAfterDays is how long finished items stay in the TUI when archive_after isn't set
+const defaultArchiveAfterDays = 14
+
+// ArchiveAge returns how long after completion finished items are hidden from the TUI
+func (c *Config) ArchiveAge() time.Duration {
+	days := defaultArchiveAfterDays
+	if c.ArchiveAfter != nil {
+		days = max(0, *c.ArchiveAfter)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// RepoRoot returns the main worktree of the repository the working directory is in, or
+// ErrNotInRepo
+func RepoRoot() (string, error) {
 	// Try to get the main worktree root by listing all worktrees
 	// The first worktree in the list is always the main worktree
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	output, err := gitOutput(".", "worktree", "list", "--porcelain")
 	if err == nil {
 		// Parse the output to get the first worktree path
-		lines := strings.Split(string(output), "\n")
+		lines := strings.Split(output, "\n")
 		for _, line := range lines {
 			if strings.HasPrefix(line, "worktree ") {
 				return strings.TrimPrefix(line, "worktree "), nil
@@ -203,10 +834,9 @@ func getRepoRoot() (string, error) {
 	}
 
 	// Fallback to rev-parse if worktree list fails (e.g., not using worktrees)
-	cmd = exec.Command("git", "rev-parse", "--show-toplevel")
-	output, err = cmd.Output()
+	output, err = gitOutput(".", "rev-parse", "--show-toplevel")
 	if err != nil {
-		return "", fmt.Errorf("not in a git repository")
+		return "", ErrNotInRepo
 	}
-	return strings.TrimSpace(string(output)), nil
+	return output, nil
 }
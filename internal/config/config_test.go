@@ -1,15 +1,27 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/runner"
 )
 
 func TestAddTodo(t *testing.T) {
 	cfg := &Config{
-		Name:   "test-project",
-		Todos:  []Todo{},
+		Name:       "test-project",
+		Todos:      []Todo{},
 		configPath: "/tmp/test-config.yaml",
 	}
 
@@ -46,6 +58,9 @@ func TestMarkTodoDone(t *testing.T) {
 	if cfg.Todos[0].Status != TodoStatusDone {
 		t.Errorf("Expected first todo to be done, got %q", cfg.Todos[0].Status)
 	}
+	if cfg.Todos[0].CompletedAt.IsZero() {
+		t.Error("Expected first todo to record when it was completed")
+	}
 	if cfg.Todos[1].Status != TodoStatusPending {
 		t.Errorf("Expected second todo to remain pending, got %q", cfg.Todos[1].Status)
 	}
@@ -119,3 +134,853 @@ func TestSaveAndLoad(t *testing.T) {
 func testStringPtr(s string) *string {
 	return &s
 }
+
+func TestMirrorToPR(t *testing.T) {
+	tests := []struct {
+		mirrorTo string
+		expected bool
+	}{
+		{mirrorTo: "", expected: true},
+		{mirrorTo: "pr", expected: true},
+		{mirrorTo: "issue", expected: false},
+	}
+
+	for _, tt := range tests {
+		backend := &StorageBackend{Type: "github", MirrorTo: tt.mirrorTo}
+		if got := backend.MirrorToPR(); got != tt.expected {
+			t.Errorf("MirrorToPR() with mirror_to %q = %v, want %v", tt.mirrorTo, got, tt.expected)
+		}
+	}
+}
+
+func TestAutoStatusMove(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name       string
+		autoStatus *AutoStatus
+		status     string
+		to         string
+		move       bool
+	}{
+		{name: "unset moves to In Progress", status: "Todo", to: "In Progress", move: true},
+		{name: "unset leaves Done alone", status: "Done", to: "In Progress", move: false},
+		{name: "unset leaves In Progress alone", status: "In Progress", to: "In Progress", move: false},
+		{name: "custom target", autoStatus: &AutoStatus{To: "Doing"}, status: "Todo", to: "Doing", move: true},
+		{name: "from a listed status", autoStatus: &AutoStatus{From: []string{"Todo"}}, status: "Todo", to: "In Progress", move: true},
+		{name: "from an unlisted status", autoStatus: &AutoStatus{From: []string{"Todo"}}, status: "In Review", to: "In Progress", move: false},
+		{name: "from Done when listed", autoStatus: &AutoStatus{From: []string{"Done"}}, status: "Done", to: "In Progress", move: true},
+		{name: "disabled", autoStatus: &AutoStatus{Enabled: &disabled}, status: "Todo", to: "In Progress", move: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, move := tt.autoStatus.Move(tt.status)
+			if to != tt.to || move != tt.move {
+				t.Errorf("Move(%q) = %q, %v, want %q, %v", tt.status, to, move, tt.to, tt.move)
+			}
+		})
+	}
+}
+
+func TestSummaryDay(t *testing.T) {
+	today := time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+	tests := []struct {
+		summaryAt string
+		now       time.Time
+		expected  time.Time
+	}{
+		{summaryAt: "", now: today.Add(17 * time.Hour), expected: yesterday},
+		{summaryAt: "", now: today.Add(18 * time.Hour), expected: today},
+		{summaryAt: "09:30", now: today.Add(9*time.Hour + 29*time.Minute), expected: yesterday},
+		{summaryAt: "09:30", now: today.Add(10 * time.Hour), expected: today},
+		{summaryAt: "teatime", now: today.Add(20 * time.Hour), expected: today},
+	}
+
+	for _, tt := range tests {
+		backend := &StorageBackend{Type: "github", Mirror: MirrorDaily, SummaryAt: tt.summaryAt}
+		if got := backend.SummaryDay(tt.now); !got.Equal(tt.expected) {
+			t.Errorf("SummaryDay(%s) with summary_at %q = %s, want %s", tt.now, tt.summaryAt, got, tt.expected)
+		}
+	}
+}
+
+func TestBackendKinds(t *testing.T) {
+	tests := []struct {
+		name        string
+		backend     *StorageBackend
+		isGitHub    bool
+		usesProject bool
+		remote      bool
+	}{
+		{name: "none", backend: nil},
+		{name: "local", backend: &StorageBackend{Type: "local"}},
+		{name: "project", backend: &StorageBackend{Type: "github"}, isGitHub: true, usesProject: true, remote: true},
+		{name: "issues", backend: &StorageBackend{Type: "github-issues"}, isGitHub: true, remote: true},
+		{name: "plugin", backend: &StorageBackend{Type: "plugin", Plugin: "jira"}, remote: true},
+		{name: "plugin without a name", backend: &StorageBackend{Type: "plugin"}},
+		{name: "org", backend: &StorageBackend{Type: "org"}, remote: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backend.IsGitHub(); got != tt.isGitHub {
+				t.Errorf("IsGitHub() = %v, want %v", got, tt.isGitHub)
+			}
+			if got := tt.backend.UsesProject(); got != tt.usesProject {
+				t.Errorf("UsesProject() = %v, want %v", got, tt.usesProject)
+			}
+			if got := tt.backend.Remote(); got != tt.remote {
+				t.Errorf("Remote() = %v, want %v", got, tt.remote)
+			}
+		})
+	}
+
+	cfg := &Config{StorageBackend: &StorageBackend{Type: "org"}, configPath: "/repo/lfg-config.yaml"}
+	if got := cfg.OrgFile(); got != "/repo/todo.org" {
+		t.Errorf("OrgFile() default = %q, want /repo/todo.org", got)
+	}
+	cfg.StorageBackend.File = "notes/lfg.org"
+	if got := cfg.OrgFile(); got != "/repo/notes/lfg.org" {
+		t.Errorf("OrgFile() = %q, want /repo/notes/lfg.org", got)
+	}
+
+	if got := (&StorageBackend{Type: "github-issues"}).InProgress(); got != "in-progress" {
+		t.Errorf("InProgress() default = %q, want in-progress", got)
+	}
+	if got := (&StorageBackend{Type: "github-issues", InProgressLabel: "doing"}).InProgress(); got != "doing" {
+		t.Errorf("InProgress() = %q, want doing", got)
+	}
+}
+
+func TestResolveKeybindings(t *testing.T) {
+	tests := []struct {
+		name        string
+		keybindings Keybindings
+		action      string
+		expected    []string
+		expectErr   bool
+	}{
+		{
+			name:     "defaults",
+			action:   ActionNew,
+			expected: []string{"n", "c"},
+		},
+		{
+			name:        "override replaces default keys",
+			keybindings: Keybindings{ActionDelete: {"x"}},
+			action:      ActionDelete,
+			expected:    []string{"x"},
+		},
+		{
+			name:        "freed default key can be reused",
+			keybindings: Keybindings{ActionDelete: {"x"}, ActionRefresh: {"d"}},
+			action:      ActionRefresh,
+			expected:    []string{"d"},
+		},
+		{
+			name:        "unknown action",
+			keybindings: Keybindings{"explode": {"e"}},
+			expectErr:   true,
+		},
+		{
+			name:        "conflict with another action",
+			keybindings: Keybindings{ActionRefresh: {"d"}},
+			expectErr:   true,
+		},
+		{
+			name:        "conflict with list navigation",
+			keybindings: Keybindings{ActionNew: {"j"}},
+			expectErr:   true,
+		},
+		{
+			name:        "conflict with recent worktree shortcuts",
+			keybindings: Keybindings{ActionNew: {"1"}},
+			expectErr:   true,
+		},
+		{
+			name:        "no keys",
+			keybindings: Keybindings{ActionNew: {}},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Keybindings: tt.keybindings}
+			bindings, err := cfg.ResolveKeybindings()
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ResolveKeybindings() expected error, got %v", bindings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveKeybindings() error = %v", err)
+			}
+			if strings.Join(bindings[tt.action], ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("ResolveKeybindings()[%q] = %v, want %v", tt.action, bindings[tt.action], tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveTheme(t *testing.T) {
+	tests := []struct {
+		name      string
+		theme     Theme
+		dark      bool
+		expected  Theme
+		expectErr bool
+	}{
+		{
+			name:     "auto on a dark terminal",
+			dark:     true,
+			expected: builtinThemes["dark"],
+		},
+		{
+			name:     "auto on a light terminal",
+			theme:    Theme{Name: "auto"},
+			expected: builtinThemes["light"],
+		},
+		{
+			name:     "named theme ignores the terminal",
+			theme:    Theme{Name: "light"},
+			dark:     true,
+			expected: builtinThemes["light"],
+		},
+		{
+			name:  "overrides keep the rest of the base",
+			theme: Theme{Name: "dark", Accent: "#5f87ff"},
+			expected: func() Theme {
+				theme := builtinThemes["dark"]
+				theme.Accent = "#5f87ff"
+				return theme
+			}(),
+		},
+		{
+			name:  "glyph overrides keep the rest of the glyph",
+			theme: Theme{Name: "high-contrast", Glyphs: Glyphs{Done: Glyph{Symbol: "DONE"}}},
+			expected: func() Theme {
+				theme := builtinThemes["high-contrast"]
+				theme.Glyphs.Done = Glyph{Symbol: "DONE", Color: "39"}
+				return theme
+			}(),
+		},
+		{
+			name:      "unknown theme",
+			theme:     Theme{Name: "solarized"},
+			expected:  builtinThemes["dark"],
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := resolveTheme(tt.theme, tt.dark)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("resolveTheme() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if theme != tt.expected {
+				t.Errorf("resolveTheme() = %+v, want %+v", theme, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetLayoutForWorktree(t *testing.T) {
+	cfg := &Config{
+		Layout: []LayoutRow{{Height: "100%", Name: "shell"}},
+		Layouts: map[string][]LayoutRow{
+			"review": {{Height: "100%", Name: "diff"}},
+		},
+		Todos: []Todo{
+			{Description: "Default", Worktree: "plain"},
+			{Description: "Review", Worktree: "reviewing", Layout: "review"},
+			{Description: "Stale", Worktree: "stale", Layout: "removed"},
+		},
+	}
+
+	tests := []struct {
+		worktree string
+		expected string
+	}{
+		{worktree: "plain", expected: "shell"},
+		{worktree: "reviewing", expected: "diff"},
+		{worktree: "stale", expected: "shell"},
+		{worktree: "no-todo", expected: "shell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.worktree, func(t *testing.T) {
+			layout := cfg.GetLayoutForWorktree(tt.worktree)
+			if len(layout) != 1 || layout[0].Name != tt.expected {
+				t.Errorf("GetLayoutForWorktree(%q) = %+v, want a %q row", tt.worktree, layout, tt.expected)
+			}
+		})
+	}
+}
+
+func TestArchiveAge(t *testing.T) {
+	days := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		after    *int
+		expected time.Duration
+	}{
+		{name: "default", after: nil, expected: 14 * 24 * time.Hour},
+		{name: "configured", after: days(3), expected: 3 * 24 * time.Hour},
+		{name: "immediately", after: days(0), expected: 0},
+		{name: "negative", after: days(-1), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{ArchiveAfter: tt.after}
+			if age := cfg.ArchiveAge(); age != tt.expected {
+				t.Errorf("ArchiveAge() = %v, want %v", age, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsConcurrentChanges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := (&Config{Name: "proj", configPath: configPath}).Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Each writer holds a copy loaded before any of the others saved
+	const writers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		cfg, err := LoadFromPath(configPath)
+		if err != nil {
+			t.Fatalf("LoadFromPath() error = %v", err)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := cfg.Update(func(latest *Config) error {
+				latest.AddTodo(fmt.Sprintf("Task %d", i), fmt.Sprintf("proj-%d", i))
+				return nil
+			}); err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	if len(cfg.Todos) != writers {
+		t.Errorf("%d todos saved, want one from each of %d writers", len(cfg.Todos), writers)
+	}
+}
+
+func TestPortsInBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		ports    *Ports
+		block    int
+		expected []Port
+	}{
+		{name: "not configured", ports: nil, block: 2, expected: nil},
+		{
+			name:     "defaults",
+			ports:    &Ports{Names: []string{"PORT", "DB_PORT"}},
+			block:    2,
+			expected: []Port{{Name: "PORT", Number: 4020}, {Name: "DB_PORT", Number: 4021}},
+		},
+		{
+			name:     "set base and block",
+			ports:    &Ports{Names: []string{"PORT"}, Base: 3000, Block: 100},
+			block:    1,
+			expected: []Port{{Name: "PORT", Number: 3100}},
+		},
+		{
+			name:     "blocks fit every name",
+			ports:    &Ports{Names: []string{"A", "B", "C"}, Block: 2},
+			block:    1,
+			expected: []Port{{Name: "A", Number: 4003}, {Name: "B", Number: 4004}, {Name: "C", Number: 4005}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ports.InBlock(tt.block); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("InBlock(%d) = %v, want %v", tt.block, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCacheEnv(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := []struct {
+		name     string
+		caches   *Caches
+		expected []string
+		wantErr  bool
+	}{
+		{name: "not configured", caches: nil, expected: nil},
+		{
+			name:     "default directory",
+			caches:   &Caches{Share: []string{"go", "cargo"}},
+			expected: []string{"CARGO_TARGET_DIR=/repo/.lfg/caches/cargo-target", "GOCACHE=/repo/.lfg/caches/go/build", "GOMODCACHE=/repo/.lfg/caches/go/mod"},
+		},
+		{
+			name:     "directory in the home directory",
+			caches:   &Caches{Share: []string{"pnpm"}, Dir: "~/caches"},
+			expected: []string{"npm_config_store_dir=" + filepath.Join(home, "caches", "pnpm-store")},
+		},
+		{
+			name:     "other variables override shared caches",
+			caches:   &Caches{Share: []string{"yarn"}, Dir: "/cache", Env: map[string]string{"YARN_CACHE_FOLDER": "/yarn", "CCACHE_DIR": "~/.ccache"}},
+			expected: []string{"CCACHE_DIR=" + filepath.Join(home, ".ccache"), "YARN_CACHE_FOLDER=/yarn"},
+		},
+		{name: "unknown cache", caches: &Caches{Share: []string{"maven"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Caches: tt.caches, configPath: "/repo/lfg-config.yaml"}
+			got, err := cfg.CacheEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CacheEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("CacheEnv() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWorktreeEnv(t *testing.T) {
+	cfg := &Config{
+		Environments: map[string]Environment{
+			"staging": {"API_URL": "https://staging.example.com", "DEBUG": "1"},
+		},
+		Todos: []Todo{
+			{Worktree: "proj-staging", Environment: "staging"},
+			{Worktree: "proj-local"},
+			{Worktree: "proj-gone", Environment: "production"},
+		},
+	}
+	tests := []struct {
+		worktree string
+		expected []string
+		wantErr  bool
+	}{
+		{worktree: "proj-staging", expected: []string{"API_URL=https://staging.example.com", "DEBUG=1"}},
+		{worktree: "proj-local"},
+		{worktree: "proj-untracked"},
+		{worktree: "proj-gone", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.worktree, func(t *testing.T) {
+			got, err := cfg.WorktreeEnv(tt.worktree)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WorktreeEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("WorktreeEnv() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBranchPrefixFor(t *testing.T) {
+	cfg := &Config{BranchTypes: []BranchType{
+		{Prefix: "feat/", Labels: []string{"enhancement", "feature"}},
+		{Prefix: "fix/", Labels: []string{"bug"}},
+		{Prefix: "chore/"},
+	}}
+	tests := []struct {
+		labels   []string
+		expected string
+	}{
+		{labels: []string{"Bug"}, expected: "fix/"},
+		{labels: []string{"ui", "feature"}, expected: "feat/"},
+		{labels: []string{"bug", "enhancement"}, expected: "feat/"}, // The first type in the config wins
+		{labels: []string{"docs"}},
+		{},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.labels, ","), func(t *testing.T) {
+			if got := cfg.BranchPrefixFor(tt.labels); got != tt.expected {
+				t.Errorf("BranchPrefixFor(%v) = %q, want %q", tt.labels, got, tt.expected)
+			}
+		})
+	}
+	if got, want := cfg.BranchPrefixes(), []string{"feat/", "fix/", "chore/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BranchPrefixes() = %v, want %v", got, want)
+	}
+}
+
+func TestWebhookSends(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     Webhook
+		event    string
+		status   string
+		expected bool
+	}{
+		{name: "every event", hook: Webhook{}, event: WebhookAgentFinished, expected: true},
+		{name: "event not listed", hook: Webhook{Events: []string{WebhookWorktreeCreated}}, event: WebhookAgentFinished, expected: false},
+		{name: "default statuses", hook: Webhook{}, event: WebhookStatusChanged, status: "Done", expected: true},
+		{name: "status not in defaults", hook: Webhook{}, event: WebhookStatusChanged, status: "Todo", expected: false},
+		{name: "configured statuses", hook: Webhook{Statuses: []string{"In Review"}}, event: WebhookStatusChanged, status: "In Review", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hook.Sends(tt.event, tt.status); got != tt.expected {
+				t.Errorf("Sends(%q, %q) = %v, want %v", tt.event, tt.status, got, tt.expected)
+			}
+		})
+	}
+}
+
+// wizardStep hands msg to the wizard and runs the commands it returns, feeding gh's
+// answers back until there are no more. Spinner ticks and cursor blinks are dropped.
+func wizardStep(t *testing.T, m *initModel, msg tea.Msg) {
+	t.Helper()
+	pending := []tea.Msg{msg}
+	for len(pending) > 0 {
+		msg, pending = pending[0], pending[1:]
+		var cmd tea.Cmd
+		switch msg.(type) {
+		case tea.KeyMsg, authCheckMsg, projectsMsg, projectCreateMsg, loginMsg:
+			_, cmd = m.Update(msg)
+		default:
+			continue
+		}
+		var cmds []tea.Cmd
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		for len(cmds) > 0 {
+			next := cmds[0]()
+			cmds = cmds[1:]
+			if batch, ok := next.(tea.BatchMsg); ok {
+				for _, cmd := range batch {
+					if cmd != nil {
+						cmds = append(cmds, cmd)
+					}
+				}
+				continue
+			}
+			pending = append(pending, next)
+		}
+	}
+}
+
+func TestInitWizard(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh auth token", "gho_token\n", nil)
+	fake.On("gh api --include user", "HTTP/2.0 200 OK\nX-Oauth-Scopes: project, repo\n\n{}", nil)
+	fake.On("gh repo view", `{"owner": {"login": "owner"}, "name": "repo"}`, nil)
+	fake.On("gh api graphql", `{"data": {"repository": {"projectsV2": {"nodes": [{"id": "PVT_1", "number": 4, "title": "Roadmap"}]}}}}`, nil)
+	previous := github.SetRunner(fake)
+	t.Cleanup(func() { github.SetRunner(previous) })
+
+	configPath := filepath.Join(gitRepo(t), "lfg-config.yaml")
+	m := newInitModel(configPath, "proj")
+	keys := func(keys ...tea.KeyMsg) {
+		t.Helper()
+		for _, key := range keys {
+			wizardStep(t, m, key)
+		}
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+
+	// Runes beyond ASCII and pasted text go into the name
+	keys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("-café")}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" 漢字"), Paste: true})
+	if got := m.projectName.Value(); got != "proj-café 漢字" {
+		t.Fatalf("project name = %q, want the typed and pasted text", got)
+	}
+
+	// Back from the next step keeps the name
+	keys(enter, esc)
+	if m.step != stepProjectName || m.cancelled || m.projectName.Value() != "proj-café 漢字" {
+		t.Fatalf("after going back, step = %v, cancelled = %v, name = %q", m.step, m.cancelled, m.projectName.Value())
+	}
+
+	keys(enter, enter, down, enter)
+	if m.step != stepGitHubProjectSelect || m.loading != "" {
+		t.Fatalf("step = %v, loading %q, want the projects to pick from", m.step, m.loading)
+	}
+	if len(m.githubSetup.projects) != 1 || m.githubSetup.projects[0].Title != "Roadmap" {
+		t.Errorf("projects = %+v, want Roadmap", m.githubSetup.projects)
+	}
+
+	// Back to the auth step, then forward again without asking gh
+	calls := len(fake.Calls())
+	keys(esc)
+	if m.step != stepGitHubAuth {
+		t.Fatalf("step = %v, want back at the auth step", m.step)
+	}
+	keys(enter, enter)
+	if len(fake.Calls()) != calls {
+		t.Errorf("gh was asked again: %v", fake.Calls()[calls:])
+	}
+	if m.step != stepComplete {
+		t.Fatalf("step = %v, want the setup complete", m.step)
+	}
+
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "proj-café 漢字" || !cfg.StorageBackend.UsesProject() || cfg.StorageBackend.ProjectNumber != 4 {
+		t.Errorf("saved %q with %+v, want the Roadmap project", cfg.Name, cfg.StorageBackend)
+	}
+}
+
+func TestInitWizardIgnoresLateAnswers(t *testing.T) {
+	m := newInitModel(filepath.Join(t.TempDir(), "lfg-config.yaml"), "proj")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.storageChoice = storageGitHubIssues
+	// Enter starts checking gh, esc goes back before it answers
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(authCheckMsg{setup: &githubSetupState{owner: "owner", repo: "repo"}})
+	if m.step != stepStorageBackend || m.config != nil {
+		t.Errorf("step = %v, config = %+v, want the late answer ignored", m.step, m.config)
+	}
+}
+
+func TestInitWizardLogin(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh auth token", "gho_new\n", nil)
+	fake.On("gh api --include user", "HTTP/2.0 200 OK\nX-Oauth-Scopes: repo\n\n{}", nil)
+	fake.On("gh repo view", `{"owner": {"login": "owner"}, "name": "repo"}`, nil)
+	previous := github.SetRunner(fake)
+	t.Cleanup(func() { github.SetRunner(previous) })
+
+	m := newInitModel(filepath.Join(gitRepo(t), "lfg-config.yaml"), "proj")
+	m.storageChoice = storageGitHubIssues
+	m.step = stepGitHubAuth
+	m.githubSetup = &githubSetupState{authError: "Not logged in to GitHub. Press 'a' to log in"}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if m.loading == "" {
+		t.Fatal("pressing a didn't start logging in")
+	}
+	m.Update(deviceCodeMsg{code: &github.DeviceCode{UserCode: "ABCD-1234", VerificationURI: "https://github.com/login/device"}})
+	if view := m.View(); !strings.Contains(view, "ABCD-1234") || !strings.Contains(view, "https://github.com/login/device") {
+		t.Errorf("view doesn't show the code and where to enter it:\n%s", view)
+	}
+
+	// Esc gives up on the login but stays to try again
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.step != stepGitHubAuth || m.deviceCode != nil || m.loading != "" {
+		t.Fatalf("after esc, step = %v, code = %+v, loading %q, want the login given up", m.step, m.deviceCode, m.loading)
+	}
+	m.Update(loginMsg{})
+	if m.config != nil {
+		t.Fatal("a login given up on was carried on with")
+	}
+
+	// Once the code's entered, gh is checked again and the setup goes on
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	m.Update(deviceCodeMsg{code: &github.DeviceCode{UserCode: "EFGH-5678"}})
+	wizardStep(t, m, loginMsg{})
+	if m.step != stepComplete || !m.config.StorageBackend.IsGitHub() {
+		t.Errorf("step = %v, config = %+v, want the issues backend set up", m.step, m.config)
+	}
+}
+
+// gitRepo returns a new git repository to set lfg up in
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if output, err := exec.Command("git", "init", "--quiet", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+	return root
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         string
+		gitignore     string
+		wantExclude   string
+		wantGitignore string
+	}{
+		{name: "unset", gitignore: "node_modules/\n", wantGitignore: "node_modules/\n"},
+		{name: "exclude", files: FilesExclude, wantExclude: "/lfg-config.yaml\n/.lfg/\n"},
+		{name: "gitignore", files: FilesGitignore, gitignore: "node_modules/", wantGitignore: "node_modules/\n/lfg-config.yaml\n/.lfg/\n"},
+		{name: "commit", files: FilesCommit, wantGitignore: "/.lfg/\n"},
+		{name: "already ignored", files: FilesGitignore, gitignore: ".lfg\n*.yaml\n", wantGitignore: ".lfg\n*.yaml\n"},
+		{name: "git dir", files: FilesGitDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := gitRepo(t)
+			if tt.gitignore != "" {
+				os.WriteFile(filepath.Join(root, ".gitignore"), []byte(tt.gitignore), 0644)
+			}
+			configPath, err := configPathFor(root, tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &Config{Files: tt.files, configPath: configPath}
+			// Adding them again finds them ignored already
+			for range 2 {
+				if err := cfg.IgnoreFiles(); err != nil {
+					t.Fatalf("IgnoreFiles() error = %v", err)
+				}
+			}
+
+			exclude, _ := os.ReadFile(filepath.Join(root, ".git", "info", "exclude"))
+			if got := strings.Join(slices.DeleteFunc(strings.SplitAfter(string(exclude), "\n"), func(line string) bool { return strings.HasPrefix(line, "#") }), ""); got != tt.wantExclude {
+				t.Errorf(".git/info/exclude = %q, want %q", got, tt.wantExclude)
+			}
+			if got, _ := os.ReadFile(filepath.Join(root, ".gitignore")); string(got) != tt.wantGitignore {
+				t.Errorf(".gitignore = %q, want %q", got, tt.wantGitignore)
+			}
+		})
+	}
+
+	if err := (&Config{Files: "somewhere"}).IgnoreFiles(); err == nil {
+		t.Error("IgnoreFiles() with unknown files succeeded")
+	}
+
+	// git is run through the package's runner, so it's checked against the fake's ignores
+	fake := &runner.Fake{}
+	fake.On("git -C /repo check-ignore", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+	if err := (&Config{Files: FilesGitignore, configPath: "/repo/lfg-config.yaml"}).IgnoreFiles(); err != nil {
+		t.Fatalf("IgnoreFiles() with the files ignored error = %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 2 {
+		t.Errorf("IgnoreFiles() ran %v, want git check-ignore for each file", calls)
+	}
+}
+
+func TestFilesInGitDir(t *testing.T) {
+	root := gitRepo(t)
+	m := newInitModel(filepath.Join(root, "lfg-config.yaml"), "proj")
+	for _, key := range []tea.KeyType{tea.KeyEnter, tea.KeyUp, tea.KeyEnter, tea.KeyEnter} {
+		m.Update(tea.KeyMsg{Type: key})
+	}
+	if m.step != stepComplete {
+		t.Fatalf("step = %v, error %q, want the setup complete", m.step, m.saveError)
+	}
+
+	configPath, ok := findConfig(root)
+	if want := filepath.Join(root, ".git", "lfg", "lfg-config.yaml"); !ok || configPath != want {
+		t.Fatalf("findConfig() = %q, %v, want %q", configPath, ok, want)
+	}
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Files != FilesGitDir || cfg.Root() != root {
+		t.Errorf("files = %q, root = %q, want git_dir in %q", cfg.Files, cfg.Root(), root)
+	}
+	if got, want := StateDir(configPath), filepath.Join(root, ".git", "lfg"); got != want {
+		t.Errorf("StateDir() = %q, want %q", got, want)
+	}
+	if got, want := cfg.OrgFile(), filepath.Join(root, "todo.org"); got != want {
+		t.Errorf("OrgFile() = %q, want %q", got, want)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Errorf("working tree has %d entries, want only .git", len(entries))
+	}
+}
+
+func TestKnownRepos(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	api, web, gone := t.TempDir(), t.TempDir(), filepath.Join(t.TempDir(), "gone")
+	for _, root := range []string{gone, web, api, web} {
+		if err := rememberRepo(root); err != nil {
+			t.Fatalf("rememberRepo(%s) error = %v", root, err)
+		}
+	}
+
+	// The most recently used first, each once, without the one that's gone
+	known, err := KnownRepos()
+	if err != nil || !slices.Equal(known, []string{web, api}) {
+		t.Errorf("KnownRepos() = %v, %v, want %v", known, err, []string{web, api})
+	}
+}
+
+func TestChooseRepo(t *testing.T) {
+	source := gitRepo(t)
+	api := t.TempDir()
+
+	tests := []struct {
+		name    string
+		answers string
+		want    string // The repository chosen, relative to dir for clones
+		cloned  bool
+		output  string
+	}{
+		{name: "known", answers: "1\n", want: api, output: "1. " + api},
+		{name: "quit", answers: "q\n"},
+		{name: "nothing to read", answers: ""},
+		{name: "asked again", answers: "7\n1\n", want: api, output: "Pick a number from the list, c or q"},
+		{name: "clone", answers: "c\n" + source + "\n", want: filepath.Base(source), cloned: true, output: "Clone a repository into"},
+		{name: "clone fails", answers: "c\n" + filepath.Join(api, "missing") + "\nq\n", output: "Failed to clone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			if err := rememberRepo(api); err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			var out strings.Builder
+
+			got, err := ChooseRepo(strings.NewReader(tt.answers), &out, dir)
+			if err != nil {
+				t.Fatalf("ChooseRepo() error = %v", err)
+			}
+			want := tt.want
+			if tt.cloned {
+				want = filepath.Join(dir, tt.want)
+				if _, err := os.Stat(filepath.Join(want, ".git")); err != nil {
+					t.Errorf("the repository wasn't cloned: %v", err)
+				}
+			}
+			if got != want {
+				t.Errorf("ChooseRepo() = %q, want %q", got, want)
+			}
+			if !strings.Contains(out.String(), "isn't in one") || !strings.Contains(out.String(), tt.output) {
+				t.Errorf("ChooseRepo() wrote %q, want it to say %q", out.String(), tt.output)
+			}
+		})
+	}
+}
+
+func TestRequireLocal(t *testing.T) {
+	t.Setenv(OnHostEnv, "")
+	if err := (&Config{}).RequireLocal("lfg gc"); err != nil {
+		t.Errorf("RequireLocal() without a remote = %v", err)
+	}
+	cfg := &Config{Remote: &Remote{Host: "me@devbox", Path: "~/src/app"}}
+	if err := cfg.RequireLocal("lfg gc"); err == nil || !strings.Contains(err.Error(), "lfg gc") || !strings.Contains(err.Error(), "me@devbox") {
+		t.Errorf("RequireLocal() with a remote = %v, want it to say to run lfg gc on me@devbox", err)
+	}
+	// On the host, its worktrees are this machine's
+	t.Setenv(OnHostEnv, "1")
+	if err := cfg.RequireLocal("lfg gc"); err != nil {
+		t.Errorf("RequireLocal() on the host = %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// gitTimeout bounds the git commands that find the repository and place lfg's files in it
+const gitTimeout = 30 * time.Second
+
+// commands runs git on the clone the config is kept in, which is this machine's even when
+// the worktrees are managed on a remote host. It's swapped out by tests.
+var commands runner.Runner = runner.Exec{Timeout: gitTimeout}
+
+// SetRunner makes the package run git through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// gitOutput runs git in the repository at root, returning its trimmed output
+func gitOutput(root string, args ...string) (string, error) {
+	output, err := commands.Run(context.Background(), nil, "git", append([]string{"-C", root}, args...)...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// Where lfg keeps lfg-config.yaml and .lfg/, and how git is kept from committing them
+const (
+	FilesExclude   = "exclude"   // In the repository, listed in .git/info/exclude for this clone only
+	FilesGitignore = "gitignore" // In the repository, listed in .gitignore for everyone
+	FilesCommit    = "commit"    // lfg-config.yaml is committed for the team, .lfg/ listed in .gitignore
+	FilesGitDir    = "git_dir"   // Under .git/lfg, out of the working tree altogether
+)
+
+// gitDirName is the directory in the repository's .git that lfg's files are kept in with
+// files: git_dir
+const gitDirName = "lfg"
+
+// stateDirName is the directory lfg keeps local state in next to a config in the repository
+const stateDirName = ".lfg"
+
+// inGitDir reports whether the config at configPath is kept under .git/lfg
+func inGitDir(configPath string) bool {
+	dir := filepath.Dir(configPath)
+	return filepath.Base(dir) == gitDirName && filepath.Base(filepath.Dir(dir)) == ".git"
+}
+
+// StateDir returns the directory lfg keeps local state in for the config at configPath:
+// .lfg next to it, or the config's own directory under .git/lfg
+func StateDir(configPath string) string {
+	if inGitDir(configPath) {
+		return filepath.Dir(configPath)
+	}
+	return filepath.Join(filepath.Dir(configPath), stateDirName)
+}
+
+// rootOf returns the repository's main worktree for the config at configPath
+func rootOf(configPath string) string {
+	if inGitDir(configPath) {
+		return filepath.Dir(filepath.Dir(filepath.Dir(configPath)))
+	}
+	return filepath.Dir(configPath)
+}
+
+// Root returns the repository's main worktree, which paths in the config are relative to
+func (c *Config) Root() string {
+	return rootOf(c.configPath)
+}
+
+// gitDir returns the .git directory of the repository at root, shared by all its worktrees
+func gitDir(root string) (string, error) {
+	dir, err := gitOutput(root, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the .git directory: %w", err)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir, nil
+}
+
+// configPathFor returns where the config of the repository at root is kept with files
+func configPathFor(root, files string) (string, error) {
+	if files != FilesGitDir {
+		return filepath.Join(root, configFileName), nil
+	}
+	dir, err := gitDir(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, gitDirName, configFileName), nil
+}
+
+// findConfig returns the config of the repository at root, in the repository or else under
+// .git/lfg, and whether there is one
+func findConfig(root string) (string, bool) {
+	configPath := filepath.Join(root, configFileName)
+	if _, err := os.Stat(configPath); err == nil {
+		return configPath, true
+	}
+	if hidden, err := configPathFor(root, FilesGitDir); err == nil {
+		if _, err := os.Stat(hidden); err == nil {
+			return hidden, true
+		}
+	}
+	return configPath, false
+}
+
+// IgnoreFiles keeps git from committing lfg's files as files says, adding lfg-config.yaml
+// and .lfg/ to .git/info/exclude or .gitignore unless git ignores them already. Files kept
+// under .git/lfg, or without files set, are left as they are. It's done once, as the init
+// wizard writes the config.
+func (c *Config) IgnoreFiles() error {
+	var entries []string
+	var ignoreFile string
+	switch c.Files {
+	case "", FilesGitDir:
+		return nil
+	case FilesExclude, FilesGitignore:
+		entries = []string{"/" + configFileName, "/" + stateDirName + "/"}
+	case FilesCommit:
+		entries = []string{"/" + stateDirName + "/"}
+	default:
+		return fmt.Errorf("unknown files %q, want %s, %s, %s or %s", c.Files, FilesExclude, FilesGitignore, FilesCommit, FilesGitDir)
+	}
+
+	root := c.Root()
+	if c.Files == FilesExclude {
+		dir, err := gitDir(root)
+		if err != nil {
+			return err
+		}
+		ignoreFile = filepath.Join(dir, "info", "exclude")
+	} else {
+		ignoreFile = filepath.Join(root, ".gitignore")
+	}
+
+	var missing []string
+	for _, entry := range entries {
+		ignored, err := isIgnored(root, entry)
+		if err != nil {
+			return err
+		}
+		if !ignored {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := appendLines(ignoreFile, missing); err != nil {
+		return fmt.Errorf("failed to add lfg's files to %s: %w", ignoreFile, err)
+	}
+	return nil
+}
+
+// isIgnored reports whether git already ignores entry, an ignore pattern for lfg-config.yaml
+// or .lfg/, by any of its ignore files
+func isIgnored(root, entry string) (bool, error) {
+	// A directory that may not exist yet is checked by a file in it
+	name := strings.TrimPrefix(entry, "/")
+	if strings.HasSuffix(name, "/") {
+		name += "state.yaml"
+	}
+	_, err := gitOutput(root, "check-ignore", "--quiet", "--no-index", name)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check git's ignore files: %w", err)
+	}
+}
+
+// appendLines adds lines to the end of the file at path, on lines of their own
+func appendLines(path string, lines []string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	text := strings.Join(lines, "\n") + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		text = "\n" + text
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,24 +1,31 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/markcipolla/lfg/internal/browser"
 	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
 )
 
 func runInitWizard(configPath, repoRoot string) (*Config, error) {
 	// Get default project name from directory
 	defaultName := filepath.Base(repoRoot)
 
-	m := &initModel{
-		step:        stepProjectName,
-		projectName: defaultName,
-		configPath:  configPath,
-	}
+	// There's no config to read a theme from yet, so follow the terminal background
+	theme, _ := (*Config)(nil).ResolveTheme()
+	applyTheme(theme)
+	(*Config)(nil).UseLanguage()
 
+	m := newInitModel(configPath, defaultName)
 	p := tea.NewProgram(m)
 	finalModel, err := p.Run()
 	if err != nil {
@@ -37,6 +44,7 @@ type initStep int
 
 const (
 	stepProjectName initStep = iota
+	stepFiles
 	stepStorageBackend
 	stepGitHubAuth
 	stepGitHubProjectSelect
@@ -45,15 +53,22 @@ const (
 )
 
 type initModel struct {
-	step            initStep
-	projectName     string
-	storageChoice   int // 0 = Local, 1 = GitHub
-	githubSetup     *githubSetupState
-	configPath      string
-	config          *Config
-	cancelled       bool
-	width           int
-	height          int
+	step          initStep
+	previous      []initStep // The steps before this one, for going back
+	projectName   textinput.Model
+	filesChoice   int // Index into filesOptions
+	storageChoice int // Index into storageOptions
+	githubSetup   *githubSetupState
+	spinner       spinner.Model
+	loading       string             // What gh is being waited on for, "" when nothing is
+	deviceCode    *github.DeviceCode // The code to enter on GitHub while logging in
+	stopLogin     context.CancelFunc // Gives up waiting for the code to be entered
+	saveError     string
+	configPath    string
+	config        *Config
+	cancelled     bool
+	width         int
+	height        int
 }
 
 type githubSetupState struct {
@@ -61,11 +76,32 @@ type githubSetupState struct {
 	repo            string
 	projects        []githubProject
 	selectedProject int
-	projectName     string
+	projectName     textinput.Model // The GitHub project to create
 	authStatus      string
 	authError       string
 }
 
+func newInitModel(configPath, defaultName string) *initModel {
+	return &initModel{
+		step:        stepProjectName,
+		projectName: newTextInput(defaultName),
+		spinner:     spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(spinnerStyle)),
+		configPath:  configPath,
+	}
+}
+
+// newTextInput is a focused input holding value, which is typed over, pasted into and
+// edited as any other
+func newTextInput(value string) textinput.Model {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.CharLimit = 100
+	input.Width = 50
+	input.SetValue(value)
+	input.Focus()
+	return input
+}
+
 type githubProject struct {
 	ID     string
 	Number int
@@ -88,41 +124,57 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")).
 			Bold(true)
+
+	spinnerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205"))
 )
 
+// applyTheme recolours the wizard's styles
+func applyTheme(theme Theme) {
+	titleStyle = titleStyle.Foreground(lipgloss.Color(theme.Accent))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.Muted))
+	selectedStyle = selectedStyle.Foreground(lipgloss.Color(theme.Highlight))
+	errorStyle = errorStyle.Foreground(lipgloss.Color(theme.Error))
+	spinnerStyle = spinnerStyle.Foreground(lipgloss.Color(theme.Spinner))
+}
+
 func (m *initModel) Init() tea.Cmd {
-	return nil
+	return textinput.Blink
 }
 
 func (m *initModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "esc":
+		case "ctrl+c":
 			m.cancelled = true
 			return m, tea.Quit
-
+		case "esc":
+			return m.back()
 		case "enter":
 			return m.handleEnter()
-
+		}
+		if input := m.input(); input != nil {
+			var cmd tea.Cmd
+			*input, cmd = input.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
 		case "up", "k":
 			return m.handleUp()
-
 		case "down", "j":
 			return m.handleDown()
-
-		case "backspace":
-			return m.handleBackspace()
-
 		case "a":
-			if m.step == stepGitHubAuth {
+			if m.step == stepGitHubAuth && m.loading == "" {
+				return m.handleGitHubLogin()
+			}
+		case "r":
+			if m.step == stepGitHubAuth && m.loading == "" {
 				return m.handleGitHubAuth()
 			}
-
-		default:
-			// Handle character input for text fields
-			if len(msg.String()) == 1 {
-				return m.handleChar(msg.String()[0])
+		case "o":
+			if m.deviceCode != nil {
+				browser.Open(m.deviceCode.VerificationURI)
 			}
 		}
 
@@ -130,24 +182,83 @@ func (m *initModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
+	case spinner.TickMsg:
+		if m.loading == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
 	case authCheckMsg:
+		// Gone back while gh was asked
+		if m.step != stepGitHubAuth || m.loading == "" {
+			return m, nil
+		}
+		m.loading = ""
 		m.githubSetup = msg.setup
-		// Automatically proceed if auth was successful
-		if msg.setup != nil && msg.setup.authError == "" {
-			if len(msg.setup.projects) > 0 {
-				m.step = stepGitHubProjectSelect
-			} else {
-				m.step = stepGitHubProjectName
-			}
+		if msg.setup.authError != "" {
+			return m, nil
 		}
-		return m, nil
+		// Issues don't need a project
+		if m.storageChoice == storageGitHubIssues {
+			return m.completeSetup(&StorageBackend{
+				Type:  "github-issues",
+				Owner: msg.setup.owner,
+				Repo:  msg.setup.repo,
+			})
+		}
+		m.loading = i18n.T("Loading projects...")
+		return m, tea.Batch(m.spinner.Tick, listGitHubProjects(msg.setup.owner, msg.setup.repo))
+
+	case deviceCodeMsg:
+		if m.step != stepGitHubAuth || m.loading == "" || m.stopLogin != nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.loading = ""
+			m.githubSetup = &githubSetupState{authError: msg.err.Error()}
+			return m, nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		m.deviceCode, m.stopLogin = msg.code, cancel
+		m.loading = i18n.T("Waiting for the code to be entered...")
+		return m, waitForLogin(ctx, msg.code)
+
+	case loginMsg:
+		if errors.Is(msg.err, context.Canceled) || m.stopLogin == nil {
+			return m, nil
+		}
+		m.stopLogin()
+		m.deviceCode, m.stopLogin = nil, nil
+		if msg.err != nil {
+			m.loading = ""
+			m.githubSetup = &githubSetupState{authError: i18n.T("Failed to log in: %v", msg.err)}
+			return m, nil
+		}
+		return m.handleGitHubAuth()
+
+	case projectsMsg:
+		if m.step != stepGitHubAuth || m.loading == "" || m.githubSetup == nil {
+			return m, nil
+		}
+		m.loading = ""
+		if msg.err != nil {
+			m.githubSetup.authError = i18n.T("Failed to list projects: %v", msg.err)
+			return m, nil
+		}
+		m.githubSetup.projects = msg.projects
+		if len(msg.projects) > 0 {
+			m.githubSetup.authStatus = i18n.T("✓ Found %d project(s)", len(msg.projects))
+		} else {
+			m.githubSetup.authStatus = i18n.T("✓ Authenticated. No projects found, will create new one.")
+		}
+		return m.forward()
 
 	case projectCreateMsg:
+		m.loading = ""
 		if msg.err != nil {
-			if m.githubSetup != nil {
-				m.githubSetup.authError = fmt.Sprintf("Failed to create project: %v", msg.err)
-			}
-			m.step = stepGitHubProjectName
+			m.githubSetup.authError = i18n.T("Failed to create project: %v", msg.err)
 			return m, nil
 		}
 
@@ -164,153 +275,271 @@ func (m *initModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// input is the text input the current step is typed into, nil for one chosen from a list
+func (m *initModel) input() *textinput.Model {
+	switch m.step {
+	case stepProjectName:
+		return &m.projectName
+	case stepGitHubProjectName:
+		if m.githubSetup != nil && m.loading == "" {
+			return &m.githubSetup.projectName
+		}
+	}
+	return nil
+}
+
+// goTo moves on to step, remembering this one to come back to
+func (m *initModel) goTo(step initStep) {
+	m.previous = append(m.previous, m.step)
+	m.step = step
+}
+
+// back returns to the step before, leaving anything gh is still being asked for to be
+// ignored, or cancels from the first. A project being created can't be taken back, and
+// nor can the saved config.
+func (m *initModel) back() (tea.Model, tea.Cmd) {
+	if m.stopLogin != nil {
+		m.stopLogin()
+		m.deviceCode, m.stopLogin = nil, nil
+		m.loading = ""
+		return m, nil
+	}
+	switch {
+	case m.step == stepComplete:
+		return m, tea.Quit
+	case m.step == stepGitHubProjectName && m.loading != "":
+		return m, nil
+	case len(m.previous) == 0:
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	m.step = m.previous[len(m.previous)-1]
+	m.previous = m.previous[:len(m.previous)-1]
+	m.loading = ""
+	return m, nil
+}
+
+// forward moves on from authenticating to picking a project, or to naming a new one
+// when there are none
+func (m *initModel) forward() (tea.Model, tea.Cmd) {
+	if len(m.githubSetup.projects) > 0 {
+		m.goTo(stepGitHubProjectSelect)
+		return m, nil
+	}
+	if m.githubSetup.projectName.Value() == "" {
+		m.githubSetup.projectName = newTextInput(m.projectName.Value())
+	}
+	m.goTo(stepGitHubProjectName)
+	return m, textinput.Blink
+}
+
 func (m *initModel) View() string {
+	var view string
 	switch m.step {
 	case stepProjectName:
-		return m.viewProjectName()
+		view = m.viewProjectName()
+	case stepFiles:
+		view = m.viewFiles()
 	case stepStorageBackend:
-		return m.viewStorageBackend()
+		view = m.viewStorageBackend()
 	case stepGitHubAuth:
-		return m.viewGitHubAuth()
+		view = m.viewGitHubAuth()
 	case stepGitHubProjectSelect:
-		return m.viewGitHubProjectSelect()
+		view = m.viewGitHubProjectSelect()
 	case stepGitHubProjectName:
-		return m.viewGitHubProjectName()
+		view = m.viewGitHubProjectName()
 	case stepComplete:
-		return m.viewComplete()
+		view = m.viewComplete()
 	}
-	return ""
+	if m.saveError != "" {
+		view += "\n" + errorStyle.Render(m.saveError) + "\n"
+	}
+	return view
 }
 
 func (m *initModel) viewProjectName() string {
 	return fmt.Sprintf(
-		"%s\n\nProject Name:\n> %s\n\n%s\n",
-		titleStyle.Render("LFG Initialization"),
-		m.projectName,
-		helpStyle.Render("Enter: Continue | Type to edit | Esc: Cancel"),
+		"%s\n\n%s\n%s\n\n%s\n",
+		titleStyle.Render(i18n.T("LFG Initialization")),
+		i18n.T("Project Name:"),
+		m.projectName.View(),
+		helpStyle.Render(i18n.T("Enter: Continue | Type to edit | Esc: Cancel")),
 	)
 }
 
-func (m *initModel) viewStorageBackend() string {
-	options := []string{
-		"Local YAML (todos stored in lfg-config.yaml)",
-		"GitHub Projects (todos synced with GitHub)",
+// filesOptions are the places the wizard offers to keep lfg's files in, in order
+var filesOptions = []struct {
+	files string
+	label string
+}{
+	{FilesExclude, "Keep them out of git in this clone (.git/info/exclude)"},
+	{FilesGitignore, "Keep them out of git for everyone (.gitignore)"},
+	{FilesCommit, "Commit lfg-config.yaml for the team, keep .lfg/ out (.gitignore)"},
+	{FilesGitDir, "Keep them in .git/lfg, out of the working tree"},
+}
+
+func (m *initModel) viewFiles() string {
+	result := titleStyle.Render(i18n.T("Where should lfg keep lfg-config.yaml and .lfg/?")) + "\n\n"
+	for i, opt := range filesOptions {
+		label := i18n.T(opt.label)
+		if i == m.filesChoice {
+			result += selectedStyle.Render("> "+label) + "\n"
+		} else {
+			result += "  " + label + "\n"
+		}
 	}
 
-	result := titleStyle.Render("Choose Todo Storage Backend") + "\n\n"
-	for i, opt := range options {
+	result += "\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Select | Esc: Back"))
+	return result
+}
+
+// storageOptions are the backends the wizard offers, in order
+var storageOptions = []string{
+	"Local YAML (todos stored in lfg-config.yaml)",
+	"GitHub Projects (todos synced with GitHub)",
+	"GitHub Issues (todos are issues, no project board needed)",
+	"Org file (todos are headlines in " + defaultOrgFile + ")",
+}
+
+const (
+	storageLocal = iota
+	storageGitHubProject
+	storageGitHubIssues
+	storageOrg
+)
+
+func (m *initModel) viewStorageBackend() string {
+	result := titleStyle.Render(i18n.T("Choose Todo Storage Backend")) + "\n\n"
+	for i, opt := range storageOptions {
+		opt = i18n.T(opt)
 		cursor := "  "
 		if i == m.storageChoice {
 			cursor = "> "
-			result += selectedStyle.Render(cursor + opt) + "\n"
+			result += selectedStyle.Render(cursor+opt) + "\n"
 		} else {
 			result += cursor + opt + "\n"
 		}
 	}
 
-	result += "\n" + helpStyle.Render("↑↓/jk: Navigate | Enter: Select | Esc: Cancel")
+	result += "\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Select | Esc: Back"))
 	return result
 }
 
 func (m *initModel) viewGitHubAuth() string {
-	status := "Checking authentication..."
-	if m.githubSetup != nil {
-		if m.githubSetup.authError != "" {
-			status = errorStyle.Render("✗ " + m.githubSetup.authError)
-		} else if m.githubSetup.authStatus != "" {
-			status = m.githubSetup.authStatus
-		}
+	if m.deviceCode != nil {
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n  %s\n\n%s\n\n%s\n",
+			titleStyle.Render(i18n.T("GitHub Authentication")),
+			i18n.T("Open %s and enter this code:", m.deviceCode.VerificationURI),
+			selectedStyle.Render(m.deviceCode.UserCode),
+			m.spinner.View()+" "+m.loading,
+			helpStyle.Render(i18n.T("o: Open in browser | Esc: Cancel login")),
+		)
+	}
+
+	status := ""
+	switch {
+	case m.loading != "":
+		status = m.spinner.View() + " " + m.loading
+	case m.githubSetup != nil && m.githubSetup.authError != "":
+		status = errorStyle.Render("✗ " + m.githubSetup.authError)
+	case m.githubSetup != nil:
+		status = m.githubSetup.authStatus
 	}
 
 	return fmt.Sprintf(
 		"%s\n\n%s\n\n%s\n",
-		titleStyle.Render("GitHub Authentication"),
+		titleStyle.Render(i18n.T("GitHub Authentication")),
 		status,
-		helpStyle.Render("a: Authenticate | Esc: Cancel"),
+		helpStyle.Render(i18n.T("a: Log in to GitHub | r: Check again | Esc: Back")),
 	)
 }
 
 func (m *initModel) viewGitHubProjectSelect() string {
 	if m.githubSetup == nil || len(m.githubSetup.projects) == 0 {
-		return "No projects found"
+		return i18n.T("No projects found")
 	}
 
-	result := titleStyle.Render("Select GitHub Project") + "\n\n"
+	result := titleStyle.Render(i18n.T("Select GitHub Project")) + "\n\n"
 	for i, proj := range m.githubSetup.projects {
-		cursor := "  "
+		option := i18n.T("%s (Project #%d)", proj.Title, proj.Number)
 		if i == m.githubSetup.selectedProject {
-			cursor = "> "
-			result += selectedStyle.Render(fmt.Sprintf("%s%s (Project #%d)", cursor, proj.Title, proj.Number)) + "\n"
+			result += selectedStyle.Render("> "+option) + "\n"
 		} else {
-			result += fmt.Sprintf("%s%s (Project #%d)", cursor, proj.Title, proj.Number) + "\n"
+			result += "  " + option + "\n"
 		}
 	}
 
-	result += "\n" + helpStyle.Render("↑↓/jk: Navigate | Enter: Select | Esc: Cancel")
+	result += "\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Select | Esc: Back"))
 	return result
 }
 
 func (m *initModel) viewGitHubProjectName() string {
-	repoInfo := ""
-	if m.githubSetup != nil {
-		repoInfo = fmt.Sprintf("%s/%s", m.githubSetup.owner, m.githubSetup.repo)
-	}
-
-	errorMsg := ""
-	if m.githubSetup != nil && m.githubSetup.authError != "" {
-		errorMsg = "\n\n" + errorStyle.Render("Error: "+m.githubSetup.authError)
-	}
+	repoInfo := fmt.Sprintf("%s/%s", m.githubSetup.owner, m.githubSetup.repo)
 
-	projectName := m.projectName
-	if m.githubSetup != nil && m.githubSetup.projectName != "" {
-		projectName = m.githubSetup.projectName
+	status := ""
+	if m.loading != "" {
+		status = "\n\n" + m.spinner.View() + " " + m.loading
+	} else if m.githubSetup.authError != "" {
+		status = "\n\n" + errorStyle.Render(i18n.T("Error: %s", m.githubSetup.authError))
 	}
 
 	return fmt.Sprintf(
-		"%s\n\nNo GitHub Projects found for %s\n\nProject Name:\n> %s%s\n\n%s\n",
-		titleStyle.Render("Create GitHub Project"),
-		repoInfo,
-		projectName,
-		errorMsg,
-		helpStyle.Render("Enter: Create Project | Type to edit | Esc: Cancel"),
+		"%s\n\n%s\n\n%s\n%s%s\n\n%s\n",
+		titleStyle.Render(i18n.T("Create GitHub Project")),
+		i18n.T("No GitHub Projects found for %s", repoInfo),
+		i18n.T("Project Name:"),
+		m.githubSetup.projectName.View(),
+		status,
+		helpStyle.Render(i18n.T("Enter: Create Project | Type to edit | Esc: Back")),
 	)
 }
 
 func (m *initModel) viewComplete() string {
-	backendInfo := "Local YAML"
-	if m.config != nil && m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-		backendInfo = fmt.Sprintf("GitHub Projects (%s/%s #%d)",
+	backendInfo := i18n.T("Local YAML")
+	if m.config != nil && m.config.StorageBackend.UsesProject() {
+		backendInfo = i18n.T("GitHub Projects (%s/%s #%d)",
 			m.config.StorageBackend.Owner,
 			m.config.StorageBackend.Repo,
 			m.config.StorageBackend.ProjectNumber)
+	} else if m.config != nil && m.config.StorageBackend.IsGitHub() {
+		backendInfo = i18n.T("GitHub Issues (%s/%s)", m.config.StorageBackend.Owner, m.config.StorageBackend.Repo)
+	} else if m.config != nil && m.config.StorageBackend.IsOrg() {
+		backendInfo = i18n.T("Org file (%s)", defaultOrgFile)
 	}
 
 	return fmt.Sprintf(
-		"%s\n\n✓ Configuration created successfully!\n\nProject: %s\nStorage: %s\n\n%s\n",
-		titleStyle.Render("Setup Complete"),
-		m.projectName,
-		backendInfo,
-		helpStyle.Render("Press Enter to continue..."),
+		"%s\n\n%s\n\n%s\n%s\n\n%s\n",
+		titleStyle.Render(i18n.T("Setup Complete")),
+		i18n.T("✓ Configuration created successfully!"),
+		i18n.T("Project: %s", m.config.Name),
+		i18n.T("Storage: %s", backendInfo),
+		helpStyle.Render(i18n.T("Press Enter to continue...")),
 	)
 }
 
 func (m *initModel) handleEnter() (tea.Model, tea.Cmd) {
 	switch m.step {
 	case stepProjectName:
-		m.step = stepStorageBackend
+		if m.projectName.Value() == "" {
+			return m, nil
+		}
+		m.goTo(stepFiles)
+	case stepFiles:
+		m.goTo(stepStorageBackend)
 	case stepStorageBackend:
-		if m.storageChoice == 1 {
-			m.step = stepGitHubAuth
-			return m, m.checkGitHubAuth
+		switch m.storageChoice {
+		case storageLocal:
+			return m.completeSetup(nil)
+		case storageOrg:
+			return m.completeSetup(&StorageBackend{Type: "org"})
 		}
-		// Local storage selected
-		return m.completeSetup(nil)
+		m.goTo(stepGitHubAuth)
+		return m.handleGitHubAuth()
 	case stepGitHubAuth:
-		// Move to project selection or creation
-		if m.githubSetup != nil && len(m.githubSetup.projects) > 0 {
-			m.step = stepGitHubProjectSelect
-		} else if m.githubSetup != nil {
-			m.step = stepGitHubProjectName
+		// Move to project selection or creation, once they're loaded
+		if m.loading == "" && m.githubSetup != nil && m.githubSetup.authError == "" && m.githubSetup.owner != "" {
+			return m.forward()
 		}
 	case stepGitHubProjectSelect:
 		if m.githubSetup != nil && m.githubSetup.selectedProject < len(m.githubSetup.projects) {
@@ -324,8 +553,12 @@ func (m *initModel) handleEnter() (tea.Model, tea.Cmd) {
 			return m.completeSetup(backend)
 		}
 	case stepGitHubProjectName:
-		// Create new project
-		return m, m.createGitHubProject
+		if m.loading != "" || m.githubSetup.projectName.Value() == "" {
+			return m, nil
+		}
+		m.githubSetup.authError = ""
+		m.loading = i18n.T("Creating project...")
+		return m, tea.Batch(m.spinner.Tick, createGitHubProject(m.githubSetup.owner, m.githubSetup.repo, m.githubSetup.projectName.Value()))
 	case stepComplete:
 		return m, tea.Quit
 	}
@@ -334,8 +567,10 @@ func (m *initModel) handleEnter() (tea.Model, tea.Cmd) {
 
 func (m *initModel) handleUp() (tea.Model, tea.Cmd) {
 	switch m.step {
+	case stepFiles:
+		m.filesChoice = (m.filesChoice + len(filesOptions) - 1) % len(filesOptions)
 	case stepStorageBackend:
-		m.storageChoice = (m.storageChoice + 1) % 2
+		m.storageChoice = (m.storageChoice + len(storageOptions) - 1) % len(storageOptions)
 	case stepGitHubProjectSelect:
 		if m.githubSetup != nil && m.githubSetup.selectedProject > 0 {
 			m.githubSetup.selectedProject--
@@ -346,8 +581,10 @@ func (m *initModel) handleUp() (tea.Model, tea.Cmd) {
 
 func (m *initModel) handleDown() (tea.Model, tea.Cmd) {
 	switch m.step {
+	case stepFiles:
+		m.filesChoice = (m.filesChoice + 1) % len(filesOptions)
 	case stepStorageBackend:
-		m.storageChoice = (m.storageChoice + 1) % 2
+		m.storageChoice = (m.storageChoice + 1) % len(storageOptions)
 	case stepGitHubProjectSelect:
 		if m.githubSetup != nil && m.githubSetup.selectedProject < len(m.githubSetup.projects)-1 {
 			m.githubSetup.selectedProject++
@@ -356,44 +593,45 @@ func (m *initModel) handleDown() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *initModel) handleBackspace() (tea.Model, tea.Cmd) {
-	switch m.step {
-	case stepProjectName:
-		if len(m.projectName) > 0 {
-			m.projectName = m.projectName[:len(m.projectName)-1]
-		}
-	case stepGitHubProjectName:
-		if m.githubSetup != nil && len(m.githubSetup.projectName) > 0 {
-			m.githubSetup.projectName = m.githubSetup.projectName[:len(m.githubSetup.projectName)-1]
-		}
-	}
-	return m, nil
+// handleGitHubAuth checks gh's login in the background, with the spinner going
+func (m *initModel) handleGitHubAuth() (tea.Model, tea.Cmd) {
+	m.loading = i18n.T("Checking authentication...")
+	return m, tea.Batch(m.spinner.Tick, checkGitHubAuth(m.storageChoice == storageGitHubIssues))
 }
 
-func (m *initModel) handleChar(c byte) (tea.Model, tea.Cmd) {
-	switch m.step {
-	case stepProjectName:
-		m.projectName += string(c)
-	case stepGitHubProjectName:
-		if m.githubSetup != nil {
-			if m.githubSetup.projectName == "" {
-				m.githubSetup.projectName = m.projectName
-			}
-			m.githubSetup.projectName += string(c)
-		}
+// handleGitHubLogin starts gh's login in the browser, for the user to enter the code it
+// gives while the wizard waits
+func (m *initModel) handleGitHubLogin() (tea.Model, tea.Cmd) {
+	m.loading = i18n.T("Asking GitHub for a login code...")
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		code, err := github.StartLogin()
+		return deviceCodeMsg{code: code, err: err}
+	})
+}
+
+// waitForLogin waits for the code to be entered and gh to be given its token
+func waitForLogin(ctx context.Context, code *github.DeviceCode) tea.Cmd {
+	return func() tea.Msg {
+		return loginMsg{err: code.Wait(ctx)}
 	}
-	return m, nil
 }
 
-func (m *initModel) handleGitHubAuth() (tea.Model, tea.Cmd) {
-	// This would trigger the actual GitHub authentication
-	// For now, we'll implement a simplified version
-	return m, m.checkGitHubAuth
+type deviceCodeMsg struct {
+	code *github.DeviceCode
+	err  error
+}
+
+type loginMsg struct {
+	err error
 }
 
 type authCheckMsg struct {
 	setup *githubSetupState
-	err   error
+}
+
+type projectsMsg struct {
+	projects []githubProject
+	err      error
 }
 
 type projectCreateMsg struct {
@@ -401,94 +639,93 @@ type projectCreateMsg struct {
 	err     error
 }
 
-func (m *initModel) checkGitHubAuth() tea.Msg {
-	setup := &githubSetupState{}
-
-	// Check if authenticated
-	if !github.IsAuthenticated() {
-		setup.authError = "Not authenticated. Press 'a' to authenticate with GitHub CLI"
-		return authCheckMsg{setup: setup}
-	}
+// checkGitHubAuth checks gh is logged in with the scopes needed and finds the repository.
+// Issues only need the repo scope gh logs in with, not project.
+func checkGitHubAuth(issuesOnly bool) tea.Cmd {
+	return func() tea.Msg {
+		setup := &githubSetupState{}
 
-	// Check if has required scopes
-	hasScopes, err := github.HasRequiredScopes()
-	if err != nil {
-		setup.authError = fmt.Sprintf("Failed to check scopes: %v", err)
-		return authCheckMsg{err: err, setup: setup}
-	}
-
-	if !hasScopes {
-		setup.authError = "Missing required scopes. Press 'a' to re-authenticate with project and repo scopes"
-		return authCheckMsg{setup: setup}
-	}
-
-	// Get repo info
-	repoInfo, err := github.GetRepoInfo()
-	if err != nil {
-		setup.authError = fmt.Sprintf("Failed to get repository info: %v", err)
-		return authCheckMsg{err: err, setup: setup}
-	}
-
-	setup.owner = repoInfo.Owner
-	setup.repo = repoInfo.Name
-	setup.authStatus = "✓ Authenticated successfully! Loading projects..."
+		// Check if authenticated
+		if !github.IsAuthenticated() {
+			setup.authError = i18n.T("Not logged in to GitHub. Press 'a' to log in")
+			return authCheckMsg{setup: setup}
+		}
 
-	// List projects
-	projects, err := github.ListProjects(repoInfo.Owner, repoInfo.Name)
-	if err != nil {
-		setup.authError = fmt.Sprintf("Failed to list projects: %v", err)
-		return authCheckMsg{err: err, setup: setup}
-	}
+		// Check if has required scopes
+		hasScopes, err := github.HasRequiredScopes()
+		if err != nil {
+			setup.authError = i18n.T("Failed to check scopes: %v", err)
+			return authCheckMsg{setup: setup}
+		}
+		if !hasScopes && !issuesOnly {
+			setup.authError = i18n.T("Missing the project and repo scopes. Press 'a' to log in again with them")
+			return authCheckMsg{setup: setup}
+		}
 
-	// Convert to our type
-	for _, p := range projects {
-		setup.projects = append(setup.projects, githubProject{
-			ID:     p.ID,
-			Number: p.Number,
-			Title:  p.Title,
-		})
-	}
+		// Get repo info
+		repoInfo, err := github.GetRepoInfo()
+		if err != nil {
+			setup.authError = i18n.T("Failed to get repository info: %v", err)
+			return authCheckMsg{setup: setup}
+		}
 
-	if len(setup.projects) > 0 {
-		setup.authStatus = fmt.Sprintf("✓ Found %d project(s)", len(setup.projects))
-	} else {
-		setup.authStatus = "✓ Authenticated. No projects found, will create new one."
+		setup.owner = repoInfo.Owner
+		setup.repo = repoInfo.Name
+		setup.authStatus = i18n.T("✓ Authenticated successfully!")
+		return authCheckMsg{setup: setup}
 	}
-
-	return authCheckMsg{setup: setup}
 }
 
-func (m *initModel) createGitHubProject() tea.Msg {
-	if m.githubSetup == nil {
-		return projectCreateMsg{err: fmt.Errorf("GitHub not set up")}
+// listGitHubProjects lists the projects the repository's owner has
+func listGitHubProjects(owner, repo string) tea.Cmd {
+	return func() tea.Msg {
+		projects, err := github.ListProjects(owner, repo)
+		if err != nil {
+			return projectsMsg{err: err}
+		}
+		var found []githubProject
+		for _, p := range projects {
+			found = append(found, githubProject{
+				ID:     p.ID,
+				Number: p.Number,
+				Title:  p.Title,
+			})
+		}
+		return projectsMsg{projects: found}
 	}
+}
 
-	projectName := m.githubSetup.projectName
-	if projectName == "" {
-		projectName = m.projectName
+func createGitHubProject(owner, repo, title string) tea.Cmd {
+	return func() tea.Msg {
+		project, err := github.CreateProject(owner, repo, title)
+		if err != nil {
+			return projectCreateMsg{err: err}
+		}
+		return projectCreateMsg{
+			project: &githubProject{
+				ID:     project.ID,
+				Number: project.Number,
+				Title:  project.Title,
+			},
+		}
 	}
+}
 
-	project, err := github.CreateProject(m.githubSetup.owner, m.githubSetup.repo, projectName)
+func (m *initModel) completeSetup(backend *StorageBackend) (tea.Model, tea.Cmd) {
+	files := filesOptions[m.filesChoice].files
+	configPath, err := configPathFor(rootOf(m.configPath), files)
 	if err != nil {
-		return projectCreateMsg{err: err}
-	}
-
-	return projectCreateMsg{
-		project: &githubProject{
-			ID:     project.ID,
-			Number: project.Number,
-			Title:  project.Title,
-		},
+		m.saveError = i18n.T("Failed to save config: %v", err)
+		return m, nil
 	}
-}
 
-func (m *initModel) completeSetup(backend *StorageBackend) (tea.Model, tea.Cmd) {
 	// Create default config with new layout format
 	// Description pane is automatic (always top 10%), so layout only defines the remaining 90%
 	m.config = &Config{
-		Name:           m.projectName,
+		Name:           m.projectName.Value(),
 		WorktreeNaming: "Add feature",
 		StorageBackend: backend,
+		Files:          files,
 		Todos:          []Todo{},
 		Layout: []LayoutRow{
 			{
@@ -496,8 +733,8 @@ func (m *initModel) completeSetup(backend *StorageBackend) (tea.Model, tea.Cmd)
 				Name:   "code",
 			},
 			{
-				Height: "34%",
-				Name:   "server",
+				Height:  "34%",
+				Name:    "server",
 				Command: stringPtr("claude --dangerously-skip-permissions"),
 			},
 			{
@@ -505,17 +742,24 @@ func (m *initModel) completeSetup(backend *StorageBackend) (tea.Model, tea.Cmd)
 				Name:   "shell",
 			},
 		},
-		configPath: m.configPath,
+		configPath: configPath,
 	}
 
 	// Save config
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		m.saveError = i18n.T("Failed to save config: %v", err)
+		return m, nil
+	}
 	if err := m.config.Save(); err != nil {
-		m.githubSetup = &githubSetupState{
-			authError: fmt.Sprintf("Failed to save config: %v", err),
-		}
+		m.saveError = i18n.T("Failed to save config: %v", err)
+		return m, nil
+	}
+	if err := m.config.IgnoreFiles(); err != nil {
+		m.saveError = i18n.T("Failed to keep lfg's files out of git: %v", err)
 		return m, nil
 	}
 
+	m.saveError = ""
 	m.step = stepComplete
 	return m, nil
 }
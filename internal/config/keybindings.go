@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Keybindings maps TUI actions to the keys that trigger them
+type Keybindings map[string][]string
+
+// Actions that can be bound to keys in the TUI
+const (
+	ActionQuit        = "quit"
+	ActionOpen        = "open"
+	ActionNew         = "new"
+	ActionBranches    = "branches"
+	ActionDelete      = "delete"
+	ActionRefresh     = "refresh"
+	ActionMark        = "mark"
+	ActionStatus      = "status"
+	ActionCycleStatus = "cycle_status"
+	ActionSync        = "sync"
+	ActionSort        = "sort"
+	ActionPreview     = "preview"
+	ActionPauseMirror = "pause_mirror"
+	ActionFlushMirror = "flush_mirror"
+	ActionEdit        = "edit"
+	ActionBrowse      = "browse"
+	ActionCopyBranch  = "copy_branch"
+	ActionCopyPath    = "copy_path"
+	ActionCopyURL     = "copy_url"
+	ActionArchived    = "show_archived"
+	ActionRaise       = "raise_priority"
+	ActionLower       = "lower_priority"
+	ActionChecklist   = "checklist"
+	ActionTagFilter   = "filter_tag"
+	ActionFeed        = "feed"
+	ActionScratchpad  = "scratchpad"
+	ActionCollapse    = "collapse"
+	ActionHandoff     = "handoff"
+)
+
+// DefaultKeybindings returns the keys each action is bound to out of the box
+func DefaultKeybindings() Keybindings {
+	return Keybindings{
+		ActionQuit:        {"q", "ctrl+c"},
+		ActionOpen:        {"enter"},
+		ActionNew:         {"n", "c"},
+		ActionBranches:    {"b"},
+		ActionDelete:      {"d"},
+		ActionRefresh:     {"r"},
+		ActionMark:        {" "},
+		ActionStatus:      {"s"},
+		ActionCycleStatus: {"S"},
+		ActionSync:        {"u"},
+		ActionSort:        {"O"},
+		ActionPreview:     {"p"},
+		ActionPauseMirror: {"m"},
+		ActionFlushMirror: {"M"},
+		ActionEdit:        {"e"},
+		ActionBrowse:      {"o"},
+		ActionCopyBranch:  {"B"},
+		ActionCopyPath:    {"y"},
+		ActionCopyURL:     {"Y"},
+		ActionArchived:    {"A"},
+		ActionRaise:       {"+"},
+		ActionLower:       {"-"},
+		ActionChecklist:   {"t"},
+		ActionTagFilter:   {"#"},
+		ActionFeed:        {"F"},
+		ActionScratchpad:  {"N"},
+		ActionCollapse:    {"z"},
+		ActionHandoff:     {"H"},
+	}
+}
+
+// reservedKeys can't be bound to actions, keyed by what they already do
+var reservedKeys = map[string][]string{
+	"list navigation":  {"up", "down", "j", "k", "/", "?", "esc"},
+	"recent worktrees": {"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+}
+
+// ResolveKeybindings merges the configured keybindings over the defaults. Unknown
+// actions and keys bound to more than one action are errors.
+func (c *Config) ResolveKeybindings() (Keybindings, error) {
+	bindings := DefaultKeybindings()
+	for action, keys := range c.Keybindings {
+		if _, ok := bindings[action]; !ok {
+			return nil, fmt.Errorf("unknown keybinding action %q", action)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("keybinding %q has no keys", action)
+		}
+		bindings[action] = keys
+	}
+
+	// Sort actions so conflicts are reported the same way every time
+	actions := make([]string, 0, len(bindings))
+	for action := range bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	owners := make(map[string]string)
+	for owner, keys := range reservedKeys {
+		for _, key := range keys {
+			owners[key] = owner
+		}
+	}
+	for _, action := range actions {
+		for _, key := range bindings[action] {
+			if owner, ok := owners[key]; ok && owner != action {
+				return nil, fmt.Errorf("key %q is bound to both %s and %s", key, owner, action)
+			}
+			owners[key] = action
+		}
+	}
+
+	return bindings, nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// repoNamePattern matches a GitHub repository named by its owner, e.g. markcipolla/lfg
+var repoNamePattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// cloneRepo clones source, a GitHub repository name, URL or path, into dir, and is swapped
+// out by tests
+var cloneRepo = func(source, dir string, out io.Writer) error {
+	var cmd *exec.Cmd
+	if _, err := os.Stat(source); err != nil && repoNamePattern.MatchString(source) {
+		if _, err := exec.LookPath("gh"); err == nil {
+			cmd = exec.Command("gh", "repo", "clone", source, dir)
+		} else {
+			cmd = exec.Command("git", "clone", "https://github.com/"+source+".git", dir)
+		}
+	} else {
+		cmd = exec.Command("git", "clone", source, dir)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// ChooseRepo asks which repository to run lfg in when it's started in dir, outside one: a
+// repository it's been set up in before, or one cloned into dir. It returns the repository's
+// directory, or "" to exit.
+func ChooseRepo(in io.Reader, out io.Writer, dir string) (string, error) {
+	known, err := KnownRepos()
+	if err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	}
+	reader := bufio.NewReader(in)
+	ask := func(prompt string) (string, bool) {
+		fmt.Fprint(out, prompt)
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out)
+			return "", false
+		}
+		return strings.TrimSpace(answer), true
+	}
+
+	fmt.Fprintf(out, "%s\n\n", i18n.T("lfg works on a git repository, and %s isn't in one.", dir))
+	for {
+		for i, root := range known {
+			fmt.Fprintf(out, "  %d. %s\n", i+1, root)
+		}
+		fmt.Fprintf(out, "  c. %s\n", i18n.T("Clone a repository into %s", dir))
+		fmt.Fprintf(out, "  q. %s\n\n", i18n.T("Quit"))
+
+		answer, ok := ask(i18n.T("Open which? "))
+		if !ok {
+			return "", nil
+		}
+		switch answer = strings.ToLower(answer); answer {
+		case "q", "":
+			return "", nil
+		case "c":
+			source, ok := ask(i18n.T("Repository to clone (owner/repo, URL or path): "))
+			if !ok || source == "" {
+				continue
+			}
+			target := filepath.Join(dir, strings.TrimSuffix(filepath.Base(strings.TrimRight(source, "/")), ".git"))
+			if err := cloneRepo(source, target, out); err != nil {
+				fmt.Fprintf(out, "%s\n\n", i18n.T("Failed to clone %s: %v", source, err))
+				continue
+			}
+			return target, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(known) {
+			return known[n-1], nil
+		}
+		fmt.Fprintf(out, "%s\n\n", i18n.T("Pick a number from the list, c or q"))
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/markcipolla/lfg/internal/sharedfile"
+)
+
+// ErrNotInRepo is returned when lfg is run outside a git repository
+var ErrNotInRepo = errors.New("not in a git repository")
+
+// reposFileName is the file in the user's config directory listing the repositories lfg has
+// been set up in, so it can offer them when it's run outside one
+const reposFileName = "repos.yaml"
+
+// maxKnownRepos bounds the list of known repositories
+const maxKnownRepos = 20
+
+type knownRepos struct {
+	Repos []string `yaml:"repos"` // Repository roots, most recently used first
+}
+
+// reposPath returns where the list of known repositories is kept
+func reposPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "lfg", reposFileName), nil
+}
+
+// InRepo reports whether the working directory is in a git repository
+func InRepo() bool {
+	_, err := RepoRoot()
+	return err == nil
+}
+
+// KnownRepos lists the repositories lfg has been set up in, most recently used first,
+// leaving out any that have since gone
+func KnownRepos() ([]string, error) {
+	path, err := reposPath()
+	if err != nil {
+		return nil, err
+	}
+	known, err := readKnownRepos(path)
+	if err != nil {
+		return nil, err
+	}
+	var roots []string
+	for _, root := range known.Repos {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			roots = append(roots, root)
+		}
+	}
+	return roots, nil
+}
+
+// rememberRepo puts root at the top of the known repositories
+func rememberRepo(root string) error {
+	path, err := reposPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := sharedfile.Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	known, err := readKnownRepos(path)
+	if err != nil {
+		return err
+	}
+	if len(known.Repos) > 0 && known.Repos[0] == root {
+		return nil
+	}
+	known.Repos = slices.DeleteFunc(known.Repos, func(known string) bool { return known == root })
+	known.Repos = append([]string{root}, known.Repos...)
+	known.Repos = known.Repos[:min(len(known.Repos), maxKnownRepos)]
+
+	data, err := yaml.Marshal(known)
+	if err != nil {
+		return fmt.Errorf("failed to marshal known repositories: %w", err)
+	}
+	if err := sharedfile.Write(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write known repositories: %w", err)
+	}
+	return nil
+}
+
+func readKnownRepos(path string) (knownRepos, error) {
+	var known knownRepos
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return known, nil
+	}
+	if err != nil {
+		return known, fmt.Errorf("failed to read known repositories: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &known); err != nil {
+		return known, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return known, nil
+}
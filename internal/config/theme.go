@@ -0,0 +1,206 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// Theme is the palette used by the TUI, viewer and init wizard. Colors are anything
+// lipgloss understands: ANSI 256 numbers like "86" or hex like "#5f87ff".
+type Theme struct {
+	Name       string `yaml:"name,omitempty"`       // Built-in theme to start from: "auto" (default), "dark", "light" or "high-contrast"
+	Accent     string `yaml:"accent,omitempty"`     // Titles
+	Highlight  string `yaml:"highlight,omitempty"`  // Marks, selections and status
+	Muted      string `yaml:"muted,omitempty"`      // Help text and borders
+	Error      string `yaml:"error,omitempty"`      // Error messages
+	Spinner    string `yaml:"spinner,omitempty"`    // Loading spinner
+	Background string `yaml:"background,omitempty"` // Behind the viewer's title bar
+	Markdown   string `yaml:"markdown,omitempty"`   // glamour style for rendered markdown, e.g. "dark", "light" or "dracula"
+	Glyphs     Glyphs `yaml:"glyphs,omitempty"`
+}
+
+// Glyph is the symbol a status is shown with and the color it's drawn in, the
+// terminal's own when it's unset
+type Glyph struct {
+	Symbol string `yaml:"symbol,omitempty"`
+	Color  string `yaml:"color,omitempty"`
+}
+
+// Render draws the glyph's symbol in its color
+func (g Glyph) Render() string {
+	if g.Color == "" {
+		return g.Symbol
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(g.Color)).Render(g.Symbol)
+}
+
+// Glyphs are the symbols statuses are shown with in the TUI, the viewer and their
+// messages. Each status has a symbol of its own, so none is told apart by color alone.
+type Glyphs struct {
+	Todo      Glyph `yaml:"todo,omitempty"`      // An item not started
+	Active    Glyph `yaml:"active,omitempty"`    // An item checked out in a worktree
+	Done      Glyph `yaml:"done,omitempty"`      // Items, tasks and checks that passed
+	Failed    Glyph `yaml:"failed,omitempty"`    // Tasks, installs and checks that failed
+	Pending   Glyph `yaml:"pending,omitempty"`   // Checks still running
+	Skipped   Glyph `yaml:"skipped,omitempty"`   // Checks skipped
+	Cancelled Glyph `yaml:"cancelled,omitempty"` // Checks cancelled
+	Blocked   Glyph `yaml:"blocked,omitempty"`   // Items waiting on others
+	Watching  Glyph `yaml:"watching,omitempty"`  // Sessions shared read-only and being watched
+	Marked    Glyph `yaml:"marked,omitempty"`    // Items marked for bulk actions
+	Overdue   Glyph `yaml:"overdue,omitempty"`   // Items past their due date
+	Warning   Glyph `yaml:"warning,omitempty"`   // What deleting a worktree would lose
+}
+
+// unicodeGlyphs are the dark and light themes' glyphs, drawn in the styles around them
+var unicodeGlyphs = Glyphs{
+	Todo:      Glyph{Symbol: "○"},
+	Active:    Glyph{Symbol: "●"},
+	Done:      Glyph{Symbol: "✓"},
+	Failed:    Glyph{Symbol: "✗"},
+	Pending:   Glyph{Symbol: "⏳"},
+	Skipped:   Glyph{Symbol: "⏭"},
+	Cancelled: Glyph{Symbol: "🚫"},
+	Blocked:   Glyph{Symbol: "⛔"},
+	Watching:  Glyph{Symbol: "👁"},
+	Marked:    Glyph{Symbol: "■"},
+	Overdue:   Glyph{Symbol: "!"},
+	Warning:   Glyph{Symbol: "⚠"},
+}
+
+// DefaultGlyphs are the glyphs used until a theme is applied
+func DefaultGlyphs() Glyphs {
+	return unicodeGlyphs
+}
+
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Name:       "dark",
+		Accent:     "86",
+		Highlight:  "212",
+		Muted:      "241",
+		Error:      "196",
+		Spinner:    "205",
+		Background: "236",
+		Markdown:   "dark",
+		Glyphs:     unicodeGlyphs,
+	},
+	"light": {
+		Name:       "light",
+		Accent:     "30",
+		Highlight:  "162",
+		Muted:      "244",
+		Error:      "160",
+		Spinner:    "162",
+		Background: "254",
+		Markdown:   "light",
+		Glyphs:     unicodeGlyphs,
+	},
+	// Text labels for fonts without the symbols, in colors that stay apart for the
+	// common kinds of color blindness: blue for what went well, orange for what didn't
+	"high-contrast": {
+		Name:       "high-contrast",
+		Accent:     "15",
+		Highlight:  "226",
+		Muted:      "250",
+		Error:      "208",
+		Spinner:    "226",
+		Background: "16",
+		Markdown:   "dark",
+		Glyphs: Glyphs{
+			Todo:      Glyph{Symbol: "[ ]"},
+			Active:    Glyph{Symbol: "[>]", Color: "226"},
+			Done:      Glyph{Symbol: "[x]", Color: "39"},
+			Failed:    Glyph{Symbol: "[fail]", Color: "208"},
+			Pending:   Glyph{Symbol: "[wait]", Color: "226"},
+			Skipped:   Glyph{Symbol: "[skip]", Color: "250"},
+			Cancelled: Glyph{Symbol: "[stop]", Color: "250"},
+			Blocked:   Glyph{Symbol: "[blocked]", Color: "208"},
+			Watching:  Glyph{Symbol: "[watched]", Color: "226"},
+			Marked:    Glyph{Symbol: "[+]", Color: "226"},
+			Overdue:   Glyph{Symbol: "[late]", Color: "208"},
+			Warning:   Glyph{Symbol: "[!]", Color: "208"},
+		},
+	},
+}
+
+// ResolveTheme returns the configured theme with unset colors filled in from its
+// built-in base. The "auto" theme queries the terminal background, so call this
+// before starting a bubbletea program.
+func (c *Config) ResolveTheme() (Theme, error) {
+	var theme Theme
+	if c != nil && c.Theme != nil {
+		theme = *c.Theme
+	}
+	if theme.Name == "" || theme.Name == "auto" {
+		return resolveTheme(theme, lipgloss.HasDarkBackground())
+	}
+	return resolveTheme(theme, true)
+}
+
+// resolveTheme fills in a theme from its base, using dark to pick the base for "auto"
+func resolveTheme(theme Theme, dark bool) (Theme, error) {
+	name := theme.Name
+	if name == "" || name == "auto" {
+		name = "light"
+		if dark {
+			name = "dark"
+		}
+	}
+
+	base, ok := builtinThemes[name]
+	if !ok {
+		return builtinThemes["dark"], fmt.Errorf("unknown theme %q", theme.Name)
+	}
+
+	for _, field := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&theme.Accent, base.Accent},
+		{&theme.Highlight, base.Highlight},
+		{&theme.Muted, base.Muted},
+		{&theme.Error, base.Error},
+		{&theme.Spinner, base.Spinner},
+		{&theme.Background, base.Background},
+		{&theme.Markdown, base.Markdown},
+	} {
+		if *field.value == "" {
+			*field.value = field.fallback
+		}
+	}
+	for _, glyph := range []struct {
+		value    *Glyph
+		fallback Glyph
+	}{
+		{&theme.Glyphs.Todo, base.Glyphs.Todo},
+		{&theme.Glyphs.Active, base.Glyphs.Active},
+		{&theme.Glyphs.Done, base.Glyphs.Done},
+		{&theme.Glyphs.Failed, base.Glyphs.Failed},
+		{&theme.Glyphs.Pending, base.Glyphs.Pending},
+		{&theme.Glyphs.Skipped, base.Glyphs.Skipped},
+		{&theme.Glyphs.Cancelled, base.Glyphs.Cancelled},
+		{&theme.Glyphs.Blocked, base.Glyphs.Blocked},
+		{&theme.Glyphs.Watching, base.Glyphs.Watching},
+		{&theme.Glyphs.Marked, base.Glyphs.Marked},
+		{&theme.Glyphs.Overdue, base.Glyphs.Overdue},
+		{&theme.Glyphs.Warning, base.Glyphs.Warning},
+	} {
+		glyph.value.Symbol = cmp.Or(glyph.value.Symbol, glyph.fallback.Symbol)
+		glyph.value.Color = cmp.Or(glyph.value.Color, glyph.fallback.Color)
+	}
+	theme.Name = name
+	return theme, nil
+}
+
+// UseLanguage switches the TUI, viewer and init wizard to the configured language and
+// messages, or without a config to the environment's language
+func (c *Config) UseLanguage() error {
+	if c == nil {
+		return i18n.Use("", nil)
+	}
+	return i18n.Use(c.Language, c.Messages)
+}
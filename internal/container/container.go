@@ -0,0 +1,120 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// commands runs docker and the devcontainer CLI, and is swapped out by tests. Starting
+// containers can mean building or pulling their images.
+var commands runner.Runner = runner.Exec{Timeout: 15 * time.Minute}
+
+// SetRunner makes the package run docker through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// composeFiles are the files docker compose looks for when none are configured
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// ProjectName is the compose project a worktree's containers, networks and volumes belong
+// to. Compose only allows lowercase letters, digits, dashes and underscores.
+func ProjectName(worktree string) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(worktree) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			name.WriteRune(r)
+		default:
+			name.WriteRune('_')
+		}
+	}
+	return strings.TrimLeft(name.String(), "-_")
+}
+
+// Env is the environment a worktree's tmux session gets, so docker compose run in any of
+// its panes works on the worktree's own project
+func Env(settings *config.Container, worktree string) []string {
+	if settings == nil || settings.Devcontainer() {
+		return nil
+	}
+	return []string{"COMPOSE_PROJECT_NAME=" + ProjectName(worktree)}
+}
+
+// compose is the start of every docker compose command for the worktree at path
+func compose(settings *config.Container, worktree, path string) []string {
+	args := []string{"docker", "compose", "--project-directory", path, "--project-name", ProjectName(worktree)}
+	files := settings.Files
+	if len(files) == 0 {
+		for _, file := range composeFiles {
+			if _, err := os.Stat(filepath.Join(path, file)); err == nil {
+				files = []string{file}
+				break
+			}
+		}
+	}
+	for _, file := range files {
+		args = append(args, "--file", filepath.Join(path, file))
+	}
+	return args
+}
+
+// Up starts the worktree's containers, building them first if they need it
+func Up(ctx context.Context, settings *config.Container, worktree, path string) error {
+	args := []string{"devcontainer", "up", "--workspace-folder", path}
+	if !settings.Devcontainer() {
+		args = append(compose(settings, worktree, path), "up", "--detach")
+	}
+	if _, err := commands.Run(ctx, nil, args[0], args[1:]...); err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+	return nil
+}
+
+// Down removes the worktree's containers, along with its compose project's networks
+func Down(ctx context.Context, settings *config.Container, worktree, path string) error {
+	if !settings.Devcontainer() {
+		// The project is found by its name, so this works once the worktree is gone too
+		_, err := commands.Run(ctx, nil, "docker", "compose", "--project-name", ProjectName(worktree), "down", "--remove-orphans")
+		if err != nil {
+			return fmt.Errorf("failed to remove containers: %w", err)
+		}
+		return nil
+	}
+
+	// The devcontainer CLI labels the containers it starts with their workspace folder
+	output, err := commands.Run(ctx, nil, "docker", "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder="+path)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := commands.Run(ctx, nil, "docker", append([]string{"rm", "--force"}, ids...)...); err != nil {
+		return fmt.Errorf("failed to remove containers: %w", err)
+	}
+	return nil
+}
+
+// Exec is the command line that runs command in the worktree's container, or a shell there
+// when command is ""
+func Exec(settings *config.Container, worktree, path, command string) []string {
+	args := []string{"devcontainer", "exec", "--workspace-folder", path}
+	if !settings.Devcontainer() {
+		args = append(compose(settings, worktree, path), "exec", settings.Service)
+	}
+	if command == "" {
+		return append(args, "sh")
+	}
+	return append(args, "sh", "-c", command)
+}
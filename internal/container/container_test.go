@@ -0,0 +1,88 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		worktree string
+		expected string
+	}{
+		{worktree: "proj-fix-login", expected: "proj-fix-login"},
+		{worktree: "My.Project-x", expected: "my_project-x"},
+		{worktree: "-proj x", expected: "proj_x"},
+	}
+
+	for _, tt := range tests {
+		if got := ProjectName(tt.worktree); got != tt.expected {
+			t.Errorf("ProjectName(%q) = %q, want %q", tt.worktree, got, tt.expected)
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, "docker-compose.yml"), nil, 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+	compose := []string{"docker", "compose", "--project-directory", path, "--project-name", "proj-x", "--file", filepath.Join(path, "docker-compose.yml")}
+
+	tests := []struct {
+		name     string
+		settings config.Container
+		stdout   string // What docker ps finds
+		expected [][]string
+	}{
+		{
+			name:     "compose",
+			settings: config.Container{Service: "web"},
+			expected: [][]string{
+				append(compose, "up", "--detach"),
+				{"docker", "compose", "--project-name", "proj-x", "down", "--remove-orphans"},
+			},
+		},
+		{
+			name:     "dev container",
+			settings: config.Container{Type: config.ContainerDevcontainer},
+			stdout:   "abc123\ndef456\n",
+			expected: [][]string{
+				{"devcontainer", "up", "--workspace-folder", path},
+				{"docker", "ps", "--all", "--quiet", "--filter", "label=devcontainer.local_folder=" + path},
+				{"docker", "rm", "--force", "abc123", "def456"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("docker ps", tt.stdout, nil)
+			fake.On("docker", "", nil)
+			fake.On("devcontainer", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			if err := Up(context.Background(), &tt.settings, "proj-x", path); err != nil {
+				t.Fatalf("Up() error = %v", err)
+			}
+			if err := Down(context.Background(), &tt.settings, "proj-x", path); err != nil {
+				t.Fatalf("Down() error = %v", err)
+			}
+			var got [][]string
+			for _, call := range fake.Calls() {
+				got = append(got, call.Args)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ran %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// Blockers are the worktrees and issues in the target's todo's blocked_by that aren't done
+// yet. items is the backend's items, to tell which of them are. A blocker lfg can't find,
+// such as a worktree that has been deleted, doesn't block.
+func (s *Service) Blockers(target Target, items []github.ProjectItem) []string {
+	if target.Todo == nil {
+		return nil
+	}
+	var blocking []string
+	for _, blocker := range target.Todo.BlockedBy {
+		if !s.blockerDone(blocker, items) {
+			blocking = append(blocking, blocker)
+		}
+	}
+	return blocking
+}
+
+// blockerDone reports whether a blocker is done, or can't be found
+func (s *Service) blockerDone(blocker string, items []github.ProjectItem) bool {
+	if number := blockerIssue(blocker); number > 0 {
+		for _, item := range items {
+			if item.Content.Number == number {
+				return item.Status == "Done"
+			}
+		}
+		for _, todo := range s.config.Todos {
+			if n, err := github.IssueNumberFromURL(todo.GitHubURL); err == nil && n == number {
+				return todo.Status == config.TodoStatusDone
+			}
+		}
+		return true
+	}
+	for _, item := range items {
+		if item.Worktree == blocker {
+			return item.Status == "Done"
+		}
+	}
+	if todo := s.config.GetTodoForWorktree(blocker); todo != nil {
+		return todo.Status == config.TodoStatusDone
+	}
+	return true
+}
+
+// blockerIssue is the issue a blocker names as #12 or by its URL, 0 for a worktree
+func blockerIssue(blocker string) int {
+	if digits, ok := strings.CutPrefix(blocker, "#"); ok {
+		number, _ := strconv.Atoi(digits)
+		return number
+	}
+	if strings.Contains(blocker, "/issues/") {
+		number, _ := github.IssueNumberFromURL(blocker)
+		return number
+	}
+	return 0
+}
+
+// blocks reports whether blocker names the worktree name or the issue number
+func blocks(blocker, name string, number int) bool {
+	if issue := blockerIssue(blocker); issue > 0 {
+		return issue == number
+	}
+	return name != "" && blocker == name
+}
+
+// targetIssue is the issue the target is, or its todo is linked to, on any backend
+func targetIssue(target Target) int {
+	if target.Item != nil && target.Item.Content.Number > 0 {
+		return target.Item.Content.Number
+	}
+	if target.Todo != nil {
+		if number, err := github.IssueNumberFromURL(target.Todo.GitHubURL); err == nil {
+			return number
+		}
+	}
+	return 0
+}
+
+// SetBlockedBy replaces the worktrees and issues (#12) the target's todo is blocked by
+func (s *Service) SetBlockedBy(target Target, blockers []string) error {
+	if target.Todo == nil {
+		return fmt.Errorf("blockers are kept on todos, and this item has no worktree")
+	}
+	blockers = NormalizeList(blockers)
+	for _, blocker := range blockers {
+		if strings.HasPrefix(blocker, "#") && blockerIssue(blocker) == 0 {
+			return fmt.Errorf("%q isn't an issue, use a worktree's name or an issue's number like #12", blocker)
+		}
+		if blocks(blocker, target.name(), targetIssue(target)) {
+			return fmt.Errorf("%s can't be blocked by itself", target.name())
+		}
+	}
+
+	err := s.config.Update(func(cfg *config.Config) error {
+		todo := cfg.GetTodoForWorktree(target.name())
+		if todo == nil {
+			return fmt.Errorf("%s has no todo", target.name())
+		}
+		todo.BlockedBy = blockers
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	s.emit(Event{Kind: BlockersChanged, Worktree: target.name(), Item: target.Item})
+	return nil
+}
+
+// unblock drops a target that's done from the blocked_by of the todos it was blocking
+func (s *Service) unblock(target Target) error {
+	name, number := target.name(), targetIssue(target)
+	isBlocker := func(blocker string) bool { return blocks(blocker, name, number) }
+	blocking := slices.ContainsFunc(s.config.Todos, func(todo config.Todo) bool {
+		return slices.ContainsFunc(todo.BlockedBy, isBlocker)
+	})
+	if !blocking {
+		return nil
+	}
+
+	var unblocked []string
+	err := s.config.Update(func(cfg *config.Config) error {
+		unblocked = nil
+		for i := range cfg.Todos {
+			if todo := &cfg.Todos[i]; slices.ContainsFunc(todo.BlockedBy, isBlocker) {
+				todo.BlockedBy = slices.DeleteFunc(todo.BlockedBy, isBlocker)
+				unblocked = append(unblocked, todo.Worktree)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	for _, worktree := range unblocked {
+		s.emit(Event{Kind: BlockersChanged, Worktree: worktree})
+	}
+	return nil
+}
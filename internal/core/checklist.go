@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/markcipolla/lfg/internal/checklist"
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// Checklist is the markdown holding the target's checklist: its issue's body when it's
+// linked to one, otherwise its todo's notes
+func (s *Service) Checklist(target Target) string {
+	if s.issueNumber(target) > 0 {
+		if target.Item != nil && target.Item.Content.Body != "" {
+			return target.Item.Content.Body
+		}
+		if target.Todo != nil {
+			return target.Todo.GitHubBody
+		}
+		return ""
+	}
+	if target.Todo != nil {
+		return target.Todo.Notes
+	}
+	return ""
+}
+
+// CheckTask checks or unchecks the index'th item of the target's checklist, whose text is
+// text, returning the checklist's markdown as it's now saved. An issue's task list is
+// changed on GitHub, keeping the todo's copy of the body up to date.
+func (s *Service) CheckTask(target Target, index int, text string, checked bool) (string, error) {
+	var markdown string
+	number := s.issueNumber(target)
+	if number > 0 {
+		backend := s.config.StorageBackend
+		body, err := github.SetIssueTask(backend.Owner, backend.Repo, number, index, text, checked)
+		if err != nil {
+			return "", err
+		}
+		if target.Item != nil {
+			target.Item.Content.Body = body
+		}
+		markdown = body
+	} else if target.Todo == nil {
+		return "", fmt.Errorf("checklists are kept in todos' notes, and this item has no worktree")
+	}
+
+	if target.Todo != nil {
+		err := s.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(target.name())
+			if todo == nil {
+				return fmt.Errorf("%s has no todo", target.name())
+			}
+			if number > 0 {
+				todo.GitHubBody = markdown
+				return nil
+			}
+			tasks := checklist.Parse(todo.Notes)
+			if index >= len(tasks) || tasks[index].Text != text {
+				return fmt.Errorf("the checklist has changed")
+			}
+			notes, err := checklist.Set(todo.Notes, index, checked)
+			if err != nil {
+				return err
+			}
+			todo.Notes, markdown = notes, notes
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+	s.emit(Event{Kind: ChecklistChanged, Worktree: target.name(), Item: target.Item})
+	return markdown, nil
+}
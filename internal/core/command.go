@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// WorktreeCommand is argv to run in a worktree's directory, with CommandEnv's environment.
+// argv is run as it is, without a shell, so arguments keep their spaces and quotes.
+func (s *Service) WorktreeCommand(worktree string, argv []string) (*exec.Cmd, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("no command to run in %s", worktree)
+	}
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return nil, err
+	}
+	env, err := s.CommandEnv(worktree)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = path
+	cmd.Env = env
+	return cmd, nil
+}
+
+// CommandEnv is lfg's environment with a worktree's ports, the shared caches and the
+// worktree's environment added, for commands run in it. Only the ports the worktree already
+// has are added; running a command doesn't give it any.
+func (s *Service) CommandEnv(worktree string) ([]string, error) {
+	st, err := state.Load(s.config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	caches, err := s.config.CacheEnv()
+	if err != nil {
+		return nil, err
+	}
+	environment, err := s.config.WorktreeEnv(worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	env := append(os.Environ(), caches...)
+	for _, port := range st.Ports(s.config.Ports, worktree) {
+		env = append(env, port.Env())
+	}
+	return append(env, environment...), nil
+}
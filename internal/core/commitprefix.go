@@ -0,0 +1,199 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// generatedSubjects start the commit messages git writes itself, which are left unprefixed
+var generatedSubjects = []string{"Merge ", "fixup! ", "squash! ", "amend! "}
+
+// scissors starts the line git drops everything below from in messages written in an editor
+const scissors = "# ------------------------ >8 ------------------------"
+
+// CommitPrefix is what commit_prefix starts the commits in a worktree with: its todo's issue
+// or pull request, e.g. "#123: ", named with its repository when that's not the backend's.
+// It's "" for worktrees whose todo has neither.
+func (s *Service) CommitPrefix(worktree string) string {
+	todo := s.config.GetTodoForWorktree(worktree)
+	if todo == nil {
+		return ""
+	}
+	link, err := github.ParseLink(todo.GitHubURL)
+	if err != nil {
+		return ""
+	}
+	backend := s.config.StorageBackend
+	if backend.IsGitHub() && strings.EqualFold(link.Owner, backend.Owner) && strings.EqualFold(link.Repo, backend.Repo) {
+		return fmt.Sprintf("#%d: ", link.Number)
+	}
+	return fmt.Sprintf("%s/%s#%d: ", link.Owner, link.Repo, link.Number)
+}
+
+// PrefixCommitMessage starts a commit message's subject with prefix, unless the subject
+// already refers to the issue, has no text yet, or is one git wrote, like a merge's or a
+// fixup's
+func PrefixCommitMessage(message, prefix string) string {
+	if prefix == "" {
+		return message
+	}
+	lines := strings.SplitAfter(message, "\n")
+	for i, line := range lines {
+		subject := strings.TrimSpace(line)
+		if subject == "" {
+			continue
+		}
+		if strings.HasPrefix(subject, scissors) || refersTo(subject, strings.TrimSuffix(prefix, ": ")) {
+			return message
+		}
+		for _, generated := range generatedSubjects {
+			if strings.HasPrefix(subject, generated) {
+				return message
+			}
+		}
+		lines[i] = prefix + line
+		return strings.Join(lines, "")
+	}
+	return message
+}
+
+// PrefixCommitFile prefixes the commit message in the file at path, for the commit-msg hook
+// of a commit in worktree. The hook runs before git strips comments from the message, so a
+// prefix git would take for a comment, like #123 in a message written in an editor under
+// the default commit.cleanup, names the issue as GH-123 instead, which GitHub links the
+// same. It reports when neither would survive, leaving the message unprefixed.
+func (s *Service) PrefixCommitFile(worktree, path string) (warning string, err error) {
+	prefix := s.CommitPrefix(worktree)
+	if prefix == "" {
+		return "", nil
+	}
+	worktreePath, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return "", err
+	}
+	// git sets GIT_EDITOR to : for the hook when the message wasn't written in an editor
+	comment := strippedComment(worktreePath, os.Getenv("GIT_EDITOR") != ":")
+	if comment != "" && strings.HasPrefix(prefix, comment) {
+		linked := "GH-" + strings.TrimPrefix(prefix, "#")
+		if !strings.HasPrefix(prefix, "#") || strings.HasPrefix(linked, comment) {
+			return fmt.Sprintf("commit left unprefixed, as git would take %s for a comment", strings.TrimSuffix(prefix, ": ")), nil
+		}
+		prefix = linked
+	}
+
+	message, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	prefixed := PrefixCommitMessage(string(message), prefix)
+	if prefixed == string(message) {
+		return "", nil
+	}
+	return "", os.WriteFile(path, []byte(prefixed), 0644)
+}
+
+// strippedComment is what git takes the lines of a commit message in the repository at path
+// starting with for comments, stripping them once the commit-msg hook has run, or "" when it
+// strips none. commit.cleanup's default only strips them from messages written in an editor.
+func strippedComment(path string, edited bool) string {
+	switch git.ConfigValue(path, "commit.cleanup") {
+	case "strip":
+	case "", "default":
+		if !edited {
+			return ""
+		}
+	default:
+		// whitespace, verbatim and scissors keep them
+		return ""
+	}
+	comment := git.ConfigValue(path, "core.commentString")
+	if comment == "" {
+		comment = git.ConfigValue(path, "core.commentChar")
+	}
+	// auto picks # unless lines of the message git starts with already start with it
+	if comment == "" || comment == "auto" {
+		return "#"
+	}
+	return comment
+}
+
+// refersTo reports whether a commit subject mentions ref, e.g. #123 but not #1234
+func refersTo(subject, ref string) bool {
+	return regexp.MustCompile(`(^|[^\w/])` + regexp.QuoteMeta(ref) + `\b`).MatchString(subject)
+}
+
+// hookMarker identifies the commit-msg hook lfg installs, so it's only ever replaced or
+// removed when it's lfg's own
+const hookMarker = "# Installed by lfg for commit_prefix"
+
+// hookCommand is what a commit-msg hook of the user's own runs to prefix commits itself
+const hookCommand = `lfg commit-msg "$1"`
+
+// commitHook is the commit-msg hook that prefixes commits, run with the lfg at executable
+// when there's none on the PATH. A commit is never held up by lfg missing.
+func commitHook(executable string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s, and removed when it's turned off
+lfg=$(command -v lfg || echo '%s')
+[ -x "$lfg" ] || exit 0
+exec "$lfg" commit-msg "$1"
+`, hookMarker, strings.ReplaceAll(executable, "'", `'\''`))
+}
+
+// SyncCommitHook keeps the repository's commit-msg hook in step with commit_prefix,
+// installing lfg's when it's set and removing it when it isn't. A hook of the user's own is
+// left alone, and reported unless it already runs lfg commit-msg.
+func (s *Service) SyncCommitHook() error {
+	if s.config.RemoteHost() != nil {
+		return fmt.Errorf("commits are made on %s, so run lfg commit-prefix in a session there", s.config.RemoteHost().Host)
+	}
+	root := s.config.Root()
+	hooks, err := git.HooksDir(root)
+	if err != nil {
+		return err
+	}
+	hookPath := filepath.Join(hooks, "commit-msg")
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	ours := bytes.Contains(existing, []byte(hookMarker))
+
+	if !s.config.CommitPrefix {
+		if ours {
+			return os.Remove(hookPath)
+		}
+		return nil
+	}
+
+	switch {
+	case len(existing) > 0 && !ours:
+		if !bytes.Contains(existing, []byte("lfg commit-msg")) {
+			return fmt.Errorf("%s is a commit-msg hook of your own; add %s to it for commit_prefix", hookPath, hookCommand)
+		}
+	case len(existing) == 0 && git.ConfigValue(root, "core.hooksPath") != "":
+		// Hooks there are usually the team's, committed to the repository
+		return fmt.Errorf("core.hooksPath is set; add a commit-msg hook running %s to %s for commit_prefix", hookCommand, hooks)
+	default:
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if hook := commitHook(executable); string(existing) != hook {
+			if err := os.MkdirAll(hooks, 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(hookPath, []byte(hook), 0755); err != nil {
+				return fmt.Errorf("failed to install the commit-msg hook: %w", err)
+			}
+		}
+	}
+	return nil
+}
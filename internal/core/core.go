@@ -0,0 +1,169 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/history"
+	"github.com/markcipolla/lfg/internal/webhook"
+)
+
+// EventKind is what happened in an Event
+type EventKind string
+
+const (
+	WorktreeCreated   EventKind = "worktree_created"
+	WorktreeDeleted   EventKind = "worktree_deleted"
+	SessionKilled     EventKind = "session_killed"
+	StatusChanged     EventKind = "status_changed"
+	ItemCreated       EventKind = "item_created"
+	ItemRenamed       EventKind = "item_renamed"
+	PriorityChanged   EventKind = "priority_changed"
+	DueChanged        EventKind = "due_changed"
+	ChecklistChanged  EventKind = "checklist_changed"
+	TagsChanged       EventKind = "tags_changed"
+	BlockersChanged   EventKind = "blockers_changed"
+	TodoHandedOff     EventKind = "todo_handed_off"
+	PullRequestOpened EventKind = "pull_request_opened"
+
+	// Happenings outside the service, told to it with Record
+	AgentFinished EventKind = "agent_finished"
+	PaneCrashed   EventKind = "pane_crashed"
+
+	// A worktree's pull request merged on GitHub, which lfg watch looks for itself
+	PullRequestMerged EventKind = "pull_request_merged"
+)
+
+// Event is a change the service made
+type Event struct {
+	Kind     EventKind           `json:"kind"`
+	Worktree string              `json:"worktree,omitempty"` // The worktree it happened to, if any
+	Item     *github.ProjectItem `json:"item,omitempty"`     // The backend's item it happened to, if any
+	Status   string              `json:"status,omitempty"`   // With StatusChanged, the status moved to
+	From     string              `json:"from,omitempty"`     // With StatusChanged, the status moved from
+	Title    string              `json:"title,omitempty"`    // With WorktreeDeleted, its todo's description, the todo being gone
+	Detail   string              `json:"detail,omitempty"`   // With AgentFinished and PaneCrashed, what finished or crashed and how; with TodoHandedOff, the worktree it came from; with PullRequestOpened, its URL
+	Time     time.Time           `json:"time"`
+}
+
+// Service carries out lfg's operations on worktrees, their todos and the backend's items,
+// telling subscribers about each change. The TUI and the CLI subcommands share it, so
+// neither has to know how the other does things.
+type Service struct {
+	config *config.Config
+
+	mu            sync.Mutex
+	statuses      []string // The backend's statuses, once known
+	priorities    []string // The priorities items move between, once known
+	priorityField bool     // The project board has a Priority field to keep items' priorities in
+	dueField      *string  // The project board's due date field, "" when it has none, once known
+	subscribers   []subscriber
+	nextID        int
+}
+
+type subscriber struct {
+	id     int
+	handle func(Event)
+}
+
+// New returns a service working on the repository cfg is for, recording its changes in the
+// repository's history and posting them to the config's webhooks
+func New(cfg *config.Config) *Service {
+	s := &Service{config: cfg}
+	if cfg != nil && cfg.GetConfigPath() != "" {
+		s.Subscribe(s.recordHistory)
+	}
+	if cfg != nil && len(cfg.Webhooks) > 0 {
+		s.Subscribe(s.postWebhooks)
+	}
+	return s
+}
+
+// recordHistory adds an event to the history lfg history shows. A history that can't be
+// written doesn't stop the change it records, which has already been made.
+func (s *Service) recordHistory(event Event) {
+	entry := history.Entry{Time: event.Time, Kind: string(event.Kind), Worktree: event.Worktree, Status: event.Status, From: event.From, Detail: event.Detail}
+	if item := event.Item; item != nil {
+		entry.Title, entry.URL = item.Title, item.Content.URL
+	} else if todo := s.config.GetTodoForWorktree(event.Worktree); event.Worktree != "" && todo != nil {
+		entry.Title, entry.URL = todo.Description, todo.GitHubURL
+	} else {
+		entry.Title = event.Title
+	}
+	history.Append(s.config.GetConfigPath(), entry)
+}
+
+// postWebhooks posts the events webhooks are sent for: new worktrees and items moving
+func (s *Service) postWebhooks(event Event) {
+	hooked := webhook.Event{Worktree: event.Worktree, Status: event.Status, From: event.From, Time: event.Time}
+	if item := event.Item; item != nil {
+		hooked.Title, hooked.URL = item.Title, item.Content.URL
+	} else if todo := s.config.GetTodoForWorktree(event.Worktree); todo != nil {
+		hooked.Title, hooked.URL = todo.Description, todo.GitHubURL
+	}
+
+	switch event.Kind {
+	case WorktreeCreated:
+		hooked.Kind = config.WebhookWorktreeCreated
+		hooked.Text = fmt.Sprintf("%s: created worktree %s", s.config.Name, event.Worktree)
+		if hooked.Title != "" {
+			hooked.Text = fmt.Sprintf("%s: started %q in %s", s.config.Name, hooked.Title, event.Worktree)
+		}
+	case StatusChanged:
+		hooked.Kind = config.WebhookStatusChanged
+		what := hooked.Title
+		if what == "" {
+			what = event.Worktree
+		}
+		hooked.Text = fmt.Sprintf("%s: %q moved to %s", s.config.Name, what, event.Status)
+	default:
+		return
+	}
+	webhook.Send(s.config, hooked)
+}
+
+// Config returns the config the service works with
+func (s *Service) Config() *config.Config {
+	return s.config
+}
+
+// Subscribe calls handle with every event from now on, until cancel is called. Events are
+// handled on the goroutine that made the change, so slow work should be handed off.
+func (s *Service) Subscribe(handle func(Event)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := s.nextID
+	s.subscribers = append(s.subscribers, subscriber{id: id, handle: handle})
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, sub := range s.subscribers {
+			if sub.id == id {
+				s.subscribers = append(s.subscribers[:i:i], s.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Record tells subscribers of something lfg saw happen outside the service, like an agent
+// finishing or a pane's command crashing, so it's in the history with the rest
+func (s *Service) Record(event Event) {
+	s.emit(event)
+}
+
+// emit tells every subscriber about event
+func (s *Service) emit(event Event) {
+	event.Time = time.Now()
+	s.mu.Lock()
+	subscribers := s.subscribers
+	s.mu.Unlock()
+	for _, sub := range subscribers {
+		sub.handle(event)
+	}
+}
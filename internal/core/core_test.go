@@ -0,0 +1,1269 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/history"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/testrepo"
+	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/internal/transcript"
+)
+
+const orgConfig = `name: proj
+storage_backend:
+    type: org
+`
+
+const orgFile = `* Backlog
+** TODO Fix login
+:PROPERTIES:
+:ID: login
+:END:
+`
+
+// recorder collects the kinds of the events a service emits
+type recorder struct {
+	kinds []EventKind
+}
+
+func (r *recorder) record(event Event) {
+	r.kinds = append(r.kinds, event.Kind)
+}
+
+// newService returns a service for a fresh repository using the org backend, with tmux
+// faked so no real sessions are touched
+func newService(t *testing.T) (*Service, *testrepo.Repo, *recorder) {
+	t.Helper()
+	repo := testrepo.New(t)
+	repo.WriteFile("todo.org", orgFile)
+	fake := &runner.Fake{}
+	fake.On("tmux", "", errors.New("no server running"))
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	s := New(repo.Config(orgConfig))
+	events := &recorder{}
+	s.Subscribe(events.record)
+	return s, repo, events
+}
+
+func TestSubscribe(t *testing.T) {
+	s := New(nil)
+	var first, second recorder
+	cancel := s.Subscribe(first.record)
+	s.Subscribe(second.record)
+
+	s.emit(Event{Kind: ItemCreated})
+	cancel()
+	s.emit(Event{Kind: ItemRenamed})
+
+	if want := []EventKind{ItemCreated}; !reflect.DeepEqual(first.kinds, want) {
+		t.Errorf("cancelled subscriber got %v, want %v", first.kinds, want)
+	}
+	if want := []EventKind{ItemCreated, ItemRenamed}; !reflect.DeepEqual(second.kinds, want) {
+		t.Errorf("subscriber got %v, want %v", second.kinds, want)
+	}
+}
+
+func TestOrgParents(t *testing.T) {
+	s, repo, _ := newService(t)
+	repo.WriteFile("todo.org", orgFile+"** TODO Accounts\n*** TODO Sessions\n")
+	items, _, err := s.ListItems()
+	if err != nil || len(items) != 3 {
+		t.Fatalf("ListItems() = %v, %v, want the org file's headlines", items, err)
+	}
+	if items[0].Parent != nil || items[1].Parent != nil {
+		t.Errorf("top-level items have parents %+v and %+v", items[0].Parent, items[1].Parent)
+	}
+	if parent := items[2].Parent; parent == nil || parent.Key() != items[1].Ref().Key() {
+		t.Errorf("Sessions' parent = %+v, want Accounts", parent)
+	}
+}
+
+func TestCreateAndDeleteWorktree(t *testing.T) {
+	s, repo, events := newService(t)
+	items, _, err := s.ListItems()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ListItems() = %v, %v, want the org file's headline", items, err)
+	}
+	item := &items[0]
+
+	warnings, err := s.CreateWorktree(context.Background(), CreateRequest{Name: "proj-fix-login", Item: item})
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("CreateWorktree() = %v, %v", warnings, err)
+	}
+	if err := s.TrackWorktree("proj-fix-login", "Fix login", "", "", item); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	org, _ := os.ReadFile(filepath.Join(repo.Root, "todo.org"))
+	if !strings.Contains(string(org), "DOING Fix login") || !strings.Contains(string(org), ":WORKTREE: proj-fix-login") {
+		t.Errorf("the headline wasn't started and linked:\n%s", org)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-fix-login"); todo == nil {
+		t.Error("TrackWorktree() didn't add a todo")
+	}
+	scratchpad, _ := state.PrepareScratchpad(s.Config().GetConfigPath(), "proj-fix-login")
+	os.WriteFile(scratchpad, []byte("Login fails on Safari"), 0644)
+
+	deleted, err := s.DeleteWorktree(Target{Worktree: "proj-fix-login", Todo: s.Config().GetTodoForWorktree("proj-fix-login")})
+	if err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+	if deleted.Current {
+		t.Error("DeleteWorktree() reports deleting the worktree the test runs in")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(repo.Root), "proj-fix-login")); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after delete: %v", err)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-fix-login"); todo != nil {
+		t.Errorf("todo still exists after delete: %+v", todo)
+	}
+	if _, err := os.Stat(scratchpad); !os.IsNotExist(err) {
+		t.Errorf("scratchpad still exists after delete: %v", err)
+	}
+
+	want := []EventKind{StatusChanged, WorktreeCreated, WorktreeDeleted}
+	if !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+}
+
+func TestCreateWorktreeFailure(t *testing.T) {
+	s, _, events := newService(t)
+	if _, err := s.CreateWorktree(context.Background(), CreateRequest{Name: "proj-x", Base: "no-such-branch"}); err == nil {
+		t.Fatal("CreateWorktree() from a missing base succeeded")
+	}
+	if len(events.kinds) > 0 {
+		t.Errorf("a failed create emitted %v", events.kinds)
+	}
+}
+
+func TestCollision(t *testing.T) {
+	s, repo, _ := newService(t)
+	fake := &runner.Fake{}
+	fake.On("tmux has-session -t proj-running-", "", errors.New("can't find session"))
+	fake.On("tmux has-session -t proj-running", "", nil)
+	fake.On("tmux", "", errors.New("can't find session"))
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	if _, err := s.CreateWorktree(context.Background(), CreateRequest{Name: "proj-login", Branch: "login"}); err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	repo.Git("branch", "proj-signup")
+	repo.Git("branch", "proj-signup-2")
+	if err := os.Mkdir(filepath.Join(filepath.Dir(repo.Root), "proj-docs"), 0755); err != nil {
+		t.Fatalf("failed to make a directory in the way: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		request   CreateRequest
+		collision string
+		renamed   CreateRequest
+	}{
+		{
+			name:    "free",
+			request: CreateRequest{Name: "proj-free"},
+		},
+		{
+			name:      "a worktree",
+			request:   CreateRequest{Name: "proj-login", Branch: "other"},
+			collision: "a worktree named proj-login already exists",
+			renamed:   CreateRequest{Name: "proj-login-2", Branch: "other"},
+		},
+		{
+			name:      "a directory",
+			request:   CreateRequest{Name: "proj-docs"},
+			collision: filepath.Join(filepath.Dir(repo.Root), "proj-docs") + " already exists",
+			renamed:   CreateRequest{Name: "proj-docs-2"},
+		},
+		{
+			name:      "a branch, and the next one too",
+			request:   CreateRequest{Name: "proj-signup", Branch: "proj-signup"},
+			collision: "a branch named proj-signup already exists",
+			renamed:   CreateRequest{Name: "proj-signup-3", Branch: "proj-signup-3"},
+		},
+		{
+			name:      "a prefixed branch named after it",
+			request:   CreateRequest{Name: "proj-login", Branch: "feat/proj-login"},
+			collision: "a worktree named proj-login already exists",
+			renamed:   CreateRequest{Name: "proj-login-2", Branch: "feat/proj-login-2"},
+		},
+		{
+			name:    "checking out an existing branch",
+			request: CreateRequest{Name: "proj-checkout", Branch: "proj-signup", Checkout: true},
+		},
+		{
+			name:      "a tmux session",
+			request:   CreateRequest{Name: "proj-running"},
+			collision: "a tmux session named proj-running is already running",
+			renamed:   CreateRequest{Name: "proj-running-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collision, err := s.Collision(tt.request)
+			if err != nil || collision != tt.collision {
+				t.Fatalf("Collision() = %q, %v, want %q", collision, err, tt.collision)
+			}
+			if collision == "" {
+				return
+			}
+			renamed, err := s.Unclash(tt.request)
+			if err != nil || renamed != tt.renamed {
+				t.Errorf("Unclash() = %+v, %v, want %+v", renamed, err, tt.renamed)
+			}
+		})
+	}
+}
+
+func TestCreateWorktreeEnvrc(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.WriteFile(".envrc.tmpl", "export NAME={{.Worktree}} BRANCH={{.Branch}} PORT={{.Ports.PORT}}\nexport ROOT={{.Root}}\n")
+	s := New(repo.Config("name: proj\nports:\n    names: [PORT]\nenvrc:\n    template: .envrc.tmpl\n    allow: false\n"))
+
+	warnings, err := s.CreateWorktree(context.Background(), CreateRequest{Name: "proj-login", Branch: "login"})
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("CreateWorktree() = %v, %v", warnings, err)
+	}
+	envrc, err := os.ReadFile(filepath.Join(filepath.Dir(repo.Root), "proj-login", ".envrc"))
+	if err != nil {
+		t.Fatalf("the .envrc wasn't written: %v", err)
+	}
+	if want := "export NAME=proj-login BRANCH=login PORT=4000\nexport ROOT=" + repo.Root + "\n"; string(envrc) != want {
+		t.Errorf(".envrc = %q, want %q", envrc, want)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   string // The todo's status afterwards
+	}{
+		{name: "done", status: "Done", want: "done"},
+		{name: "todo done", status: "done", want: "done"},
+		{name: "anything else reopens", status: "In Progress", want: "pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testrepo.New(t)
+			s := New(repo.Config("name: proj\ntodos:\n    - description: Add login\n      status: pending\n      worktree: proj-login\n"))
+			var got []Event
+			s.Subscribe(func(event Event) { got = append(got, event) })
+
+			todo := s.Config().GetTodoForWorktree("proj-login")
+			if err := s.SetStatus(Target{Worktree: "proj-login", Todo: todo}, tt.status); err != nil {
+				t.Fatalf("SetStatus() error = %v", err)
+			}
+			// The change is made to the config as it is on disk, which s now holds
+			if todo := s.Config().GetTodoForWorktree("proj-login"); string(todo.Status) != tt.want {
+				t.Errorf("todo status = %q, want %q", todo.Status, tt.want)
+			}
+			if len(got) != 1 || got[0].Kind != StatusChanged || got[0].Worktree != "proj-login" || got[0].From != "pending" {
+				t.Errorf("events = %+v, want one StatusChanged for proj-login from pending", got)
+			}
+		})
+	}
+}
+
+func TestReview(t *testing.T) {
+	repo := testrepo.New(t)
+	// The pull request is a commit the main branch doesn't have, on origin's pull ref
+	repo.Git("checkout", "--quiet", "-b", "fix-login")
+	repo.WriteFile("login.go", "package login\n")
+	repo.Git("add", "login.go")
+	repo.Git("commit", "--quiet", "-m", "Fix login")
+	repo.Git("update-ref", "refs/pull/7/head", "HEAD")
+	repo.Git("checkout", "--quiet", "main")
+	repo.Git("remote", "add", "origin", repo.Root)
+
+	fake := &runner.Fake{}
+	fake.On("tmux", "", errors.New("no server running"))
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+	gh := &runner.Fake{}
+	gh.On("gh pr view 7", `{"number": 7, "title": "Fix login", "body": "Fixes the login form", "url": "https://github.com/owner/repo/pull/7", "state": "OPEN"}`, nil)
+	previousGH := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previousGH) })
+
+	s := New(repo.Config("name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\n"))
+	name, warnings, err := s.Review(context.Background(), 7)
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("Review() = %v, %v", warnings, err)
+	}
+	path := filepath.Join(filepath.Dir(repo.Root), "proj-review-7")
+	if _, err := os.Stat(filepath.Join(path, "login.go")); name != "proj-review-7" || err != nil {
+		t.Fatalf("Review() = %q, want the pull request checked out in proj-review-7: %v", name, err)
+	}
+	todo := s.Config().GetTodoForWorktree(name)
+	if todo == nil || todo.Description != "Review #7: Fix login" || todo.GitHubBody != "Fixes the login form" {
+		t.Errorf("review todo = %+v", todo)
+	}
+	st, err := state.Load(s.Config().GetConfigPath())
+	if err != nil || st.Reviews[name] != (state.Review{Owner: "owner", Repo: "repo", Number: 7}) {
+		t.Errorf("Reviews = %v, %v", st.Reviews, err)
+	}
+
+	// Open pull requests are kept, merged ones cleaned up
+	if pruned, err := s.PruneReviews(); err != nil || len(pruned) > 0 {
+		t.Fatalf("PruneReviews() of an open pull request = %v, %v", pruned, err)
+	}
+	gh = &runner.Fake{}
+	gh.On("gh pr view 7", `{"number": 7, "state": "MERGED", "headRefOid": "`+repo.Git("rev-parse", "refs/pull/7/head")+`"}`, nil)
+	github.SetRunner(gh)
+
+	// Commits made while reviewing keep the worktree until they're gone
+	repo.Git("-C", path, "commit", "--quiet", "--allow-empty", "-m", "Try a fix")
+	if pruned, err := s.PruneReviews(); err != nil || len(pruned) > 0 {
+		t.Fatalf("PruneReviews() of a review with commits of its own = %v, %v", pruned, err)
+	}
+	repo.Git("-C", path, "reset", "--quiet", "--hard", "HEAD~1")
+	if pruned, err := s.PruneReviews(); err != nil || !reflect.DeepEqual(pruned, []string{name}) {
+		t.Fatalf("PruneReviews() of a merged pull request = %v, %v", pruned, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the review worktree is still there: %v", err)
+	}
+}
+
+func TestPruneCandidates(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-merged")
+	wip := repo.AddWorktree("proj-wip")
+	repo.Git("-C", wip, "commit", "--quiet", "--allow-empty", "-m", "Work in progress")
+	repo.Git("remote", "add", "origin", repo.Root)
+	repo.Git("fetch", "--quiet", "origin")
+
+	fake := &runner.Fake{}
+	fake.On("tmux list-sessions", "proj-gone\t0\nproj-wip\t1\nscratch\t0\n", nil)
+	fake.On("tmux has-session", "", nil)
+	fake.On("tmux kill-session", "", nil)
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	s := New(repo.Config("name: proj\ntodos:\n  - description: Gone\n    status: pending\n    worktree: proj-gone\n"))
+	candidates, err := s.PruneCandidates(nil, 0)
+	if err != nil {
+		t.Fatalf("PruneCandidates() error = %v", err)
+	}
+	var got []string
+	for _, candidate := range candidates {
+		got = append(got, string(candidate.Kind)+" "+candidate.Name+": "+strings.Join(candidate.Reasons, ", "))
+	}
+	want := []string{
+		"worktree proj-merged: branch merged",
+		"session proj-gone: its worktree is gone",
+		"todo proj-gone: its worktree is gone",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("PruneCandidates() = %q, want %q", got, want)
+	}
+
+	for _, candidate := range candidates {
+		if warnings, err := s.Prune(candidate); err != nil || len(warnings) > 0 {
+			t.Errorf("Prune(%s) = %v, %v", candidate.Name, warnings, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(repo.Root), "proj-merged")); !os.IsNotExist(err) {
+		t.Errorf("the merged worktree is still there: %v", err)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-gone"); todo != nil {
+		t.Errorf("the orphaned todo is still there: %+v", todo)
+	}
+
+	// Everything is idle when any time at all counts
+	candidates, err = s.PruneCandidates(nil, time.Nanosecond)
+	if err != nil || len(candidates) == 0 || candidates[0].Name != "proj-wip" || candidates[0].Reasons[0] != "last worked on just now" {
+		t.Errorf("PruneCandidates() with an idle limit = %+v, %v", candidates, err)
+	}
+}
+
+func TestShiftPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		steps    int
+		expected string
+	}{
+		{name: "raise from none", current: "", steps: 1, expected: "Low"},
+		{name: "raise", current: "Medium", steps: 1, expected: "High"},
+		{name: "raise the most urgent", current: "High", steps: 1, expected: "High"},
+		{name: "lower", current: "High", steps: -1, expected: "Medium"},
+		{name: "lower the least urgent", current: "Low", steps: -1, expected: ""},
+		{name: "lower none", current: "", steps: -1, expected: ""},
+		{name: "unknown counts as none", current: "P0", steps: 1, expected: "Low"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShiftPriority(DefaultPriorities, tt.current, tt.steps); got != tt.expected {
+				t.Errorf("ShiftPriority(%q, %d) = %q, want %q", tt.current, tt.steps, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	s, repo, events := newService(t)
+	repo.AddWorktree("proj-x")
+	if err := s.TrackWorktree("proj-x", "Fix login", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+
+	if err := s.SetPriority(Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}, "High"); err != nil {
+		t.Fatalf("SetPriority() error = %v", err)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-x"); todo == nil || todo.Priority != "High" {
+		t.Errorf("todo = %+v, want High priority", todo)
+	}
+	if want := []EventKind{WorktreeCreated, PriorityChanged}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+
+	// The org backend has nowhere to keep an item's priority
+	if err := s.SetPriority(Target{Item: &github.ProjectItem{ID: "login"}}, "High"); err == nil {
+		t.Error("SetPriority() of an item without a todo succeeded")
+	}
+}
+
+func TestSetDue(t *testing.T) {
+	s, repo, events := newService(t)
+	repo.AddWorktree("proj-x")
+	if err := s.TrackWorktree("proj-x", "Fix login", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	target := Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}
+
+	if err := s.SetDue(target, "next week"); err == nil {
+		t.Error("SetDue() with a date that isn't YYYY-MM-DD succeeded")
+	}
+	if err := s.SetDue(target, "2026-10-20"); err != nil {
+		t.Fatalf("SetDue() error = %v", err)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-x"); todo == nil || todo.Due != "2026-10-20" {
+		t.Errorf("todo = %+v, want due 2026-10-20", todo)
+	}
+	if want := []EventKind{WorktreeCreated, DueChanged}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+
+	// The org backend has nowhere to keep an item's due date
+	if err := s.SetDue(Target{Item: &github.ProjectItem{ID: "login"}}, "2026-10-20"); err == nil {
+		t.Error("SetDue() of an item without a todo succeeded")
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	s, repo, events := newService(t)
+	repo.AddWorktree("proj-x")
+	if err := s.TrackWorktree("proj-x", "Fix login", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	target := Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}
+
+	if err := s.SetTags(target, []string{" bug", "auth", "", "bug"}); err != nil {
+		t.Fatalf("SetTags() error = %v", err)
+	}
+	todo := s.Config().GetTodoForWorktree("proj-x")
+	if want := []string{"bug", "auth"}; todo == nil || !reflect.DeepEqual(todo.Tags, want) {
+		t.Errorf("todo = %+v, want tags %v", todo, want)
+	}
+	if tags := s.Tags(Target{Worktree: "proj-x", Todo: todo}); !reflect.DeepEqual(tags, []string{"bug", "auth"}) {
+		t.Errorf("Tags() = %v, want the todo's tags", tags)
+	}
+	if want := []EventKind{WorktreeCreated, TagsChanged}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+
+	// The org backend has nowhere to keep an item's tags
+	if err := s.SetTags(Target{Item: &github.ProjectItem{ID: "login"}}, []string{"bug"}); err == nil {
+		t.Error("SetTags() of an item without a todo succeeded")
+	}
+}
+
+func TestBlockedBy(t *testing.T) {
+	s, repo, events := newService(t)
+	for _, name := range []string{"proj-api", "proj-x"} {
+		repo.AddWorktree(name)
+		if err := s.TrackWorktree(name, "Task "+name, "", "", nil); err != nil {
+			t.Fatalf("TrackWorktree() error = %v", err)
+		}
+	}
+	target := func(name string) Target {
+		return Target{Worktree: name, Todo: s.Config().GetTodoForWorktree(name)}
+	}
+
+	if err := s.SetBlockedBy(target("proj-x"), []string{"proj-x"}); err == nil {
+		t.Error("SetBlockedBy() with the todo itself succeeded")
+	}
+	if err := s.SetBlockedBy(target("proj-x"), []string{"#twelve"}); err == nil {
+		t.Error("SetBlockedBy() with a bad issue number succeeded")
+	}
+	if err := s.SetBlockedBy(target("proj-x"), []string{"proj-api", " #12", "proj-gone"}); err != nil {
+		t.Fatalf("SetBlockedBy() error = %v", err)
+	}
+	items := []github.ProjectItem{{ID: "12", Status: "Todo"}}
+	items[0].Content.Number = 12
+	if blockers := s.Blockers(target("proj-x"), items); !reflect.DeepEqual(blockers, []string{"proj-api", "#12"}) {
+		t.Errorf("Blockers() = %v, want proj-api and #12, not the worktree that's gone", blockers)
+	}
+	items[0].Status = "Done"
+	if blockers := s.Blockers(target("proj-x"), items); !reflect.DeepEqual(blockers, []string{"proj-api"}) {
+		t.Errorf("Blockers() = %v, want proj-api once #12 is done", blockers)
+	}
+	// Blocked items wait for their blockers rather than being started on their own
+	if to, ok := s.AutoStatus(target("proj-x"), "Todo", items); ok {
+		t.Errorf("AutoStatus() moved a blocked item to %s", to)
+	}
+	if to, ok := s.AutoStatus(target("proj-api"), "Todo", items); !ok || to != "In Progress" {
+		t.Errorf("AutoStatus() = %s, %v, want an item that isn't blocked moved to In Progress", to, ok)
+	}
+
+	// Finishing a blocker takes it off the todos it was blocking
+	events.kinds = nil
+	if err := s.SetStatus(target("proj-api"), "done"); err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+	if blockedBy := s.Config().GetTodoForWorktree("proj-x").BlockedBy; !reflect.DeepEqual(blockedBy, []string{"#12", "proj-gone"}) {
+		t.Errorf("blocked_by = %v, want proj-api dropped", blockedBy)
+	}
+	if want := []EventKind{StatusChanged, BlockersChanged}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	s, repo, _ := newService(t)
+	repo.AddWorktree("proj-x")
+	if err := s.TrackWorktree("proj-x", "Fix login", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	target := Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}
+	if err := s.SetStatus(target, "done"); err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+	if _, err := s.DeleteWorktree(Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}); err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+
+	entries, err := history.Load(s.Config().GetConfigPath())
+	if err != nil {
+		t.Fatalf("history.Load() error = %v", err)
+	}
+	var kinds []string
+	for _, entry := range entries {
+		kinds = append(kinds, entry.Kind)
+		if entry.Worktree != "proj-x" || entry.Title != "Fix login" || entry.Actor == "" || entry.Time.IsZero() {
+			t.Errorf("entry = %+v, want proj-x's Fix login, with who did it and when", entry)
+		}
+	}
+	if want := []string{"worktree_created", "status_changed", "worktree_deleted"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("history = %v, want %v", kinds, want)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	repo := testrepo.New(t)
+	fake := &runner.Fake{}
+	fake.On("tmux", "", nil)
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+	gh := &runner.Fake{}
+	gh.On("gh api /repos/owner/repo/events", `[
+		{"type": "PullRequestEvent", "actor": {"login": "sam"}, "created_at": "2026-10-12T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 5, "title": "Fix crash", "merged": true, "head": {"ref": "proj-crash"}}}},
+		{"type": "IssueCommentEvent", "actor": {"login": "agent-bot"}, "created_at": "2026-10-11T09:00:00Z", "payload": {"action": "created", "issue": {"number": 3, "title": "Add login"}}},
+		{"type": "WatchEvent", "actor": {"login": "fan"}, "created_at": "2026-10-13T09:00:00Z", "payload": {"action": "started"}}
+	]`, nil)
+	previousGH := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previousGH) })
+
+	s := New(repo.Config("name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\n"))
+	repo.AddWorktree("proj-crash")
+	if err := s.TrackWorktree("proj-crash", "Fix crash", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	err := s.Config().Update(func(cfg *config.Config) error {
+		cfg.Todos = append(cfg.Todos, config.Todo{Description: "Add login", Worktree: "proj-login", GitHubURL: "https://github.com/owner/repo/issues/3"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	feed, err := s.Feed(10)
+	if err != nil {
+		t.Fatalf("Feed() error = %v", err)
+	}
+	var lines []string
+	for _, entry := range feed {
+		lines = append(lines, entry.Worktree+": "+entry.Text)
+	}
+	expected := []string{
+		`proj-crash: created worktree proj-crash (Fix crash)`,
+		`proj-crash: merged pull request #5 Fix crash`,
+		`proj-login: commented on issue #3 Add login`,
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Feed() = %q, want %q", lines, expected)
+	}
+	if feed, _ := s.Feed(1); len(feed) != 1 {
+		t.Errorf("Feed(1) = %d entries, want 1", len(feed))
+	}
+
+	// Without GitHub, lfg's own history still comes back
+	github.SetRunner(&runner.Fake{})
+	feed, err = s.Feed(10)
+	if err == nil || len(feed) != 1 {
+		t.Errorf("Feed() when GitHub fails = %d entries, %v, want the history and the error", len(feed), err)
+	}
+}
+
+func TestWatchedEvents(t *testing.T) {
+	repo := testrepo.New(t)
+	fake := &runner.Fake{}
+	fake.On("tmux", "", nil)
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+	gh := &runner.Fake{}
+	gh.On("gh api /repos/owner/repo/events", `[
+		{"type": "PullRequestEvent", "actor": {"login": "sam"}, "created_at": "2026-10-12T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 5, "title": "Fix crash", "merged": true, "head": {"ref": "proj-crash"}}}},
+		{"type": "PullRequestEvent", "actor": {"login": "sam"}, "created_at": "2026-10-11T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 4, "title": "Abandoned", "merged": false, "head": {"ref": "proj-old"}}}},
+		{"type": "PullRequestEvent", "actor": {"login": "kim"}, "created_at": "2026-10-10T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 3, "title": "Old news", "merged": true, "head": {"ref": "proj-news"}}}}
+	]`, nil)
+	previousGH := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previousGH) })
+
+	s := New(repo.Config("name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\n"))
+	repo.AddWorktree("proj-crash")
+	merged, err := s.MergedPullRequests(time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("MergedPullRequests() error = %v", err)
+	}
+	if len(merged) != 1 || merged[0].Actor != "sam" || DescribeEntry(merged[0]) != "merged pull request proj-crash (#5 Fix crash)" {
+		t.Errorf("MergedPullRequests() = %+v, want #5 merged from proj-crash", merged)
+	}
+
+	s.Record(Event{Kind: AgentFinished, Worktree: "proj-crash"})
+	s.Record(Event{Kind: PaneCrashed, Worktree: "proj-crash", Detail: "server (npm start) exit status 1"})
+	entries, err := history.Load(s.Config().GetConfigPath())
+	if err != nil {
+		t.Fatalf("history.Load() error = %v", err)
+	}
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, DescribeEntry(entry))
+	}
+	expected := []string{"agent in proj-crash finished", "pane crashed in proj-crash: server (npm start) exit status 1"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("history = %q, want %q", lines, expected)
+	}
+}
+
+func TestReapSessions(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-idle")
+	repo.AddWorktree("proj-attached")
+	repo.AddWorktree("proj-recent")
+	repo.AddWorktree("proj-warm")
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	old := strconv.FormatInt(now.AddDate(0, 0, -10).Unix(), 10)
+	recent := strconv.FormatInt(now.AddDate(0, 0, -1).Unix(), 10)
+	fake := &runner.Fake{}
+	fake.On("tmux list-sessions", "proj-idle\t0\t"+old+"\nproj-attached\t1\t"+old+"\nproj-recent\t0\t"+recent+"\nproj-warm\t0\t"+old+"\t1\nscratch\t0\t"+old+"\n", nil)
+	fake.On("tmux kill-session", "", nil)
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	s := New(repo.Config("name: proj\nidle_sessions:\n  days: 7\n"))
+	idle, err := s.ReapSessions(now)
+	if err != nil {
+		t.Fatalf("ReapSessions() error = %v", err)
+	}
+	if len(idle) != 1 || idle[0].Worktree != "proj-idle" || idle[0].Killed {
+		t.Fatalf("ReapSessions() = %+v, want proj-idle reported and left running", idle)
+	}
+	for _, call := range fake.Calls() {
+		if strings.Contains(call.String(), "kill-session") {
+			t.Errorf("a session was killed when the policy only notifies: %s", call)
+		}
+	}
+
+	var events []Event
+	killer := New(repo.Config("name: proj\nidle_sessions:\n  action: kill\n"))
+	killer.Subscribe(func(e Event) { events = append(events, e) })
+	idle, err = killer.ReapSessions(now)
+	if err != nil || len(idle) != 1 || !idle[0].Killed {
+		t.Fatalf("ReapSessions() = %+v, %v, want proj-idle killed", idle, err)
+	}
+	if len(events) != 1 || events[0].Kind != SessionKilled || events[0].Worktree != "proj-idle" {
+		t.Errorf("events = %+v, want proj-idle's session killed", events)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(repo.Root), "proj-idle")); err != nil {
+		t.Errorf("the idle session's worktree is gone: %v", err)
+	}
+
+	if _, err := New(repo.Config("name: proj\nidle_sessions:\n  action: archive\n")).ReapSessions(now); err == nil {
+		t.Error("ReapSessions() with an unknown action succeeded")
+	}
+}
+
+func TestPrewarmPicks(t *testing.T) {
+	repo := testrepo.New(t)
+	worked := time.Now().Add(time.Hour)
+	for i, name := range []string{"proj-old", "proj-login", "proj-signup", "proj-shipped"} {
+		path := repo.AddWorktree(name)
+		// An uncommitted file each, the later in the list the more recently worked on
+		file := filepath.Join(path, "notes.txt")
+		if err := os.WriteFile(file, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		when := worked.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(file, when, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+	todos := "todos:\n" +
+		"  - description: Old\n    status: pending\n    priority: High\n    worktree: proj-old\n" +
+		"  - description: Login\n    status: pending\n    priority: Low\n    worktree: proj-login\n" +
+		"  - description: Shipped\n    status: done\n    priority: High\n    worktree: proj-shipped\n"
+
+	tests := []struct {
+		name     string
+		prewarm  string
+		recent   []string
+		expected []string
+	}{
+		{name: "most recently worked on", prewarm: "  count: 2\n", expected: []string{"proj-signup", "proj-login"}},
+		{name: "most recently jumped to", prewarm: "  count: 2\n", recent: []string{"proj-old"}, expected: []string{"proj-old", "proj-signup"}},
+		{name: "most urgent", prewarm: "  count: 2\n  by: priority\n", expected: []string{"proj-old", "proj-login"}},
+		{name: "three unless set", prewarm: "  by: recent\n", expected: []string{"proj-signup", "proj-login", "proj-old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(repo.Config("name: proj\nprewarm_sessions:\n" + tt.prewarm + todos))
+			if _, err := state.Update(s.Config().GetConfigPath(), func(st *state.State) error {
+				st.Recent = tt.recent
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			worktrees, err := s.prewarmPicks(nil)
+			if err != nil {
+				t.Fatalf("prewarmPicks() error = %v", err)
+			}
+			var got []string
+			for _, wt := range worktrees {
+				got = append(got, git.GetWorktreeName(wt.Path))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("prewarmPicks() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := New(repo.Config("name: proj\nprewarm_sessions:\n  by: luck\n")).prewarmPicks(nil); err == nil {
+		t.Error("prewarmPicks() by an unknown way succeeded")
+	}
+	if built, err := New(repo.Config("name: proj\n")).PrewarmSessions(nil); built != nil || err != nil {
+		t.Errorf("PrewarmSessions() without prewarm_sessions = %v, %v, want nothing done", built, err)
+	}
+}
+
+func TestKillSessionSavesWIP(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	if err := os.WriteFile(filepath.Join(path, "login.go"), []byte("package login\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := &runner.Fake{}
+	fake.On("tmux kill-session", "", nil)
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	s := New(repo.Config("name: proj\nwip: commit\n"))
+	if err := s.KillSession("proj-login"); err != nil {
+		t.Fatalf("KillSession() error = %v", err)
+	}
+	if subject := repo.Git("-C", path, "log", "-1", "--format=%s"); subject != git.WIPMessage {
+		t.Errorf("latest commit = %q, want the work in progress saved", subject)
+	}
+	if calls := fake.Calls(); len(calls) != 1 {
+		t.Errorf("tmux calls = %v, want the session killed", calls)
+	}
+
+	// The session is left alone when the work can't be saved
+	s = New(repo.Config("name: proj\nwip: shelve\n"))
+	if err := s.KillSession("proj-login"); err == nil || len(fake.Calls()) != 1 {
+		t.Errorf("KillSession() with an unknown wip = %v, %d tmux calls, want an error and no kill", err, len(fake.Calls()))
+	}
+}
+
+func TestHandOff(t *testing.T) {
+	s, repo, events := newService(t)
+	items, _, err := s.ListItems()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ListItems() = %v, %v, want the org file's headline", items, err)
+	}
+	spike := filepath.Join(filepath.Dir(repo.Root), "proj-spike")
+	repo.Git("worktree", "add", "--quiet", "-b", "feat/spike", spike)
+	if err := os.WriteFile(filepath.Join(spike, "login.go"), []byte("package login\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.Git("-C", spike, "add", "login.go")
+	repo.Git("-C", spike, "commit", "--quiet", "-m", "Try a login fix")
+	if err := s.TrackWorktree("proj-spike", "Fix login", "", "", &items[0]); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	repo.AddWorktree("proj-api")
+	if err := s.TrackWorktree("proj-api", "API", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	if err := s.SetBlockedBy(Target{Worktree: "proj-api", Todo: s.Config().GetTodoForWorktree("proj-api")}, []string{"proj-spike"}); err != nil {
+		t.Fatalf("SetBlockedBy() error = %v", err)
+	}
+
+	configPath := s.Config().GetConfigPath()
+	log := filepath.Join(transcript.Dir(configPath, "proj-spike"), "run.log")
+	os.MkdirAll(filepath.Dir(log), 0755)
+	os.WriteFile(log, []byte("tried it"), 0644)
+	if _, err := state.Update(configPath, func(st *state.State) error {
+		st.EnqueueRun("proj-spike", "try it").Transcript = log
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	scratchpad, _ := state.PrepareScratchpad(configPath, "proj-spike")
+	os.WriteFile(scratchpad, []byte("Safari only"), 0644)
+
+	for _, tt := range []struct{ from, to string }{{"proj-spike", "proj-spike"}, {"proj-gone", "proj-x"}, {"proj-spike", "proj-api"}} {
+		if _, err := s.HandOff(context.Background(), tt.from, tt.to); err == nil {
+			t.Errorf("HandOff(%s, %s) succeeded", tt.from, tt.to)
+		}
+	}
+
+	events.kinds = nil
+	handed, err := s.HandOff(context.Background(), "proj-spike", "proj-login")
+	if err != nil || !handed.Created || len(handed.Warnings) > 0 {
+		t.Fatalf("HandOff() = %+v, %v, want proj-login created", handed, err)
+	}
+	login := filepath.Join(filepath.Dir(repo.Root), "proj-login")
+	if branch := repo.Git("-C", login, "branch", "--show-current"); branch != "feat/proj-login" {
+		t.Errorf("proj-login's branch = %q, want feat/proj-login", branch)
+	}
+	if subject := repo.Git("-C", login, "log", "-1", "--format=%s"); subject != "Try a login fix" {
+		t.Errorf("proj-login's latest commit = %q, want the spike's", subject)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-login"); todo == nil || todo.Description != "Fix login" {
+		t.Errorf("proj-login's todo = %+v, want the spike's", todo)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-spike"); todo != nil {
+		t.Errorf("proj-spike still has a todo: %+v", todo)
+	}
+	if blockedBy := s.Config().GetTodoForWorktree("proj-api").BlockedBy; !reflect.DeepEqual(blockedBy, []string{"proj-login"}) {
+		t.Errorf("blocked_by = %v, want proj-login", blockedBy)
+	}
+	if org, _ := os.ReadFile(filepath.Join(repo.Root, "todo.org")); !strings.Contains(string(org), ":WORKTREE: proj-login") {
+		t.Errorf("the headline wasn't linked to proj-login:\n%s", org)
+	}
+
+	moved := filepath.Join(transcript.Dir(configPath, "proj-login"), "run.log")
+	if _, err := os.Stat(moved); err != nil {
+		t.Errorf("transcript wasn't moved: %v", err)
+	}
+	st, _ := state.Load(configPath)
+	if run := st.LatestRun("proj-login"); run == nil || run.Transcript != moved {
+		t.Errorf("LatestRun(proj-login) = %+v, want its transcript at %s", run, moved)
+	}
+	if notes := state.Scratchpad(configPath, "proj-login"); notes != "Safari only" {
+		t.Errorf("proj-login's scratchpad = %q, want the spike's", notes)
+	}
+	if notes := state.Scratchpad(configPath, "proj-spike"); notes != "" {
+		t.Errorf("proj-spike's scratchpad = %q, want it moved", notes)
+	}
+	if want := []EventKind{TodoHandedOff}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+
+	// Back to a worktree that exists, which isn't created again
+	handed, err = s.HandOff(context.Background(), "proj-login", "proj-spike")
+	if err != nil || handed.Created {
+		t.Errorf("HandOff() back = %+v, %v, want the todo moved back", handed, err)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-spike"); todo == nil {
+		t.Error("proj-spike has no todo after handing it back")
+	}
+}
+
+func TestPullRequest(t *testing.T) {
+	repo := testrepo.New(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	repo.Git("init", "--quiet", "--bare", origin)
+	repo.Git("remote", "add", "origin", origin)
+	path := repo.AddWorktree("proj-login")
+	if err := os.WriteFile(filepath.Join(path, "login.go"), []byte("package login\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.Git("-C", path, "add", "login.go")
+	repo.Git("-C", path, "commit", "--quiet", "-m", "Fix the redirect")
+
+	gh := &runner.Fake{}
+	gh.On("gh pr list", "[]", nil)
+	gh.On("gh api /repos/owner/repo/issues/7", `{"title": "Login loops", "body": "Safari never gets past the form", "updated_at": ""}`, nil)
+	gh.On("gh pr create", "https://github.com/owner/repo/pull/9\n", nil)
+	previous := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previous) })
+
+	const cfg = "name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\n" +
+		"todos:\n  - description: Fix login\n    worktree: proj-login\n    github_url: https://github.com/owner/repo/issues/7\n    notes: Needs a Safari check\n"
+	s := New(repo.Config(cfg))
+	draft, err := s.DraftPullRequest("proj-login", "", "Fixed the redirect loop")
+	if err != nil {
+		t.Fatalf("DraftPullRequest() error = %v", err)
+	}
+	if draft.Title != "Fix login" || draft.Base != "main" || draft.Branch != "proj-login" {
+		t.Errorf("DraftPullRequest() = %+v, want Fix login from proj-login into main", draft)
+	}
+	for _, want := range []string{"Closes #7", "## Summary\n\nFixed the redirect loop", "## Notes\n\nNeeds a Safari check", "login.go | 1 +", "<summary>#7 Login loops</summary>\n\nSafari never gets past the form"} {
+		if !strings.Contains(draft.Body, want) {
+			t.Errorf("description doesn't have %q:\n%s", want, draft.Body)
+		}
+	}
+
+	url, err := s.OpenPullRequest(*draft)
+	if err != nil || url != "https://github.com/owner/repo/pull/9" {
+		t.Fatalf("OpenPullRequest() = %q, %v", url, err)
+	}
+	if pushed := repo.Git("--git-dir", origin, "log", "-1", "--format=%s", "proj-login"); pushed != "Fix the redirect" {
+		t.Errorf("origin's proj-login is at %q, want it pushed", pushed)
+	}
+	calls := gh.Calls()
+	if create := calls[len(calls)-1]; !strings.Contains(create.String(), "--head proj-login --base main --title Fix login") || create.Stdin != draft.Body {
+		t.Errorf("gh call = %s with %q, want the draft opened", create, create.Stdin)
+	}
+
+	// The config's own template
+	repo.WriteFile("pr.tmpl", "{{.Title}} on {{.Branch}}: {{.Summary}}")
+	s = New(repo.Config(cfg + "pull_request:\n    template: pr.tmpl\n    draft: true\n"))
+	if draft, err := s.DraftPullRequest("proj-login", "", "done"); err != nil || draft.Body != "Fix login on proj-login: done\n" || !draft.Draft {
+		t.Errorf("DraftPullRequest() with a template = %+v, %v", draft, err)
+	}
+
+	gh = &runner.Fake{}
+	gh.On("gh pr list", `[{"number": 9, "url": "https://github.com/owner/repo/pull/9", "state": "OPEN"}]`, nil)
+	github.SetRunner(gh)
+	if _, err := s.DraftPullRequest("proj-login", "", ""); err == nil {
+		t.Error("DraftPullRequest() for a branch with a pull request succeeded")
+	}
+}
+
+func TestCheckTask(t *testing.T) {
+	s, repo, events := newService(t)
+	repo.AddWorktree("proj-x")
+	if err := s.TrackWorktree("proj-x", "Fix login", "", "", nil); err != nil {
+		t.Fatalf("TrackWorktree() error = %v", err)
+	}
+	err := s.Config().Update(func(cfg *config.Config) error {
+		cfg.GetTodoForWorktree("proj-x").Notes = "- [ ] Reproduce\n- [ ] Fix"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	target := Target{Worktree: "proj-x", Todo: s.Config().GetTodoForWorktree("proj-x")}
+	if checklist := s.Checklist(target); checklist != "- [ ] Reproduce\n- [ ] Fix" {
+		t.Errorf("Checklist() = %q, want the todo's notes", checklist)
+	}
+
+	notes, err := s.CheckTask(target, 1, "Fix", true)
+	if err != nil {
+		t.Fatalf("CheckTask() error = %v", err)
+	}
+	if expected := "- [ ] Reproduce\n- [x] Fix"; notes != expected || s.Config().GetTodoForWorktree("proj-x").Notes != expected {
+		t.Errorf("CheckTask() = %q, todo notes %q, want %q", notes, s.Config().GetTodoForWorktree("proj-x").Notes, expected)
+	}
+	if want := []EventKind{WorktreeCreated, ChecklistChanged}; !reflect.DeepEqual(events.kinds, want) {
+		t.Errorf("events = %v, want %v", events.kinds, want)
+	}
+
+	if _, err := s.CheckTask(target, 1, "Deploy", true); err == nil {
+		t.Error("CheckTask() of an item that has changed succeeded")
+	}
+	if _, err := s.CheckTask(Target{Item: &github.ProjectItem{ID: "login"}}, 0, "Fix", true); err == nil {
+		t.Error("CheckTask() of an item without a todo succeeded")
+	}
+}
+
+func TestOpenLink(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.Git("branch", "fix-signup")
+	fake := &runner.Fake{}
+	fake.On("tmux", "", errors.New("no server running"))
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+	gh := &runner.Fake{}
+	gh.On("gh issue list", `[{"id": "I_12", "number": 12, "title": "Fix login", "body": "The form hangs", "url": "https://github.com/owner/repo/issues/12", "state": "OPEN", "labels": [{"name": "bug"}]}]`, nil)
+	gh.On("gh api /repos/owner/repo/issues/30", `{"title": "Old crash", "body": "Closed a while ago", "updated_at": "2026-01-02T00:00:00Z"}`, nil)
+	gh.On("gh pr view 9", `{"number": 9, "title": "Fix signup", "url": "https://github.com/owner/repo/pull/9", "state": "OPEN", "headRefName": "fix-signup"}`, nil)
+	gh.On("gh", "", nil)
+	previousGH := github.SetRunner(gh)
+	t.Cleanup(func() { github.SetRunner(previousGH) })
+
+	s := New(repo.Config("name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\nbranch_types:\n    - prefix: fix/\n      labels: [bug]\n"))
+	if _, err := s.CreateWorktree(context.Background(), CreateRequest{Name: "proj-signup", Branch: "fix-signup", Checkout: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{name: "issue on the board", link: "https://github.com/owner/repo/issues/12", want: "proj-fix-login"},
+		{name: "the same issue again", link: "https://github.com/Owner/Repo/issues/12#issuecomment-1", want: "proj-fix-login"},
+		{name: "issue off the board", link: "https://github.com/owner/repo/issues/30", want: "proj-old-crash"},
+		{name: "pull request on a worktree's branch", link: "https://github.com/owner/repo/pull/9/files", want: "proj-signup"},
+		{name: "another repository", link: "https://github.com/other/repo/issues/12", wantErr: true},
+		{name: "not an issue", link: "https://github.com/owner/repo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, warnings, err := s.OpenLink(context.Background(), tt.link)
+			if (err != nil) != tt.wantErr || len(warnings) > 0 {
+				t.Fatalf("OpenLink() = %v, %v, wantErr %v", warnings, err, tt.wantErr)
+			}
+			if name != tt.want {
+				t.Errorf("OpenLink() = %q, want %q", name, tt.want)
+			}
+		})
+	}
+
+	if branch := repo.Git("-C", filepath.Join(filepath.Dir(repo.Root), "proj-fix-login"), "branch", "--show-current"); strings.TrimSpace(branch) != "fix/proj-fix-login" {
+		t.Errorf("issue's branch = %q, want the bug's fix/ prefix", branch)
+	}
+	if todo := s.Config().GetTodoForWorktree("proj-old-crash"); todo == nil || todo.GitHubURL != "https://github.com/owner/repo/issues/30" || todo.GitHubBody != "Closed a while ago" {
+		t.Errorf("todo for the issue off the board = %+v, want it to keep the issue", todo)
+	}
+	worktrees, _ := git.ListWorktrees()
+	if len(worktrees) != 4 {
+		t.Errorf("%d worktrees, want one each for the two issues alongside main and proj-signup", len(worktrees))
+	}
+}
+
+func TestCommitPrefix(t *testing.T) {
+	repo := testrepo.New(t)
+	const cfg = "name: proj\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\ntodos:\n" +
+		"  - description: Fix login\n    worktree: proj-login\n    github_url: https://github.com/owner/repo/issues/7\n" +
+		"  - description: Bump the SDK\n    worktree: proj-sdk\n    github_url: https://github.com/owner/sdk/issues/12\n" +
+		"  - description: Tidy up\n    worktree: proj-tidy\n"
+	s := New(repo.Config(cfg))
+	for worktree, want := range map[string]string{"proj-login": "#7: ", "proj-sdk": "owner/sdk#12: ", "proj-tidy": "", "proj-none": ""} {
+		if got := s.CommitPrefix(worktree); got != want {
+			t.Errorf("CommitPrefix(%s) = %q, want %q", worktree, got, want)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{name: "subject", message: "Fix the redirect\n\nIt looped.\n", expected: "#7: Fix the redirect\n\nIt looped.\n"},
+		{name: "written in an editor", message: "\nKeep the cookie\n\n" + scissors + "\n# On branch proj-login\n", expected: "\n#7: Keep the cookie\n\n" + scissors + "\n# On branch proj-login\n"},
+		{name: "already prefixed", message: "#7: Fix the redirect\n", expected: "#7: Fix the redirect\n"},
+		{name: "refers to the issue", message: "Fix the redirect (#7)\n", expected: "Fix the redirect (#7)\n"},
+		{name: "refers to another issue", message: "Fix the redirect, see #70\n", expected: "#7: Fix the redirect, see #70\n"},
+		{name: "empty in an editor", message: "\n" + scissors + "\n", expected: "\n" + scissors + "\n"},
+		{name: "merge", message: "Merge branch 'main' into proj-login\n", expected: "Merge branch 'main' into proj-login\n"},
+		{name: "fixup", message: "fixup! Fix the redirect\n", expected: "fixup! Fix the redirect\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrefixCommitMessage(tt.message, "#7: "); got != tt.expected {
+				t.Errorf("PrefixCommitMessage(%q) = %q, want %q", tt.message, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCommitMsgHook stands in for lfg commit-msg when TestPrefixCommitFile's commits run
+// the test binary as their commit-msg hook
+func TestCommitMsgHook(t *testing.T) {
+	path := os.Getenv("LFG_TEST_COMMIT_MSG")
+	if path == "" {
+		t.Skip("only run as TestPrefixCommitFile's commit-msg hook")
+	}
+	cfg, err := config.LoadFromPath(os.Getenv("LFG_TEST_CONFIG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := git.GetCurrentWorktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	warning, err := New(cfg).PrefixCommitFile(worktree, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+}
+
+func TestPrefixCommitFile(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.Config("name: proj\ncommit_prefix: true\nstorage_backend:\n    type: github-issues\n    owner: owner\n    repo: repo\ntodos:\n" +
+		"  - description: Fix login\n    worktree: proj-login\n    github_url: https://github.com/owner/repo/issues/7\n" +
+		"  - description: Bump the SDK\n    worktree: proj-sdk\n    github_url: https://github.com/owner/sdk/issues/12\n")
+	worktrees := map[string]string{"proj-login": repo.AddWorktree("proj-login"), "proj-sdk": repo.AddWorktree("proj-sdk")}
+	t.Setenv("LFG_TEST_CONFIG", filepath.Join(repo.Root, "lfg-config.yaml"))
+	repo.WriteFile(".git/hooks/commit-msg", fmt.Sprintf("#!/bin/sh\nLFG_TEST_COMMIT_MSG=\"$1\" exec '%s' -test.run='^TestCommitMsgHook$'\n", os.Args[0]))
+	os.Chmod(filepath.Join(repo.Root, ".git", "hooks", "commit-msg"), 0755)
+	// The editor writes the subject above git's comments, as someone would
+	editor := filepath.Join(t.TempDir(), "editor")
+	os.WriteFile(editor, []byte("#!/bin/sh\n{ echo 'Keep the cookie'; cat \"$1\"; } > \"$1.new\" && mv \"$1.new\" \"$1\"\n"), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+
+	tests := []struct {
+		name     string
+		worktree string
+		config   []string
+		args     []string
+		subject  string
+		said     string
+	}{
+		{name: "message given", worktree: "proj-login", args: []string{"-m", "Fix the redirect"}, subject: "#7: Fix the redirect"},
+		{name: "written in an editor", worktree: "proj-login", subject: "GH-7: Keep the cookie"},
+		{name: "scissors", worktree: "proj-login", config: []string{"commit.cleanup", "scissors"}, subject: "#7: Keep the cookie"},
+		{name: "stripped though given", worktree: "proj-login", config: []string{"commit.cleanup", "strip"}, args: []string{"-m", "Fix the redirect"}, subject: "GH-7: Fix the redirect"},
+		{name: "other comment char", worktree: "proj-login", config: []string{"core.commentChar", ";"}, subject: "#7: Keep the cookie"},
+		{name: "another repository's issue", worktree: "proj-sdk", config: []string{"core.commentChar", "o"}, subject: "Keep the cookie", said: "git would take owner/sdk#12 for a comment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config != nil {
+				repo.Git(append([]string{"config"}, tt.config...)...)
+				defer repo.Git("config", "--unset", tt.config[0])
+			}
+			cmd := exec.Command("git", append([]string{"-C", worktrees[tt.worktree], "commit", "--allow-empty"}, tt.args...)...)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("git commit failed: %v\n%s", err, output)
+			}
+			if got := repo.Git("log", "-1", "--format=%s", tt.worktree); got != tt.subject {
+				t.Errorf("subject = %q, want %q", got, tt.subject)
+			}
+			if !strings.Contains(string(output), tt.said) {
+				t.Errorf("git commit printed %q, want it to say %q", output, tt.said)
+			}
+		})
+	}
+}
+
+func TestWorktreeCommand(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	repo.AddWorktree("proj-sdk")
+	s := New(repo.Config("name: proj\nports:\n    names: [PORT]\n"))
+	configPath := s.Config().GetConfigPath()
+	if _, err := state.AssignPorts(configPath, s.Config().Ports, "proj-login"); err != nil {
+		t.Fatalf("AssignPorts() error = %v", err)
+	}
+
+	cmd, err := s.WorktreeCommand("proj-login", []string{"sh", "-c", `printf '%s|' "$@" "$PORT"`, "sh", "a b", "it's"})
+	if err != nil {
+		t.Fatalf("WorktreeCommand() error = %v", err)
+	}
+	if cmd.Dir != path {
+		t.Errorf("WorktreeCommand() dir = %s, want %s", cmd.Dir, path)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running the command: %v", err)
+	}
+	if got, want := string(output), "a b|it's|4000|"; got != want {
+		t.Errorf("the command printed %q, want %q", got, want)
+	}
+
+	// Ports are only read, so a worktree without any isn't given them by running something
+	cmd, err = s.WorktreeCommand("proj-sdk", []string{"sh", "-c", `printf '%s' "$PORT"`})
+	if err != nil {
+		t.Fatalf("WorktreeCommand() error = %v", err)
+	}
+	if output, err := cmd.Output(); err != nil || len(output) > 0 {
+		t.Errorf("the command printed %q, %v, want no port", output, err)
+	}
+	if st, err := state.Load(configPath); err != nil || len(st.PortBlocks) != 1 {
+		t.Errorf("port blocks = %v, %v, want only proj-login's", st.PortBlocks, err)
+	}
+
+	if _, err := s.WorktreeCommand("proj-login", nil); err == nil {
+		t.Error("WorktreeCommand() with no command succeeded")
+	}
+}
+
+func TestSyncCommitHook(t *testing.T) {
+	repo := testrepo.New(t)
+	s := New(repo.Config("name: proj\ncommit_prefix: true\n"))
+	hookPath := filepath.Join(repo.Root, ".git", "hooks", "commit-msg")
+
+	for range 2 {
+		if err := s.SyncCommitHook(); err != nil {
+			t.Fatalf("SyncCommitHook() error = %v", err)
+		}
+	}
+	if hook, err := os.ReadFile(hookPath); err != nil || !strings.Contains(string(hook), `commit-msg "$1"`) {
+		t.Errorf("commit-msg hook = %q, %v, want lfg's", hook, err)
+	}
+	// git's config is left to the user
+	if got := git.ConfigValue(repo.Root, "commit.cleanup"); got != "" {
+		t.Errorf("commit.cleanup = %q, want it left unset", got)
+	}
+
+	s.config.CommitPrefix = false
+	if err := s.SyncCommitHook(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(hookPath); !os.IsNotExist(err) {
+		t.Errorf("lfg's hook was kept once commit_prefix was turned off: %v", err)
+	}
+
+	// A hook of the user's own is never replaced or removed
+	own := "#!/bin/sh\nnpx commitlint --edit \"$1\"\n"
+	os.WriteFile(hookPath, []byte(own), 0755)
+	s.config.CommitPrefix = true
+	if err := s.SyncCommitHook(); err == nil || !strings.Contains(err.Error(), hookCommand) {
+		t.Errorf("SyncCommitHook() with the user's own hook = %v, want it to say to add %s", err, hookCommand)
+	}
+	os.WriteFile(hookPath, []byte(own+hookCommand+"\n"), 0755)
+	if err := s.SyncCommitHook(); err != nil {
+		t.Errorf("SyncCommitHook() with the user's own hook running lfg = %v", err)
+	}
+	s.config.CommitPrefix = false
+	if err := s.SyncCommitHook(); err != nil {
+		t.Fatal(err)
+	}
+	if hook, _ := os.ReadFile(hookPath); string(hook) != own+hookCommand+"\n" {
+		t.Errorf("commit-msg hook = %q, want the user's own left as it was", hook)
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// DueField is the name of the project board's due date field, a date field named Due or
+// Due date, or "" when the backend has none. The board is asked once.
+func (s *Service) DueField() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dueField != nil {
+		return *s.dueField
+	}
+	var name string
+	if backend := s.config.StorageBackend; backend.UsesProject() {
+		name, _ = github.DueField(backend.Owner, backend.Repo, backend.ProjectNumber)
+	}
+	s.dueField = &name
+	return name
+}
+
+// SetDue gives a worktree's todo, and its item when the board has a due date field, a due
+// date as YYYY-MM-DD, or clears it when due is ""
+func (s *Service) SetDue(target Target, due string) error {
+	if due != "" {
+		if _, err := time.Parse(time.DateOnly, due); err != nil {
+			return fmt.Errorf("due date %q isn't a YYYY-MM-DD date", due)
+		}
+	}
+	onBoard := target.Item != nil && s.DueField() != ""
+	if target.Todo == nil && !onBoard {
+		return fmt.Errorf("due dates are kept on todos, and this item has no worktree")
+	}
+
+	if onBoard {
+		backend := s.config.StorageBackend
+		if err := github.UpdateProjectItemDue(backend.Owner, backend.Repo, backend.ProjectNumber, target.Item.ID, due); err != nil {
+			return err
+		}
+		target.Item.Due = due
+	}
+	if target.Todo != nil {
+		err := s.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(target.name())
+			if todo == nil {
+				return fmt.Errorf("%s has no todo", target.name())
+			}
+			todo.Due = due
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+	s.emit(Event{Kind: DueChanged, Worktree: target.name(), Item: target.Item})
+	return nil
+}
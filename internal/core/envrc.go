@@ -0,0 +1,78 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// direnv runs direnv allow, which only records the file as trusted
+var direnv runner.Runner = runner.Exec{Timeout: 30 * time.Second}
+
+// envrcData is what an .envrc template is given
+type envrcData struct {
+	Project  string
+	Worktree string
+	Branch   string
+	Path     string         // The worktree's directory
+	Root     string         // The repository's main worktree
+	Ports    map[string]int // The worktree's ports, by name
+}
+
+// writeEnvrc generates a new worktree's .envrc from the configured template and allows it
+// with direnv when that's installed. A .envrc the branch already has is left alone.
+func (s *Service) writeEnvrc(ctx context.Context, name, branch string) error {
+	settings := s.config.Envrc
+	root := s.config.Root()
+	tmpl, err := template.New(filepath.Base(settings.Template)).Option("missingkey=error").ParseFiles(filepath.Join(root, settings.Template))
+	if err != nil {
+		return fmt.Errorf("failed to read .envrc template: %w", err)
+	}
+
+	path, err := git.GetWorktreePath(name)
+	if err != nil {
+		return err
+	}
+	envrc := filepath.Join(path, ".envrc")
+	if _, err := os.Stat(envrc); err == nil {
+		return nil
+	}
+
+	// The worktree's ports are assigned now, so its .envrc and sessions agree on them
+	data := envrcData{Project: s.config.Name, Worktree: name, Branch: branch, Path: path, Root: root, Ports: map[string]int{}}
+	ports, err := state.AssignPorts(s.config.GetConfigPath(), s.config.Ports, name)
+	if err != nil {
+		return err
+	}
+	for _, port := range ports {
+		data.Ports[port.Name] = port.Number
+	}
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return fmt.Errorf("failed to fill in .envrc template: %w", err)
+	}
+	if err := os.WriteFile(envrc, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write .envrc: %w", err)
+	}
+
+	if !settings.Allows() {
+		return nil
+	}
+	if _, err := exec.LookPath("direnv"); err != nil {
+		return nil
+	}
+	if _, err := direnv.Run(ctx, nil, "direnv", "allow", envrc); err != nil {
+		return fmt.Errorf("failed to allow .envrc: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/history"
+)
+
+// FeedEntry is one thing that happened in the repository, for the activity feed
+type FeedEntry struct {
+	Time     time.Time
+	Actor    string // Who did it: the user lfg ran as, or the GitHub login
+	Text     string // What happened, e.g. "merged pull request #12 Fix login"
+	Worktree string // The worktree it happened to, if it's one of the repository's
+	URL      string // The issue, pull request or comment, if any
+}
+
+// Feed gathers what has happened in the repository lately, newest first and at most limit
+// entries: the changes lfg made, from its history, and with a GitHub backend, the
+// repository's events, such as pull requests merged and comments added. When the events
+// can't be fetched, lfg's own changes come back with the error.
+func (s *Service) Feed(limit int) ([]FeedEntry, error) {
+	entries, err := history.Load(s.config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	feed := make([]FeedEntry, 0, len(entries))
+	for _, entry := range entries {
+		feed = append(feed, FeedEntry{Time: entry.Time, Actor: entry.Actor, Text: DescribeEntry(entry), Worktree: entry.Worktree, URL: entry.URL})
+	}
+
+	var fetchErr error
+	if backend := s.config.StorageBackend; backend.IsGitHub() {
+		var events []github.RepoEvent
+		events, fetchErr = github.ListRepoEvents(backend.Owner, backend.Repo)
+		branches := worktreeBranches()
+		for _, event := range events {
+			if text := event.Summary(); text != "" {
+				feed = append(feed, FeedEntry{Time: event.CreatedAt, Actor: event.Actor, Text: text, Worktree: s.eventWorktree(event, branches), URL: event.URL})
+			}
+		}
+	}
+
+	slices.SortStableFunc(feed, func(a, b FeedEntry) int { return b.Time.Compare(a.Time) })
+	if limit > 0 && len(feed) > limit {
+		feed = feed[:limit]
+	}
+	return feed, fetchErr
+}
+
+// worktreeBranches maps the branches checked out in worktrees to the worktrees' names
+func worktreeBranches() map[string]string {
+	worktrees, _ := git.ListWorktrees()
+	branches := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		if branch := strings.TrimPrefix(wt.Branch, "refs/heads/"); branch != "" {
+			branches[branch] = git.GetWorktreeName(wt.Path)
+		}
+	}
+	return branches
+}
+
+// eventWorktree is the worktree a repository event happened to: the one with the pull
+// request's branch, or the one whose todo is linked to the issue or pull request
+func (s *Service) eventWorktree(event github.RepoEvent, branches map[string]string) string {
+	if name, ok := branches[event.Branch]; ok && event.Branch != "" {
+		return name
+	}
+	if event.Number == 0 {
+		return ""
+	}
+	for _, todo := range s.config.Todos {
+		// Review worktrees' todos link to their pull requests, which share issues' numbers
+		if number, err := github.IssueNumberFromURL(todo.GitHubURL); err == nil && number == event.Number {
+			return todo.Worktree
+		}
+	}
+	return ""
+}
+
+// MergedPullRequests lists the repository's pull requests merged after since, oldest
+// first, as history entries of the worktrees they're from, if they're known. There are
+// none without a GitHub backend.
+func (s *Service) MergedPullRequests(since time.Time) ([]history.Entry, error) {
+	backend := s.config.StorageBackend
+	if !backend.IsGitHub() {
+		return nil, nil
+	}
+	events, err := github.ListRepoEvents(backend.Owner, backend.Repo)
+	if err != nil {
+		return nil, err
+	}
+	branches := worktreeBranches()
+	var merged []history.Entry
+	for _, event := range slices.Backward(events) {
+		if event.Type != "PullRequestEvent" || event.Action != "closed" || !event.Merged || !event.CreatedAt.After(since) {
+			continue
+		}
+		merged = append(merged, history.Entry{
+			Time:     event.CreatedAt,
+			Kind:     string(PullRequestMerged),
+			Actor:    event.Actor,
+			Via:      "github",
+			Worktree: s.eventWorktree(event, branches),
+			Title:    fmt.Sprintf("#%d %s", event.Number, event.Title),
+			URL:      event.URL,
+		})
+	}
+	return merged, nil
+}
+
+// DescribeEntry says what a history entry did, e.g. "deleted worktree proj-login (Add login)"
+func DescribeEntry(entry history.Entry) string {
+	subject := entry.Worktree
+	if entry.Title != "" {
+		subject = strconv.Quote(entry.Title)
+		if entry.Worktree != "" {
+			subject = fmt.Sprintf("%s (%s)", entry.Worktree, entry.Title)
+		}
+	}
+
+	switch EventKind(entry.Kind) {
+	case WorktreeCreated:
+		return "created worktree " + subject
+	case WorktreeDeleted:
+		return "deleted worktree " + subject
+	case SessionKilled:
+		return "killed the tmux session of " + subject
+	case StatusChanged:
+		if entry.From != "" && entry.From != entry.Status {
+			return fmt.Sprintf("moved %s from %s to %s", subject, entry.From, entry.Status)
+		}
+		return fmt.Sprintf("moved %s to %s", subject, entry.Status)
+	case ItemCreated:
+		return "created item " + subject
+	case ItemRenamed:
+		return "renamed " + subject
+	case TodoHandedOff:
+		return fmt.Sprintf("handed %s over from %s", subject, entry.Detail)
+	case PullRequestOpened:
+		return fmt.Sprintf("opened pull request %s for %s", entry.Detail, subject)
+	case AgentFinished:
+		if entry.Detail != "" {
+			return fmt.Sprintf("agent in %s %s", subject, entry.Detail)
+		}
+		return fmt.Sprintf("agent in %s finished", subject)
+	case PaneCrashed:
+		return fmt.Sprintf("pane crashed in %s: %s", subject, entry.Detail)
+	case PullRequestMerged:
+		return "merged pull request " + subject
+	}
+	// e.g. "due_changed" is "due changed"
+	return strings.ReplaceAll(entry.Kind, "_", " ") + ": " + subject
+}
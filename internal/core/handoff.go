@@ -0,0 +1,221 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/internal/transcript"
+)
+
+// HandedOff is what handing a worktree's todo to another did
+type HandedOff struct {
+	Created  bool     // The worktree handed to was created for it, from the other's branch
+	Session  string   // The session closed in the worktree handed from, if it had one
+	Warnings []string // Parts of the handoff that failed without stopping it
+}
+
+// HandOff moves a worktree's todo to another worktree, with everything kept for it: its
+// item's link, the todos blocked by it, its agent transcripts and runs, its mirrored
+// conversations, its scratchpad and its place among the recently used. A worktree that
+// doesn't exist yet is created from the first's branch, so a spike's work comes along when
+// it graduates into a properly named worktree. The first's session is closed, saving its
+// work in progress when wip is set, and the task's session opens in the other on the next
+// jump. The config and the state are each changed in one go, under their locks.
+func (s *Service) HandOff(ctx context.Context, from, to string) (HandedOff, error) {
+	var handed HandedOff
+	if from == to {
+		return handed, fmt.Errorf("%s can't be handed off to itself", from)
+	}
+	if _, err := git.GetWorktreePath(from); err != nil {
+		return handed, err
+	}
+	todo := s.config.GetTodoForWorktree(from)
+	if todo == nil {
+		return handed, fmt.Errorf("%s has no todo to hand off", from)
+	}
+	if s.config.GetTodoForWorktree(to) != nil {
+		return handed, fmt.Errorf("%s already has a todo", to)
+	}
+	st, err := state.Load(s.config.GetConfigPath())
+	if err != nil {
+		return handed, err
+	}
+	if st.HasRunningRun(from) {
+		return handed, fmt.Errorf("an agent task is running in %s; wait for it to finish first", from)
+	}
+
+	_, err = git.GetWorktreePath(to)
+	create := errors.Is(err, git.ErrWorktreeNotFound)
+	if err != nil && !create {
+		return handed, err
+	}
+	var request CreateRequest
+	if create {
+		if request, err = s.handOffRequest(from, to); err != nil {
+			return handed, err
+		}
+	}
+
+	// What runs in the session is for the task, and its work in progress should go with it
+	if session := tmux.SanitizeSessionName(from); tmux.SessionExists(session) {
+		if err := s.KillSession(from); err != nil {
+			return handed, fmt.Errorf("failed to close %s's session: %w", from, err)
+		}
+		handed.Session = session
+	}
+	if create {
+		warnings, err := s.CreateWorktree(ctx, request)
+		handed.Warnings = append(handed.Warnings, warnings...)
+		if err != nil {
+			return handed, err
+		}
+		handed.Created = true
+	}
+
+	err = s.config.Update(func(cfg *config.Config) error {
+		todo := cfg.GetTodoForWorktree(from)
+		if todo == nil {
+			return fmt.Errorf("%s has no todo to hand off", from)
+		}
+		todo.Worktree = to
+		for i := range cfg.Todos {
+			for j, blocker := range cfg.Todos[i].BlockedBy {
+				if blocker == from {
+					cfg.Todos[i].BlockedBy[j] = to
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return handed, fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if s.config.StorageBackend.IsOrg() {
+		if item, err := s.orgItemFor(from, todo); err != nil {
+			handed.Warnings = append(handed.Warnings, err.Error())
+		} else if item != nil {
+			if err := s.LinkWorktree(item, to); err != nil {
+				handed.Warnings = append(handed.Warnings, fmt.Sprintf("failed to link %s to its item: %v", to, err))
+			}
+		}
+	}
+
+	configPath := s.config.GetConfigPath()
+	moved, err := moveFiles(transcript.Dir(configPath, from), transcript.Dir(configPath, to))
+	if err != nil {
+		handed.Warnings = append(handed.Warnings, fmt.Sprintf("failed to move transcripts: %v", err))
+	}
+	_, err = state.Update(configPath, func(st *state.State) error {
+		st.HandOff(from, to)
+		for i, run := range st.Runs {
+			if target, ok := moved[run.Transcript]; ok {
+				st.Runs[i].Transcript = target
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		handed.Warnings = append(handed.Warnings, fmt.Sprintf("failed to save state: %v", err))
+	}
+	if err := handOffScratchpad(configPath, from, to); err != nil {
+		handed.Warnings = append(handed.Warnings, err.Error())
+	}
+
+	s.emit(Event{Kind: TodoHandedOff, Worktree: to, Detail: from})
+	return handed, nil
+}
+
+// handOffRequest is the worktree to create for a todo handed off to a name that has none:
+// on a new branch named like the first's, prefix and all, from the first's branch
+func (s *Service) handOffRequest(from, to string) (CreateRequest, error) {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return CreateRequest{}, err
+	}
+	request := CreateRequest{Name: to}
+	for _, wt := range worktrees {
+		if git.GetWorktreeName(wt.Path) == from {
+			request.Base = strings.TrimPrefix(wt.Branch, "refs/heads/")
+		}
+	}
+	if prefix, _, ok := strings.Cut(request.Base, "/"); ok {
+		request.Branch = prefix + "/" + to
+	}
+	if request.Base == "" {
+		return request, fmt.Errorf("%s has no branch to start %s from", from, to)
+	}
+	collision, err := s.Collision(request)
+	if err != nil {
+		return request, err
+	}
+	if collision != "" {
+		return request, fmt.Errorf("can't create %s: %s", to, collision)
+	}
+	return request, nil
+}
+
+// orgItemFor finds the org headline a worktree's todo is for
+func (s *Service) orgItemFor(worktree string, todo *config.Todo) (*github.ProjectItem, error) {
+	items, _, err := s.orgItems()
+	if err != nil {
+		return nil, err
+	}
+	return itemFor(items, worktree, todo), nil
+}
+
+// moveFiles moves the files in one directory into another, returning where each went.
+// The directory moved from is removed once it's empty.
+func moveFiles(from, to string) (map[string]string, error) {
+	entries, err := os.ReadDir(from)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(to, 0755); err != nil {
+		return nil, err
+	}
+	moved := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		source, target := filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			return moved, fmt.Errorf("%s is already in %s", entry.Name(), to)
+		}
+		if err := os.Rename(source, target); err != nil {
+			return moved, err
+		}
+		moved[source] = target
+	}
+	return moved, os.Remove(from)
+}
+
+// handOffScratchpad moves a worktree's scratchpad to another, after the notes already in
+// the other's
+func handOffScratchpad(configPath, from, to string) error {
+	notes := state.Scratchpad(configPath, from)
+	if notes == "" {
+		return state.RemoveScratchpad(configPath, from)
+	}
+	if existing := state.Scratchpad(configPath, to); existing != "" {
+		notes = existing + "\n\n" + notes
+	}
+	path, err := state.PrepareScratchpad(configPath, to)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(notes+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to move scratchpad: %w", err)
+	}
+	return state.RemoveScratchpad(configPath, from)
+}
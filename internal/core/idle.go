@@ -0,0 +1,72 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// IdleSession is a worktree's tmux session that nobody is attached to and that hasn't been
+// used for as long as the config's idle_sessions allows
+type IdleSession struct {
+	Worktree   string
+	LastActive time.Time
+	Killed     bool // Whether ReapSessions killed it
+}
+
+// ReapSessions finds the idle sessions of the repository's worktrees and, when
+// idle_sessions says to, kills them, keeping their worktrees. It finds nothing without
+// idle_sessions. Sessions that fail to be killed are still returned, along with the error.
+func (s *Service) ReapSessions(now time.Time) ([]IdleSession, error) {
+	policy := s.config.IdleSessions
+	if policy == nil {
+		return nil, nil
+	}
+	kill := false
+	switch policy.Action {
+	case "", config.IdleNotify:
+	case config.IdleKill:
+		kill = true
+	default:
+		return nil, fmt.Errorf("unknown idle_sessions action %q, want %s or %s", policy.Action, config.IdleNotify, config.IdleKill)
+	}
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := tmux.ListSessionInfo()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]tmux.SessionInfo, len(infos))
+	for _, info := range infos {
+		sessions[info.Name] = info
+	}
+
+	var idle []IdleSession
+	var errs []error
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		info, ok := sessions[tmux.SanitizeSessionName(name)]
+		// Without its last activity a session can't be told to be idle. Prewarmed ones are
+		// waiting to be used, not forgotten.
+		if !ok || info.Attached > 0 || info.Prewarmed || info.Activity.IsZero() || now.Sub(info.Activity) < policy.After() {
+			continue
+		}
+		session := IdleSession{Worktree: name, LastActive: info.Activity}
+		if kill {
+			if err := s.KillSession(name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to kill the session of %s: %w", name, err))
+			} else {
+				session.Killed = true
+			}
+		}
+		idle = append(idle, session)
+	}
+	return idle, errors.Join(errs...)
+}
@@ -0,0 +1,374 @@
+package core
+
+import (
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/orgmode"
+	"github.com/markcipolla/lfg/internal/plugin"
+)
+
+// DefaultStatuses are used until the project's own Status options have been fetched
+var DefaultStatuses = []string{"Todo", "In Progress", "Done"}
+
+// BackendName names where items are tracked, for messages
+func (s *Service) BackendName() string {
+	switch backend := s.config.StorageBackend; {
+	case backend.IsPlugin():
+		return backend.Plugin
+	case backend.IsOrg():
+		return filepath.Base(s.config.OrgFile())
+	}
+	return "GitHub"
+}
+
+// SetStatuses records the statuses the backend's items move between, once they're known
+func (s *Service) SetStatuses(statuses []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses = statuses
+}
+
+// StatusOptions lists the statuses items can be moved to for the configured backend
+func (s *Service) StatusOptions() []string {
+	if !s.config.StorageBackend.Remote() {
+		return []string{string(config.TodoStatusPending), string(config.TodoStatusDone)}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.statuses) > 0 {
+		return s.statuses
+	}
+	return DefaultStatuses
+}
+
+// CanMoveTo reports whether the backend has a status lfg moves items to as their worktrees
+// are created and finished. A plugin's statuses may go by other names.
+func (s *Service) CanMoveTo(status string) bool {
+	if !s.config.StorageBackend.IsPlugin() {
+		return true
+	}
+	return slices.Contains(s.StatusOptions(), status)
+}
+
+// AutoStatus returns the status lfg moves target's item in status to on its own once the
+// item has a worktree, and whether storage_backend.auto_status lets it and the backend has
+// that status. Blocked items wait for their blockers among items, however they got a worktree.
+func (s *Service) AutoStatus(target Target, status string, items []github.ProjectItem) (string, bool) {
+	backend := s.config.StorageBackend
+	if !backend.Remote() {
+		return "", false
+	}
+	to, ok := backend.AutoStatus.Move(status)
+	return to, ok && s.CanMoveTo(to) && len(s.Blockers(target, items)) == 0
+}
+
+// WorktreeStatus is the status of a worktree's item among items, or of its todo when it has
+// no item, "" when it has neither
+func (s *Service) WorktreeStatus(items []github.ProjectItem, worktree string) string {
+	todo := s.config.GetTodoForWorktree(worktree)
+	if item := itemFor(items, worktree, todo); item != nil {
+		return item.Status
+	}
+	if todo != nil {
+		return string(todo.Status)
+	}
+	return ""
+}
+
+// itemFor finds a worktree's item by the link the backend records or its todo's copy of it
+func itemFor(items []github.ProjectItem, name string, todo *config.Todo) *github.ProjectItem {
+	for i := range items {
+		item := &items[i]
+		if item.Worktree == name {
+			return item
+		}
+		if todo != nil && (todo.GitHubURL != "" && item.Content.URL == todo.GitHubURL || item.Title == todo.Description) {
+			return item
+		}
+	}
+	return nil
+}
+
+// ListItems fetches every item from the configured backend in one go, with the statuses
+// they move between
+func (s *Service) ListItems() ([]github.ProjectItem, []string, error) {
+	items, statuses, err := s.listItems()
+	if err == nil && len(statuses) > 0 {
+		s.SetStatuses(statuses)
+	}
+	return items, statuses, err
+}
+
+func (s *Service) listItems() ([]github.ProjectItem, []string, error) {
+	backend := s.config.StorageBackend
+	switch {
+	case !backend.Remote():
+		return nil, nil, nil
+	case backend.IsPlugin():
+		return s.pluginItems()
+	case backend.IsOrg():
+		return s.orgItems()
+	case !backend.UsesProject():
+		items, err := github.ListIssues(backend.Owner, backend.Repo, backend.InProgress())
+		return items, github.IssueStatuses, err
+	}
+
+	projectID, err := github.FindProjectID(backend.Owner, backend.Repo, backend.ProjectNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []github.ProjectItem
+	cursor := ""
+	for {
+		page, next, err := github.ListProjectItemsPage(projectID, cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	// Non-fatal, callers fall back to the default board columns
+	statuses, _ := github.ListStatusOptions(backend.Owner, backend.Repo, backend.ProjectNumber)
+	return items, statuses, nil
+}
+
+// SetItemStatus moves an item to a status: a column on the project board, the state and
+// in-progress label of a plain issue, or whatever a plugin makes of it. Moving it to Done
+// unblocks the todos it was blocking.
+func (s *Service) SetItemStatus(item *github.ProjectItem, status string) error {
+	if err := s.setItemStatus(item, status, item.Worktree); err != nil {
+		return err
+	}
+	if isDone(status) {
+		return s.unblock(Target{Worktree: item.Worktree, Item: item})
+	}
+	return nil
+}
+
+func (s *Service) setItemStatus(item *github.ProjectItem, status, worktree string) error {
+	backend := s.config.StorageBackend
+	var err error
+	switch {
+	case backend.IsPlugin():
+		err = plugin.SetStatus(backend.Plugin, backend.Options, toPluginItem(item), status)
+	case backend.IsOrg():
+		err = s.editOrg(func(file *orgmode.File) error {
+			return file.SetStatus(item.ID, status, time.Now())
+		})
+	case !backend.UsesProject():
+		err = github.SetIssueStatus(backend.Owner, backend.Repo, item, backend.InProgress(), status)
+	default:
+		err = github.UpdateProjectItemStatus(backend.Owner, backend.Repo, backend.ProjectNumber, item.ID, status)
+	}
+	if err != nil {
+		return err
+	}
+	s.emit(Event{Kind: StatusChanged, Worktree: worktree, Item: item, Status: status, From: item.Status})
+	return nil
+}
+
+// CreateItem adds an item titled title: a draft issue on the project board, an issue, an
+// item in the plugin's tracker, or an org headline
+func (s *Service) CreateItem(title string) (*github.ProjectItem, error) {
+	item, err := s.createItem(title)
+	if err != nil {
+		return nil, err
+	}
+	s.emit(Event{Kind: ItemCreated, Item: item})
+	return item, nil
+}
+
+func (s *Service) createItem(title string) (*github.ProjectItem, error) {
+	backend := s.config.StorageBackend
+	switch {
+	case backend.IsPlugin():
+		created, err := plugin.Create(backend.Plugin, backend.Options, title)
+		if err != nil {
+			return nil, err
+		}
+		item := fromPluginItem(*created)
+		return &item, nil
+	case backend.IsOrg():
+		var added orgmode.Headline
+		err := s.editOrg(func(file *orgmode.File) (err error) {
+			added, err = file.Add(title)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		item := fromHeadline(added)
+		return &item, nil
+	case !backend.UsesProject():
+		return github.CreateIssue(backend.Owner, backend.Repo, title)
+	}
+	return github.CreateProjectItem(backend.Owner, backend.Repo, backend.ProjectNumber, title)
+}
+
+// RenameItem renames an item
+func (s *Service) RenameItem(item *github.ProjectItem, title string) error {
+	backend := s.config.StorageBackend
+	var err error
+	switch {
+	case backend.IsPlugin():
+		err = plugin.SetTitle(backend.Plugin, backend.Options, toPluginItem(item), title)
+	case backend.IsOrg():
+		err = s.editOrg(func(file *orgmode.File) error {
+			return file.SetTitle(item.ID, title)
+		})
+	default:
+		err = github.UpdateItemTitle(backend.Owner, backend.Repo, item, title)
+	}
+	if err != nil {
+		return err
+	}
+	s.emit(Event{Kind: ItemRenamed, Worktree: item.Worktree, Item: item})
+	return nil
+}
+
+// LinkWorktree records which worktree an item is being worked on in, for backends that keep
+// the link themselves
+func (s *Service) LinkWorktree(item *github.ProjectItem, worktree string) error {
+	if !s.config.StorageBackend.IsOrg() {
+		return nil
+	}
+	err := s.editOrg(func(file *orgmode.File) error {
+		return file.SetWorktree(item.ID, worktree)
+	})
+	if err == nil {
+		item.Worktree = worktree
+	}
+	return err
+}
+
+// editOrg applies edit to the org file, reading it fresh so changes made in Emacs since the
+// last refresh are kept
+func (s *Service) editOrg(edit func(file *orgmode.File) error) error {
+	file, err := orgmode.Load(s.config.OrgFile())
+	if err != nil {
+		return err
+	}
+	if err := edit(file); err != nil {
+		return err
+	}
+	return file.Save()
+}
+
+// orgItems lists the org file's headlines
+func (s *Service) orgItems() ([]github.ProjectItem, []string, error) {
+	file, err := orgmode.Load(s.config.OrgFile())
+	if err != nil {
+		return nil, nil, err
+	}
+	headlines := file.Headlines()
+	items := make([]github.ProjectItem, 0, len(headlines))
+	parents := make([]string, 0, len(headlines))
+	for _, headline := range headlines {
+		items = append(items, fromHeadline(headline))
+		parents = append(parents, headline.Parent)
+	}
+	linkParents(items, parents)
+	return items, orgmode.Statuses, nil
+}
+
+// fromHeadline converts an org headline to the shape GitHub items come in
+func fromHeadline(headline orgmode.Headline) github.ProjectItem {
+	item := github.ProjectItem{
+		ID:       headline.ID,
+		Title:    headline.Title,
+		Status:   headline.Status,
+		Body:     headline.Body,
+		Worktree: headline.Worktree,
+	}
+	item.Content.Title = headline.Title
+	item.Content.Body = headline.Body
+	return item
+}
+
+// pluginItems lists the plugin's items, which arrive in one go
+func (s *Service) pluginItems() ([]github.ProjectItem, []string, error) {
+	backend := s.config.StorageBackend
+	pluginItems, statuses, err := plugin.List(backend.Plugin, backend.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]github.ProjectItem, 0, len(pluginItems))
+	parents := make([]string, 0, len(pluginItems))
+	for _, item := range pluginItems {
+		items = append(items, fromPluginItem(item))
+		parents = append(parents, item.Parent)
+	}
+	linkParents(items, parents)
+	return items, statuses, nil
+}
+
+// linkParents points each item at its parent, given by the parent's ID in parents. A
+// parent that isn't among the items is known by its ID alone.
+func linkParents(items []github.ProjectItem, parents []string) {
+	byID := make(map[string]int, len(items))
+	for i := range items {
+		byID[items[i].ID] = i
+	}
+	for i, id := range parents {
+		if id == "" {
+			continue
+		}
+		parent := github.ItemRef{Title: id}
+		if j, ok := byID[id]; ok {
+			parent = items[j].Ref()
+		}
+		items[i].Parent = &parent
+	}
+}
+
+// fromPluginItem converts a plugin's item to the shape GitHub items come in
+func fromPluginItem(item plugin.Item) github.ProjectItem {
+	converted := github.ProjectItem{
+		ID:        item.ID,
+		Title:     item.Title,
+		Status:    item.Status,
+		Body:      item.Body,
+		UpdatedAt: item.UpdatedAt,
+	}
+	converted.Content.Number = item.Number
+	converted.Content.Title = item.Title
+	converted.Content.Body = item.Body
+	converted.Content.URL = item.URL
+	return converted
+}
+
+func toPluginItem(item *github.ProjectItem) plugin.Item {
+	return plugin.Item{
+		ID:        item.ID,
+		Title:     item.Title,
+		Status:    item.Status,
+		Body:      item.Content.Body,
+		URL:       item.Content.URL,
+		Number:    item.Content.Number,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
+
+// issueNumber is the GitHub issue the target is, or its todo is linked to, 0 when there
+// isn't one
+func (s *Service) issueNumber(target Target) int {
+	if !s.config.StorageBackend.IsGitHub() {
+		return 0
+	}
+	if target.Item != nil && target.Item.Content.Number > 0 {
+		return target.Item.Content.Number
+	}
+	if target.Todo != nil {
+		if number, err := github.IssueNumberFromURL(target.Todo.GitHubURL); err == nil {
+			return number
+		}
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// OpenLink finds the worktree for the issue or pull request a GitHub URL points at,
+// creating one when there isn't one, and returns its name. A worktree is found by the
+// issue's item, as the TUI matches them, by the URL its todo was created from, or for a
+// pull request by its branch. New worktrees' todos keep the URL, so the next link to the
+// same issue or pull request finds them. Pull requests without a worktree are checked out
+// to review as lfg review does.
+func (s *Service) OpenLink(ctx context.Context, link string) (name string, warnings []string, err error) {
+	target, err := github.ParseLink(link)
+	if err != nil {
+		return "", nil, err
+	}
+	owner, repo, err := s.pullRequestRepo()
+	if err != nil {
+		return "", nil, err
+	}
+	if !strings.EqualFold(target.Owner, owner) || !strings.EqualFold(target.Repo, repo) {
+		return "", nil, fmt.Errorf("%s is on %s/%s, not this repository's %s/%s", target.URL(), target.Owner, target.Repo, owner, repo)
+	}
+	target.Owner, target.Repo = owner, repo
+
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return "", nil, err
+	}
+	// The URL GitHub gives links to the issue or pull request's own repository
+	byURL := func(todoURL string) bool {
+		linked, err := github.ParseLink(todoURL)
+		return err == nil && linked.Number == target.Number && linked.PullRequest == target.PullRequest &&
+			strings.EqualFold(linked.Owner, owner) && strings.EqualFold(linked.Repo, repo)
+	}
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		if todo := s.config.GetTodoForWorktree(name); todo != nil && todo.GitHubURL != "" && byURL(todo.GitHubURL) {
+			return name, nil, nil
+		}
+	}
+
+	if target.PullRequest {
+		pr, err := github.GetPullRequest(owner, repo, target.Number)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, wt := range worktrees {
+			if pr.HeadRefName != "" && strings.TrimPrefix(wt.Branch, "refs/heads/") == pr.HeadRefName {
+				return git.GetWorktreeName(wt.Path), nil, nil
+			}
+		}
+		return s.Review(ctx, target.Number)
+	}
+
+	// The issue's item, if it's on the board, is matched to a worktree as the TUI does
+	items, _, err := s.ListItems()
+	if err != nil {
+		return "", nil, err
+	}
+	var item *github.ProjectItem
+	for i := range items {
+		if items[i].Content.Number == target.Number && (items[i].Content.URL == "" || byURL(items[i].Content.URL)) {
+			item = &items[i]
+			break
+		}
+	}
+	onBoard := item
+	if item != nil {
+		index := NewItemIndex(s.config.Name, items)
+		for _, wt := range worktrees {
+			name := git.GetWorktreeName(wt.Path)
+			if i := index.Match(name, s.config.GetTodoForWorktree(name)); i >= 0 && &items[i] == item {
+				return name, nil, nil
+			}
+		}
+	} else {
+		issue, err := github.GetIssue(owner, repo, target.Number)
+		if err != nil {
+			return "", nil, err
+		}
+		// Not on the board, so the worktree's todo is all that tracks it
+		item = &github.ProjectItem{Title: issue.Title}
+		item.Content.Number = target.Number
+		item.Content.Body = issue.Body
+		item.Content.URL = target.URL()
+	}
+	if item.Content.URL == "" {
+		item.Content.URL = target.URL()
+	}
+
+	// Named and branched as the TUI names a worktree created from an item
+	request := CreateRequest{Name: WorktreeName(s.config.Name, item.Title), Item: onBoard}
+	if prefix := s.config.BranchPrefixFor(item.Labels); prefix != "" {
+		request.Branch = prefix + request.Name
+	}
+	if collision, err := s.Collision(request); err != nil {
+		return "", nil, err
+	} else if collision != "" {
+		if request, err = s.Unclash(request); err != nil {
+			return "", nil, err
+		}
+	}
+	if warnings, err = s.CreateWorktree(ctx, request); err != nil {
+		return "", warnings, err
+	}
+	if err := s.TrackWorktree(request.Name, item.Title, "", "", item); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	return request.Name, warnings, nil
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// WorktreeName creates a worktree name from project name and feature description
+// Format: [project-name]-[dasherized-feature-name]
+func WorktreeName(projectName, description string) string {
+	key := [2]string{projectName, description}
+	worktreeNames.Lock()
+	defer worktreeNames.Unlock()
+	name, ok := worktreeNames.names[key]
+	if !ok {
+		if len(worktreeNames.names) >= maxWorktreeNames {
+			clear(worktreeNames.names)
+		}
+		name = projectName + "-" + dasherize(description)
+		worktreeNames.names[key] = name
+	}
+	return name
+}
+
+// worktreeNames memoizes WorktreeName, which every refresh runs for each item on
+// the board
+var worktreeNames = struct {
+	sync.Mutex
+	names map[[2]string]string
+}{names: make(map[[2]string]string)}
+
+// maxWorktreeNames bounds the memo, which is emptied when it fills
+const maxWorktreeNames = 4096
+
+// dasherize lowercases s, keeps its letters and digits and joins the runs of them with
+// single dashes
+func dasherize(s string) string {
+	var result strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			if dash && result.Len() > 0 {
+				result.WriteByte('-')
+			}
+			dash = false
+			result.WriteRune(r)
+		case r == ' ' || r == '-':
+			dash = true
+		}
+	}
+	return result.String()
+}
+
+// ItemIndex finds the item for a worktree by a link the tracker records, the worktree name
+// its title makes, its issue number, or a todo edited to match it, without trying every
+// item for each worktree. Each key maps to the first item on the board with it.
+type ItemIndex struct {
+	byWorktree map[string]int
+	byName     map[string]int
+	byIssue    map[string]int
+	byTitle    map[string]int
+	byURL      map[string]int
+}
+
+// NewItemIndex indexes items by everything a worktree may be matched to one by
+func NewItemIndex(projectName string, items []github.ProjectItem) *ItemIndex {
+	index := &ItemIndex{
+		byWorktree: make(map[string]int),
+		byName:     make(map[string]int, len(items)),
+		byIssue:    make(map[string]int, len(items)),
+		byTitle:    make(map[string]int, len(items)),
+		byURL:      make(map[string]int, len(items)),
+	}
+	add := func(keys map[string]int, key string, i int) {
+		if _, ok := keys[key]; !ok {
+			keys[key] = i
+		}
+	}
+	for i, item := range items {
+		if item.Worktree != "" {
+			add(index.byWorktree, item.Worktree, i)
+		}
+		add(index.byName, WorktreeName(projectName, item.Title), i)
+		if item.Content.Number > 0 {
+			add(index.byIssue, fmt.Sprintf("issue-%d", item.Content.Number), i)
+		}
+		add(index.byTitle, item.Title, i)
+		if item.Content.URL != "" {
+			add(index.byURL, item.Content.URL, i)
+		}
+	}
+	return index
+}
+
+// Match returns the index of the first item matching the worktree, or -1 if none does
+func (x *ItemIndex) Match(name string, todo *config.Todo) int {
+	first := -1
+	consider := func(keys map[string]int, key string) {
+		if i, ok := keys[key]; ok && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	consider(x.byWorktree, name)
+	consider(x.byName, name)
+	consider(x.byIssue, name)
+	if todo != nil {
+		consider(x.byTitle, todo.Description)
+		if todo.GitHubURL != "" {
+			consider(x.byURL, todo.GitHubURL)
+		}
+	}
+	return first
+}
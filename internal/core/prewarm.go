@@ -0,0 +1,108 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// PrewarmSessions builds sessions, detached and with their commands held until they're
+// opened, for the worktrees prewarm_sessions picks that don't have one yet. It does nothing
+// without prewarm_sessions, and returns the worktrees it built sessions for. Sessions that
+// fail to be built don't stop the rest, and are returned in the error.
+func (s *Service) PrewarmSessions(items []github.ProjectItem) ([]string, error) {
+	if s.config.Prewarm == nil {
+		return nil, nil
+	}
+	worktrees, err := s.prewarmPicks(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var built []string
+	var errs []error
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		ok, err := tmux.PrewarmSession(name, wt.Path, s.config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to prewarm %s: %w", name, err))
+		} else if ok {
+			built = append(built, name)
+		}
+	}
+	return built, errors.Join(errs...)
+}
+
+// prewarmPicks are the worktrees likely to be jumped to next, as many as prewarm_sessions
+// keeps sessions ready for: the most recently jumped to, then worked on, or with
+// by: priority the most urgent, most recent first among equals. Worktrees whose work is
+// done aren't picked.
+func (s *Service) prewarmPicks(items []github.ProjectItem) ([]git.Worktree, error) {
+	policy := s.config.Prewarm
+	switch policy.By {
+	case "", config.PrewarmRecent, config.PrewarmPriority:
+	default:
+		return nil, fmt.Errorf("unknown prewarm_sessions by %q, want %s or %s", policy.By, config.PrewarmRecent, config.PrewarmPriority)
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	st, err := state.Load(s.config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	type pick struct {
+		worktree git.Worktree
+		rank     int // Where its priority comes among the options, 0 unless picked by priority
+		used     int // Where it comes among the recently used, after them if it isn't one
+		last     time.Time
+	}
+	var options []string
+	if policy.By == config.PrewarmPriority {
+		options = s.PriorityOptions()
+	}
+	var picks []pick
+	for _, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		todo := s.config.GetTodoForWorktree(name)
+		item := itemFor(items, name, todo)
+		if item != nil && item.Status == "Done" || todo != nil && todo.Status == config.TodoStatusDone {
+			continue
+		}
+		p := pick{worktree: wt, used: slices.Index(st.Recent, name), last: git.GetActivity(wt.Path, "").LastActivity()}
+		if p.used < 0 {
+			p.used = len(st.Recent)
+		}
+		if options != nil {
+			priority := ""
+			if item != nil && item.Priority != "" {
+				priority = item.Priority
+			} else if todo != nil {
+				priority = todo.Priority
+			}
+			if p.rank = slices.Index(options, priority); p.rank < 0 {
+				p.rank = len(options)
+			}
+		}
+		picks = append(picks, p)
+	}
+	slices.SortStableFunc(picks, func(a, b pick) int {
+		return cmp.Or(cmp.Compare(a.rank, b.rank), cmp.Compare(a.used, b.used), b.last.Compare(a.last))
+	})
+
+	picked := make([]git.Worktree, 0, policy.Sessions())
+	for _, p := range picks[:min(len(picks), policy.Sessions())] {
+		picked = append(picked, p.worktree)
+	}
+	return picked, nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// DefaultPriorities are the priorities todos move between, most urgent first, unless the
+// project board has a Priority field of its own
+var DefaultPriorities = []string{"High", "Medium", "Low"}
+
+// PriorityOptions lists the priorities items can be given, most urgent first: the options of
+// the project board's Priority field when it has one, otherwise DefaultPriorities. The
+// board is asked once.
+func (s *Service) PriorityOptions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.priorities != nil {
+		return s.priorities
+	}
+	s.priorities = DefaultPriorities
+	if backend := s.config.StorageBackend; backend.UsesProject() {
+		options, err := github.ListFieldOptions(backend.Owner, backend.Repo, backend.ProjectNumber, "Priority")
+		if err == nil && len(options) > 0 {
+			s.priorities, s.priorityField = options, true
+		}
+	}
+	return s.priorities
+}
+
+// ShiftPriority moves a priority by steps among options, which are most urgent first:
+// raising it for positive steps, lowering it otherwise. Lowering the least urgent clears
+// it, and raising one that isn't set starts from the least urgent.
+func ShiftPriority(options []string, current string, steps int) string {
+	rank := slices.Index(options, current)
+	if rank < 0 {
+		rank = len(options)
+	}
+	rank = min(max(rank-steps, 0), len(options))
+	if rank == len(options) {
+		return ""
+	}
+	return options[rank]
+}
+
+// SetPriority gives a worktree's todo, and its item when the board has a Priority field,
+// a priority, or clears it when priority is ""
+func (s *Service) SetPriority(target Target, priority string) error {
+	onBoard := false
+	if target.Item != nil {
+		s.PriorityOptions()
+		s.mu.Lock()
+		onBoard = s.priorityField
+		s.mu.Unlock()
+	}
+	if target.Todo == nil && !onBoard {
+		return fmt.Errorf("priorities are kept on todos, and this item has no worktree")
+	}
+
+	if onBoard {
+		backend := s.config.StorageBackend
+		if err := github.UpdateProjectItemField(backend.Owner, backend.Repo, backend.ProjectNumber, target.Item.ID, "Priority", priority); err != nil {
+			return err
+		}
+		target.Item.Priority = priority
+	}
+	if target.Todo != nil {
+		err := s.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(target.name())
+			if todo == nil {
+				return fmt.Errorf("%s has no todo", target.name())
+			}
+			todo.Priority = priority
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+	s.emit(Event{Kind: PriorityChanged, Worktree: target.name(), Item: target.Item})
+	return nil
+}
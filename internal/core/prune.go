@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// PruneKind is what a prune candidate removes
+type PruneKind string
+
+const (
+	PruneWorktree PruneKind = "worktree" // A worktree, with its branch, session and todo
+	PruneSession  PruneKind = "session"  // A tmux session whose worktree is gone
+	PruneTodo     PruneKind = "todo"     // A todo whose worktree is gone
+)
+
+// PruneCandidate is something lfg prune offers to clean up
+type PruneCandidate struct {
+	Kind    PruneKind
+	Name    string   // The worktree, session or todo's worktree
+	Reasons []string // Why it's offered, e.g. "branch merged"
+	Dirty   int      // A worktree's uncommitted files, lost if it's pruned
+	Target  Target   // The worktree to delete, for PruneWorktree
+}
+
+// PruneCandidates finds worktrees whose branch is merged, whose item or todo is Done, or
+// that haven't been worked on for idle (never, when it's 0), along with tmux sessions and
+// todos left behind by worktrees that are gone. items is the backend's items, to tell which
+// are Done. The main worktree is never offered.
+func (s *Service) PruneCandidates(items []github.ProjectItem, idle time.Duration) ([]PruneCandidate, error) {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []PruneCandidate
+	names := make(map[string]bool, len(worktrees))
+	for i, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		names[name] = true
+		if i == 0 {
+			continue
+		}
+
+		branch := strings.TrimPrefix(wt.Branch, "refs/heads/")
+		todo := s.config.GetTodoForWorktree(name)
+		item := itemFor(items, name, todo)
+		var reasons []string
+		if merged, _ := git.IsBranchMerged(branch); merged && branch != "" {
+			reasons = append(reasons, "branch merged")
+		}
+		if item != nil && item.Status == "Done" {
+			reasons = append(reasons, "item is Done")
+		} else if todo != nil && todo.Status == config.TodoStatusDone {
+			reasons = append(reasons, "todo is done")
+		}
+		if last := git.GetActivity(wt.Path, "").LastActivity(); idle > 0 && !last.IsZero() && time.Since(last) >= idle {
+			reasons = append(reasons, "last worked on "+humanize.Ago(last))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		dirty, _ := git.DirtyFileCount(wt.Path)
+		candidates = append(candidates, PruneCandidate{
+			Kind:    PruneWorktree,
+			Name:    name,
+			Reasons: reasons,
+			Dirty:   dirty,
+			Target:  Target{Worktree: name, Branch: branch, Todo: todo, Item: item},
+		})
+	}
+
+	// Sessions are lfg's when they're named like its worktrees
+	sessions := make(map[string]bool, len(names))
+	for name := range names {
+		sessions[tmux.SanitizeSessionName(name)] = true
+	}
+	prefix := tmux.SanitizeSessionName(s.config.Name + "-")
+	if infos, err := tmux.ListSessionInfo(); err == nil {
+		for _, info := range infos {
+			if strings.HasPrefix(info.Name, prefix) && !sessions[info.Name] {
+				candidates = append(candidates, PruneCandidate{Kind: PruneSession, Name: info.Name, Reasons: []string{"its worktree is gone"}})
+			}
+		}
+	}
+
+	for _, todo := range s.config.Todos {
+		if todo.Worktree != "" && !names[todo.Worktree] {
+			candidates = append(candidates, PruneCandidate{Kind: PruneTodo, Name: todo.Worktree, Reasons: []string{"its worktree is gone"}})
+		}
+	}
+	return candidates, nil
+}
+
+// Prune removes a candidate PruneCandidates found, returning the parts of its cleanup that
+// failed without stopping it
+func (s *Service) Prune(candidate PruneCandidate) ([]string, error) {
+	switch candidate.Kind {
+	case PruneWorktree:
+		deleted, err := s.DeleteWorktree(candidate.Target)
+		return deleted.Warnings, err
+	case PruneSession:
+		return nil, tmux.KillSession(candidate.Name)
+	case PruneTodo:
+		err := s.config.Update(func(cfg *config.Config) error {
+			cfg.RemoveTodo(candidate.Name)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save config: %w", err)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown prune candidate %q", candidate.Kind)
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// defaultPullRequestTemplate describes pull requests when the config has no template of
+// its own
+const defaultPullRequestTemplate = `{{with .Issue}}Closes #{{.Number}}
+
+{{end}}## Summary
+
+{{with .Summary}}{{.}}{{else}}{{.Title}}{{end}}
+{{with .Notes}}
+## Notes
+
+{{.}}
+{{end}}{{with .Diffstat}}
+## Changes
+
+` + "```" + `
+{{.}}
+` + "```" + `
+{{end}}{{with .Issue}}{{if .Body}}
+<details>
+<summary>#{{.Number}} {{.Title}}</summary>
+
+{{.Body}}
+</details>
+{{end}}{{end}}`
+
+// PullRequestDraft is a pull request about to be opened for a worktree's branch
+type PullRequestDraft struct {
+	Worktree string
+	Owner    string
+	Repo     string
+	Branch   string
+	Base     string
+	Title    string
+	Body     string
+	Draft    bool
+}
+
+// pullRequestData is what a pull request template is given
+type pullRequestData struct {
+	Project  string
+	Worktree string
+	Branch   string
+	Base     string
+	Title    string            // The todo's description
+	Notes    string            // The todo's notes
+	Issue    *pullRequestIssue // The issue the todo is linked to, nil if it has none
+	Diffstat string            // What the branch changes since base, as git diff --stat prints it
+	Summary  string            // The agent's last reply in the worktree
+}
+
+// pullRequestIssue is the issue a pull request is for
+type pullRequestIssue struct {
+	Number int
+	Title  string
+	Body   string
+	URL    string
+}
+
+// DraftPullRequest fills in the title and description of a pull request for a worktree's
+// branch into base, the main worktree's branch unless given, from the config's template or
+// lfg's own. summary is the agent's summary of its work there. Branches that already have
+// an open pull request are refused.
+func (s *Service) DraftPullRequest(worktree, base, summary string) (*PullRequestDraft, error) {
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := git.GetBranch(path)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		worktrees, err := git.ListWorktrees()
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimPrefix(worktrees[0].Branch, "refs/heads/")
+	}
+	if branch == base {
+		return nil, fmt.Errorf("%s is on %s, the branch its pull request would go into", worktree, base)
+	}
+	owner, repo, err := s.pullRequestRepo()
+	if err != nil {
+		return nil, err
+	}
+	if pr, err := github.FindPullRequestForBranch(owner, repo, branch); err != nil {
+		return nil, err
+	} else if pr != nil {
+		return nil, fmt.Errorf("%s already has pull request #%d: %s", branch, pr.Number, pr.URL)
+	}
+
+	data := pullRequestData{Project: s.config.Name, Worktree: worktree, Branch: branch, Base: base, Title: worktree, Summary: summary}
+	if todo := s.config.GetTodoForWorktree(worktree); todo != nil {
+		data.Title = todo.Description
+		data.Notes = strings.TrimSpace(todo.Notes)
+		if number, err := github.IssueNumberFromURL(todo.GitHubURL); err == nil && !strings.Contains(todo.GitHubURL, "/pull/") {
+			// The todo keeps the issue's body from when it was created, for when GitHub can't be reached
+			data.Issue = &pullRequestIssue{Number: number, Title: todo.Description, Body: todo.GitHubBody, URL: todo.GitHubURL}
+			if issue, err := github.GetIssue(owner, repo, number); err == nil {
+				data.Issue.Title, data.Issue.Body = issue.Title, issue.Body
+			}
+		}
+	}
+	if data.Diffstat, err = git.DiffStatText(path, base); err != nil {
+		return nil, err
+	}
+
+	body, err := s.renderPullRequest(data)
+	if err != nil {
+		return nil, err
+	}
+	draft := &PullRequestDraft{Worktree: worktree, Owner: owner, Repo: repo, Branch: branch, Base: base, Title: data.Title, Body: body}
+	draft.Draft = s.config.PullRequest != nil && s.config.PullRequest.Draft
+	return draft, nil
+}
+
+// renderPullRequest fills in the config's pull request template, or lfg's own
+func (s *Service) renderPullRequest(data pullRequestData) (string, error) {
+	tmpl := template.New("pull request").Option("missingkey=error")
+	var err error
+	if settings := s.config.PullRequest; settings != nil && settings.Template != "" {
+		tmpl, err = template.New(filepath.Base(settings.Template)).Option("missingkey=error").ParseFiles(filepath.Join(s.config.Root(), settings.Template))
+	} else {
+		tmpl, err = tmpl.Parse(defaultPullRequestTemplate)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read pull request template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to fill in pull request template: %w", err)
+	}
+	return strings.TrimSpace(body.String()) + "\n", nil
+}
+
+// OpenPullRequest pushes the draft's branch and opens its pull request, returning its URL
+func (s *Service) OpenPullRequest(draft PullRequestDraft) (string, error) {
+	if strings.TrimSpace(draft.Title) == "" {
+		return "", fmt.Errorf("the pull request has no title")
+	}
+	path, err := git.GetWorktreePath(draft.Worktree)
+	if err != nil {
+		return "", err
+	}
+	if err := git.Push(path, draft.Branch); err != nil {
+		return "", err
+	}
+	url, err := github.CreatePullRequest(draft.Owner, draft.Repo, draft.Branch, draft.Base, draft.Title, draft.Body, draft.Draft)
+	if err != nil {
+		return "", err
+	}
+	s.emit(Event{Kind: PullRequestOpened, Worktree: draft.Worktree, Detail: url})
+	return url, nil
+}
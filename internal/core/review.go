@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// reviewName is the worktree, and branch, a pull request is checked out in to review
+func (s *Service) reviewName(number int) string {
+	return fmt.Sprintf("%s-review-%d", s.config.Name, number)
+}
+
+// Review checks a pull request out in a worktree of its own to review it in, returning the
+// worktree's name. Its todo holds the pull request's description, and the viewer shows the
+// conversation and review comments alongside it. A pull request already being reviewed
+// keeps its worktree.
+func (s *Service) Review(ctx context.Context, number int) (name string, warnings []string, err error) {
+	owner, repo, err := s.pullRequestRepo()
+	if err != nil {
+		return "", nil, err
+	}
+	pr, err := github.GetPullRequest(owner, repo, number)
+	if err != nil {
+		return "", nil, err
+	}
+	if pr.State != "OPEN" {
+		return "", nil, fmt.Errorf("pull request #%d is %s", number, strings.ToLower(pr.State))
+	}
+
+	name = s.reviewName(number)
+	if _, err := git.GetWorktreePath(name); err == nil {
+		return name, nil, nil
+	}
+	// The pull request goes on a branch of lfg's own, leaving any local copy of the author's
+	// branch alone
+	if err := git.FetchPullRequest(ctx, number, name); err != nil {
+		return "", nil, err
+	}
+	warnings, err = s.CreateWorktree(ctx, CreateRequest{Name: name, Branch: name, Checkout: true})
+	if err != nil {
+		return "", warnings, err
+	}
+
+	_, err = state.Update(s.config.GetConfigPath(), func(st *state.State) error {
+		st.SetReview(name, state.Review{Owner: owner, Repo: repo, Number: number})
+		return nil
+	})
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	err = s.config.Update(func(cfg *config.Config) error {
+		cfg.AddTodo(fmt.Sprintf("Review #%d: %s", number, pr.Title), name)
+		if todo := cfg.GetTodoForWorktree(name); todo != nil {
+			todo.GitHubBody = pr.Body
+			todo.GitHubURL = pr.URL
+		}
+		return nil
+	})
+	s.emit(Event{Kind: WorktreeCreated, Worktree: name})
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to save config: %v", err))
+	}
+	return name, warnings, nil
+}
+
+// PruneReviews deletes the review worktrees whose pull requests have been merged or
+// closed, returning their names. Worktrees with uncommitted changes, or commits the pull
+// request doesn't have, are kept, so nothing written while reviewing is lost.
+func (s *Service) PruneReviews() (pruned []string, err error) {
+	st, err := state.Load(s.config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for name, review := range st.Reviews {
+		pr, err := github.GetPullRequest(review.Owner, review.Repo, review.Number)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if pr.State == "OPEN" {
+			continue
+		}
+		if path, err := git.GetWorktreePath(name); err == nil {
+			if dirty, err := git.DirtyFileCount(path); err != nil || dirty > 0 {
+				continue
+			}
+		}
+		// The branch is lfg's own, with no upstream, so it's deleted even when unmerged
+		if ahead, err := git.CommitsNotIn(context.Background(), name, pr.HeadRefOid); err != nil || ahead > 0 {
+			continue
+		}
+
+		deleted, err := s.DeleteWorktree(Target{Worktree: name, Todo: s.config.GetTodoForWorktree(name)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", name, err))
+			continue
+		}
+		for _, warning := range deleted.Warnings {
+			errs = append(errs, fmt.Errorf("%s: %s", name, warning))
+		}
+		pruned = append(pruned, name)
+	}
+	return pruned, errors.Join(errs...)
+}
+
+// pullRequestRepo is the repository pull requests are on: the backend's for GitHub
+// backends, otherwise the one gh finds for the working directory
+func (s *Service) pullRequestRepo() (owner, repo string, err error) {
+	if backend := s.config.StorageBackend; backend.IsGitHub() {
+		return backend.Owner, backend.Repo, nil
+	}
+	info, err := github.GetRepoInfo()
+	if err != nil {
+		return "", "", err
+	}
+	return info.Owner, info.Name, nil
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// NormalizeList trims a list's values, such as tags, dropping empty and repeated ones and
+// keeping the rest in order
+func NormalizeList(values []string) []string {
+	var normalized []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.Contains(normalized, value) {
+			normalized = append(normalized, value)
+		}
+	}
+	return normalized
+}
+
+// Tags are the target's tags: its issue's labels when it's linked to one, otherwise its
+// todo's tags
+func (s *Service) Tags(target Target) []string {
+	if s.issueNumber(target) > 0 && target.Item != nil {
+		return target.Item.Labels
+	}
+	if target.Todo != nil {
+		return target.Todo.Tags
+	}
+	return nil
+}
+
+// SetTags replaces the target's tags, adding and removing its issue's labels to match when
+// it's linked to one
+func (s *Service) SetTags(target Target, tags []string) error {
+	tags = NormalizeList(tags)
+	number := s.issueNumber(target)
+	if target.Todo == nil && number == 0 {
+		return fmt.Errorf("tags are kept on todos, and this item has no worktree")
+	}
+
+	if number > 0 {
+		current := s.Tags(target)
+		var add, remove []string
+		for _, tag := range tags {
+			if !slices.Contains(current, tag) {
+				add = append(add, tag)
+			}
+		}
+		for _, tag := range current {
+			if !slices.Contains(tags, tag) {
+				remove = append(remove, tag)
+			}
+		}
+		backend := s.config.StorageBackend
+		if err := github.EditIssueLabels(backend.Owner, backend.Repo, number, add, remove); err != nil {
+			return err
+		}
+		if target.Item != nil {
+			target.Item.Labels = tags
+		}
+	}
+	if target.Todo != nil {
+		err := s.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(target.name())
+			if todo == nil {
+				return fmt.Errorf("%s has no todo", target.name())
+			}
+			todo.Tags = tags
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+	s.emit(Event{Kind: TagsChanged, Worktree: target.name(), Item: target.Item})
+	return nil
+}
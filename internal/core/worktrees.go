@@ -0,0 +1,360 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/bootstrap"
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/container"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// CreateRequest is a worktree to create
+type CreateRequest struct {
+	Name     string
+	Branch   string // Defaults to Name
+	Base     string // Defaults to HEAD
+	Checkout bool   // The branch already exists, check it out rather than creating it
+	// The entry in the config's environments the worktree gets, "" for none
+	Environment string
+	// The item the worktree is for, if any, moved to In Progress and linked once it exists
+	Item *github.ProjectItem
+}
+
+// CreateWorktree creates a worktree, with its .envrc when one is configured, stopping git
+// when ctx is cancelled, and starts installing its dependencies when bootstrap is on. It
+// only touches git and the backend, so it's safe to run in the background; TrackWorktree
+// records it once it's made. Problems with the .envrc, bootstrap or item don't undo the
+// worktree, they come back as warnings.
+func (s *Service) CreateWorktree(ctx context.Context, request CreateRequest) (warnings []string, err error) {
+	if request.Checkout {
+		err = git.CheckoutWorktreeContext(ctx, request.Name, request.Branch)
+	} else {
+		err = git.CreateWorktreeContext(ctx, request.Name, request.Branch, request.Base)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.Envrc != nil {
+		branch := request.Branch
+		if branch == "" {
+			branch = request.Name
+		}
+		if err := s.writeEnvrc(ctx, request.Name, branch); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	if s.config.Bootstrap != "" {
+		env, err := s.config.EnvironmentVars(request.Environment)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		}
+		if err := bootstrap.Start(s.config, request.Name, env); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	if item := request.Item; item != nil {
+		warnings = append(warnings, s.StartItem(item, request.Name)...)
+	}
+	return warnings, nil
+}
+
+// maxNameSuffix bounds the numbered names Unclash tries
+const maxNameSuffix = 99
+
+// Collision reports what a request's worktree or new branch would clash with: a worktree
+// of the same name, a directory where it would go, a branch of the same name or a tmux
+// session already using its name. It's "" when nothing is in the way.
+func (s *Service) Collision(request CreateRequest) (string, error) {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if git.GetWorktreeName(wt.Path) == request.Name {
+			return fmt.Sprintf("a worktree named %s already exists", request.Name), nil
+		}
+	}
+	path, err := git.NewWorktreePath(request.Name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Sprintf("%s already exists", path), nil
+	}
+	if branch := request.newBranch(); branch != "" && git.BranchExists(branch) {
+		return fmt.Sprintf("a branch named %s already exists", branch), nil
+	}
+	if session := tmux.SanitizeSessionName(request.Name); tmux.SessionExists(session) {
+		return fmt.Sprintf("a tmux session named %s is already running", session), nil
+	}
+	return "", nil
+}
+
+// Unclash renames a request whose name collides to the first of name-2, name-3 and so on
+// that's free. A new branch takes the same suffix when it's named after the worktree, with
+// or without a prefix like feat/, or collides itself.
+func (s *Service) Unclash(request CreateRequest) (CreateRequest, error) {
+	afterWorktree := strings.HasSuffix(request.Branch, request.Name)
+	suffixBranch := !request.Checkout && request.Branch != "" && (afterWorktree || git.BranchExists(request.Branch))
+	for n := 2; n <= maxNameSuffix; n++ {
+		renamed := request
+		renamed.Name = fmt.Sprintf("%s-%d", request.Name, n)
+		if suffixBranch {
+			renamed.Branch = fmt.Sprintf("%s-%d", request.Branch, n)
+		}
+		collision, err := s.Collision(renamed)
+		if err != nil {
+			return request, err
+		}
+		if collision == "" {
+			return renamed, nil
+		}
+	}
+	return request, fmt.Errorf("%s-2 to %s-%d are all taken", request.Name, request.Name, maxNameSuffix)
+}
+
+// newBranch is the branch a request creates, "" when it checks out an existing one
+func (r CreateRequest) newBranch() string {
+	switch {
+	case r.Checkout:
+		return ""
+	case r.Branch == "":
+		return r.Name
+	}
+	return r.Branch
+}
+
+// StartItem links an item to the worktree it's being worked on in and moves it to In
+// Progress, or where auto_status says, unless its todo is blocked, returning what went
+// wrong as warnings
+func (s *Service) StartItem(item *github.ProjectItem, worktree string) []string {
+	var warnings []string
+	if err := s.LinkWorktree(item, worktree); err != nil {
+		warnings = append(warnings, fmt.Sprintf("failed to link the worktree to its item: %v", err))
+	}
+	if to, ok := s.AutoStatus(Target{Todo: s.config.GetTodoForWorktree(worktree)}, item.Status, nil); ok {
+		if err := s.setItemStatus(item, to, worktree); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to update item status: %v", err))
+		}
+	}
+	return warnings
+}
+
+// TrackWorktree gives a created worktree its todo, copying the item's body and URL when it's
+// for one, and tells subscribers it's ready. The worktree is announced even if the config
+// can't be saved, since it exists either way.
+func (s *Service) TrackWorktree(name, description, layout, environment string, item *github.ProjectItem) error {
+	err := s.config.Update(func(cfg *config.Config) error {
+		cfg.AddTodo(description, name)
+		if todo := cfg.GetTodoForWorktree(name); todo != nil {
+			todo.Layout = layout
+			todo.Environment = environment
+			if item != nil {
+				todo.GitHubBody = item.Content.Body
+				todo.GitHubURL = item.Content.URL
+			}
+		}
+		return nil
+	})
+	s.emit(Event{Kind: WorktreeCreated, Worktree: name, Item: item})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// Target is a worktree, or an item without one, to act on
+type Target struct {
+	Worktree string              // The worktree's name, "" if there isn't one checked out
+	Branch   string              // The branch checked out in it, used to check it's merged
+	Todo     *config.Todo        // Its todo, if any
+	Item     *github.ProjectItem // The backend's item for it, if any
+}
+
+// name is the worktree the target is, or was, checked out in
+func (t Target) name() string {
+	if t.Worktree != "" {
+		return t.Worktree
+	}
+	if t.Todo != nil {
+		return t.Todo.Worktree
+	}
+	return ""
+}
+
+// Deleted is what deleting a worktree did
+type Deleted struct {
+	Current  bool     // It was the worktree lfg is running in
+	Session  string   // The tmux session killed with it, if there was one
+	Warnings []string // Parts of the cleanup that failed without stopping the delete
+}
+
+// DeleteWorktree removes a worktree with its branch, tmux session and todo. Its item is
+// moved to Done if the branch was merged; an item without a worktree is just moved to Done.
+func (s *Service) DeleteWorktree(target Target) (Deleted, error) {
+	var deleted Deleted
+	remote := s.config.StorageBackend.Remote()
+	name := target.name()
+	if name == "" {
+		if target.Item != nil && remote && s.CanMoveTo("Done") {
+			if err := s.SetItemStatus(target.Item, "Done"); err != nil {
+				deleted.Warnings = append(deleted.Warnings, fmt.Sprintf("failed to update item status to Done: %v", err))
+			}
+		}
+		return deleted, nil
+	}
+
+	// Only items need the check, so local-only repos without a remote to check against
+	// don't warn on every delete
+	if target.Item != nil && remote {
+		branch := target.Branch
+		if branch == "" {
+			branch = name
+		}
+		isMerged, err := git.IsBranchMerged(branch)
+		if err != nil {
+			deleted.Warnings = append(deleted.Warnings, fmt.Sprintf("failed to check if branch is merged: %v", err))
+		}
+		if isMerged && s.CanMoveTo("Done") {
+			if err := s.setItemStatus(target.Item, "Done", name); err != nil {
+				deleted.Warnings = append(deleted.Warnings, fmt.Sprintf("failed to update item status to Done: %v", err))
+			}
+		}
+	}
+
+	current, err := git.GetCurrentWorktree()
+	deleted.Current = err == nil && current == name
+
+	if session := tmux.SanitizeSessionName(name); tmux.SessionExists(session) {
+		if err := s.killSession(name); err != nil {
+			deleted.Warnings = append(deleted.Warnings, fmt.Sprintf("failed to kill tmux session: %v", err))
+		} else {
+			deleted.Session = session
+		}
+	}
+
+	// The worktree's containers go with it, before its compose files do
+	if s.config.Container != nil {
+		path, err := git.GetWorktreePath(name)
+		if err == nil {
+			err = container.Down(context.Background(), s.config.Container, name, path)
+		}
+		if err != nil {
+			deleted.Warnings = append(deleted.Warnings, err.Error())
+		}
+	}
+
+	if err := git.DeleteWorktree(name, true); err != nil {
+		return deleted, err
+	}
+
+	// It's no longer a recent worktree to jump back to
+	_, err = state.Update(s.config.GetConfigPath(), func(st *state.State) error {
+		st.ForgetWorktree(name)
+		return nil
+	})
+	if err != nil {
+		deleted.Warnings = append(deleted.Warnings, fmt.Sprintf("failed to save state: %v", err))
+	}
+	if err := state.RemoveScratchpad(s.config.GetConfigPath(), name); err != nil {
+		deleted.Warnings = append(deleted.Warnings, err.Error())
+	}
+
+	// Remove the todo entirely rather than marking it done
+	err = s.config.Update(func(cfg *config.Config) error {
+		cfg.RemoveTodo(name)
+		return nil
+	})
+	deletedEvent := Event{Kind: WorktreeDeleted, Worktree: name, Item: target.Item}
+	if target.Todo != nil {
+		deletedEvent.Title = target.Todo.Description
+	}
+	s.emit(deletedEvent)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to save config: %w", err)
+	}
+	return deleted, nil
+}
+
+// KillSession ends a worktree's tmux session, first saving its uncommitted work when the
+// config's wip asks for it
+func (s *Service) KillSession(worktree string) error {
+	// Killing the session is where work is switched away from, so it's saved first
+	if s.config.WIP != "" {
+		if _, err := s.SaveWIP(worktree); err != nil {
+			return fmt.Errorf("%w; the session was left running", err)
+		}
+	}
+	return s.killSession(worktree)
+}
+
+// SaveWIP saves a worktree's uncommitted work as the config's wip says, in a commit unless
+// it says stash. It reports whether there was anything to save.
+func (s *Service) SaveWIP(worktree string) (bool, error) {
+	if s.config.WIP != "" && s.config.WIP != config.WIPCommit && s.config.WIP != config.WIPStash {
+		return false, fmt.Errorf("unknown wip %q, want %s or %s", s.config.WIP, config.WIPCommit, config.WIPStash)
+	}
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return false, err
+	}
+	return git.SaveWIP(path, s.config.WIP == config.WIPStash)
+}
+
+// killSession kills a worktree's tmux session as it is
+func (s *Service) killSession(worktree string) error {
+	if err := tmux.KillSession(tmux.SanitizeSessionName(worktree)); err != nil {
+		return err
+	}
+	s.emit(Event{Kind: SessionKilled, Worktree: worktree})
+	return nil
+}
+
+// SetStatus moves a worktree's todo and item to status. Todos are only pending or done, so
+// any status other than Done reopens one.
+func (s *Service) SetStatus(target Target, status string) error {
+	var from string
+	if target.Todo != nil {
+		err := s.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(target.name())
+			if todo == nil {
+				return fmt.Errorf("%s has no todo", target.name())
+			}
+			from = string(todo.Status)
+			if isDone(status) {
+				todo.SetStatus(config.TodoStatusDone)
+			} else {
+				todo.SetStatus(config.TodoStatusPending)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	if target.Item != nil && s.config.StorageBackend.Remote() {
+		if err := s.setItemStatus(target.Item, status, target.name()); err != nil {
+			return err
+		}
+	} else if target.Todo != nil {
+		s.emit(Event{Kind: StatusChanged, Worktree: target.name(), Status: status, From: from})
+	}
+	if isDone(status) {
+		return s.unblock(target)
+	}
+	return nil
+}
+
+// isDone reports whether status is Done, in the board's terms or the todos'
+func isDone(status string) bool {
+	return status == "Done" || status == string(config.TodoStatusDone)
+}
@@ -0,0 +1,289 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+const (
+	// localInterval is how often worktrees and tmux sessions are looked up again
+	localInterval = 5 * time.Second
+	// backendInterval is how often items are fetched from the backend again
+	backendInterval = time.Minute
+	// dialTimeout bounds connecting to the daemon, so a stuck one doesn't slow lfg down
+	dialTimeout = 200 * time.Millisecond
+	// requestTimeout bounds a whole request once connected
+	requestTimeout = 2 * time.Second
+)
+
+// State is what the daemon keeps warm for the TUI
+type State struct {
+	Backend     *config.StorageBackend      `json:"backend,omitempty"` // The backend Items came from
+	Worktrees   []git.Worktree              `json:"worktrees"`
+	Sessions    map[string]tmux.SessionInfo `json:"sessions,omitempty"`
+	Items       []github.ProjectItem        `json:"items,omitempty"`
+	Statuses    []string                    `json:"statuses,omitempty"`
+	ItemsError  string                      `json:"items_error,omitempty"` // Why the last backend fetch failed
+	RefreshedAt time.Time                   `json:"refreshed_at"`          // When Items were last fetched
+	StartedAt   time.Time                   `json:"started_at"`
+	PID         int                         `json:"pid"`
+}
+
+// HasItems reports whether Items are the backend's as of the last fetch, rather than
+// missing because it hasn't finished or failed
+func (s *State) HasItems() bool {
+	return s != nil && !s.RefreshedAt.IsZero() && s.ItemsError == ""
+}
+
+// request is one line a client sends. Op is "state", "refresh" or "stop".
+type request struct {
+	Op string `json:"op"`
+}
+
+// response is the line the daemon answers with
+type response struct {
+	State *State `json:"state,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Sources are where the daemon looks things up
+type Sources struct {
+	Worktrees func(ctx context.Context) ([]git.Worktree, error)
+	Sessions  func(ctx context.Context) ([]tmux.SessionInfo, error)
+	Items     func(cfg *config.Config) ([]github.ProjectItem, []string, error) // Every item and the statuses they move between
+	// Watch, if set, is run after each backend fetch with the items fetched, for other
+	// lookups that go to the backend as often, like CI on the worktrees' pull requests
+	Watch func(cfg *config.Config, worktrees []git.Worktree, items []github.ProjectItem)
+}
+
+// SocketPath returns the socket the daemon for a config listens on
+func SocketPath(configPath string) string {
+	return filepath.Join(state.Dir(configPath), "daemon.sock")
+}
+
+// server is a running daemon
+type server struct {
+	configPath string
+	sources    Sources
+	refresh    chan struct{} // Asks for everything to be looked up again now
+	stop       context.CancelFunc
+
+	mu    sync.Mutex
+	state State
+}
+
+// Serve runs the daemon for the config at configPath until ctx is done or a client asks
+// it to stop. It refuses to start while another daemon is answering on the socket.
+func Serve(ctx context.Context, configPath string, sources Sources) error {
+	socket := SocketPath(configPath)
+	if _, err := Query(configPath); err == nil {
+		return fmt.Errorf("the daemon is already running on %s", socket)
+	}
+	// Nothing answered, so a socket left behind is from a daemon that didn't exit cleanly
+	os.Remove(socket)
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s := &server{
+		configPath: configPath,
+		sources:    sources,
+		refresh:    make(chan struct{}, 1),
+		stop:       cancel,
+		state:      State{StartedAt: time.Now(), PID: os.Getpid()},
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	go s.watch(ctx)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept a connection: %w", err)
+		}
+		go s.handle(conn)
+	}
+}
+
+// watch keeps the state warm: worktrees and sessions every few seconds, the backend's
+// items every minute, and everything at once when a client asks
+func (s *server) watch(ctx context.Context) {
+	s.refreshLocal(ctx)
+	s.refreshItems()
+
+	local := time.NewTicker(localInterval)
+	defer local.Stop()
+	backend := time.NewTicker(backendInterval)
+	defer backend.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-local.C:
+			s.refreshLocal(ctx)
+		case <-backend.C:
+			s.refreshItems()
+		case <-s.refresh:
+			s.refreshLocal(ctx)
+			s.refreshItems()
+		}
+	}
+}
+
+// refreshLocal looks up worktrees and tmux sessions, keeping the last answer if one fails
+func (s *server) refreshLocal(ctx context.Context) {
+	worktrees, worktreesErr := s.sources.Worktrees(ctx)
+	sessions, sessionsErr := s.sources.Sessions(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if worktreesErr == nil {
+		s.state.Worktrees = worktrees
+	}
+	if sessionsErr == nil {
+		s.state.Sessions = make(map[string]tmux.SessionInfo, len(sessions))
+		for _, session := range sessions {
+			s.state.Sessions[session.Name] = session
+		}
+	}
+}
+
+// refreshItems fetches the backend's items, reloading the config so a switch of backend
+// or project is picked up
+func (s *server) refreshItems() {
+	cfg, err := config.LoadFromPath(s.configPath)
+	if err != nil {
+		s.mu.Lock()
+		s.state.ItemsError = err.Error()
+		s.mu.Unlock()
+		return
+	}
+
+	var items []github.ProjectItem
+	var statuses []string
+	if cfg.StorageBackend.Remote() {
+		items, statuses, err = s.sources.Items(cfg)
+	}
+
+	s.mu.Lock()
+	s.state.Backend = cfg.StorageBackend
+	if err != nil {
+		s.state.ItemsError = err.Error()
+	} else {
+		s.state.Items = items
+		s.state.Statuses = statuses
+		s.state.ItemsError = ""
+		s.state.RefreshedAt = time.Now()
+	}
+	worktrees := s.state.Worktrees
+	s.mu.Unlock()
+
+	if s.sources.Watch != nil {
+		s.sources.Watch(cfg, worktrees, items)
+	}
+}
+
+// handle answers one request
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	var resp response
+	switch req.Op {
+	case "state":
+		s.mu.Lock()
+		current := s.state
+		s.mu.Unlock()
+		resp.State = &current
+	case "refresh":
+		select {
+		case s.refresh <- struct{}{}:
+		default:
+			// A refresh is already on its way
+		}
+	case "stop":
+		defer s.stop()
+	default:
+		resp.Error = fmt.Sprintf("unknown op %q", req.Op)
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// Query returns the running daemon's state, or an error if no daemon is answering
+func Query(configPath string) (*State, error) {
+	resp, err := call(configPath, "state")
+	if err != nil {
+		return nil, err
+	}
+	if resp.State == nil {
+		return nil, errors.New("the daemon didn't return its state")
+	}
+	return resp.State, nil
+}
+
+// Refresh asks the running daemon to look everything up again now
+func Refresh(configPath string) error {
+	_, err := call(configPath, "refresh")
+	return err
+}
+
+// Stop asks the running daemon to exit
+func Stop(configPath string) error {
+	_, err := call(configPath, "stop")
+	return err
+}
+
+// call sends the daemon one request and reads its response
+func call(configPath, op string) (*response, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(configPath), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("the daemon isn't running: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(request{Op: op}); err != nil {
+		return nil, fmt.Errorf("failed to send %s to the daemon: %w", op, err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read the daemon's response to %s: %w", op, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("daemon %s failed: %s", op, resp.Error)
+	}
+	return &resp, nil
+}
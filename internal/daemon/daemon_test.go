@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// waitFor polls the daemon until check passes on its state
+func waitFor(t *testing.T, configPath string, check func(*State) bool) *State {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if st, err := Query(configPath); err == nil && check(st) {
+			return st
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for the daemon")
+	return nil
+}
+
+func TestServe(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := os.WriteFile(configPath, []byte("name: lfg\nstorage_backend:\n  type: github-issues\n  owner: owner\n  repo: repo\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	worktrees := []git.Worktree{{Path: "/src/lfg", Branch: "refs/heads/main"}}
+	var fetches, watches atomic.Int32
+	sources := Sources{
+		Worktrees: func(context.Context) ([]git.Worktree, error) { return worktrees, nil },
+		Sessions: func(context.Context) ([]tmux.SessionInfo, error) {
+			return []tmux.SessionInfo{{Name: "lfg", Attached: 1}}, nil
+		},
+		Items: func(cfg *config.Config) ([]github.ProjectItem, []string, error) {
+			fetches.Add(1)
+			return []github.ProjectItem{{ID: "I_1", Title: "Login", Status: "Todo"}}, github.IssueStatuses, nil
+		},
+		Watch: func(cfg *config.Config, watched []git.Worktree, items []github.ProjectItem) {
+			if reflect.DeepEqual(watched, worktrees) {
+				watches.Add(1)
+			}
+		},
+	}
+
+	if _, err := Query(configPath); err == nil {
+		t.Fatal("Query() succeeded before the daemon started")
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- Serve(context.Background(), configPath, sources) }()
+
+	st := waitFor(t, configPath, (*State).HasItems)
+	if !reflect.DeepEqual(st.Worktrees, worktrees) {
+		t.Errorf("Worktrees = %+v, want %+v", st.Worktrees, worktrees)
+	}
+	if st.Sessions["lfg"].Attached != 1 {
+		t.Errorf("Sessions = %+v, want lfg attached", st.Sessions)
+	}
+	if len(st.Items) != 1 || st.Items[0].ID != "I_1" || !reflect.DeepEqual(st.Statuses, github.IssueStatuses) {
+		t.Errorf("Items = %+v, Statuses = %v", st.Items, st.Statuses)
+	}
+	if st.Backend == nil || st.Backend.Type != "github-issues" {
+		t.Errorf("Backend = %+v, want the config's", st.Backend)
+	}
+
+	if err := Serve(context.Background(), configPath, sources); err == nil {
+		t.Error("a second Serve() started while the daemon was running")
+	}
+
+	if err := Refresh(configPath); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	waitFor(t, configPath, func(*State) bool { return fetches.Load() >= 2 })
+	if watches.Load() < 1 {
+		t.Error("Watch wasn't given the worktrees alongside a fetch")
+	}
+
+	if err := Stop(configPath); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() didn't return after Stop()")
+	}
+	if _, err := os.Stat(SocketPath(configPath)); !os.IsNotExist(err) {
+		t.Errorf("socket left behind: %v", err)
+	}
+}
+
+func TestHasItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    *State
+		expected bool
+	}{
+		{name: "no daemon", state: nil, expected: false},
+		{name: "not fetched yet", state: &State{}, expected: false},
+		{name: "fetched", state: &State{RefreshedAt: time.Now()}, expected: true},
+		{name: "last fetch failed", state: &State{RefreshedAt: time.Now(), ItemsError: "offline"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.HasItems(); got != tt.expected {
+				t.Errorf("HasItems() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
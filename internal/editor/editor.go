@@ -0,0 +1,45 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command returns the command that edits the file at path in the user's editor: $VISUAL, then
+// $EDITOR, then vi. Either may carry arguments, e.g. "code --wait".
+func Command(path string) *exec.Cmd {
+	name, args := editCommand(os.Getenv("VISUAL"), os.Getenv("EDITOR"), path)
+	return exec.Command(name, args...)
+}
+
+// PopupCommand is Command in a tmux popup over the current pane when run inside tmux, for
+// panes too small to edit in, and Command itself otherwise
+func PopupCommand(path string) *exec.Cmd {
+	if os.Getenv("TMUX") == "" {
+		return Command(path)
+	}
+	name, args := editCommand(os.Getenv("VISUAL"), os.Getenv("EDITOR"), path)
+	return exec.Command("tmux", popupArgs(name, args)...)
+}
+
+// popupArgs are tmux's arguments for a popup running name with args, closed when it exits
+func popupArgs(name string, args []string) []string {
+	quoted := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return []string{"display-popup", "-E", "-w", "80%", "-h", "80%", strings.Join(quoted, " ")}
+}
+
+// editCommand is the editor command for path given $VISUAL and $EDITOR
+func editCommand(visual, editor, path string) (string, []string) {
+	fields := strings.Fields(visual)
+	if len(fields) == 0 {
+		fields = strings.Fields(editor)
+	}
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	return fields[0], append(fields[1:], path)
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditCommand(t *testing.T) {
+	path := "/repo/.lfg/scratchpads/proj-login.md"
+
+	tests := []struct {
+		name         string
+		visual       string
+		editor       string
+		expectedName string
+		expectedArgs []string
+	}{
+		{name: "visual", visual: "nvim", editor: "nano", expectedName: "nvim", expectedArgs: []string{path}},
+		{name: "editor", editor: "nano", expectedName: "nano", expectedArgs: []string{path}},
+		{name: "with arguments", editor: "code --wait", expectedName: "code", expectedArgs: []string{"--wait", path}},
+		{name: "neither", visual: " ", expectedName: "vi", expectedArgs: []string{path}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args := editCommand(tt.visual, tt.editor, path)
+			if name != tt.expectedName || !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("editCommand() = %s %v, want %s %v", name, args, tt.expectedName, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestPopupArgs(t *testing.T) {
+	got := popupArgs("code", []string{"--wait", "/repo/it's.md"})
+	want := []string{"display-popup", "-E", "-w", "80%", "-h", "80%", `'code' '--wait' '/repo/it'\''s.md'`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("popupArgs() = %q, want %q", got, want)
+	}
+}
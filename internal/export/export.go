@@ -0,0 +1,210 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Version is the version of the document format Build makes
+const Version = 1
+
+// Document is a repository's todos, what links them to worktrees and the TUI's settings, as
+// lfg export writes them and lfg import reads them back
+type Document struct {
+	Version      int       `json:"version" yaml:"version"`
+	Project      string    `json:"project" yaml:"project"`
+	ExportedAt   time.Time `json:"exported_at" yaml:"exported_at"`
+	Todos        []Todo    `json:"todos" yaml:"todos"`
+	SortMode     string    `json:"sort_mode,omitempty" yaml:"sort_mode,omitempty"`
+	ShowArchived bool      `json:"show_archived,omitempty" yaml:"show_archived,omitempty"`
+}
+
+// Todo is a todo, with the branch and pull request of the worktree it's linked to so the
+// worktree can be made again in another clone
+type Todo struct {
+	Description string     `json:"description" yaml:"description"`
+	Status      string     `json:"status" yaml:"status"`
+	Worktree    string     `json:"worktree,omitempty" yaml:"worktree,omitempty"`
+	Branch      string     `json:"branch,omitempty" yaml:"branch,omitempty"`
+	IssueURL    string     `json:"issue_url,omitempty" yaml:"issue_url,omitempty"`
+	IssueBody   string     `json:"issue_body,omitempty" yaml:"issue_body,omitempty"`
+	Notes       string     `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Layout      string     `json:"layout,omitempty" yaml:"layout,omitempty"`
+	Priority    string     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Due         string     `json:"due,omitempty" yaml:"due,omitempty"`
+	Tags        []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	BlockedBy   []string   `json:"blocked_by,omitempty" yaml:"blocked_by,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+	Review      *Review    `json:"review,omitempty" yaml:"review,omitempty"` // The pull request the worktree was checked out to review
+}
+
+// Review is the pull request a review worktree is for
+type Review struct {
+	Owner  string `json:"owner" yaml:"owner"`
+	Repo   string `json:"repo" yaml:"repo"`
+	Number int    `json:"number" yaml:"number"`
+}
+
+// Build collects the repository's todos and settings. branches is each worktree's branch,
+// by worktree name.
+func Build(cfg *config.Config, st *state.State, branches map[string]string) Document {
+	doc := Document{
+		Version:      Version,
+		Project:      cfg.Name,
+		ExportedAt:   time.Now().UTC(),
+		Todos:        make([]Todo, 0, len(cfg.Todos)),
+		SortMode:     st.SortMode,
+		ShowArchived: st.ShowArchived,
+	}
+	for _, todo := range cfg.Todos {
+		exported := Todo{
+			Description: todo.Description,
+			Status:      string(todo.Status),
+			Worktree:    todo.Worktree,
+			Branch:      branches[todo.Worktree],
+			IssueURL:    todo.GitHubURL,
+			IssueBody:   todo.GitHubBody,
+			Notes:       todo.Notes,
+			Layout:      todo.Layout,
+			Priority:    todo.Priority,
+			Due:         todo.Due,
+			Tags:        todo.Tags,
+			BlockedBy:   todo.BlockedBy,
+		}
+		exported.CreatedAt = timeOrNil(todo.CreatedAt)
+		exported.StartedAt = timeOrNil(todo.StartedAt)
+		exported.CompletedAt = timeOrNil(todo.CompletedAt)
+		if review, ok := st.Reviews[todo.Worktree]; ok {
+			exported.Review = &Review{Owner: review.Owner, Repo: review.Repo, Number: review.Number}
+		}
+		doc.Todos = append(doc.Todos, exported)
+	}
+	return doc
+}
+
+// Marshal writes doc as "json" or "yaml"
+func Marshal(doc Document, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal export: %w", err)
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal export: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, use json or yaml", format)
+}
+
+// Parse reads a document written by Marshal in either format, since JSON is YAML too
+func Parse(data []byte) (Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("failed to parse export: %w", err)
+	}
+	if doc.Version == 0 || doc.Version > Version {
+		return doc, fmt.Errorf("unsupported export version %d, this lfg reads version %d", doc.Version, Version)
+	}
+	return doc, nil
+}
+
+// Result is what Merge changed
+type Result struct {
+	Added   int
+	Updated int
+}
+
+// Merge brings the document's todos into cfg. A todo replaces the one for the same
+// worktree, or for todos without one, the one with the same description; the rest are
+// added after the existing todos, in the document's order.
+func Merge(cfg *config.Config, doc Document) Result {
+	var result Result
+	for _, imported := range doc.Todos {
+		todo := imported.todo()
+		if existing := find(cfg, todo); existing != nil {
+			*existing = todo
+			result.Updated++
+			continue
+		}
+		cfg.Todos = append(cfg.Todos, todo)
+		result.Added++
+	}
+	return result
+}
+
+// MergeState brings the document's settings and reviews into st
+func MergeState(st *state.State, doc Document) {
+	if doc.SortMode != "" {
+		st.SortMode = doc.SortMode
+	}
+	st.ShowArchived = st.ShowArchived || doc.ShowArchived
+	for _, todo := range doc.Todos {
+		if todo.Review != nil && todo.Worktree != "" {
+			st.SetReview(todo.Worktree, state.Review{Owner: todo.Review.Owner, Repo: todo.Review.Repo, Number: todo.Review.Number})
+		}
+	}
+}
+
+// find returns the todo in cfg that imported replaces, nil if there isn't one
+func find(cfg *config.Config, imported config.Todo) *config.Todo {
+	if imported.Worktree != "" {
+		return cfg.GetTodoForWorktree(imported.Worktree)
+	}
+	for i := range cfg.Todos {
+		if cfg.Todos[i].Worktree == "" && cfg.Todos[i].Description == imported.Description {
+			return &cfg.Todos[i]
+		}
+	}
+	return nil
+}
+
+// todo is the exported todo as it's kept in the config
+func (t Todo) todo() config.Todo {
+	todo := config.Todo{
+		Description: t.Description,
+		Status:      config.TodoStatus(t.Status),
+		Worktree:    t.Worktree,
+		GitHubBody:  t.IssueBody,
+		GitHubURL:   t.IssueURL,
+		Notes:       t.Notes,
+		Layout:      t.Layout,
+		Priority:    t.Priority,
+		Due:         t.Due,
+		Tags:        t.Tags,
+		BlockedBy:   t.BlockedBy,
+	}
+	if todo.Status == "" {
+		todo.Status = config.TodoStatusPending
+	}
+	if t.CreatedAt != nil {
+		todo.CreatedAt = *t.CreatedAt
+	}
+	if t.StartedAt != nil {
+		todo.StartedAt = *t.StartedAt
+	}
+	if t.CompletedAt != nil {
+		todo.CompletedAt = *t.CompletedAt
+	}
+	return todo
+}
+
+// timeOrNil is t, or nil when it isn't set so it's left out
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+func loadConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	return cfg
+}
+
+func TestRoundTrip(t *testing.T) {
+	completed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := loadConfig(t, "name: proj\n")
+	cfg.Todos = []config.Todo{
+		{Description: "Fix login", Status: config.TodoStatusPending, Worktree: "proj-fix-login", CreatedAt: completed.AddDate(0, 0, -2), StartedAt: completed.AddDate(0, 0, -1), GitHubURL: "https://github.com/owner/repo/issues/3", Notes: "Try the staging API", Priority: "High", Due: "2026-10-20", Tags: []string{"bug"}, BlockedBy: []string{"#2"}},
+		{Description: "Review #7: Speed up search", Status: config.TodoStatusPending, Worktree: "proj-review-7"},
+		{Description: "Write docs", Status: config.TodoStatusDone, CompletedAt: completed},
+	}
+	st := &state.State{SortMode: "activity"}
+	st.SetReview("proj-review-7", state.Review{Owner: "owner", Repo: "repo", Number: 7})
+
+	doc := Build(cfg, st, map[string]string{"proj-fix-login": "fix-login", "proj-review-7": "proj-review-7"})
+	if doc.Todos[0].Branch != "fix-login" || doc.Todos[1].Review == nil || doc.Todos[2].CompletedAt == nil {
+		t.Fatalf("Build() = %+v", doc.Todos)
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Marshal(doc, format)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			parsed, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			imported := loadConfig(t, "name: proj\n")
+			if result := Merge(imported, parsed); result != (Result{Added: 3}) {
+				t.Errorf("Merge() = %+v, want 3 added", result)
+			}
+			if !reflect.DeepEqual(imported.Todos, cfg.Todos) {
+				t.Errorf("imported todos = %+v, want %+v", imported.Todos, cfg.Todos)
+			}
+			importedState := &state.State{}
+			MergeState(importedState, parsed)
+			if importedState.SortMode != "activity" || importedState.Reviews["proj-review-7"].Number != 7 {
+				t.Errorf("imported state = %+v", importedState)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cfg := loadConfig(t, "name: proj\n")
+	cfg.Todos = []config.Todo{
+		{Description: "Fix login", Status: config.TodoStatusPending, Worktree: "proj-fix-login"},
+		{Description: "Write docs", Status: config.TodoStatusPending},
+		{Description: "Local only", Status: config.TodoStatusPending},
+	}
+	doc := Document{Version: Version, Todos: []Todo{
+		{Description: "Fix the login form", Status: "done", Worktree: "proj-fix-login"},
+		{Description: "Write docs", Notes: "Start with the README"},
+		{Description: "Add search", Worktree: "proj-add-search"},
+	}}
+
+	if result := Merge(cfg, doc); result != (Result{Added: 1, Updated: 2}) {
+		t.Errorf("Merge() = %+v, want 1 added and 2 updated", result)
+	}
+	expected := []config.Todo{
+		{Description: "Fix the login form", Status: config.TodoStatusDone, Worktree: "proj-fix-login"},
+		{Description: "Write docs", Status: config.TodoStatusPending, Notes: "Start with the README"},
+		{Description: "Local only", Status: config.TodoStatusPending},
+		{Description: "Add search", Status: config.TodoStatusPending, Worktree: "proj-add-search"},
+	}
+	if !reflect.DeepEqual(cfg.Todos, expected) {
+		t.Errorf("todos = %+v, want %+v", cfg.Todos, expected)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, data := range []string{`{"todos": []}`, "version: 2\ntodos: []\n"} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want a version error", data)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gc
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// cleaner is the cleanup step for a kind of project, found by a file in its root
+type cleaner struct {
+	marker  string
+	command string
+}
+
+// cleaners are the project kinds Detect knows, in the order their steps run. Rust's target
+// directory is removed rather than cleaned with cargo, which would empty a shared one.
+var cleaners = []cleaner{
+	{marker: "package.json", command: "rm -rf node_modules dist"},
+	{marker: "go.mod", command: "go clean"},
+	{marker: "Cargo.toml", command: "rm -rf target"},
+}
+
+// Detect returns the commands that remove the build artifacts and dependencies of the
+// project in dir, none if it isn't a kind of project lfg knows
+func Detect(dir string) []string {
+	var steps []string
+	for _, kind := range cleaners {
+		if _, err := os.Stat(filepath.Join(dir, kind.marker)); err == nil {
+			steps = append(steps, kind.command)
+		}
+	}
+	return steps
+}
+
+// Commands returns the configured cleanup commands for the worktree in dir, detecting them
+// when the config has none
+func Commands(cfg *config.Config, dir string) []string {
+	if len(cfg.GC) > 0 {
+		return cfg.GC
+	}
+	return Detect(dir)
+}
+
+// Run runs the cleanup commands in dir in turn, writing their output to out, and returns
+// how much smaller dir is for it. It stops at the first command that fails.
+func Run(commands []string, dir string, out io.Writer) (int64, error) {
+	before := Size(dir)
+	var runErr error
+	for _, command := range commands {
+		fmt.Fprintf(out, "$ %s\n", command)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = dir
+		cmd.Stdout, cmd.Stderr = out, out
+		if err := cmd.Run(); err != nil {
+			runErr = fmt.Errorf("%s failed: %w", command, err)
+			break
+		}
+	}
+	return max(before-Size(dir), 0), runErr
+}
+
+// Size adds up the sizes of the files under dir, not following symlinks. Files that can't
+// be read are left out.
+func Size(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
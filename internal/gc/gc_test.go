@@ -0,0 +1,76 @@
+package gc
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected []string
+	}{
+		{name: "nothing to clean", files: []string{"README.md"}, expected: nil},
+		{name: "node", files: []string{"package.json"}, expected: []string{"rm -rf node_modules dist"}},
+		{name: "go", files: []string{"go.mod"}, expected: []string{"go clean"}},
+		{name: "rust", files: []string{"Cargo.toml"}, expected: []string{"rm -rf target"}},
+		{name: "several kinds", files: []string{"Cargo.toml", "package.json"}, expected: []string{"rm -rf node_modules dist", "rm -rf target"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", file, err)
+				}
+			}
+			if got := Detect(dir); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Detect() = %q, want %q", got, tt.expected)
+			}
+			configured := []string{"make clean"}
+			if got := Commands(&config.Config{GC: configured}, dir); !reflect.DeepEqual(got, configured) {
+				t.Errorf("Commands() = %q, want the configured %q", got, configured)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", 100)
+	write("node_modules/left-pad/index.js", 3000)
+	write("dist/app.js", 500)
+
+	reclaimed, err := Run([]string{"rm -rf node_modules", "rm -rf dist"}, dir, io.Discard)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if reclaimed != 3500 {
+		t.Errorf("Run() reclaimed %d bytes, want 3500", reclaimed)
+	}
+	if size := Size(dir); size != 100 {
+		t.Errorf("Size() after Run() = %d, want the 100 bytes of code left", size)
+	}
+
+	write("dist/app.js", 500)
+	reclaimed, err = Run([]string{"false", "rm -rf dist"}, dir, io.Discard)
+	if err == nil || reclaimed != 0 {
+		t.Errorf("Run() with a failing command = %d, %v, want an error before anything was removed", reclaimed, err)
+	}
+}
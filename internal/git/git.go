@@ -1,16 +1,52 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/runner"
 	"github.com/markcipolla/lfg/internal/tmux"
 )
 
+const (
+	// gitTimeout bounds a git command, so a hung one (e.g. waiting on a lock) fails
+	gitTimeout = 2 * time.Minute
+	// worktreeAddTimeout bounds adding a worktree, whose checkout hooks may install things
+	worktreeAddTimeout = 10 * time.Minute
+)
+
+// ErrWorktreeNotFound is returned for a worktree name the repository has no worktree by
+var ErrWorktreeNotFound = errors.New("worktree not found")
+
+// commands runs git, and is swapped out by tests
+var commands runner.Runner = runner.Exec{Timeout: gitTimeout}
+
+// SetRunner makes the package run git through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// gitOutput runs git with args and returns its stdout
+func gitOutput(args ...string) ([]byte, error) {
+	return commands.Run(context.Background(), nil, "git", args...)
+}
+
+// gitRun runs git with args for its exit status
+func gitRun(args ...string) error {
+	_, err := gitOutput(args...)
+	return err
+}
+
 type Worktree struct {
 	Path   string
 	Branch string
@@ -19,8 +55,12 @@ type Worktree struct {
 
 // ListWorktrees returns all git worktrees
 func ListWorktrees() ([]Worktree, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	return ListWorktreesContext(context.Background())
+}
+
+// ListWorktreesContext is ListWorktrees, stopping git when ctx is done
+func ListWorktreesContext(ctx context.Context) ([]Worktree, error) {
+	output, err := commands.Run(ctx, nil, "git", "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
@@ -67,7 +107,212 @@ func GetWorktreePath(name string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("worktree %q not found", name)
+	return "", fmt.Errorf("%w: %s", ErrWorktreeNotFound, name)
+}
+
+// GetBranch returns the branch checked out in the worktree at path
+func GetBranch(path string) (string, error) {
+	output, err := gitOutput("-C", path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get branch: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Activity summarises how recently a worktree was worked on and how far it has drifted
+type Activity struct {
+	LastCommit   time.Time // Time of the most recent commit on HEAD
+	LastModified time.Time // Most recent change to an uncommitted file
+	Created      time.Time // When the worktree was added
+	Ahead        int       // Commits on HEAD not on the base branch
+	Behind       int       // Commits on the base branch not on HEAD
+}
+
+// LastActivity is when the worktree was last worked on, by commit or uncommitted edit
+func (a Activity) LastActivity() time.Time {
+	if a.LastModified.After(a.LastCommit) {
+		return a.LastModified
+	}
+	return a.LastCommit
+}
+
+// GetActivity collects activity for the worktree at path, relative to the base branch.
+// Anything that can't be determined is left zero.
+func GetActivity(path, base string) Activity {
+	var activity Activity
+
+	if output, err := gitOutput("-C", path, "log", "-1", "--format=%ct"); err == nil {
+		if secs, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64); err == nil {
+			activity.LastCommit = time.Unix(secs, 0)
+		}
+	}
+
+	// Only modified and untracked files are walked, so ignored build output doesn't count
+	if output, err := gitOutput("-C", path, "ls-files", "--modified", "--others", "--exclude-standard"); err == nil {
+		for _, file := range splitLines(string(output)) {
+			if info, err := os.Stat(filepath.Join(path, file)); err == nil && info.ModTime().After(activity.LastModified) {
+				activity.LastModified = info.ModTime()
+			}
+		}
+	}
+
+	// A linked worktree's .git file is written once when it's added
+	if info, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		activity.Created = info.ModTime()
+	}
+
+	if base != "" {
+		activity.Ahead, activity.Behind, _ = AheadBehind(path, base)
+	}
+
+	return activity
+}
+
+// AheadBehind returns how many commits HEAD of the worktree at path is ahead of and behind base
+func AheadBehind(path, base string) (int, int, error) {
+	output, err := gitOutput("-C", path, "rev-list", "--left-right", "--count", base+"...HEAD")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", base, err)
+	}
+	behind, ahead, err := parseLeftRightCount(string(output))
+	return ahead, behind, err
+}
+
+// RecentCommits returns the last n commits on HEAD of the worktree at path, as "<hash> <subject>"
+func RecentCommits(path string, n int) ([]string, error) {
+	output, err := gitOutput("-C", path, "log", fmt.Sprintf("-%d", n), "--format=%h %s")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent commits: %w", err)
+	}
+	return splitLines(string(output)), nil
+}
+
+// DirtyFileCount returns how many files in the worktree at path have uncommitted changes
+func DirtyFileCount(path string) (int, error) {
+	files, err := UncommittedFiles(path)
+	return len(files), err
+}
+
+// UncommittedFiles returns the worktree at path's uncommitted changes, one file per line
+// like `git status --short`, e.g. " M main.go"
+func UncommittedFiles(path string) ([]string, error) {
+	output, err := gitOutput("-C", path, "status", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	return splitLines(string(output)), nil
+}
+
+// UnpushedCommitCount returns how many commits on HEAD of the worktree at path aren't on
+// its upstream branch. It errors if the branch has no upstream.
+func UnpushedCommitCount(path string) (int, error) {
+	output, err := gitOutput("-C", path, "rev-list", "--count", "@{upstream}..HEAD")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unpushed commits: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// FileChange is one file's line counts in a DiffStat. Binary files count no lines.
+type FileChange struct {
+	Path       string
+	Insertions int
+	Deletions  int
+	Binary     bool
+}
+
+// DiffStat summarises the changes on a branch
+type DiffStat struct {
+	Files      []FileChange
+	Insertions int
+	Deletions  int
+}
+
+// DiffStatFromBase returns what HEAD of the worktree at path changes since it forked from base,
+// like `git diff --stat base...HEAD`
+func DiffStatFromBase(path, base string) (DiffStat, error) {
+	output, err := gitOutput("-C", path, "diff", "--numstat", base+"...HEAD")
+	if err != nil {
+		return DiffStat{}, fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+	return parseNumstat(string(output))
+}
+
+// DiffStatText is what HEAD of the worktree at path changes since it forked from base, as
+// `git diff --stat base...HEAD` prints it
+func DiffStatText(path, base string) (string, error) {
+	output, err := gitOutput("-C", path, "diff", "--stat", base+"...HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// Push pushes the worktree at path's branch to origin, setting it as the branch's upstream
+func Push(path, branch string) error {
+	if err := gitRun("-C", path, "push", "--quiet", "--set-upstream", "origin", branch); err != nil {
+		return fmt.Errorf("failed to push %s: %w", branch, err)
+	}
+	return nil
+}
+
+// parseNumstat parses the output of `git diff --numstat`
+func parseNumstat(output string) (DiffStat, error) {
+	var stat DiffStat
+	for _, line := range splitLines(output) {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return DiffStat{}, fmt.Errorf("unexpected numstat line: %q", line)
+		}
+
+		change := FileChange{Path: fields[2]}
+		if fields[0] == "-" && fields[1] == "-" {
+			change.Binary = true
+		} else {
+			insertions, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return DiffStat{}, fmt.Errorf("unexpected numstat line: %q", line)
+			}
+			deletions, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return DiffStat{}, fmt.Errorf("unexpected numstat line: %q", line)
+			}
+			change.Insertions, change.Deletions = insertions, deletions
+		}
+
+		stat.Files = append(stat.Files, change)
+		stat.Insertions += change.Insertions
+		stat.Deletions += change.Deletions
+	}
+	return stat, nil
+}
+
+// splitLines splits command output into lines, dropping blank ones
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseLeftRightCount parses the output of `git rev-list --left-right --count`
+func parseLeftRightCount(output string) (int, int, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	left, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	right, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return left, right, nil
 }
 
 // GetCurrentWorktree returns the name of the current worktree, or empty string if not in a worktree
@@ -83,52 +328,214 @@ func GetCurrentWorktree() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return WorktreeAt(worktrees, cwd), nil
+}
 
-	// Check if current directory is in any worktree
+// WorktreeAt returns the name of the worktree dir is in, or "" if it's in none of them
+func WorktreeAt(worktrees []Worktree, dir string) string {
 	for _, wt := range worktrees {
-		// Check if cwd is the worktree path or a subdirectory of it
-		if cwd == wt.Path || strings.HasPrefix(cwd, wt.Path+string(filepath.Separator)) {
-			return GetWorktreeName(wt.Path), nil
+		// Check if dir is the worktree path or a subdirectory of it
+		if dir == wt.Path || strings.HasPrefix(dir, wt.Path+string(filepath.Separator)) {
+			return GetWorktreeName(wt.Path)
 		}
 	}
-
-	return "", nil
+	return ""
 }
 
 // CreateWorktree creates a new git worktree in the parent directory of the repo root
 func CreateWorktree(name string) error {
+	return CreateWorktreeContext(context.Background(), name, "", "")
+}
+
+// CreateWorktreeContext is CreateWorktree on a new branch started from base, stopping git
+// when ctx is cancelled. The branch defaults to the worktree name and base to HEAD.
+// A cancelled creation is cleaned up so the names can be used again.
+func CreateWorktreeContext(ctx context.Context, name, branch, base string) error {
+	if branch == "" {
+		branch = name
+	}
+	return addWorktree(ctx, name, branch, base)
+}
+
+// CheckoutWorktreeContext creates a worktree for an existing local branch, stopping git
+// when ctx is cancelled
+func CheckoutWorktreeContext(ctx context.Context, name, branch string) error {
+	return addWorktree(ctx, name, "", branch)
+}
+
+// FetchPullRequest fetches a pull request's head from origin onto a local branch, which
+// works for pull requests from forks too
+func FetchPullRequest(ctx context.Context, number int, branch string) error {
+	refspec := fmt.Sprintf("+pull/%d/head:refs/heads/%s", number, branch)
+	if _, err := commands.Run(ctx, nil, "git", "fetch", "origin", refspec); err != nil {
+		return fmt.Errorf("failed to fetch pull request #%d: %w", number, err)
+	}
+	return nil
+}
+
+// CommitsNotIn counts the commits on branch that commit doesn't have, fetching commit from
+// origin first when it isn't here, e.g. a pull request's head pushed to since it was fetched
+func CommitsNotIn(ctx context.Context, branch, commit string) (int, error) {
+	if commit == "" {
+		return 0, fmt.Errorf("no commit to compare %s with", branch)
+	}
+	if _, err := gitOutput("cat-file", "-e", commit+"^{commit}"); err != nil {
+		if _, err := commands.Run(ctx, nil, "git", "fetch", "origin", commit); err != nil {
+			return 0, fmt.Errorf("failed to fetch %s: %w", commit, err)
+		}
+	}
+	output, err := gitOutput("rev-list", "--count", commit+".."+branch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare %s with %s: %w", branch, commit, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// addWorktree runs `git worktree add` for a worktree in the parent directory of the repo
+// root, on newBranch started from commitish, or on commitish itself when newBranch is empty
+func addWorktree(ctx context.Context, name, newBranch, commitish string) error {
+	// Checkout hooks can take a while on a big repo, but it's still worth a bound
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, worktreeAddTimeout)
+		defer cancel()
+	}
+
 	// Get the repository root
-	rootCmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	rootOutput, err := rootCmd.Output()
+	rootOutput, err := commands.Run(ctx, nil, "git", "rev-parse", "--show-toplevel")
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return ctx.Err()
+		}
 		return fmt.Errorf("failed to get repo root: %w", err)
 	}
 	repoRoot := strings.TrimSpace(string(rootOutput))
+	worktreePath := worktreePathIn(repoRoot, name)
 
-	// Get the parent directory
-	parentDir := filepath.Dir(repoRoot)
-
-	// Create worktree path in parent directory
-	worktreePath := filepath.Join(parentDir, name)
+	// Remember what was already there, so cleaning up after a cancel can't remove it
+	_, statErr := os.Stat(worktreePath)
+	pathExisted := statErr == nil
+	branchExisted := newBranch == "" ||
+		gitRun("-C", repoRoot, "rev-parse", "--verify", "--quiet", "refs/heads/"+newBranch) == nil
 
 	// Create branch and worktree
-	cmd := exec.Command("git", "worktree", "add", "-b", name, worktreePath)
-	output, err := cmd.CombinedOutput()
+	args := []string{"-C", repoRoot, "worktree", "add"}
+	if newBranch != "" {
+		args = append(args, "-b", newBranch)
+	}
+	args = append(args, worktreePath)
+	if commitish != "" {
+		args = append(args, commitish)
+	}
+	_, err = commands.Run(ctx, nil, "git", args...)
+	if ctx.Err() != nil {
+		removePartialWorktree(repoRoot, newBranch, worktreePath, pathExisted, branchExisted)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("failed to create worktree: %w", err)
+		}
+		return ctx.Err()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create worktree: %s", string(output))
+		return fmt.Errorf("failed to create worktree: %w", err)
 	}
 	return nil
 }
 
+// worktreePathIn is where a worktree named name is added to the repository at repoRoot, in
+// the directory the repository is in
+func worktreePathIn(repoRoot, name string) string {
+	return filepath.Join(filepath.Dir(repoRoot), name)
+}
+
+// NewWorktreePath is where a worktree named name would be added
+func NewWorktreePath(name string) (string, error) {
+	output, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to get repo root: %w", err)
+	}
+	return worktreePathIn(strings.TrimSpace(string(output)), name), nil
+}
+
+// BranchExists reports whether there's a local branch named branch
+func BranchExists(branch string) bool {
+	return gitRun("rev-parse", "--verify", "--quiet", "refs/heads/"+branch) == nil
+}
+
+// Branch is a local branch, or a remote-tracking branch such as origin/fix
+type Branch struct {
+	Name   string
+	Remote string // The remote a remote-tracking branch belongs to, empty for local branches
+}
+
+// LocalName is the name of the local branch, e.g. fix for origin/fix
+func (b Branch) LocalName() string {
+	if b.Remote == "" {
+		return b.Name
+	}
+	return strings.TrimPrefix(b.Name, b.Remote+"/")
+}
+
+// ListBranches returns the local branches followed by the remote-tracking branches that
+// have no local branch of the same name
+func ListBranches() ([]Branch, error) {
+	output, err := gitOutput("for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	return parseBranchRefs(string(output)), nil
+}
+
+func parseBranchRefs(output string) []Branch {
+	var local, remote []Branch
+	localNames := make(map[string]bool)
+	for _, ref := range splitLines(output) {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			local = append(local, Branch{Name: name})
+			localNames[name] = true
+			continue
+		}
+		name, ok := strings.CutPrefix(ref, "refs/remotes/")
+		if !ok {
+			continue
+		}
+		remoteName, branch, ok := strings.Cut(name, "/")
+		// refs/remotes/origin/HEAD points at another branch in the list
+		if !ok || branch == "HEAD" {
+			continue
+		}
+		remote = append(remote, Branch{Name: name, Remote: remoteName})
+	}
+
+	branches := local
+	for _, branch := range remote {
+		if !localNames[branch.LocalName()] {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// removePartialWorktree undoes an interrupted `git worktree add`, leaving alone
+// a directory or branch that was there before we started
+func removePartialWorktree(repoRoot, branch, worktreePath string, pathExisted, branchExisted bool) {
+	if pathExisted {
+		return
+	}
+	gitRun("-C", repoRoot, "worktree", "remove", "--force", worktreePath)
+	os.RemoveAll(worktreePath)
+	gitRun("-C", repoRoot, "worktree", "prune")
+	if !branchExisted {
+		gitRun("-C", repoRoot, "branch", "-D", branch)
+	}
+}
+
 // IsBranchMerged checks if a branch has been merged into the default branch
 func IsBranchMerged(branchName string) (bool, error) {
 	// Get the default branch
-	cmd := exec.Command("git", "symbolic-ref", "refs/remotes/origin/HEAD")
-	output, err := cmd.Output()
+	output, err := gitOutput("symbolic-ref", "refs/remotes/origin/HEAD")
 	if err != nil {
 		// Fallback to master/main
-		cmd = exec.Command("git", "rev-parse", "--verify", "origin/main")
-		if cmd.Run() == nil {
+		if gitRun("rev-parse", "--verify", "origin/main") == nil {
 			output = []byte("refs/remotes/origin/main")
 		} else {
 			output = []byte("refs/remotes/origin/master")
@@ -137,8 +544,7 @@ func IsBranchMerged(branchName string) (bool, error) {
 	defaultBranch := strings.TrimSpace(strings.TrimPrefix(string(output), "refs/remotes/"))
 
 	// Check if branch is merged
-	cmd = exec.Command("git", "branch", "-r", "--merged", defaultBranch)
-	output, err = cmd.Output()
+	output, err = gitOutput("branch", "-r", "--merged", defaultBranch)
 	if err != nil {
 		return false, err
 	}
@@ -162,8 +568,7 @@ func DeleteWorktree(name string, deleteBranch bool) error {
 	if err != nil {
 		// Worktree doesn't exist in git, just try to delete the branch
 		if deleteBranch {
-			cmd := exec.Command("git", "branch", "-D", name)
-			if err := cmd.Run(); err != nil {
+			if err := gitRun("branch", "-D", name); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to delete branch %s\n", name)
 			}
 		}
@@ -183,19 +588,22 @@ func DeleteWorktree(name string, deleteBranch bool) error {
 		}
 	}
 
+	// The branch may have been named differently from the worktree when it was created
+	branch := name
+	if current, err := GetBranch(worktreePath); err == nil && current != "HEAD" {
+		branch = current
+	}
+
 	// Remove worktree using the full path
-	cmd := exec.Command("git", "worktree", "remove", worktreePath)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to remove worktree: %s", string(output))
+	if err := gitRun("worktree", "remove", worktreePath); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
 	}
 
 	// Delete branch if requested
 	if deleteBranch {
-		cmd = exec.Command("git", "branch", "-D", name)
-		if err := cmd.Run(); err != nil {
+		if err := gitRun("branch", "-D", branch); err != nil {
 			// Don't fail if branch deletion fails
-			fmt.Fprintf(os.Stderr, "Warning: failed to delete branch %s\n", name)
+			fmt.Fprintf(os.Stderr, "Warning: failed to delete branch %s\n", branch)
 		}
 	}
 
@@ -241,9 +649,152 @@ func JumpToWorktree(name string, cfg *config.Config) error {
 	}
 
 	if targetPath == "" {
-		return fmt.Errorf("worktree '%s' not found", name)
+		return fmt.Errorf("%w: %s", ErrWorktreeNotFound, name)
 	}
 
 	// Create/attach tmux session
 	return tmux.CreateOrAttachSession(name, targetPath, cfg)
 }
+
+// Head returns the commit checked out in the worktree at path
+func Head(path string) (string, error) {
+	output, err := gitOutput("-C", path, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HooksDir returns the directory git runs the hooks of the repository at path from
+func HooksDir(path string) (string, error) {
+	output, err := gitOutput("-C", path, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git hooks directory: %w", err)
+	}
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return dir, nil
+}
+
+// ConfigValue returns a git setting of the repository at path, "" when it isn't set
+func ConfigValue(path, key string) string {
+	output, err := gitOutput("-C", path, "config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Changes returns the changes to the worktree at path's tracked files since HEAD, staged or
+// not, as a binary patch ApplyPatch can put back
+func Changes(path string) ([]byte, error) {
+	output, err := gitOutput("-C", path, "diff", "--binary", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff uncommitted changes: %w", err)
+	}
+	return output, nil
+}
+
+// UntrackedFiles returns the files in the worktree at path that git doesn't track and
+// isn't told to ignore, relative to it
+func UntrackedFiles(path string) ([]string, error) {
+	output, err := gitOutput("-C", path, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// ResetHard moves the worktree at path's branch to commit, throwing away changes to its
+// tracked files. Git's reflog still has where the branch was.
+func ResetHard(path, commit string) error {
+	if err := gitRun("-C", path, "reset", "--quiet", "--hard", commit); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", commit, err)
+	}
+	return nil
+}
+
+// ApplyPatch applies a patch from Changes to the worktree at path, leaving it uncommitted
+func ApplyPatch(path string, patch []byte) error {
+	if _, err := commands.Run(context.Background(), bytes.NewReader(patch), "git", "-C", path, "apply", "--binary", "-"); err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+	return nil
+}
+
+// WIPMessage is the message of the commits and stashes SaveWIP makes
+const WIPMessage = "wip: uncommitted work saved by lfg"
+
+// WIP is uncommitted work SaveWIP saved and that hasn't been restored
+type WIP struct {
+	Stash string // The stash it's in, e.g. stash@{0}, or "" for the commit at HEAD
+}
+
+// SaveWIP saves the uncommitted changes of the worktree at path, untracked files included,
+// in a commit, or with stash in a stash. It reports whether there was anything to save.
+func SaveWIP(path string, stash bool) (bool, error) {
+	if count, err := DirtyFileCount(path); err != nil || count == 0 {
+		return false, err
+	}
+	if stash {
+		if err := gitRun("-C", path, "stash", "push", "--include-untracked", "-m", WIPMessage); err != nil {
+			return false, fmt.Errorf("failed to stash uncommitted work: %w", err)
+		}
+		return true, nil
+	}
+	if err := gitRun("-C", path, "add", "--all"); err != nil {
+		return false, fmt.Errorf("failed to stage uncommitted work: %w", err)
+	}
+	// Hooks are skipped, since work in progress is rarely ready for them
+	if err := gitRun("-C", path, "commit", "--quiet", "--no-verify", "-m", WIPMessage); err != nil {
+		return false, fmt.Errorf("failed to commit uncommitted work: %w", err)
+	}
+	return true, nil
+}
+
+// FindWIP looks for work SaveWIP saved in the worktree at path: the commit at HEAD, or the
+// latest stash made on its branch. It's nil when there's none.
+func FindWIP(path string) (*WIP, error) {
+	if output, err := gitOutput("-C", path, "log", "-1", "--format=%s"); err == nil && strings.TrimSpace(string(output)) == WIPMessage {
+		return &WIP{}, nil
+	}
+	branch, err := GetBranch(path)
+	if err != nil {
+		return nil, err
+	}
+	output, err := gitOutput("-C", path, "stash", "list", "--format=%gd%x09%gs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+	// Stashes are shared by every worktree, so only ones made on this branch count
+	for _, line := range splitLines(string(output)) {
+		ref, subject, _ := strings.Cut(line, "\t")
+		if subject == "On "+branch+": "+WIPMessage {
+			return &WIP{Stash: ref}, nil
+		}
+	}
+	return nil, nil
+}
+
+// RestoreWIP puts saved work back as uncommitted changes, soft-resetting its commit, which
+// leaves the changes staged, or popping its stash
+func RestoreWIP(path string, wip *WIP) error {
+	if wip.Stash != "" {
+		if err := gitRun("-C", path, "stash", "pop", "--quiet", wip.Stash); err != nil {
+			return fmt.Errorf("failed to pop %s: %w", wip.Stash, err)
+		}
+		return nil
+	}
+	if err := gitRun("-C", path, "reset", "--quiet", "--soft", "HEAD~1"); err != nil {
+		return fmt.Errorf("failed to undo the work in progress commit: %w", err)
+	}
+	return nil
+}
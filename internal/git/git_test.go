@@ -1,9 +1,16 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/testrepo"
 )
 
 func TestGetWorktreeName(t *testing.T) {
@@ -64,3 +71,278 @@ func TestGetCurrentWorktree(t *testing.T) {
 		t.Logf("Current worktree: %q (expected %q or empty)", worktreeName, expectedName)
 	}
 }
+
+func TestWorktreeAt(t *testing.T) {
+	worktrees := []Worktree{{Path: "/src/lfg"}, {Path: "/src/lfg-login"}}
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected string
+	}{
+		{name: "main worktree", dir: "/src/lfg", expected: "lfg"},
+		{name: "subdirectory", dir: "/src/lfg-login/internal/tui", expected: "lfg-login"},
+		{name: "shared prefix isn't a subdirectory", dir: "/src/lfg-other", expected: ""},
+		{name: "outside every worktree", dir: "/tmp", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WorktreeAt(worktrees, tt.dir); got != tt.expected {
+				t.Errorf("WorktreeAt(%q) = %q, want %q", tt.dir, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLeftRightCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		left      int
+		right     int
+		expectErr bool
+	}{
+		{name: "tab separated", output: "3\t5\n", left: 3, right: 5},
+		{name: "in sync", output: "0\t0\n", left: 0, right: 0},
+		{name: "empty", output: "", expectErr: true},
+		{name: "not numbers", output: "a\tb\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right, err := parseLeftRightCount(tt.output)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("parseLeftRightCount(%q) expected error", tt.output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLeftRightCount(%q) error = %v", tt.output, err)
+			}
+			if left != tt.left || right != tt.right {
+				t.Errorf("parseLeftRightCount(%q) = %d, %d, want %d, %d", tt.output, left, right, tt.left, tt.right)
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected int
+	}{
+		{name: "empty", output: "", expected: 0},
+		{name: "trailing newline", output: " M a.go\n?? b.go\n", expected: 2},
+		{name: "blank lines", output: "abc123 First\n\n\ndef456 Second", expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLines(tt.output); len(got) != tt.expected {
+				t.Errorf("splitLines(%q) returned %d lines, want %d", tt.output, len(got), tt.expected)
+			}
+		})
+	}
+}
+
+func TestActivityLastActivity(t *testing.T) {
+	commit := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	edit := commit.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		activity Activity
+		expected time.Time
+	}{
+		{name: "nothing known", activity: Activity{}, expected: time.Time{}},
+		{name: "only a commit", activity: Activity{LastCommit: commit}, expected: commit},
+		{name: "edited since the last commit", activity: Activity{LastCommit: commit, LastModified: edit}, expected: edit},
+		{name: "committed since the last edit", activity: Activity{LastCommit: edit, LastModified: commit}, expected: edit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.activity.LastActivity(); !got.Equal(tt.expected) {
+				t.Errorf("LastActivity() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		files      []FileChange
+		insertions int
+		deletions  int
+		wantErr    bool
+	}{
+		{name: "no changes", output: ""},
+		{
+			name:   "text and binary files",
+			output: "10\t2\tmain.go\n-\t-\tlogo.png\n0\t5\tdocs/old name.md\n",
+			files: []FileChange{
+				{Path: "main.go", Insertions: 10, Deletions: 2},
+				{Path: "logo.png", Binary: true},
+				{Path: "docs/old name.md", Deletions: 5},
+			},
+			insertions: 10,
+			deletions:  7,
+		},
+		{name: "garbage", output: "not numstat\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stat, err := parseNumstat(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumstat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(stat.Files, tt.files) {
+				t.Errorf("parseNumstat() files = %+v, want %+v", stat.Files, tt.files)
+			}
+			if stat.Insertions != tt.insertions || stat.Deletions != tt.deletions {
+				t.Errorf("parseNumstat() = +%d -%d, want +%d -%d", stat.Insertions, stat.Deletions, tt.insertions, tt.deletions)
+			}
+		})
+	}
+}
+
+func TestParseBranchRefs(t *testing.T) {
+	output := "refs/heads/main\nrefs/heads/feat/login\nrefs/remotes/origin/HEAD\nrefs/remotes/origin/main\nrefs/remotes/origin/fix\nrefs/remotes/upstream/release/1.0\n"
+
+	expected := []Branch{
+		{Name: "main"},
+		{Name: "feat/login"},
+		{Name: "origin/fix", Remote: "origin"},
+		{Name: "upstream/release/1.0", Remote: "upstream"},
+	}
+	if got := parseBranchRefs(output); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseBranchRefs() = %+v, want %+v", got, expected)
+	}
+
+	if name := (Branch{Name: "upstream/release/1.0", Remote: "upstream"}).LocalName(); name != "release/1.0" {
+		t.Errorf("LocalName() = %q, want %q", name, "release/1.0")
+	}
+}
+
+func TestListWorktrees(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("git worktree list --porcelain", `worktree /code/proj
+HEAD 1111111
+branch refs/heads/main
+
+worktree /code/proj-fix
+HEAD 2222222
+detached
+`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	worktrees, err := ListWorktrees()
+	if err != nil {
+		t.Fatalf("ListWorktrees() error = %v", err)
+	}
+	expected := []Worktree{
+		{Path: "/code/proj", Branch: "refs/heads/main", Commit: "1111111"},
+		{Path: "/code/proj-fix", Commit: "2222222"},
+	}
+	if !reflect.DeepEqual(worktrees, expected) {
+		t.Errorf("ListWorktrees() = %+v, want %+v", worktrees, expected)
+	}
+}
+
+func TestCreateAndDeleteWorktree(t *testing.T) {
+	repo := testrepo.New(t)
+
+	if err := CreateWorktree("proj-fix"); err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	path, err := GetWorktreePath("proj-fix")
+	if err != nil {
+		t.Fatalf("GetWorktreePath() error = %v", err)
+	}
+	if expected := filepath.Join(filepath.Dir(repo.Root), "proj-fix"); path != expected {
+		t.Errorf("worktree created at %q, want %q", path, expected)
+	}
+	if branch, err := GetBranch(path); err != nil || branch != "proj-fix" {
+		t.Errorf("GetBranch() = %q, %v, want proj-fix", branch, err)
+	}
+	if err := CreateWorktree("proj-fix"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateWorktree() of an existing worktree error = %v, want git's complaint", err)
+	}
+
+	if err := DeleteWorktree("proj-fix", true); err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after DeleteWorktree(): %v", err)
+	}
+	if branches := repo.Git("branch", "--list", "proj-fix"); branches != "" {
+		t.Errorf("branch still exists after DeleteWorktree(): %q", branches)
+	}
+}
+
+func TestWIP(t *testing.T) {
+	for _, stash := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stash %v", stash), func(t *testing.T) {
+			repo := testrepo.New(t)
+			path := repo.AddWorktree("proj-fix")
+
+			if saved, err := SaveWIP(path, stash); err != nil || saved {
+				t.Fatalf("SaveWIP() of a clean worktree = %v, %v, want nothing saved", saved, err)
+			}
+			if wip, err := FindWIP(path); err != nil || wip != nil {
+				t.Fatalf("FindWIP() before saving = %+v, %v, want none", wip, err)
+			}
+
+			if err := os.WriteFile(filepath.Join(path, "notes.txt"), []byte("half done\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if saved, err := SaveWIP(path, stash); err != nil || !saved {
+				t.Fatalf("SaveWIP() = %v, %v, want the new file saved", saved, err)
+			}
+			if count, _ := DirtyFileCount(path); count != 0 {
+				t.Errorf("%d uncommitted files left after SaveWIP()", count)
+			}
+			// Another worktree's stashes and commits aren't its
+			if wip, err := FindWIP(repo.Root); err != nil || wip != nil {
+				t.Errorf("FindWIP() in the main worktree = %+v, %v, want none", wip, err)
+			}
+
+			wip, err := FindWIP(path)
+			if err != nil || wip == nil || (wip.Stash != "") != stash {
+				t.Fatalf("FindWIP() = %+v, %v", wip, err)
+			}
+			if err := RestoreWIP(path, wip); err != nil {
+				t.Fatalf("RestoreWIP() error = %v", err)
+			}
+			if files, _ := UncommittedFiles(path); len(files) != 1 || !strings.HasSuffix(files[0], "notes.txt") {
+				t.Errorf("uncommitted files after RestoreWIP() = %q, want notes.txt back", files)
+			}
+			if wip, err := FindWIP(path); err != nil || wip != nil {
+				t.Errorf("FindWIP() after restoring = %+v, %v, want none", wip, err)
+			}
+		})
+	}
+}
+
+func BenchmarkListWorktrees(b *testing.B) {
+	repo := testrepo.New(b)
+	for i := 0; i < 40; i++ {
+		repo.AddWorktree(fmt.Sprintf("proj-task-%d", i))
+	}
+
+	for b.Loop() {
+		if _, err := ListWorktrees(); err != nil {
+			b.Fatalf("ListWorktrees() error = %v", err)
+		}
+	}
+}
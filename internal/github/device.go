@@ -0,0 +1,107 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// loginScopes are the scopes lfg's boards need on top of those gh logs in with
+const loginScopes = "project"
+
+var (
+	// userCodePattern finds the one-time code in gh auth login's output
+	userCodePattern = regexp.MustCompile(`one-time code: (\S+)`)
+	// verificationPattern finds where gh auth login says to enter the code
+	verificationPattern = regexp.MustCompile(`https://\S+`)
+)
+
+// DeviceCode is the one-time code gh auth login --web asks for, to be entered at
+// VerificationURI while it waits, so lfg can log gh in without leaving it
+type DeviceCode struct {
+	UserCode        string
+	VerificationURI string
+	login           *runner.Launched
+	exited          chan error    // gh's exit, once it's given up or been given the token
+	drained         chan struct{} // Closed once the rest of gh's output has been read
+	said            string        // The last thing gh said, for when it fails
+}
+
+// StartLogin runs gh auth login in the browser for github.com, asking for the project
+// scope too, and reads the code gh asks to be entered from what it prints. gh keeps the
+// token it's given itself, for gh auth token to hand out.
+func StartLogin() (*DeviceCode, error) {
+	launcher, ok := commands.(runner.Launcher)
+	if !ok {
+		return nil, fmt.Errorf("gh auth login can't be launched through %T", commands)
+	}
+	output, writer := io.Pipe()
+	// Without a terminal gh prints where to enter the code rather than opening a browser
+	job := runner.Job{Stdout: writer, Stderr: writer}
+	login, err := launcher.Launch(job, gh(), "auth", "login", "--hostname", "github.com", "--web", "--scopes", loginScopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run gh auth login: %w", err)
+	}
+	code := &DeviceCode{login: login, exited: make(chan error, 1), drained: make(chan struct{})}
+	go func() {
+		err := login.Wait()
+		writer.Close()
+		code.exited <- err
+	}()
+
+	lines := bufio.NewScanner(output)
+	for (code.UserCode == "" || code.VerificationURI == "") && lines.Scan() {
+		code.read(lines.Text())
+	}
+	// gh can't finish until what's left of its output has been read
+	go func() {
+		defer close(code.drained)
+		for lines.Scan() {
+			code.read(lines.Text())
+		}
+	}()
+	if code.UserCode == "" || code.VerificationURI == "" {
+		<-code.exited
+		<-code.drained
+		return nil, fmt.Errorf("gh auth login didn't give a code to enter: %s", code.said)
+	}
+	return code, nil
+}
+
+// read picks the code and where to enter it out of a line gh printed
+func (c *DeviceCode) read(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	c.said = line
+	if match := userCodePattern.FindStringSubmatch(line); match != nil && c.UserCode == "" {
+		c.UserCode = match[1]
+	}
+	if url := verificationPattern.FindString(line); url != "" && c.VerificationURI == "" {
+		c.VerificationURI = url
+	}
+}
+
+// Wait waits for gh to be given the token once the code has been entered, or for it to
+// give up. gh is stopped when ctx is done.
+func (c *DeviceCode) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		c.login.Kill()
+		<-c.exited
+		<-c.drained
+		return ctx.Err()
+	case err := <-c.exited:
+		<-c.drained
+		if err != nil {
+			return fmt.Errorf("gh auth login failed: %s", c.said)
+		}
+		return nil
+	}
+}
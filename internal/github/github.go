@@ -2,12 +2,83 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/checklist"
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// ghTimeout bounds a gh command, so a hung request fails instead of freezing lfg
+const ghTimeout = 30 * time.Second
+
+// commands runs gh, and is swapped out by tests
+var commands runner.Runner = runner.Exec{Timeout: ghTimeout}
+
+// SetRunner makes the package run gh through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// ghPathEnv is set by gh to its own path when it runs an extension
+const ghPathEnv = "GH_PATH"
+
+// extensionName is lfg's executable's name when it's installed as gh's lfg extension
+const extensionName = "gh-lfg"
+
+// gh is the gh to run: the one running lfg as an extension, or PATH's
+func gh() string {
+	if path := os.Getenv(ghPathEnv); path != "" {
+		return path
+	}
+	return "gh"
+}
+
+// Extension reports whether lfg is installed as gh's extension, to be run as gh lfg
+func Extension() bool {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == extensionName
+}
+
+// Errors from gh and GitHub that lfg can say what to do about
+var (
+	ErrGhUnauthenticated = errors.New("gh isn't logged in to GitHub")
+	ErrProjectNotFound   = errors.New("project not found")
 )
 
+// ghOutput runs gh with args and returns its stdout
+func ghOutput(args ...string) ([]byte, error) {
+	return ghInput(nil, args...)
+}
+
+// ghInput is ghOutput, feeding stdin to gh
+func ghInput(stdin io.Reader, args ...string) ([]byte, error) {
+	output, err := commands.Run(context.Background(), stdin, gh(), args...)
+	// gh auth reports on the login itself, its failures say what went wrong
+	if err != nil && args[0] != "auth" && unauthenticated(err) {
+		reason, _, _ := strings.Cut(strings.TrimSpace(runner.Stderr(err)), "\n")
+		return output, fmt.Errorf("%w (%s)", ErrGhUnauthenticated, reason)
+	}
+	return output, err
+}
+
+// unauthenticated reports whether gh failed for want of a login, or with a token GitHub
+// doesn't take
+func unauthenticated(err error) bool {
+	stderr := runner.Stderr(err)
+	return strings.Contains(stderr, "gh auth login") || strings.Contains(stderr, "HTTP 401")
+}
+
 type Project struct {
 	ID     string `json:"id"`
 	Number int    `json:"number"`
@@ -15,11 +86,17 @@ type Project struct {
 }
 
 type ProjectItem struct {
-	ID      string `json:"id"`
-	Title   string `json:"title"`
-	Status  string `json:"status"`
-	Body    string `json:"body"`
-	Content struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Priority  string    `json:"priority,omitempty"` // The Priority field's option on boards with one
+	Due       string    `json:"due,omitempty"`      // The due date field's date, as YYYY-MM-DD, on boards with one
+	Labels    []string  `json:"labels,omitempty"`   // The issue's labels, for items that are issues
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Worktree  string    `json:"-"`                // The worktree the tracker links the item to, for backends that record one
+	Parent    *ItemRef  `json:"parent,omitempty"` // The epic or parent issue it belongs to, for backends that track one
+	Content   struct {
 		Number int    `json:"number"`
 		Title  string `json:"title"`
 		Body   string `json:"body"`
@@ -27,51 +104,90 @@ type ProjectItem struct {
 	} `json:"content"`
 }
 
+// ItemRef points at another item, such as an item's parent issue, which needn't be on the
+// board itself
+type ItemRef struct {
+	Number int    `json:"number,omitempty"`
+	Title  string `json:"title"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Key identifies the item ref points at: by its URL, its number, or else its title
+func (ref ItemRef) Key() string {
+	switch {
+	case ref.URL != "":
+		return ref.URL
+	case ref.Number > 0:
+		return "#" + strconv.Itoa(ref.Number)
+	}
+	return "title:" + ref.Title
+}
+
+// Ref is a ref pointing at the item, as its children's parents do
+func (item *ProjectItem) Ref() ItemRef {
+	return ItemRef{Number: item.Content.Number, Title: item.Title, URL: item.Content.URL}
+}
+
 type RepoInfo struct {
 	Owner string
 	Name  string
 }
 
-// IsAuthenticated checks if gh CLI is authenticated
+// IsAuthenticated checks if gh has a token for github.com, whether it logged in for it or
+// was given one with GH_TOKEN
 func IsAuthenticated() bool {
-	cmd := exec.Command("gh", "auth", "status")
-	return cmd.Run() == nil
+	output, err := ghOutput("auth", "token", "-h", "github.com")
+	return err == nil && strings.TrimSpace(string(output)) != ""
 }
 
-// HasRequiredScopes checks if the token has project and repo scopes
+// HasRequiredScopes checks if gh's token has project and repo scopes. GitHub lists a
+// classic token's scopes in the X-OAuth-Scopes header of each response; fine-grained and
+// app tokens have none, their permissions aren't scopes, so they're left for the API to
+// refuse.
 func HasRequiredScopes() (bool, error) {
-	cmd := exec.Command("gh", "auth", "status", "-t")
-	output, err := cmd.Output()
+	output, err := ghOutput("api", "--include", "user")
 	if err != nil {
-		return false, nil
+		return false, fmt.Errorf("failed to ask GitHub for the token's scopes: %w", err)
 	}
+	scopes, ok := tokenScopes(output)
+	if !ok {
+		return true, nil
+	}
+	return scopes["project"] && scopes["repo"], nil
+}
 
-	// Parse output to check for scopes
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "Token scopes:") {
-			hasProject := strings.Contains(line, "'project'") || strings.Contains(line, "project")
-			hasRepo := strings.Contains(line, "'repo'") || strings.Contains(line, "repo")
-			return hasProject && hasRepo, nil
+// tokenScopes reads the scopes from a gh api --include response's headers. ok is false when
+// they aren't listed.
+func tokenScopes(response []byte) (scopes map[string]bool, ok bool) {
+	for _, line := range strings.Split(string(response), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break // The end of the headers
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found || !strings.EqualFold(name, "X-OAuth-Scopes") {
+			continue
+		}
+		scopes = map[string]bool{}
+		for _, scope := range strings.Split(value, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes[scope] = true
+			}
 		}
+		return scopes, true
 	}
-
-	return false, nil
+	return nil, false
 }
 
 // Authenticate triggers GitHub authentication with required scopes
 func Authenticate() error {
-	cmd := exec.Command("gh", "auth", "refresh", "-h", "github.com", "-s", "project", "-s", "repo")
-	cmd.Stdin = nil
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Run()
+	// gh prompts for the browser login, so it's given the terminal
+	return commands.Attach(gh(), "auth", "refresh", "-h", "github.com", "-s", "project", "-s", "repo")
 }
 
 // GetRepoInfo gets the current repository owner and name
 func GetRepoInfo() (*RepoInfo, error) {
-	cmd := exec.Command("gh", "repo", "view", "--json", "owner,name")
-	output, err := cmd.Output()
+	output, err := ghOutput("repo", "view", "--json", "owner,name")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repo info: %w", err)
 	}
@@ -227,13 +343,9 @@ func CreateProject(owner, repo, title string) (*Project, error) {
 }
 
 func runGraphQL(query string) ([]byte, error) {
-	cmd := exec.Command("gh", "api", "graphql", "-f", fmt.Sprintf("query=%s", query))
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	output, err := cmd.Output()
+	output, err := ghOutput("api", "graphql", "-f", fmt.Sprintf("query=%s", query))
 	if err != nil {
-		return nil, fmt.Errorf("GraphQL query failed: %s", stderr.String())
+		return nil, fmt.Errorf("GraphQL query failed: %w", err)
 	}
 
 	return output, nil
@@ -241,7 +353,28 @@ func runGraphQL(query string) ([]byte, error) {
 
 // ListProjectItems fetches all items from a GitHub Project
 func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, error) {
-	// First, get the project ID
+	projectID, err := FindProjectID(owner, repo, projectNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ProjectItem
+	cursor := ""
+	for {
+		page, next, err := ListProjectItemsPage(projectID, cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if next == "" {
+			return items, nil
+		}
+		cursor = next
+	}
+}
+
+// FindProjectID looks up the node ID of one of a repository's projects
+func FindProjectID(owner, repo string, projectNumber int) (string, error) {
 	projectQuery := fmt.Sprintf(`
 		query {
 			repository(owner: "%s", name: "%s") {
@@ -258,7 +391,7 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 
 	output, err := runGraphQL(projectQuery)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	var projectResult struct {
@@ -272,7 +405,7 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 	}
 
 	if err := json.Unmarshal(output, &projectResult); err != nil {
-		return nil, fmt.Errorf("failed to parse projects: %w", err)
+		return "", fmt.Errorf("failed to parse projects: %w", err)
 	}
 
 	// Find the project with the matching number
@@ -285,7 +418,20 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 	}
 
 	if projectID == "" {
-		return nil, fmt.Errorf("project #%d not found", projectNumber)
+		return "", fmt.Errorf("%w: #%d", ErrProjectNotFound, projectNumber)
+	}
+	return projectID, nil
+}
+
+// projectItemsPageSize is how many items ListProjectItemsPage fetches at once, GitHub's maximum
+const projectItemsPageSize = 100
+
+// ListProjectItemsPage fetches a page of a project's items, starting after the cursor
+// (empty for the first page). The returned cursor is empty on the last page.
+func ListProjectItemsPage(projectID, cursor string) ([]ProjectItem, string, error) {
+	after := "null"
+	if cursor != "" {
+		after = `"` + escapeString(cursor) + `"`
 	}
 
 	// Get the project items with status field
@@ -293,9 +439,14 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 		query {
 			node(id: "%s") {
 				... on ProjectV2 {
-					items(first: 100) {
+					items(first: %d, after: %s) {
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
 						nodes {
 							id
+							updatedAt
 							fieldValues(first: 10) {
 								nodes {
 									... on ProjectV2ItemFieldSingleSelectValue {
@@ -314,6 +465,14 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 											}
 										}
 									}
+									... on ProjectV2ItemFieldDateValue {
+										date
+										field {
+											... on ProjectV2FieldCommon {
+												name
+											}
+										}
+									}
 								}
 							}
 							content {
@@ -322,6 +481,23 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 									title
 									body
 									url
+									labels(first: 20) {
+										nodes {
+											name
+										}
+									}
+									parent {
+										number
+										title
+										url
+									}
+									trackedInIssues(first: 1) {
+										nodes {
+											number
+											title
+											url
+										}
+									}
 								}
 								... on DraftIssue {
 									title
@@ -333,23 +509,29 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 				}
 			}
 		}
-	`, projectID)
+	`, projectID, projectItemsPageSize, after)
 
-	output, err = runGraphQL(itemsQuery)
+	output, err := runGraphQL(itemsQuery)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var itemsResult struct {
 		Data struct {
 			Node struct {
 				Items struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
 					Nodes []struct {
-						ID          string `json:"id"`
+						ID          string    `json:"id"`
+						UpdatedAt   time.Time `json:"updatedAt"`
 						FieldValues struct {
 							Nodes []struct {
 								Name  string `json:"name"`
 								Text  string `json:"text"`
+								Date  string `json:"date"`
 								Field struct {
 									Name string `json:"name"`
 								} `json:"field"`
@@ -360,6 +542,15 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 							Title  string `json:"title"`
 							Body   string `json:"body"`
 							URL    string `json:"url"`
+							Labels struct {
+								Nodes []struct {
+									Name string `json:"name"`
+								} `json:"nodes"`
+							} `json:"labels"`
+							Parent          *ItemRef `json:"parent"`
+							TrackedInIssues struct {
+								Nodes []ItemRef `json:"nodes"`
+							} `json:"trackedInIssues"`
 						} `json:"content"`
 					} `json:"nodes"`
 				} `json:"items"`
@@ -368,30 +559,52 @@ func ListProjectItems(owner, repo string, projectNumber int) ([]ProjectItem, err
 	}
 
 	if err := json.Unmarshal(output, &itemsResult); err != nil {
-		return nil, fmt.Errorf("failed to parse project items: %w", err)
+		return nil, "", fmt.Errorf("failed to parse project items: %w", err)
 	}
 
 	// Convert to ProjectItem
 	var items []ProjectItem
 	for _, node := range itemsResult.Data.Node.Items.Nodes {
 		item := ProjectItem{
-			ID:      node.ID,
-			Title:   node.Content.Title,
-			Content: node.Content,
+			ID:        node.ID,
+			Title:     node.Content.Title,
+			UpdatedAt: node.UpdatedAt,
+		}
+		item.Content.Number = node.Content.Number
+		item.Content.Title = node.Content.Title
+		item.Content.Body = node.Content.Body
+		item.Content.URL = node.Content.URL
+		for _, label := range node.Content.Labels.Nodes {
+			item.Labels = append(item.Labels, label.Name)
+		}
+		// A sub-issue's parent, or else the first issue tracking it in a task list
+		item.Parent = node.Content.Parent
+		if item.Parent == nil && len(node.Content.TrackedInIssues.Nodes) > 0 {
+			item.Parent = &node.Content.TrackedInIssues.Nodes[0]
 		}
 
-		// Extract status from field values
+		// Extract status, priority and due date from field values
 		for _, fv := range node.FieldValues.Nodes {
-			if fv.Field.Name == "Status" {
+			switch fv.Field.Name {
+			case "Status":
 				item.Status = fv.Name
-				break
+			case "Priority":
+				item.Priority = fv.Name
+			default:
+				if fv.Date != "" && IsDueField(fv.Field.Name) {
+					item.Due = fv.Date
+				}
 			}
 		}
 
 		items = append(items, item)
 	}
 
-	return items, nil
+	pageInfo := itemsResult.Data.Node.Items.PageInfo
+	if !pageInfo.HasNextPage {
+		return items, "", nil
+	}
+	return items, pageInfo.EndCursor, nil
 }
 
 // CreateProjectItem creates a new item in a GitHub Project
@@ -441,7 +654,7 @@ func CreateProjectItem(owner, repo string, projectNumber int, title string) (*Pr
 	}
 
 	if projectID == "" {
-		return nil, fmt.Errorf("project #%d not found", projectNumber)
+		return nil, fmt.Errorf("%w: #%d", ErrProjectNotFound, projectNumber)
 	}
 
 	// Create a draft issue in the project
@@ -493,8 +706,127 @@ func CreateProjectItem(owner, repo string, projectNumber int, title string) (*Pr
 
 // UpdateProjectItemStatus updates the status of a project item
 func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID string, status string) error {
-	// First, get the project ID and status field ID
-	projectQuery := fmt.Sprintf(`
+	return UpdateProjectItemField(owner, repo, projectNumber, itemID, "Status", status)
+}
+
+// UpdateProjectItemField sets one of a project item's single select fields, like Status or
+// Priority, to the option named value, or clears it when value is ""
+func UpdateProjectItemField(owner, repo string, projectNumber int, itemID, fieldName, value string) error {
+	projectID, fields, err := projectFields(owner, repo, projectNumber)
+	if err != nil {
+		return err
+	}
+
+	field := findField(fields, func(f projectField) bool { return f.Name == fieldName })
+	if field == nil {
+		return fmt.Errorf("%s field not found in project", fieldName)
+	}
+	// Find the option matching the desired value
+	var optionID string
+	for _, option := range field.Options {
+		if option.Name == value {
+			optionID = option.ID
+			break
+		}
+	}
+	if optionID == "" && value != "" {
+		return fmt.Errorf("%s option '%s' not found", strings.ToLower(fieldName), value)
+	}
+
+	if err := setItemField(projectID, itemID, field.ID, fmt.Sprintf(`singleSelectOptionId: "%s"`, optionID), value == ""); err != nil {
+		return fmt.Errorf("failed to update item %s: %w", strings.ToLower(fieldName), err)
+	}
+	return nil
+}
+
+// IsDueField reports whether a project's date field holds its items' due dates, by its
+// name: Due or Due date
+func IsDueField(name string) bool {
+	return strings.EqualFold(name, "Due") || strings.EqualFold(name, "Due date")
+}
+
+// DueField returns the name of a project's due date field, "" when it has none
+func DueField(owner, repo string, projectNumber int) (string, error) {
+	_, fields, err := projectFields(owner, repo, projectNumber)
+	if err != nil {
+		return "", err
+	}
+	if field := findField(fields, isDueDateField); field != nil {
+		return field.Name, nil
+	}
+	return "", nil
+}
+
+// UpdateProjectItemDue sets a project item's due date field to date, as YYYY-MM-DD, or
+// clears it when date is ""
+func UpdateProjectItemDue(owner, repo string, projectNumber int, itemID, date string) error {
+	projectID, fields, err := projectFields(owner, repo, projectNumber)
+	if err != nil {
+		return err
+	}
+	field := findField(fields, isDueDateField)
+	if field == nil {
+		return fmt.Errorf("project #%d has no Due date field", projectNumber)
+	}
+	if err := setItemField(projectID, itemID, field.ID, fmt.Sprintf(`date: "%s"`, escapeString(date)), date == ""); err != nil {
+		return fmt.Errorf("failed to update item due date: %w", err)
+	}
+	return nil
+}
+
+// ListStatusOptions returns the options of a project's Status field, in board order
+func ListStatusOptions(owner, repo string, projectNumber int) ([]string, error) {
+	return ListFieldOptions(owner, repo, projectNumber, "Status")
+}
+
+// ListFieldOptions returns the options of one of a project's single select fields, in the
+// order the board lists them
+func ListFieldOptions(owner, repo string, projectNumber int, fieldName string) ([]string, error) {
+	_, fields, err := projectFields(owner, repo, projectNumber)
+	if err != nil {
+		return nil, err
+	}
+	field := findField(fields, func(f projectField) bool { return f.Name == fieldName })
+	if field == nil {
+		return nil, fmt.Errorf("project #%d has no %s field", projectNumber, fieldName)
+	}
+	options := make([]string, 0, len(field.Options))
+	for _, option := range field.Options {
+		options = append(options, option.Name)
+	}
+	return options, nil
+}
+
+// projectField is one of a project board's fields, with its options when it's a single
+// select field
+type projectField struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	DataType string `json:"dataType"`
+	Options  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"options"`
+}
+
+// isDueDateField reports whether f is the field a project keeps due dates in
+func isDueDateField(f projectField) bool {
+	return f.DataType == "DATE" && IsDueField(f.Name)
+}
+
+// findField returns the first of fields that match accepts, nil if there isn't one
+func findField(fields []projectField, match func(projectField) bool) *projectField {
+	for i := range fields {
+		if match(fields[i]) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// projectFields looks up a repository's project by number, returning its ID and fields
+func projectFields(owner, repo string, projectNumber int) (string, []projectField, error) {
+	query := fmt.Sprintf(`
 		query {
 			repository(owner: "%s", name: "%s") {
 				projectsV2(first: 10) {
@@ -503,9 +835,12 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 						number
 						fields(first: 20) {
 							nodes {
-								... on ProjectV2SingleSelectField {
+								... on ProjectV2FieldCommon {
 									id
 									name
+									dataType
+								}
+								... on ProjectV2SingleSelectField {
 									options {
 										id
 										name
@@ -519,12 +854,12 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 		}
 	`, owner, repo)
 
-	output, err := runGraphQL(projectQuery)
+	output, err := runGraphQL(query)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
-	var projectResult struct {
+	var result struct {
 		Data struct {
 			Repository struct {
 				ProjectsV2 struct {
@@ -532,14 +867,7 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 						ID     string `json:"id"`
 						Number int    `json:"number"`
 						Fields struct {
-							Nodes []struct {
-								ID      string `json:"id"`
-								Name    string `json:"name"`
-								Options []struct {
-									ID   string `json:"id"`
-									Name string `json:"name"`
-								} `json:"options"`
-							} `json:"nodes"`
+							Nodes []projectField `json:"nodes"`
 						} `json:"fields"`
 					} `json:"nodes"`
 				} `json:"projectsV2"`
@@ -547,44 +875,21 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 		} `json:"data"`
 	}
 
-	if err := json.Unmarshal(output, &projectResult); err != nil {
-		return fmt.Errorf("failed to parse projects: %w", err)
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", nil, fmt.Errorf("failed to parse projects: %w", err)
 	}
 
-	// Find the project and status field
-	var projectID, statusFieldID, statusOptionID string
-	for _, project := range projectResult.Data.Repository.ProjectsV2.Nodes {
+	for _, project := range result.Data.Repository.ProjectsV2.Nodes {
 		if project.Number == projectNumber {
-			projectID = project.ID
-			// Find the Status field
-			for _, field := range project.Fields.Nodes {
-				if field.Name == "Status" {
-					statusFieldID = field.ID
-					// Find the option matching the desired status
-					for _, option := range field.Options {
-						if option.Name == status {
-							statusOptionID = option.ID
-							break
-						}
-					}
-					break
-				}
-			}
-			break
+			return project.ID, project.Fields.Nodes, nil
 		}
 	}
+	return "", nil, fmt.Errorf("%w: #%d", ErrProjectNotFound, projectNumber)
+}
 
-	if projectID == "" {
-		return fmt.Errorf("project #%d not found", projectNumber)
-	}
-	if statusFieldID == "" {
-		return fmt.Errorf("Status field not found in project")
-	}
-	if statusOptionID == "" {
-		return fmt.Errorf("status option '%s' not found", status)
-	}
-
-	// Update the item status
+// setItemField sets a field of a project item to value, a field value such as
+// `date: "2026-10-20"`, or clears the field when unset is true
+func setItemField(projectID, itemID, fieldID, value string, unset bool) error {
 	mutation := fmt.Sprintf(`
 		mutation {
 			updateProjectV2ItemFieldValue(input: {
@@ -592,7 +897,7 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 				itemId: "%s"
 				fieldId: "%s"
 				value: {
-					singleSelectOptionId: "%s"
+					%s
 				}
 			}) {
 				projectV2Item {
@@ -600,11 +905,93 @@ func UpdateProjectItemStatus(owner, repo string, projectNumber int, itemID strin
 				}
 			}
 		}
-	`, projectID, itemID, statusFieldID, statusOptionID)
+	`, projectID, itemID, fieldID, value)
+	if unset {
+		mutation = fmt.Sprintf(`
+		mutation {
+			clearProjectV2ItemFieldValue(input: {
+				projectId: "%s"
+				itemId: "%s"
+				fieldId: "%s"
+			}) {
+				projectV2Item {
+					id
+				}
+			}
+		}
+	`, projectID, itemID, fieldID)
+	}
+
+	_, err := runGraphQL(mutation)
+	return err
+}
 
-	_, err = runGraphQL(mutation)
+// UpdateItemTitle renames a project item: the issue for items backed by one, otherwise the draft issue
+func UpdateItemTitle(owner, repo string, item *ProjectItem, title string) error {
+	if item.Content.Number > 0 {
+		if _, err := ghOutput("issue", "edit",
+			fmt.Sprintf("%d", item.Content.Number),
+			"--repo", fmt.Sprintf("%s/%s", owner, repo),
+			"--title", title); err != nil {
+			return fmt.Errorf("failed to update issue title: %w", err)
+		}
+		return nil
+	}
+
+	// Draft issues are edited by their own ID, not the project item's
+	draftQuery := fmt.Sprintf(`
+		query {
+			node(id: "%s") {
+				... on ProjectV2Item {
+					content {
+						... on DraftIssue {
+							id
+						}
+					}
+				}
+			}
+		}
+	`, item.ID)
+
+	output, err := runGraphQL(draftQuery)
 	if err != nil {
-		return fmt.Errorf("failed to update item status: %w", err)
+		return err
+	}
+
+	var draftResult struct {
+		Data struct {
+			Node struct {
+				Content struct {
+					ID string `json:"id"`
+				} `json:"content"`
+			} `json:"node"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(output, &draftResult); err != nil {
+		return fmt.Errorf("failed to parse draft issue: %w", err)
+	}
+
+	draftID := draftResult.Data.Node.Content.ID
+	if draftID == "" {
+		return fmt.Errorf("project item %s is not an issue or draft issue", item.ID)
+	}
+
+	mutation := fmt.Sprintf(`
+		mutation {
+			updateProjectV2DraftIssue(input: {
+				draftIssueId: "%s"
+				title: "%s"
+			}) {
+				draftIssue {
+					id
+				}
+			}
+		}
+	`, draftID, escapeString(title))
+
+	if _, err := runGraphQL(mutation); err != nil {
+		return fmt.Errorf("failed to update draft issue title: %w", err)
 	}
 
 	return nil
@@ -627,13 +1014,71 @@ type IssueComment struct {
 	} `json:"user"`
 }
 
+// IssueNumberFromURL extracts the issue number from a GitHub URL
+// e.g., "https://github.com/owner/repo/issues/123" -> 123
+func IssueNumberFromURL(url string) (int, error) {
+	if url == "" {
+		return 0, fmt.Errorf("empty GitHub URL")
+	}
+
+	// Remove trailing slash if present
+	url = strings.TrimSuffix(url, "/")
+
+	// Split by "/"
+	parts := strings.Split(url, "/")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid GitHub URL format")
+	}
+
+	// Last part should be the issue number
+	var issueNum int
+	if _, err := fmt.Sscanf(parts[len(parts)-1], "%d", &issueNum); err != nil {
+		return 0, fmt.Errorf("failed to parse issue number: %w", err)
+	}
+
+	return issueNum, nil
+}
+
+// Link is an issue or pull request a GitHub URL points at
+type Link struct {
+	Owner       string
+	Repo        string
+	Number      int
+	PullRequest bool
+}
+
+// ParseLink reads the issue or pull request out of a GitHub URL, e.g. one pasted from
+// chat, ignoring anything after the number like /files or #issuecomment-1
+func ParseLink(link string) (*Link, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host != "github.com" && u.Host != "www.github.com" {
+		return nil, fmt.Errorf("%q isn't a GitHub issue or pull request URL", link)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "issues" && parts[2] != "pull" {
+		return nil, fmt.Errorf("%q isn't a GitHub issue or pull request URL", link)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return nil, fmt.Errorf("%q isn't a GitHub issue or pull request URL", link)
+	}
+	return &Link{Owner: parts[0], Repo: parts[1], Number: number, PullRequest: parts[2] == "pull"}, nil
+}
+
+// URL is the link's canonical URL, as GitHub gives it for the issue or pull request
+func (l *Link) URL() string {
+	kind := "issues"
+	if l.PullRequest {
+		kind = "pull"
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/%s/%d", l.Owner, l.Repo, kind, l.Number)
+}
+
 // GetIssueComments fetches all comments for a GitHub issue
 func GetIssueComments(owner, repo string, issueNumber int) ([]IssueComment, error) {
-	cmd := exec.Command("gh", "api",
+	output, err := ghOutput("api",
 		fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, issueNumber),
 		"--jq", ".")
-
-	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get issue comments: %w", err)
 	}
@@ -646,6 +1091,81 @@ func GetIssueComments(owner, repo string, issueNumber int) ([]IssueComment, erro
 	return comments, nil
 }
 
+// Issue is the part of a GitHub issue the viewer shows
+type Issue struct {
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// GetIssue fetches a GitHub issue's title, body and when it was last updated
+func GetIssue(owner, repo string, issueNumber int) (*Issue, error) {
+	output, err := ghOutput("api",
+		fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, issueNumber),
+		"--jq", "{title, body: (.body // \"\"), updated_at}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+
+	var issue Issue
+	if err := json.Unmarshal(output, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// GetIssueBody fetches the current body of a GitHub issue
+func GetIssueBody(owner, repo string, issueNumber int) (string, error) {
+	output, err := ghOutput("api",
+		fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, issueNumber),
+		"--jq", ".body // \"\"")
+	if err != nil {
+		return "", fmt.Errorf("failed to get issue: %w", err)
+	}
+	// --jq prints the string with a trailing newline
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// UpdateIssueBody replaces the body of a GitHub issue
+func UpdateIssueBody(owner, repo string, issueNumber int, body string) error {
+	payloadBytes, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal issue body: %w", err)
+	}
+
+	if _, err := ghInput(bytes.NewReader(payloadBytes), "api",
+		fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, issueNumber),
+		"--method", "PATCH",
+		"--input", "-"); err != nil {
+		return fmt.Errorf("failed to update issue body: %w", err)
+	}
+
+	return nil
+}
+
+// SetIssueTask checks or unchecks the index'th item of an issue's task list, whose text is
+// text, returning the body it's saved with. The change is made to the issue's current body,
+// so edits made on GitHub in the meantime aren't lost; it's an error if they moved the item.
+func SetIssueTask(owner, repo string, issueNumber, index int, text string, checked bool) (string, error) {
+	body, err := GetIssueBody(owner, repo, issueNumber)
+	if err != nil {
+		return "", err
+	}
+
+	tasks := checklist.Parse(body)
+	if index >= len(tasks) || tasks[index].Text != text {
+		return "", fmt.Errorf("the checklist has changed on GitHub")
+	}
+	body, err = checklist.Set(body, index, checked)
+	if err != nil {
+		return "", err
+	}
+	if err := UpdateIssueBody(owner, repo, issueNumber, body); err != nil {
+		return "", err
+	}
+	return body, nil
+}
+
 // CreateIssueComment creates a new comment on a GitHub issue
 func CreateIssueComment(owner, repo string, issueNumber int, body string) error {
 	// Create a JSON payload
@@ -658,19 +1178,601 @@ func CreateIssueComment(owner, repo string, issueNumber int, body string) error
 		return fmt.Errorf("failed to marshal comment body: %w", err)
 	}
 
-	cmd := exec.Command("gh", "api",
+	if _, err := ghInput(bytes.NewReader(payloadBytes), "api",
 		fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, issueNumber),
 		"--method", "POST",
-		"--input", "-")
+		"--input", "-"); err != nil {
+		return fmt.Errorf("failed to create issue comment: %w", err)
+	}
+
+	return nil
+}
+
+// PullRequest represents a GitHub pull request
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+// FindPullRequestForBranch returns the open pull request for a branch, or nil if there isn't one
+func FindPullRequestForBranch(owner, repo, branch string) (*PullRequest, error) {
+	output, err := ghOutput("pr", "list",
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--head", branch,
+		"--state", "open",
+		"--json", "number,title,url,state",
+		"--limit", "1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var prs []PullRequest
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse pull requests: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// CreatePullRequest opens a pull request from head into base, returning its URL
+func CreatePullRequest(owner, repo, head, base, title, body string, draft bool) (string, error) {
+	args := []string{"pr", "create",
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--head", head,
+		"--base", base,
+		"--title", title,
+		"--body-file", "-"}
+	if draft {
+		args = append(args, "--draft")
+	}
+	output, err := ghInput(strings.NewReader(body), args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create pull request: %w", err)
+	}
+	// gh prints the new pull request's URL last
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1], nil
+}
+
+// Check is one CI check on a pull request
+type Check struct {
+	Name     string `json:"name"`
+	Workflow string `json:"workflow"`
+	State    string `json:"state"`
+	Bucket   string `json:"bucket"` // "pass", "fail", "pending", "skipping" or "cancel"
+	Link     string `json:"link"`
+}
+
+// ListPullRequestChecks returns the CI checks on a pull request's latest commit
+func ListPullRequestChecks(owner, repo string, number int) ([]Check, error) {
+	// gh exits non-zero when checks are failing or pending, but still prints them
+	output, err := ghOutput("pr", "checks", fmt.Sprintf("%d", number),
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--json", "name,workflow,state,bucket,link")
+	if err != nil && len(bytes.TrimSpace(output)) == 0 {
+		if strings.Contains(runner.Stderr(err), "no checks reported") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pull request checks: %w", err)
+	}
+
+	var checks []Check
+	if err := json.Unmarshal(output, &checks); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request checks: %w", err)
+	}
+	return checks, nil
+}
+
+// CI outcomes, summarising a pull request's checks
+const (
+	CIPassed  = "pass"
+	CIFailed  = "fail"
+	CIPending = "pending"
+)
+
+// ciRollup is a pull request as `gh pr list --json headRefName,statusCheckRollup`
+// describes it. Its checks are check runs, with a status and conclusion, or commit
+// statuses, with a state.
+type ciRollup struct {
+	HeadRefName string `json:"headRefName"`
+	Checks      []struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		State      string `json:"state"`
+	} `json:"statusCheckRollup"`
+}
+
+// ListPullRequestCI returns the CI outcome of each open pull request in a repository, by
+// branch, in one request. Pull requests without checks are left out.
+func ListPullRequestCI(owner, repo string) (map[string]string, error) {
+	output, err := ghOutput("pr", "list",
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--state", "open",
+		"--json", "headRefName,statusCheckRollup",
+		"--limit", "100")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var prs []ciRollup
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse pull requests: %w", err)
+	}
+	outcomes := make(map[string]string, len(prs))
+	for _, pr := range prs {
+		if outcome := pr.outcome(); outcome != "" {
+			outcomes[pr.HeadRefName] = outcome
+		}
+	}
+	return outcomes, nil
+}
+
+// outcome is failed if any check failed, pending while any are still running, and passed
+// once they've all finished without failing
+func (pr ciRollup) outcome() string {
+	outcome := ""
+	for _, check := range pr.Checks {
+		result := check.Conclusion
+		if check.State != "" {
+			result = check.State
+		} else if check.Status != "COMPLETED" {
+			result = "PENDING"
+		}
+		switch result {
+		case "FAILURE", "ERROR", "TIMED_OUT", "CANCELLED", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			return CIFailed
+		case "PENDING", "EXPECTED":
+			outcome = CIPending
+		default:
+			if outcome == "" {
+				outcome = CIPassed
+			}
+		}
+	}
+	return outcome
+}
+
+// ReviewStatus is where review of an open pull request stands
+type ReviewStatus struct {
+	Decision   string // "APPROVED", "CHANGES_REQUESTED" or "REVIEW_REQUIRED", empty when no review is required
+	Unresolved int    // Review threads not yet resolved
+}
+
+// ListPullRequestReviews returns where review stands on each open pull request in a
+// repository, by branch, in one request
+func ListPullRequestReviews(owner, repo string) (map[string]ReviewStatus, error) {
+	query := fmt.Sprintf(`
+		query {
+			repository(owner: "%s", name: "%s") {
+				pullRequests(states: OPEN, first: 100) {
+					nodes {
+						headRefName
+						reviewDecision
+						reviewThreads(first: 100) {
+							nodes {
+								isResolved
+							}
+						}
+					}
+				}
+			}
+		}
+	`, owner, repo)
+
+	output, err := runGraphQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull request reviews: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			Repository struct {
+				PullRequests struct {
+					Nodes []struct {
+						HeadRefName    string `json:"headRefName"`
+						ReviewDecision string `json:"reviewDecision"`
+						ReviewThreads  struct {
+							Nodes []struct {
+								IsResolved bool `json:"isResolved"`
+							} `json:"nodes"`
+						} `json:"reviewThreads"`
+					} `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request reviews: %w", err)
+	}
+
+	reviews := make(map[string]ReviewStatus)
+	for _, pr := range result.Data.Repository.PullRequests.Nodes {
+		status := ReviewStatus{Decision: pr.ReviewDecision}
+		for _, thread := range pr.ReviewThreads.Nodes {
+			if !thread.IsResolved {
+				status.Unresolved++
+			}
+		}
+		reviews[pr.HeadRefName] = status
+	}
+	return reviews, nil
+}
+
+// PullRequestDetails is a pull request to check out and review
+type PullRequestDetails struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	URL         string `json:"url"`
+	State       string `json:"state"`       // "OPEN", "CLOSED" or "MERGED"
+	HeadRefName string `json:"headRefName"` // The branch the pull request is from
+	HeadRefOid  string `json:"headRefOid"`  // The commit the branch is at
+}
+
+// GetPullRequest fetches a pull request's title, description, state and branch
+func GetPullRequest(owner, repo string, number int) (*PullRequestDetails, error) {
+	output, err := ghOutput("pr", "view", fmt.Sprintf("%d", number),
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--json", "number,title,body,url,state,headRefName,headRefOid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	var pr PullRequestDetails
+	if err := json.Unmarshal(output, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// reviewStates label the reviews that approve or request changes
+var reviewStates = map[string]string{
+	"APPROVED":          "Approved",
+	"CHANGES_REQUESTED": "Requested changes",
+	"DISMISSED":         "Dismissed review",
+}
+
+// GetReviewComments fetches a pull request's reviews and the comments left on its diff, as
+// comments the viewer can show alongside the conversation. Comments on the diff start with
+// the line they're on.
+func GetReviewComments(owner, repo string, number int) ([]IssueComment, error) {
+	output, err := ghOutput("api", fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	var reviews []struct {
+		IssueComment
+		State       string `json:"state"`
+		SubmittedAt string `json:"submitted_at"`
+	}
+	if err := json.Unmarshal(output, &reviews); err != nil {
+		return nil, fmt.Errorf("failed to parse reviews: %w", err)
+	}
+
+	output, err = ghOutput("api", fmt.Sprintf("/repos/%s/%s/pulls/%d/comments", owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review comments: %w", err)
+	}
+	var lineComments []struct {
+		IssueComment
+		Path string `json:"path"`
+		Line int    `json:"line"`
+	}
+	if err := json.Unmarshal(output, &lineComments); err != nil {
+		return nil, fmt.Errorf("failed to parse review comments: %w", err)
+	}
 
-	cmd.Stdin = bytes.NewReader(payloadBytes)
+	var comments []IssueComment
+	for _, review := range reviews {
+		comment := review.IssueComment
+		comment.CreatedAt = review.SubmittedAt
+		// Plain reviews without a summary only hold the comments listed below
+		if label, ok := reviewStates[review.State]; ok {
+			comment.Body = strings.TrimSpace("**" + label + "**\n\n" + comment.Body)
+		} else if strings.TrimSpace(comment.Body) == "" {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	for _, line := range lineComments {
+		comment := line.IssueComment
+		where := line.Path
+		if line.Line > 0 {
+			where = fmt.Sprintf("%s:%d", line.Path, line.Line)
+		}
+		comment.Body = "`" + where + "`\n\n" + comment.Body
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+// IssueStatuses are the statuses issues move between when there's no project board. An open
+// issue is in progress while it carries the in-progress label, and closed issues are done.
+var IssueStatuses = []string{"Todo", "In Progress", "Done"}
+
+// maxIssues is how many of a repository's most recently updated issues are listed as items
+const maxIssues = 300
+
+// repoIssue is an issue as `gh issue list --json` describes it
+type repoIssue struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url"`
+	State     string    `json:"state"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create issue comment: %s", stderr.String())
+// ListIssues fetches a repository's issues as items, taking their status from their state
+// and the in-progress label
+func ListIssues(owner, repo, inProgressLabel string) ([]ProjectItem, error) {
+	output, err := ghOutput("issue", "list",
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--state", "all",
+		"--search", "sort:updated-desc",
+		"--limit", fmt.Sprintf("%d", maxIssues),
+		"--json", "id,number,title,body,url,state,updatedAt,labels")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
 	}
 
+	var issues []repoIssue
+	if err := json.Unmarshal(output, &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse issues: %w", err)
+	}
+	return issueItems(issues, inProgressLabel), nil
+}
+
+// issueItems converts issues to items, so they show in the TUI like project items do
+func issueItems(issues []repoIssue, inProgressLabel string) []ProjectItem {
+	items := make([]ProjectItem, 0, len(issues))
+	for _, issue := range issues {
+		item := ProjectItem{
+			ID:        issue.ID,
+			Title:     issue.Title,
+			Status:    "Todo",
+			UpdatedAt: issue.UpdatedAt,
+		}
+		item.Content.Number = issue.Number
+		item.Content.Title = issue.Title
+		item.Content.Body = issue.Body
+		item.Content.URL = issue.URL
+
+		for _, label := range issue.Labels {
+			if label.Name != inProgressLabel {
+				item.Labels = append(item.Labels, label.Name)
+			} else if !strings.EqualFold(issue.State, "closed") {
+				item.Status = "In Progress"
+			}
+		}
+		if strings.EqualFold(issue.State, "closed") {
+			item.Status = "Done"
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// CreateIssue opens an issue titled title, returning it as an item
+func CreateIssue(owner, repo, title string) (*ProjectItem, error) {
+	output, err := ghOutput("issue", "create",
+		"--repo", fmt.Sprintf("%s/%s", owner, repo),
+		"--title", title,
+		"--body", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	// gh prints the new issue's URL
+	url := strings.TrimSpace(string(output))
+	number, err := IssueNumberFromURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the new issue's number from %q: %w", url, err)
+	}
+
+	item := &ProjectItem{Title: title, Status: "Todo"}
+	item.Content.Number = number
+	item.Content.Title = title
+	item.Content.URL = url
+	return item, nil
+}
+
+// SetIssueStatus moves an issue to one of IssueStatuses by opening or closing it and adding or
+// removing the in-progress label
+func SetIssueStatus(owner, repo string, item *ProjectItem, inProgressLabel, status string) error {
+	repoName := fmt.Sprintf("%s/%s", owner, repo)
+	number := fmt.Sprintf("%d", item.Content.Number)
+	if item.Content.Number == 0 {
+		return fmt.Errorf("%q is not an issue", item.Title)
+	}
+
+	switch {
+	case status == "Done" && item.Status != "Done":
+		if err := runGH("failed to close issue", "issue", "close", number, "--repo", repoName); err != nil {
+			return err
+		}
+	case status != "Done" && item.Status == "Done":
+		if err := runGH("failed to reopen issue", "issue", "reopen", number, "--repo", repoName); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case status == "In Progress" && item.Status != "In Progress":
+		add := []string{"issue", "edit", number, "--repo", repoName, "--add-label", inProgressLabel}
+		if err := runGH("failed to label issue", add...); err != nil {
+			// The label may not exist in the repository yet
+			if createErr := runGH("failed to create label", "label", "create", inProgressLabel, "--repo", repoName); createErr != nil {
+				return err
+			}
+			return runGH("failed to label issue", add...)
+		}
+	case status != "In Progress" && item.Status == "In Progress":
+		return runGH("failed to unlabel issue", "issue", "edit", number, "--repo", repoName, "--remove-label", inProgressLabel)
+	}
+	return nil
+}
+
+// EditIssueLabels adds and removes labels on an issue, creating added labels the repository
+// doesn't have yet
+func EditIssueLabels(owner, repo string, number int, add, remove []string) error {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	repoName := fmt.Sprintf("%s/%s", owner, repo)
+	args := []string{"issue", "edit", fmt.Sprintf("%d", number), "--repo", repoName}
+	if len(add) > 0 {
+		args = append(args, "--add-label", strings.Join(add, ","))
+	}
+	if len(remove) > 0 {
+		args = append(args, "--remove-label", strings.Join(remove, ","))
+	}
+	err := runGH("failed to label issue", args...)
+	if err == nil || len(add) == 0 {
+		return err
+	}
+	// Labels may not exist in the repository yet
+	for _, label := range add {
+		runGH("failed to create label", "label", "create", label, "--repo", repoName)
+	}
+	return runGH("failed to label issue", args...)
+}
+
+// RepoEvent is something that happened in a repository, from its recent events
+type RepoEvent struct {
+	Type      string    // The kind of event, e.g. "PullRequestEvent"
+	Actor     string    // Who did it
+	CreatedAt time.Time // When
+	Action    string    // What was done, e.g. "closed", for the events that have one
+	Number    int       // The issue or pull request it happened to, if any
+	Title     string    // The issue's or pull request's title
+	Pull      bool      // It happened to a pull request
+	Merged    bool      // With a closed pull request, whether it was merged
+	Branch    string    // The pull request's branch, or the branch pushed to
+	Review    string    // With a review, its state, e.g. "approved"
+	URL       string    // The comment, review, issue or pull request
+}
+
+// ListRepoEvents fetches a repository's recent events, newest first
+func ListRepoEvents(owner, repo string) ([]RepoEvent, error) {
+	output, err := ghOutput("api", fmt.Sprintf("/repos/%s/%s/events?per_page=100", owner, repo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository events: %w", err)
+	}
+
+	type issue struct {
+		Number      int             `json:"number"`
+		Title       string          `json:"title"`
+		URL         string          `json:"html_url"`
+		Merged      bool            `json:"merged"`
+		PullRequest json.RawMessage `json:"pull_request"` // Issues that are pull requests have one
+		Head        struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	}
+	var raw []struct {
+		Type  string `json:"type"`
+		Actor struct {
+			Login string `json:"login"`
+		} `json:"actor"`
+		CreatedAt time.Time `json:"created_at"`
+		Payload   struct {
+			Action      string `json:"action"`
+			Ref         string `json:"ref"`
+			Issue       *issue `json:"issue"`
+			PullRequest *issue `json:"pull_request"`
+			Comment     struct {
+				URL string `json:"html_url"`
+			} `json:"comment"`
+			Review struct {
+				State string `json:"state"`
+				URL   string `json:"html_url"`
+			} `json:"review"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse repository events: %w", err)
+	}
+
+	events := make([]RepoEvent, 0, len(raw))
+	for _, r := range raw {
+		event := RepoEvent{
+			Type:      r.Type,
+			Actor:     r.Actor.Login,
+			CreatedAt: r.CreatedAt,
+			Action:    r.Payload.Action,
+			Branch:    strings.TrimPrefix(r.Payload.Ref, "refs/heads/"),
+			Review:    strings.ToLower(r.Payload.Review.State),
+		}
+		if subject := r.Payload.PullRequest; subject != nil {
+			event.Number, event.Title, event.URL, event.Pull = subject.Number, subject.Title, subject.URL, true
+			event.Merged, event.Branch = subject.Merged, subject.Head.Ref
+		} else if subject := r.Payload.Issue; subject != nil {
+			event.Number, event.Title, event.URL = subject.Number, subject.Title, subject.URL
+			event.Pull = len(subject.PullRequest) > 0 && string(subject.PullRequest) != "null"
+		}
+		if r.Payload.Comment.URL != "" {
+			event.URL = r.Payload.Comment.URL
+		} else if r.Payload.Review.URL != "" {
+			event.URL = r.Payload.Review.URL
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Summary describes the event, e.g. "merged pull request #12 Fix login", or is "" for events
+// not worth showing, such as stars and forks
+func (e RepoEvent) Summary() string {
+	subject := fmt.Sprintf("issue #%d %s", e.Number, e.Title)
+	if e.Pull {
+		subject = fmt.Sprintf("pull request #%d %s", e.Number, e.Title)
+	}
+	switch e.Type {
+	case "PullRequestEvent":
+		if e.Action == "closed" && e.Merged {
+			return "merged " + subject
+		}
+		if e.Action == "opened" || e.Action == "closed" || e.Action == "reopened" {
+			return e.Action + " " + subject
+		}
+	case "IssuesEvent":
+		if e.Action == "opened" || e.Action == "closed" || e.Action == "reopened" {
+			return e.Action + " " + subject
+		}
+	case "IssueCommentEvent":
+		if e.Action == "created" {
+			return "commented on " + subject
+		}
+	case "PullRequestReviewEvent":
+		switch e.Review {
+		case "approved":
+			return "approved " + subject
+		case "changes_requested":
+			return "requested changes on " + subject
+		}
+		return "reviewed " + subject
+	case "PullRequestReviewCommentEvent":
+		return "commented on the diff of " + subject
+	case "PushEvent":
+		return "pushed to " + e.Branch
+	}
+	return ""
+}
+
+// runGH runs a gh command, describing a failure with what and gh's own message
+func runGH(what string, args ...string) error {
+	if _, err := ghOutput(args...); err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
 	return nil
 }
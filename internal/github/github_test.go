@@ -1,7 +1,14 @@
 package github
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/markcipolla/lfg/internal/runner"
 )
 
 func TestEscapeString(t *testing.T) {
@@ -46,3 +53,663 @@ func TestEscapeString(t *testing.T) {
 		})
 	}
 }
+
+func TestIssueNumberFromURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		expected    int
+		expectError bool
+	}{
+		{
+			name:        "valid GitHub issue URL",
+			url:         "https://github.com/owner/repo/issues/123",
+			expected:    123,
+			expectError: false,
+		},
+		{
+			name:        "valid GitHub issue URL with trailing slash",
+			url:         "https://github.com/owner/repo/issues/456/",
+			expected:    456,
+			expectError: false,
+		},
+		{
+			name:        "empty URL",
+			url:         "",
+			expected:    0,
+			expectError: true,
+		},
+		{
+			name:        "invalid URL format",
+			url:         "not-a-url",
+			expected:    0,
+			expectError: true,
+		},
+		{
+			name:        "URL without issue number",
+			url:         "https://github.com/owner/repo/issues/",
+			expected:    0,
+			expectError: true,
+		},
+		{
+			name:        "URL with non-numeric issue",
+			url:         "https://github.com/owner/repo/issues/abc",
+			expected:    0,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := IssueNumberFromURL(tt.url)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("IssueNumberFromURL(%q) expected error, got nil", tt.url)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("IssueNumberFromURL(%q) unexpected error: %v", tt.url, err)
+				}
+				if result != tt.expected {
+					t.Errorf("IssueNumberFromURL(%q) = %d, want %d", tt.url, result, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestIssueItems(t *testing.T) {
+	output := `[
+		{"id":"I_1","number":1,"title":"Open","state":"OPEN","labels":[]},
+		{"id":"I_2","number":2,"title":"Started","state":"OPEN","labels":[{"name":"bug"},{"name":"in-progress"}]},
+		{"id":"I_3","number":3,"title":"Finished","state":"CLOSED","labels":[{"name":"in-progress"}],"url":"https://github.com/o/r/issues/3"}
+	]`
+	var issues []repoIssue
+	if err := json.Unmarshal([]byte(output), &issues); err != nil {
+		t.Fatalf("failed to parse issues: %v", err)
+	}
+
+	items := issueItems(issues, "in-progress")
+	expected := []struct {
+		status string
+		number int
+	}{
+		{status: "Todo", number: 1},
+		{status: "In Progress", number: 2},
+		{status: "Done", number: 3},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("issueItems() returned %d items, want %d", len(items), len(expected))
+	}
+	for i, item := range items {
+		if item.Status != expected[i].status || item.Content.Number != expected[i].number {
+			t.Errorf("issueItems()[%d] = %s #%d, want %s #%d", i, item.Status, item.Content.Number, expected[i].status, expected[i].number)
+		}
+	}
+	if items[2].Content.URL != "https://github.com/o/r/issues/3" || items[2].Title != "Finished" {
+		t.Errorf("issueItems()[2] = %+v, want the issue's title and URL", items[2])
+	}
+	// The in-progress label is the status, not one of the issue's labels
+	if !reflect.DeepEqual(items[1].Labels, []string{"bug"}) || items[2].Labels != nil {
+		t.Errorf("issueItems() labels = %v and %v, want [bug] and none", items[1].Labels, items[2].Labels)
+	}
+}
+
+func TestListProjectItemsPageParents(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh api graphql", `{"data": {"node": {"items": {"pageInfo": {"hasNextPage": false}, "nodes": [
+		{"id": "PVTI_1", "content": {"number": 4, "title": "Login form", "parent": {"number": 1, "title": "Accounts", "url": "https://github.com/o/r/issues/1"}, "trackedInIssues": {"nodes": []}}},
+		{"id": "PVTI_2", "content": {"number": 5, "title": "Sessions", "parent": null, "trackedInIssues": {"nodes": [{"number": 2, "title": "Auth", "url": "https://github.com/o/r/issues/2"}]}}},
+		{"id": "PVTI_3", "content": {"number": 1, "title": "Accounts", "parent": null, "trackedInIssues": {"nodes": []}}},
+		{"id": "PVTI_4", "content": {"title": "A draft"}}
+	]}}}}`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	items, cursor, err := ListProjectItemsPage("P_1", "")
+	if err != nil || cursor != "" || len(items) != 4 {
+		t.Fatalf("ListProjectItemsPage() = %v, %q, %v", items, cursor, err)
+	}
+	expected := []*ItemRef{
+		{Number: 1, Title: "Accounts", URL: "https://github.com/o/r/issues/1"},
+		{Number: 2, Title: "Auth", URL: "https://github.com/o/r/issues/2"},
+		nil,
+		nil,
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(items[i].Parent, want) {
+			t.Errorf("items[%d].Parent = %+v, want %+v", i, items[i].Parent, want)
+		}
+	}
+}
+
+func TestListPullRequestChecks(t *testing.T) {
+	failed := errors.New("exit status 8")
+	tests := []struct {
+		name     string
+		stdout   string
+		err      error
+		expected int    // Number of checks
+		errLike  string // Expected in the error, empty for success
+	}{
+		{
+			name:     "passing",
+			stdout:   `[{"name":"test","bucket":"pass"}]`,
+			expected: 1,
+		},
+		{
+			name:     "failing checks still print",
+			stdout:   `[{"name":"test","bucket":"fail"},{"name":"lint","bucket":"pass"}]`,
+			err:      &runner.Error{Command: "gh pr checks", Err: failed},
+			expected: 2,
+		},
+		{
+			name: "no checks",
+			err:  &runner.Error{Command: "gh pr checks", Stderr: "no checks reported on the 'fix' branch", Err: failed},
+		},
+		{
+			name:    "gh's message in the error",
+			err:     &runner.Error{Command: "gh pr checks", Stderr: "HTTP 401: Bad credentials", Err: failed},
+			errLike: "Bad credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("gh pr checks 7", tt.stdout, tt.err)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			checks, err := ListPullRequestChecks("owner", "repo", 7)
+			if tt.errLike != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errLike) {
+					t.Errorf("ListPullRequestChecks() error = %v, want one mentioning %q", err, tt.errLike)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListPullRequestChecks() error = %v", err)
+			}
+			if len(checks) != tt.expected {
+				t.Errorf("ListPullRequestChecks() = %d checks, want %d", len(checks), tt.expected)
+			}
+		})
+	}
+}
+
+func TestListPullRequestCI(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh pr list --repo owner/repo --state open", `[
+		{"headRefName": "passing", "statusCheckRollup": [
+			{"status": "COMPLETED", "conclusion": "SUCCESS"},
+			{"status": "COMPLETED", "conclusion": "SKIPPED"},
+			{"state": "SUCCESS"}
+		]},
+		{"headRefName": "running", "statusCheckRollup": [
+			{"status": "COMPLETED", "conclusion": "SUCCESS"},
+			{"status": "IN_PROGRESS", "conclusion": ""}
+		]},
+		{"headRefName": "failing", "statusCheckRollup": [
+			{"status": "IN_PROGRESS", "conclusion": ""},
+			{"status": "COMPLETED", "conclusion": "FAILURE"}
+		]},
+		{"headRefName": "status-error", "statusCheckRollup": [{"state": "ERROR"}]},
+		{"headRefName": "no-checks", "statusCheckRollup": []}
+	]`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	outcomes, err := ListPullRequestCI("owner", "repo")
+	if err != nil {
+		t.Fatalf("ListPullRequestCI() error = %v", err)
+	}
+	expected := map[string]string{"passing": CIPassed, "running": CIPending, "failing": CIFailed, "status-error": CIFailed}
+	if !reflect.DeepEqual(outcomes, expected) {
+		t.Errorf("ListPullRequestCI() = %v, want %v", outcomes, expected)
+	}
+}
+
+func TestGetReviewComments(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh api /repos/owner/repo/pulls/7/reviews", `[
+		{"id": 1, "user": {"login": "ana"}, "state": "CHANGES_REQUESTED", "body": "Needs a test", "submitted_at": "2026-01-02T10:00:00Z"},
+		{"id": 2, "user": {"login": "ana"}, "state": "COMMENTED", "body": "", "submitted_at": "2026-01-02T10:00:00Z"},
+		{"id": 3, "user": {"login": "bo"}, "state": "APPROVED", "body": "", "submitted_at": "2026-01-03T10:00:00Z"}
+	]`, nil)
+	fake.On("gh api /repos/owner/repo/pulls/7/comments", `[
+		{"id": 4, "user": {"login": "ana"}, "path": "login.go", "line": 12, "body": "Check the error", "created_at": "2026-01-02T09:59:00Z"}
+	]`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	comments, err := GetReviewComments("owner", "repo", 7)
+	if err != nil {
+		t.Fatalf("GetReviewComments() error = %v", err)
+	}
+	var got []string
+	for _, comment := range comments {
+		got = append(got, comment.User.Login+" "+comment.CreatedAt+" "+comment.Body)
+	}
+	expected := []string{
+		"ana 2026-01-02T10:00:00Z **Requested changes**\n\nNeeds a test",
+		"bo 2026-01-03T10:00:00Z **Approved**",
+		"ana 2026-01-02T09:59:00Z `login.go:12`\n\nCheck the error",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetReviewComments() = %q, want %q", got, expected)
+	}
+}
+
+func TestSetIssueStatus(t *testing.T) {
+	missingLabel := &runner.Error{Command: "gh issue edit", Stderr: "'in-progress' not found", Err: errors.New("exit status 1")}
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		setup    func(fake *runner.Fake)
+		expected []string // The gh commands run, in order
+	}{
+		{
+			name:     "start",
+			from:     "Todo",
+			to:       "In Progress",
+			expected: []string{"gh issue edit 3 --repo owner/repo --add-label in-progress"},
+		},
+		{
+			name: "start without the label",
+			from: "Todo",
+			to:   "In Progress",
+			setup: func(fake *runner.Fake) {
+				fake.On("gh issue edit 3 --repo owner/repo --add-label in-progress", "", missingLabel)
+			},
+			expected: []string{
+				"gh issue edit 3 --repo owner/repo --add-label in-progress",
+				"gh label create in-progress --repo owner/repo",
+				"gh issue edit 3 --repo owner/repo --add-label in-progress",
+			},
+		},
+		{
+			name: "finish",
+			from: "In Progress",
+			to:   "Done",
+			expected: []string{
+				"gh issue close 3 --repo owner/repo",
+				"gh issue edit 3 --repo owner/repo --remove-label in-progress",
+			},
+		},
+		{
+			name:     "reopen",
+			from:     "Done",
+			to:       "Todo",
+			expected: []string{"gh issue reopen 3 --repo owner/repo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			if tt.setup != nil {
+				tt.setup(fake)
+			}
+			fake.On("gh", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			item := &ProjectItem{Title: "Bug", Status: tt.from}
+			item.Content.Number = 3
+			err := SetIssueStatus("owner", "repo", item, "in-progress", tt.to)
+			// The fake can't tell a retry from the first try, so the missing label stays missing
+			if tt.setup == nil && err != nil {
+				t.Fatalf("SetIssueStatus() error = %v", err)
+			}
+
+			var calls []string
+			for _, call := range fake.Calls() {
+				calls = append(calls, call.String())
+			}
+			if strings.Join(calls, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("SetIssueStatus() ran\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
+
+func TestListPullRequestReviews(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh api graphql", `{"data": {"repository": {"pullRequests": {"nodes": [
+		{"headRefName": "fix-login", "reviewDecision": "CHANGES_REQUESTED", "reviewThreads": {"nodes": [{"isResolved": false}, {"isResolved": true}, {"isResolved": false}]}},
+		{"headRefName": "add-docs", "reviewDecision": "APPROVED", "reviewThreads": {"nodes": []}},
+		{"headRefName": "spike", "reviewDecision": null, "reviewThreads": {"nodes": []}}
+	]}}}}`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	reviews, err := ListPullRequestReviews("owner", "repo")
+	if err != nil {
+		t.Fatalf("ListPullRequestReviews() error = %v", err)
+	}
+	expected := map[string]ReviewStatus{
+		"fix-login": {Decision: "CHANGES_REQUESTED", Unresolved: 2},
+		"add-docs":  {Decision: "APPROVED"},
+		"spike":     {},
+	}
+	if !reflect.DeepEqual(reviews, expected) {
+		t.Errorf("ListPullRequestReviews() = %+v, want %+v", reviews, expected)
+	}
+}
+
+func TestUpdateProjectItemDue(t *testing.T) {
+	fields := `{"data": {"repository": {"projectsV2": {"nodes": [{"id": "P_1", "number": 3, "fields": {"nodes": [
+		{"id": "F_status", "name": "Status", "dataType": "SINGLE_SELECT", "options": [{"id": "O_todo", "name": "Todo"}]},
+		{"id": "F_start", "name": "Start date", "dataType": "DATE"},
+		{"id": "F_due", "name": "Due date", "dataType": "DATE"}
+	]}}]}}}}`
+	tests := []struct {
+		name     string
+		date     string
+		mutation string // What the mutation sets the field with
+	}{
+		{name: "set", date: "2026-10-20", mutation: `updateProjectV2ItemFieldValue(input: {
+				projectId: "P_1"
+				itemId: "I_1"
+				fieldId: "F_due"
+				value: {
+					date: "2026-10-20"
+				}`},
+		{name: "clear", mutation: `clearProjectV2ItemFieldValue(input: {
+				projectId: "P_1"
+				itemId: "I_1"
+				fieldId: "F_due"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("gh api graphql", fields, nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			if err := UpdateProjectItemDue("owner", "repo", 3, "I_1", tt.date); err != nil {
+				t.Fatalf("UpdateProjectItemDue() error = %v", err)
+			}
+			calls := fake.Calls()
+			if len(calls) != 2 || !strings.Contains(strings.Join(calls[1].Args, " "), tt.mutation) {
+				t.Errorf("UpdateProjectItemDue() ran %v, want a mutation with %s", calls, tt.mutation)
+			}
+		})
+	}
+
+	fake := &runner.Fake{}
+	fake.On("gh api graphql", fields, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+	if name, err := DueField("owner", "repo", 3); err != nil || name != "Due date" {
+		t.Errorf("DueField() = %q, %v, want Due date", name, err)
+	}
+}
+
+func TestEditIssueLabels(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh issue edit 3 --repo owner/repo --add-label bug,auth --remove-label ui", "", errors.New("'auth' not found"))
+	fake.On("gh label create", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	if err := EditIssueLabels("owner", "repo", 3, []string{"bug", "auth"}, []string{"ui"}); err == nil {
+		t.Error("EditIssueLabels() succeeded when gh kept failing")
+	}
+	var commands []string
+	for _, call := range fake.Calls() {
+		commands = append(commands, strings.Join(call.Args, " "))
+	}
+	expected := []string{
+		"gh issue edit 3 --repo owner/repo --add-label bug,auth --remove-label ui",
+		"gh label create bug --repo owner/repo",
+		"gh label create auth --repo owner/repo",
+		"gh issue edit 3 --repo owner/repo --add-label bug,auth --remove-label ui",
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("EditIssueLabels() ran %q, want %q", commands, expected)
+	}
+
+	// Nothing to change runs nothing
+	fake = &runner.Fake{}
+	SetRunner(fake)
+	if err := EditIssueLabels("owner", "repo", 3, nil, nil); err != nil || len(fake.Calls()) != 0 {
+		t.Errorf("EditIssueLabels() with no changes = %v, ran %d commands", err, len(fake.Calls()))
+	}
+}
+
+func TestListRepoEvents(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh api /repos/owner/repo/events", `[
+		{"type": "PullRequestEvent", "actor": {"login": "sam"}, "created_at": "2026-10-12T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 5, "title": "Fix crash", "merged": true, "html_url": "https://github.com/owner/repo/pull/5", "head": {"ref": "proj-crash"}}}},
+		{"type": "IssueCommentEvent", "actor": {"login": "agent-bot"}, "created_at": "2026-10-11T09:00:00Z", "payload": {"action": "created", "issue": {"number": 3, "title": "Add login", "html_url": "https://github.com/owner/repo/issues/3"}, "comment": {"html_url": "https://github.com/owner/repo/issues/3#issuecomment-1"}}},
+		{"type": "PullRequestReviewEvent", "actor": {"login": "kim"}, "created_at": "2026-10-10T09:00:00Z", "payload": {"action": "created", "review": {"state": "CHANGES_REQUESTED", "html_url": "https://github.com/owner/repo/pull/5#review-1"}, "pull_request": {"number": 5, "title": "Fix crash", "head": {"ref": "proj-crash"}}}},
+		{"type": "WatchEvent", "actor": {"login": "fan"}, "created_at": "2026-10-09T09:00:00Z", "payload": {"action": "started"}}
+	]`, nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	events, err := ListRepoEvents("owner", "repo")
+	if err != nil {
+		t.Fatalf("ListRepoEvents() error = %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("ListRepoEvents() = %d events, want 4", len(events))
+	}
+	if merged := events[0]; !merged.Pull || !merged.Merged || merged.Branch != "proj-crash" || merged.Actor != "sam" || merged.CreatedAt.IsZero() {
+		t.Errorf("events[0] = %+v, want sam's merged pull request", merged)
+	}
+	if comment := events[1]; comment.URL != "https://github.com/owner/repo/issues/3#issuecomment-1" || comment.Pull {
+		t.Errorf("events[1] = %+v, want the comment's URL on the issue", comment)
+	}
+
+	summaries := make([]string, len(events))
+	for i, event := range events {
+		summaries[i] = event.Summary()
+	}
+	expected := []string{
+		"merged pull request #5 Fix crash",
+		"commented on issue #3 Add login",
+		"requested changes on pull request #5 Fix crash",
+		"",
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("Summary() = %q, want %q", summaries, expected)
+	}
+}
+
+func TestSetIssueTask(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("gh api /repos/owner/repo/issues/3 --jq", "Steps:\n- [ ] One\n- [ ] Two\n", nil)
+	fake.On("gh api /repos/owner/repo/issues/3 --method PATCH", "{}", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	body, err := SetIssueTask("owner", "repo", 3, 1, "Two", true)
+	if err != nil {
+		t.Fatalf("SetIssueTask() error = %v", err)
+	}
+	if expected := "Steps:\n- [ ] One\n- [x] Two"; body != expected {
+		t.Errorf("SetIssueTask() = %q, want %q", body, expected)
+	}
+	calls := fake.Calls()
+	if last := calls[len(calls)-1]; last.Stdin != `{"body":"Steps:\n- [ ] One\n- [x] Two"}` {
+		t.Errorf("SetIssueTask() saved %s", last.Stdin)
+	}
+
+	// The checklist changed on GitHub since it was read
+	if _, err := SetIssueTask("owner", "repo", 3, 1, "Three", true); err == nil {
+		t.Error("SetIssueTask() of an item that has changed succeeded")
+	}
+}
+
+func TestHasRequiredScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected bool
+	}{
+		{
+			name:     "classic token with both",
+			response: "HTTP/2.0 200 OK\r\nX-Oauth-Scopes: gist, project, read:org, repo\r\n\r\n{\"login\":\"octocat\"}",
+			expected: true,
+		},
+		{
+			name:     "read only project scope",
+			response: "HTTP/2.0 200 OK\r\nX-Oauth-Scopes: read:project, repo\r\n\r\n{}",
+			expected: false,
+		},
+		{
+			name:     "scopes in the body aren't headers",
+			response: "HTTP/2.0 200 OK\r\nX-Oauth-Scopes: repo\r\n\r\nX-Oauth-Scopes: project, repo",
+			expected: false,
+		},
+		{
+			name:     "fine-grained token lists none",
+			response: "HTTP/2.0 200 OK\r\nContent-Type: application/json\r\n\r\n{}",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("gh api --include user", tt.response, nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+			if ok, err := HasRequiredScopes(); err != nil || ok != tt.expected {
+				t.Errorf("HasRequiredScopes() = %v, %v, want %v", ok, err, tt.expected)
+			}
+		})
+	}
+
+	// gh is run from where gh says it is, as an extension
+	t.Setenv(ghPathEnv, "/opt/gh/bin/gh")
+	fake := &runner.Fake{}
+	fake.On("/opt/gh/bin/gh auth token", "gho_token\n", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+	if !IsAuthenticated() {
+		t.Errorf("IsAuthenticated() ran %v, want %s's gh", fake.Calls(), ghPathEnv)
+	}
+}
+
+func TestStartLogin(t *testing.T) {
+	said := "! First copy your one-time code: ABCD-1234\nOpen this URL to continue in your web browser: https://github.com/login/device\n"
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		wantErr string // Expected in Wait's error, empty for a login
+	}{
+		{name: "code entered", output: said + "✓ Authentication complete.\n"},
+		{name: "refused", output: said + "failed to authenticate via web browser: access_denied\n", err: failed, wantErr: "access_denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("gh auth login", tt.output, tt.err)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			code, err := StartLogin()
+			if err != nil {
+				t.Fatalf("StartLogin() error = %v", err)
+			}
+			if code.UserCode != "ABCD-1234" || code.VerificationURI != "https://github.com/login/device" {
+				t.Errorf("code = %+v, want the code and URL to show", code)
+			}
+			if calls := fake.Calls(); calls[0].String() != "gh auth login --hostname github.com --web --scopes project" {
+				t.Errorf("gh ran %v, want a login in the browser with the project scope", calls)
+			}
+
+			err = code.Wait(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Wait() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Wait() error = %v, want gh's reason", err)
+			}
+		})
+	}
+
+	// gh giving no code says why
+	fake := &runner.Fake{}
+	fake.On("gh auth login", "The value of the GH_TOKEN environment variable is being used for authentication.\n", failed)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+	if _, err := StartLogin(); err == nil || !strings.Contains(err.Error(), "GH_TOKEN") {
+		t.Errorf("StartLogin() error = %v, want gh's reason", err)
+	}
+}
+
+func TestUnauthenticated(t *testing.T) {
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		name     string
+		stderr   string
+		expected bool
+	}{
+		{name: "not logged in", stderr: "To get started with GitHub CLI, please run:  gh auth login", expected: true},
+		{name: "token GitHub doesn't take", stderr: "HTTP 401: Bad credentials (https://api.github.com/repos/owner/repo/issues/7)", expected: true},
+		{name: "other failures", stderr: "HTTP 404: Not Found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("gh api", "", &runner.Error{Command: "gh api", Stderr: tt.stderr, Err: failed})
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			_, err := GetIssue("owner", "repo", 7)
+			if err == nil {
+				t.Fatal("GetIssue() succeeded, want an error")
+			}
+			if got := errors.Is(err, ErrGhUnauthenticated); got != tt.expected {
+				t.Errorf("GetIssue() error = %v, ErrGhUnauthenticated %v, want %v", err, got, tt.expected)
+			}
+		})
+	}
+
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    Link
+		wantURL string
+		wantErr bool
+	}{
+		{link: "https://github.com/owner/repo/issues/12", want: Link{Owner: "owner", Repo: "repo", Number: 12}, wantURL: "https://github.com/owner/repo/issues/12"},
+		{link: "  https://github.com/owner/repo/pull/7/files?w=1 \n", want: Link{Owner: "owner", Repo: "repo", Number: 7, PullRequest: true}, wantURL: "https://github.com/owner/repo/pull/7"},
+		{link: "https://www.github.com/owner/repo/issues/3#issuecomment-99", want: Link{Owner: "owner", Repo: "repo", Number: 3}, wantURL: "https://github.com/owner/repo/issues/3"},
+		{link: "https://github.com/owner/repo", wantErr: true},
+		{link: "https://github.com/owner/repo/discussions/4", wantErr: true},
+		{link: "https://github.com/owner/repo/issues/new", wantErr: true},
+		{link: "https://gitlab.com/owner/repo/issues/12", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			got, err := ParseLink(tt.link)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *got != tt.want || got.URL() != tt.wantURL {
+				t.Errorf("ParseLink() = %+v (%s), want %+v (%s)", *got, got.URL(), tt.want, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Entry is one thing lfg did, as a line of the history file
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`               // What happened, one of core's event kinds
+	Actor    string    `json:"actor"`              // The user who did it
+	Via      string    `json:"via"`                // The command it was done through, e.g. "lfg prune", or "lfg" for the TUI
+	Worktree string    `json:"worktree,omitempty"` // The worktree it happened to, if any
+	Title    string    `json:"title,omitempty"`    // The item's or todo's title, if any
+	URL      string    `json:"url,omitempty"`      // The item's issue or pull request, if any
+	Status   string    `json:"status,omitempty"`   // With status changes, the status moved to
+	From     string    `json:"from,omitempty"`     // With status changes, the status moved from
+	Detail   string    `json:"detail,omitempty"`   // More about what happened, e.g. the pane that crashed
+}
+
+// Via is the command entries are recorded as done through. main sets it for subcommands.
+var Via = filepath.Base(os.Args[0])
+
+// path returns the history file
+func path(configPath string) string {
+	return filepath.Join(state.Dir(configPath), "history.jsonl")
+}
+
+// Append adds an entry to the end of the history, filling in its actor and command when
+// they're empty. The file is only ever appended to, a line at a time, so several lfg
+// processes can record at once.
+func Append(configPath string, entry Entry) error {
+	if entry.Actor == "" {
+		entry.Actor = actor()
+	}
+	if entry.Via == "" {
+		entry.Via = Via
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	file := path(configPath)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Load returns the history, oldest first. Lines that can't be read, such as one cut short
+// when the disk filled up, are skipped.
+func Load(configPath string) ([]Entry, error) {
+	f, err := os.Open(path(configPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Follow calls handle with each entry appended to the history from now on, looking for
+// more every interval until ctx is done. A line is handled once it's whole, so one
+// partway through being written isn't skipped.
+func Follow(ctx context.Context, configPath string, interval time.Duration, handle func(Entry)) error {
+	file := path(configPath)
+	var offset int64
+	if info, err := os.Stat(file); err == nil {
+		offset = info.Size()
+	}
+	var partial []byte
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		f, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open history: %w", err)
+		}
+		if info, err := f.Stat(); err == nil && info.Size() < offset {
+			offset, partial = 0, nil // Started again
+		}
+		data, err := io.ReadAll(io.NewSectionReader(f, offset, 1<<62))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+		offset += int64(len(data))
+		partial = append(partial, data...)
+		for {
+			line, rest, ok := bytes.Cut(partial, []byte("\n"))
+			if !ok {
+				break
+			}
+			partial = rest
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err == nil {
+				handle(entry)
+			}
+		}
+	}
+}
+
+// actor is the user lfg is running as
+func actor() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	entries, err := Load(configPath)
+	if err != nil || entries != nil {
+		t.Fatalf("Load() without a history = %v, %v, want nothing", entries, err)
+	}
+
+	created := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	if err := Append(configPath, Entry{Time: created, Kind: "worktree_created", Worktree: "proj-login", Via: "lfg"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := Append(configPath, Entry{Time: created.Add(time.Hour), Kind: "status_changed", Worktree: "proj-login", Status: "Done", From: "In Progress", Actor: "sam"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	entries, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Load() = %d entries, want 2", len(entries))
+	}
+	if first := entries[0]; first.Kind != "worktree_created" || !first.Time.Equal(created) || first.Actor == "" {
+		t.Errorf("first entry = %+v, want the worktree created, with the user filled in", first)
+	}
+	if second := entries[1]; second.Actor != "sam" || second.Via != Via || second.Status != "Done" || second.From != "In Progress" {
+		t.Errorf("second entry = %+v, want sam's status change through %s", second, Via)
+	}
+}
+
+func TestLoadSkipsBrokenLines(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := Append(configPath, Entry{Kind: "session_killed", Worktree: "proj-login"}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	// A line cut short partway through
+	f, err := os.OpenFile(path(configPath), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"kind": "worktree_del`)
+	f.Close()
+
+	entries, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Kind != "session_killed" {
+		t.Errorf("Load() = %+v, want only the whole entry", entries)
+	}
+}
+
+func TestAppendConcurrently(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := os.MkdirAll(state.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			if err := Append(configPath, Entry{Kind: "status_changed", Worktree: "proj-login"}); err != nil {
+				t.Errorf("Append() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if entries, err := Load(configPath); err != nil || len(entries) != 20 {
+		t.Errorf("Load() = %d entries, %v, want all 20", len(entries), err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := Append(configPath, Entry{Kind: "worktree_created", Worktree: "proj-old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	followed := make(chan Entry)
+	done := make(chan error)
+	go func() {
+		done <- Follow(ctx, configPath, 10*time.Millisecond, func(entry Entry) { followed <- entry })
+	}()
+	next := func() Entry {
+		t.Helper()
+		select {
+		case entry := <-followed:
+			return entry
+		case <-time.After(5 * time.Second):
+			t.Fatal("Follow() didn't handle the entry appended")
+			return Entry{}
+		}
+	}
+
+	// Give Follow time to find the end of what's there already
+	time.Sleep(50 * time.Millisecond)
+	if err := Append(configPath, Entry{Kind: "pane_crashed", Worktree: "proj-new", Detail: "server (npm start) exit status 1"}); err != nil {
+		t.Fatal(err)
+	}
+	if entry := next(); entry.Worktree != "proj-new" || entry.Detail != "server (npm start) exit status 1" {
+		t.Errorf("followed %+v, want proj-new's crash and not what was there before", entry)
+	}
+
+	// A line written in two goes is handled once it's whole
+	f, err := os.OpenFile(path(configPath), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"kind": "status_changed", `)
+	time.Sleep(50 * time.Millisecond)
+	f.WriteString(`"worktree": "proj-new", "status": "Done"}` + "\n")
+	f.Close()
+	if entry := next(); entry.Kind != "status_changed" || entry.Status != "Done" {
+		t.Errorf("followed %+v, want the status change", entry)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Follow() error = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package humanize
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Ago formats the time elapsed since t compactly, e.g. "just now", "5m ago", "2h ago", "12d ago"
+func Ago(t time.Time) string {
+	return since(time.Since(t))
+}
+
+func since(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// DaysUntil counts the calendar days from now until date, a YYYY-MM-DD date, negative once
+// it has passed. ok is false when date isn't a date.
+func DaysUntil(date string, now time.Time) (days int, ok bool) {
+	due, err := time.ParseInLocation(time.DateOnly, date, now.Location())
+	if err != nil {
+		return 0, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(math.Round(due.Sub(today).Hours() / 24)), true
+}
+
+// Due describes a due date by the days until it, e.g. "due today", "due in 3d" or "overdue by 2d"
+func Due(days int) string {
+	switch {
+	case days < 0:
+		return fmt.Sprintf("overdue by %dd", -days)
+	case days == 0:
+		return "due today"
+	case days == 1:
+		return "due tomorrow"
+	default:
+		return fmt.Sprintf("due in %dd", days)
+	}
+}
+
+// Bytes formats a size compactly, e.g. "512 B", "1.5 KB" or "2.3 GB"
+func Bytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, unit := float64(n)/1024, 0
+	for size >= 1024 && unit < 4 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %cB", size, "KMGTP"[unit])
+}
+
+// Duration formats a length of time in its two largest units, e.g. "35m", "5h 10m" or
+// "2d 4h"
+func Duration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours()/24), int(d.Hours())%24)
+	}
+}
@@ -0,0 +1,84 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{name: "seconds", duration: 30 * time.Second, expected: "just now"},
+		{name: "minutes", duration: 5 * time.Minute, expected: "5m ago"},
+		{name: "hours", duration: 2*time.Hour + 10*time.Minute, expected: "2h ago"},
+		{name: "days", duration: 12 * 24 * time.Hour, expected: "12d ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := since(tt.duration); got != tt.expected {
+				t.Errorf("since(%v) = %q, want %q", tt.duration, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2026, 10, 14, 23, 30, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		date string
+		days int
+		ok   bool
+	}{
+		{name: "today", date: "2026-10-14", days: 0, ok: true},
+		{name: "tomorrow", date: "2026-10-15", days: 1, ok: true},
+		{name: "passed", date: "2026-10-11", days: -3, ok: true},
+		{name: "across a month", date: "2026-11-02", days: 19, ok: true},
+		{name: "not a date", date: "next week", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, ok := DaysUntil(tt.date, now)
+			if days != tt.days || ok != tt.ok {
+				t.Errorf("DaysUntil(%q) = %d, %v, want %d, %v", tt.date, days, ok, tt.days, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	tests := map[int]string{-2: "overdue by 2d", 0: "due today", 1: "due tomorrow", 5: "due in 5d"}
+	for days, expected := range tests {
+		if got := Due(days); got != expected {
+			t.Errorf("Due(%d) = %q, want %q", days, got, expected)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := map[int64]string{0: "0 B", 512: "512 B", 1536: "1.5 KB", 5 * 1024 * 1024: "5.0 MB", 2469606195: "2.3 GB"}
+	for n, expected := range tests {
+		if got := Bytes(n); got != expected {
+			t.Errorf("Bytes(%d) = %q, want %q", n, got, expected)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:             "<1m",
+		35 * time.Minute:             "35m",
+		5*time.Hour + 10*time.Minute: "5h 10m",
+		52 * time.Hour:               "2d 4h",
+	}
+	for d, expected := range tests {
+		if got := Duration(d); got != expected {
+			t.Errorf("Duration(%v) = %q, want %q", d, got, expected)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package i18n translates the text of the TUI, the viewer and the init wizard. Messages
+// are written in English in the code and looked up as they're written in a language's
+// catalog, so one a catalog doesn't have yet is shown in English.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// catalogs are the built-in languages' messages, locales/<language>.yaml, where each
+// English message maps to its translation
+//
+//go:embed locales/*.yaml
+var catalogs embed.FS
+
+var (
+	mu       sync.RWMutex
+	messages map[string]string // The current language's; nil for English
+)
+
+// Use switches to a language's messages, e.g. "es" or "pt_BR", or to the environment's
+// when language is "", as LC_ALL, LC_MESSAGES or LANG name it. overrides replace single
+// messages whatever the language, such as a glyph the terminal's font lacks. A language
+// with no catalog is English, which is an error only when it's asked for by name.
+func Use(language string, overrides map[string]string) error {
+	named := language != ""
+	if !named {
+		language = environmentLanguage()
+	}
+	catalog, err := load(language)
+	if err != nil && !named {
+		err = nil
+	}
+	if catalog == nil && len(overrides) > 0 {
+		catalog = map[string]string{}
+	}
+	for message, translation := range overrides {
+		catalog[message] = translation
+	}
+
+	mu.Lock()
+	messages = catalog
+	mu.Unlock()
+	return err
+}
+
+// load reads a language's catalog, the language with its region first, e.g. "pt_BR",
+// then without, "pt". English has none.
+func load(language string) (map[string]string, error) {
+	language = strings.ReplaceAll(language, "-", "_")
+	base, _, _ := strings.Cut(language, "_")
+	if base == "en" {
+		return nil, nil
+	}
+	for _, name := range slices.Compact([]string{language, base}) {
+		data, err := catalogs.ReadFile(path.Join("locales", name+".yaml"))
+		if err != nil {
+			continue
+		}
+		var catalog map[string]string
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to read the %s messages: %w", name, err)
+		}
+		return catalog, nil
+	}
+	return nil, fmt.Errorf("no messages in %s, have %s; using English", language, strings.Join(Languages(), ", "))
+}
+
+// environmentLanguage is the language the environment asks for, e.g. "de_DE" from
+// LANG=de_DE.UTF-8, or "" for C and POSIX
+func environmentLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			language, _, _ := strings.Cut(value, ".")
+			language, _, _ = strings.Cut(language, "@")
+			if language == "C" || language == "POSIX" {
+				return ""
+			}
+			return language
+		}
+	}
+	return ""
+}
+
+// Languages lists the languages with built-in messages, English first
+func Languages() []string {
+	languages := []string{"en"}
+	entries, _ := catalogs.ReadDir("locales")
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	return languages
+}
+
+// T is message in the current language, formatted with args as fmt.Sprintf does when
+// there are any
+func T(message string, args ...any) string {
+	mu.RLock()
+	if translation, ok := messages[message]; ok {
+		message = translation
+	}
+	mu.RUnlock()
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUse(t *testing.T) {
+	t.Cleanup(func() { Use("en", nil) })
+
+	tests := []struct {
+		name      string
+		language  string
+		env       string
+		overrides map[string]string
+		want      string
+		wantErr   bool
+	}{
+		{name: "english", language: "en", want: "Moved proj-login to Done"},
+		{name: "named", language: "es", want: "proj-login pasa a Done"},
+		{name: "with a region", language: "es-MX", want: "proj-login pasa a Done"},
+		{name: "from the environment", env: "es_ES.UTF-8", want: "proj-login pasa a Done"},
+		{name: "C locale", env: "C", want: "Moved proj-login to Done"},
+		{name: "unknown in the environment", env: "xx_YY.UTF-8", want: "Moved proj-login to Done"},
+		{name: "unknown by name", language: "xx", want: "Moved proj-login to Done", wantErr: true},
+		{name: "override", language: "es", overrides: map[string]string{"Moved %s to %s": "%s → %s"}, want: "proj-login → Done"},
+		{name: "override in English", language: "en", overrides: map[string]string{"Moved %s to %s": "%s → %s"}, want: "proj-login → Done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_MESSAGES", "")
+			t.Setenv("LANG", tt.env)
+			if err := Use(tt.language, tt.overrides); (err != nil) != tt.wantErr {
+				t.Fatalf("Use(%q) error = %v, wantErr %v", tt.language, err, tt.wantErr)
+			}
+			if got := T("Moved %s to %s", "proj-login", "Done"); got != tt.want {
+				t.Errorf("T() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	t.Cleanup(func() { Use("en", nil) })
+	if err := Use("es", map[string]string{"Saved": "Stored"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := T("Saved"); got != "Stored" {
+		t.Errorf("T(Saved) = %q, want the override", got)
+	}
+	// A message with no translation is shown as it's written
+	if got := T("Not a message %d"); got != "Not a message %d" {
+		t.Errorf("T() = %q, want it unchanged", got)
+	}
+}
+
+// The translations have to take the same arguments as their messages, in the same order
+func TestCatalogsKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for _, language := range Languages()[1:] {
+		data, err := catalogs.ReadFile(path.Join("locales", language+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var catalog map[string]string
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			t.Fatalf("%s: %v", language, err)
+		}
+		if len(catalog) == 0 {
+			t.Errorf("%s has no messages", language)
+		}
+		for message, translation := range catalog {
+			if want, got := verbs.FindAllString(message, -1), verbs.FindAllString(translation, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q has verbs %v, want %v as %q has", language, translation, got, want, message)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package issuecache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Entry is what the viewer last fetched for an issue
+type Entry struct {
+	UpdatedAt string                `json:"updated_at"` // The issue's updated_at when cached, empty to force a refetch
+	Body      string                `json:"body"`
+	Comments  []github.IssueComment `json:"comments"`
+}
+
+// Fresh reports whether the entry is still current for an issue last updated at updatedAt.
+// Comments update the issue's updated_at too, so it covers the whole thread.
+func (e *Entry) Fresh(updatedAt string) bool {
+	return e != nil && e.UpdatedAt != "" && e.UpdatedAt == updatedAt
+}
+
+// path returns the cache file for an issue
+func path(configPath, owner, repo string, number int) string {
+	return filepath.Join(state.Dir(configPath), "cache", "issues", fmt.Sprintf("%s-%s-%d.json", owner, repo, number))
+}
+
+// Load returns the cached entry for an issue, or nil if it hasn't been cached
+func Load(configPath, owner, repo string, number int) (*Entry, error) {
+	data, err := os.ReadFile(path(configPath, owner, repo, number))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issue cache: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse issue cache: %w", err)
+	}
+	return &entry, nil
+}
+
+// Save caches an entry for an issue
+func Save(configPath, owner, repo string, number int, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal issue cache: %w", err)
+	}
+
+	file := path(configPath, owner, repo, number)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write issue cache: %w", err)
+	}
+	return nil
+}
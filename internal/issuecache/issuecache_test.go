@@ -0,0 +1,61 @@
+package issuecache
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	entry, err := Load(configPath, "owner", "repo", 12)
+	if err != nil {
+		t.Fatalf("Load() before Save() error = %v", err)
+	}
+	if entry != nil {
+		t.Fatalf("Load() before Save() = %+v, want nil", entry)
+	}
+
+	comment := github.IssueComment{ID: 1, Body: "Looks good", CreatedAt: "2026-10-14T10:00:00Z"}
+	comment.User.Login = "alice"
+	saved := Entry{UpdatedAt: "2026-10-14T11:00:00Z", Body: "- [ ] Login works", Comments: []github.IssueComment{comment}}
+	if err := Save(configPath, "owner", "repo", 12, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	entry, err = Load(configPath, "owner", "repo", 12)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if entry == nil || !reflect.DeepEqual(*entry, saved) {
+		t.Errorf("Load() = %+v, want %+v", entry, saved)
+	}
+	if other, _ := Load(configPath, "owner", "repo", 13); other != nil {
+		t.Errorf("Load() of another issue = %+v, want nil", other)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		entry     *Entry
+		updatedAt string
+		expected  bool
+	}{
+		{name: "not cached", entry: nil, updatedAt: "2026-10-14T11:00:00Z", expected: false},
+		{name: "unchanged", entry: &Entry{UpdatedAt: "2026-10-14T11:00:00Z"}, updatedAt: "2026-10-14T11:00:00Z", expected: true},
+		{name: "updated since", entry: &Entry{UpdatedAt: "2026-10-14T11:00:00Z"}, updatedAt: "2026-10-14T12:00:00Z", expected: false},
+		{name: "refetch forced", entry: &Entry{}, updatedAt: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.entry.Fresh(tt.updatedAt); result != tt.expected {
+				t.Errorf("Fresh(%q) = %v, want %v", tt.updatedAt, result, tt.expected)
+			}
+		})
+	}
+}
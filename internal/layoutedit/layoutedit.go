@@ -0,0 +1,651 @@
+package layoutedit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// step is how many percent a resize moves a border by, and the least a row or pane is left
+const step = 5
+
+// pane is a pane being edited
+type pane struct {
+	name     string
+	command  string
+	width    int // Percent of what its row's fixed panes leave
+	columns  int // Its width in columns when it's fixed, 0 when it's a percentage
+	min, max int // Its min_width and max_width, kept as they are
+}
+
+// row is a row of panes being edited, split left to right
+type row struct {
+	height   int // Percent of what fixed rows leave of the work area below the agent's pane
+	lines    int // Its height in lines when it's fixed, 0 when it's a percentage
+	min, max int // Its min_height and max_height, kept as they are
+	panes    []pane
+}
+
+// Input modes
+const (
+	editNone    = ""
+	editName    = "name"
+	editCommand = "command"
+)
+
+type model struct {
+	cfg      *config.Config
+	name     string // The entry in layouts being edited, "" for layout
+	rows     []row
+	row, col int // The selected pane
+	editing  string
+	input    textinput.Model
+	dirty    bool // There are changes that haven't been written
+	quitting bool // q was pressed with unwritten changes
+	message  string
+	width    int
+	height   int
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
+	selectedStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("212"))
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("241"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// applyTheme recolours the editor's styles
+func applyTheme(theme config.Theme) {
+	titleStyle = titleStyle.Foreground(lipgloss.Color(theme.Accent))
+	selectedStyle = selectedStyle.BorderForeground(lipgloss.Color(theme.Highlight))
+	paneStyle = paneStyle.BorderForeground(lipgloss.Color(theme.Muted))
+	dimStyle = dimStyle.Foreground(lipgloss.Color(theme.Muted))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.Muted))
+}
+
+// Run opens the editor on the config's layout, or the named entry in its layouts, which is
+// created if there isn't one, writing it back to the config when asked to
+func Run(cfg *config.Config, name string) error {
+	theme, err := cfg.ResolveTheme()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the default theme\n", err)
+	}
+	applyTheme(theme)
+
+	layout := cfg.GetLayout()
+	if name != "" {
+		layout = cfg.Layouts[name]
+	}
+	m := newModel(cfg, name, layout)
+	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func newModel(cfg *config.Config, name string, layout []config.LayoutRow) *model {
+	input := textinput.New()
+	input.CharLimit = 200
+	input.Width = 60
+	return &model{cfg: cfg, name: name, rows: fromLayout(layout), input: input}
+}
+
+// fromLayout is the layout's rows to edit, the heights and widths that aren't fixed made
+// whole percentages adding up to 100. Sizes left out share what the rest leave over, and an
+// empty layout is a single shell.
+func fromLayout(layout []config.LayoutRow) []row {
+	if len(layout) == 0 {
+		return []row{{height: 100, panes: []pane{{name: "shell", width: 100}}}}
+	}
+	rows := make([]row, len(layout))
+	var heights []int
+	for i, r := range layout {
+		rows[i].lines, rows[i].min, rows[i].max = fixed(r.Height), r.MinHeight, r.MaxHeight
+		if rows[i].lines == 0 {
+			heights = append(heights, percent(r.Height))
+		}
+		if len(r.Panes) == 0 {
+			rows[i].panes = []pane{{name: r.Name, command: commandOf(r.Command), width: 100}}
+			continue
+		}
+		var widths []int
+		for _, p := range r.Panes {
+			rows[i].panes = append(rows[i].panes, pane{name: p.Name, command: commandOf(p.Command), columns: fixed(p.Width), min: p.MinWidth, max: p.MaxWidth})
+			if fixed(p.Width) == 0 {
+				widths = append(widths, percent(p.Width))
+			}
+		}
+		widths = share(widths)
+		for j := range rows[i].panes {
+			if rows[i].panes[j].columns == 0 {
+				rows[i].panes[j].width, widths = widths[0], widths[1:]
+			}
+		}
+	}
+	heights = share(heights)
+	for i := range rows {
+		if rows[i].lines == 0 {
+			rows[i].height, heights = heights[0], heights[1:]
+		}
+	}
+	return rows
+}
+
+// toLayout is the edited rows as the config's layout: single-pane rows with their name and
+// command on the row, others with their panes
+func toLayout(rows []row) []config.LayoutRow {
+	layout := make([]config.LayoutRow, len(rows))
+	for i, r := range rows {
+		layout[i].Height = sizeOf(r.height, r.lines)
+		layout[i].MinHeight, layout[i].MaxHeight = r.min, r.max
+		if len(r.panes) == 1 {
+			layout[i].Name = r.panes[0].name
+			layout[i].Command = commandPtr(r.panes[0].command)
+			continue
+		}
+		for _, p := range r.panes {
+			layout[i].Panes = append(layout[i].Panes, config.Pane{Name: p.name, Width: sizeOf(p.width, p.columns), MinWidth: p.min, MaxWidth: p.max, Command: commandPtr(p.command)})
+		}
+	}
+	return layout
+}
+
+// sizeOf is a row's height or a pane's width as the config gives it, fixed or a percentage
+func sizeOf(percent, fixed int) string {
+	if fixed > 0 {
+		return strconv.Itoa(fixed)
+	}
+	return fmt.Sprintf("%d%%", percent)
+}
+
+// share makes sizes add up to 100: those left out (0) split what the others leave evenly,
+// then all are scaled to fit, with what rounding loses given to the last
+func share(sizes []int) []int {
+	if len(sizes) == 0 {
+		return nil
+	}
+	shared := append([]int(nil), sizes...)
+	total, missing := 0, 0
+	for _, size := range shared {
+		total += size
+		if size <= 0 {
+			missing++
+		}
+	}
+	if missing > 0 {
+		each := max((100-total)/missing, step)
+		for i := range shared {
+			if shared[i] <= 0 {
+				shared[i] = each
+				total += each
+			}
+		}
+	}
+	sum := 0
+	for i := range shared {
+		shared[i] = max(shared[i]*100/total, 1)
+		sum += shared[i]
+	}
+	shared[len(shared)-1] += 100 - sum
+	return shared
+}
+
+// percent parses a size like "40%", 0 if it isn't one
+func percent(s string) int {
+	number, ok := strings.CutSuffix(strings.TrimSpace(s), "%")
+	n, err := strconv.Atoi(strings.TrimSpace(number))
+	if !ok || err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// fixed parses a size in lines or columns like "3", 0 if it isn't one
+func fixed(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func commandOf(command *string) string {
+	if command == nil {
+		return ""
+	}
+	return *command
+}
+
+func commandPtr(command string) *string {
+	if command == "" {
+		return nil
+	}
+	return &command
+}
+
+// resize grows sizes[i] by delta, which may be negative, taking it from the next size, or
+// the one before for the last. Neither is left smaller than step.
+func resize(sizes []int, i, delta int) bool {
+	neighbour := i + 1
+	if neighbour == len(sizes) {
+		neighbour = i - 1
+	}
+	if neighbour < 0 || sizes[i]+delta < step || sizes[neighbour]-delta < step {
+		return false
+	}
+	sizes[i] += delta
+	sizes[neighbour] -= delta
+	return true
+}
+
+// resizeFixed grows or shrinks a fixed size by one, leaving it at least one
+func resizeFixed(size *int, grow bool) bool {
+	if !grow && *size <= 1 {
+		return false
+	}
+	if grow {
+		*size++
+	} else {
+		*size--
+	}
+	return true
+}
+
+// paneName is a name for a new pane that no other pane has
+func (m *model) paneName() string {
+	taken := map[string]bool{}
+	for _, r := range m.rows {
+		for _, p := range r.panes {
+			taken[p.name] = true
+		}
+	}
+	for n := 2; ; n++ {
+		if name := fmt.Sprintf("pane%d", n); !taken[name] {
+			return name
+		}
+	}
+}
+
+// splitRight splits the selected pane in two side by side, selecting the new one. A fixed
+// pane's columns are split between the two.
+func (m *model) splitRight() {
+	r := &m.rows[m.row]
+	width, columns := r.panes[m.col].width, r.panes[m.col].columns
+	if (columns == 0 && width < 2*step) || columns == 1 {
+		m.message = "That pane is too narrow to split"
+		return
+	}
+	r.panes[m.col].width, r.panes[m.col].columns = width/2, columns/2
+	added := pane{name: m.paneName(), width: width - width/2, columns: columns - columns/2}
+	r.panes = append(r.panes[:m.col+1], append([]pane{added}, r.panes[m.col+1:]...)...)
+	m.col++
+	m.dirty = true
+}
+
+// splitDown splits the selected pane's row in two, adding a row with one pane below it. A
+// fixed row's lines are split between the two.
+func (m *model) splitDown() {
+	height, lines := m.rows[m.row].height, m.rows[m.row].lines
+	if (lines == 0 && height < 2*step) || lines == 1 {
+		m.message = "That row is too short to split"
+		return
+	}
+	m.rows[m.row].height, m.rows[m.row].lines = height/2, lines/2
+	added := row{height: height - height/2, lines: lines - lines/2, panes: []pane{{name: m.paneName(), width: 100}}}
+	m.rows = append(m.rows[:m.row+1], append([]row{added}, m.rows[m.row+1:]...)...)
+	m.row, m.col = m.row+1, 0
+	m.dirty = true
+}
+
+// remove removes the selected pane, giving its room to the nearest pane or row that isn't
+// fixed
+func (m *model) remove() {
+	r := &m.rows[m.row]
+	switch {
+	case len(r.panes) > 1:
+		if r.panes[m.col].columns == 0 {
+			fixed := make([]bool, len(r.panes))
+			for i, p := range r.panes {
+				fixed[i] = p.columns > 0
+			}
+			if next := nearest(fixed, m.col); next >= 0 {
+				r.panes[next].width += r.panes[m.col].width
+			}
+		}
+		r.panes = append(r.panes[:m.col], r.panes[m.col+1:]...)
+		m.col = max(m.col-1, 0)
+		if len(r.panes) == 1 {
+			// A row's only pane is as wide as the window
+			r.panes[0].width, r.panes[0].columns = 100, 0
+		}
+	case len(m.rows) > 1:
+		if r.lines == 0 {
+			fixed := make([]bool, len(m.rows))
+			for i, other := range m.rows {
+				fixed[i] = other.lines > 0
+			}
+			if next := nearest(fixed, m.row); next >= 0 {
+				m.rows[next].height += r.height
+			}
+		}
+		m.rows = append(m.rows[:m.row], m.rows[m.row+1:]...)
+		m.row, m.col = max(m.row-1, 0), 0
+	default:
+		m.message = "A layout needs at least one pane"
+		return
+	}
+	m.dirty = true
+}
+
+// nearest is the index closest to i, before it first, that isn't fixed, or -1 when there's none
+func nearest(fixed []bool, i int) int {
+	for d := 1; d < len(fixed); d++ {
+		if i-d >= 0 && !fixed[i-d] {
+			return i - d
+		}
+		if i+d < len(fixed) && !fixed[i+d] {
+			return i + d
+		}
+	}
+	return -1
+}
+
+// resizeFlexible resizes the i'th of sizes among those that aren't fixed, as resize does
+func resizeFlexible(sizes []int, fixed []bool, i, delta int) bool {
+	var flexible []int
+	at := -1
+	for j := range sizes {
+		if !fixed[j] {
+			if j == i {
+				at = len(flexible)
+			}
+			flexible = append(flexible, sizes[j])
+		}
+	}
+	if at < 0 || !resize(flexible, at, delta) {
+		return false
+	}
+	for j := range sizes {
+		if !fixed[j] {
+			sizes[j], flexible = flexible[0], flexible[1:]
+		}
+	}
+	return true
+}
+
+// save writes the layout back to the config
+func (m *model) save() error {
+	layout := toLayout(m.rows)
+	return m.cfg.Update(func(cfg *config.Config) error {
+		if m.name == "" {
+			cfg.Layout = layout
+			return nil
+		}
+		if cfg.Layouts == nil {
+			cfg.Layouts = map[string][]config.LayoutRow{}
+		}
+		cfg.Layouts[m.name] = layout
+		return nil
+	})
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		if m.editing != editNone {
+			return m.handleInputKey(msg)
+		}
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		p := &m.rows[m.row].panes[m.col]
+		switch {
+		case m.editing == editName && value == "":
+			m.message = "Panes need a name"
+		case m.editing == editName:
+			p.name = value
+			m.dirty = true
+		default:
+			p.command = value
+			m.dirty = true
+		}
+		m.editing = editNone
+		m.input.Blur()
+		return m, nil
+	case "esc":
+		m.editing = editNone
+		m.input.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key != "q" && key != "esc" {
+		m.quitting = false
+	}
+	m.message = ""
+	r := &m.rows[m.row]
+	switch key {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q", "esc":
+		if m.dirty && !m.quitting {
+			m.quitting = true
+			m.message = "There are unwritten changes: w writes them, q again quits without"
+			return m, nil
+		}
+		return m, tea.Quit
+	case "up", "k":
+		if m.row > 0 {
+			m.row--
+			m.col = min(m.col, len(m.rows[m.row].panes)-1)
+		}
+	case "down", "j":
+		if m.row < len(m.rows)-1 {
+			m.row++
+			m.col = min(m.col, len(m.rows[m.row].panes)-1)
+		}
+	case "left", "h":
+		m.col = max(m.col-1, 0)
+	case "right", "l":
+		m.col = min(m.col+1, len(r.panes)-1)
+	case "+", "=", "-", "_":
+		// Fixed rows are a line taller or shorter
+		grow := key == "+" || key == "="
+		if r.lines > 0 {
+			m.dirty = resizeFixed(&r.lines, grow) || m.dirty
+			break
+		}
+		heights := make([]int, len(m.rows))
+		fixed := make([]bool, len(m.rows))
+		for i := range m.rows {
+			heights[i], fixed[i] = m.rows[i].height, m.rows[i].lines > 0
+		}
+		delta := step
+		if !grow {
+			delta = -step
+		}
+		if resizeFlexible(heights, fixed, m.row, delta) {
+			for i := range m.rows {
+				m.rows[i].height = heights[i]
+			}
+			m.dirty = true
+		}
+	case ">", ".", "<", ",":
+		// Fixed panes are a column wider or narrower
+		grow := key == ">" || key == "."
+		if r.panes[m.col].columns > 0 {
+			m.dirty = resizeFixed(&r.panes[m.col].columns, grow) || m.dirty
+			break
+		}
+		widths := make([]int, len(r.panes))
+		fixed := make([]bool, len(r.panes))
+		for i := range r.panes {
+			widths[i], fixed[i] = r.panes[i].width, r.panes[i].columns > 0
+		}
+		delta := step
+		if !grow {
+			delta = -step
+		}
+		if resizeFlexible(widths, fixed, m.col, delta) {
+			for i := range r.panes {
+				r.panes[i].width = widths[i]
+			}
+			m.dirty = true
+		}
+	case "s":
+		m.splitRight()
+	case "v":
+		m.splitDown()
+	case "d", "x":
+		m.remove()
+	case "n", "c":
+		m.editing = editName
+		m.input.SetValue(r.panes[m.col].name)
+		m.input.Placeholder = "pane name"
+		if key == "c" {
+			m.editing = editCommand
+			m.input.SetValue(r.panes[m.col].command)
+			m.input.Placeholder = "command to run, empty for a shell"
+		}
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case "w":
+		if err := m.save(); err != nil {
+			m.message = fmt.Sprintf("Failed to write the layout: %v", err)
+			return m, nil
+		}
+		m.dirty = false
+		m.message = "Written to " + m.cfg.GetConfigPath()
+	}
+	return m, nil
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	title := "Layout"
+	if m.name != "" {
+		title = "Layout " + m.name
+	}
+	if m.dirty {
+		title += " (unwritten)"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n")
+	b.WriteString(dimStyle.Render("The agent's pane takes the top 45% of the window; these rows share the rest") + "\n")
+	b.WriteString(m.grid(max(m.width, 40), max(m.height-5, 3*len(m.rows))) + "\n")
+
+	switch {
+	case m.editing == editName:
+		b.WriteString("Name: " + m.input.View() + "\n")
+	case m.editing == editCommand:
+		b.WriteString("Command: " + m.input.View() + "\n")
+	case m.message != "":
+		b.WriteString(m.message + "\n")
+	default:
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("↑↓←→/hjkl: Select | +/-: Height | </>: Width | s: Split right | v: Split down | d: Remove | n: Name | c: Command | w: Write | q: Quit"))
+	return b.String()
+}
+
+// grid draws the rows as boxes in a width by height area, sized as they'd be in the session
+func (m *model) grid(width, height int) string {
+	rows := make([]string, len(m.rows))
+	heights := make([]int, len(m.rows))
+	rowsFixed := make([]bool, len(m.rows))
+	for i, r := range m.rows {
+		heights[i], rowsFixed[i] = gridSize(r.height, r.lines, 3)
+	}
+	heights = fill(heights, rowsFixed, height, 3)
+	for i, r := range m.rows {
+		rowHeight := heights[i]
+
+		boxes := make([]string, len(r.panes))
+		widths := make([]int, len(r.panes))
+		fixed := make([]bool, len(r.panes))
+		for j, p := range r.panes {
+			widths[j], fixed[j] = gridSize(p.width, p.columns, 8)
+		}
+		widths = fill(widths, fixed, width, 8)
+		for j, p := range r.panes {
+			boxWidth := widths[j]
+
+			style := paneStyle
+			if i == m.row && j == m.col {
+				style = selectedStyle
+			}
+			command := p.command
+			if command == "" {
+				command = "shell"
+			}
+			text := fmt.Sprintf("%s\n%s\n%s", p.name, dimStyle.Render(command), dimStyle.Render(label(p.width, p.columns, "cols")+" × "+label(r.height, r.lines, "lines")))
+			boxes[j] = style.Width(boxWidth - 2).Height(rowHeight - 2).MaxHeight(rowHeight).Render(text)
+		}
+		rows[i] = lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// gridSize is the size to draw a fixed row or pane at, no smaller than a box can be, and
+// true, or the percentage to draw one that isn't fixed at
+func gridSize(percent, fixed, least int) (int, bool) {
+	if fixed > 0 {
+		return max(fixed, least), true
+	}
+	return percent, false
+}
+
+// fill turns the sizes that aren't fixed from percentages into their share of what the fixed
+// ones leave of total, the last of them taking what rounding loses, none less than least
+func fill(sizes []int, fixed []bool, total, least int) []int {
+	rest := total
+	for i, n := range sizes {
+		if fixed[i] {
+			rest -= n
+		}
+	}
+	last := -1
+	used := 0
+	for i, n := range sizes {
+		if !fixed[i] {
+			sizes[i] = max(rest*n/100, least)
+			used += sizes[i]
+			last = i
+		}
+	}
+	if last >= 0 {
+		sizes[last] = max(sizes[last]+rest-used, least)
+	}
+	return sizes
+}
+
+// label is a size as it's shown on a pane, e.g. "30%" or "3 lines"
+func label(percent, fixed int, unit string) string {
+	if fixed > 0 {
+		return fmt.Sprintf("%d %s", fixed, unit)
+	}
+	return fmt.Sprintf("%d%%", percent)
+}
@@ -0,0 +1,156 @@
+package layoutedit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+func TestFromLayout(t *testing.T) {
+	server := "npm run dev"
+	tests := []struct {
+		name     string
+		layout   []config.LayoutRow
+		expected []row
+	}{
+		{
+			name:     "empty",
+			expected: []row{{height: 100, panes: []pane{{name: "shell", width: 100}}}},
+		},
+		{
+			name: "sizes as given",
+			layout: []config.LayoutRow{
+				{Height: "60%", Name: "server", Command: &server},
+				{Height: "40%", Panes: []config.Pane{{Name: "shell", Width: "30%"}, {Name: "tests", Width: "70%"}}},
+			},
+			expected: []row{
+				{height: 60, panes: []pane{{name: "server", command: "npm run dev", width: 100}}},
+				{height: 40, panes: []pane{{name: "shell", width: 30}, {name: "tests", width: 70}}},
+			},
+		},
+		{
+			name: "sizes left out share the rest",
+			layout: []config.LayoutRow{
+				{Height: "50%", Name: "server"},
+				{Name: "logs"},
+				{Panes: []config.Pane{{Name: "a"}, {Name: "b"}, {Name: "c"}}},
+			},
+			expected: []row{
+				{height: 50, panes: []pane{{name: "server", width: 100}}},
+				{height: 25, panes: []pane{{name: "logs", width: 100}}},
+				{height: 25, panes: []pane{{name: "a", width: 33}, {name: "b", width: 33}, {name: "c", width: 34}}},
+			},
+		},
+		{
+			name: "fixed sizes and limits are kept",
+			layout: []config.LayoutRow{
+				{Height: "3", Name: "description"},
+				{Height: "60%", MinHeight: 10, Name: "server"},
+				{Panes: []config.Pane{{Name: "shell", Width: "40", MaxWidth: 60}, {Name: "tests"}}},
+			},
+			expected: []row{
+				{lines: 3, panes: []pane{{name: "description", width: 100}}},
+				{height: 60, min: 10, panes: []pane{{name: "server", width: 100}}},
+				{height: 40, panes: []pane{{name: "shell", columns: 40, max: 60}, {name: "tests", width: 100}}},
+			},
+		},
+		{
+			name: "sizes over 100 are scaled down",
+			layout: []config.LayoutRow{
+				{Height: "80%", Name: "server"},
+				{Height: "80%", Name: "logs"},
+			},
+			expected: []row{
+				{height: 50, panes: []pane{{name: "server", width: 100}}},
+				{height: 50, panes: []pane{{name: "logs", width: 100}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := fromLayout(tt.layout)
+			if !reflect.DeepEqual(rows, tt.expected) {
+				t.Errorf("fromLayout() = %+v, want %+v", rows, tt.expected)
+			}
+			if tt.layout != nil && !reflect.DeepEqual(fromLayout(toLayout(rows)), rows) {
+				t.Errorf("toLayout() = %+v, which doesn't read back as it was", toLayout(rows))
+			}
+		})
+	}
+}
+
+func TestEditAndWrite(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	layout := "name: proj\nlayout:\n  - name: server\n    height: 100%\n    command: npm run dev\n"
+	if err := os.WriteFile(configPath, []byte(layout), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	m := newModel(cfg, "", cfg.GetLayout())
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			}
+			m.Update(msg)
+		}
+	}
+
+	// A row below the server, taller than half, split in two with a command in the right
+	press("v", "+", "+", "s", ">", "c")
+	m.input.SetValue("go test ./...")
+	press("enter", "n")
+	m.input.SetValue("tests")
+	press("enter")
+	if !m.dirty {
+		t.Fatal("editing left the layout unchanged")
+	}
+	press("w")
+	if m.dirty {
+		t.Fatalf("w didn't write the layout: %s", m.message)
+	}
+
+	written, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	command, tests := "npm run dev", "go test ./..."
+	expected := []config.LayoutRow{
+		{Height: "40%", Name: "server", Command: &command},
+		{Height: "60%", Panes: []config.Pane{{Name: "pane2", Width: "45%"}, {Name: "tests", Width: "55%", Command: &tests}}},
+	}
+	if !reflect.DeepEqual(written.Layout, expected) {
+		t.Errorf("wrote %+v, want %+v", written.Layout, expected)
+	}
+
+	// Removing panes gives their room to their neighbours, down to the last
+	press("d", "d", "d")
+	if len(m.rows) != 1 || m.rows[0].height != 100 || m.message != "A layout needs at least one pane" {
+		t.Errorf("after removing panes rows = %+v, message %q", m.rows, m.message)
+	}
+	if view := m.View(); !strings.Contains(view, "(unwritten)") {
+		t.Errorf("View() doesn't show the unwritten changes:\n%s", view)
+	}
+
+	// Quitting with unwritten changes asks first
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd != nil {
+		t.Error("q quit without asking about the unwritten changes")
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q again didn't quit")
+	}
+}
@@ -0,0 +1,81 @@
+package listcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// Snapshot is what the TUI last showed, enough to draw the list on launch before git, tmux
+// or the backend have answered
+type Snapshot struct {
+	Backend   *config.StorageBackend      `json:"backend,omitempty"` // Where the items came from
+	Worktrees []git.Worktree              `json:"worktrees"`
+	Items     []Item                      `json:"items"`
+	Statuses  []string                    `json:"statuses,omitempty"`
+	Sessions  map[string]tmux.SessionInfo `json:"sessions,omitempty"`
+}
+
+// Item is one list item, a worktree, a tracked item or both once merged
+type Item struct {
+	Worktree   *git.Worktree       `json:"worktree,omitempty"`
+	GitHubItem *github.ProjectItem `json:"github_item,omitempty"`
+}
+
+// Matches reports whether the snapshot was taken with backend, so switching backends
+// doesn't show the old one's items
+func (s *Snapshot) Matches(backend *config.StorageBackend) bool {
+	return s != nil && reflect.DeepEqual(s.Backend, backend)
+}
+
+// path returns the snapshot file
+func path(configPath string) string {
+	return filepath.Join(state.Dir(configPath), "cache", "list.json")
+}
+
+// Load returns the last snapshot, or nil if none has been saved
+func Load(configPath string) (*Snapshot, error) {
+	data, err := os.ReadFile(path(configPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list cache: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse list cache: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Save replaces the snapshot
+func Save(configPath string, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal list cache: %w", err)
+	}
+
+	file := path(configPath)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// Write alongside and rename, so another lfg launching meanwhile never reads half a file
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write list cache: %w", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return fmt.Errorf("failed to write list cache: %w", err)
+	}
+	return nil
+}
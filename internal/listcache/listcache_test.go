@@ -0,0 +1,71 @@
+package listcache
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	snapshot, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() before Save() error = %v", err)
+	}
+	if snapshot != nil {
+		t.Fatalf("Load() before Save() = %+v, want nil", snapshot)
+	}
+
+	worktree := git.Worktree{Path: "/repo/lfg-login", Branch: "refs/heads/lfg-login", Commit: "abc123"}
+	item := github.ProjectItem{ID: "PVTI_1", Title: "Login", Status: "In Progress"}
+	item.Content.Number = 7
+	saved := Snapshot{
+		Backend:   &config.StorageBackend{Type: "github", Owner: "owner", Repo: "repo", ProjectNumber: 1},
+		Worktrees: []git.Worktree{worktree},
+		Items:     []Item{{Worktree: &worktree, GitHubItem: &item}, {GitHubItem: &github.ProjectItem{ID: "PVTI_2", Title: "Dark mode", Status: "Todo"}}},
+		Statuses:  []string{"Todo", "In Progress", "Done"},
+		Sessions:  map[string]tmux.SessionInfo{"lfg-login": {Name: "lfg-login", Attached: 1}},
+	}
+	if err := Save(configPath, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	snapshot, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if snapshot == nil || !reflect.DeepEqual(*snapshot, saved) {
+		t.Errorf("Load() = %+v, want %+v", snapshot, saved)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	project := &config.StorageBackend{Type: "github", Owner: "owner", Repo: "repo", ProjectNumber: 1}
+
+	tests := []struct {
+		name     string
+		snapshot *Snapshot
+		backend  *config.StorageBackend
+		expected bool
+	}{
+		{name: "no snapshot", snapshot: nil, backend: project, expected: false},
+		{name: "same backend", snapshot: &Snapshot{Backend: project}, backend: &config.StorageBackend{Type: "github", Owner: "owner", Repo: "repo", ProjectNumber: 1}, expected: true},
+		{name: "another project", snapshot: &Snapshot{Backend: project}, backend: &config.StorageBackend{Type: "github", Owner: "owner", Repo: "repo", ProjectNumber: 2}, expected: false},
+		{name: "both local", snapshot: &Snapshot{}, backend: nil, expected: true},
+		{name: "switched to local", snapshot: &Snapshot{Backend: project}, backend: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.snapshot.Matches(tt.backend); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
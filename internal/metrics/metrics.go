@@ -0,0 +1,271 @@
+// Package metrics works out how work flows through a repository's todos and items: how
+// long each took from starting to finishing, how many were in progress at once and how
+// many were finished each week. It reads what lfg already records, the history and the
+// todos' timestamps, so worktrees deleted since are counted too.
+package metrics
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/history"
+)
+
+// Task is a todo or item and when it moved through the board
+type Task struct {
+	Worktree string    `json:"worktree,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Created  time.Time `json:"created,omitzero"`
+	Started  time.Time `json:"started,omitzero"`
+	Done     time.Time `json:"done,omitzero"` // Zero while it isn't done
+}
+
+// CycleTime is how long a task took from being started until it was done, or from being
+// created for one with no start recorded
+func (t Task) CycleTime() (time.Duration, bool) {
+	start := cmp.Or(t.Started, t.Created)
+	if t.Done.IsZero() || start.IsZero() || t.Done.Before(start) {
+		return 0, false
+	}
+	return t.Done.Sub(start), true
+}
+
+// inProgress reports whether the task was started but not done just before at
+func (t Task) inProgress(at time.Time) bool {
+	return !t.Started.IsZero() && t.Started.Before(at) && (t.Done.IsZero() || !t.Done.Before(at))
+}
+
+// Tasks puts together what's known of each task, from the history and then the todos,
+// whose timestamps are kept as they happen. Tasks are told apart by their issue, or their
+// worktree or title without one. They're returned in the order they were created.
+func Tasks(todos []config.Todo, entries []history.Entry) []Task {
+	byKey := map[string]*Task{}
+	var order []string
+	task := func(key string) *Task {
+		if t, ok := byKey[key]; ok {
+			return t
+		}
+		byKey[key] = &Task{}
+		order = append(order, key)
+		return byKey[key]
+	}
+
+	for _, entry := range entries {
+		key := cmp.Or(entry.URL, entry.Worktree, entry.Title)
+		if key == "" {
+			continue
+		}
+		var t *Task
+		switch core.EventKind(entry.Kind) {
+		case core.WorktreeCreated:
+			t = task(key)
+			t.Worktree = entry.Worktree
+			t.Created = cmp.Or(t.Created, entry.Time)
+			t.Started = cmp.Or(t.Started, entry.Time)
+		case core.ItemCreated:
+			t = task(key)
+			t.Created = cmp.Or(t.Created, entry.Time)
+		case core.StatusChanged:
+			t = task(key)
+			switch {
+			case isDone(entry.Status):
+				t.Done = entry.Time
+			case isInProgress(entry.Status):
+				t.Started = cmp.Or(t.Started, entry.Time)
+				t.Done = time.Time{}
+			default:
+				t.Done = time.Time{} // Reopened
+			}
+		default:
+			continue
+		}
+		t.Worktree = cmp.Or(entry.Worktree, t.Worktree)
+		t.Title = cmp.Or(entry.Title, t.Title)
+		t.URL = cmp.Or(entry.URL, t.URL)
+	}
+
+	for _, todo := range todos {
+		key := cmp.Or(todo.GitHubURL, todo.Worktree, todo.Description)
+		// History recorded without an issue finds its task by worktree
+		if _, ok := byKey[key]; !ok && todo.Worktree != "" {
+			if t, ok := byKey[todo.Worktree]; ok {
+				byKey[key] = t
+			}
+		}
+		t := task(key)
+		t.Worktree = cmp.Or(todo.Worktree, t.Worktree)
+		t.Title = cmp.Or(todo.Description, t.Title)
+		t.URL = cmp.Or(todo.GitHubURL, t.URL)
+		t.Created = cmp.Or(todo.CreatedAt, t.Created)
+		t.Started = cmp.Or(todo.StartedAt, t.Started)
+		if todo.Status == config.TodoStatusDone {
+			t.Done = cmp.Or(todo.CompletedAt, t.Done)
+		} else {
+			t.Done = time.Time{}
+		}
+	}
+
+	tasks := make([]Task, 0, len(order))
+	seen := map[*Task]bool{}
+	for _, key := range order {
+		if t := byKey[key]; !seen[t] {
+			seen[t] = true
+			tasks = append(tasks, *t)
+		}
+	}
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		return cmp.Or(a.Created, a.Started).Compare(cmp.Or(b.Created, b.Started))
+	})
+	return tasks
+}
+
+// isDone reports whether a status is a finished one, of a todo or a board
+func isDone(status string) bool {
+	return strings.EqualFold(status, "done")
+}
+
+// isInProgress reports whether a status is the one work in progress has
+func isInProgress(status string) bool {
+	return strings.EqualFold(strings.ReplaceAll(status, "_", " "), "in progress")
+}
+
+// Stats summarises a set of cycle times
+type Stats struct {
+	Count        int
+	Median       time.Duration
+	Mean         time.Duration
+	Percentile85 time.Duration // 85% of tasks took this long or less
+}
+
+// Week is what happened in the week starting on Start, a Monday
+type Week struct {
+	Start      time.Time `json:"start"`
+	Completed  int       `json:"completed"`
+	InProgress int       `json:"in_progress"` // Tasks in progress at the end of the week, or now for this week
+	CycleTime  Stats     `json:"cycle_time"`  // Of the tasks completed in the week
+}
+
+// Report is the metrics for the weeks up to now
+type Report struct {
+	Since      time.Time `json:"since"`
+	CycleTime  Stats     `json:"cycle_time"`  // Of the tasks completed since Since
+	InProgress int       `json:"in_progress"` // Tasks in progress now
+	Weeks      []Week    `json:"weeks"`       // Oldest first
+	Tasks      []Task    `json:"tasks"`       // Those created, started or done since Since
+}
+
+// Build reports on the weeks weeks up to and including now's
+func Build(tasks []Task, now time.Time, weeks int) Report {
+	weeks = max(weeks, 1)
+	start := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+	report := Report{Since: start}
+
+	var all []time.Duration
+	for i := range weeks {
+		week := Week{Start: start.AddDate(0, 0, 7*i)}
+		next := week.Start.AddDate(0, 0, 7)
+		end := next
+		if end.After(now) {
+			end = now
+		}
+		var cycleTimes []time.Duration
+		for _, task := range tasks {
+			if !task.Done.Before(week.Start) && task.Done.Before(next) {
+				week.Completed++
+				if d, ok := task.CycleTime(); ok {
+					cycleTimes = append(cycleTimes, d)
+				}
+			}
+			if task.inProgress(end) {
+				week.InProgress++
+			}
+		}
+		week.CycleTime = stats(cycleTimes)
+		all = append(all, cycleTimes...)
+		report.Weeks = append(report.Weeks, week)
+	}
+	report.CycleTime = stats(all)
+	report.InProgress = report.Weeks[len(report.Weeks)-1].InProgress
+
+	for _, task := range tasks {
+		if slices.ContainsFunc([]time.Time{task.Created, task.Started, task.Done}, func(t time.Time) bool { return !t.Before(start) }) {
+			report.Tasks = append(report.Tasks, task)
+		}
+	}
+	return report
+}
+
+// weekStart is midnight on the Monday of t's week, in t's location
+func weekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
+
+func stats(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+	sorted := slices.Sorted(slices.Values(durations))
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+	// The nearest rank: the smallest that at least 85% are no longer than
+	rank := (len(sorted)*85 + 99) / 100
+	return Stats{
+		Count:        len(sorted),
+		Median:       median,
+		Mean:         total / time.Duration(len(sorted)),
+		Percentile85: sorted[rank-1],
+	}
+}
+
+// MarshalJSON writes the durations in seconds, rather than nanoseconds
+func (s Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count        int     `json:"count"`
+		Median       float64 `json:"median_seconds"`
+		Mean         float64 `json:"mean_seconds"`
+		Percentile85 float64 `json:"p85_seconds"`
+	}{s.Count, s.Median.Seconds(), s.Mean.Seconds(), s.Percentile85.Seconds()})
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV writes a row for each week: when it started, how many tasks were completed and
+// in progress, and the completed tasks' median cycle time in hours
+func WriteCSV(w io.Writer, report Report) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"week", "completed", "in_progress", "median_cycle_hours"})
+	for _, week := range report.Weeks {
+		median := ""
+		if week.CycleTime.Count > 0 {
+			median = strconv.FormatFloat(week.CycleTime.Median.Hours(), 'f', 1, 64)
+		}
+		out.Write([]string{week.Start.Format(time.DateOnly), strconv.Itoa(week.Completed), strconv.Itoa(week.InProgress), median})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/history"
+)
+
+// Monday 2 March 2026
+var monday = time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+func day(n int, hours ...int) time.Time {
+	t := monday.AddDate(0, 0, n)
+	for _, h := range hours {
+		t = t.Add(time.Duration(h) * time.Hour)
+	}
+	return t
+}
+
+func TestTasks(t *testing.T) {
+	issue := "https://github.com/owner/repo/issues/3"
+	entries := []history.Entry{
+		{Time: day(0), Kind: "item_created", Title: "Fix login", URL: issue},
+		{Time: day(1), Kind: "worktree_created", Worktree: "proj-fix-login", Title: "Fix login", URL: issue},
+		{Time: day(3), Kind: "status_changed", Title: "Fix login", URL: issue, Status: "Done"},
+		// A deleted worktree's todo is only in the history
+		{Time: day(2), Kind: "worktree_created", Worktree: "proj-gone"},
+		{Time: day(4), Kind: "status_changed", Worktree: "proj-gone", Status: "done"},
+		// Reopened after being done
+		{Time: day(2), Kind: "worktree_created", Worktree: "proj-reopened"},
+		{Time: day(3), Kind: "status_changed", Worktree: "proj-reopened", Status: "done"},
+		{Time: day(4), Kind: "status_changed", Worktree: "proj-reopened", Status: "pending"},
+		{Time: day(5), Kind: "session_killed", Worktree: "proj-ignored"},
+	}
+	todos := []config.Todo{
+		// Its timestamps win over the history's
+		{Description: "Fix login", Status: config.TodoStatusDone, Worktree: "proj-fix-login", GitHubURL: issue, StartedAt: day(1, 6), CompletedAt: day(3, 1)},
+		{Description: "Write docs", Status: config.TodoStatusPending, CreatedAt: day(6)},
+	}
+
+	want := []Task{
+		{Worktree: "proj-fix-login", Title: "Fix login", URL: issue, Created: day(0), Started: day(1, 6), Done: day(3, 1)},
+		{Worktree: "proj-gone", Created: day(2), Started: day(2), Done: day(4)},
+		{Worktree: "proj-reopened", Created: day(2), Started: day(2)},
+		{Title: "Write docs", Created: day(6)},
+	}
+	if got := Tasks(todos, entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tasks() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tasks := []Task{
+		{Title: "a", Started: day(-7), Done: day(-5)},      // Last week, 2 days
+		{Title: "b", Started: day(-6), Done: day(1)},       // This week, 7 days
+		{Title: "c", Created: day(0), Done: day(0, 12)},    // This week, 12 hours from its creation
+		{Title: "d", Started: day(-3)},                     // In progress since last week
+		{Title: "e", Started: day(2)},                      // In progress since this week
+		{Title: "f", Started: day(-30), Done: day(-20)},    // Before the report
+		{Title: "g", Created: day(-1), Started: day(3, 1)}, // Not started until after now
+	}
+	report := Build(tasks, day(3), 2)
+
+	if report.Since != day(-7) {
+		t.Errorf("Since = %v, want %v", report.Since, day(-7))
+	}
+	wantWeeks := []Week{
+		{Start: day(-7), Completed: 1, InProgress: 2, CycleTime: Stats{Count: 1, Median: 48 * time.Hour, Mean: 48 * time.Hour, Percentile85: 48 * time.Hour}},
+		{Start: day(0), Completed: 2, InProgress: 2, CycleTime: Stats{Count: 2, Median: 7*12*time.Hour + 6*time.Hour, Mean: 7*12*time.Hour + 6*time.Hour, Percentile85: 7 * 24 * time.Hour}},
+	}
+	if !reflect.DeepEqual(report.Weeks, wantWeeks) {
+		t.Errorf("Weeks =\n%+v\nwant\n%+v", report.Weeks, wantWeeks)
+	}
+	if want := (Stats{Count: 3, Median: 48 * time.Hour, Mean: (48 + 168 + 12) * time.Hour / 3, Percentile85: 168 * time.Hour}); report.CycleTime != want {
+		t.Errorf("CycleTime = %+v, want %+v", report.CycleTime, want)
+	}
+	if report.InProgress != 2 {
+		t.Errorf("InProgress = %d, want 2", report.InProgress)
+	}
+	if len(report.Tasks) != 6 {
+		t.Errorf("Tasks = %+v, want all but f", report.Tasks)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	report := Build([]Task{{Started: day(0), Done: day(0, 36)}}, day(3), 2)
+	var out bytes.Buffer
+	if err := WriteCSV(&out, report); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	want := "week,completed,in_progress,median_cycle_hours\n2026-02-23,0,0,\n2026-03-02,1,0,36.0\n"
+	if out.String() != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
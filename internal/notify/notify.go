@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// commands runs the notifiers, and is swapped out by tests
+var commands runner.Runner = runner.Exec{Timeout: 10 * time.Second}
+
+// SetRunner makes the package run notifiers through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// goos and lookPath decide which notifier is used, and are swapped out by tests
+var (
+	goos     = runtime.GOOS
+	lookPath = exec.LookPath
+)
+
+// Send shows a notification if the config asks for notifications of event
+func Send(cfg *config.Config, event, title, message string) error {
+	if !cfg.Notifies(event) {
+		return nil
+	}
+	return Show(title, message)
+}
+
+// Show shows a desktop notification, with osascript on macOS or notify-send on Linux,
+// falling back to a message in tmux when neither works
+func Show(title, message string) error {
+	var err error
+	for _, notifier := range notifiers(title, message) {
+		if _, err = commands.Run(context.Background(), nil, notifier[0], notifier[1:]...); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to show notification: %w", err)
+}
+
+// notifiers are the commands that can show a notification, in the order they're tried
+func notifiers(title, message string) [][]string {
+	var found [][]string
+	if goos == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		found = append(found, []string{"osascript", "-e", script})
+	} else if _, err := lookPath("notify-send"); err == nil {
+		found = append(found, []string{"notify-send", "--app-name", "lfg", title, message})
+	}
+	return append(found, []string{"tmux", "display-message", title + ": " + message})
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// CIWatch notifies when the checks on a worktree's pull request pass or fail. It isn't
+// safe for concurrent use.
+type CIWatch struct {
+	outcomes map[string]string // The outcome last seen, by branch
+}
+
+// Check looks up CI on the worktrees' pull requests, notifying of those that have passed
+// or failed since the last check. The first check only records where they are, so
+// starting to watch doesn't notify of every pull request at once.
+func (w *CIWatch) Check(cfg *config.Config, worktrees []git.Worktree) error {
+	if !cfg.Notifies(config.NotifyCI) || !cfg.StorageBackend.IsGitHub() {
+		return nil
+	}
+	outcomes, err := github.ListPullRequestCI(cfg.StorageBackend.Owner, cfg.StorageBackend.Repo)
+	if err != nil {
+		return err
+	}
+
+	first := w.outcomes == nil
+	seen := make(map[string]string, len(worktrees))
+	var errs []error
+	for _, wt := range worktrees {
+		branch := strings.TrimPrefix(wt.Branch, "refs/heads/")
+		outcome, ok := outcomes[branch]
+		if !ok {
+			continue
+		}
+		seen[branch] = outcome
+		if first || w.outcomes[branch] == outcome {
+			continue
+		}
+
+		name := git.GetWorktreeName(wt.Path)
+		switch outcome {
+		case github.CIPassed:
+			err = Show("CI passed", fmt.Sprintf("Checks passed for %s", name))
+		case github.CIFailed:
+			err = Show("CI failed", fmt.Sprintf("Checks failed for %s", name))
+		default:
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	w.outcomes = seen
+	return errors.Join(errs...)
+}
+
+// DueWatch notifies when todos and items fall due and when they become overdue. It isn't
+// safe for concurrent use.
+type DueWatch struct {
+	notified map[string]bool // What's been notified of, by name, due date and whether it was overdue
+}
+
+// Check notifies of the config's todos and the items that are due today or overdue, and
+// haven't been notified of as such. Done ones are left out.
+func (w *DueWatch) Check(cfg *config.Config, items []github.ProjectItem) error {
+	if !cfg.Notifies(config.NotifyDue) {
+		return nil
+	}
+	if w.notified == nil {
+		w.notified = map[string]bool{}
+	}
+
+	now := time.Now()
+	overdue := false
+	var lines []string
+	for _, d := range dues(cfg, items) {
+		days, ok := humanize.DaysUntil(d.date, now)
+		if !ok || days > 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s %s %v", d.name, d.date, days < 0)
+		if w.notified[key] {
+			continue
+		}
+		w.notified[key] = true
+		overdue = overdue || days < 0
+		lines = append(lines, d.name+" is "+humanize.Due(days))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	title := "Due today"
+	if overdue {
+		title = "Overdue"
+	}
+	return Show(title, strings.Join(lines, "; "))
+}
+
+// IdleWatch notifies of the idle sessions core's ReapSessions finds, once each time a
+// session goes idle. It isn't safe for concurrent use.
+type IdleWatch struct {
+	notified map[string]time.Time // The last activity of the sessions notified of, by worktree
+}
+
+// Check notifies of the sessions that have gone idle since they were last notified of,
+// saying which were killed
+func (w *IdleWatch) Check(sessions []core.IdleSession) error {
+	if w.notified == nil {
+		w.notified = map[string]time.Time{}
+	}
+	var idle, killed []string
+	for _, session := range sessions {
+		if last, ok := w.notified[session.Worktree]; ok && last.Equal(session.LastActive) {
+			continue
+		}
+		w.notified[session.Worktree] = session.LastActive
+		if session.Killed {
+			killed = append(killed, session.Worktree)
+		} else {
+			idle = append(idle, fmt.Sprintf("%s (last used %s)", session.Worktree, humanize.Ago(session.LastActive)))
+		}
+	}
+
+	var errs []error
+	if len(killed) > 0 {
+		errs = append(errs, Show("Killed idle sessions", strings.Join(killed, ", ")+"; their worktrees are kept"))
+	}
+	if len(idle) > 0 {
+		errs = append(errs, Show("Idle sessions", strings.Join(idle, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// due is a todo or item with a due date
+type due struct {
+	name string // The todo's worktree, or the item's title
+	date string
+}
+
+// dues lists the todos and items with due dates that aren't done, leaving out items whose
+// todo is listed
+func dues(cfg *config.Config, items []github.ProjectItem) []due {
+	var found []due
+	linked := map[string]bool{}
+	for _, todo := range cfg.Todos {
+		if todo.Due == "" || todo.Status == config.TodoStatusDone {
+			continue
+		}
+		name := todo.Worktree
+		if name == "" {
+			name = todo.Description
+		}
+		found = append(found, due{name: name, date: todo.Due})
+		linked[todo.Worktree] = true
+		linked[todo.GitHubURL] = true
+	}
+	for _, item := range items {
+		if item.Due == "" || item.Status == "Done" {
+			continue
+		}
+		if (item.Worktree != "" && linked[item.Worktree]) || (item.Content.URL != "" && linked[item.Content.URL]) {
+			continue
+		}
+		found = append(found, due{name: item.Title, date: item.Due})
+	}
+	return found
+}
@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// fakeNotifiers runs notifiers through a fake on a system with notify-send
+func fakeNotifiers(t *testing.T) *runner.Fake {
+	t.Helper()
+	fake := &runner.Fake{}
+	previous, previousOS, previousLookPath := SetRunner(fake), goos, lookPath
+	goos = "linux"
+	lookPath = func(string) (string, error) { return "/usr/bin/notify-send", nil }
+	t.Cleanup(func() {
+		SetRunner(previous)
+		goos, lookPath = previousOS, previousLookPath
+	})
+	return fake
+}
+
+func loadConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+	return cfg
+}
+
+func TestNotifiers(t *testing.T) {
+	tmux := []string{"tmux", "display-message", `Done: "proj-x" finished`}
+	tests := []struct {
+		name       string
+		goos       string
+		notifySend bool
+		expected   [][]string
+	}{
+		{
+			name:     "macOS",
+			goos:     "darwin",
+			expected: [][]string{{"osascript", "-e", `display notification "\"proj-x\" finished" with title "Done"`}, tmux},
+		},
+		{
+			name:       "Linux",
+			goos:       "linux",
+			notifySend: true,
+			expected:   [][]string{{"notify-send", "--app-name", "lfg", "Done", `"proj-x" finished`}, tmux},
+		},
+		{
+			name:     "Linux without notify-send",
+			goos:     "linux",
+			expected: [][]string{tmux},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousOS, previousLookPath := goos, lookPath
+			t.Cleanup(func() { goos, lookPath = previousOS, previousLookPath })
+			goos = tt.goos
+			lookPath = func(string) (string, error) {
+				if tt.notifySend {
+					return "/usr/bin/notify-send", nil
+				}
+				return "", errors.New("not found")
+			}
+
+			if got := notifiers("Done", `"proj-x" finished`); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("notifiers() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShowFallsBackToTmux(t *testing.T) {
+	fake := fakeNotifiers(t)
+	fake.On("notify-send", "", errors.New("no notification daemon"))
+	fake.On("tmux display-message", "", nil)
+
+	if err := Show("Done", "proj-x"); err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 2 || calls[1].Args[0] != "tmux" {
+		t.Errorf("Show() ran %v, want notify-send then tmux", calls)
+	}
+}
+
+func TestSendOnlyWhenAsked(t *testing.T) {
+	fake := fakeNotifiers(t)
+	fake.On("notify-send", "", nil)
+	cfg := loadConfig(t, "name: proj\nnotify: [crash]\n")
+
+	if err := Send(cfg, config.NotifyAgent, "Agent finished", "proj-x"); err != nil || len(fake.Calls()) > 0 {
+		t.Errorf("Send() of an event not asked for = %v, ran %v", err, fake.Calls())
+	}
+	if err := Send(cfg, config.NotifyCrash, "server crashed", "proj-x"); err != nil || len(fake.Calls()) != 1 {
+		t.Errorf("Send() of an event asked for = %v, ran %v", err, fake.Calls())
+	}
+}
+
+func TestCIWatch(t *testing.T) {
+	fake := fakeNotifiers(t)
+	fake.On("notify-send", "", nil)
+	previous := github.SetRunner(&runner.Fake{})
+	t.Cleanup(func() { github.SetRunner(previous) })
+
+	cfg := loadConfig(t, "name: proj\nnotify: [ci]\nstorage_backend:\n  type: github-issues\n  owner: owner\n  repo: repo\n")
+	worktrees := []git.Worktree{
+		{Path: "/code/proj-a", Branch: "refs/heads/proj-a"},
+		{Path: "/code/proj-b", Branch: "refs/heads/proj-b"},
+	}
+	rollup := func(a, b string) string {
+		return `[{"headRefName": "proj-a", "statusCheckRollup": [{"status": "` + a + `"}]},
+			{"headRefName": "proj-b", "statusCheckRollup": [{"status": "COMPLETED", "conclusion": "` + b + `"}]}]`
+	}
+
+	watch := &CIWatch{}
+	checks := []struct {
+		rollup   string
+		expected []string // The notifications' titles
+	}{
+		{rollup: rollup("IN_PROGRESS", "FAILURE")}, // Where things are when watching starts
+		{rollup: rollup("IN_PROGRESS", "FAILURE")},
+		{rollup: rollup("COMPLETED", "SUCCESS"), expected: []string{"CI passed", "CI passed"}},
+		{rollup: rollup("COMPLETED", "FAILURE"), expected: []string{"CI failed"}},
+	}
+	for i, check := range checks {
+		gh := &runner.Fake{}
+		gh.On("gh pr list", check.rollup, nil)
+		github.SetRunner(gh)
+		before := len(fake.Calls())
+		if err := watch.Check(cfg, worktrees); err != nil {
+			t.Fatalf("check %d: Check() error = %v", i, err)
+		}
+
+		var titles []string
+		for _, call := range fake.Calls()[before:] {
+			titles = append(titles, call.Args[3])
+		}
+		if !reflect.DeepEqual(titles, check.expected) {
+			t.Errorf("check %d notified %v, want %v", i, titles, check.expected)
+		}
+	}
+}
+
+func TestDueWatch(t *testing.T) {
+	fake := fakeNotifiers(t)
+	fake.On("notify-send", "", nil)
+	today := time.Now().Format(time.DateOnly)
+	lastWeek := time.Now().AddDate(0, 0, -7).Format(time.DateOnly)
+	nextWeek := time.Now().AddDate(0, 0, 7).Format(time.DateOnly)
+	cfg := loadConfig(t, `name: proj
+notify: [due]
+todos:
+  - description: Add login
+    status: pending
+    worktree: proj-login
+    due: `+today+`
+  - description: Write docs
+    status: pending
+    worktree: proj-docs
+    due: `+nextWeek+`
+  - description: Old release
+    status: done
+    worktree: proj-release
+    due: `+lastWeek+`
+`)
+	items := []github.ProjectItem{
+		{ID: "1", Title: "Add login", Worktree: "proj-login", Due: today},
+		{ID: "2", Title: "Fix billing", Status: "Todo", Due: lastWeek},
+		{ID: "3", Title: "Ship it", Status: "Done", Due: lastWeek},
+	}
+
+	watch := &DueWatch{}
+	if err := watch.Check(cfg, items); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	expected := [][]string{{"notify-send", "--app-name", "lfg", "Overdue", "proj-login is due today; Fix billing is overdue by 7d"}}
+	var calls [][]string
+	for _, call := range fake.Calls() {
+		calls = append(calls, call.Args)
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("notified %v, want %v", calls, expected)
+	}
+
+	// Each is only notified of once
+	if err := watch.Check(cfg, items); err != nil || len(fake.Calls()) != 1 {
+		t.Errorf("second Check() = %v, notified %d times, want once", err, len(fake.Calls()))
+	}
+}
+
+func TestIdleWatch(t *testing.T) {
+	fake := fakeNotifiers(t)
+	fake.On("notify-send", "", nil)
+	lastWeek := time.Now().AddDate(0, 0, -8)
+	sessions := []core.IdleSession{
+		{Worktree: "proj-login", LastActive: lastWeek},
+		{Worktree: "proj-docs", LastActive: lastWeek, Killed: true},
+	}
+
+	watch := &IdleWatch{}
+	if err := watch.Check(sessions); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	expected := [][]string{
+		{"notify-send", "--app-name", "lfg", "Killed idle sessions", "proj-docs; their worktrees are kept"},
+		{"notify-send", "--app-name", "lfg", "Idle sessions", "proj-login (last used 8d ago)"},
+	}
+	var calls [][]string
+	for _, call := range fake.Calls() {
+		calls = append(calls, call.Args)
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("notified %v, want %v", calls, expected)
+	}
+
+	// A session is notified of again only once it's been used and gone idle again
+	if err := watch.Check(sessions[:1]); err != nil || len(fake.Calls()) != 2 {
+		t.Errorf("second Check() = %v, notified %d times, want no more", err, len(fake.Calls()))
+	}
+	sessions[0].LastActive = lastWeek.Add(time.Hour)
+	if err := watch.Check(sessions[:1]); err != nil || len(fake.Calls()) != 3 {
+		t.Errorf("Check() once used again = %v, notified %d times, want 3", err, len(fake.Calls()))
+	}
+}
@@ -0,0 +1,291 @@
+package orgmode
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Statuses are the lfg statuses org headlines move between, in order
+var Statuses = []string{"Todo", "In Progress", "Done"}
+
+// keywords maps each status to the org keyword it's written as
+var keywords = map[string]string{
+	"Todo":        "TODO",
+	"In Progress": "DOING",
+	"Done":        "DONE",
+}
+
+// Properties lfg reads and writes in a headline's property drawer
+const (
+	idProperty       = "ID"
+	worktreeProperty = "WORKTREE"
+)
+
+// headlinePattern matches a headline, its keyword, priority cookie, title and tags
+var headlinePattern = regexp.MustCompile(`^(\*+) +(?:(TODO|DOING|DONE) +)?(\[#.\] +)?(.*?)(?: +(:[^\s]+:))? *$`)
+
+// planningPattern matches the line of CLOSED, SCHEDULED and DEADLINE stamps under a headline
+var planningPattern = regexp.MustCompile(`^\s*(CLOSED|SCHEDULED|DEADLINE):`)
+
+// closedPattern matches a CLOSED stamp within a planning line
+var closedPattern = regexp.MustCompile(`CLOSED: \[[^\]]*\] *`)
+
+// Headline is a TODO, DOING or DONE headline, one lfg item
+type Headline struct {
+	ID       string // The ID property, or the title when the headline has none
+	Title    string
+	Status   string
+	Body     string
+	Worktree string // The WORKTREE property, linking the headline to a worktree
+	Parent   string // The ID of the item headline it's nested under, "" at the top
+}
+
+// File is an org file held as lines and edited in place, so everything lfg doesn't touch
+// stays as it was written
+type File struct {
+	path  string
+	lines []string
+}
+
+// headline is where a parsed headline sits in the file
+type headline struct {
+	Headline
+	line     int // The headline itself
+	planning int // The planning line, -1 if there isn't one
+	drawer   int // The :PROPERTIES: line, -1 if there isn't a drawer
+	end      int // The first line after the headline's own text
+	keyword  string
+	stars    string
+	priority string
+	tags     string
+	hasID    bool
+}
+
+// Load reads the org file at path. A file that doesn't exist yet is empty.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org file: %w", err)
+	}
+	return &File{path: path, lines: strings.Split(string(data), "\n")}, nil
+}
+
+// Save writes the file back
+func (f *File) Save() error {
+	data := strings.Join(f.lines, "\n")
+	if data != "" && !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	if err := os.WriteFile(f.path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write org file: %w", err)
+	}
+	return nil
+}
+
+// Headlines returns every headline with a keyword, in file order. Headlines without one
+// are taken to be sections grouping the items.
+func (f *File) Headlines() []Headline {
+	var headlines []Headline
+	for _, h := range f.parse() {
+		headlines = append(headlines, h.Headline)
+	}
+	return headlines
+}
+
+func (f *File) parse() []headline {
+	var headlines []headline
+	var open []headline // The item headlines enclosing this line, outermost first
+	for i := 0; i < len(f.lines); i++ {
+		match := headlinePattern.FindStringSubmatch(f.lines[i])
+		if match == nil {
+			continue
+		}
+		for len(open) > 0 && len(open[len(open)-1].stars) >= len(match[1]) {
+			open = open[:len(open)-1]
+		}
+		if match[2] == "" {
+			continue
+		}
+		h := headline{line: i, planning: -1, drawer: -1, stars: match[1], keyword: match[2], priority: match[3], tags: match[5]}
+		h.Title = match[4]
+		for status, keyword := range keywords {
+			if keyword == h.keyword {
+				h.Status = status
+			}
+		}
+
+		next := i + 1
+		if next < len(f.lines) && planningPattern.MatchString(f.lines[next]) {
+			h.planning = next
+			next++
+		}
+		properties := map[string]string{}
+		if next < len(f.lines) && strings.TrimSpace(f.lines[next]) == ":PROPERTIES:" {
+			h.drawer = next
+			for next++; next < len(f.lines) && strings.TrimSpace(f.lines[next]) != ":END:"; next++ {
+				name, value, ok := strings.Cut(strings.TrimSpace(f.lines[next]), " ")
+				if ok && strings.HasPrefix(name, ":") && strings.HasSuffix(name, ":") {
+					properties[strings.ToUpper(strings.Trim(name, ":"))] = strings.TrimSpace(value)
+				}
+			}
+			next++
+		}
+
+		var body []string
+		for ; next < len(f.lines) && !headlinePattern.MatchString(f.lines[next]); next++ {
+			body = append(body, f.lines[next])
+		}
+		h.end = next
+		h.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		h.Worktree = properties[worktreeProperty]
+		h.ID, h.hasID = properties[idProperty]
+		if !h.hasID {
+			h.ID = h.Title
+		}
+		if len(open) > 0 {
+			h.Parent = open[len(open)-1].ID
+		}
+		headlines = append(headlines, h)
+		open = append(open, h)
+	}
+	return headlines
+}
+
+// find returns the headline with id
+func (f *File) find(id string) (headline, error) {
+	for _, h := range f.parse() {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return headline{}, fmt.Errorf("no org headline %q", id)
+}
+
+// Add appends a TODO headline titled title, with an ID so it can be found once renamed
+func (f *File) Add(title string) (Headline, error) {
+	id, err := newID()
+	if err != nil {
+		return Headline{}, err
+	}
+	lines := []string{"* TODO " + title, ":PROPERTIES:", ":" + idProperty + ": " + id, ":END:"}
+	if len(f.lines) > 0 && f.lines[len(f.lines)-1] == "" {
+		f.lines = f.lines[:len(f.lines)-1]
+	}
+	f.lines = append(f.lines, lines...)
+	return Headline{ID: id, Title: title, Status: "Todo"}, nil
+}
+
+// SetStatus changes a headline's keyword, stamping when it was closed as org does
+func (f *File) SetStatus(id, status string, now time.Time) error {
+	keyword, ok := keywords[status]
+	if !ok {
+		return fmt.Errorf("org headlines can't be %q", status)
+	}
+	h, err := f.find(id)
+	if err != nil {
+		return err
+	}
+	h.keyword = keyword
+	f.lines[h.line] = h.render()
+
+	switch {
+	case keyword == "DONE" && h.planning < 0:
+		f.insert(h.line+1, "CLOSED: "+now.Format("[2006-01-02 Mon 15:04]"))
+	case keyword == "DONE" && !closedPattern.MatchString(f.lines[h.planning]):
+		f.lines[h.planning] = "CLOSED: " + now.Format("[2006-01-02 Mon 15:04]") + " " + strings.TrimSpace(f.lines[h.planning])
+	case keyword != "DONE" && h.planning >= 0:
+		planning := strings.TrimSpace(closedPattern.ReplaceAllString(f.lines[h.planning], ""))
+		if planning == "" {
+			f.lines = append(f.lines[:h.planning], f.lines[h.planning+1:]...)
+		} else {
+			f.lines[h.planning] = planning
+		}
+	}
+	return nil
+}
+
+// SetTitle renames a headline, keeping its keyword, priority and tags
+func (f *File) SetTitle(id, title string) error {
+	h, err := f.find(id)
+	if err != nil {
+		return err
+	}
+	if !h.hasID {
+		// The title was all that identified it, so give it an ID to keep it findable
+		if err := f.setProperty(h, idProperty, h.ID); err != nil {
+			return err
+		}
+		if h, err = f.find(id); err != nil {
+			return err
+		}
+	}
+	h.Title = title
+	f.lines[h.line] = h.render()
+	return nil
+}
+
+// SetWorktree links a headline to a worktree
+func (f *File) SetWorktree(id, worktree string) error {
+	h, err := f.find(id)
+	if err != nil {
+		return err
+	}
+	return f.setProperty(h, worktreeProperty, worktree)
+}
+
+// setProperty sets a property in the headline's drawer, adding the drawer if it has none
+func (f *File) setProperty(h headline, name, value string) error {
+	property := ":" + name + ": " + value
+	if h.drawer < 0 {
+		at := h.line + 1
+		if h.planning >= 0 {
+			at = h.planning + 1
+		}
+		f.insert(at, ":PROPERTIES:", property, ":END:")
+		return nil
+	}
+	for i := h.drawer + 1; i < len(f.lines) && i < h.end; i++ {
+		line := strings.TrimSpace(f.lines[i])
+		if line == ":END:" {
+			f.insert(i, property)
+			return nil
+		}
+		if existing, _, _ := strings.Cut(line, " "); strings.EqualFold(existing, ":"+name+":") {
+			f.lines[i] = property
+			return nil
+		}
+	}
+	return fmt.Errorf("org headline %q has an unclosed property drawer", h.ID)
+}
+
+// insert puts lines in before index at
+func (f *File) insert(at int, lines ...string) {
+	f.lines = append(f.lines[:at], append(lines, f.lines[at:]...)...)
+}
+
+// render writes the headline line back out
+func (h headline) render() string {
+	line := h.stars + " " + h.keyword + " " + h.priority + h.Title
+	if h.tags != "" {
+		line += " " + h.tags
+	}
+	return line
+}
+
+// newID returns a random UUID, the form org-id uses
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate an ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,203 @@
+package orgmode
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `#+TITLE: lfg
+
+* Backlog
+** TODO [#A] Fix the login page :web:
+:PROPERTIES:
+:ID: login
+:WORKTREE: lfg-fix-the-login-page
+:END:
+It 500s on submit.
+** DOING Rate limits
+SCHEDULED: <2026-10-20 Tue>
+Back off after 3 retries.
+** DONE Ship it
+CLOSED: [2026-10-01 Thu 09:00]
+* Notes
+`
+
+func writeSample(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "todo.org")
+	if err := os.WriteFile(path, []byte(sample), 0644); err != nil {
+		t.Fatalf("failed to write org file: %v", err)
+	}
+	return path
+}
+
+func TestHeadlines(t *testing.T) {
+	file, err := Load(writeSample(t))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	expected := []Headline{
+		{ID: "login", Title: "Fix the login page", Status: "Todo", Body: "It 500s on submit.", Worktree: "lfg-fix-the-login-page"},
+		{ID: "Rate limits", Title: "Rate limits", Status: "In Progress", Body: "Back off after 3 retries."},
+		{ID: "Ship it", Title: "Ship it", Status: "Done"},
+	}
+	if got := file.Headlines(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Headlines() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestHeadlineParents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.org")
+	org := `* TODO Accounts
+:PROPERTIES:
+:ID: accounts
+:END:
+** TODO Login form
+** Notes
+*** DOING Sessions
+**** TODO Expiry
+* Backlog
+** TODO Dark mode
+`
+	if err := os.WriteFile(path, []byte(org), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	parents := map[string]string{}
+	for _, headline := range file.Headlines() {
+		parents[headline.Title] = headline.Parent
+	}
+	// Sections without a keyword group items without being their parents
+	expected := map[string]string{"Accounts": "", "Login form": "accounts", "Sessions": "accounts", "Expiry": "Sessions", "Dark mode": ""}
+	if !reflect.DeepEqual(parents, expected) {
+		t.Errorf("parents = %v, want %v", parents, expected)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), "todo.org"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if headlines := file.Headlines(); len(headlines) != 0 {
+		t.Errorf("Headlines() = %+v, want none", headlines)
+	}
+}
+
+func TestEdits(t *testing.T) {
+	now := time.Date(2026, 10, 14, 16, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		edit     func(f *File) error
+		expected []string // Lines that should now be in the file
+		removed  []string // Lines that should no longer be
+	}{
+		{
+			name:     "status keeps priority and tags",
+			edit:     func(f *File) error { return f.SetStatus("login", "In Progress", now) },
+			expected: []string{"** DOING [#A] Fix the login page :web:"},
+		},
+		{
+			name:     "done is stamped closed",
+			edit:     func(f *File) error { return f.SetStatus("Rate limits", "Done", now) },
+			expected: []string{"** DONE Rate limits", "CLOSED: [2026-10-14 Wed 16:30] SCHEDULED: <2026-10-20 Tue>"},
+		},
+		{
+			name:     "reopening drops the stamp",
+			edit:     func(f *File) error { return f.SetStatus("Ship it", "Todo", now) },
+			expected: []string{"** TODO Ship it"},
+			removed:  []string{"CLOSED: [2026-10-01 Thu 09:00]"},
+		},
+		{
+			name:     "link a worktree to a headline without a drawer",
+			edit:     func(f *File) error { return f.SetWorktree("Rate limits", "lfg-rate-limits") },
+			expected: []string{"SCHEDULED: <2026-10-20 Tue>\n:PROPERTIES:\n:WORKTREE: lfg-rate-limits\n:END:"},
+		},
+		{
+			name:     "relink a worktree",
+			edit:     func(f *File) error { return f.SetWorktree("login", "lfg-login") },
+			expected: []string{":WORKTREE: lfg-login"},
+			removed:  []string{":WORKTREE: lfg-fix-the-login-page"},
+		},
+		{
+			name:     "renaming a headline without an ID gives it one",
+			edit:     func(f *File) error { return f.SetTitle("Ship it", "Ship v2") },
+			expected: []string{"** DONE Ship v2\nCLOSED: [2026-10-01 Thu 09:00]\n:PROPERTIES:\n:ID: Ship it\n:END:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSample(t)
+			file, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if err := tt.edit(file); err != nil {
+				t.Fatalf("edit error = %v", err)
+			}
+			if err := file.Save(); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read org file: %v", err)
+			}
+			content := string(data)
+			for _, expected := range tt.expected {
+				if !strings.Contains(content, expected) {
+					t.Errorf("file doesn't contain %q:\n%s", expected, content)
+				}
+			}
+			for _, removed := range tt.removed {
+				if strings.Contains(content, removed) {
+					t.Errorf("file still contains %q:\n%s", removed, content)
+				}
+			}
+			if !strings.HasPrefix(content, "#+TITLE: lfg\n\n* Backlog\n") || !strings.HasSuffix(content, "* Notes\n") {
+				t.Errorf("edit changed the rest of the file:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	path := writeSample(t)
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	added, err := file.Add("Dark mode")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := file.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	file, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	headlines := file.Headlines()
+	if got := headlines[len(headlines)-1]; !reflect.DeepEqual(got, added) || added.Status != "Todo" || added.ID == "" {
+		t.Errorf("Add() = %+v, reloaded as %+v", added, got)
+	}
+	if err := file.SetStatus("nope", "Done", time.Now()); err == nil {
+		t.Error("SetStatus() of a missing headline succeeded")
+	}
+	if err := file.SetStatus(added.ID, "Blocked", time.Now()); err == nil {
+		t.Error("SetStatus() to an unknown status succeeded")
+	}
+}
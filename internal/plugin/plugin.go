@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// pluginTimeout bounds one call to a plugin, which usually talks to a tracker's API
+const pluginTimeout = 30 * time.Second
+
+// commands runs plugins
+var commands runner.Runner = runner.Exec{Timeout: pluginTimeout}
+
+// Item is a tracker item as a backend plugin describes it
+type Item struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Body      string    `json:"body,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Number    int       `json:"number,omitempty"` // The item's number in the tracker, if it has one
+	Parent    string    `json:"parent,omitempty"` // The ID of the epic or parent item it belongs to, if any
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// Request is what lfg sends a plugin on stdin. Op is "list", "create" or "update".
+type Request struct {
+	Op      string            `json:"op"`
+	Options map[string]string `json:"options,omitempty"` // storage_backend.options from the config
+	Item    *Item             `json:"item,omitempty"`    // The item to update
+	Title   string            `json:"title,omitempty"`   // The title to create an item with, or rename it to
+	Status  string            `json:"status,omitempty"`  // The status to move an item to
+}
+
+// Response is what a plugin prints on stdout
+type Response struct {
+	Items    []Item   `json:"items,omitempty"`    // Every item, for list
+	Statuses []string `json:"statuses,omitempty"` // The statuses items move between, in order, for list
+	Item     *Item    `json:"item,omitempty"`     // The new item, for create
+	Error    string   `json:"error,omitempty"`
+}
+
+// Command returns the executable lfg runs for the named plugin, found on PATH
+func Command(name string) string {
+	return "lfg-backend-" + name
+}
+
+// List fetches every item the plugin tracks, and the statuses they move between
+func List(name string, options map[string]string) ([]Item, []string, error) {
+	response, err := call(name, Request{Op: "list", Options: options})
+	if err != nil {
+		return nil, nil, err
+	}
+	return response.Items, response.Statuses, nil
+}
+
+// Create adds an item titled title
+func Create(name string, options map[string]string, title string) (*Item, error) {
+	response, err := call(name, Request{Op: "create", Options: options, Title: title})
+	if err != nil {
+		return nil, err
+	}
+	if response.Item == nil {
+		return nil, fmt.Errorf("%s didn't return the item it created", Command(name))
+	}
+	return response.Item, nil
+}
+
+// SetStatus moves an item to status
+func SetStatus(name string, options map[string]string, item Item, status string) error {
+	_, err := call(name, Request{Op: "update", Options: options, Item: &item, Status: status})
+	return err
+}
+
+// SetTitle renames an item
+func SetTitle(name string, options map[string]string, item Item, title string) error {
+	_, err := call(name, Request{Op: "update", Options: options, Item: &item, Title: title})
+	return err
+}
+
+// call runs the plugin with request on stdin, reading its response from stdout. A plugin
+// fails by exiting non-zero with a message on stderr, or by setting error in its response.
+func call(name string, request Request) (*Response, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	output, err := commands.Run(context.Background(), bytes.NewReader(input), Command(name), request.Op)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", Command(name), request.Op, err)
+	}
+
+	var response Response
+	if len(bytes.TrimSpace(output)) > 0 {
+		if err := json.Unmarshal(output, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s response: %w", Command(name), request.Op, err)
+		}
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("%s %s failed: %s", Command(name), request.Op, response.Error)
+	}
+	return &response, nil
+}
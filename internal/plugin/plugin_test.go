@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakePlugin installs lfg-backend-fake on PATH. It saves each request to request.json in
+// dir and answers with the script's output for the op.
+func fakePlugin(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	content := "#!/bin/sh\ncat > " + filepath.Join(dir, "request.json") + "\ncase \"$1\" in\n" + script + "\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, Command("fake")), []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake plugin: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func readRequest(t *testing.T, dir string) Request {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatalf("failed to read request: %v", err)
+	}
+	var request Request
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("failed to parse request %q: %v", data, err)
+	}
+	return request
+}
+
+func TestList(t *testing.T) {
+	dir := fakePlugin(t, `list) echo '{"items":[{"id":"T-1","title":"Login","status":"Doing","number":1}],"statuses":["Todo","Doing","Done"]}' ;;`)
+
+	items, statuses, err := List("fake", map[string]string{"project": "WEB"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if expected := []Item{{ID: "T-1", Title: "Login", Status: "Doing", Number: 1}}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("List() items = %+v, want %+v", items, expected)
+	}
+	if expected := []string{"Todo", "Doing", "Done"}; !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("List() statuses = %v, want %v", statuses, expected)
+	}
+	if request := readRequest(t, dir); request.Op != "list" || request.Options["project"] != "WEB" {
+		t.Errorf("List() sent %+v, want the list op with the options", request)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := fakePlugin(t, `update) ;;`)
+
+	if err := SetStatus("fake", nil, Item{ID: "T-1"}, "Done"); err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+	if request := readRequest(t, dir); request.Op != "update" || request.Item == nil || request.Item.ID != "T-1" || request.Status != "Done" {
+		t.Errorf("SetStatus() sent %+v, want an update of T-1 to Done", request)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fakePlugin(t, `create) echo '{}' ;;
+list) echo '{"error":"not logged in"}' ;;
+update) echo "no such item" >&2; exit 1 ;;`)
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected string
+	}{
+		{
+			name:     "error in response",
+			call:     func() error { _, _, err := List("fake", nil); return err },
+			expected: "not logged in",
+		},
+		{
+			name:     "exit status",
+			call:     func() error { return SetTitle("fake", nil, Item{ID: "T-1"}, "Renamed") },
+			expected: "no such item",
+		},
+		{
+			name:     "no item created",
+			call:     func() error { _, err := Create("fake", nil, "New"); return err },
+			expected: "didn't return the item",
+		},
+		{
+			name:     "missing plugin",
+			call:     func() error { _, _, err := List("missing", nil); return err },
+			expected: "lfg-backend-missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.expected)
+			}
+		})
+	}
+}
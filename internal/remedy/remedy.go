@@ -0,0 +1,67 @@
+// Package remedy says what to do about the errors lfg knows, and which status lfg exits
+// with for each, so scripts can tell them apart
+package remedy
+
+import (
+	"errors"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// Statuses lfg exits with. 2 is left to flag's usage errors.
+const (
+	ExitFailure           = 1 // Anything not listed below
+	ExitNotInRepo         = 3
+	ExitWorktreeNotFound  = 4
+	ExitTmuxMissing       = 5
+	ExitGhUnauthenticated = 6
+	ExitProjectNotFound   = 7
+)
+
+// remedies are the errors lfg knows, with their exit statuses and what to do about them
+var remedies = []struct {
+	err  error
+	exit int
+	hint string
+}{
+	{config.ErrNotInRepo, ExitNotInRepo, "Run lfg in a git repository, or lfg on its own to open or clone one"},
+	{git.ErrWorktreeNotFound, ExitWorktreeNotFound, "lfg status lists the worktrees there are"},
+	{tmux.ErrTmuxMissing, ExitTmuxMissing, "Install tmux, e.g. brew install tmux or apt install tmux"},
+	{github.ErrGhUnauthenticated, ExitGhUnauthenticated, "Run gh auth login -s project, or set GH_TOKEN"},
+	{github.ErrProjectNotFound, ExitProjectNotFound, "Check storage_backend.project_number in lfg-config.yaml, and that gh can see the project (gh auth refresh -s project)"},
+}
+
+// Hint says what to do about err, "" for errors lfg knows no remedy for
+func Hint(err error) string {
+	for _, remedy := range remedies {
+		if errors.Is(err, remedy.err) {
+			return i18n.T(remedy.hint)
+		}
+	}
+	return ""
+}
+
+// Exit returns the status lfg exits with for err, 0 for none
+func Exit(err error) int {
+	if err == nil {
+		return 0
+	}
+	for _, remedy := range remedies {
+		if errors.Is(err, remedy.err) {
+			return remedy.exit
+		}
+	}
+	return ExitFailure
+}
+
+// Message is err followed by what to do about it, if lfg knows
+func Message(err error) string {
+	if hint := Hint(err); hint != "" {
+		return err.Error() + ". " + hint
+	}
+	return err.Error()
+}
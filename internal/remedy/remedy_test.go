@@ -0,0 +1,47 @@
+package remedy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+func TestRemedies(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		exit    int
+		message string
+	}{
+		{name: "none", err: nil, exit: 0},
+		{name: "unknown", err: errors.New("disk full"), exit: ExitFailure, message: "disk full"},
+		{name: "not in a repository", err: fmt.Errorf("failed to load config: %w", config.ErrNotInRepo), exit: ExitNotInRepo,
+			message: "failed to load config: not in a git repository. Run lfg in a git repository, or lfg on its own to open or clone one"},
+		{name: "worktree", err: fmt.Errorf("%w: proj-gone", git.ErrWorktreeNotFound), exit: ExitWorktreeNotFound,
+			message: "worktree not found: proj-gone. lfg status lists the worktrees there are"},
+		{name: "tmux", err: tmux.ErrTmuxMissing, exit: ExitTmuxMissing,
+			message: "tmux isn't installed. Install tmux, e.g. brew install tmux or apt install tmux"},
+		{name: "gh", err: fmt.Errorf("failed to list issues: %w", github.ErrGhUnauthenticated), exit: ExitGhUnauthenticated,
+			message: "failed to list issues: gh isn't logged in to GitHub. Run gh auth login -s project, or set GH_TOKEN"},
+		{name: "project", err: fmt.Errorf("%w: #3", github.ErrProjectNotFound), exit: ExitProjectNotFound,
+			message: "project not found: #3. Check storage_backend.project_number in lfg-config.yaml, and that gh can see the project (gh auth refresh -s project)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Exit(tt.err); got != tt.exit {
+				t.Errorf("Exit() = %d, want %d", got, tt.exit)
+			}
+			if tt.err == nil {
+				return
+			}
+			if got := Message(tt.err); got != tt.message {
+				t.Errorf("Message() = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
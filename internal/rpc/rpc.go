@@ -0,0 +1,177 @@
+// Package rpc serves lfg to editor plugins over stdio, as `lfg --rpc`: one JSON request per
+// line in, one JSON response per line out, answered in the order they're asked.
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/pkg/lfg"
+)
+
+// maxRequest is the longest line read as a request
+const maxRequest = 1 << 20
+
+// Repo is what requests are served from, an *lfg.Repo outside of tests
+type Repo interface {
+	Worktrees(ctx context.Context) ([]lfg.Worktree, error)
+	CreateWorktree(ctx context.Context, opts lfg.CreateOptions) ([]string, error)
+	DeleteWorktree(ctx context.Context, name string, item *lfg.Item) ([]string, error)
+	OpenSession(ctx context.Context, name string) (string, error)
+}
+
+// request is one line a plugin sends. Method is "list", "jump", "create" or "delete", and
+// ID is sent back with its response, whatever it is.
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params struct {
+		Name        string `json:"name"`
+		Branch      string `json:"branch"`
+		Base        string `json:"base"`
+		Description string `json:"description"`
+		Environment string `json:"environment"`
+	} `json:"params"`
+}
+
+// response is the line each request is answered with
+type response struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result any             `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// worktree is a worktree as list returns it
+type worktree struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Branch      string `json:"branch,omitempty"`
+	Main        bool   `json:"main,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Session     bool   `json:"session"`            // It has a running tmux session
+	Attached    int    `json:"attached,omitempty"` // Clients attached to the session
+}
+
+// jumped is jump's result. Outside of tmux there's no client to switch, so Attach is the
+// command for the plugin to run in a terminal to attach to the session.
+type jumped struct {
+	Session  string   `json:"session"`
+	Switched bool     `json:"switched"`
+	Attach   []string `json:"attach,omitempty"`
+}
+
+// changed is create's and delete's result
+type changed struct {
+	Warnings []string `json:"warnings"`
+}
+
+// Serve answers requests read from in on out until in ends
+func Serve(ctx context.Context, repo Repo, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxRequest)
+	encoder := json.NewEncoder(out)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req request
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp.ID = req.ID
+			result, err := handle(ctx, repo, req)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Result = result
+			}
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle runs one request, returning its result
+func handle(ctx context.Context, repo Repo, req request) (any, error) {
+	switch req.Method {
+	case "jump", "create", "delete":
+		if req.Params.Name == "" {
+			return nil, fmt.Errorf("%s needs a worktree name", req.Method)
+		}
+	}
+	switch req.Method {
+	case "list":
+		worktrees, err := repo.Worktrees(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]worktree, 0, len(worktrees))
+		for _, wt := range worktrees {
+			listed := worktree{Name: wt.Name, Path: wt.Path, Branch: wt.Branch, Main: wt.Main}
+			if wt.Task != nil {
+				listed.Description, listed.Status = wt.Task.Description, wt.Task.Status
+			}
+			if wt.Session != nil {
+				listed.Session, listed.Attached = true, wt.Session.Attached
+			}
+			result = append(result, listed)
+		}
+		return result, nil
+
+	case "jump":
+		session, err := repo.OpenSession(ctx, req.Params.Name)
+		if err != nil {
+			return nil, err
+		}
+		switched, err := tmux.SwitchClient(session)
+		if err != nil {
+			return nil, err
+		}
+		result := jumped{Session: session, Switched: switched}
+		if !switched {
+			result.Attach = []string{lfgPath(), req.Params.Name}
+		}
+		return result, nil
+
+	case "create":
+		warnings, err := repo.CreateWorktree(ctx, lfg.CreateOptions{
+			Name:        req.Params.Name,
+			Branch:      req.Params.Branch,
+			Base:        req.Params.Base,
+			Description: req.Params.Description,
+			Environment: req.Params.Environment,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return changed{Warnings: append([]string{}, warnings...)}, nil
+
+	case "delete":
+		warnings, err := repo.DeleteWorktree(ctx, req.Params.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		return changed{Warnings: append([]string{}, warnings...)}, nil
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+// lfgPath is this lfg, which attaches to a worktree's session wherever it's running
+func lfgPath() string {
+	if self, err := os.Executable(); err == nil {
+		return self
+	}
+	return "lfg"
+}
@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/pkg/lfg"
+)
+
+// fakeRepo is a repository with one worktree besides the main one
+type fakeRepo struct {
+	created lfg.CreateOptions
+	deleted string
+}
+
+func (r *fakeRepo) Worktrees(ctx context.Context) ([]lfg.Worktree, error) {
+	return []lfg.Worktree{
+		{Name: "proj", Path: "/src/proj", Branch: "main", Main: true},
+		{Name: "proj-login", Path: "/src/proj-login", Branch: "login", Task: &lfg.Task{Description: "Fix login", Status: "pending"}, Session: &lfg.Session{Name: "proj-login", Attached: 1}},
+	}, nil
+}
+
+func (r *fakeRepo) CreateWorktree(ctx context.Context, opts lfg.CreateOptions) ([]string, error) {
+	r.created = opts
+	return []string{"no item to link"}, nil
+}
+
+func (r *fakeRepo) DeleteWorktree(ctx context.Context, name string, item *lfg.Item) ([]string, error) {
+	if name != "proj-login" {
+		return nil, errors.New("worktree '" + name + "' not found")
+	}
+	r.deleted = name
+	return nil, nil
+}
+
+func (r *fakeRepo) OpenSession(ctx context.Context, name string) (string, error) {
+	return strings.ReplaceAll(name, ".", "_"), nil
+}
+
+func TestServe(t *testing.T) {
+	// Outside of tmux, so jump says how to attach rather than switching a client
+	t.Setenv("TMUX", "")
+	fake := &runner.Fake{}
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	requests := strings.Join([]string{
+		`{"id":1,"method":"list"}`,
+		``,
+		`{"id":"two","method":"create","params":{"name":"proj-docs","description":"Write docs","environment":"staging"}}`,
+		`{"id":3,"method":"jump","params":{"name":"proj-v1.2"}}`,
+		`{"id":4,"method":"delete","params":{"name":"proj-gone"}}`,
+		`{"id":5,"method":"delete","params":{"name":"proj-login"}}`,
+		`{"id":6,"method":"delete"}`,
+		`{"id":7,"method":"rename","params":{"name":"proj-login"}}`,
+		`not json`,
+	}, "\n")
+	repo := &fakeRepo{}
+	var out bytes.Buffer
+	if err := Serve(context.Background(), repo, strings.NewReader(requests), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	expected := []string{
+		`{"id":1,"result":[{"name":"proj","path":"/src/proj","branch":"main","main":true,"session":false},{"name":"proj-login","path":"/src/proj-login","branch":"login","description":"Fix login","status":"pending","session":true,"attached":1}]}`,
+		`{"id":"two","result":{"warnings":["no item to link"]}}`,
+		`{"id":3,"result":{"session":"proj-v1_2","switched":false,"attach":["` + lfgPath() + `","proj-v1.2"]}}`,
+		`{"id":4,"error":"worktree 'proj-gone' not found"}`,
+		`{"id":5,"result":{"warnings":[]}}`,
+		`{"id":6,"error":"delete needs a worktree name"}`,
+		`{"id":7,"error":"unknown method \"rename\""}`,
+		`{"error":"invalid request: invalid character 'o' in literal null (expecting 'u')"}`,
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Serve() wrote %d responses, want %d:\n%s", len(lines), len(expected), out.String())
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("response %d = %s, want %s", i+1, line, expected[i])
+		}
+	}
+	if want := (lfg.CreateOptions{Name: "proj-docs", Description: "Write docs", Environment: "staging"}); repo.created != want {
+		t.Errorf("create made %+v, want %+v", repo.created, want)
+	}
+	if len(fake.Calls()) != 0 {
+		t.Errorf("jump ran %v outside of tmux", fake.Calls())
+	}
+}
@@ -0,0 +1,277 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner runs the external commands lfg drives: git, tmux and gh
+type Runner interface {
+	// Run runs name with args and returns what it printed to stdout. stdin is fed to the
+	// command when it isn't nil. A failure is an *Error carrying the command's stderr.
+	Run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error)
+	// Attach runs name with args on the terminal, for commands like tmux attach that take
+	// it over until they exit
+	Attach(name string, args ...string) error
+}
+
+// Starter is a Runner that can also start a command to talk to while it runs, like tmux in
+// control mode
+type Starter interface {
+	Start(name string, args ...string) (*Process, error)
+}
+
+// Process is a command a Starter started
+type Process struct {
+	Stdin  io.WriteCloser // Closing it tells the command there's no more input
+	Stdout io.Reader
+	Wait   func() error // Waits for the command to exit once Stdout has been read
+}
+
+// Launcher is a Runner that can also launch a command that runs for as long as it takes, in
+// a directory and environment of its own, like the agent working in a worktree
+type Launcher interface {
+	Launch(job Job, name string, args ...string) (*Launched, error)
+}
+
+// Job is where a launched command runs and what it reads and writes
+type Job struct {
+	Dir    string   // The directory it's run in; "" is lfg's
+	Env    []string // Its whole environment; nil is lfg's
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Launched is a command a Launcher launched
+type Launched struct {
+	Signal func(os.Signal) error // Passes a signal on to the command
+	Kill   func() error
+	Wait   func() error // Waits for the command to exit
+}
+
+// Error is a command that failed
+type Error struct {
+	Command string // The command line, for messages
+	Stderr  string // What the command wrote to stderr, trimmed
+	Err     error  // The exit status, or the context's error if it timed out or was cancelled
+}
+
+func (e *Error) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("%s timed out", e.Command)
+	}
+	// The command's own complaint says more than its exit status
+	if e.Stderr != "" {
+		return e.Stderr
+	}
+	return fmt.Sprintf("%s: %v", e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Stderr returns what a failed command wrote to stderr, or "" if err isn't from a Runner
+func Stderr(err error) string {
+	var runErr *Error
+	if errors.As(err, &runErr) {
+		return runErr.Stderr
+	}
+	return ""
+}
+
+// Exec runs commands for real
+type Exec struct {
+	// Timeout bounds each command that isn't already given a deadline by its context, so
+	// a hung command fails instead of blocking lfg forever. Zero means no limit.
+	Timeout time.Duration
+}
+
+// waitDelay is how long a cancelled command's output is waited on, so hooks or helpers
+// that outlive it don't hold lfg up
+const waitDelay = time.Second
+
+func (e Exec) Run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.WaitDelay = waitDelay
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return output, &Error{Command: commandLine(name, args), Stderr: strings.TrimSpace(stderr.String()), Err: err}
+	}
+	return output, nil
+}
+
+func (e Exec) Attach(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return &Error{Command: commandLine(name, args), Err: err}
+	}
+	return nil
+}
+
+func (e Exec) Start(name string, args ...string) (*Process, error) {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, &Error{Command: commandLine(name, args), Err: err}
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, &Error{Command: commandLine(name, args), Err: err}
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, &Error{Command: commandLine(name, args), Err: err}
+	}
+	wait := func() error {
+		if err := cmd.Wait(); err != nil {
+			return &Error{Command: commandLine(name, args), Stderr: strings.TrimSpace(stderr.String()), Err: err}
+		}
+		return nil
+	}
+	return &Process{Stdin: stdin, Stdout: stdout, Wait: wait}, nil
+}
+
+func (e Exec) Launch(job Job, name string, args ...string) (*Launched, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = job.Dir
+	cmd.Env = job.Env
+	cmd.Stdin = job.Stdin
+	cmd.Stdout = job.Stdout
+	cmd.Stderr = job.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, &Error{Command: commandLine(name, args), Err: err}
+	}
+	wait := func() error {
+		if err := cmd.Wait(); err != nil {
+			return &Error{Command: commandLine(name, args), Err: err}
+		}
+		return nil
+	}
+	return &Launched{Signal: cmd.Process.Signal, Kill: cmd.Process.Kill, Wait: wait}, nil
+}
+
+// commandLine names a command for messages, e.g. "git worktree list"
+func commandLine(name string, args []string) string {
+	line := []string{name}
+	for _, arg := range args {
+		// Queries and bodies make messages unreadable, the subcommand is enough
+		if len(line) >= 3 || strings.ContainsAny(arg, " \n") {
+			break
+		}
+		line = append(line, arg)
+	}
+	return strings.Join(line, " ")
+}
+
+// Fake answers commands from canned responses, recording each it's given, so the packages
+// that shell out can be tested without git, tmux or gh
+type Fake struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses []response
+}
+
+// Call is a command a Fake was asked to run
+type Call struct {
+	Args  []string // The command and its arguments
+	Stdin string
+	Dir   string   // Where a launched command was run
+	Env   []string // A launched command's environment
+}
+
+// String is the command line, arguments separated by spaces
+func (c Call) String() string {
+	return strings.Join(c.Args, " ")
+}
+
+type response struct {
+	prefix string
+	stdout string
+	err    error
+}
+
+// On answers commands whose line starts with prefix, e.g. "git worktree list", with stdout
+// and err. The earliest matching response is used.
+func (f *Fake) On(prefix, stdout string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, response{prefix: prefix, stdout: stdout, err: err})
+}
+
+// Calls returns every command run so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+func (f *Fake) Run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	call := Call{Args: append([]string{name}, args...)}
+	if stdin != nil {
+		input, _ := io.ReadAll(stdin)
+		call.Stdin = string(input)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	for _, r := range f.responses {
+		if strings.HasPrefix(call.String(), r.prefix) {
+			return []byte(r.stdout), r.err
+		}
+	}
+	return nil, &Error{Command: commandLine(name, args), Err: errors.New("no fake response")}
+}
+
+func (f *Fake) Attach(name string, args ...string) error {
+	_, err := f.Run(context.Background(), nil, name, args...)
+	return err
+}
+
+// Launch answers a launched command like Run, writing the response to the job's stdout as
+// it's waited for. Its stdin isn't read, as it's usually the terminal.
+func (f *Fake) Launch(job Job, name string, args ...string) (*Launched, error) {
+	output, err := f.Run(context.Background(), nil, name, args...)
+	f.mu.Lock()
+	f.calls[len(f.calls)-1].Dir = job.Dir
+	f.calls[len(f.calls)-1].Env = job.Env
+	f.mu.Unlock()
+	wait := func() error {
+		if job.Stdout != nil {
+			job.Stdout.Write(output)
+		}
+		return err
+	}
+	return &Launched{
+		Signal: func(os.Signal) error { return nil },
+		Kill:   func() error { return nil },
+		Wait:   wait,
+	}, nil
+}
@@ -0,0 +1,185 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   Exec
+		stdin    string
+		script   string
+		expected string // Expected stdout
+		errLike  string // Expected in the error, empty for success
+	}{
+		{
+			name:     "stdout",
+			script:   "echo hello",
+			expected: "hello\n",
+		},
+		{
+			name:     "stdin",
+			stdin:    "piped",
+			script:   "cat",
+			expected: "piped",
+		},
+		{
+			name:    "stderr in the error",
+			script:  "echo 'fatal: not a git repository' >&2; exit 128",
+			errLike: "fatal: not a git repository",
+		},
+		{
+			name:    "exit status without stderr",
+			script:  "exit 3",
+			errLike: "exit status 3",
+		},
+		{
+			name:    "timeout",
+			runner:  Exec{Timeout: 50 * time.Millisecond},
+			script:  "sleep 5",
+			errLike: "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdin io.Reader
+			if tt.stdin != "" {
+				stdin = strings.NewReader(tt.stdin)
+			}
+			output, err := tt.runner.Run(context.Background(), stdin, "sh", "-c", tt.script)
+
+			if tt.errLike == "" {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				if string(output) != tt.expected {
+					t.Errorf("Run() = %q, want %q", output, tt.expected)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errLike) {
+				t.Errorf("Run() error = %v, want one mentioning %q", err, tt.errLike)
+			}
+		})
+	}
+}
+
+func TestExecRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := Exec{}.Run(ctx, nil, "sleep", "5")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %v after being cancelled", elapsed)
+	}
+}
+
+func TestStderr(t *testing.T) {
+	_, err := Exec{}.Run(context.Background(), nil, "sh", "-c", "echo 'no server running' >&2; exit 1")
+	if got := Stderr(err); got != "no server running" {
+		t.Errorf("Stderr() = %q, want %q", got, "no server running")
+	}
+	if got := Stderr(errors.New("other")); got != "" {
+		t.Errorf("Stderr() of a plain error = %q, want empty", got)
+	}
+}
+
+func TestExecLaunch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "name"), []byte("worktree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout strings.Builder
+	job := Job{Dir: dir, Env: []string{"GREETING=hello"}, Stdout: &stdout}
+	launched, err := Exec{}.Launch(job, "sh", "-c", `echo "$GREETING from $(cat name)"`)
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	if err := launched.Wait(); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if want := "hello from worktree\n"; stdout.String() != want {
+		t.Errorf("Launch() wrote %q, want %q", stdout.String(), want)
+	}
+
+	launched, err = Exec{}.Launch(Job{}, "sleep", "5")
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	launched.Signal(os.Interrupt)
+	if err := launched.Wait(); err == nil {
+		t.Error("Wait() on an interrupted command = nil, want its exit")
+	}
+}
+
+func TestFake(t *testing.T) {
+	var fake Fake
+	fake.On("git worktree list", "worktree /repo\n", nil)
+	fake.On("git", "", errors.New("fallback"))
+
+	output, err := fake.Run(context.Background(), nil, "git", "worktree", "list", "--porcelain")
+	if err != nil || string(output) != "worktree /repo\n" {
+		t.Errorf("Run() = %q, %v, want the worktree list", output, err)
+	}
+	if _, err := fake.Run(context.Background(), strings.NewReader("input"), "git", "status"); err == nil || err.Error() != "fallback" {
+		t.Errorf("Run() error = %v, want the fallback", err)
+	}
+	if _, err := fake.Run(context.Background(), nil, "gh", "auth", "status"); err == nil {
+		t.Error("Run() of an unexpected command succeeded")
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("Calls() = %d calls, want 3", len(calls))
+	}
+	if calls[0].String() != "git worktree list --porcelain" {
+		t.Errorf("Calls()[0] = %q", calls[0])
+	}
+	if calls[1].Stdin != "input" {
+		t.Errorf("Calls()[1].Stdin = %q, want %q", calls[1].Stdin, "input")
+	}
+}
+
+func TestSSH(t *testing.T) {
+	local := &Fake{}
+	local.On("ssh", "", &Error{Command: "ssh", Stderr: "no server running on /tmp/tmux-1000/default", Err: errors.New("exit status 1")})
+	host := SSH{Host: "me@devbox", Dir: "~/src/my app", Options: []string{"-p", "2222"}, Local: local}
+
+	_, err := host.Run(context.Background(), nil, "tmux", "list-sessions", "-F", "#{session_name}")
+	if Stderr(err) != "no server running on /tmp/tmux-1000/default" {
+		t.Errorf("Run() error = %v, want tmux's stderr", err)
+	}
+	if err == nil || !strings.HasPrefix(err.(*Error).Command, "tmux list-sessions") {
+		t.Errorf("Run() error names %v, want the command run on the host", err)
+	}
+	if err := host.Attach("tmux", "attach-session", "-t", "proj"); err == nil {
+		t.Error("Attach() succeeded, want the fake's error")
+	}
+
+	calls := local.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Calls() = %d calls, want 2", len(calls))
+	}
+	if want := "-p 2222 -- me@devbox cd ~/'src/my app' && 'tmux' 'list-sessions' '-F' '#{session_name}'"; !strings.HasSuffix(calls[0].String(), want) {
+		t.Errorf("Run() ran %q, want it to end %q", calls[0], want)
+	}
+	if !strings.Contains(calls[1].String(), "-p 2222 -t -- me@devbox") {
+		t.Errorf("Attach() ran %q, want a terminal on the host", calls[1])
+	}
+}
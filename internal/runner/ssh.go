@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sshOptions share one connection to the host between lfg's commands, so each git or tmux
+// call doesn't pay for a new SSH handshake
+var sshOptions = []string{"-o", "ControlMaster=auto", "-o", "ControlPath=~/.ssh/lfg-%C", "-o", "ControlPersist=10m"}
+
+// SSH runs commands on another host over ssh, in Dir there, through Local
+type SSH struct {
+	Host    string   // What ssh connects to, e.g. "me@devbox"
+	Dir     string   // The directory commands are run in on the host; a leading ~/ is the home directory there
+	Options []string // More arguments for ssh, e.g. "-p", "2222"
+	Local   Runner   // Runs ssh itself
+}
+
+func (s SSH) Run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	output, err := s.Local.Run(ctx, stdin, "ssh", s.args(false, name, args)...)
+	return output, s.wrap(err, name, args)
+}
+
+func (s SSH) Attach(name string, args ...string) error {
+	// -t gives the command a terminal on the host, which tmux attach needs
+	return s.wrap(s.Local.Attach("ssh", s.args(true, name, args)...), name, args)
+}
+
+func (s SSH) Start(name string, args ...string) (*Process, error) {
+	local, ok := s.Local.(Starter)
+	if !ok {
+		return nil, &Error{Command: commandLine(name, args) + " on " + s.Host, Err: errors.New("ssh can't be started to talk to")}
+	}
+	return local.Start("ssh", s.args(false, name, args)...)
+}
+
+// args are ssh's arguments for running name with args on the host
+func (s SSH) args(terminal bool, name string, args []string) []string {
+	sshArgs := append(append([]string{}, sshOptions...), s.Options...)
+	if terminal {
+		sshArgs = append(sshArgs, "-t")
+	}
+	remote := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		remote = append(remote, quote(arg))
+	}
+	command := strings.Join(remote, " ")
+	if s.Dir != "" {
+		command = "cd " + quoteDir(s.Dir) + " && " + command
+	}
+	return append(sshArgs, "--", s.Host, command)
+}
+
+// wrap names the command that failed as the one run on the host, rather than ssh
+func (s SSH) wrap(err error, name string, args []string) error {
+	var runErr *Error
+	if !errors.As(err, &runErr) {
+		return err
+	}
+	return &Error{Command: commandLine(name, args) + " on " + s.Host, Stderr: runErr.Stderr, Err: runErr.Err}
+}
+
+// quote quotes s as a single word for the host's shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// quoteDir quotes a directory for the host's shell, leaving a leading ~/ for it to expand
+func quoteDir(dir string) string {
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		return "~/" + quote(rest)
+	}
+	return quote(dir)
+}
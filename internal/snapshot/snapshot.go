@@ -0,0 +1,222 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// idFormat names snapshots by when they were taken
+const idFormat = "20060102-150405"
+
+// Snapshot is a worktree's working state as it was: the commit it was on, its uncommitted
+// changes and its tmux session
+type Snapshot struct {
+	ID        string       `json:"id"`
+	Worktree  string       `json:"worktree"`
+	Message   string       `json:"message,omitempty"` // What it was taken for, if it was said
+	CreatedAt time.Time    `json:"created_at"`
+	Branch    string       `json:"branch"`
+	Head      string       `json:"head"`                // The commit checked out
+	Untracked []string     `json:"untracked,omitempty"` // Files kept as they were, as git doesn't track them
+	Window    *tmux.Window `json:"window,omitempty"`    // The session's panes, if it had one
+}
+
+// Dir is where a worktree's snapshots are kept, one directory each
+func Dir(cfg *config.Config, worktree string) string {
+	return filepath.Join(state.Dir(cfg.GetConfigPath()), "snapshots", worktree)
+}
+
+// Take records a worktree's working state. Changes to tracked files are kept as a patch,
+// untracked files as copies, and the worktree itself is left as it is.
+func Take(cfg *config.Config, worktree, message string) (*Snapshot, error) {
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return nil, err
+	}
+	snap := &Snapshot{Worktree: worktree, Message: message, CreatedAt: time.Now()}
+	snap.ID = snap.CreatedAt.Format(idFormat)
+	if snap.Branch, err = git.GetBranch(path); err != nil {
+		return nil, err
+	}
+	if snap.Head, err = git.Head(path); err != nil {
+		return nil, err
+	}
+	changes, err := git.Changes(path)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git.UntrackedFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	// lfg's own state, snapshots included, is in the main worktree
+	stateDir := state.Dir(cfg.GetConfigPath()) + string(filepath.Separator)
+	for _, file := range untracked {
+		if !strings.HasPrefix(filepath.Join(path, file), stateDir) {
+			snap.Untracked = append(snap.Untracked, file)
+		}
+	}
+	if snap.Window, err = tmux.CaptureWindow(worktree); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(Dir(cfg, worktree), snap.ID)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "changes.patch"), changes, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save changes: %w", err)
+	}
+	for _, file := range snap.Untracked {
+		if err := copyFile(filepath.Join(path, file), filepath.Join(dir, "untracked", file)); err != nil {
+			return nil, fmt.Errorf("failed to save %s: %w", file, err)
+		}
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "snapshot.json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// List returns a worktree's snapshots, oldest first. Ones that can't be read are skipped.
+func List(cfg *config.Config, worktree string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(Dir(cfg, worktree))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	var snaps []Snapshot
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(Dir(cfg, worktree), entry.Name(), "snapshot.json"))
+		if err != nil {
+			continue
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err == nil {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].CreatedAt.Before(snaps[j].CreatedAt) })
+	return snaps, nil
+}
+
+// Restored is what Restore put back
+type Restored struct {
+	Snapshot  *Snapshot
+	MovedFrom string // The commit the branch was moved back from, "" if it was already there
+	Session   bool   // Whether the tmux session was recreated
+}
+
+// Restore puts a worktree back as a snapshot has it, the latest when id is "": the branch
+// back on the snapshot's commit, its uncommitted changes and untracked files back, and its
+// session recreated if it has none. A worktree with uncommitted changes of its own is
+// refused unless force, as they'd be thrown away.
+func Restore(cfg *config.Config, worktree, id string, force bool) (*Restored, error) {
+	snaps, err := List(cfg, worktree)
+	if err != nil {
+		return nil, err
+	}
+	var snap *Snapshot
+	for i := range snaps {
+		if id == "" || snaps[i].ID == id {
+			snap = &snaps[i]
+		}
+	}
+	if snap == nil && id == "" {
+		return nil, fmt.Errorf("%s has no snapshots", worktree)
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("%s has no snapshot %s", worktree, id)
+	}
+
+	path, err := git.GetWorktreePath(worktree)
+	if err != nil {
+		return nil, err
+	}
+	if branch, err := git.GetBranch(path); err != nil {
+		return nil, err
+	} else if branch != snap.Branch {
+		return nil, fmt.Errorf("%s is on %s, but the snapshot was taken on %s; check %s out first", worktree, branch, snap.Branch, snap.Branch)
+	}
+	if count, err := git.DirtyFileCount(path); err != nil {
+		return nil, err
+	} else if count > 0 && !force {
+		return nil, fmt.Errorf("%s has %d uncommitted files, which restoring would throw away; commit them, take a snapshot of them or pass --force", worktree, count)
+	}
+
+	restored := &Restored{Snapshot: snap}
+	if head, err := git.Head(path); err == nil && head != snap.Head {
+		restored.MovedFrom = head
+	}
+	if err := git.ResetHard(path, snap.Head); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(Dir(cfg, worktree), snap.ID)
+	changes, err := os.ReadFile(filepath.Join(dir, "changes.patch"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	if len(changes) > 0 {
+		if err := git.ApplyPatch(path, changes); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range snap.Untracked {
+		if err := copyFile(filepath.Join(dir, "untracked", file), filepath.Join(path, file)); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+	}
+
+	if snap.Window != nil && !tmux.SessionExists(tmux.SanitizeSessionName(worktree)) {
+		if err := tmux.RestoreSession(worktree, path, cfg, snap.Window); err != nil {
+			return restored, err
+		}
+		restored.Session = true
+	}
+	return restored, nil
+}
+
+// copyFile copies the file at from to to, with its permissions, making to's directory
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
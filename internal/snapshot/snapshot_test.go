@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/testrepo"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+func TestTakeAndRestore(t *testing.T) {
+	repo := testrepo.New(t)
+	cfg := repo.Config("name: proj\n")
+	path := repo.AddWorktree("proj-login")
+	fake := &runner.Fake{}
+	fake.On("tmux has-session", "", errors.New("no session"))
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	write("README.md", "# proj, half rewritten\n")
+	write("login.go", "package login\n")
+
+	snap, err := Take(cfg, "proj-login", "before rebasing")
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if snap.Branch != "proj-login" || len(snap.Untracked) != 1 || snap.Untracked[0] != "login.go" || snap.Window != nil {
+		t.Errorf("Take() = %+v, want the branch and login.go, without a session", snap)
+	}
+	if read("README.md") != "# proj, half rewritten\n" {
+		t.Error("Take() changed the worktree")
+	}
+
+	// A rebase gone wrong: the work committed and the branch rewritten
+	repo.Git("-C", path, "add", "--all")
+	repo.Git("-C", path, "commit", "--quiet", "-m", "Squashed by mistake")
+	write("README.md", "# conflict markers\n")
+
+	if _, err := Restore(cfg, "proj-login", "", false); err == nil {
+		t.Fatal("Restore() over uncommitted changes succeeded without force")
+	}
+	restored, err := Restore(cfg, "proj-login", "", true)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.Snapshot.ID != snap.ID || restored.MovedFrom == "" || restored.Session {
+		t.Errorf("Restore() = %+v, want the branch moved back and no session", restored)
+	}
+	if head := repo.Git("-C", path, "rev-parse", "HEAD"); head != snap.Head {
+		t.Errorf("HEAD = %s, want the snapshot's %s", head, snap.Head)
+	}
+	if got := read("README.md"); got != "# proj, half rewritten\n" {
+		t.Errorf("README.md = %q, want the uncommitted change back", got)
+	}
+	if got := read("login.go"); got != "package login\n" {
+		t.Errorf("login.go = %q, want the untracked file back", got)
+	}
+
+	snaps, err := List(cfg, "proj-login")
+	if err != nil || len(snaps) != 1 || snaps[0].Message != "before rebasing" {
+		t.Errorf("List() = %+v, %v, want the one snapshot", snaps, err)
+	}
+	if _, err := Restore(cfg, "proj-login", "19990101-000000", false); err == nil {
+		t.Error("Restore() of a snapshot that doesn't exist succeeded")
+	}
+}
@@ -0,0 +1,586 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/sharedfile"
+)
+
+// RunStatus is the lifecycle state of a headless agent run
+type RunStatus string
+
+const (
+	RunStatusQueued    RunStatus = "queued"
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+// Run records a single headless agent task queued against a worktree
+type Run struct {
+	ID         string    `yaml:"id"`
+	Worktree   string    `yaml:"worktree"`
+	Prompt     string    `yaml:"prompt"`
+	Status     RunStatus `yaml:"status"`
+	QueuedAt   time.Time `yaml:"queued_at"`
+	StartedAt  time.Time `yaml:"started_at,omitempty"`
+	FinishedAt time.Time `yaml:"finished_at,omitempty"`
+	PID        int       `yaml:"pid,omitempty"`        // The lfg run working on it, while it's running
+	Transcript string    `yaml:"transcript,omitempty"` // Path to the transcript file
+	Error      string    `yaml:"error,omitempty"`
+}
+
+// Alive reports whether the run is running in a process that's still there. One whose lfg
+// run was killed stays running in the state until it's reaped.
+func (r Run) Alive() bool {
+	return r.Status == RunStatusRunning && processAlive(r.PID)
+}
+
+// SessionMark is the high-water mark of a mirrored Claude session log
+type SessionMark struct {
+	Worktree string `yaml:"worktree"`
+	Offset   int64  `yaml:"offset"`
+	LastUUID string `yaml:"last_uuid,omitempty"`
+}
+
+// Bootstrap is the install of a new worktree's dependencies, run in the background
+type Bootstrap struct {
+	Command    string    `yaml:"command"`
+	Status     RunStatus `yaml:"status"`
+	StartedAt  time.Time `yaml:"started_at"`
+	FinishedAt time.Time `yaml:"finished_at,omitempty"`
+	Log        string    `yaml:"log,omitempty"` // Path to the command's output
+	Error      string    `yaml:"error,omitempty"`
+}
+
+// Review is a pull request checked out in a worktree of its own by lfg review
+type Review struct {
+	Owner  string `yaml:"owner"`
+	Repo   string `yaml:"repo"`
+	Number int    `yaml:"number"`
+}
+
+// MonitorStatus reports on, and lets the TUI control, a worktree's conversation monitor.
+// The monitor owns every field except Paused and FlushRequestedAt, which the TUI sets.
+type MonitorStatus struct {
+	PID              int       `yaml:"pid"`
+	Active           bool      `yaml:"active"`
+	Paused           bool      `yaml:"paused,omitempty"`
+	FlushRequestedAt time.Time `yaml:"flush_requested_at,omitempty"`
+	Target           int       `yaml:"target,omitempty"` // Issue or PR number being mirrored to
+	Daily            bool      `yaml:"daily,omitempty"`  // Conversation is summed up once a day rather than mirrored
+	LastPostAt       time.Time `yaml:"last_post_at,omitempty"`
+	Pending          int       `yaml:"pending,omitempty"`
+	Errors           int       `yaml:"errors,omitempty"`
+	LastError        string    `yaml:"last_error,omitempty"`
+}
+
+// Running reports whether the monitor's process is still alive
+func (m MonitorStatus) Running() bool {
+	return m.Active && processAlive(m.PID)
+}
+
+// processAlive reports whether the process with pid is still running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Equal reports whether two statuses are the same, comparing times by instant
+func (m MonitorStatus) Equal(o MonitorStatus) bool {
+	return m.PID == o.PID &&
+		m.Active == o.Active &&
+		m.Paused == o.Paused &&
+		m.FlushRequestedAt.Equal(o.FlushRequestedAt) &&
+		m.Target == o.Target &&
+		m.Daily == o.Daily &&
+		m.LastPostAt.Equal(o.LastPostAt) &&
+		m.Pending == o.Pending &&
+		m.Errors == o.Errors &&
+		m.LastError == o.LastError
+}
+
+// Summary describes the monitor in a single line, e.g. "Mirror: on · posted 2m ago · 1 error"
+func (m MonitorStatus) Summary() string {
+	parts := []string{"Mirror: on"}
+	if m.Paused {
+		parts[0] = "Mirror: paused"
+	} else if m.Daily {
+		parts[0] = "Mirror: daily"
+	}
+	if m.Target > 0 {
+		parts = append(parts, fmt.Sprintf("#%d", m.Target))
+	}
+	if !m.LastPostAt.IsZero() {
+		parts = append(parts, "posted "+humanize.Ago(m.LastPostAt))
+	}
+	if m.Pending > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending", m.Pending))
+	}
+	if m.Errors == 1 {
+		parts = append(parts, "1 error")
+	} else if m.Errors > 1 {
+		parts = append(parts, fmt.Sprintf("%d errors", m.Errors))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// maxRecentWorktrees bounds the list of recently used worktrees
+const maxRecentWorktrees = 10
+
+// maxMirroredUUIDs bounds the per-worktree list of message UUIDs already mirrored
+const maxMirroredUUIDs = 1000
+
+// maxFinishedRuns bounds how many finished runs are kept for each worktree, enough for its
+// daily summaries and the TUI's last run
+const maxFinishedRuns = 50
+
+// State holds machine-local data that shouldn't live in the shared config
+// (which is usually committed to the repository)
+type State struct {
+	Runs          []Run                    `yaml:"runs,omitempty"`
+	Sessions      map[string]SessionMark   `yaml:"sessions,omitempty"`       // Keyed by session log path
+	MirroredUUIDs map[string][]string      `yaml:"mirrored_uuids,omitempty"` // Keyed by worktree
+	Monitors      map[string]MonitorStatus `yaml:"monitors,omitempty"`       // Keyed by worktree
+	SortMode      string                   `yaml:"sort_mode,omitempty"`      // How the TUI orders the worktree list
+	Recent        []string                 `yaml:"recent,omitempty"`         // Recently used worktrees, most recent first
+	ShowArchived  bool                     `yaml:"show_archived,omitempty"`  // Whether the TUI lists long-finished items
+	Collapsed     []string                 `yaml:"collapsed,omitempty"`      // Epics the TUI folds away when grouping by epic, by their key
+	PortBlocks    map[string]int           `yaml:"port_blocks,omitempty"`    // The block of ports each worktree has, keyed by worktree
+	Bootstraps    map[string]Bootstrap     `yaml:"bootstraps,omitempty"`     // Dependency installs in new worktrees, keyed by worktree
+	Reviews       map[string]Review        `yaml:"reviews,omitempty"`        // Pull requests checked out to review, keyed by worktree
+	Summarized    map[string]string        `yaml:"summarized,omitempty"`     // The last day each worktree's work was summed up on its issue, as 2006-01-02, keyed by worktree
+	path          string
+}
+
+const fileName = "state.yaml"
+
+// Dir returns the directory lfg uses for local state, next to the config file or with it
+// under .git/lfg
+func Dir(configPath string) string {
+	return config.StateDir(configPath)
+}
+
+// Load reads the state for the given config path, returning empty state if none exists yet
+func Load(configPath string) (*State, error) {
+	path := filepath.Join(Dir(configPath), fileName)
+
+	s := &State{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	s.path = path
+	return s, nil
+}
+
+// Save writes the state to disk
+func (s *State) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := sharedfile.Write(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return nil
+}
+
+// Update makes change to the state as it is on disk and saves it, holding a lock so that
+// processes updating the state at once each keep their changes. It returns what was saved.
+func Update(configPath string, change func(s *State) error) (*State, error) {
+	unlock, err := sharedfile.Lock(filepath.Join(Dir(configPath), "state.lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(s); err != nil {
+		return nil, err
+	}
+	if err := s.Save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EnqueueRun adds a new queued run for a worktree and returns it
+func (s *State) EnqueueRun(worktree, prompt string) *Run {
+	now := time.Now()
+	s.Runs = append(s.Runs, Run{
+		ID:       fmt.Sprintf("%s-%d", worktree, now.UnixNano()),
+		Worktree: worktree,
+		Prompt:   prompt,
+		Status:   RunStatusQueued,
+		QueuedAt: now,
+	})
+	return &s.Runs[len(s.Runs)-1]
+}
+
+// GetRun returns the run with the given ID
+func (s *State) GetRun(id string) *Run {
+	for i := range s.Runs {
+		if s.Runs[i].ID == id {
+			return &s.Runs[i]
+		}
+	}
+	return nil
+}
+
+// NextQueuedRun returns the oldest queued run for a worktree
+func (s *State) NextQueuedRun(worktree string) *Run {
+	for i := range s.Runs {
+		if s.Runs[i].Worktree == worktree && s.Runs[i].Status == RunStatusQueued {
+			return &s.Runs[i]
+		}
+	}
+	return nil
+}
+
+// HasRunningRun reports whether a worktree already has a run in progress in a process
+// that's still there
+func (s *State) HasRunningRun(worktree string) bool {
+	for _, r := range s.Runs {
+		if r.Worktree == worktree && r.Alive() {
+			return true
+		}
+	}
+	return false
+}
+
+// StartNextRun marks the oldest queued run for a worktree as running in the process with
+// pid and returns it, unless the worktree already has one running or none queued. Runs whose
+// process has gone are failed first, so a killed lfg run doesn't hold up the queue for good.
+// Checking and marking in the same Update keeps two processes from both starting a run.
+func (s *State) StartNextRun(worktree string, pid int) *Run {
+	s.ReapRuns()
+	if s.HasRunningRun(worktree) {
+		return nil
+	}
+	next := s.NextQueuedRun(worktree)
+	if next == nil {
+		return nil
+	}
+	next.Status = RunStatusRunning
+	next.StartedAt = time.Now()
+	next.PID = pid
+	return next
+}
+
+// ReapRuns fails the runs left running by processes that have gone
+func (s *State) ReapRuns() {
+	for i := range s.Runs {
+		if run := &s.Runs[i]; run.Status == RunStatusRunning && !run.Alive() {
+			run.Status = RunStatusFailed
+			run.FinishedAt = time.Now()
+			run.PID = 0
+			run.Error = "the lfg run working on it exited before it finished"
+		}
+	}
+}
+
+// PruneRuns drops each worktree's oldest finished runs beyond maxFinishedRuns
+func (s *State) PruneRuns() {
+	finished := make(map[string]int)
+	kept := make([]Run, 0, len(s.Runs))
+	for i := len(s.Runs) - 1; i >= 0; i-- {
+		run := s.Runs[i]
+		if run.Status == RunStatusSucceeded || run.Status == RunStatusFailed {
+			if finished[run.Worktree]++; finished[run.Worktree] > maxFinishedRuns {
+				continue
+			}
+		}
+		kept = append(kept, run)
+	}
+	slices.Reverse(kept)
+	s.Runs = kept
+}
+
+// LatestRun returns the most recently queued run for a worktree
+func (s *State) LatestRun(worktree string) *Run {
+	for i := len(s.Runs) - 1; i >= 0; i-- {
+		if s.Runs[i].Worktree == worktree {
+			return &s.Runs[i]
+		}
+	}
+	return nil
+}
+
+// CountQueuedRuns returns how many runs are waiting for a worktree
+func (s *State) CountQueuedRuns(worktree string) int {
+	count := 0
+	for _, r := range s.Runs {
+		if r.Worktree == worktree && r.Status == RunStatusQueued {
+			count++
+		}
+	}
+	return count
+}
+
+// SessionOffset returns how far into a session log has already been mirrored
+func (s *State) SessionOffset(logPath string) int64 {
+	return s.Sessions[logPath].Offset
+}
+
+// SetSessionMark records how far into a session log has been mirrored
+func (s *State) SetSessionMark(logPath string, mark SessionMark) {
+	if s.Sessions == nil {
+		s.Sessions = make(map[string]SessionMark)
+	}
+	s.Sessions[logPath] = mark
+}
+
+// HasMirrored reports whether a message has already been mirrored for a worktree
+func (s *State) HasMirrored(worktree, uuid string) bool {
+	for _, id := range s.MirroredUUIDs[worktree] {
+		if id == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkMirrored records a message as mirrored, keeping only the most recent UUIDs
+func (s *State) MarkMirrored(worktree, uuid string) {
+	if uuid == "" || s.HasMirrored(worktree, uuid) {
+		return
+	}
+	if s.MirroredUUIDs == nil {
+		s.MirroredUUIDs = make(map[string][]string)
+	}
+	ids := append(s.MirroredUUIDs[worktree], uuid)
+	if len(ids) > maxMirroredUUIDs {
+		ids = ids[len(ids)-maxMirroredUUIDs:]
+	}
+	s.MirroredUUIDs[worktree] = ids
+}
+
+// SetMonitor records the status of a worktree's conversation monitor
+func (s *State) SetMonitor(worktree string, status MonitorStatus) {
+	if s.Monitors == nil {
+		s.Monitors = make(map[string]MonitorStatus)
+	}
+	s.Monitors[worktree] = status
+}
+
+// RecordUse moves a worktree to the front of the recently used list
+func (s *State) RecordUse(worktree string) {
+	recent := []string{worktree}
+	for _, name := range s.Recent {
+		if name != worktree {
+			recent = append(recent, name)
+		}
+	}
+	if len(recent) > maxRecentWorktrees {
+		recent = recent[:maxRecentWorktrees]
+	}
+	s.Recent = recent
+}
+
+// SetSummarized records the last day a worktree's work was summed up on its issue
+func (s *State) SetSummarized(worktree, day string) {
+	if s.Summarized == nil {
+		s.Summarized = make(map[string]string)
+	}
+	s.Summarized[worktree] = day
+}
+
+// ForgetWorktree drops a worktree from the recently used list, frees its ports and forgets
+// its agent runs, the marks and monitor of its mirrored conversations, its bootstrap, review
+// and summaries
+func (s *State) ForgetWorktree(worktree string) {
+	recent := s.Recent[:0]
+	for _, name := range s.Recent {
+		if name != worktree {
+			recent = append(recent, name)
+		}
+	}
+	s.Recent = recent
+	runs := s.Runs[:0]
+	for _, run := range s.Runs {
+		if run.Worktree != worktree {
+			runs = append(runs, run)
+		}
+	}
+	s.Runs = runs
+	for path, mark := range s.Sessions {
+		if mark.Worktree == worktree {
+			delete(s.Sessions, path)
+		}
+	}
+	delete(s.MirroredUUIDs, worktree)
+	delete(s.Monitors, worktree)
+	delete(s.PortBlocks, worktree)
+	delete(s.Bootstraps, worktree)
+	delete(s.Reviews, worktree)
+	delete(s.Summarized, worktree)
+}
+
+// HandOff moves what's kept for the task in one worktree to another: its agent runs, the
+// marks and monitor of its mirrored conversations, when it was last summed up, and its place
+// among the recently used
+func (s *State) HandOff(from, to string) {
+	for i := range s.Runs {
+		if s.Runs[i].Worktree == from {
+			s.Runs[i].Worktree = to
+		}
+	}
+	for path, mark := range s.Sessions {
+		if mark.Worktree == from {
+			mark.Worktree = to
+			s.Sessions[path] = mark
+		}
+	}
+	for _, uuid := range s.MirroredUUIDs[from] {
+		s.MarkMirrored(to, uuid)
+	}
+	delete(s.MirroredUUIDs, from)
+	if monitor, ok := s.Monitors[from]; ok {
+		if _, taken := s.Monitors[to]; !taken {
+			s.Monitors[to] = monitor
+		}
+		delete(s.Monitors, from)
+	}
+	if day, ok := s.Summarized[from]; ok {
+		if _, taken := s.Summarized[to]; !taken {
+			s.SetSummarized(to, day)
+		}
+		delete(s.Summarized, from)
+	}
+	// The task is used as often as before, wherever it's worked on
+	if slices.Contains(s.Recent, from) {
+		s.Recent = slices.DeleteFunc(s.Recent, func(name string) bool { return name == to })
+		s.Recent[slices.Index(s.Recent, from)] = to
+	}
+}
+
+// SetBootstrap records the progress of a worktree's bootstrap
+func (s *State) SetBootstrap(worktree string, bootstrap Bootstrap) {
+	if s.Bootstraps == nil {
+		s.Bootstraps = make(map[string]Bootstrap)
+	}
+	s.Bootstraps[worktree] = bootstrap
+}
+
+// SetReview records the pull request a worktree was checked out to review
+func (s *State) SetReview(worktree string, review Review) {
+	if s.Reviews == nil {
+		s.Reviews = make(map[string]Review)
+	}
+	s.Reviews[worktree] = review
+}
+
+// AssignPorts gives a worktree a block of the ports in settings if it hasn't one yet,
+// returning its ports
+func AssignPorts(configPath string, settings *config.Ports, worktree string) ([]config.Port, error) {
+	if settings == nil || len(settings.Names) == 0 {
+		return nil, nil
+	}
+	var block int
+	if _, err := Update(configPath, func(s *State) error {
+		block = s.PortBlock(worktree)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to assign ports: %w", err)
+	}
+	return settings.InBlock(block), nil
+}
+
+// Ports returns the ports in settings of the block a worktree has, nil when it hasn't been
+// given one yet
+func (s *State) Ports(settings *config.Ports, worktree string) []config.Port {
+	block, ok := s.PortBlocks[worktree]
+	if settings == nil || !ok {
+		return nil
+	}
+	return settings.InBlock(block)
+}
+
+// PortBlock returns the block of ports a worktree has, giving it the lowest free block if it
+// hasn't one yet
+func (s *State) PortBlock(worktree string) int {
+	if block, ok := s.PortBlocks[worktree]; ok {
+		return block
+	}
+	taken := make(map[int]bool, len(s.PortBlocks))
+	for _, block := range s.PortBlocks {
+		taken[block] = true
+	}
+	block := 0
+	for taken[block] {
+		block++
+	}
+	if s.PortBlocks == nil {
+		s.PortBlocks = make(map[string]int)
+	}
+	s.PortBlocks[worktree] = block
+	return block
+}
+
+// ScratchpadPath returns the file a worktree's scratchpad is kept in: notes of its own that
+// stay in this clone, unlike its todo's notes, and never go to its issue
+func ScratchpadPath(configPath, worktree string) string {
+	return filepath.Join(Dir(configPath), "scratchpads", worktree+".md")
+}
+
+// Scratchpad returns a worktree's scratchpad, "" when it has none
+func Scratchpad(configPath, worktree string) string {
+	data, err := os.ReadFile(ScratchpadPath(configPath, worktree))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// PrepareScratchpad makes sure a worktree's scratchpad can be opened in an editor,
+// returning its path
+func PrepareScratchpad(configPath, worktree string) (string, error) {
+	path := ScratchpadPath(configPath, worktree)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create scratchpad directory: %w", err)
+	}
+	return path, nil
+}
+
+// RemoveScratchpad deletes a worktree's scratchpad, if it has one
+func RemoveScratchpad(configPath, worktree string) error {
+	err := os.Remove(ScratchpadPath(configPath, worktree))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove scratchpad: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunQueue(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	st, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	first := st.EnqueueRun("wt-1", "first task")
+	firstID := first.ID
+	st.EnqueueRun("wt-1", "second task")
+	st.EnqueueRun("wt-2", "other worktree")
+
+	if got := st.CountQueuedRuns("wt-1"); got != 2 {
+		t.Errorf("CountQueuedRuns(wt-1) = %d, want 2", got)
+	}
+
+	next := st.NextQueuedRun("wt-1")
+	if next == nil || next.Prompt != "first task" {
+		t.Fatalf("NextQueuedRun(wt-1) = %+v, want first task", next)
+	}
+	if started := st.StartNextRun("wt-1", os.Getpid()); started != next || started.Status != RunStatusRunning || started.PID != os.Getpid() {
+		t.Fatalf("StartNextRun(wt-1) = %+v, want the first task running here", started)
+	}
+	if started := st.StartNextRun("wt-1", os.Getpid()); started != nil {
+		t.Errorf("StartNextRun(wt-1) with a run going = %+v, want nil", started)
+	}
+
+	if !st.HasRunningRun("wt-1") {
+		t.Error("HasRunningRun(wt-1) = false, want true")
+	}
+	if st.HasRunningRun("wt-2") {
+		t.Error("HasRunningRun(wt-2) = true, want false")
+	}
+
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after save error = %v", err)
+	}
+	if len(loaded.Runs) != 3 {
+		t.Fatalf("expected 3 runs after reload, got %d", len(loaded.Runs))
+	}
+	if run := loaded.GetRun(firstID); run == nil || run.Status != RunStatusRunning {
+		t.Errorf("GetRun(%q) = %+v, want running", firstID, run)
+	}
+	if latest := loaded.LatestRun("wt-1"); latest == nil || latest.Prompt != "second task" {
+		t.Errorf("LatestRun(wt-1) = %+v, want second task", latest)
+	}
+
+	// A run whose process has gone is failed, and no longer holds up the queue
+	loaded.GetRun(firstID).PID = 1 << 30
+	if loaded.HasRunningRun("wt-1") {
+		t.Error("HasRunningRun(wt-1) with its process gone = true, want false")
+	}
+	if started := loaded.StartNextRun("wt-1", os.Getpid()); started == nil || started.Prompt != "second task" {
+		t.Errorf("StartNextRun(wt-1) after its process had gone = %+v, want second task", started)
+	}
+	if run := loaded.GetRun(firstID); run.Status != RunStatusFailed || run.Error == "" || run.FinishedAt.IsZero() {
+		t.Errorf("run with its process gone = %+v, want failed", run)
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	st := &State{}
+	for i := range maxFinishedRuns + 5 {
+		st.EnqueueRun("wt-1", fmt.Sprintf("task %d", i)).Status = RunStatusSucceeded
+	}
+	st.EnqueueRun("wt-1", "waiting")
+	st.EnqueueRun("wt-2", "other worktree").Status = RunStatusFailed
+
+	st.PruneRuns()
+	if len(st.Runs) != maxFinishedRuns+2 {
+		t.Fatalf("PruneRuns() kept %d runs, want %d", len(st.Runs), maxFinishedRuns+2)
+	}
+	if first := st.Runs[0].Prompt; first != "task 5" {
+		t.Errorf("PruneRuns() kept %q first, want the oldest dropped", first)
+	}
+	if latest := st.LatestRun("wt-1"); latest.Prompt != "waiting" {
+		t.Errorf("LatestRun(wt-1) after PruneRuns() = %q, want the queued run kept", latest.Prompt)
+	}
+	if st.LatestRun("wt-2") == nil {
+		t.Error("PruneRuns() dropped another worktree's run")
+	}
+}
+
+func TestSessionMarks(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	st, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := st.SessionOffset("/logs/a.jsonl"); got != 0 {
+		t.Errorf("SessionOffset() for unknown log = %d, want 0", got)
+	}
+
+	st.SetSessionMark("/logs/a.jsonl", SessionMark{Worktree: "wt", Offset: 512, LastUUID: "u2"})
+	st.MarkMirrored("wt", "u1")
+	st.MarkMirrored("wt", "u2")
+	st.MarkMirrored("wt", "u2")
+
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() after save error = %v", err)
+	}
+	if got := loaded.SessionOffset("/logs/a.jsonl"); got != 512 {
+		t.Errorf("SessionOffset() = %d, want 512", got)
+	}
+	if !loaded.HasMirrored("wt", "u1") || !loaded.HasMirrored("wt", "u2") {
+		t.Error("expected u1 and u2 to be mirrored")
+	}
+	if loaded.HasMirrored("other", "u1") {
+		t.Error("mirrored UUIDs should be scoped to a worktree")
+	}
+	if got := len(loaded.MirroredUUIDs["wt"]); got != 2 {
+		t.Errorf("expected duplicate UUIDs to be ignored, got %d entries", got)
+	}
+}
+
+func TestMarkMirroredIsBounded(t *testing.T) {
+	st := &State{}
+	for i := 0; i < maxMirroredUUIDs+10; i++ {
+		st.MarkMirrored("wt", fmt.Sprintf("uuid-%d", i))
+	}
+
+	if got := len(st.MirroredUUIDs["wt"]); got != maxMirroredUUIDs {
+		t.Errorf("expected %d UUIDs, got %d", maxMirroredUUIDs, got)
+	}
+	if st.HasMirrored("wt", "uuid-0") {
+		t.Error("expected oldest UUID to be evicted")
+	}
+}
+
+func TestMonitorStatusSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   MonitorStatus
+		expected string
+	}{
+		{
+			name:     "idle",
+			status:   MonitorStatus{Active: true},
+			expected: "Mirror: on",
+		},
+		{
+			name:     "paused with pending messages",
+			status:   MonitorStatus{Active: true, Paused: true, Target: 42, Pending: 3},
+			expected: "Mirror: paused · #42 · 3 pending",
+		},
+		{
+			name:     "summed up daily",
+			status:   MonitorStatus{Active: true, Daily: true, Target: 42},
+			expected: "Mirror: daily · #42",
+		},
+		{
+			name:     "errors",
+			status:   MonitorStatus{Active: true, Errors: 2},
+			expected: "Mirror: on · 2 errors",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.Summary(); got != tt.expected {
+				t.Errorf("Summary() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMonitorStatusSurvivesReload(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+
+	st, _ := Load(configPath)
+	status := MonitorStatus{PID: 1, Active: true, LastPostAt: time.Now(), Errors: 1}
+	st.SetMonitor("wt", status)
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !loaded.Monitors["wt"].Equal(status) {
+		t.Errorf("reloaded status %+v not equal to %+v", loaded.Monitors["wt"], status)
+	}
+}
+
+func TestRecordUse(t *testing.T) {
+	st := &State{}
+	for _, name := range []string{"wt-1", "wt-2", "wt-3", "wt-1"} {
+		st.RecordUse(name)
+	}
+	if got := fmt.Sprint(st.Recent); got != "[wt-1 wt-3 wt-2]" {
+		t.Errorf("Recent = %s, want [wt-1 wt-3 wt-2]", got)
+	}
+
+	st.ForgetWorktree("wt-3")
+	if got := fmt.Sprint(st.Recent); got != "[wt-1 wt-2]" {
+		t.Errorf("Recent after ForgetWorktree = %s, want [wt-1 wt-2]", got)
+	}
+
+	for i := 0; i < 2*maxRecentWorktrees; i++ {
+		st.RecordUse(fmt.Sprintf("many-%d", i))
+	}
+	if len(st.Recent) != maxRecentWorktrees {
+		t.Errorf("len(Recent) = %d, want %d", len(st.Recent), maxRecentWorktrees)
+	}
+}
+
+func TestHandOff(t *testing.T) {
+	st := &State{}
+	st.EnqueueRun("spike", "try it")
+	st.EnqueueRun("other", "elsewhere")
+	st.SetSessionMark("/logs/a.jsonl", SessionMark{Worktree: "spike", Offset: 10})
+	st.MarkMirrored("spike", "uuid-1")
+	st.MarkMirrored("feature", "uuid-0")
+	st.SetMonitor("spike", MonitorStatus{PID: 42})
+	st.SetSummarized("spike", "2026-10-13")
+	for _, name := range []string{"feature", "other", "spike"} {
+		st.RecordUse(name)
+	}
+
+	st.HandOff("spike", "feature")
+
+	if run := st.LatestRun("feature"); run == nil || run.Prompt != "try it" {
+		t.Errorf("LatestRun(feature) = %+v, want try it", run)
+	}
+	if run := st.LatestRun("spike"); run != nil {
+		t.Errorf("LatestRun(spike) = %+v, want none", run)
+	}
+	if mark := st.Sessions["/logs/a.jsonl"]; mark.Worktree != "feature" || mark.Offset != 10 {
+		t.Errorf("session mark = %+v, want feature at 10", mark)
+	}
+	if !st.HasMirrored("feature", "uuid-1") || !st.HasMirrored("feature", "uuid-0") || st.HasMirrored("spike", "uuid-1") {
+		t.Errorf("MirroredUUIDs = %v, want both on feature", st.MirroredUUIDs)
+	}
+	if _, ok := st.Monitors["spike"]; ok || st.Monitors["feature"].PID != 42 {
+		t.Errorf("Monitors = %v, want spike's on feature", st.Monitors)
+	}
+	if _, ok := st.Summarized["spike"]; ok || st.Summarized["feature"] != "2026-10-13" {
+		t.Errorf("Summarized = %v, want spike's on feature", st.Summarized)
+	}
+	if got := fmt.Sprint(st.Recent); got != "[feature other]" {
+		t.Errorf("Recent = %s, want [feature other]", got)
+	}
+}
+
+func TestForgetWorktree(t *testing.T) {
+	st := &State{}
+	st.EnqueueRun("gone", "try it")
+	st.EnqueueRun("kept", "elsewhere")
+	st.SetSessionMark("/logs/a.jsonl", SessionMark{Worktree: "gone", Offset: 10})
+	st.SetSessionMark("/logs/b.jsonl", SessionMark{Worktree: "kept", Offset: 20})
+	st.MarkMirrored("gone", "uuid-1")
+	st.MarkMirrored("kept", "uuid-2")
+	st.SetMonitor("gone", MonitorStatus{PID: 42})
+	st.SetMonitor("kept", MonitorStatus{PID: 43})
+
+	st.ForgetWorktree("gone")
+
+	if len(st.Runs) != 1 || st.LatestRun("kept") == nil {
+		t.Errorf("Runs = %+v, want only kept's", st.Runs)
+	}
+	if _, ok := st.Sessions["/logs/a.jsonl"]; ok || st.Sessions["/logs/b.jsonl"].Offset != 20 {
+		t.Errorf("Sessions = %v, want only kept's mark", st.Sessions)
+	}
+	if st.HasMirrored("gone", "uuid-1") || !st.HasMirrored("kept", "uuid-2") {
+		t.Errorf("MirroredUUIDs = %v, want only kept's", st.MirroredUUIDs)
+	}
+	if _, ok := st.Monitors["gone"]; ok || st.Monitors["kept"].PID != 43 {
+		t.Errorf("Monitors = %v, want only kept's", st.Monitors)
+	}
+}
+
+func TestUpdateKeepsConcurrentChanges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	const writers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := Update(configPath, func(s *State) error {
+				s.RecordUse(fmt.Sprintf("wt-%d", i))
+				return nil
+			}); err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	st, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(st.Recent) != writers {
+		t.Errorf("Recent = %v, want one worktree from each of %d writers", st.Recent, writers)
+	}
+}
+
+func TestPortBlock(t *testing.T) {
+	st := &State{}
+	for i, name := range []string{"wt-1", "wt-2", "wt-3"} {
+		if block := st.PortBlock(name); block != i {
+			t.Errorf("PortBlock(%s) = %d, want %d", name, block, i)
+		}
+	}
+	if block := st.PortBlock("wt-2"); block != 1 {
+		t.Errorf("PortBlock(wt-2) again = %d, want the block it has, 1", block)
+	}
+
+	st.ForgetWorktree("wt-2")
+	if block := st.PortBlock("wt-4"); block != 1 {
+		t.Errorf("PortBlock(wt-4) = %d, want wt-2's freed block, 1", block)
+	}
+}
+
+func TestScratchpad(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	if got := Scratchpad(configPath, "proj-login"); got != "" {
+		t.Fatalf("Scratchpad() without one = %q", got)
+	}
+
+	path, err := PrepareScratchpad(configPath, "proj-login")
+	if err != nil {
+		t.Fatalf("PrepareScratchpad() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte("\nTry clearing the cache\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Scratchpad(configPath, "proj-login"); got != "Try clearing the cache" {
+		t.Errorf("Scratchpad() = %q, want what was written", got)
+	}
+
+	if err := RemoveScratchpad(configPath, "proj-login"); err != nil {
+		t.Fatalf("RemoveScratchpad() error = %v", err)
+	}
+	if err := RemoveScratchpad(configPath, "proj-login"); err != nil {
+		t.Errorf("RemoveScratchpad() of none = %v", err)
+	}
+	if got := Scratchpad(configPath, "proj-login"); got != "" {
+		t.Errorf("Scratchpad() after removing it = %q", got)
+	}
+}
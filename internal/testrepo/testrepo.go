@@ -0,0 +1,93 @@
+package testrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// Repo is a throwaway git repository with one commit on main, for tests that need real git.
+// Its worktrees are added next to it, the way lfg adds them.
+type Repo struct {
+	Root string // The main worktree
+	t    testing.TB
+}
+
+// New makes a repository named proj in a temporary directory and changes into it. Git is
+// isolated from the user's own config, so hooks and signing settings don't interfere.
+func New(t testing.TB) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// Git reports resolved paths, e.g. /private/var rather than /var on macOS
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	r := &Repo{Root: filepath.Join(dir, "proj"), t: t}
+	if err := os.Mkdir(r.Root, 0755); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+	r.Git("init", "--quiet", "--initial-branch", "main")
+	r.WriteFile("README.md", "# proj\n")
+	r.Git("add", "README.md")
+	r.Git("commit", "--quiet", "-m", "init")
+	t.Chdir(r.Root)
+	return r
+}
+
+// Git runs git in the main worktree and returns its output, failing the test if it fails
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", r.Root}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// WriteFile writes a file in the main worktree, relative to its root
+func (r *Repo) WriteFile(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.Root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		r.t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// AddWorktree adds a worktree on a new branch of the same name next to the main one,
+// returning its path
+func (r *Repo) AddWorktree(name string) string {
+	r.t.Helper()
+	path := filepath.Join(filepath.Dir(r.Root), name)
+	r.Git("worktree", "add", "--quiet", "-b", name, path)
+	return path
+}
+
+// Config writes content as the repository's lfg-config.yaml and loads it
+func (r *Repo) Config(content string) *config.Config {
+	r.t.Helper()
+	r.WriteFile("lfg-config.yaml", content)
+	cfg, err := config.LoadFromPath(filepath.Join(r.Root, "lfg-config.yaml"))
+	if err != nil {
+		r.t.Fatalf("failed to load config: %v", err)
+	}
+	return cfg
+}
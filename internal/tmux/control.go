@@ -0,0 +1,212 @@
+package tmux
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/runner"
+)
+
+// control is a tmux client in control mode (tmux -C). Commands are written to it a line
+// at a time and tmux answers each between %begin and %end, or %error, lines; anything
+// else it writes is a notification of what's happening in the session, like a pane's
+// %output. The client only sends the commands that build a session, so the one
+// notification it acts on is %exit, after which tmux takes no more commands.
+type control struct {
+	proc    *runner.Process
+	mu      sync.Mutex // Held from writing a command until its answer has been read
+	broken  bool       // tmux stopped answering, or took too long to
+	exited  atomic.Bool
+	replies chan controlReply
+	done    chan struct{} // Closed once tmux has stopped writing
+}
+
+// controlReply is tmux's answer to one command
+type controlReply struct {
+	output []byte
+	failed bool
+}
+
+// activeControl is the control mode client tmux commands go through while one is open
+var activeControl atomic.Pointer[control]
+
+// withControl runs fn with the tmux commands it runs going through one control mode client
+// attached to the session, rather than starting a tmux for each. Without control mode, e.g.
+// when the runner can't start a client or tmux won't attach one, they're run one by one.
+func withControl(sessionName string, fn func() error) error {
+	if activeControl.Load() != nil {
+		return fn()
+	}
+	client, err := openControl(sessionName)
+	if err != nil {
+		return fn()
+	}
+	if !activeControl.CompareAndSwap(nil, client) {
+		client.close()
+		return fn()
+	}
+	defer func() {
+		activeControl.Store(nil)
+		client.close()
+	}()
+	return fn()
+}
+
+// openControl attaches a control mode client to the session
+func openControl(sessionName string) (*control, error) {
+	starter, ok := commands.(runner.Starter)
+	if !ok {
+		return nil, errors.New("tmux can't be started in control mode")
+	}
+	proc, err := starter.Start("tmux", "-C", "attach-session", "-t", sessionName)
+	if err != nil {
+		return nil, err
+	}
+	c := &control{proc: proc, replies: make(chan controlReply, 1), done: make(chan struct{})}
+	go c.read()
+
+	// tmux answers the attach itself first
+	reply, err := c.reply()
+	if err == nil && reply.failed {
+		err = errors.New(strings.TrimSpace(string(reply.output)))
+	}
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// read hands each of tmux's answers to whoever is waiting for it, noting when tmux says the
+// client is exiting and dropping the other notifications
+func (c *control) read() {
+	defer close(c.done)
+	scanner := bufio.NewScanner(c.proc.Stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var block *bytes.Buffer
+	var guard string // The %begin line's time and command number, which its %end repeats
+	for scanner.Scan() {
+		line := scanner.Text()
+		if block == nil {
+			if rest, ok := strings.CutPrefix(line, "%begin "); ok {
+				block, guard = &bytes.Buffer{}, controlGuard(rest)
+			} else if line == "%exit" || strings.HasPrefix(line, "%exit ") {
+				// E.g. the session was killed; commands written now would go unanswered
+				c.exited.Store(true)
+			}
+			continue
+		}
+		end, endOK := strings.CutPrefix(line, "%end ")
+		failed, failedOK := strings.CutPrefix(line, "%error ")
+		switch {
+		case endOK && controlGuard(end) == guard:
+			c.answer(controlReply{output: block.Bytes()})
+			block = nil
+		case failedOK && controlGuard(failed) == guard:
+			c.answer(controlReply{output: block.Bytes(), failed: true})
+			block = nil
+		default:
+			block.WriteString(line + "\n")
+		}
+	}
+}
+
+// answer hands over a reply, dropping it when nobody will take it, as after a timeout
+func (c *control) answer(reply controlReply) {
+	select {
+	case c.replies <- reply:
+	default:
+	}
+}
+
+// controlGuard is the time and command number from the rest of a %begin, %end or %error line
+func controlGuard(rest string) string {
+	fields := strings.Fields(rest)
+	return strings.Join(fields[:min(len(fields), 2)], " ")
+}
+
+// reply waits for tmux's next answer
+func (c *control) reply() (controlReply, error) {
+	select {
+	case reply := <-c.replies:
+		return reply, nil
+	case <-c.done:
+		// An answer may have come in just before tmux stopped
+		select {
+		case reply := <-c.replies:
+			return reply, nil
+		default:
+		}
+		c.broken = true
+		return controlReply{}, errors.New("tmux control mode client exited")
+	case <-time.After(tmuxTimeout):
+		// A late answer would be taken for the next command's
+		c.broken = true
+		return controlReply{}, errors.New("tmux timed out")
+	}
+}
+
+// run runs a tmux command through the client. sent is false when it couldn't be, so it
+// should be run on its own instead.
+func (c *control) run(args []string) (output []byte, sent bool, err error) {
+	line, ok := controlLine(args)
+	if !ok {
+		return nil, false, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken || c.exited.Load() {
+		return nil, false, nil
+	}
+	if _, err := io.WriteString(c.proc.Stdin, line+"\n"); err != nil {
+		c.broken = true
+		return nil, false, nil
+	}
+
+	reply, err := c.reply()
+	command := "tmux " + args[0]
+	if err != nil {
+		return nil, true, &runner.Error{Command: command, Err: err}
+	}
+	if reply.failed {
+		return nil, true, &runner.Error{Command: command, Stderr: strings.TrimSpace(string(reply.output)), Err: errors.New("tmux reported an error")}
+	}
+	return reply.output, true, nil
+}
+
+// close detaches the client, which exits once its input ends
+func (c *control) close() {
+	c.proc.Stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(tmuxTimeout):
+	}
+	c.proc.Wait()
+}
+
+// controlLine is a command line for the client, and false when it would need more than one
+func controlLine(args []string) (string, bool) {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "\n\r") {
+			return "", false
+		}
+		words[i] = controlQuote(arg)
+	}
+	return strings.Join(words, " "), true
+}
+
+// controlQuote quotes s as a single word for tmux's command parser, which expands nothing
+// in single quotes but has no escapes in them either
+func controlQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(s) + `"`
+}
@@ -0,0 +1,69 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// sessionEnvironmentOption records which of the config's environments a session's panes
+// were started with
+const sessionEnvironmentOption = "@lfg-environment"
+
+// environmentEnv is the variables of the environment picked for a worktree, warning and
+// going without when it isn't in the config
+func environmentEnv(worktreeName string, cfg *config.Config) []string {
+	env, err := cfg.WorktreeEnv(worktreeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return env
+}
+
+// switchEnvironment gives an existing session the environment now picked for its worktree:
+// its variables are set for panes started from now on, the other environments' are unset,
+// and the layout's panes are restarted to pick them up when it's changed since they were
+// started
+func switchEnvironment(sessionName, worktreeName string, cfg *config.Config) {
+	environment := cfg.WorktreeEnvironment(worktreeName)
+	env := environmentEnv(worktreeName, cfg)
+	set := map[string]bool{}
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		set[name] = true
+		if err := tmuxRun("set-environment", "-t", sessionName, name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to set %s: %v\n", name, err)
+		}
+	}
+	for _, other := range cfg.Environments {
+		for name := range other {
+			if !set[name] {
+				set[name] = true
+				tmuxRun("set-environment", "-u", "-t", sessionName, name) // Ignore errors, it may not be set
+			}
+		}
+	}
+
+	output, err := tmuxOutput("display-message", "-p", "-t", sessionName, "#{"+sessionEnvironmentOption+"}")
+	if err != nil || strings.TrimSpace(string(output)) == environment {
+		return
+	}
+	if err := tmuxRun("set-option", "-t", sessionName, sessionEnvironmentOption, environment); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record %s's environment: %v\n", worktreeName, err)
+	}
+	panes, err := tmuxOutput("list-panes", "-s", "-t", sessionName, "-F", "#{pane_id} #{"+paneNameOption+"}")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list %s's panes: %v\n", worktreeName, err)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(panes)), "\n") {
+		// The agent keeps its conversation
+		if id, name, _ := strings.Cut(line, " "); name != "" && name != "agent" {
+			if err := RestartPane(id); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+}
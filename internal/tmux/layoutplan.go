@@ -0,0 +1,243 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// Split is one of the split-window commands a layout is built with
+type Split struct {
+	Pane     int    // Index of the pane split
+	Vertical bool   // The new pane goes below the one split (-v), rather than beside it (-h)
+	Size     int    // Lines or columns the new pane gets (-l), with room for those split off it later
+	For      string // What the new pane is for
+}
+
+// PaneGeometry is where a pane ends up in the window
+type PaneGeometry struct {
+	Index         int
+	Name          string
+	Command       string // "" for a shell
+	Left, Top     int
+	Width, Height int
+}
+
+// LayoutPlan is how a layout is built in a window of a size: the splits, in order, and the
+// panes they leave
+type LayoutPlan struct {
+	Width, Height int
+	Splits        []Split
+	Panes         []PaneGeometry
+}
+
+// PlanLayout works out how a layout is built in a width by height window, as a session's
+// is: the agent's pane takes the top 45% and the layout's rows share the rest, each row
+// split off the one above it and its panes off the one to the left of them
+func PlanLayout(layout []config.LayoutRow, width, height int) LayoutPlan {
+	plan := LayoutPlan{Width: width, Height: height}
+	workHeight := max(height-height*agentPercent/100-1, 1)
+	plan.Splits = append(plan.Splits, Split{Pane: 0, Vertical: true, Size: workHeight, For: "the layout's rows"})
+	agentHeight := height - workHeight - 1
+	plan.Panes = append(plan.Panes, PaneGeometry{Index: 0, Name: "agent", Width: width, Height: agentHeight})
+	if len(layout) == 0 {
+		return plan
+	}
+
+	rowSizes := make([]size, len(layout))
+	for i, row := range layout {
+		rowSizes[i] = parseSize(row.Height, row.MinHeight, row.MaxHeight)
+	}
+	heights := firstTakesRest(resolveSizes(rowSizes, workHeight-(len(layout)-1)), workHeight)
+	for i, splitHeight := range splitSizes(heights) {
+		plan.Splits = append(plan.Splits, Split{Pane: i + 1, Vertical: true, Size: splitHeight, For: fmt.Sprintf("row %d and below", i+2)})
+	}
+
+	// Rows' panes are split left to right after the rows are made. Each new pane's index
+	// comes straight after the pane it's split from, so each row's panes are numbered in
+	// order before the next row's.
+	index, top := 1, agentHeight+1
+	for rowIdx, row := range layout {
+		if len(row.Panes) == 0 {
+			plan.Panes = append(plan.Panes, PaneGeometry{Index: index, Name: row.Name, Command: commandOf(row.Command), Left: 0, Top: top, Width: width, Height: heights[rowIdx]})
+			index++
+			top += heights[rowIdx] + 1
+			continue
+		}
+
+		paneSizes := make([]size, len(row.Panes))
+		for i, pane := range row.Panes {
+			paneSizes[i] = parseSize(pane.Width, pane.MinWidth, pane.MaxWidth)
+		}
+		widths := firstTakesRest(resolveSizes(paneSizes, width-(len(row.Panes)-1)), width)
+		for i, splitWidth := range splitSizes(widths) {
+			plan.Splits = append(plan.Splits, Split{Pane: index + i, Size: splitWidth, For: fmt.Sprintf("row %d's panes from %s", rowIdx+1, row.Panes[i+1].Name)})
+		}
+		left := 0
+		for i, pane := range row.Panes {
+			plan.Panes = append(plan.Panes, PaneGeometry{Index: index, Name: pane.Name, Command: commandOf(pane.Command), Left: left, Top: top, Width: widths[i], Height: heights[rowIdx]})
+			index++
+			left += widths[i] + 1
+		}
+		top += heights[rowIdx] + 1
+	}
+	return plan
+}
+
+// firstTakesRest gives the first size whatever the others and the borders between them
+// leave of total, as tmux does with the pane the others are split from
+func firstTakesRest(sizes []int, total int) []int {
+	rest := total - (len(sizes) - 1)
+	for _, n := range sizes[1:] {
+		rest -= n
+	}
+	sizes[0] = rest
+	return sizes
+}
+
+// Args is the split as tmux's arguments, in the window target
+func (s Split) Args(target string) []string {
+	direction := "-h"
+	if s.Vertical {
+		direction = "-v"
+	}
+	return []string{"split-window", "-t", fmt.Sprintf("%s.%d", target, s.Pane), direction, "-l", strconv.Itoa(s.Size)}
+}
+
+// CheckLayout lists what's wrong with a layout, or would go wrong building it in a width
+// by height window: sizes that don't parse or add up, panes without names or that don't
+// fit, and commands that aren't installed. lookCommands is false when the layout's
+// commands aren't run here, as in a container or on a remote host, so can't be looked for.
+func CheckLayout(layout []config.LayoutRow, width, height int, lookCommands bool) []string {
+	if len(layout) == 0 {
+		return []string{"the layout has no rows"}
+	}
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	names := map[string]bool{}
+	checkPane := func(where, name string, command *string) {
+		switch {
+		case name == "":
+			problem("%s has no name", where)
+		case names[name]:
+			problem("%s is named %q, as another pane is", where, name)
+		}
+		names[name] = true
+		if command == nil {
+			return
+		}
+		if strings.TrimSpace(*command) == "" {
+			problem("%s's command is empty; leave it out for a shell", where)
+		} else if program := commandProgram(*command); lookCommands && program != "" {
+			if _, err := exec.LookPath(program); err != nil {
+				problem("%s's command runs %s, which isn't installed", where, program)
+			}
+		}
+	}
+
+	heights := 0
+	for i, row := range layout {
+		where := fmt.Sprintf("row %d", i+1)
+		heights += checkSize(where+"'s height", row.Height, row.MinHeight, row.MaxHeight, problem)
+		if len(row.Panes) == 0 {
+			checkPane(where, row.Name, row.Command)
+			continue
+		}
+		if row.Name != "" || row.Command != nil {
+			problem("%s has panes, so its own name and command are ignored", where)
+		}
+		widths := 0
+		for j, pane := range row.Panes {
+			paneWhere := fmt.Sprintf("%s's pane %d", where, j+1)
+			widths += checkSize(paneWhere+"'s width", pane.Width, pane.MinWidth, pane.MaxWidth, problem)
+			checkPane(paneWhere, pane.Name, pane.Command)
+		}
+		if widths > 100 {
+			problem("%s's panes' widths add up to %d%%", where, widths)
+		}
+	}
+	if heights > 100 {
+		problem("the rows' heights add up to %d%%", heights)
+	}
+
+	// tmux can't make a pane smaller than a line or column, and takes what fixed sizes
+	// can't have from the first row or pane
+	panes := PlanLayout(layout, width, height).Panes
+	for _, pane := range panes {
+		if pane.Width < 1 || pane.Height < 1 {
+			problem("%s doesn't fit in a %dx%d window", paneLabel(pane), width, height)
+		}
+	}
+	index := 1
+	for i, row := range layout {
+		if lines := parseSize(row.Height, 0, 0); !lines.flexible() && panes[index].Height != lines.value {
+			problem("row %d is %d lines high but gets %d in a %dx%d window", i+1, lines.value, panes[index].Height, width, height)
+		}
+		for _, pane := range row.Panes {
+			if columns := parseSize(pane.Width, 0, 0); !columns.flexible() && panes[index].Width != columns.value {
+				problem("%s is %d columns wide but gets %d in a %dx%d window", paneLabel(panes[index]), columns.value, panes[index].Width, width, height)
+			}
+			index++
+		}
+		if len(row.Panes) == 0 {
+			index++
+		}
+	}
+	return problems
+}
+
+// checkSize reports a height or width that isn't a percentage or a number of lines or
+// columns, or whose limits contradict each other, returning its percentage if it's one
+func checkSize(where, s string, min, max int, problem func(string, ...any)) int {
+	if strings.TrimSpace(s) == "" {
+		return 0
+	}
+	number, percent := strings.CutSuffix(strings.TrimSpace(s), "%")
+	value, err := strconv.Atoi(strings.TrimSpace(number))
+	switch {
+	case err != nil || value < 0:
+		problem("%s %q isn't a percentage or a number of lines or columns", where, s)
+		return 0
+	case min < 0 || max < 0:
+		problem("%s has a negative limit", where)
+	case max > 0 && min > max:
+		problem("%s's minimum %d is more than its maximum %d", where, min, max)
+	}
+	if !percent {
+		return 0
+	}
+	return value
+}
+
+// commandProgram is the program a pane's command runs first, "" when that's the shell's own
+// or can't be told, like a variable
+func commandProgram(command string) string {
+	for _, word := range strings.Fields(command) {
+		switch {
+		case strings.Contains(word, "=") && !strings.HasPrefix(word, "="):
+			continue // An environment variable for the command
+		case strings.ContainsAny(word, "$`\"'(){}<>|&;"):
+			return ""
+		}
+		switch word {
+		case "cd", "export", "source", ".", "exec", "eval", "set", "unset", "if", "for", "while", "exit":
+			return ""
+		}
+		return word
+	}
+	return ""
+}
+
+// paneLabel names a pane in the plan
+func paneLabel(pane PaneGeometry) string {
+	if pane.Name == "" {
+		return fmt.Sprintf("pane %d", pane.Index)
+	}
+	return fmt.Sprintf("pane %d (%s)", pane.Index, pane.Name)
+}
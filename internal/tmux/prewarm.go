@@ -0,0 +1,74 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/container"
+)
+
+// prewarmedOption marks a session built ahead of being jumped to, whose panes' commands are
+// typed but not yet run
+const prewarmedOption = "@lfg-prewarmed"
+
+// PrewarmSession builds a worktree's session, detached, with its layout's commands typed
+// into their panes but not run, so jumping to it is instant and it costs little until then.
+// The commands are run, and the containers started, when the session is next opened. It
+// reports false when the worktree already has a session.
+func PrewarmSession(name, path string, cfg *config.Config) (bool, error) {
+	if !IsInstalled() {
+		return false, ErrTmuxMissing
+	}
+	sessionName := sanitizeSessionName(name)
+	if SessionExists(sessionName) {
+		return false, nil
+	}
+	ports, caches := sessionEnv(name, cfg)
+	if err := buildPrewarmed(sessionName, name, path, ports, caches, cfg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// buildPrewarmed starts a detached session laid out with the worktree's layout, its
+// commands held, and marks it prewarmed
+func buildPrewarmed(sessionName, worktreeName, path string, ports []config.Port, caches []string, cfg *config.Config) error {
+	return startSession(sessionName, worktreeName, path, ports, caches, cfg, func() error {
+		if err := createPaneLayout(sessionName, worktreeName, path, cfg, true); err != nil {
+			return err
+		}
+		return tmuxRun("set-option", "-t", sessionName, prewarmedOption, "1")
+	})
+}
+
+// releasePrewarmed runs the commands waiting in the panes of a prewarmed session, starting
+// the worktree's containers first, and does nothing for any other session
+func releasePrewarmed(sessionName, worktreeName, path string, cfg *config.Config) error {
+	output, err := tmuxOutput("show-options", "-q", "-v", "-t", sessionName, prewarmedOption)
+	if err != nil || strings.TrimSpace(string(output)) != "1" {
+		return nil
+	}
+	if cfg.Container != nil {
+		fmt.Fprintf(os.Stderr, "Starting containers for %s...\n", worktreeName)
+		if err := container.Up(context.Background(), cfg.Container, worktreeName, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	panes, err := tmuxOutput("list-panes", "-t", sessionName+":0", "-F", "#{pane_id} #{"+paneCommandOption+"}")
+	if err != nil {
+		return fmt.Errorf("failed to list %s's panes: %w", worktreeName, err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(panes)), "\n") {
+		// Only panes with a command have one waiting to run
+		if pane, command, _ := strings.Cut(line, " "); pane != "" && command != "" {
+			if err := tmuxRun("send-keys", "-t", pane, "Enter"); err != nil {
+				return fmt.Errorf("failed to run the command in %s's pane %s: %w", worktreeName, pane, err)
+			}
+		}
+	}
+	return tmuxRun("set-option", "-u", "-t", sessionName, prewarmedOption)
+}
@@ -0,0 +1,100 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// restartKey restarts the pane it's pressed in, after tmux's prefix
+const restartKey = "R"
+
+// Options lfg keeps on the panes of a session's layout, so each can be found by its name
+// and its command run again
+const (
+	paneNameOption    = "@lfg-pane"
+	paneCommandOption = "@lfg-command"
+)
+
+// tagPane names a pane, in its title too, and records the command typed into it, "" for a
+// shell
+func tagPane(pane, name, command string) {
+	if err := tmuxRun("select-pane", "-t", pane, "-T", name); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to title pane %s: %v\n", name, err)
+	}
+	for _, option := range [][2]string{{paneNameOption, name}, {paneCommandOption, command}} {
+		if err := tmuxRun("set-option", "-p", "-t", pane, option[0], option[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record pane %s's command: %v\n", name, err)
+		}
+	}
+}
+
+// bindRestartKey binds restartKey to restart the pane it's pressed in. tmux runs it where
+// the server is, so on a remote host it runs lfg there rather than over SSH again.
+func bindRestartKey() error {
+	command := fmt.Sprintf("%s=1 %s restart-pane --target '#{pane_id}'", config.OnHostEnv, shellQuote(lfgPath()))
+	return tmuxRun("bind-key", restartKey, "run-shell", "-b", command)
+}
+
+// RestartPane kills what's running in one of the panes of a session's layout, e.g. "%3",
+// and runs its command again in the worktree, with its scrollback cleared
+func RestartPane(target string) error {
+	// One at a time, as tmux may write a tab between them as _
+	var name, dir, command string
+	for _, value := range []struct {
+		into   *string
+		format string
+	}{{&name, "#{" + paneNameOption + "}"}, {&dir, "#{session_path}"}, {&command, "#{" + paneCommandOption + "}"}} {
+		output, err := tmuxOutput("display-message", "-p", "-t", target, value.format)
+		if err != nil {
+			return fmt.Errorf("failed to find pane %s: %w", target, err)
+		}
+		*value.into = strings.TrimSuffix(string(output), "\n")
+	}
+	if name == "" {
+		return fmt.Errorf("pane %s isn't one of an lfg layout's, so has no command to restart", target)
+	}
+
+	if err := tmuxRun("respawn-pane", "-k", "-t", target, "-c", dir); err != nil {
+		return fmt.Errorf("failed to restart %s: %w", name, err)
+	}
+	if err := tmuxRun("clear-history", "-t", target); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to clear %s's scrollback: %v\n", name, err)
+	}
+	if command == "" {
+		return nil
+	}
+	if err := tmuxRun("send-keys", "-t", target, command, "Enter"); err != nil {
+		return fmt.Errorf("failed to run %s's command again: %w", name, err)
+	}
+	return nil
+}
+
+// RestartWorktreePane restarts one of the panes of a worktree's session by its name in the
+// layout, "agent" for the agent's
+func RestartWorktreePane(worktreeName, paneName string) error {
+	sessionName := sanitizeSessionName(worktreeName)
+	if !SessionExists(sessionName) {
+		return fmt.Errorf("%s has no tmux session", worktreeName)
+	}
+	output, err := tmuxOutput("list-panes", "-s", "-t", sessionName, "-F", "#{pane_id} #{"+paneNameOption+"}")
+	if err != nil {
+		return fmt.Errorf("failed to list %s's panes: %w", worktreeName, err)
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		id, name, _ := strings.Cut(line, " ")
+		if name == paneName {
+			return RestartPane(id)
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s's session has no panes lfg knows the commands of; its layout was made before lfg recorded them", worktreeName)
+	}
+	return fmt.Errorf("no pane named %q in %s's session, it has %s", paneName, worktreeName, strings.Join(names, ", "))
+}
@@ -0,0 +1,157 @@
+package tmux
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultWidth and defaultHeight are the size tmux gives a detached session's window when
+// it isn't told one
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// agentPercent is how much of the window's height the agent's pane takes
+const agentPercent = 45
+
+// size is a layout row's height or a pane's width as the config gives it
+type size struct {
+	value    int  // Lines or columns, or a percentage; 0 when it isn't set
+	percent  bool // value is a percentage of the space the rows or panes share
+	min, max int  // Limits in lines or columns, 0 for none
+}
+
+// parseSize parses a size like "40%" or "3" with its limits. Anything else is left unset,
+// to be given an even share.
+func parseSize(s string, min, max int) size {
+	s = strings.TrimSpace(s)
+	number, percent := strings.CutSuffix(s, "%")
+	value, err := strconv.Atoi(strings.TrimSpace(number))
+	if err != nil || value < 0 {
+		value = 0
+	}
+	return size{value: value, percent: percent, min: min, max: max}
+}
+
+// clamp keeps n within the size's limits, and at least a line or column
+func (s size) clamp(n int) int {
+	if s.max > 0 {
+		n = min(n, s.max)
+	}
+	return max(n, s.min, 1)
+}
+
+// flexible reports whether the size may be grown or shrunk to fill the space: fixed sizes
+// are kept as they're given
+func (s size) flexible() bool {
+	return s.percent || s.value == 0
+}
+
+// resolveSizes gives each size a number of lines or columns out of total: fixed sizes as
+// they are, percentages of what those leave and unset sizes an even share of what's left
+// after that, each kept within its limits. What that leaves over or short is spread across
+// the flexible sizes that have room for it; the first pane tmux splits from takes whatever
+// is left after that.
+func resolveSizes(sizes []size, total int) []int {
+	resolved := make([]int, len(sizes))
+	flexible := total
+	for _, s := range sizes {
+		if !s.flexible() {
+			flexible -= s.value
+		}
+	}
+	used, unset := 0, 0
+	for i, s := range sizes {
+		switch {
+		case s.value == 0:
+			unset++
+		case s.percent:
+			resolved[i] = max(flexible, 0) * s.value / 100
+		default:
+			resolved[i] = s.value
+		}
+		used += resolved[i]
+	}
+	for i, s := range sizes {
+		if s.value == 0 {
+			resolved[i] = max((total-used)/unset, 1)
+		}
+		resolved[i] = s.clamp(resolved[i])
+	}
+
+	for range len(sizes) {
+		diff := total
+		for _, n := range resolved {
+			diff -= n
+		}
+		var room []int
+		for i, s := range sizes {
+			if s.flexible() && s.clamp(resolved[i]+diff) != resolved[i] {
+				room = append(room, i)
+			}
+		}
+		if diff == 0 || len(room) == 0 {
+			break
+		}
+		for j, i := range room {
+			// An even share each, with the remainder on the last
+			share := diff / len(room)
+			if j == len(room)-1 {
+				share = diff - diff/len(room)*(len(room)-1)
+			}
+			resolved[i] = sizes[i].clamp(resolved[i] + share)
+		}
+	}
+	return resolved
+}
+
+// splitSizes are the sizes to give split-window -l for each split after the first row or
+// pane: each new pane is split off the one before it with room for all those after it,
+// including the line or column between each of them
+func splitSizes(resolved []int) []int {
+	splits := make([]int, 0, max(len(resolved)-1, 0))
+	for i := 1; i < len(resolved); i++ {
+		rest := len(resolved) - i - 1
+		for _, n := range resolved[i:] {
+			rest += n
+		}
+		splits = append(splits, rest)
+	}
+	return splits
+}
+
+// terminalSize is the size of the terminal lfg is running in, so a session can be laid out
+// at the size it'll be attached at. ok is false when lfg isn't running in one.
+func terminalSize() (width, height int, ok bool) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	return width, height, err == nil && width > 0 && height > 0
+}
+
+// LayoutSize is the size a session started now is laid out at: the terminal's, or tmux's
+// default outside of one
+func LayoutSize() (width, height int) {
+	if width, height, ok := terminalSize(); ok {
+		return width, height
+	}
+	return defaultWidth, defaultHeight
+}
+
+// windowSize is the size of a session's window, tmux's default if it can't be found
+func windowSize(target string) (width, height int) {
+	output, err := tmuxOutput("display-message", "-p", "-t", target, "#{window_width} #{window_height}")
+	if err == nil {
+		fields := strings.Fields(string(output))
+		if len(fields) == 2 {
+			width, widthErr := strconv.Atoi(fields[0])
+			height, heightErr := strconv.Atoi(fields[1])
+			if widthErr == nil && heightErr == nil && width > 0 && height > 0 {
+				return width, height
+			}
+		}
+	}
+	return defaultWidth, defaultHeight
+}
@@ -0,0 +1,130 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Window is a worktree's session window as it was: how its panes were arranged and what
+// each was running
+type Window struct {
+	Layout string      `json:"layout"` // tmux's own description of the panes' sizes and places
+	Panes  []PaneState `json:"panes"`
+}
+
+// PaneState is what a pane was doing
+type PaneState struct {
+	Path    string `json:"path"`              // The pane's directory
+	Command string `json:"command,omitempty"` // The command running in its shell, "" if none was
+}
+
+// CaptureWindow records the window of a worktree's session, nil when it has no session.
+// The commands running in panes are found among their shells' child processes with ps.
+func CaptureWindow(worktreeName string) (*Window, error) {
+	sessionName := sanitizeSessionName(worktreeName)
+	if !SessionExists(sessionName) {
+		return nil, nil
+	}
+	target := fmt.Sprintf("%s:0", sessionName)
+	layout, err := tmuxOutput("display-message", "-p", "-t", target, "#{window_layout}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the window layout: %w", err)
+	}
+	output, err := tmuxOutput("list-panes", "-t", target, "-F", "#{pane_pid}\t#{pane_current_path}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes: %w", err)
+	}
+	// Without ps the panes' places are still worth keeping
+	processes, _ := commands.Run(context.Background(), nil, "ps", "-A", "-o", "pid=,ppid=,args=")
+	children := parseProcesses(string(processes))
+
+	window := &Window{Layout: strings.TrimSpace(string(layout))}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		pid, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		window.Panes = append(window.Panes, PaneState{Path: path, Command: children[strings.TrimSpace(pid)]})
+	}
+	return window, nil
+}
+
+// parseProcesses parses `ps -o pid=,ppid=,args=` output into the command line of each
+// process's first child, by the parent's pid
+func parseProcesses(output string) map[string]string {
+	type process struct {
+		pid  int
+		args string
+	}
+	byParent := map[string][]process{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		byParent[fields[1]] = append(byParent[fields[1]], process{pid: pid, args: strings.Join(fields[2:], " ")})
+	}
+
+	children := make(map[string]string, len(byParent))
+	for parent, procs := range byParent {
+		sort.Slice(procs, func(i, j int) bool { return procs[i].pid < procs[j].pid })
+		children[parent] = procs[0].args
+	}
+	return children
+}
+
+// RestoreSession recreates a worktree's session as window was, without attaching: its panes
+// arranged as they were, each in its directory and running its command again
+func RestoreSession(worktreeName, path string, cfg *config.Config, window *Window) error {
+	sessionName := sanitizeSessionName(worktreeName)
+	if SessionExists(sessionName) {
+		return fmt.Errorf("%s already has a tmux session", worktreeName)
+	}
+	ports, err := state.AssignPorts(cfg.GetConfigPath(), cfg.Ports, worktreeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	caches, err := cfg.CacheEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return startSession(sessionName, worktreeName, path, ports, caches, cfg, func() error {
+		target := fmt.Sprintf("%s:0", sessionName)
+		for range max(len(window.Panes)-1, 0) {
+			if err := tmuxRun("split-window", "-t", target, "-c", path); err != nil {
+				return fmt.Errorf("failed to create pane: %w", err)
+			}
+			// Keeps room for the next split; the layout puts the panes back in place
+			tmuxRun("select-layout", "-t", target, "tiled")
+		}
+		if err := tmuxRun("select-layout", "-t", target, window.Layout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to arrange the panes: %v\n", err)
+		}
+
+		for i, pane := range window.Panes {
+			paneTarget := fmt.Sprintf("%s.%d", target, i)
+			command := pane.Command
+			if pane.Path != "" && pane.Path != path {
+				command = strings.TrimSuffix("cd "+shellQuote(pane.Path)+" && "+command, " && ")
+			}
+			if command == "" {
+				continue
+			}
+			if err := tmuxRun("send-keys", "-t", paneTarget, command, "Enter"); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to run %s in pane %d: %v\n", command, i, err)
+			}
+		}
+		return nil
+	})
+}
@@ -1,46 +1,149 @@
 package tmux
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/container"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
 )
 
+// tmuxTimeout bounds a tmux command, which should answer at once
+const tmuxTimeout = 10 * time.Second
+
+// commands runs tmux, and is swapped out by tests
+var commands runner.Runner = runner.Exec{Timeout: tmuxTimeout}
+
+// SetRunner makes the package run tmux through r, returning the runner it used before
+func SetRunner(r runner.Runner) runner.Runner {
+	previous := commands
+	commands = r
+	return previous
+}
+
+// ErrTmuxMissing is returned when tmux, which each worktree's session runs in, isn't installed
+var ErrTmuxMissing = errors.New("tmux isn't installed")
+
+// tmuxOutput runs tmux with args and returns its stdout
+func tmuxOutput(args ...string) ([]byte, error) {
+	if client := activeControl.Load(); client != nil {
+		if output, sent, err := client.run(args); sent {
+			return output, err
+		}
+	}
+	output, err := commands.Run(context.Background(), nil, "tmux", args...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return output, ErrTmuxMissing
+	}
+	return output, err
+}
+
+// tmuxRun runs tmux with args for its exit status
+func tmuxRun(args ...string) error {
+	_, err := tmuxOutput(args...)
+	return err
+}
+
+// noServer reports whether tmux failed because no server is running, i.e. there are no
+// sessions
+func noServer(err error) bool {
+	stderr := runner.Stderr(err)
+	return strings.Contains(stderr, "no server running") || strings.Contains(stderr, "error connecting")
+}
+
+// remote reports whether tmux is run on another host, over SSH
+func remote() bool {
+	_, ok := commands.(runner.SSH)
+	return ok
+}
+
 // IsInstalled checks if tmux is available
 func IsInstalled() bool {
+	if remote() {
+		_, err := commands.Run(context.Background(), nil, "tmux", "-V")
+		return err == nil
+	}
 	_, err := exec.LookPath("tmux")
 	return err == nil
 }
 
 // SessionExists checks if a tmux session exists
 func SessionExists(name string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", name)
-	return cmd.Run() == nil
+	return tmuxRun("has-session", "-t", name) == nil
 }
 
 // CreateOrAttachSession creates a new tmux session or attaches to existing one
 func CreateOrAttachSession(name, path string, cfg *config.Config) error {
+	sessionName, err := OpenSession(name, path, cfg)
+	if err != nil {
+		return err
+	}
+	return attachSession(sessionName)
+}
+
+// OpenSession creates a worktree's session, or brings an existing one up to date with the
+// config, without attaching to it, and returns the session's name
+func OpenSession(name, path string, cfg *config.Config) (string, error) {
 	if !IsInstalled() {
-		return fmt.Errorf("tmux is not installed")
+		return "", ErrTmuxMissing
 	}
 
 	// Sanitize session name - tmux doesn't allow dots in session names
 	sessionName := sanitizeSessionName(name)
+	ports, caches := sessionEnv(name, cfg)
 
 	// If session exists, ensure windows exist and attach
 	if SessionExists(sessionName) {
-		if err := ensureWindows(sessionName, name, path, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to ensure windows: %v\n", err)
-		}
-		return attachSession(sessionName)
+		withControl(sessionName, func() error {
+			// Panes started from now on get ports assigned since the session was created
+			for _, port := range ports {
+				if err := tmuxRun("set-environment", "-t", sessionName, port.Name, strconv.Itoa(port.Number)); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to set %s: %v\n", port.Name, err)
+				}
+			}
+			for _, env := range append(caches, container.Env(cfg.Container, name)...) {
+				variable, value, _ := strings.Cut(env, "=")
+				if err := tmuxRun("set-environment", "-t", sessionName, variable, value); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to set %s: %v\n", variable, err)
+				}
+			}
+			if err := releasePrewarmed(sessionName, name, path, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			if err := ensureWindows(sessionName, name, path, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to ensure windows: %v\n", err)
+			}
+			switchEnvironment(sessionName, name, cfg)
+			return nil
+		})
+		return sessionName, nil
 	}
 
 	// Create new session (pass both sanitized session name and original worktree name)
-	return createSession(sessionName, name, path, cfg)
+	return sessionName, buildSession(sessionName, name, path, ports, caches, cfg)
+}
+
+// sessionEnv is what goes in the environment of every pane in a worktree's session: its
+// ports, and the variables pointing it at the shared caches
+func sessionEnv(name string, cfg *config.Config) ([]config.Port, []string) {
+	ports, err := state.AssignPorts(cfg.GetConfigPath(), cfg.Ports, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	caches, err := cfg.CacheEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return ports, caches
 }
 
 // SanitizeSessionName converts characters that tmux doesn't allow in session names
@@ -57,8 +160,7 @@ func sanitizeSessionName(name string) string {
 // ensureWindows checks if the session has the correct pane layout and recreates if needed
 func ensureWindows(sessionName, worktreeName, path string, cfg *config.Config) error {
 	// Check if a window with the worktree name exists
-	cmd := exec.Command("tmux", "list-windows", "-t", sessionName, "-F", "#{window_name}")
-	output, err := cmd.Output()
+	output, err := tmuxOutput("list-windows", "-t", sessionName, "-F", "#{window_name}")
 	if err != nil {
 		return fmt.Errorf("failed to list windows: %w", err)
 	}
@@ -77,240 +179,337 @@ func ensureWindows(sessionName, worktreeName, path string, cfg *config.Config) e
 		// Kill all windows first
 		for _, line := range lines {
 			if line != "" {
-				cmd = exec.Command("tmux", "kill-window", "-t", fmt.Sprintf("%s:%s", sessionName, line))
-				cmd.Run() // Ignore errors
+				tmuxRun("kill-window", "-t", fmt.Sprintf("%s:%s", sessionName, line)) // Ignore errors
 			}
 		}
 
 		// Create new window with pane layout, named with the worktree name
-		cmd = exec.Command("tmux", "new-window", "-t", sessionName, "-n", worktreeName, "-c", path)
-		if err := cmd.Run(); err != nil {
+		if err := tmuxRun("new-window", "-t", sessionName, "-n", worktreeName, "-c", path); err != nil {
 			return fmt.Errorf("failed to create worktree window: %w", err)
 		}
 
 		// Create the pane layout
-		return createPaneLayout(sessionName, worktreeName, path, cfg)
+		return createPaneLayout(sessionName, worktreeName, path, cfg, false)
 	}
 
 	return nil
 }
 
-func createSession(sessionName, worktreeName, path string, cfg *config.Config) error {
+func createSession(sessionName, worktreeName, path string, ports []config.Port, caches []string, cfg *config.Config) error {
+	if err := buildSession(sessionName, worktreeName, path, ports, caches, cfg); err != nil {
+		return err
+	}
+	return attachSession(sessionName)
+}
+
+// buildSession starts a detached session laid out with the worktree's layout
+func buildSession(sessionName, worktreeName, path string, ports []config.Port, caches []string, cfg *config.Config) error {
+	return startSession(sessionName, worktreeName, path, ports, caches, cfg, func() error {
+		return createPaneLayout(sessionName, worktreeName, path, cfg, false)
+	})
+}
+
+// startSession creates a detached session with a single window named after the worktree,
+// with the worktree's ports, caches and container in its environment, then runs setup to
+// fill it in. Everything after creating it goes through one control mode client.
+func startSession(sessionName, worktreeName, path string, ports []config.Port, caches []string, cfg *config.Config, setup func() error) error {
 	// Verify path exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if _, err := os.Stat(path); os.IsNotExist(err) && !remote() {
 		return fmt.Errorf("path does not exist: %s", path)
 	}
 
 	// Create initial session (detached) with a single window
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", sessionName, "-c", path)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to create session: %s (output: %s)", err, string(output))
+	args := []string{"new-session", "-d", "-s", sessionName, "-c", path}
+	// At the size it'll be attached at, so its layout's fixed sizes come out as they're given
+	if width, height, ok := terminalSize(); ok {
+		args = append(args, "-x", strconv.Itoa(width), "-y", strconv.Itoa(height))
 	}
-
-	// Rename the window to show the worktree name
-	cmd = exec.Command("tmux", "rename-window", "-t", fmt.Sprintf("%s:0", sessionName), worktreeName)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to rename window: %w", err)
+	if remote() {
+		args = append(args, "-e", config.OnHostEnv+"=1")
 	}
-
-	// Enable mouse mode for this session
-	cmd = exec.Command("tmux", "set-option", "-t", sessionName, "mouse", "on")
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to enable mouse mode: %v\n", err)
+	for _, port := range ports {
+		args = append(args, "-e", port.Env())
+	}
+	for _, env := range append(caches, container.Env(cfg.Container, worktreeName)...) {
+		args = append(args, "-e", env)
+	}
+	// The environment picked for the worktree comes last, so it decides any variable it sets
+	for _, env := range environmentEnv(worktreeName, cfg) {
+		args = append(args, "-e", env)
+	}
+	if err := tmuxRun(args...); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
 	}
 
-	return createPaneLayout(sessionName, worktreeName, path, cfg)
+	return withControl(sessionName, func() error {
+		// Rename the window to show the worktree name
+		if err := tmuxRun("rename-window", "-t", fmt.Sprintf("%s:0", sessionName), worktreeName); err != nil {
+			return fmt.Errorf("failed to rename window: %w", err)
+		}
+
+		// Enable mouse mode for this session
+		if err := tmuxRun("set-option", "-t", sessionName, "mouse", "on"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to enable mouse mode: %v\n", err)
+		}
+		if environment := cfg.WorktreeEnvironment(worktreeName); environment != "" {
+			if err := tmuxRun("set-option", "-t", sessionName, sessionEnvironmentOption, environment); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record %s's environment: %v\n", worktreeName, err)
+			}
+		}
+		if err := bindRestartKey(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to bind the restart key: %v\n", err)
+		}
+		return setup()
+	})
 }
 
-func createPaneLayout(sessionName, worktreeName, path string, cfg *config.Config) error {
+// createPaneLayout splits the session's window into the worktree's layout and runs each
+// pane's command. Held, the commands are typed without being run, and the containers aren't
+// started, until releasePrewarmed.
+func createPaneLayout(sessionName, worktreeName, path string, cfg *config.Config, held bool) error {
 	// Use session and window index (window 0) as target to avoid issues with dots in window names
 	target := fmt.Sprintf("%s:0", sessionName)
 
 	// Get layout (handles backward compatibility with old Windows format)
-	layout := cfg.GetLayout()
+	layout := cfg.GetLayoutForWorktree(worktreeName)
 	if len(layout) == 0 {
 		return fmt.Errorf("no layout defined in config")
 	}
 
-	// Step 1: Create agent pane (always 45% of screen)
-	// Split pane 0: top 45% for agent, bottom 55% for user panes
-	paneTarget := fmt.Sprintf("%s.0", target)
-	fmt.Fprintf(os.Stderr, "DEBUG: Creating agent pane - target=%s, paneTarget=%s\n", target, paneTarget)
-	cmd := exec.Command("tmux", "split-window", "-t", paneTarget, "-v", "-p", "55", "-c", path)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to create agent pane: %w (output: %s)", err, string(output))
+	// The layout's commands are run in the worktree's containers, so they need to be up
+	if cfg.Container != nil && !held {
+		fmt.Fprintf(os.Stderr, "Starting containers for %s...\n", worktreeName)
+		if err := container.Up(context.Background(), cfg.Container, worktreeName, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	}
 
-	// Now we have:
-	// - Pane 0: agent (top 45%)
-	// - Pane 1: work area (bottom 55%)
+	// Sizes are worked out in lines and columns of the window, so fixed ones and limits hold
+	width, height := windowSize(target)
+	plan := PlanLayout(layout, width, height)
+
+	// Step 1: Create agent pane (always 45% of screen), splitting the work area off below it
+	if err := tmuxRun(append(plan.Splits[0].Args(target), "-c", path)...); err != nil {
+		return fmt.Errorf("failed to create agent pane: %w", err)
+	}
 
 	// Setup agent pane
 	agentPane := fmt.Sprintf("%s.0", target)
-	if err := setupAgentPane(agentPane, worktreeName, path, cfg); err != nil {
+	if err := typeCommand(agentPane, agentCommand(worktreeName, cfg), held); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to setup agent pane: %v\n", err)
 	}
+	tagPane(agentPane, "agent", agentCommand(worktreeName, cfg))
 
-	// Step 2: Build work panes in the bottom area according to layout
-	// Start with pane 1 (the user-configured work area)
-	paneIndex := 1
-
-	// Parse height percentages from layout
-	heights := make([]int, len(layout))
-	for i, row := range layout {
-		height := parsePercentage(row.Height)
-		if height <= 0 {
-			height = 100 / len(layout) // Default to equal split
+	// Step 2: Split the work area into the layout's rows, then each row into its panes
+	for _, split := range plan.Splits[1:] {
+		if err := tmuxRun(append(split.Args(target), "-c", path)...); err != nil {
+			return fmt.Errorf("failed to create %s: %w", split.For, err)
 		}
-		heights[i] = height
 	}
 
-	// Track remaining percentage of work area
-	remainingPercent := 100
-
-	// Step 1: Create all vertical rows first
-	for rowIdx := 1; rowIdx < len(layout); rowIdx++ {
-		// Calculate the sum of all remaining rows' heights
-		remainingHeight := 0
-		for i := rowIdx; i < len(layout); i++ {
-			remainingHeight += heights[i]
-		}
-
-		// Split percentage: give the new pane all remaining rows' space
-		// The current pane will keep what it needs automatically
-		splitPercent := (remainingHeight * 100) / remainingPercent
-
-		// Split vertically to create this row (always split the bottom pane)
-		splitTarget := fmt.Sprintf("%s.%d", target, paneIndex)
-		fmt.Fprintf(os.Stderr, "DEBUG: Creating row %d - splitTarget=%s, paneIndex=%d, splitPercent=%d, remainingPercent=%d, remainingHeight=%d\n",
-			rowIdx, splitTarget, paneIndex, splitPercent, remainingPercent, remainingHeight)
-		cmd := exec.Command("tmux", "split-window", "-t", splitTarget, "-v", "-p", fmt.Sprintf("%d", splitPercent), "-c", path)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create row %d: %w", rowIdx, err)
-		}
-
-		// Update remaining percentage (subtract the row we just created's height)
-		remainingPercent -= heights[rowIdx-1]
-		paneIndex++
-	}
-
-	// Now we have all vertical rows created
-	// Pane 0: agent
-	// Pane 1: row 0 (first user row)
-	// Pane 2: row 1 (second user row)
-	// etc.
-
-	// Step 3: Handle horizontal splits and commands for each row
-	paneIndex = 1 // Reset to first user pane (pane 1, after agent)
-	for rowIdx, row := range layout {
-		if len(row.Panes) > 0 {
-			// Multi-pane row: split horizontally within this row
-			rowStartPane := paneIndex
-
-			// Create all horizontal splits by splitting the leftmost pane each time
-			for paneIdx := 1; paneIdx < len(row.Panes); paneIdx++ {
-				// Calculate percentage: new pane gets (remaining-1)/remaining of current pane's size
-				remainingPanes := len(row.Panes) - paneIdx + 1
-				hSplitPercent := (100 * (remainingPanes - 1)) / remainingPanes
-
-				// Always split the first pane of this row (rowStartPane)
-				splitTarget := fmt.Sprintf("%s.%d", target, rowStartPane)
-				cmd := exec.Command("tmux", "split-window", "-t", splitTarget, "-h", "-p", fmt.Sprintf("%d", hSplitPercent), "-c", path)
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("failed to create horizontal pane %d in row %d: %w", paneIdx, rowIdx, err)
-				}
-			}
-
-			// After all splits, run commands on each pane
-			for paneIdx, pane := range row.Panes {
-				if pane.Command != nil && *pane.Command != "" {
-					paneTarget := fmt.Sprintf("%s.%d", target, rowStartPane+paneIdx)
-					cmd := exec.Command("tmux", "send-keys", "-t", paneTarget, *pane.Command, "Enter")
-					if err := cmd.Run(); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to run command in pane %s: %v\n", pane.Name, err)
-					}
-				}
+	// Step 3: Run each pane's command, recording it on the pane so it can be restarted
+	// Panes without a command get a shell in the container when the session is in one
+	containerShell := cfg.Container != nil && cfg.Container.Session
+	for _, pane := range plan.Panes[1:] {
+		paneTarget := fmt.Sprintf("%s.%d", target, pane.Index)
+		typed := ""
+		if pane.Command != "" || containerShell {
+			typed = paneCommand(cfg, worktreeName, path, pane.Name, pane.Command)
+			if err := typeCommand(paneTarget, typed, held); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to run command in pane %s: %v\n", pane.Name, err)
 			}
-
-			// Move to next row's starting pane
-			paneIndex += len(row.Panes)
-		} else {
-			// Single-pane row
-			if row.Command != nil && *row.Command != "" {
-				// Run command if specified
-				paneTarget := fmt.Sprintf("%s.%d", target, paneIndex)
-				cmd := exec.Command("tmux", "send-keys", "-t", paneTarget, *row.Command, "Enter")
-				if err := cmd.Run(); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to run command in pane %s: %v\n", row.Name, err)
-				}
-			}
-			paneIndex++
 		}
+		tagPane(paneTarget, pane.Name, typed)
 	}
 
 	// Select the agent pane (pane 0)
-	cmd = exec.Command("tmux", "select-pane", "-t", fmt.Sprintf("%s.0", target))
-	if err := cmd.Run(); err != nil {
+	if err := tmuxRun("select-pane", "-t", fmt.Sprintf("%s.0", target)); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to select agent pane: %v\n", err)
 	}
+	return nil
+}
 
-	// Attach to session
-	return attachSession(sessionName)
+// paneCommand is what's typed into a layout pane to run its command, or to open a shell in
+// the worktree's container when command is "". Commands are run in the container when
+// there is one, and with crash notifications on, through lfg exec, which notifies if they
+// fail.
+func paneCommand(cfg *config.Config, worktreeName, path, paneName, command string) string {
+	if cfg.Container != nil {
+		shell := command == ""
+		command = shellJoin(container.Exec(cfg.Container, worktreeName, path, command))
+		if shell {
+			return command
+		}
+	}
+	if !cfg.Notifies(config.NotifyCrash) {
+		return command
+	}
+	if paneName == "" {
+		paneName = "pane"
+	}
+	return fmt.Sprintf("%s exec --config %s %s %s %s", lfgPath(), shellQuote(cfg.HostConfigPath()), shellQuote(worktreeName), shellQuote(paneName), shellQuote(command))
 }
 
-func setupDescriptionPane(pane, worktreeName string, cfg *config.Config) error {
-	// Find lfg binary
-	lfgPath := "lfg"
+// commandOf is a layout pane's command, "" if it has none
+func commandOf(command *string) string {
+	if command == nil {
+		return ""
+	}
+	return *command
+}
+
+// shellQuote quotes s as a single word for the shell it's typed into
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellJoin is a command line for the shell it's typed into, quoting only the words that
+// need it so it stays readable in the pane
+func shellJoin(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = arg
+		if arg == "" || strings.ContainsFunc(arg, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@%+", r))
+		}) {
+			words[i] = shellQuote(arg)
+		}
+	}
+	return strings.Join(words, " ")
+}
 
-	// Try to find the absolute path
+// lfgPath is the lfg binary to run in panes, by its absolute path when it can be found here
+func lfgPath() string {
+	// The host's lfg is wherever its PATH has it
+	if remote() {
+		return "lfg"
+	}
 	if absPath, err := exec.LookPath("lfg"); err == nil {
-		lfgPath = absPath
+		return absPath
+	}
+	// Installed as gh lfg, lfg needn't be on PATH at all
+	if self, err := os.Executable(); err == nil {
+		return self
 	}
+	return "lfg"
+}
 
+func setupDescriptionPane(pane, worktreeName string, cfg *config.Config) error {
 	// Get the config path
-	configPath := cfg.GetConfigPath()
+	configPath := cfg.HostConfigPath()
 
 	// Launch the viewer TUI in the pane using lfg --view with config path
-	cmd := exec.Command("tmux", "send-keys", "-t", pane,
-		fmt.Sprintf("%s --view --config %s %s", lfgPath, configPath, worktreeName), "Enter")
-	return cmd.Run()
+	return tmuxRun("send-keys", "-t", pane,
+		fmt.Sprintf("%s --view --config %s %s", lfgPath(), configPath, worktreeName), "Enter")
 }
 
-func setupAgentPane(pane, worktreeName, path string, cfg *config.Config) error {
-	// Find lfg binary
-	lfgPath := "lfg"
-
-	// Try to find the absolute path
-	if absPath, err := exec.LookPath("lfg"); err == nil {
-		lfgPath = absPath
+// typeCommand types command into pane and runs it, or leaves it waiting at the prompt when
+// held
+func typeCommand(pane, command string, held bool) error {
+	if held {
+		return tmuxRun("send-keys", "-t", pane, command)
 	}
+	return tmuxRun("send-keys", "-t", pane, command, "Enter")
+}
 
-	// Get the config path
-	configPath := cfg.GetConfigPath()
-
-	// Launch the agent wrapper in the pane
-	// The wrapper will handle conversation capture and posting to GitHub
-	cmd := exec.Command("tmux", "send-keys", "-t", pane,
-		fmt.Sprintf("%s --agent --config %s %s", lfgPath, configPath, worktreeName), "Enter")
-	return cmd.Run()
+// agentCommand is what's typed into the agent's pane to run the agent wrapper
+func agentCommand(worktreeName string, cfg *config.Config) string {
+	return fmt.Sprintf("%s --agent --config %s %s", lfgPath(), cfg.HostConfigPath(), worktreeName)
 }
 
 func attachSession(name string) error {
-	// Check if we're already in a tmux session
-	if os.Getenv("TMUX") != "" {
+	// Check if we're already in a tmux session. A remote session can't be switched to from
+	// a local one, so it's attached inside it.
+	if os.Getenv("TMUX") != "" && !remote() {
 		// Switch to the session
-		cmd := exec.Command("tmux", "switch-client", "-t", name)
-		return cmd.Run()
+		return tmuxRun("switch-client", "-t", name)
 	}
 
 	// Attach to session (replace current process)
-	cmd := exec.Command("tmux", "attach-session", "-t", name)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return commands.Attach("tmux", "attach-session", "-t", name)
+}
+
+// SwitchClient moves the tmux client lfg is running in to a session. ok is false when
+// there's no client to move: outside of tmux, or with the session on a remote host.
+func SwitchClient(name string) (ok bool, err error) {
+	if os.Getenv("TMUX") == "" || remote() {
+		return false, nil
+	}
+	if err := tmuxRun("switch-client", "-t", name); err != nil {
+		return false, fmt.Errorf("failed to switch to %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// CurrentSession is the name of the tmux session lfg is running in, or "" outside of tmux or
+// with the sessions on a remote host
+func CurrentSession() (string, error) {
+	if os.Getenv("TMUX") == "" || remote() {
+		return "", nil
+	}
+	output, err := tmuxOutput("display-message", "-p", "#{session_name}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Popup runs a shell command in a popup filling the tmux client lfg is running in, until the
+// command exits
+func Popup(command string) error {
+	return commands.Attach("tmux", "display-popup", "-E", "-w", "100%", "-h", "100%", command)
+}
+
+// TypeLine types text into a pane as it is, without tmux reading key names in it, and
+// presses Enter
+func TypeLine(target, text string) error {
+	if err := tmuxRun("send-keys", "-t", target, "-l", text); err != nil {
+		return err
+	}
+	return tmuxRun("send-keys", "-t", target, "Enter")
+}
+
+// SendToPane types a command line for args into one of the layout's panes in a worktree's
+// session, or the agent's pane when paneName is "agent"
+func SendToPane(cfg *config.Config, worktreeName, paneName string, args []string) error {
+	sessionName := sanitizeSessionName(worktreeName)
+	if !SessionExists(sessionName) {
+		return fmt.Errorf("%s has no tmux session", worktreeName)
+	}
+	index, err := paneIndex(cfg.GetLayoutForWorktree(worktreeName), paneName)
+	if err != nil {
+		return err
+	}
+	if err := tmuxRun("send-keys", "-t", fmt.Sprintf("%s:0.%d", sessionName, index), shellJoin(args), "Enter"); err != nil {
+		return fmt.Errorf("failed to send the command to %s: %w", paneName, err)
+	}
+	return nil
+}
+
+// paneIndex is the index createPaneLayout gives the named pane: the agent's is 0, then the
+// layout's panes follow row by row
+func paneIndex(layout []config.LayoutRow, name string) (int, error) {
+	if name == "agent" {
+		return 0, nil
+	}
+	index := 1
+	names := []string{"agent"}
+	for _, row := range layout {
+		panes := row.Panes
+		if len(panes) == 0 {
+			panes = []config.Pane{{Name: row.Name}}
+		}
+		for _, pane := range panes {
+			if pane.Name == name {
+				return index, nil
+			}
+			if pane.Name != "" {
+				names = append(names, pane.Name)
+			}
+			index++
+		}
+	}
+	return 0, fmt.Errorf("no pane named %q, the layout has %s", name, strings.Join(names, ", "))
 }
 
 // KillSession kills a tmux session
@@ -319,17 +518,15 @@ func KillSession(name string) error {
 		return nil
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", name)
-	return cmd.Run()
+	return tmuxRun("kill-session", "-t", name)
 }
 
 // ListSessions returns all active tmux sessions
 func ListSessions() ([]string, error) {
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
-	output, err := cmd.Output()
+	output, err := tmuxOutput("list-sessions", "-F", "#{session_name}")
 	if err != nil {
 		// If no sessions exist, tmux returns an error
-		if strings.Contains(err.Error(), "no server running") {
+		if noServer(err) {
 			return []string{}, nil
 		}
 		return nil, err
@@ -339,16 +536,107 @@ func ListSessions() ([]string, error) {
 	return sessions, nil
 }
 
-// parsePercentage parses a percentage string like "40%" into an integer 40
-func parsePercentage(s string) int {
-	// Remove % sign and whitespace
-	s = strings.TrimSpace(s)
-	s = strings.TrimSuffix(s, "%")
+// SessionInfo describes a running tmux session
+type SessionInfo struct {
+	Name      string
+	Attached  int       // Number of clients attached
+	Watching  int       // How many of them are attached read-only, e.g. a teammate given lfg share's command
+	Activity  time.Time // When the session was last used, zero if tmux didn't say
+	Prewarmed bool      // Built by PrewarmSession and not opened since
+}
+
+// ListSessionInfo returns all active tmux sessions with how many clients are attached to
+// each, how many of those are read-only, and when each was last used
+func ListSessionInfo() ([]SessionInfo, error) {
+	return ListSessionInfoContext(context.Background())
+}
+
+// ListSessionInfoContext is ListSessionInfo, stopping tmux when ctx is done
+func ListSessionInfoContext(ctx context.Context) ([]SessionInfo, error) {
+	output, err := commands.Run(ctx, nil, "tmux", "list-sessions", "-F", "#{session_name}\t#{session_attached}\t#{session_activity}\t#{"+prewarmedOption+"}")
+	if err != nil {
+		// tmux errors when there's no server, which just means there are no sessions
+		if noServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sessions := parseSessionInfo(string(output))
+
+	// Sessions are still worth listing without knowing who's watching
+	clients, err := commands.Run(ctx, nil, "tmux", "list-clients", "-F", "#{client_session}\t#{client_readonly}")
+	if err == nil {
+		watching := parseWatching(string(clients))
+		for i := range sessions {
+			sessions[i].Watching = watching[sessions[i].Name]
+		}
+	}
+	return sessions, nil
+}
+
+// parseWatching counts the read-only clients of each session from `tmux list-clients`
+// output formatted as "session<TAB>readonly"
+func parseWatching(output string) map[string]int {
+	watching := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if session, readonly, ok := strings.Cut(line, "\t"); ok && strings.TrimSpace(readonly) == "1" {
+			watching[session]++
+		}
+	}
+	return watching
+}
 
-	// Parse as integer
-	val, err := strconv.Atoi(s)
+// Share returns the command that attaches to a worktree's session read-only, for a teammate
+// on the same machine to watch it with. user, if given, is let into the tmux server
+// read-only first, for a teammate logged in as someone else. tmux's access list only holds
+// for those who can reach its socket, which the directory tmux keeps its own sockets in,
+// tmux-<uid>, never lets them do, so that needs the server started with -S on a socket
+// they can open.
+func Share(worktreeName, user string) (string, error) {
+	sessionName := sanitizeSessionName(worktreeName)
+	if !SessionExists(sessionName) {
+		return "", fmt.Errorf("%s has no tmux session; open it first", worktreeName)
+	}
+	output, err := tmuxOutput("display-message", "-p", "-t", sessionName, "#{socket_path}")
 	if err != nil {
-		return 0
+		return "", fmt.Errorf("failed to find the tmux server's socket: %w", err)
+	}
+	socket := strings.TrimSpace(string(output))
+	if user != "" {
+		// tmux refuses to use the directory once anyone else can open it
+		if strings.HasPrefix(filepath.Base(filepath.Dir(socket)), "tmux-") {
+			return "", fmt.Errorf("the tmux server's socket %s is in a directory only you can open, so %s couldn't connect to it; run tmux with -S on a socket in a directory you share with them, readable and writable by a group of you both", socket, user)
+		}
+		if err := tmuxRun("server-access", "-a", "-r", user); err != nil {
+			return "", fmt.Errorf("failed to let %s into the tmux server: %w", user, err)
+		}
+	}
+	return shellJoin([]string{"tmux", "-S", socket, "attach", "-r", "-t", sessionName}), nil
+}
+
+// AttachReadOnly attaches the terminal to a worktree's session read-only
+func AttachReadOnly(worktreeName string) error {
+	return commands.Attach("tmux", "attach-session", "-r", "-t", sanitizeSessionName(worktreeName))
+}
+
+// parseSessionInfo parses `tmux list-sessions` output formatted as
+// "name<TAB>attached<TAB>activity<TAB>prewarmed", the activity in Unix seconds
+func parseSessionInfo(output string) []SessionInfo {
+	var sessions []SessionInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		info := SessionInfo{Name: fields[0]}
+		info.Attached, _ = strconv.Atoi(strings.TrimSpace(fields[1]))
+		if len(fields) > 2 {
+			if seconds, err := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64); err == nil && seconds > 0 {
+				info.Activity = time.Unix(seconds, 0)
+			}
+		}
+		info.Prewarmed = len(fields) > 3 && strings.TrimSpace(fields[3]) == "1"
+		sessions = append(sessions, info)
 	}
-	return val
+	return sessions
 }
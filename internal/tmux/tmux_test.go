@@ -1,7 +1,21 @@
 package tmux
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/runner"
 )
 
 func TestSanitizeSessionName(t *testing.T) {
@@ -48,3 +62,843 @@ func TestIsInstalled(t *testing.T) {
 	// We don't assert true/false as it depends on system
 	t.Logf("tmux installed: %v", result)
 }
+
+func TestParseSessionInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []SessionInfo
+	}{
+		{
+			name:     "empty",
+			output:   "",
+			expected: nil,
+		},
+		{
+			name:   "attached and detached sessions",
+			output: "project-feature\t2\nproject-bugfix\t0\n",
+			expected: []SessionInfo{
+				{Name: "project-feature", Attached: 2},
+				{Name: "project-bugfix", Attached: 0},
+			},
+		},
+		{
+			name:     "last activity",
+			output:   "project-feature\t0\t1791970200\n",
+			expected: []SessionInfo{{Name: "project-feature", Attached: 0, Activity: time.Unix(1791970200, 0)}},
+		},
+		{
+			name:     "prewarmed",
+			output:   "project-feature\t0\t1791970200\t1\nproject-bugfix\t0\t1791970200\t\n",
+			expected: []SessionInfo{{Name: "project-feature", Activity: time.Unix(1791970200, 0), Prewarmed: true}, {Name: "project-bugfix", Activity: time.Unix(1791970200, 0)}},
+		},
+		{
+			name:     "malformed lines are skipped",
+			output:   "no-tab-here\nproject-feature\t1\n",
+			expected: []SessionInfo{{Name: "project-feature", Attached: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseSessionInfo(tt.output)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseSessionInfo(%q) = %v, want %v", tt.output, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("parseSessionInfo(%q)[%d] = %v, want %v", tt.output, i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestListSessionInfoContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		stdout   string
+		clients  string // list-clients' answer, none when it's ""
+		err      error
+		expected []SessionInfo
+		wantErr  bool
+	}{
+		{
+			name:     "sessions",
+			stdout:   "main\t1\nfeature\t0\n",
+			expected: []SessionInfo{{Name: "main", Attached: 1}, {Name: "feature", Attached: 0}},
+		},
+		{
+			name:     "watched read-only",
+			stdout:   "main\t3\n",
+			clients:  "main\t0\nmain\t1\nmain\t1\n",
+			expected: []SessionInfo{{Name: "main", Attached: 3, Watching: 2}},
+		},
+		{
+			name: "no server",
+			err:  &runner.Error{Command: "tmux list-sessions", Stderr: "no server running on /tmp/tmux-0/default", Err: errors.New("exit status 1")},
+		},
+		{
+			name:    "other failure",
+			err:     &runner.Error{Command: "tmux list-sessions", Stderr: "unknown option", Err: errors.New("exit status 1")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("tmux list-sessions", tt.stdout, tt.err)
+			if tt.clients != "" {
+				fake.On("tmux list-clients", tt.clients, nil)
+			}
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			sessions, err := ListSessionInfo()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListSessionInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(sessions, tt.expected) {
+				t.Errorf("ListSessionInfo() = %+v, want %+v", sessions, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPaneCommand(t *testing.T) {
+	compose := "docker compose --project-directory /code/proj-x --project-name proj-x exec web sh -c"
+	tests := []struct {
+		name     string
+		config   string
+		pane     string
+		command  string
+		crash    bool   // Run through lfg exec
+		expected string // What's typed, or what follows the lfg binary and config path when it's run through lfg exec
+	}{
+		{name: "typed as it is", config: "notify: [agent]", pane: "server", command: "npm start", expected: "npm start"},
+		{name: "run through lfg exec", config: "notify: [crash]", pane: "server", command: "npm start", crash: true, expected: " 'proj-x' 'server' 'npm start'"},
+		{name: "quotes escaped", config: "notify: [crash, ci]", pane: "", command: "echo 'hi' && make", crash: true, expected: ` 'proj-x' 'pane' 'echo '\''hi'\'' && make'`},
+		{
+			name:     "in the compose service",
+			config:   "container:\n  service: web",
+			pane:     "server",
+			command:  "npm start",
+			expected: compose + " 'npm start'",
+		},
+		{
+			name:     "in the compose service through lfg exec",
+			config:   "notify: [crash]\ncontainer:\n  service: web",
+			pane:     "server",
+			command:  "npm start",
+			crash:    true,
+			expected: " 'proj-x' 'server' '" + compose + ` '\''npm start'\'''`,
+		},
+		{
+			name:     "shell in the dev container",
+			config:   "notify: [crash]\ncontainer:\n  type: devcontainer\n  session: true",
+			pane:     "shell",
+			expected: "devcontainer exec --workspace-folder /code/proj-x sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+			if err := os.WriteFile(configPath, []byte("name: proj\n"+tt.config+"\n"), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			cfg, err := config.LoadFromPath(configPath)
+			if err != nil {
+				t.Fatalf("LoadFromPath() error = %v", err)
+			}
+
+			got := paneCommand(cfg, "proj-x", "/code/proj-x", tt.pane, tt.command)
+			if !tt.crash {
+				if got != tt.expected {
+					t.Errorf("paneCommand() = %q, want %q", got, tt.expected)
+				}
+				return
+			}
+			want := " exec --config '" + configPath + "'" + tt.expected
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("paneCommand() = %q, want it to end %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSendToPane(t *testing.T) {
+	tests := []struct {
+		name     string
+		pane     string
+		expected string // The pane the command is sent to, "" if it isn't sent
+	}{
+		{name: "agent", pane: "agent", expected: "proj-x:0.0"},
+		{name: "single-pane row", pane: "server", expected: "proj-x:0.1"},
+		{name: "pane in a split row", pane: "tests", expected: "proj-x:0.3"},
+		{name: "unknown pane", pane: "logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+			layout := "name: proj\nlayout:\n  - name: server\n  - panes:\n      - name: shell\n      - name: tests\n"
+			if err := os.WriteFile(configPath, []byte(layout), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			cfg, err := config.LoadFromPath(configPath)
+			if err != nil {
+				t.Fatalf("LoadFromPath() error = %v", err)
+			}
+			fake := &runner.Fake{}
+			fake.On("tmux has-session", "", nil)
+			fake.On("tmux send-keys", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			err = SendToPane(cfg, "proj-x", tt.pane, []string{"go", "test", "-run", "Test Login"})
+			if (err != nil) != (tt.expected == "") {
+				t.Fatalf("SendToPane() error = %v", err)
+			}
+			var sent []string
+			for _, call := range fake.Calls() {
+				if call.Args[1] == "send-keys" {
+					sent = append(sent, call.Args[3])
+					if typed := call.Args[4]; typed != "go test -run 'Test Login'" {
+						t.Errorf("SendToPane() typed %q, want the argument with a space quoted", typed)
+					}
+				}
+			}
+			if tt.expected == "" {
+				if len(sent) > 0 {
+					t.Errorf("SendToPane() sent to %v, want nothing sent", sent)
+				}
+				return
+			}
+			if !reflect.DeepEqual(sent, []string{tt.expected}) {
+				t.Errorf("SendToPane() sent to %v, want %s", sent, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTypeLine(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("tmux send-keys", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	if err := TypeLine("%3", "Enter the staging details"); err != nil {
+		t.Fatalf("TypeLine() error = %v", err)
+	}
+	var sent []string
+	for _, call := range fake.Calls() {
+		sent = append(sent, call.String())
+	}
+	// Typed literally, "Enter" in the text isn't pressed as a key
+	want := []string{"tmux send-keys -t %3 -l Enter the staging details", "tmux send-keys -t %3 Enter"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("TypeLine() ran %q, want %q", sent, want)
+	}
+}
+
+func TestParseProcesses(t *testing.T) {
+	output := "  100     1 -zsh\n  205   100 npm run dev\n  230   205 node server.js\n  260   100 tail -f log\n  300     1 -zsh\n"
+	expected := map[string]string{"1": "-zsh", "100": "npm run dev", "205": "node server.js"}
+	if got := parseProcesses(output); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseProcesses() = %v, want %v", got, expected)
+	}
+}
+
+func TestCaptureAndRestoreWindow(t *testing.T) {
+	worktree := t.TempDir()
+	cfg := &config.Config{Name: "proj"}
+
+	fake := &runner.Fake{}
+	fake.On("tmux has-session", "", nil)
+	fake.On("tmux display-message", "a1b2,80x24,0,0{40x24,0,0,1,39x24,41,0,2}\n", nil)
+	fake.On("tmux list-panes", "100\t"+worktree+"\n300\t"+worktree+"/web\n", nil)
+	fake.On("ps", "  100     1 -zsh\n  205   100 lfg --agent proj-x\n  300     1 -zsh\n", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	window, err := CaptureWindow("proj-x")
+	if err != nil {
+		t.Fatalf("CaptureWindow() error = %v", err)
+	}
+	expected := &Window{
+		Layout: "a1b2,80x24,0,0{40x24,0,0,1,39x24,41,0,2}",
+		Panes:  []PaneState{{Path: worktree, Command: "lfg --agent proj-x"}, {Path: worktree + "/web"}},
+	}
+	if !reflect.DeepEqual(window, expected) {
+		t.Fatalf("CaptureWindow() = %+v, want %+v", window, expected)
+	}
+
+	fake = &runner.Fake{}
+	fake.On("tmux has-session", "", errors.New("no session"))
+	fake.On("tmux", "", nil)
+	SetRunner(fake)
+	if err := RestoreSession("proj-x", worktree, cfg, window); err != nil {
+		t.Fatalf("RestoreSession() error = %v", err)
+	}
+	var sent []string
+	for _, call := range fake.Calls() {
+		if call.Args[1] == "send-keys" || call.Args[1] == "select-layout" && call.Args[4] != "tiled" {
+			sent = append(sent, strings.Join(call.Args[1:], " "))
+		}
+	}
+	want := []string{
+		"select-layout -t proj-x:0 " + window.Layout,
+		"send-keys -t proj-x:0.0 lfg --agent proj-x Enter",
+		"send-keys -t proj-x:0.1 cd '" + worktree + "/web' Enter",
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("RestoreSession() ran %q, want %q", sent, want)
+	}
+}
+
+func TestShare(t *testing.T) {
+	fake := &runner.Fake{}
+	fake.On("tmux has-session", "", nil)
+	fake.On("tmux display-message", "/tmp/tmux-501/default\n", nil)
+	fake.On("tmux server-access", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	command, err := Share("proj.x", "")
+	if err != nil {
+		t.Fatalf("Share() error = %v", err)
+	}
+	if expected := "tmux -S /tmp/tmux-501/default attach -r -t proj_x"; command != expected {
+		t.Errorf("Share() = %q, want %q", command, expected)
+	}
+
+	// Another login can't reach a socket in tmux's own directory, whatever tmux lets in
+	if _, err := Share("proj.x", "sam"); err == nil || !strings.Contains(err.Error(), "-S") {
+		t.Errorf("Share() with the socket in tmux's own directory = %v, want it to say to run tmux with -S", err)
+	}
+	for _, call := range fake.Calls() {
+		if strings.HasPrefix(call.String(), "tmux server-access") {
+			t.Errorf("ran %s, want sam left out of a server they can't reach", call)
+		}
+	}
+
+	fake = &runner.Fake{}
+	fake.On("tmux has-session", "", nil)
+	fake.On("tmux display-message", "/srv/pair/tmux\n", nil)
+	fake.On("tmux server-access", "", nil)
+	SetRunner(fake)
+	command, err = Share("proj.x", "sam")
+	if err != nil {
+		t.Fatalf("Share() with a shared socket error = %v", err)
+	}
+	if expected := "tmux -S /srv/pair/tmux attach -r -t proj_x"; command != expected {
+		t.Errorf("Share() = %q, want %q", command, expected)
+	}
+	if calls := fake.Calls(); calls[len(calls)-1].String() != "tmux server-access -a -r sam" {
+		t.Errorf("last call = %s, want sam let in read-only", calls[len(calls)-1])
+	}
+}
+
+// controlFake is a tmux that can be started in control mode too, answering the commands
+// written to it the way Fake answers them when they're run on their own
+type controlFake struct {
+	*runner.Fake
+	mu      sync.Mutex
+	clients int      // How many control mode clients were started
+	alone   []string // The tmux commands run on their own
+	// exitAfter is how many commands a client answers before telling of its %exit, and
+	// leaving the rest unanswered. Zero answers them all.
+	exitAfter int
+}
+
+func (f *controlFake) Run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	f.alone = append(f.alone, args[0])
+	f.mu.Unlock()
+	return f.Fake.Run(ctx, stdin, name, args...)
+}
+
+func (f *controlFake) Attach(name string, args ...string) error {
+	_, err := f.Run(context.Background(), nil, name, args...)
+	return err
+}
+
+func (f *controlFake) Start(name string, args ...string) (*runner.Process, error) {
+	f.mu.Lock()
+	f.clients++
+	f.mu.Unlock()
+	stdin, input := io.Pipe()
+	output, stdout := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stdout.Close()
+		// tmux answers the attach first, and tells of the session's goings on in between
+		io.WriteString(stdout, "%begin 1 0 1\n%end 1 0 1\n%output %0 $ \n")
+		scanner := bufio.NewScanner(stdin)
+		for n := 1; scanner.Scan(); n++ {
+			if f.exitAfter > 0 && n > f.exitAfter {
+				continue
+			}
+			if n == f.exitAfter {
+				io.WriteString(stdout, "%exit\n")
+			}
+			result, err := f.Fake.Run(context.Background(), nil, name, controlWords(scanner.Text())...)
+			fmt.Fprintf(stdout, "%%begin 1 %d 1\n%s", n, result)
+			if err != nil {
+				fmt.Fprintf(stdout, "%s\n%%error 1 %d 1\n", err, n)
+			} else {
+				fmt.Fprintf(stdout, "%%end 1 %d 1\n", n)
+			}
+		}
+	}()
+	wait := func() error {
+		<-done
+		return nil
+	}
+	return &runner.Process{Stdin: input, Stdout: output, Wait: wait}, nil
+}
+
+// controlWords splits a control mode command line into its words, as tmux parses them
+func controlWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == ' ':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+func TestCreateSessionUsesControlMode(t *testing.T) {
+	t.Setenv("TMUX", "")
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	layout := "name: proj\nlayout:\n  - name: server\n    command: echo \"it's $PORT\"\n  - panes:\n      - name: shell\n      - name: tests\n"
+	if err := os.WriteFile(configPath, []byte(layout), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+
+	fake := &controlFake{Fake: &runner.Fake{}}
+	fake.On("tmux select-pane", "", &runner.Error{Command: "tmux select-pane", Stderr: "can't find pane: 0", Err: errors.New("exit status 1")})
+	fake.On("tmux", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	if err := createSession("proj-x", "proj-x", t.TempDir(), nil, nil, cfg); err != nil {
+		t.Fatalf("createSession() error = %v", err)
+	}
+	if fake.clients != 1 {
+		t.Fatalf("createSession() started %d control mode clients, want 1", fake.clients)
+	}
+	// Everything after creating the session went through the client, all but attaching
+	if want := []string{"new-session", "attach-session"}; !reflect.DeepEqual(fake.alone, want) {
+		t.Errorf("createSession() ran %q on their own, want %q", fake.alone, want)
+	}
+	var typed string
+	for _, call := range fake.Calls() {
+		if call.Args[1] == "send-keys" && call.Args[3] == "proj-x:0.1" {
+			typed = call.Args[4]
+		}
+	}
+	if typed != `echo "it's $PORT"` {
+		t.Errorf("createSession() typed %q into the server pane, want its command as it is", typed)
+	}
+	if activeControl.Load() != nil {
+		t.Error("createSession() left the control mode client open")
+	}
+}
+
+func TestControlModeExit(t *testing.T) {
+	fake := &controlFake{Fake: &runner.Fake{}, exitAfter: 1}
+	fake.On("tmux", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	err := withControl("proj-x", func() error {
+		if err := tmuxRun("rename-window", "-t", "proj-x:0", "proj-x"); err != nil {
+			return err
+		}
+		// tmux said it was exiting as it answered, so this one can't wait on the client
+		return tmuxRun("set-option", "-t", "proj-x", "mouse", "on")
+	})
+	if err != nil {
+		t.Fatalf("withControl() error = %v", err)
+	}
+	if want := []string{"set-option"}; !reflect.DeepEqual(fake.alone, want) {
+		t.Errorf("withControl() ran %q on their own, want %q", fake.alone, want)
+	}
+}
+
+func TestResolveSizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []size
+		total    int
+		expected []int
+	}{
+		{
+			name:     "percentages",
+			sizes:    []size{parseSize("60%", 0, 0), parseSize("40%", 0, 0)},
+			total:    50,
+			expected: []int{30, 20},
+		},
+		{
+			name:     "unset sizes share evenly",
+			sizes:    []size{parseSize("", 0, 0), parseSize("", 0, 0), parseSize("", 0, 0)},
+			total:    30,
+			expected: []int{10, 10, 10},
+		},
+		{
+			name:     "fixed sizes are kept and percentages share what they leave",
+			sizes:    []size{parseSize("3", 0, 0), parseSize("50%", 0, 0), parseSize("50%", 0, 0)},
+			total:    23,
+			expected: []int{3, 10, 10},
+		},
+		{
+			name:     "rounding goes to the flexible sizes",
+			sizes:    []size{parseSize("3", 0, 0), parseSize("", 0, 0), parseSize("", 0, 0)},
+			total:    24,
+			expected: []int{3, 10, 11},
+		},
+		{
+			name:     "a max gives the rest to the others",
+			sizes:    []size{parseSize("50%", 0, 8), parseSize("50%", 0, 0)},
+			total:    40,
+			expected: []int{8, 32},
+		},
+		{
+			name:     "a min takes from the others",
+			sizes:    []size{parseSize("10%", 12, 0), parseSize("", 0, 0), parseSize("", 0, 0)},
+			total:    40,
+			expected: []int{12, 14, 14},
+		},
+		{
+			name:     "sizes too big for the space are shrunk to fit",
+			sizes:    []size{parseSize("80%", 0, 0), parseSize("80%", 0, 0)},
+			total:    20,
+			expected: []int{10, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resolved := resolveSizes(tt.sizes, tt.total); !reflect.DeepEqual(resolved, tt.expected) {
+				t.Errorf("resolveSizes() = %v, want %v", resolved, tt.expected)
+			}
+		})
+	}
+
+	// Each split leaves room for the rows or panes after it, and the borders between them
+	if splits := splitSizes([]int{3, 10, 10}); !reflect.DeepEqual(splits, []int{21, 10}) {
+		t.Errorf("splitSizes() = %v, want [21 10]", splits)
+	}
+}
+
+func TestPlanLayout(t *testing.T) {
+	server := "npm run dev"
+	layout := []config.LayoutRow{
+		{Height: "3", Name: "notes"},
+		{Height: "60%", Name: "server", Command: &server},
+		{Height: "40%", Panes: []config.Pane{{Name: "shell", Width: "30%"}, {Name: "tests"}}},
+	}
+	plan := PlanLayout(layout, 120, 40)
+
+	var splits []string
+	for _, split := range plan.Splits {
+		splits = append(splits, strings.Join(split.Args("s:0"), " "))
+	}
+	expectedSplits := []string{
+		"split-window -t s:0.0 -v -l 21",
+		"split-window -t s:0.1 -v -l 17",
+		"split-window -t s:0.2 -v -l 7",
+		"split-window -t s:0.3 -h -l 84",
+	}
+	if !reflect.DeepEqual(splits, expectedSplits) {
+		t.Errorf("PlanLayout() splits = %q, want %q", splits, expectedSplits)
+	}
+
+	expectedPanes := []PaneGeometry{
+		{Index: 0, Name: "agent", Width: 120, Height: 18},
+		{Index: 1, Name: "notes", Top: 19, Width: 120, Height: 3},
+		{Index: 2, Name: "server", Command: "npm run dev", Top: 23, Width: 120, Height: 9},
+		{Index: 3, Name: "shell", Top: 33, Width: 35, Height: 7},
+		{Index: 4, Name: "tests", Left: 36, Top: 33, Width: 84, Height: 7},
+	}
+	if !reflect.DeepEqual(plan.Panes, expectedPanes) {
+		t.Errorf("PlanLayout() panes = %+v, want %+v", plan.Panes, expectedPanes)
+	}
+}
+
+func TestCheckLayout(t *testing.T) {
+	empty, missing := "", "lfg-no-such-program --watch"
+	tests := []struct {
+		name     string
+		layout   []config.LayoutRow
+		height   int
+		expected []string
+	}{
+		{
+			name:   "fine",
+			layout: []config.LayoutRow{{Height: "3", Name: "notes"}, {Name: "shell"}},
+			height: 40,
+		},
+		{
+			name:     "no rows",
+			height:   40,
+			expected: []string{"the layout has no rows"},
+		},
+		{
+			name: "sizes",
+			layout: []config.LayoutRow{
+				{Height: "70%", Name: "a"},
+				{Height: "40%", MinHeight: 5, MaxHeight: 2, Panes: []config.Pane{{Name: "b", Width: "wide"}, {Name: "c"}}},
+			},
+			height: 40,
+			expected: []string{
+				"row 2's height's minimum 5 is more than its maximum 2",
+				`row 2's pane 1's width "wide" isn't a percentage or a number of lines or columns`,
+				"the rows' heights add up to 110%",
+			},
+		},
+		{
+			name: "names and commands",
+			layout: []config.LayoutRow{
+				{Name: "a", Command: &empty},
+				{Name: "oops", Panes: []config.Pane{{Name: "a"}, {Command: &missing}}},
+			},
+			height: 40,
+			expected: []string{
+				"row 1's command is empty; leave it out for a shell",
+				"row 2 has panes, so its own name and command are ignored",
+				`row 2's pane 1 is named "a", as another pane is`,
+				"row 2's pane 2 has no name",
+				"row 2's pane 2's command runs lfg-no-such-program, which isn't installed",
+			},
+		},
+		{
+			name:     "too small",
+			layout:   []config.LayoutRow{{Height: "10", Name: "a"}, {Name: "b"}},
+			height:   20,
+			expected: []string{"row 1 is 10 lines high but gets 8 in a 80x20 window"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if problems := CheckLayout(tt.layout, 80, tt.height, true); !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("CheckLayout() = %q, want %q", problems, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrewarmedSession(t *testing.T) {
+	t.Setenv("TMUX", "")
+	configPath := filepath.Join(t.TempDir(), "lfg-config.yaml")
+	layout := "name: proj\nlayout:\n  - name: server\n    command: npm run dev\n  - panes:\n      - name: shell\n"
+	if err := os.WriteFile(configPath, []byte(layout), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error = %v", err)
+	}
+
+	fake := &runner.Fake{}
+	fake.On("tmux", "", nil)
+	previous := SetRunner(fake)
+	t.Cleanup(func() { SetRunner(previous) })
+
+	if err := buildPrewarmed("proj-x", "proj-x", t.TempDir(), nil, nil, cfg); err != nil {
+		t.Fatalf("buildPrewarmed() error = %v", err)
+	}
+	var typed []string
+	marked := false
+	for _, call := range fake.Calls() {
+		switch args := call.Args[1:]; args[0] {
+		case "send-keys":
+			typed = append(typed, strings.Join(args[3:], " "))
+		case "set-option":
+			marked = marked || strings.Join(args, " ") == "set-option -t proj-x @lfg-prewarmed 1"
+		case "attach-session", "switch-client":
+			t.Errorf("buildPrewarmed() ran %q, want the session left detached", args)
+		}
+	}
+	if want := []string{agentCommand("proj-x", cfg), "npm run dev"}; !reflect.DeepEqual(typed, want) {
+		t.Errorf("buildPrewarmed() typed %q, want %q without Enter", typed, want)
+	}
+	if !marked {
+		t.Error("buildPrewarmed() didn't mark the session prewarmed")
+	}
+
+	// Opening it runs the commands waiting in its panes, once
+	tests := []struct {
+		name      string
+		prewarmed string
+		expected  []string
+	}{
+		{
+			name:      "prewarmed",
+			prewarmed: "1\n",
+			expected:  []string{"send-keys -t %0 Enter", "send-keys -t %2 Enter", "set-option -u -t proj-x @lfg-prewarmed"},
+		},
+		{name: "opened already"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("tmux show-options -q -v -t proj-x @lfg-prewarmed", tt.prewarmed, nil)
+			fake.On("tmux list-panes", "%0 lfg --agent proj-x\n%1 \n%2 npm run dev\n", nil)
+			fake.On("tmux", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			if err := releasePrewarmed("proj-x", "proj-x", "/src/proj-x", cfg); err != nil {
+				t.Fatalf("releasePrewarmed() error = %v", err)
+			}
+			var ran []string
+			for _, call := range fake.Calls() {
+				if args := call.Args[1:]; args[0] != "show-options" && args[0] != "list-panes" {
+					ran = append(ran, strings.Join(args, " "))
+				}
+			}
+			if !reflect.DeepEqual(ran, tt.expected) {
+				t.Errorf("releasePrewarmed() ran %q, want %q", ran, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRestartWorktreePane(t *testing.T) {
+	tests := []struct {
+		name     string
+		pane     string
+		command  string
+		expected []string // The tmux commands run to restart it, nil if it isn't restarted
+	}{
+		{
+			name:     "a command",
+			pane:     "server",
+			command:  "npm run dev",
+			expected: []string{"respawn-pane -k -t %2 -c /src/proj-x", "clear-history -t %2", "send-keys -t %2 npm run dev Enter"},
+		},
+		{
+			name:     "a shell",
+			pane:     "server",
+			expected: []string{"respawn-pane -k -t %2 -c /src/proj-x", "clear-history -t %2"},
+		},
+		{name: "unknown pane", pane: "logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("tmux has-session", "", nil)
+			fake.On("tmux list-panes", "%0 agent\n%1\n%2 server\n", nil)
+			fake.On("tmux display-message -p -t %2 #{@lfg-pane}", "server\n", nil)
+			fake.On("tmux display-message -p -t %2 #{session_path}", "/src/proj-x\n", nil)
+			fake.On("tmux display-message -p -t %2 #{@lfg-command}", tt.command+"\n", nil)
+			fake.On("tmux", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			err := RestartWorktreePane("proj-x", tt.pane)
+			if (err != nil) != (tt.expected == nil) {
+				t.Fatalf("RestartWorktreePane() error = %v", err)
+			}
+			var ran []string
+			for _, call := range fake.Calls() {
+				if args := call.Args[1:]; args[0] != "has-session" && args[0] != "list-panes" && args[0] != "display-message" {
+					ran = append(ran, strings.Join(args, " "))
+				}
+			}
+			if !reflect.DeepEqual(ran, tt.expected) {
+				t.Errorf("RestartWorktreePane() ran %q, want %q", ran, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSwitchEnvironment(t *testing.T) {
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{
+			"local":   {"API_URL": "http://localhost:3000", "SEED": "1"},
+			"staging": {"API_URL": "https://staging.example.com"},
+		},
+		Todos: []config.Todo{{Worktree: "proj-x", Environment: "staging"}},
+	}
+	tests := []struct {
+		name      string
+		started   string // The environment the session's panes were started with
+		restarted bool
+	}{
+		{name: "changed", started: "local", restarted: true},
+		{name: "unchanged", started: "staging"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &runner.Fake{}
+			fake.On("tmux display-message -p -t proj-x #{@lfg-environment}", tt.started+"\n", nil)
+			fake.On("tmux list-panes", "%0 agent\n%1\n%2 server\n", nil)
+			fake.On("tmux display-message -p -t %2 #{@lfg-pane}", "server\n", nil)
+			fake.On("tmux", "", nil)
+			previous := SetRunner(fake)
+			t.Cleanup(func() { SetRunner(previous) })
+
+			switchEnvironment("proj-x", "proj-x", cfg)
+			var env, respawned []string
+			for _, call := range fake.Calls() {
+				switch args := call.Args[1:]; args[0] {
+				case "set-environment":
+					env = append(env, strings.Join(args[1:], " "))
+				case "respawn-pane":
+					respawned = append(respawned, args[3])
+				}
+			}
+			if want := []string{"-t proj-x API_URL https://staging.example.com", "-u -t proj-x SEED"}; !reflect.DeepEqual(env, want) {
+				t.Errorf("switchEnvironment() set %q, want %q", env, want)
+			}
+			// The agent and panes lfg didn't make are left running
+			var want []string
+			if tt.restarted {
+				want = []string{"%2"}
+			}
+			if !reflect.DeepEqual(respawned, want) {
+				t.Errorf("switchEnvironment() restarted %v, want %v", respawned, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// Dir returns the directory holding transcripts for a worktree
+func Dir(configPath, worktree string) string {
+	return filepath.Join(state.Dir(configPath), "transcripts", worktree)
+}
+
+// Create opens a new transcript file for a worktree, named by its start time
+func Create(configPath, worktree string) (*os.File, error) {
+	dir := Dir(configPath, worktree)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+
+	name := time.Now().Format("20060102-150405") + ".md"
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %w", err)
+	}
+	return file, nil
+}
+
+// List returns the transcript files for a worktree, oldest first
+func List(configPath, worktree string) ([]string, error) {
+	entries, err := os.ReadDir(Dir(configPath, worktree))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		paths = append(paths, filepath.Join(Dir(configPath, worktree), entry.Name()))
+	}
+
+	// Names are timestamps, so lexical order is chronological
+	sort.Strings(paths)
+	return paths, nil
+}
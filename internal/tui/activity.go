@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/git"
+)
+
+// activityWorkers is how many worktrees' activity is looked up at once
+const activityWorkers = 8
+
+type activityMsg struct {
+	activity map[string]git.Activity
+}
+
+// loadActivity collects git activity for every worktree in the background. Activity
+// shells out to git several times per worktree, too slow to do inside Update.
+func loadActivity(worktrees []git.Worktree) tea.Cmd {
+	// Copy the worktrees so the command doesn't race with later refreshes
+	worktrees = append([]git.Worktree(nil), worktrees...)
+	return func() tea.Msg {
+		base := ""
+		if len(worktrees) > 0 {
+			base = strings.TrimPrefix(worktrees[0].Branch, "refs/heads/")
+		}
+
+		// Worktrees are independent, so look a few of them up at once
+		results := make([]git.Activity, len(worktrees))
+		slots := make(chan struct{}, activityWorkers)
+		var wg sync.WaitGroup
+		for i, wt := range worktrees {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				results[i] = git.GetActivity(wt.Path, base)
+				<-slots
+			}()
+		}
+		wg.Wait()
+
+		activity := make(map[string]git.Activity, len(worktrees))
+		for i, wt := range worktrees {
+			activity[wt.Path] = results[i]
+		}
+		return activityMsg{activity: activity}
+	}
+}
+
+// applyActivity shows freshly loaded activity, re-sorting if the list is ordered by it
+func (m *model) applyActivity(activity map[string]git.Activity) {
+	m.activity = activity
+	m.previewKey = ""
+	m.resortItems()
+}
+
+// activityFor returns an item's git activity, zero until it has been loaded
+func (m *model) activityFor(item worktreeItem) git.Activity {
+	if !item.isCheckedOut {
+		return git.Activity{}
+	}
+	return m.activity[item.worktree.Path]
+}
+
+// baseBranch is the main worktree's branch, which other worktrees are compared against
+func (m *model) baseBranch() string {
+	if len(m.worktrees) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(m.worktrees[0].Branch, "refs/heads/")
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// archived reports whether an item is work finished longer ago than the archive age, which
+// the list hides unless archived items are shown
+func (m *model) archived(item worktreeItem, now time.Time) bool {
+	if statusRank(item) != 2 {
+		return false
+	}
+	// The main worktree is always listed, whatever its todo says
+	if item.isCheckedOut && len(m.worktrees) > 0 && m.worktrees[0].Path == item.worktree.Path {
+		return false
+	}
+
+	// Items finished before completion times were recorded count as long finished
+	var finished time.Time
+	if item.todo != nil && item.todo.Status == config.TodoStatusDone {
+		finished = item.todo.CompletedAt
+	}
+	if item.githubItem != nil && item.githubItem.UpdatedAt.After(finished) {
+		finished = item.githubItem.UpdatedAt
+	}
+	// Work still going on in the worktree keeps it listed
+	if last := m.activityFor(item).LastActivity(); last.After(finished) {
+		finished = last
+	}
+	return now.Sub(finished) >= m.config.ArchiveAge()
+}
+
+// withoutArchived drops archived items, returning the rest and how many were dropped
+func (m *model) withoutArchived(items []list.Item) ([]list.Item, int) {
+	now := time.Now()
+	kept := make([]list.Item, 0, len(items))
+	for _, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok && m.archived(item, now) {
+			continue
+		}
+		kept = append(kept, listItem)
+	}
+	return kept, len(items) - len(kept)
+}
+
+// toggleArchived shows or hides archived items and remembers the choice for next time
+func (m *model) toggleArchived() {
+	m.showArchived = !m.showArchived
+	m.resortItems()
+	switch age := m.config.ArchiveAge(); {
+	case m.showArchived:
+		m.notify(toastInfo, "Showing archived items")
+	case age == 0:
+		m.notify(toastInfo, "Hiding finished items")
+	default:
+		m.notify(toastInfo, "Hiding items finished over %s ago", archiveAgeLabel(age))
+	}
+
+	showArchived := m.showArchived
+	st, err := state.Update(m.config.GetConfigPath(), func(st *state.State) error {
+		st.ShowArchived = showArchived
+		return nil
+	})
+	if err != nil {
+		m.notifyError(fmt.Errorf("failed to save archive setting: %w", err))
+		return
+	}
+	m.state = st
+}
+
+// archiveAgeLabel describes the archive age in days, e.g. "14 days"
+func archiveAgeLabel(age time.Duration) string {
+	days := int(age / (24 * time.Hour))
+	if days == 1 {
+		return i18n.T("1 day")
+	}
+	return i18n.T("%d days", days)
+}
@@ -0,0 +1,16 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// blockedSummary names what the item is waiting on, e.g. "Blocked by proj-api, #12", in
+// the error color. It's "" once its blockers are all done.
+func (i worktreeItem) blockedSummary() string {
+	if len(i.blockers) == 0 {
+		return ""
+	}
+	return errorStyle.Render(i18n.T("Blocked by %s", strings.Join(i.blockers, ", ")))
+}
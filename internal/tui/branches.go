@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// branchItem is an entry in the branch picker
+type branchItem struct {
+	branch git.Branch
+}
+
+func (i branchItem) FilterValue() string { return i.branch.Name }
+func (i branchItem) Title() string       { return i.branch.Name }
+func (i branchItem) Description() string {
+	if i.branch.Remote != "" {
+		return i18n.T("remote branch, checked out as %s", i.branch.LocalName())
+	}
+	return i18n.T("local branch")
+}
+
+// startBranchPicker lists the branches that can be checked out in a new worktree
+func (m *model) startBranchPicker() tea.Cmd {
+	branches, err := git.ListBranches()
+	if err != nil {
+		m.notifyError(err)
+		return nil
+	}
+
+	// A branch can only be checked out in one worktree at a time
+	checkedOut := make(map[string]bool, len(m.worktrees))
+	for _, wt := range m.worktrees {
+		checkedOut[strings.TrimPrefix(wt.Branch, "refs/heads/")] = true
+	}
+	items := make([]list.Item, 0, len(branches))
+	for _, branch := range branches {
+		if !checkedOut[branch.LocalName()] {
+			items = append(items, branchItem{branch: branch})
+		}
+	}
+	if len(items) == 0 {
+		m.notify(toastInfo, "Every branch is already checked out in a worktree")
+		return nil
+	}
+
+	picker := list.New(items, m.delegate, 0, 0) // Sized by layout
+	picker.SetShowTitle(false)
+	picker.SetStatusBarItemName(i18n.T("branch"), i18n.T("branches"))
+	picker.SetShowHelp(false)
+	picker.DisableQuitKeybindings()
+	m.branches = &picker
+	m.layout()
+
+	// Start typing straight away, picking a branch is mostly a search
+	m.branches.SetFilterText("")
+	m.branches.SetFilterState(list.Filtering)
+	return nil
+}
+
+func (m *model) handleBranchPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		// Esc clears a filter first, then closes the picker
+		if m.branches.FilterState() == list.Unfiltered {
+			m.branches = nil
+			return m, nil
+		}
+
+	case "enter":
+		item, ok := m.branches.SelectedItem().(branchItem)
+		if !ok {
+			return m, nil
+		}
+		return m.handleCreateWorktreeFromBranch(item.branch)
+	}
+
+	var cmd tea.Cmd
+	*m.branches, cmd = m.branches.Update(msg)
+	return m, cmd
+}
+
+// handleCreateWorktreeFromBranch creates a worktree for an existing branch. A remote branch
+// gets a local branch of the same name tracking it.
+func (m *model) handleCreateWorktreeFromBranch(branch git.Branch) (tea.Model, tea.Cmd) {
+	localName := branch.LocalName()
+	// feat/login makes proj-feat-login rather than proj-featlogin
+	name := core.WorktreeName(m.config.Name, strings.ReplaceAll(localName, "/", " "))
+	request := createRequest{name: name, branch: localName, description: localName}
+	if branch.Remote != "" {
+		request.base = branch.Name
+	} else {
+		request.checkout = true
+	}
+
+	cmd := m.createOrRename(request, nil)
+	if m.clash == nil {
+		m.branches = nil
+	}
+	return m, cmd
+}
+
+func (m *model) viewBranchPicker() string {
+	var view strings.Builder
+	view.WriteString(titleStyle.Render(i18n.T("Create Worktree from Branch")))
+	view.WriteString("\n")
+	view.WriteString(m.branches.View())
+	view.WriteString("\n")
+	view.WriteString(helpStyle.Render(i18n.T("Type to filter | ↑↓: Move | Enter: Create | Esc: Cancel")))
+	return view.String()
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/browser"
+	"github.com/markcipolla/lfg/internal/github"
+)
+
+// itemURL is the issue the item is linked to, if any. Draft issues have no URL.
+func itemURL(item worktreeItem) string {
+	if item.githubItem != nil && item.githubItem.Content.URL != "" {
+		return item.githubItem.Content.URL
+	}
+	if item.todo != nil {
+		return item.todo.GitHubURL
+	}
+	return ""
+}
+
+// linkedURL returns a lookup for the item's issue, or the open PR for its branch when it
+// has no issue. The PR lookup talks to GitHub, so the lookup is for running in a tea.Cmd.
+// It's nil, after a toast saying why, when there's nothing to look up.
+func (m *model) linkedURL(item worktreeItem) func() (string, error) {
+	name := itemName(item)
+	if url := itemURL(item); url != "" {
+		return func() (string, error) { return url, nil }
+	}
+	if !item.isCheckedOut || !m.isGithubBackend() {
+		m.notify(toastWarning, "%s has no linked issue", name)
+		return nil
+	}
+
+	backend := m.config.StorageBackend
+	branch := itemBranch(item)
+	return func() (string, error) {
+		pr, err := github.FindPullRequestForBranch(backend.Owner, backend.Repo, branch)
+		if err != nil {
+			return "", err
+		}
+		if pr == nil {
+			return "", fmt.Errorf("%s has no linked issue or open pull request", name)
+		}
+		return pr.URL, nil
+	}
+}
+
+// openInBrowser opens the selected item's issue, or the open PR for its branch when it has no issue
+func (m *model) openInBrowser() tea.Cmd {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return nil
+	}
+
+	lookup := m.linkedURL(item)
+	if lookup == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		url, err := lookup()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := browser.Open(url); err != nil {
+			return errMsg{err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/checklist"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// itemKey identifies an item across refreshes
+func itemKey(item worktreeItem) string {
+	if item.isCheckedOut {
+		return git.GetWorktreeName(item.worktree.Path)
+	}
+	if item.githubItem != nil {
+		return "github:" + item.githubItem.ID
+	}
+	if item.todo != nil {
+		return item.todo.Worktree
+	}
+	return ""
+}
+
+// itemName is the human-readable name of an item
+func itemName(item worktreeItem) string {
+	if item.isCheckedOut {
+		return git.GetWorktreeName(item.worktree.Path)
+	}
+	if item.githubItem != nil {
+		return item.githubItem.Title
+	}
+	if item.todo != nil {
+		return item.todo.Description
+	}
+	return ""
+}
+
+// setItems replaces the list contents, attaching local status and keeping marks on
+// items that are still present
+func (m *model) setItems(items []list.Item) {
+	var backendItems []github.ProjectItem // To tell which blockers are done
+	for _, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok && item.githubItem != nil {
+			backendItems = append(backendItems, *item.githubItem)
+		}
+	}
+	var epics map[string]bool
+	if m.sortMode == "epic" {
+		epics = epicKeys(backendItems)
+	}
+	for idx, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok {
+			item = m.withState(item)
+			item.tasks = checklist.Parse(m.core.Checklist(m.target(item)))
+			item.tags = m.core.Tags(m.target(item))
+			item.blockers = m.core.Blockers(m.target(item), backendItems)
+			item.group = ""
+			if m.sortMode == "tag" {
+				item.group = groupTag(item)
+			}
+			item.epic = nil
+			if m.sortMode == "epic" {
+				item.epic = epicOf(item, epics)
+			}
+			item.marked = m.marked[itemKey(item)]
+			items[idx] = item
+		}
+	}
+	m.allItems = items
+
+	// Filter and sort a copy, so allItems keeps the order items were built in
+	shown := append([]list.Item(nil), items...)
+	m.archivedCount = 0
+	if !m.showArchived {
+		shown, m.archivedCount = m.withoutArchived(shown)
+	}
+	if m.tagFilter != "" {
+		shown = withTag(shown, m.tagFilter)
+	}
+	m.sortItems(shown)
+	if m.sortMode == "epic" {
+		shown = m.withEpicHeaders(shown)
+	}
+	m.list.SetItems(shown)
+
+	// Forget marks on items that have gone away
+	present := make(map[string]bool, len(items))
+	for _, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok {
+			present[itemKey(item)] = true
+		}
+	}
+	for key := range m.marked {
+		if !present[key] {
+			delete(m.marked, key)
+		}
+	}
+}
+
+// toggleMark marks or unmarks the selected item and moves to the next one
+func (m *model) toggleMark() {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return
+	}
+
+	key := itemKey(item)
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[key] {
+		delete(m.marked, key)
+	} else {
+		m.marked[key] = true
+	}
+
+	item.marked = m.marked[key]
+	m.list.SetItem(m.list.Index(), item)
+	m.list.CursorDown()
+}
+
+// markedItems returns the marked items in list order
+func (m *model) markedItems() []worktreeItem {
+	var items []worktreeItem
+	for _, listItem := range m.list.Items() {
+		if item, ok := listItem.(worktreeItem); ok && item.marked {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// clearMarks unmarks every item
+func (m *model) clearMarks() {
+	m.marked = nil
+	items := m.list.Items()
+	for idx, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok && item.marked {
+			item.marked = false
+			items[idx] = item
+		}
+	}
+	m.list.SetItems(items)
+}
+
+// handleBulkSync makes sure every marked worktree is tracked on the GitHub board, then
+// refetches so todos pick up the latest titles, bodies and URLs
+func (m *model) handleBulkSync() (tea.Model, tea.Cmd) {
+	items := m.markedItems()
+	if len(items) == 0 {
+		m.notify(toastWarning, "mark items with %s to sync them", m.keys.Mark.Help().Key)
+		return m, nil
+	}
+	if !m.isRemoteBackend() {
+		m.notify(toastWarning, "syncing requires a GitHub, org or plugin storage backend")
+		return m, nil
+	}
+	m.clearMarks()
+	m.loading = true
+
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		var warnings []string
+		for _, item := range items {
+			// Worktrees whose todo never made it onto the board get an item created
+			if item.githubItem != nil || item.todo == nil {
+				continue
+			}
+			created, err := m.core.CreateItem(item.todo.Description)
+			if err != nil {
+				warnings = append(warnings, i18n.T("failed to create %s item: %v", m.core.BackendName(), err))
+				continue
+			}
+			to, ok := m.core.AutoStatus(core.Target{Todo: item.todo}, created.Status, nil)
+			if !ok {
+				continue
+			}
+			if err := m.core.SetItemStatus(created, to); err != nil {
+				warnings = append(warnings, i18n.T("failed to update item status: %v", err))
+			}
+		}
+		return withWarnings(m.refreshAll(), warnings)
+	})
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/listcache"
+)
+
+// loadSnapshot returns the list as the last run left it, or nil if there's none for the
+// configured backend
+func loadSnapshot(cfg *config.Config) *listcache.Snapshot {
+	snapshot, err := listcache.Load(cfg.GetConfigPath())
+	if err != nil || !snapshot.Matches(cfg.StorageBackend) {
+		return nil
+	}
+	return snapshot
+}
+
+// snapshotItems rebuilds the list items from a snapshot, with todos from the current config
+func snapshotItems(cfg *config.Config, snapshot *listcache.Snapshot) []list.Item {
+	items := make([]list.Item, 0, len(snapshot.Items))
+	for _, cached := range snapshot.Items {
+		item := worktreeItem{githubItem: cached.GitHubItem}
+		if cached.Worktree != nil {
+			item.worktree = *cached.Worktree
+			item.isCheckedOut = true
+			item.todo = cfg.GetTodoForWorktree(git.GetWorktreeName(cached.Worktree.Path))
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// saveSnapshot records the reconciled list for the next launch to start from. It's only a
+// head start, so failing to save it isn't worth reporting.
+func (m *model) saveSnapshot() {
+	snapshot := listcache.Snapshot{
+		Backend:   m.config.StorageBackend,
+		Worktrees: m.worktrees,
+		Items:     make([]listcache.Item, 0, len(m.allItems)),
+		Statuses:  m.statuses,
+		Sessions:  m.sessions,
+	}
+	for _, listItem := range m.allItems {
+		item, ok := listItem.(worktreeItem)
+		if !ok {
+			continue
+		}
+		cached := listcache.Item{GitHubItem: item.githubItem}
+		if item.isCheckedOut {
+			worktree := item.worktree
+			cached.Worktree = &worktree
+		}
+		snapshot.Items = append(snapshot.Items, cached)
+	}
+	listcache.Save(m.config.GetConfigPath(), snapshot)
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/checklist"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// checklistView ticks off the items of an item's checklist
+type checklistView struct {
+	item   worktreeItem
+	tasks  []checklist.Item
+	cursor int
+	saving bool // A task is being checked, so the tasks may be about to change
+}
+
+// taskCheckedMsg reports the checklist toggleTask saved, as the markdown it left
+type taskCheckedMsg struct {
+	view     *checklistView
+	markdown string
+	err      error
+}
+
+// checklistSummary describes an item's checklist progress, e.g. "Checklist: 3/7", "" when
+// it has no checklist
+func (i worktreeItem) checklistSummary() string {
+	checked, total := checklist.Progress(i.tasks)
+	if total == 0 {
+		return ""
+	}
+	return i18n.T("Checklist: %d/%d", checked, total)
+}
+
+// startChecklist opens the selected item's checklist
+func (m *model) startChecklist() {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return
+	}
+	if len(item.tasks) == 0 {
+		m.notify(toastInfo, "%s has no checklist; add one to its notes as a task list, e.g. - [ ] Write tests", itemName(item))
+		return
+	}
+	m.checklist = &checklistView{item: item, tasks: item.tasks}
+}
+
+func (m *model) handleChecklistKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := m.checklist
+	switch msg.String() {
+	case "up", "k":
+		if view.cursor > 0 {
+			view.cursor--
+		}
+	case "down", "j":
+		if view.cursor < len(view.tasks)-1 {
+			view.cursor++
+		}
+	case " ", "x", "enter":
+		return m, m.toggleTask()
+	case "esc", "q":
+		m.checklist = nil
+	}
+	return m, nil
+}
+
+// toggleTask checks or unchecks the item under the cursor in the background. The list
+// refreshes once the service reports the change.
+func (m *model) toggleTask() tea.Cmd {
+	view := m.checklist
+	if view.saving {
+		return nil
+	}
+	view.saving = true
+	task := view.tasks[view.cursor]
+	target, index := m.target(view.item), view.cursor
+	return func() tea.Msg {
+		markdown, err := m.core.CheckTask(target, index, task.Text, !task.Checked)
+		return taskCheckedMsg{view: view, markdown: markdown, err: err}
+	}
+}
+
+// handleTaskChecked shows the checklist toggleTask saved, if it's still open
+func (m *model) handleTaskChecked(msg taskCheckedMsg) (tea.Model, tea.Cmd) {
+	view := msg.view
+	view.saving = false
+	if msg.err != nil {
+		m.notifyError(fmt.Errorf("%s: %w", itemName(view.item), msg.err))
+		return m, nil
+	}
+	view.tasks = checklist.Parse(msg.markdown)
+	view.cursor = max(min(view.cursor, len(view.tasks)-1), 0)
+	if len(view.tasks) == 0 && m.checklist == view {
+		m.checklist = nil
+	}
+	return m, nil
+}
+
+func (m *model) viewChecklist() string {
+	view := m.checklist
+	checked, total := checklist.Progress(view.tasks)
+
+	var result strings.Builder
+	result.WriteString(titleStyle.Render(i18n.T("Checklist of %s (%d/%d)", itemName(view.item), checked, total)))
+	result.WriteString("\n\n")
+	for i, task := range view.tasks {
+		box := "[ ]"
+		if task.Checked {
+			box = "[x]"
+		}
+		line := box + " " + task.Text
+		if i == view.cursor {
+			result.WriteString(markedStyle.Render("> "+line) + "\n")
+		} else {
+			result.WriteString("  " + line + "\n")
+		}
+	}
+	result.WriteString("\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Space: Check | Esc: Close")))
+	return result.String()
+}
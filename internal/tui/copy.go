@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/clipboard"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// copyText puts text on the clipboard in the background, confirming with a toast
+func copyText(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.Copy(text, os.Stdout); err != nil {
+			return errMsg{err: err}
+		}
+		return toastMsg{kind: toastSuccess, text: i18n.T("Copied %s: %s", i18n.T(what), text)}
+	}
+}
+
+// selectedCheckedOut returns the selected item if it has a worktree, toasting if not
+func (m *model) selectedCheckedOut() (worktreeItem, bool) {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return item, false
+	}
+	if !item.isCheckedOut {
+		m.notify(toastWarning, "%s has no worktree yet", itemName(item))
+		return item, false
+	}
+	return item, true
+}
+
+func (m *model) copyBranch() tea.Cmd {
+	item, ok := m.selectedCheckedOut()
+	if !ok {
+		return nil
+	}
+	return copyText("branch", itemBranch(item))
+}
+
+func (m *model) copyPath() tea.Cmd {
+	item, ok := m.selectedCheckedOut()
+	if !ok {
+		return nil
+	}
+	return copyText("path", item.worktree.Path)
+}
+
+func (m *model) copyURL() tea.Cmd {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return nil
+	}
+
+	lookup := m.linkedURL(item)
+	if lookup == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		url, err := lookup()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return copyText("URL", url)()
+	}
+}
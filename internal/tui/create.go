@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// pendingCreate is a worktree being created in the background
+type pendingCreate struct {
+	name       string
+	cancel     context.CancelFunc
+	cancelling bool
+}
+
+// createRequest is what to create: a worktree, its branch and todo, and optionally a GitHub issue
+type createRequest struct {
+	name        string
+	branch      string // Defaults to name
+	base        string // Defaults to HEAD
+	description string
+	layout      string // Entry in config.Layouts, or "" for the default layout
+	environment string // Entry in config.Environments, or "" for none
+	createIssue bool
+	checkout    bool // The branch already exists, check it out rather than creating it
+}
+
+// worktreeCreatedMsg reports the end of a background creation
+type worktreeCreatedMsg struct {
+	request    createRequest
+	githubItem *github.ProjectItem // The item the worktree was created from, if any
+	err        error
+	warnings   []string
+}
+
+// createClash is a worktree to create under another name, as its own was taken, waiting
+// for the new name to be confirmed
+type createClash struct {
+	request   createRequest // With the free name
+	item      *github.ProjectItem
+	taken     string // The name asked for
+	collision string // What it's taken by
+}
+
+func (m *model) handleCreateWorktree() (tea.Model, tea.Cmd) {
+	request, err := m.creating.request()
+	if err != nil {
+		m.notifyError(err)
+		return m, nil
+	}
+
+	// The form stays open underneath, to go back to if the new name is turned down
+	cmd := m.createOrRename(request, nil)
+	if m.clash == nil {
+		m.creating = nil
+	}
+	return m, cmd
+}
+
+func (m *model) handleCreateWorktreeFromGithub(item *github.ProjectItem) (tea.Model, tea.Cmd) {
+	// Generate worktree name from the GitHub item title
+	worktreeName := core.WorktreeName(m.config.Name, item.Title)
+	request := createRequest{name: worktreeName, description: item.Title}
+	// The branch's type comes from the item's labels, e.g. fix/ for a bug
+	if prefix := m.config.BranchPrefixFor(item.Labels); prefix != "" {
+		request.branch = prefix + worktreeName
+	}
+	return m, m.createOrRename(request, item)
+}
+
+// createOrRename creates a worktree, unless its name is taken by a worktree, directory,
+// branch or tmux session. Then it finds a free name to ask about creating it under instead.
+func (m *model) createOrRename(request createRequest, item *github.ProjectItem) tea.Cmd {
+	asked := request.serviceRequest(item)
+	collision, err := m.core.Collision(asked)
+	if err != nil || collision == "" {
+		// Whatever stopped the check is reported by creating it
+		return m.startCreate(request, item)
+	}
+	renamed, err := m.core.Unclash(asked)
+	if err != nil {
+		m.notifyError(fmt.Errorf("%s: %w", collision, err))
+		return nil
+	}
+	m.clash = &createClash{request: request, item: item, taken: request.name, collision: collision}
+	m.clash.request.name, m.clash.request.branch = renamed.Name, renamed.Branch
+	return nil
+}
+
+func (m *model) handleClashKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		clash := m.clash
+		m.clash, m.creating, m.branches = nil, nil, nil
+		return m, m.startCreate(clash.request, clash.item)
+	case "n", "N", "esc":
+		m.clash = nil
+	}
+	return m, nil
+}
+
+func (m *model) viewClash() string {
+	clash := m.clash
+	question := i18n.T("Create it as %s instead?", accentStyle.Render(clash.request.name))
+	if branch := clash.request.branch; branch != "" && branch != clash.request.name && !clash.request.checkout {
+		question = i18n.T("Create it as %s on branch %s instead?", accentStyle.Render(clash.request.name), accentStyle.Render(branch))
+	}
+	return fmt.Sprintf(
+		"%s\n\n%s\n\n%s\n\n%s\n",
+		titleStyle.Render(i18n.T("Create New Worktree")),
+		i18n.T("%s can't be used: %s.", accentStyle.Render(clash.taken), clash.collision),
+		question,
+		helpStyle.Render(i18n.T("y/Enter: Create | n/Esc: Back")),
+	)
+}
+
+// serviceRequest is the request as the service takes it
+func (r createRequest) serviceRequest(item *github.ProjectItem) core.CreateRequest {
+	return core.CreateRequest{
+		Name:        r.name,
+		Branch:      r.branch,
+		Base:        r.base,
+		Checkout:    r.checkout,
+		Environment: r.environment,
+		Item:        item,
+	}
+}
+
+// startCreate creates a worktree in the background, so large repos don't freeze the UI
+func (m *model) startCreate(request createRequest, item *github.ProjectItem) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pendingCreate = &pendingCreate{name: request.name, cancel: cancel}
+
+	create := func() tea.Msg {
+		defer cancel()
+		warnings, err := m.core.CreateWorktree(ctx, request.serviceRequest(item))
+		return worktreeCreatedMsg{request: request, githubItem: item, err: err, warnings: warnings}
+	}
+	return tea.Batch(m.spinner.Tick, create)
+}
+
+// cancelCreate stops the background creation. The worktreeCreatedMsg that follows clears it.
+func (m *model) cancelCreate() {
+	m.pendingCreate.cancelling = true
+	m.pendingCreate.cancel()
+}
+
+func (m *model) handleWorktreeCreated(msg worktreeCreatedMsg) (tea.Model, tea.Cmd) {
+	m.pendingCreate = nil
+	m.notifyWarnings(msg.warnings)
+	if errors.Is(msg.err, context.Canceled) {
+		m.notify(toastInfo, "Cancelled creating %s", msg.request.name)
+		return m, nil
+	}
+	if msg.err != nil {
+		m.notifyError(msg.err)
+		return m, nil
+	}
+
+	// The list refreshes once the service reports the new worktree
+	request := msg.request
+	if err := m.core.TrackWorktree(request.name, request.description, request.layout, request.environment, msg.githubItem); err != nil {
+		m.notifyError(err)
+	}
+	m.notify(toastSuccess, "Created worktree %s", request.name)
+
+	// A worktree for a GitHub item is opened straight away
+	if msg.githubItem != nil {
+		m.selectedWorktree = request.name
+		return m, tea.Quit
+	}
+
+	if m.isRemoteBackend() && request.createIssue {
+		return m, m.createItemFor(request.description, request.name)
+	}
+	return m, nil
+}
+
+// createItemFor creates an item for a new worktree in the background and starts it
+func (m *model) createItemFor(description, worktreeName string) tea.Cmd {
+	return func() tea.Msg {
+		item, err := m.core.CreateItem(description)
+		if err != nil {
+			return warningsMsg{i18n.T("failed to create %s item: %v", m.core.BackendName(), err)}
+		}
+		return warningsMsg(m.core.StartItem(item, worktreeName))
+	}
+}
+
+// viewCreateProgress shows the worktree being created
+func (m *model) viewCreateProgress() string {
+	status := i18n.T("Creating worktree %s...", accentStyle.Render(m.pendingCreate.name))
+	help := i18n.T("Esc: Cancel")
+	if m.pendingCreate.cancelling {
+		status = i18n.T("Cancelling, cleaning up %s...", accentStyle.Render(m.pendingCreate.name))
+		help = ""
+	}
+
+	return fmt.Sprintf(
+		"%s\n\n%s %s\n\n%s\n",
+		titleStyle.Render(i18n.T("Create New Worktree")),
+		m.spinner.View(),
+		status,
+		helpStyle.Render(help),
+	)
+}
@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// Fields of the creation form, in tab order
+const (
+	createFieldDescription = iota
+	createFieldBase
+	createFieldLayout
+	createFieldEnvironment
+	createFieldIssue
+	createFieldType
+	createFieldName
+	createFieldBranch
+)
+
+// createForm collects what's needed to create a worktree
+type createForm struct {
+	description  textinput.Model
+	base         textinput.Model
+	name         textinput.Model
+	branch       textinput.Model
+	layouts      []string // Named layouts to choose from, after the default layout
+	layout       int      // Index into layouts plus one, 0 is the default layout
+	environments []string // Environments to choose from, after none
+	environment  int      // Index into environments plus one, 0 is none
+	prefixes     []string // Branch types' prefixes to choose from, after none
+	prefix       int      // Index into prefixes plus one, 0 is none
+	canIssue     bool     // Items are tracked on GitHub or by a plugin, so one can be created
+	createIssue  bool
+	nameEdited   bool // Stop generating the name from the description once it's been edited
+	branchEdited bool // Likewise for the branch, which otherwise follows the name
+	fields       []int
+	focus        int // Index into fields
+}
+
+// startCreateForm opens the creation form
+func (m *model) startCreateForm() tea.Cmd {
+	newInput := func(placeholder string, limit int) textinput.Model {
+		input := textinput.New()
+		input.Placeholder = placeholder
+		input.CharLimit = limit
+		input.Width = 50
+		return input
+	}
+
+	form := &createForm{
+		description:  newInput(m.config.WorktreeNaming, 100),
+		base:         newInput(i18n.T("current branch"), 100),
+		name:         newInput(i18n.T("worktree name"), 100),
+		branch:       newInput(i18n.T("same as the worktree"), 100),
+		layouts:      m.config.LayoutNames(),
+		environments: m.config.EnvironmentNames(),
+		prefixes:     m.config.BranchPrefixes(),
+		canIssue:     m.isRemoteBackend(),
+		createIssue:  m.isRemoteBackend(),
+	}
+
+	form.fields = []int{createFieldDescription, createFieldBase}
+	if len(form.layouts) > 0 {
+		form.fields = append(form.fields, createFieldLayout)
+	}
+	if len(form.environments) > 0 {
+		form.fields = append(form.fields, createFieldEnvironment)
+	}
+	if form.canIssue {
+		form.fields = append(form.fields, createFieldIssue)
+	}
+	if len(form.prefixes) > 0 {
+		form.fields = append(form.fields, createFieldType)
+	}
+	form.fields = append(form.fields, createFieldName, createFieldBranch)
+
+	form.description.SetValue(m.config.WorktreeNaming)
+	form.description.CursorEnd()
+	form.generateNames(m.config.Name)
+
+	m.creating = form
+	return focus(&form.description)
+}
+
+// generateNames derives the worktree and branch names from the description, until they're edited
+func (f *createForm) generateNames(projectName string) {
+	if !f.nameEdited {
+		name := ""
+		if strings.TrimSpace(f.description.Value()) != "" {
+			name = core.WorktreeName(projectName, f.description.Value())
+		}
+		f.name.SetValue(name)
+	}
+	if !f.branchEdited {
+		branch := f.name.Value()
+		if branch != "" {
+			branch = f.prefixName() + branch
+		}
+		f.branch.SetValue(branch)
+	}
+}
+
+// input is the text input of the focused field, or nil for the choice fields
+func (f *createForm) input() *textinput.Model {
+	switch f.fields[f.focus] {
+	case createFieldDescription:
+		return &f.description
+	case createFieldBase:
+		return &f.base
+	case createFieldName:
+		return &f.name
+	case createFieldBranch:
+		return &f.branch
+	}
+	return nil
+}
+
+func (f *createForm) moveFocus(delta int) tea.Cmd {
+	if input := f.input(); input != nil {
+		input.Blur()
+	}
+	f.focus = (f.focus + delta + len(f.fields)) % len(f.fields)
+	if input := f.input(); input != nil {
+		return focus(input)
+	}
+	return nil
+}
+
+// change steps a choice field, wrapping around
+func (f *createForm) change(delta int) {
+	switch f.fields[f.focus] {
+	case createFieldLayout:
+		count := len(f.layouts) + 1
+		f.layout = (f.layout + delta + count) % count
+	case createFieldEnvironment:
+		count := len(f.environments) + 1
+		f.environment = (f.environment + delta + count) % count
+	case createFieldIssue:
+		f.createIssue = !f.createIssue
+	case createFieldType:
+		count := len(f.prefixes) + 1
+		f.prefix = (f.prefix + delta + count) % count
+	}
+}
+
+func (f *createForm) layoutName() string {
+	if f.layout == 0 {
+		return ""
+	}
+	return f.layouts[f.layout-1]
+}
+
+func (f *createForm) environmentName() string {
+	if f.environment == 0 {
+		return ""
+	}
+	return f.environments[f.environment-1]
+}
+
+// prefixName is the branch type's prefix picked, "" for none
+func (f *createForm) prefixName() string {
+	if f.prefix == 0 {
+		return ""
+	}
+	return f.prefixes[f.prefix-1]
+}
+
+// request validates the form and describes what to create. Names that are taken are left
+// for createOrRename to offer another for.
+func (f *createForm) request() (createRequest, error) {
+	request := createRequest{
+		name:        strings.TrimSpace(f.name.Value()),
+		branch:      strings.TrimSpace(f.branch.Value()),
+		base:        strings.TrimSpace(f.base.Value()),
+		description: strings.TrimSpace(f.description.Value()),
+		layout:      f.layoutName(),
+		environment: f.environmentName(),
+		createIssue: f.canIssue && f.createIssue,
+	}
+
+	if request.description == "" {
+		return request, fmt.Errorf("feature description cannot be empty")
+	}
+	if request.name == "" {
+		return request, fmt.Errorf("worktree name cannot be empty")
+	}
+	if strings.ContainsAny(request.name, `/\`) {
+		return request, fmt.Errorf("worktree name cannot contain slashes")
+	}
+	return request, nil
+}
+
+func (m *model) handleCreateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.creating
+	switch msg.String() {
+	case "esc":
+		m.creating = nil
+		return m, nil
+
+	case "enter", "ctrl+s":
+		return m.handleCreateWorktree()
+
+	case "tab", "down":
+		return m, form.moveFocus(1)
+
+	case "shift+tab", "up":
+		return m, form.moveFocus(-1)
+	}
+
+	input := form.input()
+	if input == nil {
+		switch msg.String() {
+		case "left", "h":
+			form.change(-1)
+		case "right", "l", " ":
+			form.change(1)
+		}
+		// The branch follows the type picked, until it's edited
+		form.generateNames(m.config.Name)
+		return m, nil
+	}
+
+	before := input.Value()
+	var cmd tea.Cmd
+	*input, cmd = input.Update(msg)
+	if input.Value() != before {
+		switch form.fields[form.focus] {
+		case createFieldName:
+			form.nameEdited = true
+		case createFieldBranch:
+			form.branchEdited = true
+		}
+		form.generateNames(m.config.Name)
+	}
+	return m, cmd
+}
+
+func (m *model) viewCreateWorktree() string {
+	form := m.creating
+
+	var view strings.Builder
+	view.WriteString(titleStyle.Render(i18n.T("Create New Worktree")))
+	view.WriteString("\n")
+
+	for idx, field := range form.fields {
+		label, value := "", ""
+		switch field {
+		case createFieldDescription:
+			label, value = i18n.T("Feature Description"), form.description.View()
+		case createFieldBase:
+			label, value = i18n.T("Base Branch"), form.base.View()
+		case createFieldLayout:
+			label, value = i18n.T("Layout"), "‹ "+i18n.T("default")+" ›"
+			if name := form.layoutName(); name != "" {
+				value = "‹ " + name + " ›"
+			}
+		case createFieldEnvironment:
+			label, value = i18n.T("Environment"), "‹ "+i18n.T("none")+" ›"
+			if name := form.environmentName(); name != "" {
+				value = "‹ " + name + " ›"
+			}
+		case createFieldType:
+			label, value = i18n.T("Branch Type"), "‹ "+i18n.T("none")+" ›"
+			if prefix := form.prefixName(); prefix != "" {
+				value = "‹ " + prefix + " ›"
+			}
+		case createFieldIssue:
+			check := "[ ]"
+			if form.createIssue {
+				check = "[x]"
+			}
+			label, value = i18n.T("%s Item", m.core.BackendName()), check+" "+i18n.T("Create an item for it in %s", m.core.BackendName())
+		case createFieldName:
+			label, value = i18n.T("Worktree Name"), form.name.View()
+		case createFieldBranch:
+			label, value = i18n.T("Branch Name"), form.branch.View()
+		}
+
+		if idx == form.focus {
+			label = accentStyle.Render(label + ":")
+		} else {
+			label = dimStyle.Render(label + ":")
+		}
+		view.WriteString(fmt.Sprintf("\n%s\n%s\n", label, value))
+	}
+
+	view.WriteString("\n")
+	view.WriteString(helpStyle.Render(i18n.T("Tab/↑↓: Move | ←→/Space: Change | Enter: Create | Esc: Cancel")))
+	view.WriteString("\n")
+	return view.String()
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"reflect"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/daemon"
+)
+
+// queryDaemon returns the running daemon's state, or nil if there's no daemon or its items
+// aren't for the configured backend
+func queryDaemon(cfg *config.Config) *daemon.State {
+	warm, err := daemon.Query(cfg.GetConfigPath())
+	if err != nil || !reflect.DeepEqual(warm.Backend, cfg.StorageBackend) {
+		return nil
+	}
+	return warm
+}
+
+// daemonItems hands the daemon's items to the list as if they had just been fetched
+func daemonItems(warm *daemon.State) tea.Cmd {
+	return func() tea.Msg {
+		return githubItemsMsg{items: warm.Items, statuses: warm.Statuses, seq: githubFetchSeq.Add(1)}
+	}
+}
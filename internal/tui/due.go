@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/humanize"
+)
+
+// due is the item's due date: its board item's when the board keeps one, otherwise its todo's
+func (i worktreeItem) due() string {
+	if i.githubItem != nil && i.githubItem.Due != "" {
+		return i.githubItem.Due
+	}
+	if i.todo != nil {
+		return i.todo.Due
+	}
+	return ""
+}
+
+// daysUntilDue counts the days until the item is due, negative once it's overdue. ok is
+// false for items without a due date and ones that are done.
+func (i worktreeItem) daysUntilDue() (days int, ok bool) {
+	if (i.todo != nil && i.todo.Status == config.TodoStatusDone) || (i.githubItem != nil && i.githubItem.Status == "Done") {
+		return 0, false
+	}
+	return humanize.DaysUntil(i.due(), time.Now())
+}
+
+// overdue reports whether the item's due date has passed without it being done
+func (i worktreeItem) overdue() bool {
+	days, ok := i.daysUntilDue()
+	return ok && days < 0
+}
+
+// dueSummary describes when the item is due, e.g. "Due in 3d", in the error color once
+// it's overdue. It's "" for items without a due date and ones that are done.
+func (i worktreeItem) dueSummary() string {
+	days, ok := i.daysUntilDue()
+	if !ok {
+		return ""
+	}
+	summary := humanize.Due(days)
+	summary = strings.ToUpper(summary[:1]) + summary[1:]
+	if days < 0 {
+		return errorStyle.Render(summary)
+	}
+	return summary
+}
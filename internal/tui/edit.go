@@ -0,0 +1,342 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/remedy"
+)
+
+// editForm edits an item's description, due date, tags, blockers and notes
+type editForm struct {
+	item        worktreeItem
+	description textinput.Model
+	due         textinput.Model
+	tags        textinput.Model
+	blockedBy   textinput.Model
+	notes       textarea.Model
+	hasDue      bool // Due dates live on the todo or the board, so not every item can have one
+	hasTags     bool // Tags live on the todo or the issue's labels
+	hasNotes    bool // Notes and blockers live on the todo, so items without a worktree have none
+	focus       editField
+	syncTitle   bool // Also rename the GitHub item
+}
+
+// editField is one of the edit form's fields
+type editField int
+
+const (
+	editDescription editField = iota
+	editDue
+	editTags
+	editBlockedBy
+	editNotes
+)
+
+// fields are the form's fields the item has, in the order Tab moves through them
+func (f *editForm) fields() []editField {
+	fields := []editField{editDescription}
+	if f.hasDue {
+		fields = append(fields, editDue)
+	}
+	if f.hasTags {
+		fields = append(fields, editTags)
+	}
+	if f.hasNotes {
+		fields = append(fields, editBlockedBy, editNotes)
+	}
+	return fields
+}
+
+// move focuses the field steps after the focused one, wrapping around
+func (f *editForm) move(steps int) tea.Cmd {
+	fields := f.fields()
+	f.focus = fields[(slices.Index(fields, f.focus)+steps+len(fields))%len(fields)]
+	f.description.Blur()
+	f.due.Blur()
+	f.tags.Blur()
+	f.blockedBy.Blur()
+	f.notes.Blur()
+	switch f.focus {
+	case editDue:
+		return focus(&f.due)
+	case editTags:
+		return focus(&f.tags)
+	case editBlockedBy:
+		return focus(&f.blockedBy)
+	case editNotes:
+		return focus(&f.notes)
+	}
+	return focus(&f.description)
+}
+
+// startEdit opens the edit form for the selected item
+func (m *model) startEdit() {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return
+	}
+
+	description := textinput.New()
+	description.CharLimit = 200
+	description.Width = 60
+	description.SetValue(itemDescription(item))
+	description.Focus()
+	description.CursorEnd()
+
+	due := textinput.New()
+	due.Placeholder = "YYYY-MM-DD"
+	due.CharLimit = len(time.DateOnly)
+	due.Width = 20
+	due.SetValue(item.due())
+
+	tags := textinput.New()
+	tags.Placeholder = "bug, spike"
+	tags.CharLimit = 200
+	tags.Width = 60
+	tags.SetValue(strings.Join(item.tags, ", "))
+
+	blockedBy := textinput.New()
+	blockedBy.Placeholder = "proj-api, #12"
+	blockedBy.CharLimit = 200
+	blockedBy.Width = 60
+
+	notes := textarea.New()
+	notes.Placeholder = i18n.T("Notes")
+	notes.SetWidth(60)
+	notes.SetHeight(8)
+	notes.ShowLineNumbers = false
+	if item.todo != nil {
+		blockedBy.SetValue(strings.Join(item.todo.BlockedBy, ", "))
+		notes.SetValue(item.todo.Notes)
+	}
+
+	m.editing = &editForm{
+		item:        item,
+		description: description,
+		due:         due,
+		tags:        tags,
+		blockedBy:   blockedBy,
+		notes:       notes,
+		hasDue:      item.isCheckedOut || (item.githubItem != nil && m.core.DueField() != ""),
+		hasTags:     item.isCheckedOut || (item.githubItem != nil && item.githubItem.Content.Number > 0 && m.isGithubBackend()),
+		hasNotes:    item.isCheckedOut,
+		syncTitle:   item.githubItem != nil && m.isRemoteBackend(),
+	}
+}
+
+// itemDescription is the text the edit form starts from
+func itemDescription(item worktreeItem) string {
+	if item.todo != nil {
+		return item.todo.Description
+	}
+	if item.githubItem != nil {
+		return item.githubItem.Title
+	}
+	return ""
+}
+
+func (m *model) handleEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.editing
+	switch msg.String() {
+	case "esc":
+		m.editing = nil
+		return m, nil
+
+	case "ctrl+s":
+		return m.saveEdit()
+
+	case "ctrl+g":
+		if form.item.githubItem != nil && m.isRemoteBackend() {
+			form.syncTitle = !form.syncTitle
+		}
+		return m, nil
+
+	case "tab":
+		return m, form.move(1)
+
+	case "shift+tab":
+		return m, form.move(-1)
+
+	case "enter":
+		// Enter saves from the one line fields, and starts a new line in the notes
+		if form.focus != editNotes {
+			return m.saveEdit()
+		}
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case editDue:
+		form.due, cmd = form.due.Update(msg)
+	case editTags:
+		form.tags, cmd = form.tags.Update(msg)
+	case editBlockedBy:
+		form.blockedBy, cmd = form.blockedBy.Update(msg)
+	case editNotes:
+		form.notes, cmd = form.notes.Update(msg)
+	default:
+		form.description, cmd = form.description.Update(msg)
+	}
+	return m, cmd
+}
+
+// editSavedMsg reports the changes saveEdit left to the background
+type editSavedMsg struct {
+	warnings []string
+	renamed  string // The item's new title, once the board item has it
+	stale    bool   // Renaming the board item failed, so it's fetched again
+}
+
+// saveEdit writes the edited description and notes to the todo, then sets the due date,
+// tags and blockers if they changed, and renames the GitHub item if asked, in the background
+func (m *model) saveEdit() (tea.Model, tea.Cmd) {
+	form := m.editing
+	description := strings.TrimSpace(form.description.Value())
+	if description == "" {
+		m.notify(toastWarning, "description cannot be empty")
+		return m, nil
+	}
+	due := strings.TrimSpace(form.due.Value())
+	if _, err := time.Parse(time.DateOnly, due); due != "" && err != nil {
+		m.notify(toastWarning, "due date must be YYYY-MM-DD")
+		return m, nil
+	}
+	m.editing = nil
+
+	item := form.item
+	if item.isCheckedOut {
+		name := git.GetWorktreeName(item.worktree.Path)
+		notes := strings.TrimSpace(form.notes.Value())
+		err := m.config.Update(func(cfg *config.Config) error {
+			todo := cfg.GetTodoForWorktree(name)
+			if todo == nil {
+				// Worktrees created outside lfg get a todo the first time they're edited
+				cfg.AddTodo(description, name)
+				todo = cfg.GetTodoForWorktree(name)
+			}
+			todo.Description = description
+			todo.Notes = notes
+			return nil
+		})
+		if err != nil {
+			m.notifyError(fmt.Errorf("failed to save config: %w", err))
+			return m, nil
+		}
+		m.notify(toastSuccess, "Saved %s", name)
+	}
+
+	target := m.target(item)
+	if item.isCheckedOut {
+		// The todo may only just have been made
+		target.Todo = m.config.GetTodoForWorktree(target.Worktree)
+	}
+	var changes []func() error
+	if form.hasDue && due != item.due() {
+		changes = append(changes, func() error { return m.core.SetDue(target, due) })
+	}
+	if tags := core.NormalizeList(strings.Split(form.tags.Value(), ",")); form.hasTags && !slices.Equal(tags, item.tags) {
+		changes = append(changes, func() error { return m.core.SetTags(target, tags) })
+	}
+	if blockers := core.NormalizeList(strings.Split(form.blockedBy.Value(), ",")); form.hasNotes && !slices.Equal(blockers, target.Todo.BlockedBy) {
+		changes = append(changes, func() error { return m.core.SetBlockedBy(target, blockers) })
+	}
+	rename := form.syncTitle && item.githubItem != nil && item.githubItem.Title != description
+	if len(changes) == 0 && !rename {
+		return m, m.refreshWorktrees
+	}
+
+	githubItem := item.githubItem
+	return m, func() tea.Msg {
+		var saved editSavedMsg
+		for _, change := range changes {
+			if err := change(); err != nil {
+				saved.warnings = append(saved.warnings, remedy.Message(err))
+			}
+		}
+		if rename {
+			if err := m.core.RenameItem(githubItem, description); err != nil {
+				saved.warnings = append(saved.warnings, remedy.Message(err))
+				saved.stale = true
+			} else {
+				saved.renamed = description
+			}
+		}
+		return saved
+	}
+}
+
+// handleEditSaved reports the changes saveEdit made in the background
+func (m *model) handleEditSaved(msg editSavedMsg) (tea.Model, tea.Cmd) {
+	m.notifyWarnings(msg.warnings)
+	if msg.stale {
+		m.loading = true
+		return m, tea.Batch(m.spinner.Tick, m.refreshAll)
+	}
+	if msg.renamed != "" {
+		// The list refreshes once the service reports the rename
+		m.notify(toastSuccess, "Renamed the %s item to %q", m.core.BackendName(), msg.renamed)
+		return m, nil
+	}
+	return m, m.refreshWorktrees
+}
+
+func (m *model) viewEdit() string {
+	form := m.editing
+
+	var view strings.Builder
+	view.WriteString(titleStyle.Render(i18n.T("Edit %s", itemName(form.item))))
+	view.WriteString("\n\n" + i18n.T("Description:") + "\n")
+	view.WriteString(form.description.View())
+	view.WriteString("\n")
+
+	if form.hasDue {
+		view.WriteString("\n" + i18n.T("Due date:") + "\n")
+		view.WriteString(form.due.View())
+		view.WriteString("\n")
+	}
+
+	if form.hasTags {
+		view.WriteString("\n" + i18n.T("Tags (comma separated):") + "\n")
+		view.WriteString(form.tags.View())
+		view.WriteString("\n")
+	}
+
+	if form.hasNotes {
+		view.WriteString("\n" + i18n.T("Blocked by (worktrees and issues, comma separated):") + "\n")
+		view.WriteString(form.blockedBy.View())
+		view.WriteString("\n")
+
+		view.WriteString("\n" + i18n.T("Notes:") + "\n")
+		view.WriteString(form.notes.View())
+		view.WriteString("\n")
+	}
+
+	if form.item.githubItem != nil && m.isRemoteBackend() {
+		check := "[ ]"
+		if form.syncTitle {
+			check = "[x]"
+		}
+		view.WriteString(fmt.Sprintf("\n%s %s\n", check, i18n.T("Rename the %s item to match", m.core.BackendName())))
+	}
+
+	help := i18n.T("Enter/Ctrl+S: Save | Esc: Cancel")
+	if len(form.fields()) > 1 {
+		help = i18n.T("Tab: Switch field | Ctrl+S: Save | Esc: Cancel")
+	}
+	if form.item.githubItem != nil && m.isRemoteBackend() {
+		help += " | " + i18n.T("Ctrl+G: Toggle rename")
+	}
+	view.WriteString(helpStyle.Render(help))
+	return view.String()
+}
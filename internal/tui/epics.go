@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// epicHeader heads an epic's section of the list when grouped by epic
+type epicHeader struct {
+	epic      github.ItemRef
+	count     int // Items in the section, folded away or not
+	done      int
+	collapsed bool
+}
+
+func (h epicHeader) Title() string {
+	fold := "▾"
+	if h.collapsed {
+		fold = "▸"
+	}
+	return accentStyle.Render(fold + " " + h.epic.Title)
+}
+
+func (h epicHeader) Description() string {
+	description := i18n.T("Epic")
+	if h.epic.Number > 0 {
+		description = i18n.T("Epic #%d", h.epic.Number)
+	}
+	description += " | " + i18n.T("%d/%d done", h.done, h.count)
+	if h.collapsed {
+		description += " | " + i18n.T("folded")
+	}
+	return description
+}
+
+func (h epicHeader) FilterValue() string {
+	return h.epic.Title
+}
+
+// epicKeys are the keys of the items' parents, the epics among the items and beyond
+func epicKeys(items []github.ProjectItem) map[string]bool {
+	epics := make(map[string]bool)
+	for _, item := range items {
+		if item.Parent != nil {
+			epics[item.Parent.Key()] = true
+		}
+	}
+	return epics
+}
+
+// epicOf is the epic an item is listed under when grouped by epic: its parent, or the item
+// itself when it's the parent of others. nil for items in no epic.
+func epicOf(item worktreeItem, epics map[string]bool) *github.ItemRef {
+	if item.githubItem == nil {
+		return nil
+	}
+	if item.githubItem.Parent != nil {
+		parent := *item.githubItem.Parent
+		return &parent
+	}
+	if own := item.githubItem.Ref(); epics[own.Key()] {
+		return &own
+	}
+	return nil
+}
+
+// isEpic reports whether the item is the epic it's listed under
+func (i worktreeItem) isEpic() bool {
+	return i.epic != nil && i.githubItem != nil && i.githubItem.Ref().Key() == i.epic.Key()
+}
+
+// withEpicHeaders puts a header above each epic's section of the sorted items, leaving out
+// the items of folded epics
+func (m *model) withEpicHeaders(items []list.Item) []list.Item {
+	rows := make([]list.Item, 0, len(items))
+	header := -1 // The current section's header, in rows
+	for _, row := range items {
+		item, ok := row.(worktreeItem)
+		if !ok || item.epic == nil {
+			rows = append(rows, row)
+			header = -1
+			continue
+		}
+		if header < 0 || rows[header].(epicHeader).epic.Key() != item.epic.Key() {
+			rows = append(rows, epicHeader{epic: *item.epic, collapsed: m.collapsed[item.epic.Key()]})
+			header = len(rows) - 1
+		}
+		section := rows[header].(epicHeader)
+		section.count++
+		if statusRank(item) == 2 {
+			section.done++
+		}
+		rows[header] = section
+		if !section.collapsed {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// toggleEpic folds or unfolds the selected epic, or the epic of the selected item, and
+// remembers it for next time
+func (m *model) toggleEpic() {
+	var epic *github.ItemRef
+	switch row := m.list.SelectedItem().(type) {
+	case epicHeader:
+		epic = &row.epic
+	case worktreeItem:
+		epic = row.epic
+		if epic == nil && m.sortMode == "epic" {
+			m.notify(toastInfo, "%s isn't in an epic", itemName(row))
+			return
+		}
+	}
+	if epic == nil {
+		m.notify(toastInfo, "Sort by epic to fold items under their epics")
+		return
+	}
+
+	key := epic.Key()
+	if m.collapsed[key] {
+		delete(m.collapsed, key)
+	} else {
+		m.collapsed[key] = true
+	}
+	m.resortItems()
+	m.selectRow("epic:" + key)
+
+	collapsed := make([]string, 0, len(m.collapsed))
+	for key := range m.collapsed {
+		collapsed = append(collapsed, key)
+	}
+	slices.Sort(collapsed)
+	st, err := state.Update(m.config.GetConfigPath(), func(st *state.State) error {
+		st.Collapsed = collapsed
+		return nil
+	})
+	if err != nil {
+		m.notifyError(fmt.Errorf("failed to save folded epics: %w", err))
+		return
+	}
+	m.state = st
+}
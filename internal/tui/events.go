@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/daemon"
+)
+
+// eventMsg delivers an event from the core service to the model
+type eventMsg core.Event
+
+// eventRefreshMsg is sent once the events from an action have had time to arrive
+type eventRefreshMsg struct{}
+
+// eventBuffer is how many events can wait for the model. More are dropped, the refresh
+// they'd have asked for is already coming.
+const eventBuffer = 64
+
+// eventDelay gathers the events from one action, like deleting several marked worktrees,
+// into a single refresh
+const eventDelay = 100 * time.Millisecond
+
+// subscribe passes the service's events to the model through a channel, since they can be
+// emitted from background commands
+func subscribe(service *core.Service) <-chan core.Event {
+	events := make(chan core.Event, eventBuffer)
+	service.Subscribe(func(event core.Event) {
+		select {
+		case events <- event:
+		default:
+		}
+	})
+	return events
+}
+
+// notifyDaemon asks the running daemon to look everything up again after each change, so
+// its warm state doesn't go stale until its next poll
+func notifyDaemon(service *core.Service) {
+	configPath := service.Config().GetConfigPath()
+	service.Subscribe(func(core.Event) {
+		go daemon.Refresh(configPath)
+	})
+}
+
+// waitForEvent delivers the next event from the service, until the model is done
+func (m *model) waitForEvent() tea.Msg {
+	select {
+	case event := <-m.events:
+		return eventMsg(event)
+	case <-m.done.Done():
+		return nil
+	}
+}
+
+// handleEvent schedules a refresh for a change the list doesn't show yet
+func (m *model) handleEvent(event core.Event) tea.Cmd {
+	if m.refreshQueued || event.Kind == core.SessionKilled || m.showsEvent(event) {
+		return m.waitForEvent
+	}
+	m.refreshQueued = true
+	return tea.Batch(m.waitForEvent, m.after(eventDelay, func() tea.Msg {
+		return eventRefreshMsg{}
+	}))
+}
+
+// showsEvent reports whether the list already shows an item's status change, like the
+// In Progress moves made while merging items into it
+func (m *model) showsEvent(event core.Event) bool {
+	if event.Kind != core.StatusChanged || event.Item == nil {
+		return false
+	}
+	for _, listItem := range m.allItems {
+		if item, ok := listItem.(worktreeItem); ok && item.githubItem != nil && item.githubItem.ID == event.Item.ID {
+			return item.githubItem.Status == event.Status
+		}
+	}
+	return false
+}
+
+// refreshForEvents reloads the list after changes made through the service
+func (m *model) refreshForEvents() tea.Cmd {
+	m.refreshQueued = false
+	if m.isRemoteBackend() {
+		m.loading = true
+		return tea.Batch(m.spinner.Tick, m.refreshAll)
+	}
+	return m.refreshWorktrees
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/browser"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// feedLimit is how many of the latest entries the activity feed shows
+const feedLimit = 200
+
+// feedView shows what has happened in the repository lately, newest first
+type feedView struct {
+	entries []core.FeedEntry
+	loading bool
+	cursor  int
+}
+
+type feedMsg struct {
+	entries []core.FeedEntry
+	err     error
+}
+
+// startFeed opens the activity feed, fetching it in the background
+func (m *model) startFeed() tea.Cmd {
+	m.feed = &feedView{loading: true}
+	return m.loadFeed()
+}
+
+// loadFeed gathers the feed from lfg's history and the backend
+func (m *model) loadFeed() tea.Cmd {
+	service := m.core
+	return func() tea.Msg {
+		entries, err := service.Feed(feedLimit)
+		return feedMsg{entries: entries, err: err}
+	}
+}
+
+// applyFeed shows a freshly loaded feed, unless it was closed while loading
+func (m *model) applyFeed(msg feedMsg) {
+	if msg.err != nil {
+		m.notifyError(fmt.Errorf("failed to fetch %s activity: %w", m.core.BackendName(), msg.err))
+	}
+	if m.feed == nil {
+		return
+	}
+	m.feed.entries = msg.entries
+	m.feed.loading = false
+	m.feed.cursor = min(m.feed.cursor, max(len(msg.entries)-1, 0))
+}
+
+func (m *model) handleFeedKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	view := m.feed
+	switch msg.String() {
+	case "up", "k":
+		if view.cursor > 0 {
+			view.cursor--
+		}
+	case "down", "j":
+		if view.cursor < len(view.entries)-1 {
+			view.cursor++
+		}
+	case "enter", "o":
+		if view.cursor < len(view.entries) {
+			if url := view.entries[view.cursor].URL; url != "" {
+				return m, func() tea.Msg {
+					if err := browser.Open(url); err != nil {
+						return errMsg{err: err}
+					}
+					return nil
+				}
+			}
+		}
+	case "r":
+		view.loading = true
+		return m, m.loadFeed()
+	case "esc", "q":
+		m.feed = nil
+	}
+	return m, nil
+}
+
+func (m *model) viewFeed() string {
+	view := m.feed
+
+	var result strings.Builder
+	result.WriteString(titleStyle.Render(i18n.T("Activity")))
+	result.WriteString("\n\n")
+	switch {
+	case view.loading && len(view.entries) == 0:
+		result.WriteString(m.spinner.View() + i18n.T("Fetching activity...") + "\n")
+	case len(view.entries) == 0:
+		result.WriteString(i18n.T("Nothing has happened here yet") + "\n")
+	}
+
+	// Show the page of entries around the cursor
+	rows := max(m.height-6, 5)
+	first := max(0, min(view.cursor-rows/2, len(view.entries)-rows))
+	for i := first; i < len(view.entries) && i < first+rows; i++ {
+		entry := view.entries[i]
+		line := fmt.Sprintf("%-9s %-14s %s", humanize.Ago(entry.Time), entry.Actor, entry.Text)
+		if i == view.cursor {
+			line = markedStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		if entry.Worktree != "" {
+			line += dimStyle.Render("  [" + entry.Worktree + "]")
+		}
+		result.WriteString(line + "\n")
+	}
+	result.WriteString("\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Open in browser | r: Refresh | Esc: Close")))
+	return result.String()
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// handoffPrompt asks which worktree to hand a worktree's todo to
+type handoffPrompt struct {
+	from    string
+	name    textinput.Model
+	running bool // The handoff has started, and keys wait for it
+}
+
+// handedOffMsg reports a handoff finishing
+type handedOffMsg struct {
+	from, to string
+	handed   core.HandedOff
+	err      error
+}
+
+// startHandoff asks where to hand the selected worktree's todo, suggesting a name made from
+// its description
+func (m *model) startHandoff() tea.Cmd {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return nil
+	}
+	if !item.isCheckedOut || item.todo == nil {
+		m.notify(toastInfo, "%s has no todo to hand off", itemName(item))
+		return nil
+	}
+
+	from := git.GetWorktreeName(item.worktree.Path)
+	name := textinput.New()
+	name.Placeholder = i18n.T("Worktree name")
+	name.CharLimit = 100
+	name.Width = 60
+	if suggested := core.WorktreeName(m.config.Name, item.todo.Description); suggested != from {
+		name.SetValue(suggested)
+	}
+	name.CursorEnd()
+	m.handoff = &handoffPrompt{from: from, name: name}
+	return focus(&m.handoff.name)
+}
+
+func (m *model) handleHandoffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := m.handoff
+	if prompt.running {
+		return m, nil
+	}
+	switch msg.String() {
+	case "esc":
+		m.handoff = nil
+		return m, nil
+
+	case "enter":
+		to := strings.TrimSpace(prompt.name.Value())
+		if to == "" {
+			m.notify(toastWarning, "worktree name cannot be empty")
+			return m, nil
+		}
+		prompt.running = true
+		service, from := m.core, prompt.from
+		return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+			handed, err := service.HandOff(context.Background(), from, to)
+			return handedOffMsg{from: from, to: to, handed: handed, err: err}
+		})
+	}
+
+	var cmd tea.Cmd
+	prompt.name, cmd = prompt.name.Update(msg)
+	return m, cmd
+}
+
+// handleHandedOff closes the prompt once the handoff finishes. The list refreshes once the
+// service reports it.
+func (m *model) handleHandedOff(msg handedOffMsg) {
+	m.handoff = nil
+	m.notifyWarnings(msg.handed.Warnings)
+	if msg.err != nil {
+		m.notifyError(msg.err)
+		return
+	}
+	if msg.handed.Session != "" {
+		m.notify(toastInfo, "Closed the session of %s", msg.from)
+	}
+	m.notify(toastSuccess, "Handed %s's todo to %s", msg.from, msg.to)
+}
+
+func (m *model) viewHandoff() string {
+	prompt := m.handoff
+	if prompt.running {
+		return fmt.Sprintf("%s\n\n%s %s\n", titleStyle.Render(i18n.T("Hand Off %s", prompt.from)), m.spinner.View(), i18n.T("Handing off to %s...", strings.TrimSpace(prompt.name.Value())))
+	}
+	return fmt.Sprintf(
+		"%s\n\n%s\n%s\n\n%s\n\n%s",
+		titleStyle.Render(i18n.T("Hand Off %s", prompt.from)),
+		i18n.T("Worktree to hand its todo, transcripts and scratchpad to:"),
+		prompt.name.View(),
+		dimStyle.Render(i18n.T("A worktree that doesn't exist is created from %s's branch.", prompt.from)),
+		helpStyle.Render(i18n.T("Enter: Hand off | Esc: Cancel")),
+	)
+}
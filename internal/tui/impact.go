@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// deleteImpact describes what deleting an item will do, shown before confirming
+type deleteImpact struct {
+	name         string
+	hasWorktree  bool
+	dirtyFiles   int
+	unpushed     int  // Commits not on the upstream, or ahead of the base branch if never pushed
+	neverPushed  bool // The branch has no upstream
+	merged       bool
+	session      string // tmux session that will be killed, if running
+	githubStatus string // What the tracked item will be left as, empty without one
+	backend      string // Where the item is tracked, to name it
+	removesTodo  bool
+}
+
+// deleteImpacts works out what deleting each of the items will do
+func (m *model) deleteImpacts(items []worktreeItem) []deleteImpact {
+	impacts := make([]deleteImpact, 0, len(items))
+	for _, item := range items {
+		impacts = append(impacts, m.deleteImpact(item))
+	}
+	return impacts
+}
+
+// deleteImpact mirrors the decisions deleting an item makes, without making them
+func (m *model) deleteImpact(item worktreeItem) deleteImpact {
+	impact := deleteImpact{name: itemName(item), backend: m.core.BackendName()}
+
+	if !item.isCheckedOut {
+		if item.githubItem != nil && m.isRemoteBackend() {
+			impact.githubStatus = "Done"
+		}
+		return impact
+	}
+
+	name := git.GetWorktreeName(item.worktree.Path)
+	impact.name = name
+	impact.hasWorktree = true
+	impact.removesTodo = m.config.GetTodoForWorktree(name) != nil
+
+	if count, err := git.DirtyFileCount(item.worktree.Path); err == nil {
+		impact.dirtyFiles = count
+	}
+
+	if count, err := git.UnpushedCommitCount(item.worktree.Path); err == nil {
+		impact.unpushed = count
+	} else {
+		impact.neverPushed = true
+		impact.unpushed, _, _ = git.AheadBehind(item.worktree.Path, m.baseBranch())
+	}
+
+	// Without a remote to check against, the branch counts as unmerged
+	merged, _ := git.IsBranchMerged(itemBranch(item))
+	impact.merged = merged
+
+	if session := tmux.SanitizeSessionName(name); tmux.SessionExists(session) {
+		impact.session = session
+	}
+
+	if item.githubItem != nil && m.isRemoteBackend() {
+		impact.githubStatus = item.githubItem.Status
+		if merged {
+			impact.githubStatus = "Done"
+		}
+	}
+
+	return impact
+}
+
+// warnings lists what will be lost, an empty list means nothing is
+func (i deleteImpact) warnings() []string {
+	var warnings []string
+	if i.dirtyFiles == 1 {
+		warnings = append(warnings, i18n.T("1 uncommitted file will be lost"))
+	} else if i.dirtyFiles > 1 {
+		warnings = append(warnings, i18n.T("%d uncommitted files will be lost", i.dirtyFiles))
+	}
+
+	commits := i18n.T("commits")
+	if i.unpushed == 1 {
+		commits = i18n.T("commit")
+	}
+	if i.neverPushed && i.unpushed > 0 {
+		warnings = append(warnings, i18n.T("branch was never pushed, %d %s will be lost", i.unpushed, commits))
+	} else if i.unpushed > 0 {
+		warnings = append(warnings, i18n.T("%d unpushed %s will be lost", i.unpushed, commits))
+	}
+
+	if i.hasWorktree && !i.merged {
+		warnings = append(warnings, i18n.T("branch is not merged"))
+	}
+	return warnings
+}
+
+// effects lists everything else deleting the item will do
+func (i deleteImpact) effects() []string {
+	var effects []string
+	if i.hasWorktree {
+		if i.merged {
+			effects = append(effects, i18n.T("branch is merged"))
+		}
+		effects = append(effects, i18n.T("worktree and branch %s will be deleted", i.name))
+	}
+	if i.session != "" {
+		effects = append(effects, i18n.T("tmux session '%s' will be killed", i.session))
+	}
+	switch i.githubStatus {
+	case "":
+	case "Done":
+		effects = append(effects, i18n.T("%s item will be moved to Done", i.backend))
+	default:
+		effects = append(effects, i18n.T("%s item will stay in %s", i.backend, i.githubStatus))
+	}
+	if i.removesTodo {
+		effects = append(effects, i18n.T("todo will be removed"))
+	}
+	return effects
+}
+
+// render lists the impact one line per warning and effect
+func (i deleteImpact) render() string {
+	var lines []string
+	for _, warning := range i.warnings() {
+		lines = append(lines, "  "+glyph(glyphs.Warning, errorStyle)+" "+errorStyle.Render(warning))
+	}
+	for _, effect := range i.effects() {
+		lines = append(lines, "  • "+effect)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summary describes the impact on a single line, for confirming several deletions at once
+func (i deleteImpact) summary() string {
+	if warnings := i.warnings(); len(warnings) > 0 {
+		return glyph(glyphs.Warning, errorStyle) + " " + errorStyle.Render(strings.Join(warnings, ", "))
+	}
+	if i.hasWorktree {
+		return i18n.T("merged, nothing will be lost")
+	}
+	return strings.Join(i.effects(), ", ")
+}
+
+// itemBranch is the branch checked out in the item's worktree, which is usually named after it
+func itemBranch(item worktreeItem) string {
+	if branch := strings.TrimPrefix(item.worktree.Branch, "refs/heads/"); branch != "" {
+		return branch
+	}
+	return git.GetWorktreeName(item.worktree.Path)
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// keyMap holds the bindings for every TUI action, built from the config
+type keyMap struct {
+	Quit        key.Binding
+	Open        key.Binding
+	New         key.Binding
+	Branches    key.Binding
+	Delete      key.Binding
+	Refresh     key.Binding
+	Mark        key.Binding
+	Status      key.Binding
+	CycleStatus key.Binding
+	Sync        key.Binding
+	Sort        key.Binding
+	Preview     key.Binding
+	PauseMirror key.Binding
+	FlushMirror key.Binding
+	Edit        key.Binding
+	Browse      key.Binding
+	CopyBranch  key.Binding
+	CopyPath    key.Binding
+	CopyURL     key.Binding
+	Archived    key.Binding
+	Raise       key.Binding
+	Lower       key.Binding
+	Checklist   key.Binding
+	TagFilter   key.Binding
+	Feed        key.Binding
+	Scratchpad  key.Binding
+	Collapse    key.Binding
+	Handoff     key.Binding
+}
+
+func newKeyMap(bindings config.Keybindings) keyMap {
+	binding := func(action, help string) key.Binding {
+		keys := bindings[action]
+		return key.NewBinding(
+			key.WithKeys(keys...),
+			key.WithHelp(helpKeys(keys), i18n.T(help)),
+		)
+	}
+
+	return keyMap{
+		Quit:        binding(config.ActionQuit, "quit"),
+		Open:        binding(config.ActionOpen, "open"),
+		New:         binding(config.ActionNew, "new"),
+		Branches:    binding(config.ActionBranches, "from branch"),
+		Delete:      binding(config.ActionDelete, "delete"),
+		Refresh:     binding(config.ActionRefresh, "refresh"),
+		Mark:        binding(config.ActionMark, "mark"),
+		Status:      binding(config.ActionStatus, "status"),
+		CycleStatus: binding(config.ActionCycleStatus, "next status"),
+		Sync:        binding(config.ActionSync, "sync marked"),
+		Sort:        binding(config.ActionSort, "sort"),
+		Preview:     binding(config.ActionPreview, "preview"),
+		PauseMirror: binding(config.ActionPauseMirror, "pause mirror"),
+		FlushMirror: binding(config.ActionFlushMirror, "flush mirror"),
+		Edit:        binding(config.ActionEdit, "edit"),
+		Browse:      binding(config.ActionBrowse, "browser"),
+		CopyBranch:  binding(config.ActionCopyBranch, "copy branch"),
+		CopyPath:    binding(config.ActionCopyPath, "copy path"),
+		CopyURL:     binding(config.ActionCopyURL, "copy url"),
+		Archived:    binding(config.ActionArchived, "show archived"),
+		Raise:       binding(config.ActionRaise, "raise priority"),
+		Lower:       binding(config.ActionLower, "lower priority"),
+		Checklist:   binding(config.ActionChecklist, "checklist"),
+		TagFilter:   binding(config.ActionTagFilter, "filter by tag"),
+		Feed:        binding(config.ActionFeed, "activity"),
+		Scratchpad:  binding(config.ActionScratchpad, "scratchpad"),
+		Collapse:    binding(config.ActionCollapse, "fold epic"),
+		Handoff:     binding(config.ActionHandoff, "hand off"),
+	}
+}
+
+// shortHelp lists the bindings shown in the list's help line, after its own navigation keys
+func (k keyMap) shortHelp() []key.Binding {
+	return []key.Binding{
+		k.New,
+		k.Branches,
+		k.Delete,
+		k.Edit,
+		k.Browse,
+		k.Refresh,
+		k.PauseMirror,
+		k.FlushMirror,
+		k.Mark,
+		k.Status,
+		k.CycleStatus,
+		k.Sync,
+		k.Sort,
+		k.Preview,
+	}
+}
+
+// fullHelp lists every binding for the list's expanded help
+func (k keyMap) fullHelp() []key.Binding {
+	return append(k.shortHelp(), k.CopyBranch, k.CopyPath, k.CopyURL, k.Archived, k.Raise, k.Lower, k.Checklist, k.TagFilter, k.Feed, k.Scratchpad, k.Collapse, k.Handoff)
+}
+
+// helpKeys formats keys for the help line, e.g. "n/c"
+func helpKeys(keys []string) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		if k == " " {
+			k = "space"
+		}
+		names[i] = k
+	}
+	return strings.Join(names, "/")
+}
@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/viewer"
+)
+
+// minPreviewWidth is the narrowest terminal the preview pane is shown in
+const minPreviewWidth = 100
+
+var previewStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.NormalBorder()).
+	BorderLeft(true).
+	BorderForeground(lipgloss.Color("241")).
+	PaddingLeft(1)
+
+// previewVisible reports whether there's room to show the preview pane
+func (m *model) previewVisible() bool {
+	return m.showPreview && m.width >= minPreviewWidth
+}
+
+// contentHeight is the height left for the list and preview
+func (m *model) contentHeight() int {
+	// Account for header (title, its margin and the recent worktrees line) + toasts (at least 1 line)
+	return m.height - 3 - m.toastLines()
+}
+
+// layout sizes the list to share the screen with the preview pane when it's shown
+func (m *model) layout() {
+	height := m.contentHeight()
+	if m.previewVisible() {
+		m.list.SetSize(m.width/2, height)
+	} else {
+		m.list.SetSize(m.width, height)
+	}
+	if m.branches != nil {
+		// The picker's help line takes the place of the recent worktrees line, plus its margin
+		m.branches.SetSize(m.width, height-1)
+	}
+	m.previewKey = ""
+}
+
+// viewPreview renders the selected item's details, re-rendering only when the selection changes
+func (m *model) viewPreview() string {
+	width := m.width - m.width/2 - previewStyle.GetHorizontalFrameSize()
+	height := m.contentHeight()
+
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return previewStyle.Height(height).Render("")
+	}
+
+	if key := itemKey(item); key != m.previewKey {
+		rendered, err := viewer.RenderMarkdown(m.previewMarkdown(item), width)
+		if err != nil {
+			rendered = i18n.T("Failed to render preview: %v", err)
+		}
+		m.previewKey = key
+		m.previewContent = rendered
+	}
+
+	return previewStyle.Height(height).MaxHeight(height).Width(width).Render(m.previewContent)
+}
+
+// previewMarkdown describes an item: its issue, todo, branch status, changes and recent commits
+func (m *model) previewMarkdown(item worktreeItem) string {
+	var content strings.Builder
+
+	content.WriteString("# " + itemName(item) + "\n\n")
+
+	if item.todo != nil && item.todo.Description != itemName(item) {
+		content.WriteString("## " + item.todo.Description + "\n\n")
+	} else if item.githubItem != nil && item.isCheckedOut {
+		content.WriteString("## " + item.githubItem.Title + "\n\n")
+	}
+
+	var facts []string
+	if item.githubItem != nil {
+		if item.githubItem.Content.Number > 0 {
+			facts = append(facts, i18n.T("Issue #%d", item.githubItem.Content.Number))
+		}
+		if item.githubItem.Status != "" {
+			facts = append(facts, i18n.T("Status: %s", item.githubItem.Status))
+		}
+	} else if item.todo != nil {
+		facts = append(facts, i18n.T("Status: %s", item.todo.Status))
+	}
+	if len(facts) > 0 {
+		content.WriteString("**" + strings.Join(facts, " · ") + "**\n\n")
+	}
+
+	body := ""
+	if item.githubItem != nil {
+		body = item.githubItem.Content.Body
+		if body == "" {
+			body = item.githubItem.Body
+		}
+	}
+	if body == "" && item.todo != nil {
+		body = item.todo.GitHubBody
+	}
+	if body != "" {
+		content.WriteString(body + "\n\n")
+	}
+
+	if item.todo != nil && item.todo.Notes != "" {
+		content.WriteString("### " + i18n.T("Notes") + "\n\n" + item.todo.Notes + "\n\n")
+	}
+
+	if !item.isCheckedOut {
+		content.WriteString("_" + i18n.T("No worktree yet. Press enter to create one.") + "_\n")
+		return content.String()
+	}
+
+	if scratchpad := state.Scratchpad(m.config.GetConfigPath(), git.GetWorktreeName(item.worktree.Path)); scratchpad != "" {
+		content.WriteString("### " + i18n.T("Scratchpad") + "\n\n" + scratchpad + "\n\n")
+	}
+
+	content.WriteString("### " + i18n.T("Branch") + "\n\n")
+	content.WriteString(m.branchStatus(item) + "\n\n")
+
+	if len(m.worktrees) > 0 && m.worktrees[0].Path != item.worktree.Path {
+		content.WriteString(m.changesSummary(item))
+	}
+
+	commits, err := git.RecentCommits(item.worktree.Path, 5)
+	if err == nil && len(commits) > 0 {
+		content.WriteString("### " + i18n.T("Recent commits") + "\n\n")
+		for _, commit := range commits {
+			hash, subject, _ := strings.Cut(commit, " ")
+			content.WriteString(fmt.Sprintf("- `%s` %s\n", hash, subject))
+		}
+	}
+
+	return content.String()
+}
+
+// maxPreviewFiles is how many changed files the preview lists before summarising the rest
+const maxPreviewFiles = 10
+
+// changesSummary describes what the worktree's branch changes since it forked from the base branch
+func (m *model) changesSummary(item worktreeItem) string {
+	base := m.baseBranch()
+	stat, err := git.DiffStatFromBase(item.worktree.Path, base)
+	if err != nil {
+		return ""
+	}
+
+	var content strings.Builder
+	content.WriteString("### " + i18n.T("Changes vs %s", base) + "\n\n")
+	switch len(stat.Files) {
+	case 0:
+		content.WriteString(i18n.T("No changes yet") + "\n\n")
+		return content.String()
+	case 1:
+		content.WriteString("**" + i18n.T("1 file changed, +%d −%d", stat.Insertions, stat.Deletions) + "**\n\n")
+	default:
+		content.WriteString("**" + i18n.T("%d files changed, +%d −%d", len(stat.Files), stat.Insertions, stat.Deletions) + "**\n\n")
+	}
+
+	for i, file := range stat.Files {
+		if i == maxPreviewFiles {
+			content.WriteString("- _" + i18n.T("and %d more", len(stat.Files)-maxPreviewFiles) + "_\n")
+			break
+		}
+		if file.Binary {
+			content.WriteString(fmt.Sprintf("- `%s` %s\n", file.Path, i18n.T("binary")))
+		} else {
+			content.WriteString(fmt.Sprintf("- `%s` +%d −%d\n", file.Path, file.Insertions, file.Deletions))
+		}
+	}
+	content.WriteString("\n")
+	return content.String()
+}
+
+// branchStatus summarises where a worktree's branch stands, e.g. "`feature` · 2 ahead of main · 3 uncommitted files"
+func (m *model) branchStatus(item worktreeItem) string {
+	parts := []string{i18n.T("detached HEAD")}
+	if item.worktree.Branch != "" {
+		parts[0] = "`" + strings.TrimPrefix(item.worktree.Branch, "refs/heads/") + "`"
+	}
+
+	if len(m.worktrees) > 0 && m.worktrees[0].Path != item.worktree.Path {
+		base := m.baseBranch()
+		activity := m.activityFor(item)
+		switch {
+		case activity.Ahead > 0 && activity.Behind > 0:
+			parts = append(parts, i18n.T("%d ahead, %d behind %s", activity.Ahead, activity.Behind, base))
+		case activity.Ahead > 0:
+			parts = append(parts, i18n.T("%d ahead of %s", activity.Ahead, base))
+		case activity.Behind > 0:
+			parts = append(parts, i18n.T("%d behind %s", activity.Behind, base))
+		default:
+			parts = append(parts, i18n.T("up to date with %s", base))
+		}
+	}
+
+	if count, err := git.DirtyFileCount(item.worktree.Path); err == nil {
+		switch count {
+		case 0:
+			parts = append(parts, i18n.T("clean"))
+		case 1:
+			parts = append(parts, i18n.T("1 uncommitted file"))
+		default:
+			parts = append(parts, i18n.T("%d uncommitted files", count))
+		}
+	}
+
+	if last := m.activityFor(item).LastActivity(); !last.IsZero() {
+		parts = append(parts, i18n.T("active %s", humanize.Ago(last)))
+	}
+
+	return strings.Join(parts, " · ")
+}
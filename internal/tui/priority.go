@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// priority is the item's priority: its board item's when the board keeps one, otherwise its todo's
+func (i worktreeItem) priority() string {
+	if i.githubItem != nil && i.githubItem.Priority != "" {
+		return i.githubItem.Priority
+	}
+	if i.todo != nil {
+		return i.todo.Priority
+	}
+	return ""
+}
+
+// shiftPriority raises the selected item's priority by steps in the background, or lowers
+// it for negative steps. The list refreshes once the service reports the change.
+func (m *model) shiftPriority(steps int) tea.Cmd {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return nil
+	}
+	current := item.priority()
+	next := core.ShiftPriority(m.core.PriorityOptions(), current, steps)
+	if next == current {
+		return nil
+	}
+	target, name := m.target(item), itemName(item)
+	return func() tea.Msg {
+		if err := m.core.SetPriority(target, next); err != nil {
+			return errMsg{err: fmt.Errorf("%s: %w", name, err)}
+		}
+		if next == "" {
+			return toastMsg{kind: toastSuccess, text: i18n.T("Cleared the priority of %s", name)}
+		}
+		return toastMsg{kind: toastSuccess, text: i18n.T("Set %s to %s priority", name, next)}
+	}
+}
+
+// priorityRank orders items by priority, most urgent first, with items without one last
+func priorityRank(options []string, item worktreeItem) int {
+	if rank := slices.Index(options, item.priority()); rank >= 0 {
+		return rank
+	}
+	return len(options)
+}
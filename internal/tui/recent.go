@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// maxRecentShortcuts is how many recent worktrees get a number key
+const maxRecentShortcuts = 9
+
+// recentWorktrees lists the recently used worktrees that still exist, most recent first
+func (m *model) recentWorktrees() []string {
+	if m.state == nil {
+		return nil
+	}
+
+	exists := make(map[string]bool, len(m.worktrees))
+	for _, wt := range m.worktrees {
+		exists[git.GetWorktreeName(wt.Path)] = true
+	}
+
+	var recent []string
+	for _, name := range m.state.Recent {
+		if exists[name] {
+			recent = append(recent, name)
+		}
+		if len(recent) == maxRecentShortcuts {
+			break
+		}
+	}
+	return recent
+}
+
+// recentShortcut returns the index into recentWorktrees a number key jumps to
+func recentShortcut(msg tea.KeyMsg) (int, bool) {
+	key := msg.String()
+	if len(key) != 1 || key[0] < '1' || key[0] > '9' {
+		return 0, false
+	}
+	return int(key[0] - '1'), true
+}
+
+// jumpTo quits the TUI to open a worktree. The main worktree is opened by leaving the current session.
+func (m *model) jumpTo(name string) (tea.Model, tea.Cmd) {
+	if len(m.worktrees) > 0 && git.GetWorktreeName(m.worktrees[0].Path) == name {
+		m.exitToMain = true
+	}
+	m.selectedWorktree = name
+	return m, tea.Quit
+}
+
+// viewRecent shows the recently used worktrees with their number keys, e.g. "Recent: 1 app-login  2 app-api"
+func (m *model) viewRecent() string {
+	recent := m.recentWorktrees()
+	if len(recent) == 0 {
+		return ""
+	}
+
+	entries := make([]string, len(recent))
+	for i, name := range recent {
+		entries[i] = accentStyle.Render(fmt.Sprintf("%d", i+1)) + " " + name
+	}
+	line := dimStyle.Render(i18n.T("Recent:")+" ") + strings.Join(entries, "  ")
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
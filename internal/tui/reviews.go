@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+type reviewsMsg struct {
+	reviews map[string]github.ReviewStatus
+}
+
+// loadReviews looks up review of the repository's open pull requests in the background, for
+// GitHub backends. The list is just as usable without it, so failures are left quiet.
+func loadReviews(cfg *config.Config) tea.Cmd {
+	backend := cfg.StorageBackend
+	if !backend.IsGitHub() {
+		return nil
+	}
+	return func() tea.Msg {
+		reviews, err := github.ListPullRequestReviews(backend.Owner, backend.Repo)
+		if err != nil {
+			return nil
+		}
+		return reviewsMsg{reviews: reviews}
+	}
+}
+
+// applyReviews shows freshly loaded review status on the worktrees with pull requests
+func (m *model) applyReviews(reviews map[string]github.ReviewStatus) {
+	m.reviews = reviews
+	m.previewKey = ""
+	m.setItems(m.allItems)
+}
+
+// reviewSummary describes what a pull request's review is waiting on, e.g.
+// "PR: changes requested, 2 unresolved", or "" if it's waiting on nothing
+func reviewSummary(r *github.ReviewStatus) string {
+	var parts []string
+	switch r.Decision {
+	case "CHANGES_REQUESTED":
+		parts = append(parts, i18n.T("changes requested"))
+	case "APPROVED":
+		parts = append(parts, i18n.T("approved"))
+	}
+	if r.Unresolved > 0 {
+		parts = append(parts, i18n.T("%d unresolved", r.Unresolved))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return i18n.T("PR: %s", strings.Join(parts, ", "))
+}
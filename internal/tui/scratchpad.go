@@ -0,0 +1,43 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/editor"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// scratchpadEditedMsg reports the editor closing on a worktree's scratchpad
+type scratchpadEditedMsg struct {
+	err error
+}
+
+// editScratchpad opens the selected worktree's scratchpad in the user's editor, with the TUI
+// suspended until it's closed
+func (m *model) editScratchpad() tea.Cmd {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return nil
+	}
+	if !item.isCheckedOut {
+		m.notify(toastInfo, "%s has no worktree to keep a scratchpad for", itemName(item))
+		return nil
+	}
+	path, err := state.PrepareScratchpad(m.config.GetConfigPath(), git.GetWorktreeName(item.worktree.Path))
+	if err != nil {
+		m.notifyError(err)
+		return nil
+	}
+	return tea.ExecProcess(editor.Command(path), func(err error) tea.Msg {
+		return scratchpadEditedMsg{err: err}
+	})
+}
+
+// handleScratchpadEdited shows what was written once the editor closes
+func (m *model) handleScratchpadEdited(msg scratchpadEditedMsg) {
+	if msg.err != nil {
+		m.notify(toastError, "failed to edit scratchpad: %v", msg.err)
+	}
+	m.previewKey = ""
+}
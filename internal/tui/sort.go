@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// sortModes are the orders the worktree list can be shown in, in the order the sort key ("O" by default) cycles through them.
+// The empty mode keeps `git worktree list` order.
+var sortModes = []string{"", "activity", "created", "status", "priority", "tag", "epic", "name", "ahead"}
+
+// mixedSortModes list items without a worktree among the rest, rather than after them
+var mixedSortModes = []string{"status", "priority", "tag", "epic", "name"}
+
+var sortModeLabels = map[string]string{
+	"":         "git order",
+	"activity": "last activity",
+	"created":  "creation time",
+	"status":   "status",
+	"priority": "priority",
+	"tag":      "tag",
+	"epic":     "epic",
+	"name":     "name",
+	"ahead":    "ahead/behind",
+}
+
+// cycleSortMode switches to the next sort mode and remembers it for next time
+func (m *model) cycleSortMode() {
+	next := sortModes[0]
+	for i, mode := range sortModes {
+		if mode == m.sortMode {
+			next = sortModes[(i+1)%len(sortModes)]
+			break
+		}
+	}
+	m.sortMode = next
+	m.resortItems()
+
+	st, err := state.Update(m.config.GetConfigPath(), func(st *state.State) error {
+		st.SortMode = next
+		return nil
+	})
+	if err != nil {
+		m.notifyError(fmt.Errorf("failed to save sort mode: %w", err))
+		return
+	}
+	m.state = st
+}
+
+// resortItems re-sorts and re-filters the list, keeping the cursor on the same item
+func (m *model) resortItems() {
+	m.replaceItems(m.allItems)
+}
+
+// replaceItems is setItems, keeping the cursor on the same item
+func (m *model) replaceItems(items []list.Item) {
+	selectedKey := rowKey(m.list.SelectedItem())
+	m.setItems(items)
+	m.selectRow(selectedKey)
+}
+
+// rowKey identifies a row of the list across refreshes: an item by its itemKey, an epic's
+// header by its epic
+func rowKey(row list.Item) string {
+	switch row := row.(type) {
+	case worktreeItem:
+		return itemKey(row)
+	case epicHeader:
+		return "epic:" + row.epic.Key()
+	}
+	return ""
+}
+
+// selectRow moves the cursor to the row with key, if it's listed
+func (m *model) selectRow(key string) {
+	if key == "" {
+		return
+	}
+	for idx, row := range m.list.Items() {
+		if rowKey(row) == key {
+			m.list.Select(idx)
+			return
+		}
+	}
+}
+
+// sortItems orders items by the current sort mode. Sorting is stable, so items that
+// compare equal keep `git worktree list` order.
+func (m *model) sortItems(items []list.Item) {
+	if m.sortMode == "" {
+		return
+	}
+
+	less := m.sortLess()
+	sort.SliceStable(items, func(a, b int) bool {
+		itemA, okA := items[a].(worktreeItem)
+		itemB, okB := items[b].(worktreeItem)
+		if !okA || !okB {
+			return false
+		}
+		// Items without a worktree have nothing to compare on but their status, keep them last
+		if itemA.isCheckedOut != itemB.isCheckedOut && !slices.Contains(mixedSortModes, m.sortMode) {
+			return itemA.isCheckedOut
+		}
+		return less(itemA, itemB)
+	})
+}
+
+func (m *model) sortLess() func(a, b worktreeItem) bool {
+	switch m.sortMode {
+	case "activity":
+		return func(a, b worktreeItem) bool {
+			return m.activityFor(a).LastActivity().After(m.activityFor(b).LastActivity())
+		}
+	case "created":
+		return func(a, b worktreeItem) bool {
+			return m.activityFor(a).Created.After(m.activityFor(b).Created)
+		}
+	case "status":
+		return func(a, b worktreeItem) bool {
+			return statusRank(a) < statusRank(b)
+		}
+	case "priority":
+		options := m.core.PriorityOptions()
+		return func(a, b worktreeItem) bool {
+			return priorityRank(options, a) < priorityRank(options, b)
+		}
+	case "tag":
+		return func(a, b worktreeItem) bool {
+			groupA, groupB := groupTag(a), groupTag(b)
+			if (groupA == "") != (groupB == "") {
+				return groupB == ""
+			}
+			return groupA < groupB
+		}
+	case "epic":
+		return func(a, b worktreeItem) bool {
+			if (a.epic == nil) != (b.epic == nil) {
+				return b.epic == nil
+			}
+			if a.epic == nil {
+				return false
+			}
+			if titleA, titleB := strings.ToLower(a.epic.Title), strings.ToLower(b.epic.Title); titleA != titleB {
+				return titleA < titleB
+			}
+			if keyA, keyB := a.epic.Key(), b.epic.Key(); keyA != keyB {
+				return keyA < keyB
+			}
+			// The epic's own item heads its section
+			return a.isEpic() && !b.isEpic()
+		}
+	case "name":
+		return func(a, b worktreeItem) bool {
+			return strings.ToLower(itemName(a)) < strings.ToLower(itemName(b))
+		}
+	case "ahead":
+		return func(a, b worktreeItem) bool {
+			activityA, activityB := m.activityFor(a), m.activityFor(b)
+			if activityA.Ahead != activityB.Ahead {
+				return activityA.Ahead > activityB.Ahead
+			}
+			return activityA.Behind < activityB.Behind
+		}
+	}
+	return func(a, b worktreeItem) bool { return false }
+}
+
+// statusRank orders in-progress work first, then work still to do, then finished work
+func statusRank(item worktreeItem) int {
+	status := ""
+	if item.githubItem != nil {
+		status = item.githubItem.Status
+	} else if item.todo != nil {
+		status = string(item.todo.Status)
+	}
+
+	switch status {
+	case "In Progress":
+		return 0
+	case "Done", string(config.TodoStatusDone):
+		return 2
+	}
+	if item.isCheckedOut && item.githubItem == nil && item.todo == nil {
+		// A plain worktree is being worked on, even without a todo
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/listcache"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// startupTimeout bounds the lookups the TUI waits for before it's shown
+const startupTimeout = 15 * time.Second
+
+// startup is everything the TUI looks up before it's shown
+type startup struct {
+	worktrees []git.Worktree
+	current   string // The worktree lfg was started in, if any
+	state     *state.State
+	sessions  map[string]tmux.SessionInfo
+	theme     config.Theme
+	warnings  []string // Lookups that failed without stopping lfg
+}
+
+// discover runs the startup lookups side by side, as none depends on another. A failure
+// that stops lfg cancels the rest, and every such failure is reported together. With a
+// snapshot to start from, worktrees and sessions are reconciled once the TUI is running
+// instead.
+func discover(cfg *config.Config, snapshot *listcache.Snapshot) (*startup, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+
+	var (
+		s    startup
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+		cancel()
+	}
+	warn := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+	}
+	run := func(lookup func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookup()
+		}()
+	}
+
+	run(func() {
+		if !tmux.IsInstalled() {
+			fail(tmux.ErrTmuxMissing)
+		}
+	})
+	run(func() {
+		st, err := state.Load(cfg.GetConfigPath())
+		if err != nil {
+			warn("failed to load state: %v", err)
+			st = &state.State{}
+		}
+		s.state = st
+	})
+	run(func() {
+		// "auto" asks the terminal for its background, before the program takes it over
+		theme, err := cfg.ResolveTheme()
+		if err != nil {
+			warn("%v, using the default theme", err)
+		}
+		s.theme = theme
+	})
+	if snapshot != nil {
+		s.worktrees = snapshot.Worktrees
+		s.sessions = snapshot.Sessions
+	} else {
+		run(func() {
+			worktrees, err := git.ListWorktreesContext(ctx)
+			if err != nil {
+				fail(err)
+				return
+			}
+			s.worktrees = worktrees
+		})
+		run(func() {
+			s.sessions = loadSessionsContext(ctx)
+		})
+	}
+	wg.Wait()
+
+	// Lookups stopped because another failed only repeat its error
+	var failures []error
+	for _, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+
+	if cwd, err := os.Getwd(); err != nil {
+		warn("failed to detect current worktree: %v", err)
+	} else {
+		s.current = git.WorktreeAt(s.worktrees, cwd)
+	}
+	return &s, nil
+}
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// statusOptions lists the statuses items can be moved to for the configured backend
+func (m *model) statusOptions() []string {
+	return m.core.StatusOptions()
+}
+
+func (m *model) isGithubBackend() bool {
+	return m.config.StorageBackend.IsGitHub()
+}
+
+// isRemoteBackend reports whether items come from a tracker, GitHub, an org file or a
+// plugin, rather than the config's todos
+func (m *model) isRemoteBackend() bool {
+	return m.config.StorageBackend.Remote()
+}
+
+// itemStatus is the item's current status, in the terms statusOptions uses
+func (m *model) itemStatus(item worktreeItem) string {
+	if m.isRemoteBackend() {
+		if item.githubItem != nil {
+			return item.githubItem.Status
+		}
+		return ""
+	}
+	if item.todo != nil {
+		return string(item.todo.Status)
+	}
+	return ""
+}
+
+// startStatusPicker opens the status picker for the marked items, or the selected one
+func (m *model) startStatusPicker() {
+	targets := m.markedItems()
+	if len(targets) == 0 {
+		item, ok := m.list.SelectedItem().(worktreeItem)
+		if !ok {
+			return
+		}
+		targets = []worktreeItem{item}
+	}
+
+	m.statusTargets = targets
+	m.pickingStatus = true
+	m.statusCursor = 0
+	current := m.itemStatus(targets[0])
+	for i, option := range m.statusOptions() {
+		if option == current {
+			m.statusCursor = i
+			break
+		}
+	}
+}
+
+// cycleStatus moves the selected item to the status after its current one
+func (m *model) cycleStatus() (tea.Model, tea.Cmd) {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok {
+		return m, nil
+	}
+
+	options := m.statusOptions()
+	next := options[0]
+	current := m.itemStatus(item)
+	for i, option := range options {
+		if option == current {
+			next = options[(i+1)%len(options)]
+			break
+		}
+	}
+	return m.applyStatus([]worktreeItem{item}, next)
+}
+
+func (m *model) handleStatusPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	options := m.statusOptions()
+	switch msg.String() {
+	case "up", "k":
+		if m.statusCursor > 0 {
+			m.statusCursor--
+		}
+	case "down", "j":
+		if m.statusCursor < len(options)-1 {
+			m.statusCursor++
+		}
+	case "enter":
+		m.pickingStatus = false
+		return m.applyStatus(m.statusTargets, options[m.statusCursor])
+	case "esc", "q":
+		m.pickingStatus = false
+	}
+	return m, nil
+}
+
+// statusAppliedMsg reports the moves applyStatus started
+type statusAppliedMsg struct {
+	status string
+	moved  []string // The items moved
+	total  int      // How many were to be moved
+	errs   []error
+}
+
+// applyStatus moves items to a status in the background, updating both the board and local
+// todos. The list refreshes once the service reports the changes. Blocked items are only
+// moved to In Progress when it's asked for twice in a row.
+func (m *model) applyStatus(items []worktreeItem, status string) (tea.Model, tea.Cmd) {
+	confirmed := m.blockedMoves
+	m.blockedMoves = nil
+	var moving []worktreeItem
+	for _, item := range items {
+		if status == "In Progress" && len(item.blockers) > 0 && !confirmed[itemKey(item)] {
+			if m.blockedMoves == nil {
+				m.blockedMoves = make(map[string]bool)
+			}
+			m.blockedMoves[itemKey(item)] = true
+			m.notify(toastWarning, "%s is blocked by %s; move it to In Progress again to start it anyway", itemName(item), strings.Join(item.blockers, ", "))
+			continue
+		}
+		moving = append(moving, item)
+	}
+	m.clearMarks()
+	m.statusTargets = nil
+	if len(moving) == 0 {
+		return m, nil
+	}
+
+	targets := make([]core.Target, len(moving))
+	for i, item := range moving {
+		targets[i] = m.target(item)
+	}
+	return m, func() tea.Msg {
+		applied := statusAppliedMsg{status: status, total: len(items)}
+		for i, target := range targets {
+			if err := m.core.SetStatus(target, status); err != nil {
+				applied.errs = append(applied.errs, fmt.Errorf("%s: %w", itemName(moving[i]), err))
+				continue
+			}
+			applied.moved = append(applied.moved, itemName(moving[i]))
+		}
+		return applied
+	}
+}
+
+// handleStatusApplied reports the moves applyStatus made
+func (m *model) handleStatusApplied(msg statusAppliedMsg) (tea.Model, tea.Cmd) {
+	for _, err := range msg.errs {
+		m.notifyError(err)
+	}
+	switch moved := len(msg.moved); {
+	case moved == 1 && msg.total == 1:
+		m.notify(toastSuccess, "Moved %s to %s", msg.moved[0], msg.status)
+	case moved > 0:
+		m.notify(toastSuccess, "Moved %d items to %s", moved, msg.status)
+	}
+	return m, nil
+}
+
+func (m *model) viewStatusPicker() string {
+	title := i18n.T("Change Status of %d Items", len(m.statusTargets))
+	if len(m.statusTargets) == 1 {
+		title = i18n.T("Change Status of %s", itemName(m.statusTargets[0]))
+	}
+
+	result := titleStyle.Render(title) + "\n\n"
+	for i, option := range m.statusOptions() {
+		if i == m.statusCursor {
+			result += markedStyle.Render("> "+option) + "\n"
+		} else {
+			result += "  " + option + "\n"
+		}
+	}
+	result += "\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Apply | Esc: Cancel"))
+	return result
+}
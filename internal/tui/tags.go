@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// tagsSummary lists an item's tags, e.g. "Tags: bug, spike", "" when it has none
+func (i worktreeItem) tagsSummary() string {
+	if len(i.tags) == 0 {
+		return ""
+	}
+	return i18n.T("Tags: %s", strings.Join(i.tags, ", "))
+}
+
+// groupTag is the tag an item is grouped under when sorting by tag: the first of its tags
+// alphabetically, "" for untagged items
+func groupTag(item worktreeItem) string {
+	if len(item.tags) == 0 {
+		return ""
+	}
+	return slices.Min(item.tags)
+}
+
+// knownTags lists the tags of every item, archived ones included, alphabetically
+func (m *model) knownTags() []string {
+	var tags []string
+	for _, listItem := range m.allItems {
+		if item, ok := listItem.(worktreeItem); ok {
+			tags = append(tags, item.tags...)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// withTag keeps the items tagged tag
+func withTag(items []list.Item, tag string) []list.Item {
+	kept := make([]list.Item, 0, len(items))
+	for _, listItem := range items {
+		if item, ok := listItem.(worktreeItem); ok && !slices.Contains(item.tags, tag) {
+			continue
+		}
+		kept = append(kept, listItem)
+	}
+	return kept
+}
+
+// startTagPicker opens the tag filter's picker, on the tag shown now
+func (m *model) startTagPicker() {
+	tags := m.knownTags()
+	if len(tags) == 0 && m.tagFilter == "" {
+		m.notify(toastInfo, "No items have tags; add them from the edit form")
+		return
+	}
+	m.pickingTag = true
+	m.tagCursor = slices.Index(tags, m.tagFilter) + 1
+}
+
+func (m *model) handleTagPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The first option shows every item
+	options := append([]string{""}, m.knownTags()...)
+	switch msg.String() {
+	case "up", "k":
+		if m.tagCursor > 0 {
+			m.tagCursor--
+		}
+	case "down", "j":
+		if m.tagCursor < len(options)-1 {
+			m.tagCursor++
+		}
+	case "enter":
+		m.pickingTag = false
+		m.tagFilter = options[min(m.tagCursor, len(options)-1)]
+		m.resortItems()
+	case "esc", "q":
+		m.pickingTag = false
+	}
+	return m, nil
+}
+
+func (m *model) viewTagPicker() string {
+	result := titleStyle.Render(i18n.T("Show Items Tagged")) + "\n\n"
+	for i, option := range append([]string{""}, m.knownTags()...) {
+		if option == "" {
+			option = i18n.T("All items")
+		}
+		if i == m.tagCursor {
+			result += markedStyle.Render("> "+option) + "\n"
+		} else {
+			result += "  " + option + "\n"
+		}
+	}
+	result += "\n" + helpStyle.Render(i18n.T("↑↓/jk: Navigate | Enter: Show | Esc: Cancel"))
+	return result
+}
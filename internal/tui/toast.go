@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/remedy"
+)
+
+// maxToasts is how many toasts are shown at once, older ones make way for new ones
+const maxToasts = 3
+
+type toastKind int
+
+const (
+	toastSuccess toastKind = iota
+	toastInfo
+	toastWarning
+	toastError
+)
+
+// toast is a transient notification shown under the list
+type toast struct {
+	kind    toastKind
+	text    string
+	expires time.Time
+}
+
+// toastMsg shows a toast from a background command
+type toastMsg struct {
+	kind toastKind
+	text string
+}
+
+// warningsMsg shows the warnings a background command collected
+type warningsMsg []string
+
+// toastTickMsg is sent when the oldest toast is due to expire
+type toastTickMsg struct{}
+
+// toastDuration is how long a toast stays up. Problems stay longer so there's time to read them.
+func toastDuration(kind toastKind) time.Duration {
+	if kind == toastWarning || kind == toastError {
+		return 8 * time.Second
+	}
+	return 3 * time.Second
+}
+
+// notify shows a toast, translating format before it fills in args
+func (m *model) notify(kind toastKind, format string, args ...any) {
+	// Flatten multi-line command output to fit on one line
+	text := strings.Join(strings.Fields(i18n.T(format, args...)), " ")
+	m.toasts = append(m.toasts, toast{
+		kind:    kind,
+		text:    text,
+		expires: time.Now().Add(toastDuration(kind)),
+	})
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+}
+
+// notifyError shows an error as a toast
+func (m *model) notifyError(err error) {
+	m.notify(toastError, "%s", remedy.Message(err))
+}
+
+// notifyWarnings shows warnings collected by a background command
+func (m *model) notifyWarnings(warnings []string) {
+	for _, warning := range warnings {
+		m.notify(toastWarning, "%s", warning)
+	}
+}
+
+// expireToasts drops toasts whose time is up
+func (m *model) expireToasts() {
+	now := time.Now()
+	kept := m.toasts[:0]
+	for _, t := range m.toasts {
+		if t.expires.After(now) {
+			kept = append(kept, t)
+		}
+	}
+	m.toasts = kept
+}
+
+// scheduleToastExpiry wakes the program when the next toast expires, so it's cleared without a keypress
+func (m *model) scheduleToastExpiry() tea.Cmd {
+	if len(m.toasts) == 0 || m.toastTicking {
+		return nil
+	}
+	next := m.toasts[0].expires
+	for _, t := range m.toasts[1:] {
+		if t.expires.Before(next) {
+			next = t.expires
+		}
+	}
+	m.toastTicking = true
+	return m.after(time.Until(next), func() tea.Msg {
+		return toastTickMsg{}
+	})
+}
+
+// toastLines is the number of lines reserved under the list for toasts
+func (m *model) toastLines() int {
+	return max(1, len(m.toasts))
+}
+
+func (m *model) viewToasts() string {
+	lines := make([]string, 0, len(m.toasts))
+	for _, t := range m.toasts {
+		style, icon := accentStyle, glyphs.Done
+		switch t.kind {
+		case toastInfo:
+			style, icon = dimStyle, config.Glyph{Symbol: "•"}
+		case toastWarning:
+			style, icon = markedStyle, glyphs.Warning
+		case toastError:
+			style, icon = errorStyle, glyphs.Failed
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(glyph(icon, style)+" "+style.Render(t.text)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,52 +1,179 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/markcipolla/lfg/internal/checklist"
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/daemon"
 	"github.com/markcipolla/lfg/internal/git"
 	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/listcache"
+	"github.com/markcipolla/lfg/internal/state"
 	"github.com/markcipolla/lfg/internal/tmux"
+	"github.com/markcipolla/lfg/internal/viewer"
 )
 
 type model struct {
-	config         *config.Config
-	worktrees      []git.Worktree
-	list           list.Model
-	creating       bool
-	deleting       bool
-	textInput      textinput.Model
-	spinner        spinner.Model
-	loading        bool
-	err            error
-	width          int
-	height         int
+	config           *config.Config
+	worktrees        []git.Worktree
+	list             list.Model
+	creating         *createForm // Non-nil while filling in the creation form
+	branches         *list.Model // Non-nil while picking a branch to create a worktree for
+	delegate         list.DefaultDelegate
+	deleting         bool
+	spinner          spinner.Model
+	loading          bool
+	toasts           []toast // Transient notifications, oldest first
+	toastTicking     bool    // A toastTickMsg is on its way
+	width            int
+	height           int
 	selectedWorktree string
-	exitToMain     bool // true if user selected main worktree to exit current session
+	exitToMain       bool // true if user selected main worktree to exit current session
+	state            *state.State
+	marked           map[string]bool // Items marked for bulk actions, keyed by itemKey
+	pickingStatus    bool            // Choosing a status for statusTargets
+	statusTargets    []worktreeItem
+	statusCursor     int
+	statuses         []string // The project's Status options, once fetched
+	sessions         map[string]tmux.SessionInfo
+	sortMode         string                  // One of sortModes
+	activity         map[string]git.Activity // Git activity loaded in the background, keyed by worktree path
+	githubSeq        int64                   // The fetch the list's GitHub items came from
+	allItems         []list.Item             // Every item, including archived ones the list hides
+	showArchived     bool
+	archivedCount    int             // Items hidden from the list as archived
+	collapsed        map[string]bool // Epics whose items are folded away, by their key
+	showPreview      bool
+	keys             keyMap
+	impacts          []deleteImpact  // What confirming the delete will do
+	editing          *editForm       // Non-nil while editing an item
+	checklist        *checklistView  // Non-nil while ticking off an item's checklist
+	feed             *feedView       // Non-nil while the activity feed is open
+	handoff          *handoffPrompt  // Non-nil while handing a worktree's todo off
+	tagFilter        string          // Only items with this tag are shown, when it's set
+	pickingTag       bool            // Choosing the tag to filter by
+	blockedMoves     map[string]bool // Blocked items, by itemKey, that were just stopped from moving to In Progress
+	tagCursor        int             // Index into the tag picker's options
+	pendingCreate    *pendingCreate  // Non-nil while a worktree is being created
+	clash            *createClash    // Non-nil while asking to create a worktree under a free name
+	previewKey       string          // itemKey of the item previewContent was rendered for
+	previewContent   string
+	fromSnapshot     bool          // The list started from the last run's snapshot, and is being reconciled
+	warm             *daemon.State // What the running daemon had when the TUI started, if there is one
+	core             *core.Service
+	events           <-chan core.Event              // The service's events, delivered as eventMsgs
+	done             context.Context                // Ends the model's timers and its wait for events
+	stop             context.CancelFunc             // Cancels done once the program has finished
+	refreshQueued    bool                           // An eventRefreshMsg is on its way
+	bootstraps       map[string]state.RunStatus     // Where each worktree's bootstrap was when last toasted about
+	reviews          map[string]github.ReviewStatus // Review of open pull requests, keyed by branch
 }
 
 type worktreeItem struct {
-	worktree    git.Worktree
-	todo        *config.Todo
-	githubItem  *github.ProjectItem
-	isCheckedOut bool // true if there's a worktree for this item
+	worktree     git.Worktree
+	todo         *config.Todo
+	githubItem   *github.ProjectItem
+	isCheckedOut bool                 // true if there's a worktree for this item
+	run          *state.Run           // latest headless run for this worktree
+	monitor      *state.MonitorStatus // running conversation monitor, if any
+	bootstrap    *state.Bootstrap     // dependency install, if one has run
+	review       *github.ReviewStatus // review of the branch's open pull request, if it has one
+	marked       bool                 // marked for a bulk action
+	session      *tmux.SessionInfo    // running tmux session, if any
+	activity     git.Activity         // zero until loaded in the background
+	tasks        []checklist.Item     // its checklist, from its issue's task list or its todo's notes
+	tags         []string             // its issue's labels, or its todo's tags
+	group        string               // the tag it's listed under when grouped by tag
+	epic         *github.ItemRef      // the epic it's listed under when grouped by epic
+	blockers     []string             // the worktrees and issues it's blocked by that aren't done
+}
+
+// withState attaches local run, monitor, bootstrap and tmux session status to a worktree item
+func (m *model) withState(item worktreeItem) worktreeItem {
+	if !item.isCheckedOut {
+		return item
+	}
+	name := git.GetWorktreeName(item.worktree.Path)
+	item.run = m.state.LatestRun(name)
+	item.monitor = nil
+	if status, ok := m.state.Monitors[name]; ok && status.Running() {
+		item.monitor = &status
+	}
+	item.bootstrap = nil
+	if bootstrap, ok := m.state.Bootstraps[name]; ok {
+		item.bootstrap = &bootstrap
+	}
+	item.activity = m.activity[item.worktree.Path]
+	item.review = nil
+	if review, ok := m.reviews[strings.TrimPrefix(item.worktree.Branch, "refs/heads/")]; ok {
+		item.review = &review
+	}
+	item.session = nil
+	if session, ok := m.sessions[tmux.SanitizeSessionName(name)]; ok {
+		item.session = &session
+	}
+	return item
+}
+
+// loadSessions returns the running tmux sessions keyed by name
+func loadSessions() map[string]tmux.SessionInfo {
+	return loadSessionsContext(context.Background())
+}
+
+// loadSessionsContext is loadSessions, giving up when ctx is done
+func loadSessionsContext(ctx context.Context) map[string]tmux.SessionInfo {
+	sessions, err := tmux.ListSessionInfoContext(ctx)
+	if err != nil {
+		return nil
+	}
+	byName := make(map[string]tmux.SessionInfo, len(sessions))
+	for _, session := range sessions {
+		byName[session.Name] = session
+	}
+	return byName
 }
 
 func (i worktreeItem) Title() string {
+	title := i.title()
+	if i.group != "" {
+		title = dimStyle.Render("["+i.group+"] ") + title
+	}
+	if i.overdue() {
+		title = glyph(glyphs.Overdue, errorStyle) + " " + title
+	}
+	if len(i.blockers) > 0 {
+		title = glyphs.Blocked.Render() + " " + title
+	}
+	if i.session != nil && i.session.Watching > 0 {
+		title = glyphs.Watching.Render() + " " + title
+	}
+	if i.marked {
+		return glyph(glyphs.Marked, markedStyle) + " " + title
+	}
+	return title
+}
+
+func (i worktreeItem) title() string {
 	// GitHub item without worktree
 	if i.githubItem != nil && !i.isCheckedOut {
-		status := "○"
+		status := glyphs.Todo.Render()
 		if i.githubItem.Status == "Done" {
-			status = "✓"
+			status = glyphs.Done.Render()
 		}
 		return fmt.Sprintf("%s %s", status, i.githubItem.Title)
 	}
@@ -54,16 +181,16 @@ func (i worktreeItem) Title() string {
 	// Worktree with or without todo
 	name := git.GetWorktreeName(i.worktree.Path)
 	if i.todo != nil {
-		status := "○"
+		status := glyphs.Todo.Render()
 		if i.todo.Status == config.TodoStatusDone {
-			status = "✓"
+			status = glyphs.Done.Render()
 		}
 		return fmt.Sprintf("%s %s - %s", status, name, i.todo.Description)
 	}
 	if i.githubItem != nil {
-		status := "●" // Checked out indicator
+		status := glyphs.Active.Render() // Checked out indicator
 		if i.githubItem.Status == "Done" {
-			status = "✓"
+			status = glyphs.Done.Render()
 		}
 		return fmt.Sprintf("%s %s - %s", status, name, i.githubItem.Title)
 	}
@@ -75,30 +202,157 @@ func (i worktreeItem) Description() string {
 	if i.githubItem != nil && !i.isCheckedOut {
 		statusText := ""
 		if i.githubItem.Status != "" {
-			statusText = fmt.Sprintf("Status: %s", i.githubItem.Status)
+			statusText = i18n.T("Status: %s", i.githubItem.Status)
+		}
+		if priority := i.priority(); priority != "" {
+			statusText += " | " + i18n.T("Priority: %s", priority)
+		}
+		if due := i.dueSummary(); due != "" {
+			statusText += " | " + due
+		}
+		if tasks := i.checklistSummary(); tasks != "" {
+			statusText += " | " + tasks
+		}
+		if tags := i.tagsSummary(); tags != "" {
+			statusText += " | " + tags
+		}
+		if blocked := i.blockedSummary(); blocked != "" {
+			statusText += " | " + blocked
 		}
 		if i.githubItem.Content.Number > 0 {
-			return fmt.Sprintf("Issue #%d | %s", i.githubItem.Content.Number, statusText)
+			return i18n.T("Issue #%d", i.githubItem.Content.Number) + " | " + statusText
 		}
 		return statusText
 	}
 
 	// Worktree
+	var parts []string
 	if i.worktree.Branch != "" {
 		branch := strings.TrimPrefix(i.worktree.Branch, "refs/heads/")
+		parts = append(parts, i18n.T("Branch: %s", branch))
 		if i.githubItem != nil && i.githubItem.Status != "" {
-			return fmt.Sprintf("Branch: %s | Status: %s", branch, i.githubItem.Status)
+			parts = append(parts, i18n.T("Status: %s", i.githubItem.Status))
+		}
+		if priority := i.priority(); priority != "" {
+			parts = append(parts, i18n.T("Priority: %s", priority))
+		}
+		if due := i.dueSummary(); due != "" {
+			parts = append(parts, due)
 		}
-		return fmt.Sprintf("Branch: %s", branch)
+		if tasks := i.checklistSummary(); tasks != "" {
+			parts = append(parts, tasks)
+		}
+		if tags := i.tagsSummary(); tags != "" {
+			parts = append(parts, tags)
+		}
+		if blocked := i.blockedSummary(); blocked != "" {
+			parts = append(parts, blocked)
+		}
+	} else {
+		parts = append(parts, i.worktree.Path)
+	}
+	if i.run != nil {
+		parts = append(parts, runSummary(i.run))
+	}
+	if i.bootstrap != nil {
+		if summary := bootstrapSummary(i.bootstrap); summary != "" {
+			parts = append(parts, summary)
+		}
+	}
+	if i.review != nil {
+		if summary := reviewSummary(i.review); summary != "" {
+			parts = append(parts, summary)
+		}
+	}
+	if last := i.activity.LastActivity(); !last.IsZero() {
+		parts = append(parts, i18n.T("Active %s", humanize.Ago(last)))
+	}
+	if i.session != nil {
+		parts = append(parts, sessionSummary(i.session))
+	}
+	if i.monitor != nil {
+		parts = append(parts, i.monitor.Summary())
 	}
-	return i.worktree.Path
+	return strings.Join(parts, " | ")
 }
 
+// sessionSummary describes a worktree's tmux session, e.g. "tmux: 2 attached, 1 watching"
+func sessionSummary(s *tmux.SessionInfo) string {
+	if s.Attached == 0 {
+		return i18n.T("tmux: detached")
+	}
+	if s.Watching > 0 {
+		return i18n.T("tmux: %d attached, %d watching", s.Attached, s.Watching)
+	}
+	return i18n.T("tmux: %d attached", s.Attached)
+}
+
+// runSummary describes the state of a headless agent run
+func runSummary(r *state.Run) string {
+	switch r.Status {
+	case state.RunStatusQueued:
+		return i18n.T("Task: queued")
+	case state.RunStatusRunning:
+		// Until it's reaped, a run whose lfg run was killed is still running in the state
+		if !r.Alive() {
+			return i18n.T("Task: %s failed", glyphs.Failed.Render())
+		}
+		return i18n.T("Task: running")
+	case state.RunStatusSucceeded:
+		return i18n.T("Task: %s done", glyphs.Done.Render())
+	case state.RunStatusFailed:
+		return i18n.T("Task: %s failed", glyphs.Failed.Render())
+	}
+	return ""
+}
+
+// bootstrapSummary describes a dependency install that's under way or has failed. Ones that
+// worked aren't worth the space once the toast has said so.
+func bootstrapSummary(b *state.Bootstrap) string {
+	switch b.Status {
+	case state.RunStatusQueued, state.RunStatusRunning:
+		return i18n.T("Deps: installing")
+	case state.RunStatusFailed:
+		return i18n.T("Deps: %s failed", glyphs.Failed.Render())
+	}
+	return ""
+}
+
+// FilterValue is everything `/` searches: names, titles, branches, todo descriptions and issue bodies
 func (i worktreeItem) FilterValue() string {
-	if i.githubItem != nil && !i.isCheckedOut {
-		return i.githubItem.Title
+	var fields []string
+	if i.isCheckedOut {
+		fields = append(fields, git.GetWorktreeName(i.worktree.Path))
+		fields = append(fields, strings.TrimPrefix(i.worktree.Branch, "refs/heads/"))
+	}
+	if i.todo != nil {
+		fields = append(fields, i.todo.Description, i.todo.GitHubBody)
 	}
-	return git.GetWorktreeName(i.worktree.Path)
+	if i.githubItem != nil {
+		fields = append(fields, i.githubItem.Title, i.githubItem.Body, i.githubItem.Content.Body)
+	}
+	return strings.Join(fields, "\n")
+}
+
+// filterItems matches items containing every word of the search term, ignoring case.
+// Fuzzy matching (the list default) matches almost anything once issue bodies are included.
+func filterItems(term string, targets []string) []list.Rank {
+	words := strings.Fields(strings.ToLower(term))
+	var ranks []list.Rank
+	for idx, target := range targets {
+		target = strings.ToLower(target)
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(target, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ranks = append(ranks, list.Rank{Index: idx})
+		}
+	}
+	return ranks
 }
 
 var (
@@ -114,175 +368,586 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")).
 			Bold(true)
+
+	dimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
+	markedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("212")).
+			Bold(true)
+
+	accentStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("86"))
+
+	spinnerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205"))
+
+	glyphs = config.DefaultGlyphs()
 )
 
+// glyph draws g in its own color, or in style when it has none
+func glyph(g config.Glyph, style lipgloss.Style) string {
+	if g.Color != "" {
+		return g.Render()
+	}
+	return style.Render(g.Symbol)
+}
+
+// applyTheme recolours the TUI's styles
+func applyTheme(theme config.Theme) {
+	titleStyle = titleStyle.Foreground(lipgloss.Color(theme.Accent))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.Muted))
+	errorStyle = errorStyle.Foreground(lipgloss.Color(theme.Error))
+	dimStyle = dimStyle.Foreground(lipgloss.Color(theme.Muted))
+	markedStyle = markedStyle.Foreground(lipgloss.Color(theme.Highlight))
+	accentStyle = accentStyle.Foreground(lipgloss.Color(theme.Accent))
+	spinnerStyle = spinnerStyle.Foreground(lipgloss.Color(theme.Spinner))
+	previewStyle = previewStyle.BorderForeground(lipgloss.Color(theme.Muted))
+	glyphs = theme.Glyphs
+	viewer.ApplyTheme(theme)
+}
+
 type Result struct {
 	SelectedWorktree string
 	ExitToMain       bool
 }
 
 func Run(cfg *config.Config) (*Result, error) {
-	// Check tmux
-	if !tmux.IsInstalled() {
-		return nil, fmt.Errorf("tmux is not installed")
+	if err := cfg.UseLanguage(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 
-	// Get current worktree if we're in one
-	currentWorktree, err := git.GetCurrentWorktree()
+	// Start from the list the last run left, if there is one, and reconcile once running
+	snapshot := loadSnapshot(cfg)
+
+	// A running daemon has worktrees, sessions and items to hand, fresher than the snapshot's
+	warm := queryDaemon(cfg)
+	known := snapshot
+	if warm != nil {
+		known = &listcache.Snapshot{Worktrees: warm.Worktrees, Sessions: warm.Sessions}
+	}
+
+	found, err := discover(cfg, known)
 	if err != nil {
-		// Non-fatal, just log
-		fmt.Fprintf(os.Stderr, "Warning: failed to detect current worktree: %v\n", err)
+		return nil, err
+	}
+	for _, warning := range found.warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
 	}
 
-	// Get worktrees
-	worktrees, err := git.ListWorktrees()
+	m := newModel(cfg, found, snapshot, warm)
+	defer m.stop()
+	if warm != nil {
+		notifyDaemon(m.core)
+	}
+	if snapshot == nil {
+		// So the next launch has something to start from even if this one is killed
+		m.saveSnapshot()
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	finalModel, err := p.Run()
 	if err != nil {
 		return nil, err
 	}
 
-	// Create initial list items for worktrees (without GitHub data)
-	items := make([]list.Item, 0, len(worktrees))
-	currentWorktreeIndex := -1
+	// Return the result
+	result := finalModel.(*model)
+	result.saveSnapshot()
+	return &Result{
+		SelectedWorktree: result.selectedWorktree,
+		ExitToMain:       result.exitToMain,
+	}, nil
+}
 
-	for _, wt := range worktrees {
-		name := git.GetWorktreeName(wt.Path)
-		todo := cfg.GetTodoForWorktree(name)
+// newModel builds the TUI from what startup found, starting from the last run's snapshot
+// or the daemon's state when there is one
+func newModel(cfg *config.Config, found *startup, snapshot *listcache.Snapshot, warm *daemon.State) *model {
+	worktrees, st, currentWorktree := found.worktrees, found.state, found.current
+
+	var items []list.Item
+	var statuses []string
+	if snapshot != nil {
+		items = snapshotItems(cfg, snapshot)
+		statuses = snapshot.Statuses
+	} else {
+		// Create initial list items for worktrees (without GitHub data)
+		items = make([]list.Item, 0, len(worktrees))
+		for _, wt := range worktrees {
+			name := git.GetWorktreeName(wt.Path)
+			todo := cfg.GetTodoForWorktree(name)
 
-		// Check if this is the current worktree
-		if currentWorktree != "" && name == currentWorktree {
-			currentWorktreeIndex = len(items)
+			items = append(items, worktreeItem{
+				worktree:     wt,
+				todo:         todo,
+				githubItem:   nil,
+				isCheckedOut: true,
+			})
 		}
+	}
 
-		items = append(items, worktreeItem{
-			worktree:    wt,
-			todo:        todo,
-			githubItem:  nil,
-			isCheckedOut: true,
-		})
+	theme := found.theme
+	applyTheme(theme)
+
+	// Resolve keybindings, falling back to the defaults if the config is invalid
+	var keyErr error
+	bindings, err := cfg.ResolveKeybindings()
+	if err != nil {
+		keyErr = fmt.Errorf("invalid keybindings, using defaults: %w", err)
+		bindings = config.DefaultKeybindings()
 	}
+	keys := newKeyMap(bindings)
 
 	// Create list
 	delegate := list.NewDefaultDelegate()
 	delegate.ShowDescription = true
+	highlight := lipgloss.Color(theme.Highlight)
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(highlight).BorderForeground(highlight)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(highlight).BorderForeground(highlight)
 	l := list.New(items, delegate, 80, 20) // Initial size, will be updated by WindowSizeMsg
-	l.Title = "" // No title - we show it in our custom header
+	l.Title = ""                           // No title - we show it in our custom header
 	l.SetShowTitle(false)
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
-	l.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			key.NewBinding(
-				key.WithKeys("n", "c"),
-				key.WithHelp("n/c", "new"),
-			),
-			key.NewBinding(
-				key.WithKeys("d"),
-				key.WithHelp("d", "delete"),
-			),
-			key.NewBinding(
-				key.WithKeys("r"),
-				key.WithHelp("r", "refresh"),
-			),
-		}
-	}
-
-	// Select the current worktree if found
-	if currentWorktreeIndex >= 0 {
-		l.Select(currentWorktreeIndex)
-	}
+	l.Filter = filterItems
+	l.AdditionalShortHelpKeys = keys.shortHelp
+	l.AdditionalFullHelpKeys = keys.fullHelp
+	// Esc still quits (or clears the filter), other quit keys are handled by the model
+	l.KeyMap.Quit = key.NewBinding(key.WithKeys("esc"))
 
 	// Create text input for new worktree
-	ti := textinput.New()
-	ti.Placeholder = cfg.WorktreeNaming
-	ti.CharLimit = 100
-	ti.Width = 50
-
 	// Create spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = spinnerStyle
 
+	service := core.New(cfg)
+	service.SetStatuses(statuses)
+	done, stop := context.WithCancel(context.Background())
 	m := &model{
-		config:    cfg,
-		worktrees: worktrees,
-		list:      l,
-		textInput: ti,
-		spinner:   s,
-		loading:   cfg.StorageBackend != nil && cfg.StorageBackend.Type == "github",
+		config:       cfg,
+		core:         service,
+		events:       subscribe(service),
+		done:         done,
+		stop:         stop,
+		worktrees:    worktrees,
+		list:         l,
+		delegate:     delegate,
+		spinner:      s,
+		loading:      cfg.StorageBackend.Remote(),
+		state:        st,
+		sessions:     found.sessions,
+		statuses:     statuses,
+		fromSnapshot: snapshot != nil,
+		warm:         warm,
+		sortMode:     st.SortMode,
+		showArchived: st.ShowArchived,
+		showPreview:  true,
+		keys:         keys,
 	}
-
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	finalModel, err := p.Run()
-	if err != nil {
-		return nil, err
+	if keyErr != nil {
+		m.notifyError(keyErr)
+	}
+	m.bootstraps = make(map[string]state.RunStatus, len(st.Bootstraps))
+	for name, bootstrap := range st.Bootstraps {
+		m.bootstraps[name] = bootstrap.Status
 	}
+	m.collapsed = make(map[string]bool, len(st.Collapsed))
+	for _, key := range st.Collapsed {
+		m.collapsed[key] = true
+	}
+	m.setItems(items)
 
-	// Return the result
-	result := finalModel.(*model)
-	return &Result{
-		SelectedWorktree: result.selectedWorktree,
-		ExitToMain:       result.exitToMain,
-	}, nil
+	// Select the current worktree if found
+	if currentWorktree != "" {
+		for idx, listItem := range m.list.Items() {
+			if item, ok := listItem.(worktreeItem); ok && git.GetWorktreeName(item.worktree.Path) == currentWorktree {
+				m.list.Select(idx)
+				break
+			}
+		}
+	}
+	return m
 }
 
 func (m *model) Init() tea.Cmd {
+	return tea.Batch(m.waitForEvent, m.initialLoad())
+}
+
+// initialLoad fetches whatever the list started without
+func (m *model) initialLoad() tea.Cmd {
+	// The daemon's lookups are current, so only go to the backend if it has no items
+	if m.warm != nil {
+		cmds := []tea.Cmd{m.pollState(), loadActivity(m.worktrees)}
+		switch worktrees := m.worktrees; {
+		case !m.config.StorageBackend.Remote():
+			// Rebuild the list in case it was drawn from an older snapshot
+			cmds = append(cmds, func() tea.Msg { return refreshMsg{worktrees: worktrees} })
+		case m.warm.HasItems():
+			cmds = append(cmds, daemonItems(m.warm))
+		default:
+			cmds = append(cmds, m.spinner.Tick, m.fetchGithubItems)
+		}
+		return tea.Batch(cmds...)
+	}
+
+	// A list drawn from the snapshot has worktrees to reconcile as well
+	if m.fromSnapshot {
+		if m.config.StorageBackend.Remote() {
+			return tea.Batch(m.spinner.Tick, m.refreshAll, m.pollState(), loadActivity(m.worktrees))
+		}
+		return tea.Batch(m.refreshWorktrees, m.pollState(), loadActivity(m.worktrees))
+	}
+
 	// Start spinner and fetch GitHub data if configured
-	if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-		return tea.Batch(m.spinner.Tick, m.fetchGithubItems)
+	if m.config.StorageBackend.Remote() {
+		return tea.Batch(m.spinner.Tick, m.fetchGithubItems, m.pollState(), loadActivity(m.worktrees))
 	}
+	return tea.Batch(m.pollState(), loadActivity(m.worktrees))
+}
+
+// statePollInterval is how often the TUI re-reads state to report headless run and
+// conversation monitor progress
+const statePollInterval = 5 * time.Second
+
+type stateMsg struct {
+	state    *state.State
+	sessions map[string]tmux.SessionInfo
+}
+
+// pollState reloads local state and tmux sessions after a delay so run, monitor and
+// session changes show up in the list
+func (m *model) pollState() tea.Cmd {
+	cfg := m.config
+	return m.after(statePollInterval, func() tea.Msg {
+		sessions := loadSessions()
+		st, err := state.Load(cfg.GetConfigPath())
+		if err != nil {
+			return stateMsg{sessions: sessions}
+		}
+		return stateMsg{state: st, sessions: sessions}
+	})
+}
+
+// after delivers msg's message once d has passed, like tea.Tick, unless the model is done
+// by then
+func (m *model) after(d time.Duration, msg func() tea.Msg) tea.Cmd {
+	done := m.done
+	return func() tea.Msg {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return msg()
+		case <-done.Done():
+			return nil
+		}
+	}
+}
+
+// focus focuses a text input. The cursor stays lit, since cursor messages aren't passed on
+// to the inputs, so the blink Focus returns is dropped: it reads the input in place when it
+// fires, racing the update that's replaced it by then.
+func focus(input interface{ Focus() tea.Cmd }) tea.Cmd {
+	input.Focus()
 	return nil
 }
 
+// applyState refreshes the run, monitor, bootstrap and session status shown for every
+// item, and says when a bootstrap has finished
+func (m *model) applyState(st *state.State, sessions map[string]tmux.SessionInfo) {
+	if st != nil {
+		m.notifyBootstraps(st)
+		m.state = st
+	}
+	m.sessions = sessions
+	m.setItems(m.allItems)
+}
+
+// notifyBootstraps toasts the bootstraps that have finished since they were last seen
+func (m *model) notifyBootstraps(st *state.State) {
+	for name, bootstrap := range st.Bootstraps {
+		before, ok := m.bootstraps[name]
+		m.bootstraps[name] = bootstrap.Status
+		if ok && before == bootstrap.Status {
+			continue
+		}
+		switch bootstrap.Status {
+		case state.RunStatusSucceeded:
+			m.notify(toastSuccess, "Installed dependencies in %s", name)
+		case state.RunStatusFailed:
+			m.notify(toastError, "Failed to install dependencies in %s, see %s", name, bootstrap.Log)
+		}
+	}
+}
+
+// updateMonitorControl changes the pause/flush controls of the selected worktree's monitor
+func (m *model) updateMonitorControl(update func(*state.MonitorStatus)) {
+	item, ok := m.list.SelectedItem().(worktreeItem)
+	if !ok || item.monitor == nil {
+		m.notify(toastWarning, "no conversation monitor is running for this worktree")
+		return
+	}
+
+	name := git.GetWorktreeName(item.worktree.Path)
+	st, err := state.Update(m.config.GetConfigPath(), func(st *state.State) error {
+		status := st.Monitors[name]
+		update(&status)
+		st.SetMonitor(name, status)
+		return nil
+	})
+	if err != nil {
+		m.notifyError(err)
+		return
+	}
+	m.applyState(st, m.sessions)
+}
+
 type githubItemsMsg struct {
-	items []github.ProjectItem
-	err   error
+	items    []github.ProjectItem // Every item fetched so far
+	statuses []string
+	err      error
+	warnings []string    // Non-fatal problems from the command that fetched the items
+	next     *githubPage // The rest of the items, nil once they've all arrived
+	seq      int64       // Which fetch the items came from
 }
 
+// githubPage continues a fetch of the project's items from where the last page ended
+type githubPage struct {
+	projectID string
+	cursor    string
+	items     []github.ProjectItem // Items fetched so far
+	statuses  <-chan []string      // The board's Status options, fetched alongside the pages
+	seq       int64
+}
+
+// githubFetchSeq numbers fetches of the project's items, so pages from a fetch that a
+// newer one has superseded can be dropped
+var githubFetchSeq atomic.Int64
+
+// withWarnings attaches warnings from a background command to the items it fetched afterwards
+func withWarnings(msg tea.Msg, warnings []string) tea.Msg {
+	if itemsMsg, ok := msg.(githubItemsMsg); ok {
+		itemsMsg.warnings = append(itemsMsg.warnings, warnings...)
+		return itemsMsg
+	}
+	return msg
+}
+
+// fetchGithubItems fetches the first page of the project's items, or the repository's issues
+// without a project, or a plugin's or org file's items. The list shows each page as it arrives while the
+// rest are fetched.
 func (m *model) fetchGithubItems() tea.Msg {
-	if m.config.StorageBackend == nil || m.config.StorageBackend.Type != "github" {
+	if !m.config.StorageBackend.Remote() {
 		return githubItemsMsg{items: nil, err: nil}
 	}
 
-	items, err := github.ListProjectItems(
+	seq := githubFetchSeq.Add(1)
+	if !m.config.StorageBackend.UsesProject() {
+		// Without a board, every item arrives in one go
+		items, statuses, err := m.core.ListItems()
+		return githubItemsMsg{items: items, statuses: statuses, err: err, seq: seq}
+	}
+
+	// The Status options don't depend on the items, so they're fetched while the pages are
+	statuses := make(chan []string, 1)
+	go func() {
+		// Non-fatal, the status picker falls back to the default board columns
+		options, _ := github.ListStatusOptions(
+			m.config.StorageBackend.Owner,
+			m.config.StorageBackend.Repo,
+			m.config.StorageBackend.ProjectNumber,
+		)
+		statuses <- options
+	}()
+
+	projectID, err := github.FindProjectID(
 		m.config.StorageBackend.Owner,
 		m.config.StorageBackend.Repo,
 		m.config.StorageBackend.ProjectNumber,
 	)
-	return githubItemsMsg{items: items, err: err}
+	if err != nil {
+		return githubItemsMsg{err: err, seq: seq}
+	}
+	return m.fetchGithubPage(githubPage{projectID: projectID, statuses: statuses, seq: seq})
+}
+
+// nextGithubPage fetches the page of items after the one just shown
+func (m *model) nextGithubPage(page githubPage) tea.Cmd {
+	return func() tea.Msg {
+		return m.fetchGithubPage(page)
+	}
+}
+
+func (m *model) fetchGithubPage(page githubPage) tea.Msg {
+	items, cursor, err := github.ListProjectItemsPage(page.projectID, page.cursor)
+	if err != nil {
+		return githubItemsMsg{err: err, seq: page.seq}
+	}
+	items = append(page.items, items...)
+	if cursor != "" {
+		next := page
+		next.cursor = cursor
+		next.items = items
+		return githubItemsMsg{items: items, next: &next, seq: page.seq}
+	}
+	return githubItemsMsg{items: items, statuses: <-page.statuses, seq: page.seq}
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	toastCount := len(m.toasts)
+	model, cmd := m.update(msg)
+
+	// The list shrinks to make room for toasts
+	if len(m.toasts) != toastCount {
+		m.layout()
+	}
+	return model, tea.Batch(cmd, m.scheduleToastExpiry())
+}
+
+func (m *model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case toastMsg:
+		m.notify(msg.kind, "%s", msg.text)
+		return m, nil
+
+	case warningsMsg:
+		m.notifyWarnings(msg)
+		return m, nil
+
+	case statusAppliedMsg:
+		return m.handleStatusApplied(msg)
+
+	case deletedMsg:
+		return m.handleDeleted(msg)
+
+	case taskCheckedMsg:
+		return m.handleTaskChecked(msg)
+
+	case editSavedMsg:
+		return m.handleEditSaved(msg)
+
+	case eventMsg:
+		return m, m.handleEvent(core.Event(msg))
+
+	case eventRefreshMsg:
+		return m, m.refreshForEvents()
+
+	case toastTickMsg:
+		m.toastTicking = false
+		m.expireToasts()
+		return m, nil
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
 	case githubItemsMsg:
-		m.loading = false
+		m.notifyWarnings(msg.warnings)
+		if msg.seq < m.githubSeq {
+			// A newer fetch has started, it'll bring these items too
+			return m, nil
+		}
+		m.githubSeq = msg.seq
+		m.previewKey = ""
+
 		if msg.err != nil {
-			m.err = fmt.Errorf("failed to fetch GitHub items: %w", msg.err)
-		} else if msg.items != nil {
+			m.loading = false
+			m.notifyError(fmt.Errorf("failed to fetch %s items: %w", m.core.BackendName(), msg.err))
+			return m, nil
+		}
+		// Statuses first, merging checks which ones items can be moved to
+		if len(msg.statuses) > 0 {
+			m.statuses = msg.statuses
+			m.core.SetStatuses(msg.statuses)
+		}
+		if msg.items != nil {
 			// Merge GitHub items with existing worktree items
 			m.mergeGithubItems(msg.items)
 		}
+		if msg.next != nil {
+			return m, m.nextGithubPage(*msg.next)
+		}
+
+		m.loading = false
+		m.sessions = loadSessions()
+		m.saveSnapshot()
+		return m, tea.Batch(loadActivity(m.worktrees), loadReviews(m.config))
+
+	case activityMsg:
+		m.applyActivity(msg.activity)
 		return m, nil
 
+	case reviewsMsg:
+		m.applyReviews(msg.reviews)
+		return m, nil
+
+	case feedMsg:
+		m.applyFeed(msg)
+		return m, nil
+
+	case scratchpadEditedMsg:
+		m.handleScratchpadEdited(msg)
+		return m, nil
+
+	case handedOffMsg:
+		m.handleHandedOff(msg)
+		return m, nil
+
+	case worktreeCreatedMsg:
+		return m.handleWorktreeCreated(msg)
+
+	case stateMsg:
+		m.applyState(msg.state, msg.sessions)
+		return m, m.pollState()
+
 	case tea.KeyMsg:
-		// Handle text input mode
-		if m.creating {
-			switch msg.String() {
-			case "enter":
-				return m.handleCreateWorktree()
-			case "esc":
-				m.creating = false
-				m.textInput.SetValue("")
-				return m, nil
-			default:
-				var cmd tea.Cmd
-				m.textInput, cmd = m.textInput.Update(msg)
-				return m, cmd
+		// While a worktree is being created, the only thing to do is cancel it
+		if m.pendingCreate != nil {
+			if !m.pendingCreate.cancelling && (msg.String() == "esc" || key.Matches(msg, m.keys.Quit)) {
+				m.cancelCreate()
 			}
+			return m, nil
+		}
+
+		if m.clash != nil {
+			return m.handleClashKey(msg)
+		}
+
+		if m.branches != nil {
+			return m.handleBranchPickerKey(msg)
+		}
+
+		// Handle text input mode
+		if m.creating != nil {
+			return m.handleCreateKey(msg)
+		}
+
+		// Handle the edit form
+		if m.editing != nil {
+			return m.handleEditKey(msg)
+		}
+
+		if m.checklist != nil {
+			return m.handleChecklistKey(msg)
+		}
+
+		if m.feed != nil {
+			return m.handleFeedKey(msg)
+		}
+
+		if m.handoff != nil {
+			return m.handleHandoffKey(msg)
+		}
+
+		if m.pickingTag {
+			return m.handleTagPickerKey(msg)
+		}
+
+		// Handle status picker for marked items
+		if m.pickingStatus {
+			return m.handleStatusPickerKey(msg)
 		}
 
 		// Handle delete confirmation
@@ -297,52 +962,136 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// While typing a filter, keys belong to the filter input
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		// Number keys jump straight to recently used worktrees
+		if idx, ok := recentShortcut(msg); ok {
+			if recent := m.recentWorktrees(); idx < len(recent) {
+				return m.jumpTo(recent[idx])
+			}
+			return m, nil
+		}
+
 		// Normal mode
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
 
-		case "enter":
+		case key.Matches(msg, m.keys.Open):
+			if _, ok := m.list.SelectedItem().(epicHeader); ok {
+				m.toggleEpic()
+				return m, nil
+			}
 			if item, ok := m.list.SelectedItem().(worktreeItem); ok {
 				// If it's a GitHub item without a worktree, create one
 				if item.githubItem != nil && !item.isCheckedOut {
 					return m.handleCreateWorktreeFromGithub(item.githubItem)
 				}
 
-				// Check if this is the main worktree (first in the list)
-				name := git.GetWorktreeName(item.worktree.Path)
-				isMainWorktree := false
-				if len(m.worktrees) > 0 {
-					mainName := git.GetWorktreeName(m.worktrees[0].Path)
-					isMainWorktree = (name == mainName)
-				}
+				return m.jumpTo(git.GetWorktreeName(item.worktree.Path))
+			}
 
-				// If it's the main worktree, set flag to exit current session
-				if isMainWorktree {
-					m.exitToMain = true
-					m.selectedWorktree = name
-					return m, tea.Quit
-				}
+		case key.Matches(msg, m.keys.New):
+			return m, m.startCreateForm()
 
-				// Otherwise jump to the selected worktree
-				m.selectedWorktree = name
-				return m, tea.Quit
-			}
+		case key.Matches(msg, m.keys.Delete):
+			m.impacts = m.deleteImpacts(m.deleteTargets())
+			m.deleting = len(m.impacts) > 0
+			return m, nil
+
+		case key.Matches(msg, m.keys.Edit):
+			m.startEdit()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Browse):
+			return m, m.openInBrowser()
+
+		case key.Matches(msg, m.keys.CopyBranch):
+			return m, m.copyBranch()
+
+		case key.Matches(msg, m.keys.CopyPath):
+			return m, m.copyPath()
+
+		case key.Matches(msg, m.keys.CopyURL):
+			return m, m.copyURL()
 
-		case "n", "c":
-			m.creating = true
-			m.textInput.SetValue(m.config.WorktreeNaming)
-			m.textInput.Focus()
-			m.textInput.CursorEnd()
+		case key.Matches(msg, m.keys.Mark):
+			m.toggleMark()
 			return m, nil
 
-		case "d":
-			m.deleting = true
+		case key.Matches(msg, m.keys.Status):
+			m.startStatusPicker()
 			return m, nil
 
-		case "r":
+		case key.Matches(msg, m.keys.CycleStatus):
+			return m.cycleStatus()
+
+		case key.Matches(msg, m.keys.Raise):
+			return m, m.shiftPriority(1)
+
+		case key.Matches(msg, m.keys.Lower):
+			return m, m.shiftPriority(-1)
+
+		case key.Matches(msg, m.keys.Checklist):
+			m.startChecklist()
+			return m, nil
+
+		case key.Matches(msg, m.keys.TagFilter):
+			m.startTagPicker()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Feed):
+			return m, tea.Batch(m.spinner.Tick, m.startFeed())
+
+		case key.Matches(msg, m.keys.Scratchpad):
+			return m, m.editScratchpad()
+
+		case key.Matches(msg, m.keys.Handoff):
+			return m, m.startHandoff()
+
+		case key.Matches(msg, m.keys.Collapse):
+			m.toggleEpic()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Sync):
+			return m.handleBulkSync()
+
+		case key.Matches(msg, m.keys.Sort):
+			m.cycleSortMode()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Branches):
+			return m, m.startBranchPicker()
+
+		case key.Matches(msg, m.keys.Archived):
+			m.toggleArchived()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Preview):
+			m.showPreview = !m.showPreview
+			m.layout()
+			return m, nil
+
+		case key.Matches(msg, m.keys.PauseMirror):
+			// Pause or resume mirroring of the agent conversation
+			m.updateMonitorControl(func(status *state.MonitorStatus) {
+				status.Paused = !status.Paused
+			})
+			return m, nil
+
+		case key.Matches(msg, m.keys.FlushMirror):
+			// Post pending messages now, even while paused
+			m.updateMonitorControl(func(status *state.MonitorStatus) {
+				status.FlushRequestedAt = time.Now()
+			})
+			return m, nil
+
+		case key.Matches(msg, m.keys.Refresh):
 			// Show spinner if GitHub is configured
-			if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
+			if m.config.StorageBackend.Remote() {
 				m.loading = true
 				return m, tea.Batch(m.spinner.Tick, m.refreshAll)
 			}
@@ -352,33 +1101,43 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Account for header (2 lines) + potential error line (1 line)
-		m.list.SetSize(msg.Width, msg.Height-3)
+		m.layout()
 
 	case refreshMsg:
 		m.worktrees = msg.worktrees
+		m.previewKey = ""
+		m.sessions = loadSessions()
 		// Just update worktrees list with current items (no GitHub fetch)
 		items := make([]list.Item, 0, len(m.worktrees))
 		for _, wt := range m.worktrees {
 			name := git.GetWorktreeName(wt.Path)
 			todo := m.config.GetTodoForWorktree(name)
 			items = append(items, worktreeItem{
-				worktree:    wt,
-				todo:        todo,
-				githubItem:  nil,
+				worktree:     wt,
+				todo:         todo,
+				githubItem:   nil,
 				isCheckedOut: true,
 			})
 		}
-		m.list.SetItems(items)
-		return m, nil
+		m.setItems(items)
+		m.saveSnapshot()
+		return m, loadActivity(m.worktrees)
 
 	case errMsg:
-		m.err = msg.err
+		m.loading = false
+		m.notifyError(msg.err)
 		return m, nil
 	}
 
+	// The picker's filter reports its matches in a message of its own
+	if m.branches != nil {
+		var cmd tea.Cmd
+		*m.branches, cmd = m.branches.Update(msg)
+		return m, cmd
+	}
+
 	// Update list
-	if !m.creating && !m.deleting {
+	if m.creating == nil && !m.deleting && m.editing == nil && m.handoff == nil && m.pendingCreate == nil && m.clash == nil {
 		var cmd tea.Cmd
 		m.list, cmd = m.list.Update(msg)
 		return m, cmd
@@ -388,54 +1147,105 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *model) View() string {
-	if m.creating {
+	view := m.view()
+	if len(m.toasts) > 0 {
+		view = strings.TrimSuffix(view, "\n") + "\n" + m.viewToasts()
+	}
+	return view
+}
+
+func (m *model) view() string {
+	if m.pendingCreate != nil {
+		return m.viewCreateProgress()
+	}
+
+	if m.clash != nil {
+		return m.viewClash()
+	}
+
+	if m.creating != nil {
 		return m.viewCreateWorktree()
 	}
 
+	if m.branches != nil {
+		return m.viewBranchPicker()
+	}
+
 	if m.deleting {
 		return m.viewDeleteConfirm()
 	}
 
+	if m.pickingStatus {
+		return m.viewStatusPicker()
+	}
+
+	if m.editing != nil {
+		return m.viewEdit()
+	}
+
+	if m.checklist != nil {
+		return m.viewChecklist()
+	}
+
+	if m.feed != nil {
+		return m.viewFeed()
+	}
+
+	if m.handoff != nil {
+		return m.viewHandoff()
+	}
+
+	if m.pickingTag {
+		return m.viewTagPicker()
+	}
+
 	// Build the view with header
 	var view strings.Builder
 
 	// Show header
-	header := titleStyle.Render("LFG - Git Worktrees")
-	view.WriteString(header)
-	view.WriteString("\n")
-
-	// Show loading spinner if fetching GitHub data
+	header := []string{titleStyle.Render(i18n.T("LFG - Git Worktrees"))}
+	if m.sortMode != "" {
+		header = append(header, dimStyle.Render("  "+i18n.T("sorted by %s", i18n.T(sortModeLabels[m.sortMode]))))
+	}
+	if len(m.marked) > 0 {
+		header = append(header, markedStyle.Render("  "+i18n.T("%d marked", len(m.marked))))
+	}
+	if m.tagFilter != "" {
+		header = append(header, markedStyle.Render("  "+i18n.T("tagged %s", m.tagFilter)))
+	}
+	if m.showArchived {
+		header = append(header, dimStyle.Render("  "+i18n.T("showing archived")))
+	} else if m.archivedCount > 0 {
+		header = append(header, dimStyle.Render("  "+i18n.T("%d archived", m.archivedCount)))
+	}
+	// GitHub items are merged into the list as they arrive
 	if m.loading {
-		view.WriteString("\n")
-		view.WriteString(m.spinner.View())
-		view.WriteString(" Fetching GitHub project items...")
-		return view.String()
+		header = append(header, dimStyle.Render("  "+m.spinner.View()+i18n.T("fetching %s items", m.core.BackendName())))
 	}
-
+	view.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, header...))
 	view.WriteString("\n")
 
-	// Show list
-	view.WriteString(m.list.View())
+	view.WriteString(m.viewRecent())
+	view.WriteString("\n")
 
-	// Show error if present
-	if m.err != nil {
-		view.WriteString("\n")
-		view.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	// Show list, with the selected item's details alongside if there's room
+	if m.previewVisible() {
+		// The list's help line can overflow its width, which would push the preview off screen
+		listView := lipgloss.NewStyle().MaxWidth(m.list.Width()).Render(m.list.View())
+		view.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, listView, m.viewPreview()))
+	} else {
+		view.WriteString(m.list.View())
 	}
 
 	return view.String()
 }
 
 func (m *model) mergeGithubItems(githubItems []github.ProjectItem) {
-	// Create a map of worktree names for quick lookup
-	worktreeMap := make(map[string]git.Worktree)
-	for _, wt := range m.worktrees {
-		name := git.GetWorktreeName(wt.Path)
-		worktreeMap[name] = wt
-	}
+	index := core.NewItemIndex(m.config.Name, githubItems)
 
 	// Track which GitHub items have been matched to worktrees
 	matchedGithubItems := make(map[string]bool)
+	links := make(map[string]githubLink) // Todos whose GitHub data has changed, by worktree
 
 	// Create list items
 	items := make([]list.Item, 0, len(m.worktrees)+len(githubItems))
@@ -446,56 +1256,51 @@ func (m *model) mergeGithubItems(githubItems []github.ProjectItem) {
 
 		// Try to match with GitHub item
 		var matchedItem *github.ProjectItem
-		for i := range githubItems {
+		if i := index.Match(name, todo); i >= 0 {
 			item := &githubItems[i]
-			// Match by worktree name or issue number
-			itemName := generateWorktreeName(m.config.Name, item.Title)
-			if itemName == name || (item.Content.Number > 0 && fmt.Sprintf("issue-%d", item.Content.Number) == name) {
-				matchedItem = item
-				matchedGithubItems[item.ID] = true
-
-				// Update the todo with GitHub data if it exists
-				if todo != nil {
-					// Get the body from the content if available
-					if item.Content.Body != "" {
-						todo.GitHubBody = item.Content.Body
-					} else if item.Body != "" {
-						todo.GitHubBody = item.Body
-					}
-					if item.Content.URL != "" {
-						todo.GitHubURL = item.Content.URL
-					}
-					// Save the updated config
-					m.config.Save()
+			matchedItem = item
+			matchedGithubItems[item.ID] = true
+
+			// Keep the todo's copy of the GitHub data current
+			if todo != nil {
+				link := githubLink{body: todo.GitHubBody, url: todo.GitHubURL}
+				// Get the body from the content if available
+				if item.Content.Body != "" {
+					link.body = item.Content.Body
+				} else if item.Body != "" {
+					link.body = item.Body
 				}
+				if item.Content.URL != "" {
+					link.url = item.Content.URL
+				}
+				if link.body != todo.GitHubBody || link.url != todo.GitHubURL {
+					links[name] = link
+				}
+			}
 
-				// If this item has a worktree but isn't in "In Progress" or "Done", move it to "In Progress"
-				if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-					if item.Status != "In Progress" && item.Status != "Done" {
-						err := github.UpdateProjectItemStatus(
-							m.config.StorageBackend.Owner,
-							m.config.StorageBackend.Repo,
-							m.config.StorageBackend.ProjectNumber,
-							item.ID,
-							"In Progress",
-						)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "Warning: failed to update item status to In Progress: %v\n", err)
-						} else {
-							// Update the local copy
-							item.Status = "In Progress"
-						}
-					}
+			// Record the link where the backend keeps one, so it outlasts the item being renamed
+			if item.Worktree != name {
+				if err := m.core.LinkWorktree(item, name); err != nil {
+					m.notify(toastWarning, "failed to link %s to its item: %v", name, err)
 				}
+			}
 
-				break
+			// An item with a worktree is moved to In Progress, or where auto_status says
+			if to, ok := m.core.AutoStatus(core.Target{Todo: todo}, item.Status, githubItems); ok {
+				if err := m.core.SetItemStatus(item, to); err != nil {
+					m.notify(toastWarning, "failed to update item status to %s: %v", to, err)
+				} else {
+					// Update the local copy
+					item.Status = to
+				}
 			}
+
 		}
 
 		items = append(items, worktreeItem{
-			worktree:    wt,
-			todo:        todo,
-			githubItem:  matchedItem,
+			worktree:     wt,
+			todo:         todo,
+			githubItem:   matchedItem,
 			isCheckedOut: true,
 		})
 	}
@@ -505,276 +1310,170 @@ func (m *model) mergeGithubItems(githubItems []github.ProjectItem) {
 		item := &githubItems[i]
 		if !matchedGithubItems[item.ID] {
 			items = append(items, worktreeItem{
-				githubItem:  item,
+				githubItem:   item,
 				isCheckedOut: false,
 			})
 		}
 	}
 
-	m.list.SetItems(items)
-}
-
-func (m *model) viewCreateWorktree() string {
-	// Show preview of what the worktree will be named
-	preview := ""
-	if m.textInput.Value() != "" {
-		worktreeName := generateWorktreeName(m.config.Name, m.textInput.Value())
-		preview = fmt.Sprintf("\nWorktree will be created as: %s",
-			lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Render(worktreeName))
+	if len(links) > 0 {
+		m.saveGithubLinks(links)
+		// Saving reloads the config, so the items need its todos
+		for idx, listItem := range items {
+			if item, ok := listItem.(worktreeItem); ok && item.isCheckedOut {
+				item.todo = m.config.GetTodoForWorktree(git.GetWorktreeName(item.worktree.Path))
+				items[idx] = item
+			}
+		}
 	}
 
-	return fmt.Sprintf(
-		"%s\n\nFeature Description:\n%s%s\n\n%s\n",
-		titleStyle.Render("Create New Worktree"),
-		m.textInput.View(),
-		preview,
-		helpStyle.Render("Enter: Create | Esc: Cancel"),
-	)
+	// Pages arrive while the list is in use, so keep the cursor where it is
+	m.replaceItems(items)
 }
 
-func (m *model) viewDeleteConfirm() string {
-	if item, ok := m.list.SelectedItem().(worktreeItem); ok {
-		name := git.GetWorktreeName(item.worktree.Path)
-		return fmt.Sprintf(
-			"%s\n\nAre you sure you want to delete worktree '%s'?\n\n%s\n",
-			titleStyle.Render("Delete Worktree"),
-			name,
-			helpStyle.Render("Y: Yes | N: No"),
-		)
-	}
-	return ""
+// githubLink is the GitHub data a todo keeps a copy of
+type githubLink struct {
+	body string
+	url  string
 }
 
-func (m *model) handleCreateWorktree() (tea.Model, tea.Cmd) {
-	description := m.textInput.Value()
-	if description == "" {
-		m.err = fmt.Errorf("feature description cannot be empty")
-		m.creating = false
-		return m, nil
-	}
-
-	// Generate worktree name: [project-name]-[dasherized-description]
-	worktreeName := generateWorktreeName(m.config.Name, description)
-
-	// Create worktree
-	if err := git.CreateWorktree(worktreeName); err != nil {
-		m.err = err
-		m.creating = false
-		return m, nil
+// saveGithubLinks updates the todos' copies of their items' bodies and URLs
+func (m *model) saveGithubLinks(links map[string]githubLink) {
+	err := m.config.Update(func(cfg *config.Config) error {
+		for name, link := range links {
+			if todo := cfg.GetTodoForWorktree(name); todo != nil {
+				todo.GitHubBody = link.body
+				todo.GitHubURL = link.url
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		m.notify(toastWarning, "failed to save config: %v", err)
 	}
+}
 
-	// Add todo with the original description
-	m.config.AddTodo(description, worktreeName)
-	if err := m.config.Save(); err != nil {
-		m.err = fmt.Errorf("failed to save config: %w", err)
+func (m *model) viewDeleteConfirm() string {
+	if len(m.impacts) > 1 {
+		var names strings.Builder
+		for _, impact := range m.impacts {
+			names.WriteString(fmt.Sprintf("  • %s: %s\n", impact.name, impact.summary()))
+		}
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s\n%s\n",
+			titleStyle.Render(i18n.T("Delete Worktrees")),
+			i18n.T("Are you sure you want to delete these %d items?", len(m.impacts)),
+			names.String(),
+			helpStyle.Render(i18n.T("Y: Yes | N: No")),
+		)
 	}
 
-	m.creating = false
-	m.textInput.SetValue("")
-
-	// If GitHub is configured, show spinner and create item + refresh in background
-	if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-		m.loading = true
-		return m, tea.Batch(
-			m.spinner.Tick,
-			m.createGithubItemAndRefresh(description, worktreeName),
+	if len(m.impacts) == 1 {
+		impact := m.impacts[0]
+		return fmt.Sprintf(
+			"%s\n\n%s\n\n%s\n\n%s\n",
+			titleStyle.Render(i18n.T("Delete Worktree")),
+			i18n.T("Are you sure you want to delete '%s'?", impact.name),
+			impact.render(),
+			helpStyle.Render(i18n.T("Y: Yes | N: No")),
 		)
 	}
-
-	// Otherwise just refresh
-	return m, m.refreshWorktrees
+	return ""
 }
 
-type createItemMsg struct {
-	err error
+// deletedMsg reports the deletes handleDeleteWorktree started
+type deletedMsg struct {
+	deleted  []string // The items deleted
+	total    int      // How many were to be deleted
+	current  bool     // Whether the worktree we're in was among them
+	sessions []string // The tmux sessions killed with them
+	warnings []string
+	errs     []error
+	state    *state.State // The state once they're forgotten, nil if it couldn't be read
 }
 
-func (m *model) createGithubItemAndRefresh(description, worktreeName string) tea.Cmd {
-	return func() tea.Msg {
-		// Create GitHub Project item
-		item, err := github.CreateProjectItem(
-			m.config.StorageBackend.Owner,
-			m.config.StorageBackend.Repo,
-			m.config.StorageBackend.ProjectNumber,
-			description,
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to create GitHub project item: %v\n", err)
-			return createItemMsg{err: err}
-		}
+// handleDeleteWorktree deletes the confirmed items in the background, as deleting runs git,
+// tmux and the backend
+func (m *model) handleDeleteWorktree() (tea.Model, tea.Cmd) {
+	m.deleting = false
 
-		// Move to In Progress since we're creating a worktree
-		err = github.UpdateProjectItemStatus(
-			m.config.StorageBackend.Owner,
-			m.config.StorageBackend.Repo,
-			m.config.StorageBackend.ProjectNumber,
-			item.ID,
-			"In Progress",
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to update item status: %v\n", err)
+	items := m.deleteTargets()
+	m.clearMarks()
+	targets := make([]core.Target, len(items))
+	for i, item := range items {
+		targets[i] = m.target(item)
+	}
+	configPath := m.config.GetConfigPath()
+	return m, func() tea.Msg {
+		msg := deletedMsg{total: len(items)}
+		for i, target := range targets {
+			deleted, err := m.core.DeleteWorktree(target)
+			msg.warnings = append(msg.warnings, deleted.Warnings...)
+			if deleted.Session != "" {
+				msg.sessions = append(msg.sessions, deleted.Session)
+			}
+			if err != nil {
+				msg.errs = append(msg.errs, err)
+				continue
+			}
+			msg.deleted = append(msg.deleted, itemName(items[i]))
+			msg.current = msg.current || deleted.Current
 		}
-
-		// Refresh to get all items
-		return m.fetchGithubItems()
+		// They're no longer recent worktrees to jump back to
+		if st, err := state.Load(configPath); err == nil {
+			msg.state = st
+		}
+		return msg
 	}
 }
 
-// generateWorktreeName creates a worktree name from project name and feature description
-// Format: [project-name]-[dasherized-feature-name]
-func generateWorktreeName(projectName, description string) string {
-	// Dasherize the description
-	dasherized := strings.ToLower(description)
-	dasherized = strings.ReplaceAll(dasherized, " ", "-")
-	// Remove special characters
-	var result strings.Builder
-	for _, r := range dasherized {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
-			result.WriteRune(r)
-		}
+// handleDeleted reports the deletes handleDeleteWorktree made
+func (m *model) handleDeleted(msg deletedMsg) (tea.Model, tea.Cmd) {
+	m.notifyWarnings(msg.warnings)
+	for _, session := range msg.sessions {
+		m.notify(toastInfo, "Killed tmux session %s", session)
 	}
-	dasherized = result.String()
-
-	// Remove consecutive dashes
-	for strings.Contains(dasherized, "--") {
-		dasherized = strings.ReplaceAll(dasherized, "--", "-")
+	for _, err := range msg.errs {
+		m.notifyError(err)
 	}
-
-	// Trim dashes from start/end
-	dasherized = strings.Trim(dasherized, "-")
-
-	return projectName + "-" + dasherized
-}
-
-func (m *model) handleCreateWorktreeFromGithub(item *github.ProjectItem) (tea.Model, tea.Cmd) {
-	// Generate worktree name from the GitHub item title
-	worktreeName := generateWorktreeName(m.config.Name, item.Title)
-
-	// Create worktree
-	if err := git.CreateWorktree(worktreeName); err != nil {
-		m.err = err
-		return m, nil
+	if msg.state != nil {
+		m.state = msg.state
 	}
 
-	// Update GitHub item status to In Progress
-	if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-		err := github.UpdateProjectItemStatus(
-			m.config.StorageBackend.Owner,
-			m.config.StorageBackend.Repo,
-			m.config.StorageBackend.ProjectNumber,
-			item.ID,
-			"In Progress",
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to update item status: %v\n", err)
-		}
+	switch deleted := len(msg.deleted); {
+	case deleted == 1 && msg.total == 1:
+		m.notify(toastSuccess, "Deleted %s", msg.deleted[0])
+	case deleted > 0:
+		m.notify(toastSuccess, "Deleted %d items", deleted)
 	}
 
-	// Add todo with the GitHub item title and body
-	m.config.AddTodo(item.Title, worktreeName)
-	todo := m.config.GetTodoForWorktree(worktreeName)
-	if todo != nil {
-		todo.GitHubBody = item.Content.Body
-		todo.GitHubURL = item.Content.URL
-	}
-	if err := m.config.Save(); err != nil {
-		m.err = fmt.Errorf("failed to save config: %w", err)
+	// If we deleted the current worktree, exit the TUI
+	// The user will be returned to their shell (in the main repo)
+	if msg.current {
+		return m, tea.Quit
 	}
-
-	// Set as selected and quit to jump to it
-	m.selectedWorktree = worktreeName
-	return m, tea.Quit
+	return m, nil
 }
 
-func (m *model) handleDeleteWorktree() (tea.Model, tea.Cmd) {
+// deleteTargets returns every marked item, or just the selected one
+func (m *model) deleteTargets() []worktreeItem {
+	if targets := m.markedItems(); len(targets) > 0 {
+		return targets
+	}
 	if item, ok := m.list.SelectedItem().(worktreeItem); ok {
-		// Get the name from either the worktree or the todo
-		var name string
-		if item.isCheckedOut {
-			name = git.GetWorktreeName(item.worktree.Path)
-		} else if item.todo != nil {
-			name = item.todo.Worktree
-		} else if item.githubItem != nil {
-			// GitHub item without worktree - nothing to delete from git
-			// Just remove from GitHub project if needed
-			if m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-				err := github.UpdateProjectItemStatus(
-					m.config.StorageBackend.Owner,
-					m.config.StorageBackend.Repo,
-					m.config.StorageBackend.ProjectNumber,
-					item.githubItem.ID,
-					"Done",
-				)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to update item status to Done: %v\n", err)
-				}
-			}
-			m.deleting = false
-			return m, m.refreshWorktrees
-		} else {
-			// No way to identify this item
-			m.deleting = false
-			return m, nil
-		}
-
-		// Check if branch is merged
-		isMerged, err := git.IsBranchMerged(name)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to check if branch is merged: %v\n", err)
-		}
-
-		// Update GitHub item status to Done if merged
-		if isMerged && item.githubItem != nil && m.config.StorageBackend != nil && m.config.StorageBackend.Type == "github" {
-			err := github.UpdateProjectItemStatus(
-				m.config.StorageBackend.Owner,
-				m.config.StorageBackend.Repo,
-				m.config.StorageBackend.ProjectNumber,
-				item.githubItem.ID,
-				"Done",
-			)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to update item status to Done: %v\n", err)
-			}
-		}
-
-		// Check if we're deleting the current worktree
-		currentWorktree, err := git.GetCurrentWorktree()
-		isDeletingCurrent := err == nil && currentWorktree == name
-
-		// Kill tmux session if it exists
-		sessionName := tmux.SanitizeSessionName(name)
-		if tmux.SessionExists(sessionName) {
-			if err := tmux.KillSession(sessionName); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to kill tmux session: %v\n", err)
-			}
-		}
-
-		// Delete worktree
-		if err := git.DeleteWorktree(name, true); err != nil {
-			m.err = err
-			m.deleting = false
-			return m, nil
-		}
-
-		// Remove todo entirely (don't just mark as done)
-		m.config.RemoveTodo(name)
-		if err := m.config.Save(); err != nil {
-			m.err = fmt.Errorf("failed to save config: %w", err)
-		}
-
-		m.deleting = false
-
-		// If we deleted the current worktree, exit the TUI
-		// The user will be returned to their shell (in the main repo)
-		if isDeletingCurrent {
-			return m, tea.Quit
-		}
-
-		return m, m.refreshWorktrees
+		return []worktreeItem{item}
 	}
+	return nil
+}
 
-	m.deleting = false
-	return m, nil
+// target is the worktree, or the item without one, that item stands for in the core service
+func (m *model) target(item worktreeItem) core.Target {
+	target := core.Target{Todo: item.todo, Item: item.githubItem}
+	if item.isCheckedOut {
+		target.Worktree = git.GetWorktreeName(item.worktree.Path)
+		target.Branch = itemBranch(item)
+	}
+	return target
 }
 
 type refreshMsg struct {
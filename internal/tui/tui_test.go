@@ -0,0 +1,704 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/testrepo"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// quietPeriod is how long the harness waits for a command before taking the model as
+// settled. Commands still running, like the state poll's five second tick or the wait for
+// the next event, deliver their messages when the harness next settles, or are stopped
+// with the model when the test ends.
+const quietPeriod = 300 * time.Millisecond
+
+// harness drives the TUI the way the program does, against a real git repository with
+// tmux and gh faked
+type harness struct {
+	t    *testing.T
+	repo *testrepo.Repo
+	m    *model
+	tmux *runner.Fake
+	gh   *runner.Fake
+	quit bool // The model asked to quit
+	// results carries the messages of commands started by any settle
+	results chan tea.Msg
+	// running counts the commands started that haven't returned
+	running sync.WaitGroup
+}
+
+// noServer is tmux's answer when nothing is running
+var noServer = &runner.Error{Command: "tmux", Stderr: "no server running on /tmp/tmux-1000/default", Err: errors.New("exit status 1")}
+
+// newHarness starts the TUI on repo with the config in cfgYAML, once the fakes have been
+// given their responses by setup
+func newHarness(t *testing.T, repo *testrepo.Repo, cfgYAML string, setup func(h *harness)) *harness {
+	t.Helper()
+	h := &harness{t: t, repo: repo, tmux: &runner.Fake{}, gh: &runner.Fake{}, results: make(chan tea.Msg, 16)}
+	if setup != nil {
+		setup(h)
+	}
+	h.tmux.On("tmux", "", noServer)
+	previousTmux := tmux.SetRunner(h.tmux)
+	previousGH := github.SetRunner(h.gh)
+	t.Cleanup(func() {
+		tmux.SetRunner(previousTmux)
+		github.SetRunner(previousGH)
+	})
+	// Cleanups run last first, so the commands still running are done with the fakes before
+	// they're swapped back
+	t.Cleanup(h.stop)
+
+	cfg := repo.Config(cfgYAML)
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		t.Fatalf("ListWorktrees() error = %v", err)
+	}
+	theme, err := cfg.ResolveTheme()
+	if err != nil {
+		t.Fatalf("ResolveTheme() error = %v", err)
+	}
+	found := &startup{worktrees: worktrees, state: &state.State{}, theme: theme, current: git.WorktreeAt(worktrees, repo.Root)}
+	h.m = newModel(cfg, found, nil, nil)
+
+	h.send(tea.WindowSizeMsg{Width: 140, Height: 40})
+	h.run(h.m.Init())
+	return h
+}
+
+// send hands msg to the model and runs the commands it returns until they settle
+func (h *harness) send(msg tea.Msg) {
+	h.t.Helper()
+	h.settle([]tea.Msg{msg})
+}
+
+// run runs cmd as the program would, with its messages going to the model
+func (h *harness) run(cmd tea.Cmd) {
+	h.t.Helper()
+	h.settle([]tea.Msg{tea.BatchMsg{cmd}})
+}
+
+func (h *harness) settle(pending []tea.Msg) {
+	h.t.Helper()
+	start := func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		h.running.Add(1)
+		go func() {
+			defer h.running.Done()
+			msg := cmd()
+			select {
+			case h.results <- msg:
+			case <-h.m.done.Done():
+			}
+		}()
+	}
+
+	for {
+		for len(pending) > 0 {
+			msg := pending[0]
+			pending = pending[1:]
+			switch msg := msg.(type) {
+			case nil:
+			case tea.BatchMsg:
+				for _, cmd := range msg {
+					start(cmd)
+				}
+			case tea.QuitMsg:
+				h.quit = true
+			case spinner.TickMsg:
+				// The spinner ticks for as long as anything loads
+			default:
+				_, cmd := h.m.Update(msg)
+				start(cmd)
+			}
+		}
+
+		select {
+		case msg := <-h.results:
+			pending = append(pending, msg)
+		case <-time.After(quietPeriod):
+			return
+		}
+	}
+}
+
+// stop ends the model's timers and its wait for events, and waits for every command
+// started to return
+func (h *harness) stop() {
+	if h.m == nil {
+		return
+	}
+	h.m.stop()
+	h.running.Wait()
+}
+
+// press sends keys one at a time: names like "enter" and "down", or text to type
+func (h *harness) press(keys ...string) {
+	h.t.Helper()
+	for _, k := range keys {
+		h.send(keyMsg(k))
+	}
+}
+
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "ctrl+u":
+		return tea.KeyMsg{Type: tea.KeyCtrlU}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// selectItem moves the cursor to the item whose title contains title
+func (h *harness) selectItem(title string) {
+	h.t.Helper()
+	for idx, listItem := range h.m.list.Items() {
+		if item, ok := listItem.(worktreeItem); ok && strings.Contains(item.title(), title) {
+			h.m.list.Select(idx)
+			return
+		}
+	}
+	h.t.Fatalf("no item titled %q in the list:\n%s", title, h.m.View())
+}
+
+// expectView fails the test unless the screen shows every one of texts
+func (h *harness) expectView(texts ...string) {
+	h.t.Helper()
+	view := h.m.View()
+	for _, text := range texts {
+		if !strings.Contains(view, text) {
+			h.t.Errorf("view doesn't show %q:\n%s", text, view)
+		}
+	}
+}
+
+// ghCalls returns the gh commands run so far, e.g. "gh issue list --repo owner/repo ..."
+func (h *harness) ghCalls() []string {
+	var calls []string
+	for _, call := range h.gh.Calls() {
+		calls = append(calls, call.String())
+	}
+	return calls
+}
+
+const localConfig = `name: proj
+worktree_naming: proj-
+theme:
+    name: dark
+todos:
+    - description: Add login
+      status: in_progress
+      worktree: proj-login
+`
+
+func TestListShowsWorktrees(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.expectView("proj", "proj-login - Add login", "Branch: main")
+}
+
+func TestOpenWorktree(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("enter")
+
+	if !h.quit || h.m.selectedWorktree != "proj-login" {
+		t.Errorf("after enter quit = %v, selected = %q, want proj-login opened", h.quit, h.m.selectedWorktree)
+	}
+	if h.m.exitToMain {
+		t.Error("opening a linked worktree exits to main")
+	}
+}
+
+func TestCreateWorktree(t *testing.T) {
+	repo := testrepo.New(t)
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.press("n")
+	h.expectView("Create New Worktree")
+	h.press("ctrl+u", "Fix the bug", "enter")
+
+	path := filepath.Join(filepath.Dir(repo.Root), "proj-fix-the-bug")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("worktree wasn't created: %v", err)
+	}
+	if branch := repo.Git("-C", path, "branch", "--show-current"); branch != "proj-fix-the-bug" {
+		t.Errorf("worktree is on %q, want proj-fix-the-bug", branch)
+	}
+	if todo := h.m.config.GetTodoForWorktree("proj-fix-the-bug"); todo == nil || todo.Description != "Fix the bug" {
+		t.Errorf("todo = %+v, want one for the new worktree", todo)
+	}
+	h.expectView("proj-fix-the-bug - Fix the bug", "Created worktree proj-fix-the-bug")
+}
+
+func TestCreateWorktreeNameTaken(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.Git("branch", "proj-fix-the-bug")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.press("n", "ctrl+u", "Fix the bug", "enter")
+	h.expectView("a branch named proj-fix-the-bug already exists", "Create it as proj-fix-the-bug-2 instead?")
+
+	// Turning it down goes back to the form
+	h.press("n")
+	h.expectView("Feature Description")
+	h.press("enter", "y")
+
+	path := filepath.Join(filepath.Dir(repo.Root), "proj-fix-the-bug-2")
+	if branch := repo.Git("-C", path, "branch", "--show-current"); branch != "proj-fix-the-bug-2" {
+		t.Errorf("worktree is on %q, want proj-fix-the-bug-2", branch)
+	}
+	h.expectView("Created worktree proj-fix-the-bug-2")
+}
+
+func TestCreateWorktreeWithBranchType(t *testing.T) {
+	repo := testrepo.New(t)
+	h := newHarness(t, repo, localConfig+"branch_types:\n    - prefix: feat/\n    - prefix: fix/\n", nil)
+
+	// Description, base branch, then the branch type
+	h.press("n", "ctrl+u", "Fix the bug", "tab", "tab", "l", "l")
+	h.expectView("‹ fix/ ›")
+	h.press("enter")
+
+	path := filepath.Join(filepath.Dir(repo.Root), "proj-fix-the-bug")
+	if branch := repo.Git("-C", path, "branch", "--show-current"); branch != "fix/proj-fix-the-bug" {
+		t.Errorf("worktree is on %q, want fix/proj-fix-the-bug", branch)
+	}
+}
+
+func TestDeleteWorktree(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, func(h *harness) {
+		h.tmux.On("tmux has-session -t proj-login", "", nil)
+		h.tmux.On("tmux kill-session -t proj-login", "", nil)
+	})
+
+	h.selectItem("proj-login")
+	h.press("d")
+	h.expectView("Are you sure you want to delete 'proj-login'?")
+	h.press("y")
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after delete: %v", err)
+	}
+	if branches := repo.Git("branch", "--list", "proj-login"); branches != "" {
+		t.Errorf("branch still exists after delete: %q", branches)
+	}
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo != nil {
+		t.Errorf("todo still exists after delete: %+v", todo)
+	}
+	killed := slices.ContainsFunc(h.tmux.Calls(), func(call runner.Call) bool {
+		return call.String() == "tmux kill-session -t proj-login"
+	})
+	if !killed {
+		t.Errorf("the worktree's tmux session wasn't killed, tmux ran %v", h.tmux.Calls())
+	}
+	h.expectView("Deleted proj-login")
+	if view := h.m.View(); strings.Contains(view, "proj-login - Add login") {
+		t.Errorf("the list still shows the deleted worktree:\n%s", view)
+	}
+}
+
+func TestDeleteCancelled(t *testing.T) {
+	repo := testrepo.New(t)
+	path := repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("d", "n")
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("worktree removed after cancelling the delete: %v", err)
+	}
+	h.expectView("proj-login - Add login")
+}
+
+const issuesConfig = `name: proj
+worktree_naming: proj-
+theme:
+    name: dark
+storage_backend:
+    type: github-issues
+    owner: owner
+    repo: repo
+`
+
+const issuesJSON = `[
+	{"id": "I_1", "number": 1, "title": "Crash on start", "state": "OPEN", "url": "https://github.com/owner/repo/issues/1", "labels": []},
+	{"id": "I_2", "number": 2, "title": "Old bug", "state": "CLOSED", "url": "https://github.com/owner/repo/issues/2", "labels": []}
+]`
+
+func TestIssuesBackend(t *testing.T) {
+	repo := testrepo.New(t)
+	h := newHarness(t, repo, issuesConfig, func(h *harness) {
+		h.gh.On("gh issue list --repo owner/repo", issuesJSON, nil)
+		h.gh.On("gh issue edit 1 --repo owner/repo --add-label in-progress", "", nil)
+	})
+
+	h.expectView("Crash on start")
+	if h.m.loading {
+		t.Error("still loading once the issues arrived")
+	}
+
+	h.selectItem("Crash on start")
+	h.press("S")
+
+	if !slices.Contains(h.ghCalls(), "gh issue edit 1 --repo owner/repo --add-label in-progress") {
+		t.Errorf("cycling the status didn't label the issue in progress, gh ran %v", h.ghCalls())
+	}
+}
+
+func TestIssuesBackendFailure(t *testing.T) {
+	repo := testrepo.New(t)
+	h := newHarness(t, repo, issuesConfig, func(h *harness) {
+		h.gh.On("gh issue list", "", &runner.Error{Command: "gh issue list", Stderr: "HTTP 401: Bad credentials", Err: errors.New("exit status 1")})
+	})
+
+	h.expectView("failed to fetch GitHub items", "Bad credentials", "gh auth login", "proj")
+	if h.m.loading {
+		t.Error("still loading after the fetch failed")
+	}
+}
+
+func TestPullRequestReviews(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, issuesConfig, func(h *harness) {
+		h.gh.On("gh issue list --repo owner/repo", issuesJSON, nil)
+		h.gh.On("gh api graphql", `{"data": {"repository": {"pullRequests": {"nodes": [
+			{"headRefName": "proj-login", "reviewDecision": "CHANGES_REQUESTED", "reviewThreads": {"nodes": [{"isResolved": false}, {"isResolved": false}]}}
+		]}}}}`, nil)
+	})
+
+	h.expectView("PR: changes requested, 2 unresolved")
+}
+
+func TestShiftPriority(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("+")
+	h.expectView("Priority: Low")
+	h.press("+", "+", "+")
+	h.expectView("Priority: High")
+	h.press("-")
+	h.expectView("Priority: Medium")
+
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo == nil || todo.Priority != "Medium" {
+		t.Errorf("todo = %+v, want priority Medium saved", todo)
+	}
+}
+
+func TestEditDueDate(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("e", "tab")
+	h.expectView("Due date:")
+	h.press("soon", "enter")
+	h.expectView("due date must be YYYY-MM-DD")
+
+	h.press("ctrl+u", time.Now().AddDate(0, 0, -2).Format(time.DateOnly), "enter")
+	h.expectView("! ○ proj-login - Add login", "Overdue by 2d")
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo == nil || todo.Due == "" {
+		t.Errorf("todo = %+v, want its due date saved", todo)
+	}
+}
+
+func TestChecklist(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig+"      notes: |\n        - [ ] Form\n        - [ ] Session\n", nil)
+
+	h.expectView("Checklist: 0/2")
+	h.selectItem("proj-login")
+	h.press("t", "down", " ")
+	h.expectView("Checklist of proj-login (1/2)", "[x] Session")
+	h.press("esc")
+	h.expectView("Checklist: 1/2")
+
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo == nil || todo.Notes != "- [ ] Form\n- [x] Session\n" {
+		t.Errorf("todo = %+v, want Session checked in its notes", todo)
+	}
+}
+
+func TestTags(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	repo.AddWorktree("proj-api")
+	h := newHarness(t, repo, localConfig+`      tags: [bug]
+    - description: Build API
+      status: pending
+      worktree: proj-api
+`, nil)
+
+	h.expectView("Tags: bug")
+	h.press("#", "down", "enter")
+	h.expectView("tagged bug", "proj-login - Add login")
+	if view := h.m.View(); strings.Contains(view, "proj-api") {
+		t.Errorf("view shows an untagged worktree while filtering by tag:\n%s", view)
+	}
+
+	h.selectItem("proj-login")
+	h.press("e", "tab", "tab", "ctrl+u", "bug, auth, bug", "enter")
+	h.expectView("Tags: bug, auth")
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo == nil || !slices.Equal(todo.Tags, []string{"bug", "auth"}) {
+		t.Errorf("todo = %+v, want tags bug and auth", todo)
+	}
+}
+
+func TestBlockedBy(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	repo.AddWorktree("proj-api")
+	const moveCrash = "gh issue edit 1 --repo owner/repo --add-label in-progress"
+	h := newHarness(t, repo, issuesConfig+`todos:
+    - description: Crash on start
+      status: pending
+      worktree: proj-login
+      github_url: https://github.com/owner/repo/issues/1
+      blocked_by: [proj-api]
+    - description: Build API
+      status: pending
+      worktree: proj-api
+`, func(h *harness) {
+		h.gh.On("gh issue list --repo owner/repo", issuesJSON, nil)
+		h.gh.On(moveCrash, "", nil)
+	})
+
+	h.expectView("⛔ ○ proj-login - Crash on start", "Blocked by proj-api")
+	if slices.Contains(h.ghCalls(), moveCrash) {
+		t.Errorf("a blocked item was moved to In Progress when its worktree was found, gh ran %v", h.ghCalls())
+	}
+
+	h.selectItem("proj-login")
+	h.press("S")
+	h.expectView("proj-login is blocked by proj-api")
+	if slices.Contains(h.ghCalls(), moveCrash) {
+		t.Errorf("a blocked item was moved to In Progress, gh ran %v", h.ghCalls())
+	}
+	h.press("S")
+	if !slices.Contains(h.ghCalls(), moveCrash) {
+		t.Errorf("moving a blocked item again didn't start it, gh ran %v", h.ghCalls())
+	}
+}
+
+func TestAutoStatus(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	const moveCrash = "gh issue edit 1 --repo owner/repo --add-label in-progress"
+	h := newHarness(t, repo, issuesConfig+`    auto_status:
+        from: [In Review]
+todos:
+    - description: Crash on start
+      status: pending
+      worktree: proj-login
+      github_url: https://github.com/owner/repo/issues/1
+`, func(h *harness) {
+		h.gh.On("gh issue list --repo owner/repo", issuesJSON, nil)
+		h.gh.On(moveCrash, "", nil)
+	})
+
+	h.expectView("proj-login - Crash on start")
+	if slices.Contains(h.ghCalls(), moveCrash) {
+		t.Errorf("an item outside auto_status's from was moved to In Progress, gh ran %v", h.ghCalls())
+	}
+}
+
+func TestHighContrastGlyphs(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	repo.AddWorktree("proj-api")
+	h := newHarness(t, repo, strings.Replace(localConfig, "name: dark", "name: high-contrast", 1)+`      blocked_by: [proj-api]
+    - description: Build API
+      status: pending
+      worktree: proj-api
+`, nil)
+
+	h.expectView("[blocked] [ ] proj-login - Add login")
+	h.selectItem("proj-login")
+	h.press(" ")
+	h.expectView("[+] [blocked] [ ] proj-login")
+}
+
+func TestActivityFeed(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-crash")
+	h := newHarness(t, repo, issuesConfig, func(h *harness) {
+		h.gh.On("gh issue list --repo owner/repo", issuesJSON, nil)
+		h.gh.On("gh api /repos/owner/repo/events", `[
+			{"type": "PullRequestEvent", "actor": {"login": "sam"}, "created_at": "2026-10-12T09:00:00Z", "payload": {"action": "closed", "pull_request": {"number": 5, "title": "Fix crash", "merged": true, "head": {"ref": "proj-crash"}}}}
+		]`, nil)
+	})
+
+	h.press("F")
+	h.expectView("Activity", "sam", "merged pull request #5 Fix crash", "[proj-crash]")
+	h.press("esc")
+	h.expectView("LFG - Git Worktrees")
+}
+
+func TestWatchedSession(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, func(h *harness) {
+		h.tmux.On("tmux list-sessions", "proj-login\t2\t0\n", nil)
+		h.tmux.On("tmux list-clients", "proj-login\t0\nproj-login\t1\n", nil)
+	})
+	// As the state poll finds them
+	h.send(stateMsg{sessions: loadSessions()})
+
+	h.selectItem("proj-login")
+	h.expectView("👁 ", "tmux: 2 attached, 1 watching")
+}
+
+// BenchmarkMergeGithubItems merges a 300 item board into 40 worktrees, ten of them linked
+// by the backend and the rest matched by name
+func BenchmarkMergeGithubItems(b *testing.B) {
+	repo := testrepo.New(b)
+	cfg := repo.Config("name: proj\n")
+	var worktrees []git.Worktree
+	for i := 0; i < 40; i++ {
+		worktrees = append(worktrees, git.Worktree{Path: fmt.Sprintf("/code/proj-task-%d", i)})
+	}
+	var items []github.ProjectItem
+	for i := 0; i < 300; i++ {
+		item := github.ProjectItem{ID: fmt.Sprintf("item-%d", i), Title: fmt.Sprintf("Task %d", 300-i), Status: "Todo"}
+		if i < 10 {
+			item.Worktree = fmt.Sprintf("proj-task-%d", i)
+		}
+		items = append(items, item)
+	}
+	m := newModel(cfg, &startup{worktrees: worktrees, state: &state.State{}}, nil, nil)
+
+	for b.Loop() {
+		m.mergeGithubItems(items)
+	}
+}
+
+func TestScratchpad(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("N")
+	path := state.ScratchpadPath(h.m.config.GetConfigPath(), "proj-login")
+	// What the editor would have written
+	if err := os.WriteFile(path, []byte("Redirect loops when the cookie has expired\n"), 0644); err != nil {
+		t.Fatalf("the scratchpad can't be written: %v", err)
+	}
+	h.send(scratchpadEditedMsg{})
+
+	item := h.m.list.SelectedItem().(worktreeItem)
+	if preview := h.m.previewMarkdown(item); !strings.Contains(preview, "### Scratchpad\n\nRedirect loops when the cookie has expired") {
+		t.Errorf("preview doesn't show the scratchpad:\n%s", preview)
+	}
+	// It's local, so the todo's notes are left alone
+	if todo := h.m.config.GetTodoForWorktree("proj-login"); todo == nil || todo.Notes != "" {
+		t.Errorf("todo = %+v, want its notes untouched", todo)
+	}
+}
+
+func TestHandOff(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	h := newHarness(t, repo, localConfig, nil)
+
+	h.selectItem("proj-login")
+	h.press("H")
+	h.expectView("Hand Off proj-login", "proj-add-login")
+	h.press("enter")
+
+	path := filepath.Join(filepath.Dir(repo.Root), "proj-add-login")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("worktree wasn't created: %v", err)
+	}
+	if todo := h.m.config.GetTodoForWorktree("proj-add-login"); todo == nil || todo.Description != "Add login" {
+		t.Errorf("todo = %+v, want proj-login's", todo)
+	}
+	h.expectView("proj-add-login - Add login", "Handed proj-login's todo to proj-add-login")
+
+	// The worktree it came from has nothing left to hand off
+	h.selectItem("proj-login")
+	h.press("H")
+	h.expectView("proj-login has no todo to hand off")
+}
+
+func TestGroupByEpic(t *testing.T) {
+	repo := testrepo.New(t)
+	repo.WriteFile("todo.org", `* TODO Accounts
+** TODO Login form
+** DONE Sessions
+* TODO Dark mode
+`)
+	h := newHarness(t, repo, "name: proj\nstorage_backend:\n    type: org\n", nil)
+
+	for h.m.sortMode != "epic" {
+		h.press("O")
+	}
+	h.press("A") // Sessions is long done
+	h.expectView("▾ Accounts", "1/3 done", "Login form", "Sessions", "Dark mode")
+
+	selectHeader := func() {
+		t.Helper()
+		for idx, row := range h.m.list.Items() {
+			if _, ok := row.(epicHeader); ok {
+				h.m.list.Select(idx)
+				return
+			}
+		}
+		t.Fatalf("no epic header in the list:\n%s", h.m.View())
+	}
+	selectHeader()
+	h.press("enter")
+	h.expectView("▸ Accounts", "folded", "Dark mode")
+	if view := h.m.View(); strings.Contains(view, "Login form") {
+		t.Errorf("a folded epic's items are still shown:\n%s", view)
+	}
+	if !slices.Equal(h.m.state.Collapsed, []string{"title:Accounts"}) {
+		t.Errorf("Collapsed = %v, want the folded epic remembered", h.m.state.Collapsed)
+	}
+
+	h.press("z")
+	h.expectView("▾ Accounts", "Login form")
+	h.selectItem("Dark mode")
+	h.press("z")
+	h.expectView("Dark mode isn't in an epic")
+}
@@ -0,0 +1,105 @@
+package viewer
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/issuecache"
+)
+
+// issueMsg carries the issue's body and comment thread as they are on GitHub
+type issueMsg struct {
+	updatedAt   string
+	body        string
+	comments    []github.IssueComment
+	commentsErr error
+	fresh       bool // The cached copy is current, so nothing more was fetched
+	err         error
+}
+
+// loadCached shows the issue as it was last fetched, so the viewer has it straight away
+// when a session is attached
+func (m *model) loadCached() {
+	entry, err := issuecache.Load(m.configPath, m.backend.Owner, m.backend.Repo, m.issueNumber)
+	if err != nil || entry == nil {
+		return
+	}
+	m.cached = entry
+	m.setBody(entry.Body)
+	m.comments = commentsMarkdown(entry.Comments, nil)
+}
+
+// fetchIssue checks whether the issue has changed since it was cached, fetching the comment
+// thread and caching it again if so. force refetches regardless.
+func (m model) fetchIssue(force bool) tea.Cmd {
+	backend, number, configPath, cached, review := *m.backend, m.issueNumber, m.configPath, m.cached, m.review
+	return func() tea.Msg {
+		issue, err := github.GetIssue(backend.Owner, backend.Repo, number)
+		if err != nil {
+			return issueMsg{err: err}
+		}
+		if !force && cached.Fresh(issue.UpdatedAt) {
+			return issueMsg{fresh: true}
+		}
+
+		comments, err := github.GetIssueComments(backend.Owner, backend.Repo, number)
+		if err == nil && review {
+			var reviewComments []github.IssueComment
+			reviewComments, err = github.GetReviewComments(backend.Owner, backend.Repo, number)
+			// Reviews are read in with the conversation, in the order they were left
+			comments = append(comments, reviewComments...)
+			slices.SortStableFunc(comments, func(a, b github.IssueComment) int {
+				return strings.Compare(a.CreatedAt, b.CreatedAt)
+			})
+		}
+		if err != nil {
+			return issueMsg{updatedAt: issue.UpdatedAt, body: issue.Body, commentsErr: err}
+		}
+		// A failed write only means fetching again next time
+		issuecache.Save(configPath, backend.Owner, backend.Repo, number, issuecache.Entry{
+			UpdatedAt: issue.UpdatedAt,
+			Body:      issue.Body,
+			Comments:  comments,
+		})
+		return issueMsg{updatedAt: issue.UpdatedAt, body: issue.Body, comments: comments}
+	}
+}
+
+func (m *model) handleIssue(msg issueMsg) {
+	if msg.err != nil {
+		m.setStatus("%v", msg.err)
+		if m.cached == nil {
+			m.comments = commentsMarkdown(nil, msg.err)
+			m.render()
+		}
+		return
+	}
+	if msg.fresh {
+		return
+	}
+
+	m.comments = commentsMarkdown(msg.comments, msg.commentsErr)
+	if msg.commentsErr == nil {
+		m.cached = &issuecache.Entry{UpdatedAt: msg.updatedAt, Body: msg.body, Comments: msg.comments}
+	}
+	// Don't undo a checklist change that's on its way to GitHub
+	if !m.saving {
+		m.setBody(msg.body)
+	}
+	// Keep showing what's there if the comments can't be rendered
+	m.render()
+}
+
+// cacheBody records a body the viewer saved to GitHub. Its updated_at isn't known, so the
+// entry is refetched next time.
+func (m *model) cacheBody(body string) {
+	entry := issuecache.Entry{Body: body}
+	if m.cached != nil {
+		entry.Comments = m.cached.Comments
+	}
+	m.cached = &entry
+	issuecache.Save(m.configPath, m.backend.Owner, m.backend.Repo, m.issueNumber, entry)
+}
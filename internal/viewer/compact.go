@@ -0,0 +1,65 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// compactHeight is the pane height below which the viewer shows a summary instead of the
+// markdown, which can't fit. Zooming the pane brings the full view back.
+const compactHeight = 8
+
+// compact reports whether the pane is too short for the full view
+func (m model) compact() bool {
+	return m.height < compactHeight
+}
+
+// viewCompact summarises the task in a line or two, e.g. "proj-x · pending · #12 · feat/login"
+func (m model) viewCompact() string {
+	parts := []string{titleStyle.Render(m.worktreeName)}
+	if m.todo != nil {
+		parts = append(parts, todoGlyph(m.todo.Status).Render()+" "+statusStyle.Render(string(m.todo.Status)))
+	} else {
+		parts = append(parts, i18n.T("no task"))
+	}
+	if m.issueNumber > 0 {
+		parts = append(parts, fmt.Sprintf("#%d", m.issueNumber))
+	}
+	if m.branch != "" {
+		parts = append(parts, m.branch)
+	}
+	if len(m.tasks) > 0 {
+		done := 0
+		for _, task := range m.tasks {
+			if task.Checked {
+				done++
+			}
+		}
+		parts = append(parts, i18n.T("%d/%d tasks", done, len(m.tasks)))
+	}
+	lines := []string{strings.Join(parts, " · ")}
+
+	if m.height > 1 {
+		var details []string
+		if m.todo != nil {
+			details = append(details, m.todo.Description)
+		}
+		if m.monitor != "" {
+			details = append(details, m.monitor)
+		}
+		if m.status != "" {
+			details = append(details, m.status)
+		}
+		lines = append(lines, helpStyle.Render(strings.Join(details, " · ")))
+	}
+
+	line := lipgloss.NewStyle().MaxWidth(m.width)
+	for i := range lines {
+		lines[i] = line.Render(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
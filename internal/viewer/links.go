@@ -0,0 +1,97 @@
+package viewer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/browser"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// linkPattern matches a bare or markdown URL, stopping at whatever usually surrounds one
+var linkPattern = regexp.MustCompile("https?://[^\\s<>()\\[\\]\"'`]+")
+
+// extractLinks returns the URLs in markdown, in order and without repeats
+func extractLinks(markdown string) []string {
+	var links []string
+	seen := map[string]bool{}
+	for _, link := range linkPattern.FindAllString(markdown, -1) {
+		// A sentence's full stop isn't part of the link
+		link = strings.TrimRight(link, ".,;:!?*_")
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// showLinks lists the current tab's links to pick one to open, since selecting text in a
+// tmux pane is awkward
+func (m *model) showLinks() {
+	links := extractLinks(m.markdown())
+	if len(links) == 0 {
+		m.setStatus("No links in this tab")
+		return
+	}
+	m.links = links
+	m.linkSelected = 0
+}
+
+func (m *model) handleLinksKey(msg tea.KeyMsg) tea.Cmd {
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc", "q", "l":
+		m.links = nil
+	case "up", "k":
+		m.linkSelected = (m.linkSelected - 1 + len(m.links)) % len(m.links)
+	case "down", "j":
+		m.linkSelected = (m.linkSelected + 1) % len(m.links)
+	case "enter":
+		m.openLink(m.links[m.linkSelected])
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if index := int(key[0] - '1'); index < len(m.links) {
+			m.openLink(m.links[index])
+		}
+	}
+	return nil
+}
+
+func (m *model) openLink(link string) {
+	m.links = nil
+	if err := browser.Open(link); err != nil {
+		m.setStatus("%v", err)
+		return
+	}
+	m.setStatus("Opened %s", link)
+}
+
+// viewLinks renders the link list in place of the viewport, numbered for opening with one key
+func (m model) viewLinks() string {
+	height := max(1, m.viewport.Height-1)
+	start := 0
+	if m.linkSelected >= height {
+		start = m.linkSelected - height + 1
+	}
+
+	lines := []string{titleStyle.Render(i18n.T("Links (%d)", len(m.links)))}
+	for i := start; i < len(m.links) && i < start+height; i++ {
+		hint := "   "
+		if i < 9 {
+			hint = fmt.Sprintf("%d. ", i+1)
+		}
+		if i == m.linkSelected {
+			lines = append(lines, statusStyle.Render(selectedMarker+hint+m.links[i]))
+		} else {
+			lines = append(lines, "  "+hint+m.links[i])
+		}
+	}
+	for len(lines) < m.viewport.Height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,47 @@
+package viewer
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/editor"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/state"
+)
+
+// scratchpadEditedMsg reports the editor closing on the worktree's scratchpad
+type scratchpadEditedMsg struct {
+	err error
+}
+
+// editScratchpad opens the worktree's scratchpad in the user's editor, in a tmux popup since
+// the pane is usually too small to edit in
+func (m *model) editScratchpad() tea.Cmd {
+	path, err := state.PrepareScratchpad(m.configPath, m.worktreeName)
+	if err != nil {
+		m.setStatus("%v", err)
+		return nil
+	}
+	return tea.ExecProcess(editor.PopupCommand(path), func(err error) tea.Msg {
+		return scratchpadEditedMsg{err: err}
+	})
+}
+
+// handleScratchpadEdited shows what was written once the editor closes
+func (m *model) handleScratchpadEdited(msg scratchpadEditedMsg) {
+	if msg.err != nil {
+		m.setStatus("failed to edit scratchpad: %v", msg.err)
+	}
+	if m.tab == tabDescription {
+		m.render()
+	}
+}
+
+// scratchpadMarkdown is the worktree's scratchpad as a section of the description, "" when
+// it has none
+func (m *model) scratchpadMarkdown() string {
+	scratchpad := state.Scratchpad(m.configPath, m.worktreeName)
+	if scratchpad == "" {
+		return ""
+	}
+	return "### " + i18n.T("Scratchpad") + "\n\n" + scratchpad + "\n\n"
+}
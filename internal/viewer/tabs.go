@@ -0,0 +1,285 @@
+package viewer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/transcript"
+)
+
+// tab is one of the viewer's pages, picked with the number keys
+type tab int
+
+const (
+	tabDescription tab = iota
+	tabGit
+	tabCI
+	tabTranscript
+)
+
+var tabNames = []string{"Description", "Git", "CI", "Transcript"}
+
+// tabMsg carries a tab's markdown once it has been loaded
+type tabMsg struct {
+	tab      tab
+	markdown string
+}
+
+// recentCommitCount is how many commits the git tab lists
+const recentCommitCount = 10
+
+// markdown returns what the current tab shows
+func (m *model) markdown() string {
+	if m.tab == tabDescription {
+		return m.describe() + m.comments
+	}
+	if markdown, ok := m.panes[m.tab]; ok {
+		return markdown
+	}
+	return "_" + i18n.T("Loading...") + "_\n"
+}
+
+// switchTab shows another tab, reloading it so git status and CI are current
+func (m *model) switchTab(t tab) tea.Cmd {
+	if t == m.tab {
+		return nil
+	}
+	m.tab = t
+	m.render()
+	if m.ready {
+		m.viewport.GotoTop()
+	}
+	return m.load(t)
+}
+
+// load returns the command that fetches a tab's content, nil for the description which is
+// loaded up front
+func (m model) load(t tab) tea.Cmd {
+	switch t {
+	case tabGit:
+		return m.loadGit
+	case tabCI:
+		return m.loadChecks
+	case tabTranscript:
+		return m.loadTranscript
+	}
+	return nil
+}
+
+func (m *model) handleTab(msg tabMsg) {
+	m.panes[msg.tab] = msg.markdown
+	if m.tab == msg.tab {
+		m.render()
+	}
+}
+
+// worktree returns the path and branch of the viewer's worktree, and the main worktree's
+// branch it's compared against
+func (m model) worktree() (path, branch, base string, err error) {
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return "", "", "", err
+	}
+	for _, wt := range worktrees {
+		if git.GetWorktreeName(wt.Path) == m.worktreeName {
+			path = wt.Path
+			branch = strings.TrimPrefix(wt.Branch, "refs/heads/")
+		}
+	}
+	if path == "" {
+		return "", "", "", fmt.Errorf("%w: %s", git.ErrWorktreeNotFound, m.worktreeName)
+	}
+	if len(worktrees) > 0 && worktrees[0].Path != path {
+		base = strings.TrimPrefix(worktrees[0].Branch, "refs/heads/")
+	}
+	return path, branch, base, nil
+}
+
+// loadGit describes the worktree's uncommitted changes, what its branch changes since it
+// forked from the main branch, and its recent commits
+func (m model) loadGit() tea.Msg {
+	path, branch, base, err := m.worktree()
+	if err != nil {
+		return tabMsg{tab: tabGit, markdown: "_" + i18n.T("Failed to read the worktree: %v", err) + "_\n"}
+	}
+
+	var content strings.Builder
+	if branch == "" {
+		content.WriteString("## " + i18n.T("Detached HEAD") + "\n\n")
+	} else {
+		content.WriteString("## `" + branch + "`\n\n")
+	}
+	if base != "" {
+		if ahead, behind, err := git.AheadBehind(path, base); err == nil {
+			content.WriteString("**" + i18n.T("%d ahead, %d behind %s", ahead, behind, base) + "**\n\n")
+		}
+	}
+
+	files, err := git.UncommittedFiles(path)
+	switch {
+	case err != nil:
+		content.WriteString("_" + i18n.T("Failed to get the worktree's status: %v", err) + "_\n\n")
+	case len(files) == 0:
+		content.WriteString("### " + i18n.T("Uncommitted changes") + "\n\n" + i18n.T("Clean") + "\n\n")
+	default:
+		content.WriteString(fmt.Sprintf("### %s\n\n```\n%s\n```\n\n", i18n.T("Uncommitted changes (%d)", len(files)), strings.Join(files, "\n")))
+	}
+
+	if base != "" {
+		if stat, err := git.DiffStatFromBase(path, base); err == nil {
+			content.WriteString("### " + i18n.T("Changes vs %s", base) + "\n\n")
+			if len(stat.Files) == 0 {
+				content.WriteString(i18n.T("No changes yet") + "\n\n")
+			} else {
+				files := i18n.T("files")
+				if len(stat.Files) == 1 {
+					files = i18n.T("file")
+				}
+				content.WriteString("**" + i18n.T("%d %s changed, +%d −%d", len(stat.Files), files, stat.Insertions, stat.Deletions) + "**\n\n")
+				for _, file := range stat.Files {
+					if file.Binary {
+						content.WriteString(fmt.Sprintf("- `%s` %s\n", file.Path, i18n.T("binary")))
+					} else {
+						content.WriteString(fmt.Sprintf("- `%s` +%d −%d\n", file.Path, file.Insertions, file.Deletions))
+					}
+				}
+				content.WriteString("\n")
+			}
+		}
+	}
+
+	if commits, err := git.RecentCommits(path, recentCommitCount); err == nil && len(commits) > 0 {
+		content.WriteString("### " + i18n.T("Recent commits") + "\n\n")
+		for _, commit := range commits {
+			hash, subject, _ := strings.Cut(commit, " ")
+			content.WriteString(fmt.Sprintf("- `%s` %s\n", hash, subject))
+		}
+	}
+	return tabMsg{tab: tabGit, markdown: content.String()}
+}
+
+// checkGlyph marks one of gh's check buckets
+func checkGlyph(bucket string) string {
+	switch bucket {
+	case "pass":
+		return markdownGlyph(glyphs.Done)
+	case "fail":
+		return markdownGlyph(glyphs.Failed)
+	case "pending":
+		return markdownGlyph(glyphs.Pending)
+	case "skipping":
+		return markdownGlyph(glyphs.Skipped)
+	case "cancel":
+		return markdownGlyph(glyphs.Cancelled)
+	}
+	return "•"
+}
+
+// loadChecks lists the CI checks on the worktree's open pull request
+func (m model) loadChecks() tea.Msg {
+	backend := m.cfg.StorageBackend
+	if !backend.IsGitHub() {
+		return tabMsg{tab: tabCI, markdown: "_" + i18n.T("CI checks are shown for projects stored on GitHub.") + "_\n"}
+	}
+	_, branch, _, err := m.worktree()
+	if err != nil {
+		return tabMsg{tab: tabCI, markdown: "_" + i18n.T("Failed to read the worktree: %v", err) + "_\n"}
+	}
+	if branch == "" {
+		return tabMsg{tab: tabCI, markdown: "_" + i18n.T("The worktree has no branch to look up a pull request for.") + "_\n"}
+	}
+
+	pr, err := github.FindPullRequestForBranch(backend.Owner, backend.Repo, branch)
+	if err != nil {
+		return tabMsg{tab: tabCI, markdown: fmt.Sprintf("_%v_\n", strings.TrimSpace(err.Error()))}
+	}
+	if pr == nil {
+		return tabMsg{tab: tabCI, markdown: "_" + i18n.T("No open pull request for %s.", "`"+branch+"`") + "_\n"}
+	}
+	checks, err := github.ListPullRequestChecks(backend.Owner, backend.Repo, pr.Number)
+	if err != nil {
+		return tabMsg{tab: tabCI, markdown: fmt.Sprintf("_%v_\n", strings.TrimSpace(err.Error()))}
+	}
+	return tabMsg{tab: tabCI, markdown: checksMarkdown(pr, checks)}
+}
+
+// checksMarkdown renders a pull request's checks, with a count per outcome
+func checksMarkdown(pr *github.PullRequest, checks []github.Check) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("## PR #%d: %s\n\n%s\n\n", pr.Number, pr.Title, pr.URL))
+	if len(checks) == 0 {
+		content.WriteString("_" + i18n.T("No checks reported.") + "_\n")
+		return content.String()
+	}
+
+	counts := map[string]int{}
+	for _, check := range checks {
+		counts[check.Bucket]++
+	}
+	var summary []string
+	for _, bucket := range []string{"fail", "pending", "pass", "skipping", "cancel"} {
+		if counts[bucket] > 0 {
+			summary = append(summary, fmt.Sprintf("%s %d", checkGlyph(bucket), counts[bucket]))
+		}
+	}
+	content.WriteString("**" + strings.Join(summary, "  ") + "**\n\n")
+
+	for _, check := range checks {
+		icon := checkGlyph(check.Bucket)
+		name := check.Name
+		if check.Workflow != "" {
+			name = check.Workflow + " / " + check.Name
+		}
+		content.WriteString(fmt.Sprintf("- %s **%s** %s", icon, name, strings.ReplaceAll(strings.ToLower(check.State), "_", " ")))
+		if check.Link != "" {
+			content.WriteString(" · " + check.Link)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
+
+// loadTranscript shows the worktree's most recent agent transcript
+func (m model) loadTranscript() tea.Msg {
+	paths, err := transcript.List(m.configPath, m.worktreeName)
+	if err != nil {
+		return tabMsg{tab: tabTranscript, markdown: "_" + i18n.T("Failed to list transcripts: %v", err) + "_\n"}
+	}
+	if len(paths) == 0 {
+		return tabMsg{tab: tabTranscript, markdown: "_" + i18n.T("No agent transcripts yet. One is written each time an agent runs headless.") + "_\n"}
+	}
+
+	latest := paths[len(paths)-1]
+	data, err := os.ReadFile(latest)
+	if err != nil {
+		return tabMsg{tab: tabTranscript, markdown: "_" + i18n.T("Failed to read transcript: %v", err) + "_\n"}
+	}
+	header := "_" + i18n.T("%s, the latest of %d", filepath.Base(latest), len(paths)) + "_\n\n---\n\n"
+	if len(paths) == 1 {
+		header = fmt.Sprintf("_%s_\n\n---\n\n", filepath.Base(latest))
+	}
+	return tabMsg{tab: tabTranscript, markdown: header + string(data)}
+}
+
+// tabBar renders the tab names with their keys, the current tab highlighted
+func (m model) tabBar() string {
+	inactive := helpStyle.Padding(0, 1)
+	tabs := make([]string, len(tabNames))
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, i18n.T(name))
+		if tab(i) == m.tab {
+			tabs[i] = titleStyle.Render(label)
+		} else {
+			tabs[i] = inactive.Render(label)
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
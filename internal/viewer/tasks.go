@@ -0,0 +1,184 @@
+package viewer
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/checklist"
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// selectedMarker flags the selected checklist item in the rendered description
+const selectedMarker = "▶ "
+
+// bodyMsg carries the issue's body as it is on GitHub
+type bodyMsg struct {
+	body string
+	err  error
+}
+
+// taskSavedMsg reports a checklist change reaching GitHub
+type taskSavedMsg struct {
+	body string
+	err  error
+}
+
+// setBody shows a new issue body, keeping the selection on the same item where possible
+func (m *model) setBody(body string) {
+	m.body = body
+	if !m.inNotes {
+		m.setTasks(body)
+	}
+}
+
+// setTasks reads the checklist from the markdown holding it
+func (m *model) setTasks(markdown string) {
+	m.tasks = checklist.Parse(markdown)
+	if m.selected >= len(m.tasks) {
+		m.selected = len(m.tasks) - 1
+	}
+}
+
+// canEditTasks reports whether there's a checklist to tick off, on GitHub or in the notes
+func (m model) canEditTasks() bool {
+	return (m.issueNumber > 0 || m.inNotes) && len(m.tasks) > 0
+}
+
+// fetchBody loads the issue's current body, the todo's copy may be out of date
+func (m model) fetchBody() tea.Msg {
+	body, err := github.GetIssueBody(m.backend.Owner, m.backend.Repo, m.issueNumber)
+	return bodyMsg{body: body, err: err}
+}
+
+func (m *model) handleBody(msg bodyMsg) {
+	if msg.err != nil {
+		m.setStatus("%v", msg.err)
+		return
+	}
+	// Don't undo a change that's on its way to GitHub
+	if m.saving {
+		return
+	}
+	m.setBody(msg.body)
+	m.render()
+}
+
+// setStatus shows the outcome of a checklist change on one line
+func (m *model) setStatus(format string, args ...any) {
+	m.status = strings.Join(strings.Fields(i18n.T(format, args...)), " ")
+}
+
+// selectTask moves the selection through the checklist, wrapping around
+func (m *model) selectTask(delta int) {
+	if !m.canEditTasks() {
+		return
+	}
+	switch {
+	case m.selected < 0 && delta > 0:
+		m.selected = 0
+	case m.selected < 0:
+		m.selected = len(m.tasks) - 1
+	default:
+		m.selected = (m.selected + delta + len(m.tasks)) % len(m.tasks)
+	}
+	m.render()
+	m.scrollToSelected()
+}
+
+// scrollToSelected brings the selected item into view if it's off screen
+func (m *model) scrollToSelected() {
+	if !m.ready {
+		return
+	}
+	for idx, line := range strings.Split(m.content, "\n") {
+		if !strings.Contains(line, strings.TrimSpace(selectedMarker)) {
+			continue
+		}
+		if idx < m.viewport.YOffset || idx >= m.viewport.YOffset+m.viewport.Height {
+			m.viewport.SetYOffset(max(0, idx-m.viewport.Height/2))
+		}
+		return
+	}
+}
+
+// toggleTask checks or unchecks the selected item straight away and sends the change to GitHub
+func (m *model) toggleTask() tea.Cmd {
+	if !m.canEditTasks() || m.saving {
+		return nil
+	}
+	if m.selected < 0 {
+		m.selectTask(1)
+		return nil
+	}
+
+	index := m.selected
+	task := m.tasks[index]
+	if m.inNotes {
+		m.toggleNotesTask(index, task)
+		return nil
+	}
+	body, err := checklist.Set(m.body, index, !task.Checked)
+	if err != nil {
+		m.setStatus("%v", err)
+		return nil
+	}
+	m.setBody(body)
+	m.saving = true
+	m.setStatus("Saving...")
+	m.render()
+
+	backend, issueNumber := *m.backend, m.issueNumber
+	return func() tea.Msg {
+		return saveTask(backend, issueNumber, index, task.Text, !task.Checked)
+	}
+}
+
+// toggleNotesTask checks or unchecks an item of the checklist in the todo's notes
+func (m *model) toggleNotesTask(index int, task checklist.Item) {
+	notes, err := core.New(m.cfg).CheckTask(core.Target{Worktree: m.worktreeName, Todo: m.todo}, index, task.Text, !task.Checked)
+	if err != nil {
+		m.setStatus("Not saved: %v", err)
+		return
+	}
+	m.todo.Notes = notes
+	m.setTasks(notes)
+	m.setStatus("Saved")
+	m.render()
+}
+
+// saveTask applies a checklist change to the issue's current body, so edits made on GitHub
+// since the viewer loaded it aren't lost
+func saveTask(backend config.StorageBackend, issueNumber, index int, text string, checked bool) tea.Msg {
+	body, err := github.SetIssueTask(backend.Owner, backend.Repo, issueNumber, index, text, checked)
+	return taskSavedMsg{body: body, err: err}
+}
+
+func (m *model) handleTaskSaved(msg taskSavedMsg) tea.Cmd {
+	m.saving = false
+	if msg.err != nil {
+		// Go back to what GitHub has
+		m.setStatus("Not saved: %v", msg.err)
+		return m.fetchBody
+	}
+
+	m.setStatus("Saved")
+	m.setBody(msg.body)
+	m.render()
+	m.cacheBody(msg.body)
+
+	// Keep the todo's copy of the body current
+	_, err := config.UpdateFile(m.configPath, func(cfg *config.Config) error {
+		if todo := cfg.GetTodoForWorktree(m.worktreeName); todo != nil {
+			todo.GitHubBody = msg.body
+		}
+		return nil
+	})
+	if err != nil {
+		m.setStatus("Saved, but failed to save config: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/i18n"
+)
+
+// unlinkedCommitCount is how many commits are shown for a worktree without a task
+const unlinkedCommitCount = 5
+
+// describeUnlinked is the description for a worktree lfg has no task for, e.g. one created
+// with git directly, so the pane still says what the worktree is
+func (m model) describeUnlinked() string {
+	var content strings.Builder
+	content.WriteString("_" + i18n.T("No task is linked to this worktree.") + "_\n\n")
+
+	path, branch, base, err := m.worktree()
+	if err != nil {
+		content.WriteString(fmt.Sprintf("_%v_\n\n", err))
+	} else {
+		details := []string{"**" + i18n.T("Branch:") + "** " + i18n.T("detached HEAD")}
+		if branch != "" {
+			details[0] = "**" + i18n.T("Branch:") + "** `" + branch + "`"
+		}
+		if base != "" {
+			details = append(details, "**"+i18n.T("Base:")+"** `"+base+"`")
+			if ahead, behind, err := git.AheadBehind(path, base); err == nil {
+				details = append(details, i18n.T("%d ahead, %d behind", ahead, behind))
+			}
+		}
+		content.WriteString(strings.Join(details, " · ") + "\n\n")
+
+		if commits, err := git.RecentCommits(path, unlinkedCommitCount); err == nil && len(commits) > 0 {
+			content.WriteString("### " + i18n.T("Recent commits") + "\n\n")
+			for _, commit := range commits {
+				hash, subject, _ := strings.Cut(commit, " ")
+				content.WriteString(fmt.Sprintf("- `%s` %s\n", hash, subject))
+			}
+			content.WriteString("\n")
+		}
+	}
+
+	content.WriteString(i18n.T("Press **n** to create a task for this worktree.") + "\n")
+	return content.String()
+}
+
+// startCreateTask asks for the description of a new task linked to the worktree
+func (m *model) startCreateTask() tea.Cmd {
+	input := textinput.New()
+	input.Prompt = i18n.T("New task:") + " "
+	input.Placeholder = i18n.T("What is this worktree for?")
+	input.CharLimit = 200
+	input.Width = 60
+	if _, branch, _, err := m.worktree(); err == nil {
+		input.SetValue(branch)
+		input.CursorEnd()
+	}
+	m.creating = &input
+	return input.Focus()
+}
+
+func (m *model) handleCreateTaskKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.creating = nil
+		return nil
+	case "enter":
+		description := strings.TrimSpace(m.creating.Value())
+		if description == "" {
+			return nil
+		}
+		m.creating = nil
+		m.createTask(description)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	*m.creating, cmd = m.creating.Update(msg)
+	return cmd
+}
+
+// createTask adds a todo for the worktree to the config as it is on disk, so changes made
+// elsewhere aren't clobbered
+func (m *model) createTask(description string) {
+	cfg, err := config.UpdateFile(m.configPath, func(cfg *config.Config) error {
+		if cfg.GetTodoForWorktree(m.worktreeName) == nil {
+			cfg.AddTodo(description, m.worktreeName)
+		}
+		return nil
+	})
+	if err != nil {
+		m.setStatus("%v", err)
+		return
+	}
+
+	m.cfg = cfg
+	m.todo = cfg.GetTodoForWorktree(m.worktreeName)
+	m.setStatus("Created a task for %s", m.worktreeName)
+	m.render()
+}
@@ -2,20 +2,170 @@ package viewer
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/markcipolla/lfg/internal/checklist"
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/humanize"
+	"github.com/markcipolla/lfg/internal/i18n"
+	"github.com/markcipolla/lfg/internal/issuecache"
+	"github.com/markcipolla/lfg/internal/state"
 )
 
 type model struct {
-	viewport viewport.Model
-	content  string
-	ready    bool
+	viewport     viewport.Model
+	tab          tab
+	panes        map[tab]string   // Markdown for the tabs other than the description, once loaded
+	links        []string         // Links in the current tab while the link list is open
+	linkSelected int              // Index into links
+	creating     *textinput.Model // Description of a new task for a worktree without one, while it's typed
+	unlinked     string           // Description of a worktree without a task, worked out once
+	cfg          *config.Config
+	todo         *config.Todo
+	body         string           // The issue's body, kept up to date with GitHub while the checklist is used
+	tasks        []checklist.Item // Checklist items in body, or the notes when inNotes
+	inNotes      bool             // The todo has no issue, so its notes hold its checklist
+	selected     int              // Index into tasks, -1 until an item is selected
+	saving       bool             // A checklist change is being sent to GitHub
+	status       string           // Outcome of the last checklist change, shown with the help
+	comments     string           // Markdown for the issue's comment thread, filled in once loaded
+	content      string           // The current tab, rendered
+	ready        bool
+	width        int
+	height       int
+	branch       string // The worktree's branch, shown in the compact view
+	configPath   string
+	worktreeName string
+	monitor      string // Conversation monitor summary, empty when not running
+	backend      *config.StorageBackend
+	issueNumber  int               // 0 when the todo has no GitHub issue
+	review       bool              // The issue is a pull request checked out by lfg review, whose review comments are shown too
+	cached       *issuecache.Entry // The issue as last fetched, nil if it has never been
+}
+
+// statePollInterval is how often the viewer refreshes the monitor status line
+const statePollInterval = 5 * time.Second
+
+type monitorMsg struct {
+	summary string
+}
+
+// pollMonitor reads the worktree's conversation monitor status from local state
+func (m model) pollMonitor() tea.Msg {
+	st, err := state.Load(m.configPath)
+	if err != nil {
+		return monitorMsg{}
+	}
+	if status, ok := st.Monitors[m.worktreeName]; ok && status.Running() {
+		return monitorMsg{summary: status.Summary()}
+	}
+	return monitorMsg{}
+}
+
+// commentsMarkdown renders an issue's comment thread, oldest first
+func commentsMarkdown(comments []github.IssueComment, err error) string {
+	var content strings.Builder
+	content.WriteString("---\n\n")
+	if err != nil {
+		content.WriteString("### " + i18n.T("Comments") + "\n\n")
+		content.WriteString("_" + i18n.T("Failed to load comments: %v", err) + "_\n")
+		return content.String()
+	}
+	if len(comments) == 0 {
+		content.WriteString("### " + i18n.T("Comments") + "\n\n_" + i18n.T("No comments yet.") + "_\n")
+		return content.String()
+	}
+
+	content.WriteString("### " + i18n.T("Comments (%d)", len(comments)) + "\n\n")
+	for _, comment := range comments {
+		when := comment.CreatedAt
+		if created, err := time.Parse(time.RFC3339, comment.CreatedAt); err == nil {
+			when = humanize.Ago(created)
+		}
+		content.WriteString(fmt.Sprintf("**@%s** · %s\n\n", comment.User.Login, when))
+		content.WriteString(strings.TrimSpace(comment.Body) + "\n\n")
+	}
+	return content.String()
+}
+
+// describe builds the markdown for the todo and its issue
+func (m *model) describe() string {
+	var content strings.Builder
+	content.WriteString("# 📋 " + m.worktreeName + "\n\n")
+
+	todo := m.todo
+	if todo == nil {
+		if m.unlinked == "" {
+			m.unlinked = m.describeUnlinked()
+		}
+		content.WriteString(m.unlinked)
+		content.WriteString(m.scratchpadMarkdown())
+		return content.String()
+	}
+
+	content.WriteString("## " + todo.Description + "\n\n")
+
+	// Show GitHub body if available, with the selected checklist item marked
+	if m.body != "" && m.inNotes {
+		content.WriteString(m.body + "\n\n")
+	} else if m.body != "" {
+		content.WriteString(checklist.Highlight(m.body, m.selected, selectedMarker) + "\n\n")
+	}
+
+	content.WriteString("**" + i18n.T("Status:") + "** " + markdownGlyph(todoGlyph(todo.Status)) + " `" + string(todo.Status) + "`\n\n")
+	if checked, total := checklist.Progress(m.tasks); total > 0 {
+		content.WriteString(fmt.Sprintf("**%s** %d/%d\n\n", i18n.T("Checklist:"), checked, total))
+	}
+
+	if todo.Notes != "" {
+		notes := todo.Notes
+		if m.inNotes {
+			notes = checklist.Highlight(notes, m.selected, selectedMarker)
+		}
+		content.WriteString("### " + i18n.T("Notes") + "\n\n" + notes + "\n\n")
+	}
+	content.WriteString(m.scratchpadMarkdown())
+
+	// Add GitHub info if available
+	if backend := m.cfg.StorageBackend; backend.IsGitHub() {
+		content.WriteString("---\n\n")
+		if backend.UsesProject() {
+			content.WriteString("### " + i18n.T("GitHub Project") + "\n\n")
+			content.WriteString(backend.Owner + "/" +
+				backend.Repo +
+				" #" + fmt.Sprintf("%d", backend.ProjectNumber) + "\n\n")
+		} else {
+			content.WriteString("### " + i18n.T("GitHub Issues") + "\n\n")
+			content.WriteString(backend.Owner + "/" + backend.Repo + "\n\n")
+		}
+
+		if todo.GitHubURL != "" {
+			content.WriteString("**" + i18n.T("Issue:") + "** " + todo.GitHubURL + "\n\n")
+		}
+	}
+	return content.String()
+}
+
+// render renders the current tab into the viewport
+func (m *model) render() error {
+	rendered, err := RenderMarkdown(m.markdown(), 80)
+	if err != nil {
+		return err
+	}
+	m.content = rendered
+	if m.ready {
+		m.viewport.SetContent(m.content)
+	}
+	return nil
 }
 
 var (
@@ -31,58 +181,104 @@ var (
 
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241"))
-)
 
-func Run(worktreeName string, cfg *config.Config) error {
-	// Find the todo for this worktree
-	todo := cfg.GetTodoForWorktree(worktreeName)
-
-	// Build markdown content
-	var content strings.Builder
-	content.WriteString("# 📋 " + worktreeName + "\n\n")
+	glyphs = config.DefaultGlyphs()
+)
 
-	if todo != nil {
-		content.WriteString("## " + todo.Description + "\n\n")
+// markdownStyle is the glamour style markdown is rendered with, set by ApplyTheme
+var markdownStyle = "dark"
 
-		// Show GitHub body if available
-		if todo.GitHubBody != "" {
-			content.WriteString(todo.GitHubBody + "\n\n")
-		}
+// ApplyTheme recolours the viewer's styles and picks the matching markdown style
+func ApplyTheme(theme config.Theme) {
+	titleStyle = titleStyle.
+		Foreground(lipgloss.Color(theme.Accent)).
+		Background(lipgloss.Color(theme.Background))
+	statusStyle = statusStyle.Foreground(lipgloss.Color(theme.Highlight))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(theme.Muted))
+	markdownStyle = theme.Markdown
+	glyphs = theme.Glyphs
+}
 
-		content.WriteString("**Status:** `" + string(todo.Status) + "`\n\n")
+// todoGlyph is the glyph for a todo's status
+func todoGlyph(status config.TodoStatus) config.Glyph {
+	if status == config.TodoStatusDone {
+		return glyphs.Done
+	}
+	return glyphs.Todo
+}
 
-		// Add GitHub info if available
-		if cfg.StorageBackend != nil && cfg.StorageBackend.Type == "github" {
-			content.WriteString("---\n\n")
-			content.WriteString("### GitHub Project\n\n")
-			content.WriteString(cfg.StorageBackend.Owner + "/" +
-				cfg.StorageBackend.Repo +
-				" #" + fmt.Sprintf("%d", cfg.StorageBackend.ProjectNumber) + "\n\n")
+// markdownGlyph is a glyph's symbol for markdown, escaped so "[x]" isn't taken for a
+// task list's checkbox. Colors are left to the markdown style.
+func markdownGlyph(g config.Glyph) string {
+	return markdownEscaper.Replace(g.Symbol)
+}
 
-			if todo.GitHubURL != "" {
-				content.WriteString("**Issue:** " + todo.GitHubURL + "\n\n")
-			}
-		}
-	} else {
-		content.WriteString("_No description available._\n\n")
-	}
+var markdownEscaper = strings.NewReplacer("[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`")
 
-	// Render markdown with glamour
+// RenderMarkdown renders markdown for the terminal, wrapped to width
+func RenderMarkdown(markdown string, width int) (string, error) {
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(80),
+		glamour.WithStandardStyle(markdownStyle),
+		glamour.WithWordWrap(width),
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return renderer.Render(markdown)
+}
 
-	rendered, err := renderer.Render(content.String())
+func Run(worktreeName string, cfg *config.Config) error {
+	theme, err := cfg.ResolveTheme()
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the default theme\n", err)
+	}
+	ApplyTheme(theme)
+	if err := cfg.UseLanguage(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
 
+	// Find the todo for this worktree
+	todo := cfg.GetTodoForWorktree(worktreeName)
+
 	m := model{
-		content: rendered,
+		configPath:   cfg.GetConfigPath(),
+		worktreeName: worktreeName,
+		cfg:          cfg,
+		todo:         todo,
+		selected:     -1,
+		panes:        map[tab]string{},
+	}
+	if todo != nil {
+		m.setBody(todo.GitHubBody)
+	}
+
+	// The issue's discussion is shown from the cache, and checked after the viewer opens
+	if todo != nil && cfg.StorageBackend.IsGitHub() {
+		if number, err := github.IssueNumberFromURL(todo.GitHubURL); err == nil {
+			m.backend = cfg.StorageBackend
+			m.issueNumber = number
+		}
+	}
+	// Pull requests being reviewed are shown whatever the backend
+	if st, err := state.Load(m.configPath); err == nil {
+		if review, ok := st.Reviews[worktreeName]; ok {
+			m.backend = &config.StorageBackend{Type: "github-issues", Owner: review.Owner, Repo: review.Repo}
+			m.issueNumber = review.Number
+			m.review = true
+		}
+	}
+	if m.issueNumber > 0 {
+		m.comments = "---\n\n### " + i18n.T("Comments") + "\n\n_" + i18n.T("Loading comments...") + "_\n"
+		m.loadCached()
+	} else if todo != nil {
+		m.inNotes = true
+		m.setTasks(todo.Notes)
+	}
+	if _, branch, _, err := m.worktree(); err == nil {
+		m.branch = branch
+	}
+	if err := m.render(); err != nil {
+		return err
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -91,38 +287,143 @@ func Run(worktreeName string, cfg *config.Config) error {
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	if m.issueNumber > 0 {
+		return tea.Batch(m.pollMonitor, m.fetchIssue(false))
+	}
+	return m.pollMonitor
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.links != nil {
+			return m, m.handleLinksKey(msg)
+		}
+		if m.creating != nil {
+			return m, m.handleCreateTaskKey(msg)
+		}
 		switch msg.String() {
 		case "q", "ctrl+c", "esc":
 			return m, tea.Quit
+		case "l":
+			m.showLinks()
+			return m, nil
+		case "n":
+			if m.todo == nil && m.tab == tabDescription {
+				return m, m.startCreateTask()
+			}
+		case "N":
+			return m, m.editScratchpad()
+		case "1", "2", "3", "4":
+			return m, m.switchTab(tab(msg.String()[0] - '1'))
+		case "r":
+			if m.tab != tabDescription {
+				return m, m.load(m.tab)
+			}
+			if m.issueNumber > 0 {
+				return m, m.fetchIssue(true)
+			}
 		}
 
+		// The checklist is ticked off from the description
+		if m.tab == tabDescription {
+			switch msg.String() {
+			case "tab":
+				m.selectTask(1)
+				return m, nil
+			case "shift+tab":
+				m.selectTask(-1)
+				return m, nil
+			case " ", "x":
+				// Space would otherwise page down
+				return m, m.toggleTask()
+			}
+		}
+
+	case bodyMsg:
+		m.handleBody(msg)
+		return m, nil
+
+	case tabMsg:
+		m.handleTab(msg)
+		return m, nil
+
+	case taskSavedMsg:
+		return m, m.handleTaskSaved(msg)
+
+	case issueMsg:
+		m.handleIssue(msg)
+		return m, nil
+
+	case scratchpadEditedMsg:
+		m.handleScratchpadEdited(msg)
+		return m, nil
+
+	case monitorMsg:
+		m.monitor = msg.summary
+		return m, tea.Tick(statePollInterval, func(time.Time) tea.Msg {
+			return m.pollMonitor()
+		})
+
 	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-2)
+			m.viewport = viewport.New(msg.Width, msg.Height-3)
 			m.viewport.SetContent(m.content)
 			m.ready = true
 		} else {
 			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - 2
+			m.viewport.Height = msg.Height - 3
 		}
 	}
 
+	var cmds []tea.Cmd
+	if m.creating != nil {
+		// Keeps the cursor blinking
+		var cmd tea.Cmd
+		*m.creating, cmd = m.creating.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 	var cmd tea.Cmd
 	m.viewport, cmd = m.viewport.Update(msg)
-	return m, cmd
+	return m, tea.Batch(append(cmds, cmd)...)
 }
 
 func (m model) View() string {
 	if !m.ready {
-		return "\n  Loading..."
+		return "\n  " + i18n.T("Loading...")
+	}
+	if m.compact() {
+		return m.viewCompact()
 	}
 
-	help := helpStyle.Render("↑/↓: scroll • q: close")
-	return fmt.Sprintf("%s\n%s", m.viewport.View(), help)
+	if m.links != nil {
+		help := helpStyle.Render(i18n.T("↑/↓: select • enter or 1-9: open • esc: back"))
+		return fmt.Sprintf("%s\n%s\n%s", m.tabBar(), m.viewLinks(), help)
+	}
+
+	if m.creating != nil {
+		help := m.creating.View() + "  " + helpStyle.Render(i18n.T("enter: create • esc: cancel"))
+		return fmt.Sprintf("%s\n%s\n%s", m.tabBar(), m.viewport.View(), help)
+	}
+
+	keys := []string{i18n.T("1-4: tabs"), i18n.T("↑/↓: scroll"), i18n.T("l: links"), i18n.T("N: scratchpad")}
+	if m.todo == nil && m.tab == tabDescription {
+		keys = append(keys, i18n.T("n: new task"))
+	}
+	if m.tab == tabDescription && m.canEditTasks() {
+		keys = append(keys, i18n.T("tab: next task"), i18n.T("space: check"))
+	}
+	if m.tab != tabDescription || m.issueNumber > 0 {
+		keys = append(keys, i18n.T("r: reload"))
+	}
+	keys = append(keys, i18n.T("q: close"))
+	help := helpStyle.Render(strings.Join(keys, " • "))
+	if m.status != "" {
+		help += "  " + statusStyle.Render(m.status)
+	}
+	if m.monitor != "" {
+		help += "  " + statusStyle.Render(m.monitor)
+	}
+	return fmt.Sprintf("%s\n%s\n%s", m.tabBar(), m.viewport.View(), help)
 }
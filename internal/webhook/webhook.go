@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+// client posts to webhooks, giving up on ones that don't answer
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Event is what a webhook's template is given
+type Event struct {
+	Kind     string    // One of the config's Webhook events
+	Project  string    // The repository's name in the config
+	Worktree string    // The worktree it happened in, if any
+	Title    string    // The item's or todo's title, if any
+	URL      string    // The item's issue or pull request, if any
+	Status   string    // With status_changed, the status moved to
+	From     string    // With status_changed, the status moved from
+	Text     string    // The event as a line for a chat channel
+	Time     time.Time // When it happened
+}
+
+// Templates for the body posted when a webhook has none of its own
+const (
+	slackTemplate   = `{"text": {{json .Text}}}`
+	discordTemplate = `{"content": {{json .Text}}}`
+)
+
+var funcs = template.FuncMap{
+	// json quotes a value for the JSON body, e.g. {"text": {{json .Title}}}
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// pending tracks posts still on their way, and failed collects what went wrong with them,
+// so lfg can wait for them before it exits
+var (
+	pending sync.WaitGroup
+	mu      sync.Mutex
+	failed  []error
+)
+
+// Send posts the event to each of the config's webhooks that wants it, in the background.
+// Event's Project and Time are filled in when they're empty.
+func Send(cfg *config.Config, event Event) {
+	if cfg == nil {
+		return
+	}
+	if event.Project == "" {
+		event.Project = cfg.Name
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, hook := range cfg.Webhooks {
+		if !hook.Sends(event.Kind, event.Status) {
+			continue
+		}
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			if err := post(hook, event); err != nil {
+				mu.Lock()
+				failed = append(failed, err)
+				mu.Unlock()
+			}
+		}()
+	}
+}
+
+// Wait waits for the posts still on their way, returning what went wrong with any posted
+// since it was last called
+func Wait() error {
+	pending.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	err := errors.Join(failed...)
+	failed = nil
+	return err
+}
+
+// post sends the event to a webhook
+func post(hook config.Webhook, event Event) error {
+	body, err := render(hook, event)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(hook.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The error quotes the URL, secret and all
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to webhook %s: %w", redact(hook.URL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s answered %s", redact(hook.URL), resp.Status)
+	}
+	return nil
+}
+
+// render fills in the webhook's template, or the chat message template for its service
+func render(hook config.Webhook, event Event) ([]byte, error) {
+	text := hook.Template
+	if text == "" {
+		text = slackTemplate
+		if strings.Contains(hook.URL, "discord.com/") || strings.Contains(hook.URL, "discordapp.com/") {
+			text = discordTemplate
+		}
+	}
+	tmpl, err := template.New("webhook").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, event); err != nil {
+		return nil, fmt.Errorf("failed to fill in webhook template: %w", err)
+	}
+	return body.Bytes(), nil
+}
+
+// redact drops a webhook URL's path, which is its secret, for messages
+func redact(address string) string {
+	scheme, rest, ok := strings.Cut(address, "://")
+	if !ok {
+		return "URL"
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host
+}
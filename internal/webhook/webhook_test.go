@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/config"
+)
+
+func TestRender(t *testing.T) {
+	event := Event{Kind: config.WebhookStatusChanged, Title: `Fix "login"`, Status: "Done", Text: `proj: "Fix login" moved to Done`}
+	tests := []struct {
+		name     string
+		hook     config.Webhook
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "slack",
+			hook:     config.Webhook{URL: "https://hooks.slack.com/services/T0/B0/secret"},
+			expected: `{"text": "proj: \"Fix login\" moved to Done"}`,
+		},
+		{
+			name:     "discord",
+			hook:     config.Webhook{URL: "https://discord.com/api/webhooks/1/secret"},
+			expected: `{"content": "proj: \"Fix login\" moved to Done"}`,
+		},
+		{
+			name:     "template",
+			hook:     config.Webhook{URL: "https://example.com/hook", Template: `{"title": {{json .Title}}, "status": "{{.Status}}"}`},
+			expected: `{"title": "Fix \"login\"", "status": "Done"}`,
+		},
+		{
+			name:    "unknown field",
+			hook:    config.Webhook{URL: "https://example.com/hook", Template: `{{.Missing}}`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := render(tt.hook, event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(body) != tt.expected {
+				t.Errorf("render() = %s, want %s", body, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/broken/secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Name: "proj", Webhooks: []config.Webhook{
+		{URL: server.URL + "/created", Events: []string{config.WebhookWorktreeCreated}},
+		{URL: server.URL + "/broken/secret"},
+	}}
+	Send(cfg, Event{Kind: config.WebhookWorktreeCreated, Worktree: "proj-x", Text: "proj: created worktree proj-x"})
+	Send(cfg, Event{Kind: config.WebhookStatusChanged, Status: "Todo", Text: "not sent"})
+
+	err := Wait()
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Wait() error = %v, want the broken webhook's 404", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Wait() error = %v, want the webhook's path left out", err)
+	}
+	close(bodies)
+	var got []string
+	for body := range bodies {
+		got = append(got, body)
+	}
+	if len(got) != 1 || got[0] != `{"text": "proj: created worktree proj-x"}` {
+		t.Errorf("posted %q, want only the worktree_created message", got)
+	}
+	if err := Wait(); err != nil {
+		t.Errorf("second Wait() error = %v, want nil", err)
+	}
+}
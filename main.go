@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -9,16 +11,47 @@ import (
 
 	"github.com/markcipolla/lfg/internal/agent"
 	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
 	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/history"
+	"github.com/markcipolla/lfg/internal/remedy"
+	"github.com/markcipolla/lfg/internal/rpc"
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/state"
+	"github.com/markcipolla/lfg/internal/tmux"
 	"github.com/markcipolla/lfg/internal/tui"
 	"github.com/markcipolla/lfg/internal/viewer"
+	"github.com/markcipolla/lfg/internal/webhook"
+	"github.com/markcipolla/lfg/pkg/lfg"
 )
 
 func main() {
 	viewMode := flag.Bool("view", false, "View description for a worktree")
 	agentMode := flag.Bool("agent", false, "Run agent wrapper for a worktree")
+	rpcMode := flag.Bool("rpc", false, "Answer JSON requests from an editor on stdin, one per line")
 	configPath := flag.String("config", "", "Path to config file (for viewer/agent mode)")
+	environment := flag.String("env", "", "Environment from the config to give the worktree jumped to, none for none")
 	flag.Parse()
+	history.Via = programName()
+
+	// The viewer and agent run in panes, which are already on the host
+	if !*viewMode && !*agentMode {
+		useRemote()
+	}
+
+	// Subcommands take precedence over worktree names
+	if flag.NArg() > 0 {
+		if command, ok := commands[flag.Arg(0)]; ok {
+			history.Via = programName() + " " + flag.Arg(0)
+			err := command(flag.Args()[1:])
+			waitForWebhooks()
+			if err != nil {
+				fail("Error", err)
+			}
+			return
+		}
+	}
 
 	// Check if worktree name was provided
 	worktree := ""
@@ -43,13 +76,11 @@ func main() {
 		}
 
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-			os.Exit(1)
+			fail("Error loading config", err)
 		}
 
 		if err := viewer.Run(worktree, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error running viewer: %v\n", err)
-			os.Exit(1)
+			fail("Error running viewer", err)
 		}
 		return
 	}
@@ -71,14 +102,28 @@ func main() {
 		}
 
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-			os.Exit(1)
+			fail("Error loading config", err)
 		}
 
 		// Run the agent wrapper
-		if err := agent.Run(worktree, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error running agent: %v\n", err)
-			os.Exit(1)
+		err = agent.Run(worktree, cfg)
+		waitForWebhooks()
+		if err != nil {
+			fail("Error running agent", err)
+		}
+		return
+	}
+
+	// RPC mode: serve an editor plugin's picker over stdio
+	if *rpcMode {
+		repo, err := lfg.Open()
+		if err != nil {
+			fail("Error loading config", err)
+		}
+		err = rpc.Serve(context.Background(), repo, os.Stdin, os.Stdout)
+		waitForWebhooks()
+		if err != nil {
+			fail("Error serving RPC", err)
 		}
 		return
 	}
@@ -91,71 +136,77 @@ func main() {
 		lfgPath, err := exec.LookPath("lfg")
 		if err != nil {
 			lfgPath = "lfg"
-		}
-
-		// Get the main repo root (where we want to run lfg from)
-		// Try to get the main worktree root by listing all worktrees
-		cmd := exec.Command("git", "worktree", "list", "--porcelain")
-		output, err := cmd.Output()
-		var repoRootStr string
-		if err == nil {
-			// Parse the output to get the first worktree path (main worktree)
-			lines := strings.Split(string(output), "\n")
-			for _, line := range lines {
-				if strings.HasPrefix(line, "worktree ") {
-					repoRootStr = strings.TrimPrefix(line, "worktree ")
-					break
-				}
+			if self, err := os.Executable(); err == nil {
+				lfgPath = self // Installed as gh lfg
 			}
 		}
-		if repoRootStr == "" {
-			// Fallback to rev-parse if worktree list fails
-			cmd = exec.Command("git", "rev-parse", "--show-toplevel")
-			repoRoot, err := cmd.Output()
-			if err == nil && len(repoRoot) > 0 {
-				repoRootStr = string(repoRoot[:len(repoRoot)-1]) // trim newline
-			} else {
-				// If not in a git repo, just use current dir
-				repoRootStr, _ = os.Getwd()
-			}
+
+		// Run it from the main worktree. Outside a repository it's run from here, and asks in
+		// the popup which repository to open.
+		repoRootStr, err := config.RepoRoot()
+		if err != nil {
+			repoRootStr, _ = os.Getwd()
 		}
 
 		// Use tmux display-popup to show lfg in a fullscreen popup
 		// When they exit the popup, they're back in the current pane
 		popupCmd := fmt.Sprintf("cd '%s' && LFG_POPUP=1 %s", repoRootStr, lfgPath)
-		cmd = exec.Command("tmux", "display-popup", "-E", "-w", "100%", "-h", "100%", popupCmd)
-		cmd.Run() // Ignore errors
+		tmux.Popup(popupCmd) // Ignore errors
 
 		os.Exit(0)
 	}
 
+	// Outside a repository there's nothing to list, so offer one to open
+	if !config.InRepo() {
+		if worktree != "" || !interactive() {
+			fail("Error", config.ErrNotInRepo)
+		}
+		if !openRepo() {
+			return
+		}
+	}
+
 	// Load config (creates default if missing)
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		fail("Error loading config", err)
+	}
+
+	// "lfg -" jumps back to the previously used worktree, like "cd -"
+	if worktree == "-" {
+		worktree, err = previousWorktree(cfg)
+		if err != nil {
+			fail("Error", err)
+		}
 	}
 
 	// If worktree specified, jump directly to it
 	if worktree != "" {
+		if *environment != "" {
+			if err := pickEnvironment(cfg, worktree, *environment); err != nil {
+				fail("Error", err)
+			}
+		}
+		recordWorktreeUse(cfg, worktree)
+		offerWIPRestore(worktree)
 		if err := git.JumpToWorktree(worktree, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error jumping to worktree: %v\n", err)
-			os.Exit(1)
+			fail("Error jumping to worktree", err)
 		}
 		return
 	}
 
 	// Otherwise, show TUI
 	result, err := tui.Run(cfg)
+	waitForWebhooks()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-		os.Exit(1)
+		fail("Error running TUI", err)
 	}
 
 	// Handle the result
 	if result != nil && result.SelectedWorktree != "" {
 		// If user wants to exit to main, handle specially
 		if result.ExitToMain {
+			recordWorktreeUse(cfg, result.SelectedWorktree)
 			// Get the main worktree path
 			worktrees, err := git.ListWorktrees()
 			if err == nil && len(worktrees) > 0 {
@@ -164,17 +215,13 @@ func main() {
 				// If we're in a tmux session, send commands to cd and detach
 				if os.Getenv("TMUX") != "" {
 					// Get current session name
-					sessionName := ""
-					cmd := exec.Command("tmux", "display-message", "-p", "#{session_name}")
-					if output, err := cmd.Output(); err == nil {
-						sessionName = strings.TrimSpace(string(output))
-					}
-
+					sessionName, _ := tmux.CurrentSession()
 					if sessionName != "" {
+						saveSessionWIP(cfg, sessionName)
 						// Send command to cd to main path and then kill the session
 						// This will happen after the popup closes
 						cdCmd := fmt.Sprintf("cd '%s' && tmux kill-session", mainPath)
-						exec.Command("tmux", "send-keys", "-t", sessionName, cdCmd, "Enter").Run()
+						tmux.TypeLine(sessionName, cdCmd)
 					}
 				} else {
 					// Not in tmux, just cd
@@ -185,9 +232,175 @@ func main() {
 		}
 
 		// Otherwise, jump to the selected worktree
+		recordWorktreeUse(cfg, result.SelectedWorktree)
+		offerWIPRestore(result.SelectedWorktree)
 		if err := git.JumpToWorktree(result.SelectedWorktree, cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error jumping to worktree: %v\n", err)
-			os.Exit(1)
+			fail("Error jumping to worktree", err)
+		}
+	}
+}
+
+// programName is what lfg is run as: gh lfg when it's installed as gh's extension
+func programName() string {
+	if github.Extension() {
+		return "gh lfg"
+	}
+	return "lfg"
+}
+
+// useRemote runs git and tmux on the repository's remote host over SSH, when its config
+// has one. Without a config there's nothing to say it's remote.
+func useRemote() {
+	cfg, err := config.LoadExisting()
+	if err != nil {
+		return
+	}
+	remote := cfg.RemoteHost()
+	if remote == nil {
+		return
+	}
+	// ssh is run the way each package ran its commands, within its timeouts
+	overSSH := func(local runner.Runner) runner.Runner {
+		return runner.SSH{Host: remote.Host, Dir: remote.Path, Options: remote.Options, Local: local}
+	}
+	git.SetRunner(overSSH(git.SetRunner(nil)))
+	tmux.SetRunner(overSSH(tmux.SetRunner(nil)))
+}
+
+// fail reports err after prefix, with what to do about it when lfg knows, and exits with
+// the status for it
+func fail(prefix string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", prefix, remedy.Message(err))
+	os.Exit(remedy.Exit(err))
+}
+
+// interactive reports whether someone is at the terminal to answer questions
+func interactive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// openRepo asks which repository to run in when lfg is started outside one, and moves there.
+// It reports false when there's none to run in.
+func openRepo() bool {
+	dir, err := os.Getwd()
+	if err != nil {
+		fail("Error", err)
+	}
+	repo, err := config.ChooseRepo(os.Stdin, os.Stdout, dir)
+	if err != nil {
+		fail("Error", err)
+	}
+	if repo == "" {
+		return false
+	}
+	if err := os.Chdir(repo); err != nil {
+		fail("Error", err)
+	}
+	// Its config may put it on a remote host, which wasn't known before
+	useRemote()
+	return true
+}
+
+// pickEnvironment records the environment picked for a worktree with --env, which its
+// session is switched to as it's attached
+func pickEnvironment(cfg *config.Config, worktree, environment string) error {
+	if environment == "none" {
+		environment = ""
+	}
+	if _, err := cfg.EnvironmentVars(environment); err != nil {
+		return err
+	}
+	return cfg.Update(func(cfg *config.Config) error {
+		todo := cfg.GetTodoForWorktree(worktree)
+		if todo == nil {
+			return fmt.Errorf("%s has no todo to record its environment on", worktree)
+		}
+		todo.Environment = environment
+		return nil
+	})
+}
+
+// waitForWebhooks lets webhook posts still on their way finish before lfg exits
+func waitForWebhooks() {
+	if err := webhook.Wait(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// recordWorktreeUse remembers a worktree as the most recently used, for "lfg -" and the TUI's shortcuts
+func recordWorktreeUse(cfg *config.Config, name string) {
+	_, err := state.Update(cfg.GetConfigPath(), func(st *state.State) error {
+		st.RecordUse(name)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save state: %v\n", err)
+	}
+}
+
+// saveSessionWIP saves the uncommitted work of the worktree whose session is about to be
+// killed, when the config's wip asks for it
+func saveSessionWIP(cfg *config.Config, sessionName string) {
+	if cfg.WIP == "" {
+		return
+	}
+	worktrees, _ := git.ListWorktrees()
+	for _, wt := range worktrees {
+		if name := git.GetWorktreeName(wt.Path); tmux.SanitizeSessionName(name) == sessionName {
+			if _, err := core.New(cfg).SaveWIP(name); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
+// offerWIPRestore asks whether to put back the work lfg saved in a worktree when its session
+// was killed, if there is any and someone is at the terminal to ask
+func offerWIPRestore(name string) {
+	if !interactive() {
+		return
+	}
+	path, err := git.GetWorktreePath(name)
+	if err != nil {
+		return
+	}
+	wip, err := git.FindWIP(path)
+	if err != nil || wip == nil {
+		return
+	}
+
+	saved := "a wip: commit"
+	if wip.Stash != "" {
+		saved = "a stash (" + wip.Stash + ")"
+	}
+	fmt.Printf("%s has uncommitted work lfg saved in %s. Put it back as uncommitted changes? [Y/n] ", name, saved)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+		return
+	}
+	if err := git.RestoreWIP(path, wip); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// previousWorktree returns the most recently used worktree other than the current one
+func previousWorktree(cfg *config.Config) (string, error) {
+	st, err := state.Load(cfg.GetConfigPath())
+	if err != nil {
+		return "", err
+	}
+
+	current, _ := git.GetCurrentWorktree()
+	for _, name := range st.Recent {
+		if name == current {
+			continue
+		}
+		// Skip worktrees deleted since they were used
+		if _, err := git.GetWorktreePath(name); err == nil {
+			return name, nil
 		}
 	}
+	return "", fmt.Errorf("no previously used worktree")
 }
@@ -0,0 +1,356 @@
+// Package lfg manages a repository's worktrees, their tmux sessions and the tasks tracked
+// for them the way the lfg binary does, for tools that would rather not shell out to it.
+//
+// A Repo works on the repository the working directory is in, like the binary, and uses
+// the backend set up in its lfg-config.yaml. It isn't safe for concurrent use.
+package lfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/markcipolla/lfg/internal/config"
+	"github.com/markcipolla/lfg/internal/core"
+	"github.com/markcipolla/lfg/internal/git"
+	"github.com/markcipolla/lfg/internal/github"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+// Repo is lfg in one repository
+type Repo struct {
+	config  *config.Config
+	service *core.Service
+}
+
+// Worktree is one of the repository's git worktrees
+type Worktree struct {
+	Name    string
+	Path    string
+	Branch  string   // "" when the worktree's HEAD is detached
+	Main    bool     // The repository's main worktree, rather than one added to it
+	Task    *Task    // What it's for, nil if it has no task
+	Session *Session // Its running tmux session, nil if there isn't one or tmux can't be asked
+}
+
+// Task is the todo lfg keeps in the config for a worktree
+type Task struct {
+	Description string
+	Status      string // "pending" or "done"
+	Notes       string
+	URL         string // The issue it's tracked by, if any
+}
+
+// Session is a running tmux session
+type Session struct {
+	Name     string
+	Attached int // The number of clients attached to it
+	Watching int // How many of them are attached read-only
+}
+
+// Item is a task in the configured backend: a project board item, an issue, an org
+// headline or a plugin tracker's item
+type Item struct {
+	ID        string
+	Number    int // The issue number, 0 for items without one
+	Title     string
+	Status    string
+	Priority  string   // The board's Priority option, for boards with one
+	Due       string   // The board's due date as YYYY-MM-DD, for boards with one
+	Labels    []string // The issue's labels, for items that are issues
+	Body      string
+	URL       string
+	Worktree  string // The worktree the backend links it to, for backends that record one
+	UpdatedAt time.Time
+
+	source *github.ProjectItem // What the backend returned, needed to change it
+}
+
+// CreateOptions describe a worktree to create
+type CreateOptions struct {
+	Name        string // The worktree's directory name, required
+	Branch      string // Defaults to Name
+	Base        string // What the branch starts from, defaults to HEAD
+	Description string // Its task's description, defaults to Name
+	Environment string // The entry in the config's environments its panes and commands get, if any
+	// The backend item it's for, if any, linked and moved to In Progress. It must have
+	// come from Items.
+	Item *Item
+}
+
+// EventKind is what happened in an Event
+type EventKind string
+
+const (
+	WorktreeCreated  EventKind = EventKind(core.WorktreeCreated)
+	WorktreeDeleted  EventKind = EventKind(core.WorktreeDeleted)
+	SessionKilled    EventKind = EventKind(core.SessionKilled)
+	StatusChanged    EventKind = EventKind(core.StatusChanged)
+	ItemCreated      EventKind = EventKind(core.ItemCreated)
+	ItemRenamed      EventKind = EventKind(core.ItemRenamed)
+	PriorityChanged  EventKind = EventKind(core.PriorityChanged)
+	DueChanged       EventKind = EventKind(core.DueChanged)
+	ChecklistChanged EventKind = EventKind(core.ChecklistChanged)
+	TagsChanged      EventKind = EventKind(core.TagsChanged)
+	BlockersChanged  EventKind = EventKind(core.BlockersChanged)
+)
+
+// Event is a change made through a Repo
+type Event struct {
+	Kind     EventKind
+	Worktree string // The worktree it happened to, if any
+	Item     *Item  // The backend item it happened to, if any
+	Status   string // With StatusChanged, the status moved to
+	From     string // With StatusChanged, the status moved from
+	Time     time.Time
+}
+
+// ErrWorktreeNotFound is returned for a worktree name the repository has no worktree by
+var ErrWorktreeNotFound = git.ErrWorktreeNotFound
+
+// errNotFromItems is returned for an Item that wasn't returned by the Repo
+var errNotFromItems = errors.New("the item didn't come from Items or CreateItem")
+
+// Open returns the repository the working directory is in. It fails if lfg hasn't been
+// set up there, rather than asking the questions the binary would.
+func Open() (*Repo, error) {
+	cfg, err := config.LoadExisting()
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{config: cfg, service: core.New(cfg)}, nil
+}
+
+// Name is the project name lfg names worktrees and sessions after
+func (r *Repo) Name() string {
+	return r.config.Name
+}
+
+// Subscribe calls handle with every change made through the Repo from now on, until cancel
+// is called. handle runs on the goroutine that made the change.
+func (r *Repo) Subscribe(handle func(Event)) (cancel func()) {
+	return r.service.Subscribe(func(event core.Event) {
+		converted := Event{
+			Kind:     EventKind(event.Kind),
+			Worktree: event.Worktree,
+			Status:   event.Status,
+			From:     event.From,
+			Time:     event.Time,
+		}
+		if event.Item != nil {
+			converted.Item = fromProjectItem(event.Item)
+		}
+		handle(converted)
+	})
+}
+
+// Worktrees lists the repository's worktrees with their tasks and tmux sessions, the main
+// worktree first
+func (r *Repo) Worktrees(ctx context.Context) ([]Worktree, error) {
+	worktrees, err := git.ListWorktreesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Worktrees are still worth listing without tmux
+	sessions := make(map[string]Session)
+	if running, err := r.Sessions(ctx); err == nil {
+		for _, session := range running {
+			sessions[session.Name] = session
+		}
+	}
+
+	result := make([]Worktree, 0, len(worktrees))
+	for i, wt := range worktrees {
+		name := git.GetWorktreeName(wt.Path)
+		worktree := Worktree{
+			Name:   name,
+			Path:   wt.Path,
+			Branch: strings.TrimPrefix(wt.Branch, "refs/heads/"),
+			Main:   i == 0,
+		}
+		if todo := r.config.GetTodoForWorktree(name); todo != nil {
+			worktree.Task = &Task{Description: todo.Description, Status: string(todo.Status), Notes: todo.Notes, URL: todo.GitHubURL}
+		}
+		if session, ok := sessions[tmux.SanitizeSessionName(name)]; ok {
+			worktree.Session = &session
+		}
+		result = append(result, worktree)
+	}
+	return result, nil
+}
+
+// Sessions lists the running tmux sessions, for worktrees or otherwise
+func (r *Repo) Sessions(ctx context.Context) ([]Session, error) {
+	infos, err := tmux.ListSessionInfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(infos))
+	for _, info := range infos {
+		sessions = append(sessions, Session{Name: info.Name, Attached: info.Attached, Watching: info.Watching})
+	}
+	return sessions, nil
+}
+
+// CreateWorktree adds a worktree on a new branch with a task for it, stopping git if ctx
+// is cancelled. Problems with its item don't undo the worktree, they're returned as warnings.
+func (r *Repo) CreateWorktree(ctx context.Context, opts CreateOptions) (warnings []string, err error) {
+	if opts.Name == "" {
+		return nil, errors.New("a worktree name is required")
+	}
+	var item *github.ProjectItem
+	if opts.Item != nil {
+		if item = opts.Item.source; item == nil {
+			return nil, errNotFromItems
+		}
+	}
+	description := opts.Description
+	if description == "" {
+		description = opts.Name
+	}
+
+	warnings, err = r.service.CreateWorktree(ctx, core.CreateRequest{Name: opts.Name, Branch: opts.Branch, Base: opts.Base, Environment: opts.Environment, Item: item})
+	if err != nil {
+		return nil, err
+	}
+	return warnings, r.service.TrackWorktree(opts.Name, description, "", opts.Environment, item)
+}
+
+// DeleteWorktree removes a worktree with its branch, tmux session and task. item, when
+// it's given, is moved to Done if the branch was merged. Parts of the cleanup that failed
+// without stopping the delete are returned as warnings.
+func (r *Repo) DeleteWorktree(ctx context.Context, name string, item *Item) (warnings []string, err error) {
+	worktree, err := r.worktree(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	target := core.Target{Worktree: name, Branch: worktree.Branch, Todo: r.config.GetTodoForWorktree(name)}
+	if item != nil {
+		if target.Item = item.source; target.Item == nil {
+			return nil, errNotFromItems
+		}
+	}
+	deleted, err := r.service.DeleteWorktree(target)
+	return deleted.Warnings, err
+}
+
+// worktree finds one of the repository's worktrees by name
+func (r *Repo) worktree(ctx context.Context, name string) (*git.Worktree, error) {
+	worktrees, err := git.ListWorktreesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, wt := range worktrees {
+		if git.GetWorktreeName(wt.Path) == name {
+			wt.Branch = strings.TrimPrefix(wt.Branch, "refs/heads/")
+			return &wt, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWorktreeNotFound, name)
+}
+
+// OpenSession starts a worktree's tmux session if it isn't running, or brings it up to
+// date with the config if it is, without attaching to it. It returns the session's name.
+func (r *Repo) OpenSession(ctx context.Context, name string) (string, error) {
+	worktree, err := r.worktree(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return tmux.OpenSession(name, worktree.Path, r.config)
+}
+
+// KillSession ends a worktree's tmux session
+func (r *Repo) KillSession(worktree string) error {
+	return r.service.KillSession(worktree)
+}
+
+// SetTaskStatus moves a worktree's task to status. Tasks are only pending or done, so any
+// status other than "done" or "Done" reopens one.
+func (r *Repo) SetTaskStatus(worktree, status string) error {
+	todo := r.config.GetTodoForWorktree(worktree)
+	if todo == nil {
+		return fmt.Errorf("worktree '%s' has no task", worktree)
+	}
+	return r.service.SetStatus(core.Target{Worktree: worktree, Todo: todo}, status)
+}
+
+// Items fetches every item from the configured backend, or none if it tracks tasks in the
+// config alone
+func (r *Repo) Items() ([]Item, error) {
+	items, _, err := r.service.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Item, 0, len(items))
+	for i := range items {
+		result = append(result, *fromProjectItem(&items[i]))
+	}
+	return result, nil
+}
+
+// Statuses lists the statuses the backend's items move between. They're the backend's
+// own once Items has fetched them.
+func (r *Repo) Statuses() []string {
+	return r.service.StatusOptions()
+}
+
+// CreateItem adds an item titled title to the backend
+func (r *Repo) CreateItem(title string) (*Item, error) {
+	if !r.config.StorageBackend.Remote() {
+		return nil, errors.New("no storage backend is configured")
+	}
+	item, err := r.service.CreateItem(title)
+	if err != nil {
+		return nil, err
+	}
+	return fromProjectItem(item), nil
+}
+
+// SetItemStatus moves an item to status on the backend
+func (r *Repo) SetItemStatus(item *Item, status string) error {
+	if item.source == nil {
+		return errNotFromItems
+	}
+	if err := r.service.SetItemStatus(item.source, status); err != nil {
+		return err
+	}
+	item.source.Status = status
+	item.Status = status
+	return nil
+}
+
+// RenameItem renames an item on the backend
+func (r *Repo) RenameItem(item *Item, title string) error {
+	if item.source == nil {
+		return errNotFromItems
+	}
+	if err := r.service.RenameItem(item.source, title); err != nil {
+		return err
+	}
+	item.source.Title = title
+	item.Title = title
+	return nil
+}
+
+func fromProjectItem(item *github.ProjectItem) *Item {
+	body := item.Content.Body
+	if body == "" {
+		body = item.Body
+	}
+	return &Item{
+		ID:        item.ID,
+		Number:    item.Content.Number,
+		Title:     item.Title,
+		Status:    item.Status,
+		Priority:  item.Priority,
+		Due:       item.Due,
+		Labels:    item.Labels,
+		Body:      body,
+		URL:       item.Content.URL,
+		Worktree:  item.Worktree,
+		UpdatedAt: item.UpdatedAt,
+		source:    item,
+	}
+}
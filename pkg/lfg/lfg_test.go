@@ -0,0 +1,133 @@
+package lfg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/markcipolla/lfg/internal/runner"
+	"github.com/markcipolla/lfg/internal/testrepo"
+	"github.com/markcipolla/lfg/internal/tmux"
+)
+
+const localConfig = `name: proj
+todos:
+    - description: Add login
+      status: pending
+      worktree: proj-login
+`
+
+// open returns the Repo for a fresh repository with proj-login added, and tmux faked with
+// one session running for it
+func open(t *testing.T) *Repo {
+	t.Helper()
+	repo := testrepo.New(t)
+	repo.AddWorktree("proj-login")
+	repo.Config(localConfig)
+
+	fake := &runner.Fake{}
+	fake.On("tmux list-sessions", "proj-login\t1\n", nil)
+	fake.On("tmux has-session -t proj-login", "", nil)
+	fake.On("tmux", "", &runner.Error{Command: "tmux", Stderr: "no server running", Err: errors.New("exit status 1")})
+	previous := tmux.SetRunner(fake)
+	t.Cleanup(func() { tmux.SetRunner(previous) })
+
+	r, err := Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return r
+}
+
+func TestOpenWithoutConfig(t *testing.T) {
+	testrepo.New(t)
+	if _, err := Open(); err == nil {
+		t.Error("Open() succeeded in a repository lfg hasn't been set up in")
+	}
+}
+
+func TestWorktrees(t *testing.T) {
+	r := open(t)
+	worktrees, err := r.Worktrees(context.Background())
+	if err != nil {
+		t.Fatalf("Worktrees() error = %v", err)
+	}
+	if len(worktrees) != 2 {
+		t.Fatalf("Worktrees() = %+v, want proj and proj-login", worktrees)
+	}
+
+	main, login := worktrees[0], worktrees[1]
+	if main.Name != "proj" || !main.Main || main.Branch != "main" || main.Task != nil {
+		t.Errorf("main worktree = %+v", main)
+	}
+	if login.Name != "proj-login" || login.Main || login.Branch != "proj-login" {
+		t.Errorf("linked worktree = %+v", login)
+	}
+	if login.Task == nil || login.Task.Description != "Add login" || login.Task.Status != "pending" {
+		t.Errorf("linked worktree's task = %+v, want Add login", login.Task)
+	}
+	if login.Session == nil || login.Session.Attached != 1 {
+		t.Errorf("linked worktree's session = %+v, want one with a client attached", login.Session)
+	}
+}
+
+func TestCreateAndDeleteWorktree(t *testing.T) {
+	r := open(t)
+	var events []EventKind
+	r.Subscribe(func(event Event) { events = append(events, event.Kind) })
+	ctx := context.Background()
+
+	if _, err := r.CreateWorktree(ctx, CreateOptions{Name: "proj-signup", Description: "Add signup"}); err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if err := r.SetTaskStatus("proj-signup", "done"); err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	worktrees, _ := r.Worktrees(ctx)
+	if len(worktrees) != 3 || worktrees[2].Task == nil || worktrees[2].Task.Status != "done" {
+		t.Fatalf("Worktrees() after create = %+v, want proj-signup done", worktrees)
+	}
+
+	if _, err := r.DeleteWorktree(ctx, "proj-signup", nil); err != nil {
+		t.Fatalf("DeleteWorktree() error = %v", err)
+	}
+	if worktrees, _ := r.Worktrees(ctx); len(worktrees) != 2 {
+		t.Errorf("Worktrees() after delete = %+v, want proj-signup gone", worktrees)
+	}
+
+	if want := []EventKind{WorktreeCreated, StatusChanged, WorktreeDeleted}; !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	r := open(t)
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"create without a name", func() error {
+			_, err := r.CreateWorktree(context.Background(), CreateOptions{})
+			return err
+		}},
+		{"delete a missing worktree", func() error {
+			_, err := r.DeleteWorktree(context.Background(), "proj-nope", nil)
+			return err
+		}},
+		{"status of a worktree without a task", func() error { return r.SetTaskStatus("proj", "done") }},
+		{"item made by the caller", func() error { return r.SetItemStatus(&Item{ID: "1"}, "Done") }},
+		{"create an item without a backend", func() error {
+			_, err := r.CreateItem("Add signup")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err == nil {
+				t.Error("succeeded, want an error")
+			}
+		})
+	}
+}